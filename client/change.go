@@ -24,6 +24,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -134,6 +137,23 @@ const (
 type ChangesOptions struct {
 	SnapName string // if empty, no filtering by name is done
 	Selector ChangeSelector
+
+	// Kinds and Statuses, if not empty, select changes with one of the
+	// given kinds and statuses.
+	Kinds    []string
+	Statuses []string
+	// SpawnedAfter and SpawnedBefore, if set, select changes spawned in
+	// the given time range.
+	SpawnedAfter  time.Time
+	SpawnedBefore time.Time
+	// Data selects changes with data, or a task with data, matching all
+	// of its values. Its keys are dotted paths into the data, for
+	// example "snap-setup.side-info.revision".
+	Data map[string]string
+
+	// AfterID and Limit paginate the changes, ordered by ID.
+	AfterID string
+	Limit   int
 }
 
 func (client *Client) Changes(opts *ChangesOptions) ([]*Change, error) {
@@ -145,6 +165,32 @@ func (client *Client) Changes(opts *ChangesOptions) ([]*Change, error) {
 		if opts.SnapName != "" {
 			query.Set("for", opts.SnapName)
 		}
+		if len(opts.Kinds) > 0 {
+			query.Set("kind", strings.Join(opts.Kinds, ","))
+		}
+		if len(opts.Statuses) > 0 {
+			query.Set("status", strings.Join(opts.Statuses, ","))
+		}
+		if !opts.SpawnedAfter.IsZero() {
+			query.Set("spawned-after", opts.SpawnedAfter.Format(time.RFC3339))
+		}
+		if !opts.SpawnedBefore.IsZero() {
+			query.Set("spawned-before", opts.SpawnedBefore.Format(time.RFC3339))
+		}
+		paths := make([]string, 0, len(opts.Data))
+		for path := range opts.Data {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			query.Add("data", path+"="+opts.Data[path])
+		}
+		if opts.AfterID != "" {
+			query.Set("after-id", opts.AfterID)
+		}
+		if opts.Limit > 0 {
+			query.Set("limit", strconv.Itoa(opts.Limit))
+		}
 	}
 
 	var chgds []changeAndData
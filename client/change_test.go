@@ -21,6 +21,7 @@ package client_test
 
 import (
 	"io"
+	"net/url"
 	"time"
 
 	"gopkg.in/check.v1"
@@ -179,6 +180,36 @@ func (cs *clientSuite) TestClientChanges(c *check.C) {
 
 }
 
+func (cs *clientSuite) TestClientChangesQuery(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": []}`
+
+	_, err := cs.cli.Changes(&client.ChangesOptions{
+		Selector:      client.ChangesAll,
+		Kinds:         []string{"install", "refresh"},
+		Statuses:      []string{"Error"},
+		SpawnedAfter:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		SpawnedBefore: time.Date(2026, 2, 2, 3, 4, 5, 0, time.UTC),
+		Data: map[string]string{
+			"snap-setup.side-info.revision": "5",
+			"snap-setup.side-info.name":     "foo",
+		},
+		AfterID: "10",
+		Limit:   20,
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/changes")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"select":         {"all"},
+		"kind":           {"install,refresh"},
+		"status":         {"Error"},
+		"spawned-after":  {"2026-01-02T03:04:05Z"},
+		"spawned-before": {"2026-02-02T03:04:05Z"},
+		"data":           {"snap-setup.side-info.name=foo", "snap-setup.side-info.revision=5"},
+		"after-id":       {"10"},
+		"limit":          {"20"},
+	})
+}
+
 func (cs *clientSuite) TestClientChangesData(c *check.C) {
 	cs.rsp = `{"type": "sync", "result": [{
   "id":   "uno",
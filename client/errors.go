@@ -161,6 +161,9 @@ const (
 	// ErrorKindInterfacesRequestsRuleConflict: a rule with conflicting path pattern and permissions already exists.
 	ErrorKindInterfacesRequestsRuleConflict ErrorKind = "interfaces-requests-rule-conflict"
 
+	// ErrorKindInterfacesRequestsRuleAdminOwned: the rule was provisioned by an administrator and cannot be modified or removed by the user.
+	ErrorKindInterfacesRequestsRuleAdminOwned ErrorKind = "interfaces-requests-rule-admin-owned"
+
 	// ErrorKindInterfacesRequestsRuleConflict: cannot find a snap-resource-pair when attempting to sideload a component
 	ErrorKindMissingSnapResourcePair ErrorKind = "missing-snap-resource-pair"
 )
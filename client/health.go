@@ -0,0 +1,62 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SystemHealth is a summary of the health of the snaps on the system.
+type SystemHealth struct {
+	// Status is the most worrying status reported by any of the snaps,
+	// "okay" if none of them reports being unhealthy.
+	Status string              `json:"status"`
+	Snaps  []*SnapHealthReport `json:"snaps"`
+}
+
+// SnapHealthReport is the health of a snap, and its last transitions from
+// one status to another, oldest first.
+type SnapHealthReport struct {
+	Name string `json:"name"`
+	SnapHealth
+	History []*SnapHealth `json:"history,omitempty"`
+}
+
+// HealthOptions contains options for querying snapd for the health of snaps.
+// supported options:
+// - Snaps: only consider the health of the given snaps.
+type HealthOptions struct {
+	Snaps []string
+}
+
+// Health returns the health of the snaps that reported it.
+func (client *Client) Health(opts *HealthOptions) (*SystemHealth, error) {
+	q := make(url.Values)
+	if opts != nil && len(opts.Snaps) > 0 {
+		q.Set("snaps", strings.Join(opts.Snaps, ","))
+	}
+
+	var health SystemHealth
+	if _, err := client.doSync("GET", "/v2/health", q, nil, nil, &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
@@ -0,0 +1,90 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap"
+)
+
+func (cs *clientSuite) TestHealth(c *check.C) {
+	t1 := time.Date(2026, 9, 19, 12, 41, 18, 0, time.UTC)
+	t2 := time.Date(2026, 9, 19, 12, 44, 19, 0, time.UTC)
+	cs.rsp = `{
+		"result": {
+			"status": "error",
+			"snaps": [
+				{
+					"name": "foo",
+					"revision": "7",
+					"timestamp": "2026-09-19T12:44:19Z",
+					"status": "error",
+					"message": "database is gone",
+					"code": "no-db",
+					"history": [
+						{"revision": "7", "timestamp": "2026-09-19T12:41:18Z", "status": "okay"},
+						{"revision": "7", "timestamp": "2026-09-19T12:44:19Z", "status": "error", "message": "database is gone", "code": "no-db"}
+					]
+				}
+			]
+		},
+		"status": "OK",
+		"status-code": 200,
+		"type": "sync"
+	}`
+
+	health, err := cs.cli.Health(&client.HealthOptions{Snaps: []string{"foo", "bar"}})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/health")
+	c.Check(cs.req.URL.Query().Get("snaps"), check.Equals, "foo,bar")
+
+	errHealth := client.SnapHealth{
+		Revision:  snap.R(7),
+		Timestamp: t2,
+		Status:    "error",
+		Message:   "database is gone",
+		Code:      "no-db",
+	}
+	c.Check(health, check.DeepEquals, &client.SystemHealth{
+		Status: "error",
+		Snaps: []*client.SnapHealthReport{{
+			Name:       "foo",
+			SnapHealth: errHealth,
+			History: []*client.SnapHealth{
+				{Revision: snap.R(7), Timestamp: t1, Status: "okay"},
+				&errHealth,
+			},
+		}},
+	})
+}
+
+func (cs *clientSuite) TestHealthNoOptions(c *check.C) {
+	cs.rsp = `{"result": {"status": "okay", "snaps": []}, "status": "OK", "status-code": 200, "type": "sync"}`
+
+	health, err := cs.cli.Health(nil)
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.RawQuery, check.Equals, "")
+	c.Check(health, check.DeepEquals, &client.SystemHealth{Status: "okay", Snaps: []*client.SnapHealthReport{}})
+}
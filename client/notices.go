@@ -15,8 +15,16 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type NotifyOptions struct {
@@ -61,4 +69,177 @@ type NoticeType string
 const (
 	// SnapRunInhibitNotice is recorded when "snap run" is inhibited due refresh.
 	SnapRunInhibitNotice NoticeType = "snap-run-inhibit"
+
+	// SnapInstalledNotice is recorded when a snap is installed.
+	SnapInstalledNotice NoticeType = "snap-installed"
+
+	// SnapRemovedNotice is recorded when a snap is removed.
+	SnapRemovedNotice NoticeType = "snap-removed"
+
+	// SnapRefreshedNotice is recorded when the current revision of a snap
+	// changes.
+	SnapRefreshedNotice NoticeType = "snap-refreshed"
+
+	// InterfaceConnectedNotice is recorded when a plug is connected to a
+	// slot.
+	InterfaceConnectedNotice NoticeType = "interface-connected"
+
+	// InterfaceDisconnectedNotice is recorded when a plug is disconnected
+	// from a slot.
+	InterfaceDisconnectedNotice NoticeType = "interface-disconnected"
+
+	// SnapHealthNotice is recorded when the health status of a snap
+	// changes.
+	SnapHealthNotice NoticeType = "snap-health"
+
+	// InterfacesRequestsPromptNotice is recorded when a prompt for a request
+	// from a snap is added, re-requested, or resolved, with "resolved" set in
+	// the last data of the notice in the latter case.
+	InterfacesRequestsPromptNotice NoticeType = "interfaces-requests-prompt"
 )
+
+// Notice holds details of an occurrence of a notice recorded by snapd.
+type Notice struct {
+	ID            string            `json:"id"`
+	UserID        *uint32           `json:"user-id"`
+	Type          NoticeType        `json:"type"`
+	Key           string            `json:"key"`
+	FirstOccurred time.Time         `json:"first-occurred"`
+	LastOccurred  time.Time         `json:"last-occurred"`
+	LastRepeated  time.Time         `json:"last-repeated"`
+	Occurrences   int               `json:"occurrences"`
+	LastData      map[string]string `json:"last-data,omitempty"`
+	RepeatAfter   time.Duration     `json:"-"`
+	ExpireAfter   time.Duration     `json:"-"`
+}
+
+func (n *Notice) UnmarshalJSON(data []byte) error {
+	type plainNotice Notice
+	var jn struct {
+		*plainNotice
+		RepeatAfter string `json:"repeat-after"`
+		ExpireAfter string `json:"expire-after"`
+	}
+	jn.plainNotice = (*plainNotice)(n)
+	if err := json.Unmarshal(data, &jn); err != nil {
+		return err
+	}
+	var err error
+	if jn.RepeatAfter != "" {
+		if n.RepeatAfter, err = time.ParseDuration(jn.RepeatAfter); err != nil {
+			return fmt.Errorf("invalid repeat-after duration: %w", err)
+		}
+	}
+	if jn.ExpireAfter != "" {
+		if n.ExpireAfter, err = time.ParseDuration(jn.ExpireAfter); err != nil {
+			return fmt.Errorf("invalid expire-after duration: %w", err)
+		}
+	}
+	return nil
+}
+
+// NoticesOptions selects the notices to get.
+type NoticesOptions struct {
+	// Types, if not empty, selects notices of one of these types.
+	Types []NoticeType
+	// Keys, if not empty, selects notices with one of these keys.
+	Keys []string
+	// After, if set, selects notices that last repeated after this time.
+	After time.Time
+	// UserID, if set, selects the notices of the given user and public
+	// notices instead of the ones of the requesting user. Only for admins.
+	UserID *uint32
+	// AllUsers selects the notices of all users. Only for admins.
+	AllUsers bool
+}
+
+func (opts *NoticesOptions) query() url.Values {
+	query := make(url.Values)
+	if opts == nil {
+		return query
+	}
+	if len(opts.Types) > 0 {
+		types := make([]string, len(opts.Types))
+		for i, t := range opts.Types {
+			types[i] = string(t)
+		}
+		query.Set("types", strings.Join(types, ","))
+	}
+	if len(opts.Keys) > 0 {
+		query.Set("keys", strings.Join(opts.Keys, ","))
+	}
+	if !opts.After.IsZero() {
+		query.Set("after", opts.After.Format(time.RFC3339Nano))
+	}
+	if opts.UserID != nil {
+		query.Set("user-id", strconv.FormatUint(uint64(*opts.UserID), 10))
+	}
+	if opts.AllUsers {
+		query.Set("users", "all")
+	}
+	return query
+}
+
+// StreamNotices calls f with each notice matching the options, first the
+// ones already recorded and then the new ones as snapd records them. It
+// returns once the context is done, f returns an error, or the stream is
+// interrupted, for example because snapd restarted; the stream can then be
+// resumed by setting After to the LastRepeated time of the last notice.
+func (client *Client) StreamNotices(ctx context.Context, opts *NoticesOptions, f func(*Notice) error) error {
+	headers := map[string]string{"Accept": "text/event-stream"}
+	rsp, err := client.raw(ctx, "GET", "/v2/notices", opts.query(), headers, nil)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != 200 {
+		var r response
+		if err := decodeInto(rsp.Body, &r); err != nil {
+			return err
+		}
+		return r.err(client, rsp.StatusCode)
+	}
+	if contentType := rsp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		return fmt.Errorf("cannot stream notices: unexpected content type %q", contentType)
+	}
+
+	// server-sent events are made of "field: value" lines, and end
+	// with an empty line; lines starting with ":" are comments
+	var event, data string
+	br := bufio.NewReader(rsp.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("cannot stream notices: %w", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if event == "notice" {
+				var notice Notice
+				if err := json.Unmarshal([]byte(strings.TrimSuffix(data, "\n")), &notice); err != nil {
+					return fmt.Errorf("cannot decode notice: %w", err)
+				}
+				if err := f(&notice); err != nil {
+					return err
+				}
+			}
+			event, data = "", ""
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data += value + "\n"
+		}
+	}
+}
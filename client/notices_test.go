@@ -20,8 +20,13 @@
 package client_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/snapcore/snapd/client"
 	. "gopkg.in/check.v1"
@@ -48,3 +53,107 @@ func (cs *clientSuite) TestNotify(c *C) {
 		"key":    "snap-name",
 	})
 }
+
+func (cs *clientSuite) TestStreamNotices(c *C) {
+	cs.header = http.Header{"Content-Type": {"text/event-stream"}}
+	cs.rsp = `: keep-alive
+
+id: 2026-01-01T10:00:00Z
+event: notice
+data: {"id":"1","user-id":null,"type":"change-update","key":"12","first-occurred":"2026-01-01T09:00:00Z","last-occurred":"2026-01-01T10:00:00Z","last-repeated":"2026-01-01T10:00:00Z","occurrences":2,"last-data":{"kind":"install-snap"},"expire-after":"168h0m0s"}
+
+event: other
+data: {}
+
+id: 2026-01-01T11:00:00Z
+event: notice
+data: {"id":"2","user-id":1000,"type":"warning","key":"danger",
+data: "first-occurred":"2026-01-01T11:00:00Z","last-occurred":"2026-01-01T11:00:00Z","last-repeated":"2026-01-01T11:00:00Z","occurrences":1,"repeat-after":"24h0m0s"}
+
+`
+	uid := uint32(1000)
+	var notices []*client.Notice
+	err := cs.cli.StreamNotices(context.Background(), &client.NoticesOptions{
+		Types:  []client.NoticeType{"change-update", "warning"},
+		Keys:   []string{"12", "danger"},
+		After:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		UserID: &uid,
+	}, func(n *client.Notice) error {
+		notices = append(notices, n)
+		return nil
+	})
+	// the stream ended before the context was done
+	c.Check(err, ErrorMatches, "cannot stream notices: unexpected EOF")
+
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/notices")
+	c.Check(cs.req.Header.Get("Accept"), Equals, "text/event-stream")
+	c.Check(cs.req.URL.Query(), DeepEquals, url.Values{
+		"types":   {"change-update,warning"},
+		"keys":    {"12,danger"},
+		"after":   {"2026-01-01T00:00:00Z"},
+		"user-id": {"1000"},
+	})
+
+	c.Assert(notices, HasLen, 2)
+	c.Check(notices[0], DeepEquals, &client.Notice{
+		ID:            "1",
+		Type:          "change-update",
+		Key:           "12",
+		FirstOccurred: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC),
+		LastOccurred:  time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+		LastRepeated:  time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+		Occurrences:   2,
+		LastData:      map[string]string{"kind": "install-snap"},
+		ExpireAfter:   168 * time.Hour,
+	})
+	c.Check(notices[1], DeepEquals, &client.Notice{
+		ID:            "2",
+		UserID:        &uid,
+		Type:          "warning",
+		Key:           "danger",
+		FirstOccurred: time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC),
+		LastOccurred:  time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC),
+		LastRepeated:  time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC),
+		Occurrences:   1,
+		RepeatAfter:   24 * time.Hour,
+	})
+}
+
+func (cs *clientSuite) TestStreamNoticesAllUsersStop(c *C) {
+	cs.header = http.Header{"Content-Type": {"text/event-stream"}}
+	cs.rsp = `event: notice
+data: {"id":"1","type":"warning","key":"foo"}
+
+event: notice
+data: {"id":"2","type":"warning","key":"bar"}
+
+`
+	var keys []string
+	err := cs.cli.StreamNotices(context.Background(), &client.NoticesOptions{AllUsers: true}, func(n *client.Notice) error {
+		keys = append(keys, n.Key)
+		return errors.New("enough")
+	})
+	c.Check(err, ErrorMatches, "enough")
+	c.Check(keys, DeepEquals, []string{"foo"})
+	c.Check(cs.req.URL.Query(), DeepEquals, url.Values{"users": {"all"}})
+}
+
+func (cs *clientSuite) TestStreamNoticesError(c *C) {
+	cs.status = 400
+	cs.rsp = `{"type": "error", "status-code": 400, "result": {"message": "cannot use a timeout when streaming notices"}}`
+	err := cs.cli.StreamNotices(context.Background(), nil, func(n *client.Notice) error {
+		c.Fatal("unexpected notice")
+		return nil
+	})
+	c.Check(err, ErrorMatches, "cannot use a timeout when streaming notices")
+}
+
+func (cs *clientSuite) TestStreamNoticesNotAStream(c *C) {
+	cs.rsp = `{"type": "sync", "result": []}`
+	err := cs.cli.StreamNotices(context.Background(), nil, func(n *client.Notice) error {
+		c.Fatal("unexpected notice")
+		return nil
+	})
+	c.Check(err, ErrorMatches, `cannot stream notices: unexpected content type ""`)
+}
@@ -0,0 +1,126 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Prompt holds the details of a request from a snap to access a resource,
+// which the user is prompted to allow or deny.
+type Prompt struct {
+	ID          string            `json:"id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Snap        string            `json:"snap"`
+	Interface   string            `json:"interface"`
+	Constraints PromptConstraints `json:"constraints"`
+}
+
+// PromptConstraints holds the path to which a snap requests access, the
+// permissions it requests, and the permissions the user may reply with.
+type PromptConstraints struct {
+	Path                 string   `json:"path"`
+	RequestedPermissions []string `json:"requested-permissions"`
+	AvailablePermissions []string `json:"available-permissions"`
+}
+
+// PromptReply holds the reply of the user to a prompt. Outcome is "allow" or
+// "deny", and Lifespan is "single", "session", "timespan", or "forever". For
+// anything but "single", a rule is created from the constraints of the
+// reply.
+type PromptReply struct {
+	Outcome     string                 `json:"action"`
+	Lifespan    string                 `json:"lifespan"`
+	Duration    string                 `json:"duration,omitempty"`
+	Constraints PromptReplyConstraints `json:"constraints"`
+}
+
+// PromptReplyConstraints holds the path pattern and permissions to which a
+// reply to a prompt applies.
+type PromptReplyConstraints struct {
+	PathPattern string   `json:"path-pattern"`
+	Permissions []string `json:"permissions"`
+}
+
+// Prompt returns the outstanding prompt of the current user with the given ID.
+func (client *Client) Prompt(id string) (*Prompt, error) {
+	var prompt Prompt
+	if _, err := client.doSync("GET", "/v2/interfaces/requests/prompts/"+url.PathEscape(id), nil, nil, nil, &prompt); err != nil {
+		return nil, err
+	}
+	return &prompt, nil
+}
+
+// ReplyToPrompt replies to the outstanding prompt of the current user with the
+// given ID, and returns the IDs of the prompts which were satisfied by the
+// reply.
+func (client *Client) ReplyToPrompt(id string, reply *PromptReply) ([]string, error) {
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode prompt reply: %v", err)
+	}
+
+	var satisfied []string
+	if _, err := client.doSync("POST", "/v2/interfaces/requests/prompts/"+url.PathEscape(id), nil, nil, bytes.NewReader(data), &satisfied); err != nil {
+		return nil, err
+	}
+	return satisfied, nil
+}
+
+// ExportPromptingRules returns the prompting rules of the current user in the
+// stable format understood by ImportPromptingRules. Rules provisioned by an
+// administrator are only exported when called by root.
+func (client *Client) ExportPromptingRules() (json.RawMessage, error) {
+	q := url.Values{"export": []string{"true"}}
+
+	var export json.RawMessage
+	if _, err := client.doSync("GET", "/v2/interfaces/requests/rules", q, nil, nil, &export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// ImportPromptingRules imports the given prompting rules, as previously
+// exported by ExportPromptingRules, for the current user, and returns the
+// number of rules which were imported. When called by root, the rules are
+// provisioned as admin rules, which apply to all users.
+func (client *Client) ImportPromptingRules(export json.RawMessage) (int, error) {
+	body := struct {
+		Action string          `json:"action"`
+		Import json.RawMessage `json:"import"`
+	}{
+		Action: "import",
+		Import: export,
+	}
+	data, err := json.Marshal(&body)
+	if err != nil {
+		return 0, fmt.Errorf("cannot encode prompting rules: %v", err)
+	}
+
+	var rules []json.RawMessage
+	if _, err := client.doSync("POST", "/v2/interfaces/requests/rules", nil, nil, bytes.NewReader(data), &rules); err != nil {
+		return 0, err
+	}
+	return len(rules), nil
+}
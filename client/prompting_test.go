@@ -0,0 +1,163 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestExportPromptingRules(c *check.C) {
+	cs.rsp = `{
+		"result": {"version": 1, "rules": [{"snap": "firefox", "interface": "home", "constraints": {}}]},
+		"status": "OK",
+		"status-code": 200,
+		"type": "sync"
+	}`
+
+	export, err := cs.cli.ExportPromptingRules()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces/requests/rules")
+	c.Check(cs.req.URL.Query().Get("export"), check.Equals, "true")
+	c.Check(string(export), check.Equals, `{"version": 1, "rules": [{"snap": "firefox", "interface": "home", "constraints": {}}]}`)
+}
+
+func (cs *clientSuite) TestExportPromptingRulesError(c *check.C) {
+	cs.rsp = `{
+		"result": {"message": "AppArmor Prompting is not running", "kind": "app-armor-prompting-not-running"},
+		"status-code": 500,
+		"type": "error"
+	}`
+
+	export, err := cs.cli.ExportPromptingRules()
+	c.Check(err, check.ErrorMatches, "AppArmor Prompting is not running")
+	c.Check(export, check.IsNil)
+}
+
+func (cs *clientSuite) TestImportPromptingRules(c *check.C) {
+	cs.rsp = `{
+		"result": [{"id": "0000000000000001"}, {"id": "0000000000000002"}],
+		"status": "OK",
+		"status-code": 200,
+		"type": "sync"
+	}`
+
+	n, err := cs.cli.ImportPromptingRules(json.RawMessage(`{"version": 1, "rules": []}`))
+	c.Assert(err, check.IsNil)
+	c.Check(n, check.Equals, 2)
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces/requests/rules")
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(string(body), check.Equals, `{"action":"import","import":{"version":1,"rules":[]}}`)
+}
+
+func (cs *clientSuite) TestImportPromptingRulesErrors(c *check.C) {
+	_, err := cs.cli.ImportPromptingRules(json.RawMessage(`{"version":`))
+	c.Check(err, check.ErrorMatches, "cannot encode prompting rules: .*")
+
+	cs.rsp = `{
+		"result": {"message": "unsupported rules export version: 2", "kind": "interfaces-requests-invalid-fields"},
+		"status-code": 400,
+		"type": "error"
+	}`
+	n, err := cs.cli.ImportPromptingRules(json.RawMessage(`{"version": 2, "rules": []}`))
+	c.Check(err, check.ErrorMatches, "unsupported rules export version: 2")
+	c.Check(n, check.Equals, 0)
+}
+
+func (cs *clientSuite) TestPrompt(c *check.C) {
+	cs.rsp = `{
+		"result": {
+			"id": "0000000000000002",
+			"timestamp": "2026-10-18T10:00:00Z",
+			"snap": "firefox",
+			"interface": "home",
+			"constraints": {
+				"path": "/home/test/foo",
+				"requested-permissions": ["read"],
+				"available-permissions": ["read", "write", "execute"]
+			}
+		},
+		"status": "OK",
+		"status-code": 200,
+		"type": "sync"
+	}`
+
+	prompt, err := cs.cli.Prompt("0000000000000002")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces/requests/prompts/0000000000000002")
+	c.Check(prompt, check.DeepEquals, &client.Prompt{
+		ID:        "0000000000000002",
+		Timestamp: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC),
+		Snap:      "firefox",
+		Interface: "home",
+		Constraints: client.PromptConstraints{
+			Path:                 "/home/test/foo",
+			RequestedPermissions: []string{"read"},
+			AvailablePermissions: []string{"read", "write", "execute"},
+		},
+	})
+}
+
+func (cs *clientSuite) TestPromptNotFound(c *check.C) {
+	cs.rsp = `{
+		"result": {"message": "cannot find prompt with the given ID for the given user", "kind": "interfaces-requests-prompt-not-found"},
+		"status-code": 404,
+		"type": "error"
+	}`
+
+	prompt, err := cs.cli.Prompt("0000000000000002")
+	c.Check(err, check.ErrorMatches, "cannot find prompt with the given ID for the given user")
+	c.Check(err.(*client.Error).Kind, check.Equals, client.ErrorKindInterfacesRequestsPromptNotFound)
+	c.Check(prompt, check.IsNil)
+}
+
+func (cs *clientSuite) TestReplyToPrompt(c *check.C) {
+	cs.rsp = `{
+		"result": ["0000000000000002", "0000000000000003"],
+		"status": "OK",
+		"status-code": 200,
+		"type": "sync"
+	}`
+
+	satisfied, err := cs.cli.ReplyToPrompt("0000000000000002", &client.PromptReply{
+		Outcome:  "allow",
+		Lifespan: "forever",
+		Constraints: client.PromptReplyConstraints{
+			PathPattern: "/home/test/foo",
+			Permissions: []string{"read"},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(satisfied, check.DeepEquals, []string{"0000000000000002", "0000000000000003"})
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces/requests/prompts/0000000000000002")
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(string(body), check.Equals, `{"action":"allow","lifespan":"forever","constraints":{"path-pattern":"/home/test/foo","permissions":["read"]}}`)
+}
@@ -28,6 +28,8 @@ import (
 	"mime/multipart"
 	"os"
 	"path/filepath"
+
+	"github.com/snapcore/snapd/snap"
 )
 
 // TransactionType says whether we want to treat each snap separately
@@ -64,6 +66,16 @@ type SnapOptions struct {
 	Time             string          `json:"time,omitempty"`
 	HoldLevel        string          `json:"hold-level,omitempty"`
 	Users            []string        `json:"users,omitempty"`
+
+	// SnapshotBeforeRefresh asks for a snapshot of the data of the
+	// snaps before refreshing them, that a revert can restore
+	SnapshotBeforeRefresh bool `json:"snapshot-before-refresh,omitempty"`
+	// WithData asks for a revert to also restore the data of the
+	// snapshot taken before refreshing from the revision reverted to
+	WithData bool `json:"with-data,omitempty"`
+
+	// SnapshotPassphrase is only used by the snapshot action
+	SnapshotPassphrase string `json:"-"`
 }
 
 func writeFieldBool(mw *multipart.Writer, key string, val bool) error {
@@ -136,6 +148,10 @@ type multiActionData struct {
 	Time           string              `json:"time,omitempty"`
 	HoldLevel      string              `json:"hold-level,omitempty"`
 	Components     map[string][]string `json:"components,omitempty"`
+
+	SnapshotPassphrase    string `json:"snapshot-passphrase,omitempty"`
+	SnapshotBeforeRefresh bool   `json:"snapshot-before-refresh,omitempty"`
+	DryRun                bool   `json:"dry-run,omitempty"`
 }
 
 // Install adds the snap with the given name from the given channel (or
@@ -170,6 +186,71 @@ func (client *Client) RefreshMany(names []string, components map[string][]string
 	return client.doMultiSnapAction("refresh", names, components, options)
 }
 
+// RefreshPlan describes what refreshing snaps would do, without doing it.
+type RefreshPlan struct {
+	// Summary is the summary the refresh change would have.
+	Summary string `json:"summary"`
+	// Snaps are the snaps that would be refreshed.
+	Snaps []*RefreshPlanSnap `json:"snaps,omitempty"`
+	// Tasks are the tasks the refresh change would be made of.
+	Tasks []*RefreshPlanTask `json:"tasks,omitempty"`
+}
+
+// RefreshPlanSnap describes the refresh of a single snap in a RefreshPlan.
+type RefreshPlanSnap struct {
+	Name         string        `json:"name"`
+	Type         string        `json:"type,omitempty"`
+	Revision     snap.Revision `json:"revision"`
+	Channel      string        `json:"channel,omitempty"`
+	DownloadSize int64         `json:"download-size,omitempty"`
+	// Prerequisites are the bases and default content providers needed
+	// by the new revision that are not installed and would be pulled in.
+	Prerequisites []string `json:"prerequisites,omitempty"`
+	// Restart is "system" if refreshing the snap requires a reboot,
+	// "daemon" if it restarts snapd, and empty otherwise.
+	Restart string `json:"restart,omitempty"`
+}
+
+// RefreshPlanTask describes one of the tasks of a RefreshPlan.
+type RefreshPlanTask struct {
+	ID      string   `json:"id"`
+	Kind    string   `json:"kind"`
+	Summary string   `json:"summary"`
+	Snap    string   `json:"snap,omitempty"`
+	WaitFor []string `json:"wait-for,omitempty"`
+}
+
+// RefreshPlan computes what refreshing the given snaps, or all snaps if
+// none are given, would do, without changing the system.
+func (client *Client) RefreshPlan(names []string, components map[string][]string, options *SnapOptions) (*RefreshPlan, error) {
+	action := multiActionData{
+		Action:     "refresh",
+		Snaps:      names,
+		Components: components,
+		DryRun:     true,
+	}
+	if options != nil {
+		action.Transaction = options.Transaction
+		action.IgnoreRunning = options.IgnoreRunning
+		action.SnapshotBeforeRefresh = options.SnapshotBeforeRefresh
+	}
+
+	data, err := json.Marshal(&action)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal multi-snap action: %s", err)
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	var plan RefreshPlan
+	if _, err := client.doSync("POST", "/v2/snaps", nil, headers, bytes.NewBuffer(data), &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
 func (client *Client) HoldRefreshes(name string, options *SnapOptions) (changeID string, err error) {
 	return client.doSnapAction("hold", name, nil, options)
 }
@@ -205,8 +286,8 @@ func (client *Client) Switch(name string, options *SnapOptions) (changeID string
 }
 
 // SnapshotMany snapshots many snaps (all, if names empty) for many users (all, if users is empty).
-func (client *Client) SnapshotMany(names []string, users []string) (setID uint64, changeID string, err error) {
-	result, changeID, err := client.doMultiSnapActionFull("snapshot", names, nil, &SnapOptions{Users: users})
+func (client *Client) SnapshotMany(names []string, users []string, opts *SnapshotKeyOptions) (setID uint64, changeID string, err error) {
+	result, changeID, err := client.doMultiSnapActionFull("snapshot", names, nil, &SnapOptions{Users: users, SnapshotPassphrase: opts.passphrase()})
 	if err != nil {
 		return 0, "", err
 	}
@@ -269,6 +350,8 @@ func (client *Client) doMultiSnapActionFull(actionName string, snaps []string, c
 		action.ValidationSets = options.ValidationSets
 		action.Time = options.Time
 		action.HoldLevel = options.HoldLevel
+		action.SnapshotPassphrase = options.SnapshotPassphrase
+		action.SnapshotBeforeRefresh = options.SnapshotBeforeRefresh
 	}
 
 	data, err := json.Marshal(&action)
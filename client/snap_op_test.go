@@ -33,6 +33,7 @@ import (
 	"gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -108,7 +109,7 @@ func (cs *clientSuite) TestClientMultiOpSnapServerError(c *check.C) {
 		_, err := s.op(cs.cli, nil, nil)
 		c.Check(err, check.ErrorMatches, `.*fail`, check.Commentf(s.action))
 	}
-	_, _, err := cs.cli.SnapshotMany(nil, nil)
+	_, _, err := cs.cli.SnapshotMany(nil, nil, nil)
 	c.Check(err, check.ErrorMatches, `.*fail`)
 }
 
@@ -128,7 +129,7 @@ func (cs *clientSuite) TestClientMultiOpSnapResponseError(c *check.C) {
 		_, err := s.op(cs.cli, nil, nil)
 		c.Check(err, check.ErrorMatches, `.*server error: "Internal Server Error"`, check.Commentf(s.action))
 	}
-	_, _, err := cs.cli.SnapshotMany(nil, nil)
+	_, _, err := cs.cli.SnapshotMany(nil, nil, nil)
 	c.Check(err, check.ErrorMatches, `.*server error: "Internal Server Error"`)
 }
 
@@ -283,6 +284,59 @@ func (cs *clientSuite) TestClientMultiOpSnapIgnoreRunning(c *check.C) {
 	}
 }
 
+func (cs *clientSuite) TestClientRefreshSnapshotBeforeRefresh(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	id, err := cs.cli.Refresh(pkgName, nil, &client.SnapOptions{SnapshotBeforeRefresh: true})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "d728")
+	c.Check(cs.req.URL.Path, check.Equals, fmt.Sprintf("/v2/snaps/%s", pkgName))
+
+	var jsonBody map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&jsonBody), check.IsNil)
+	c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+		"action":                  "refresh",
+		"snapshot-before-refresh": true,
+	})
+
+	id, err = cs.cli.RefreshMany([]string{pkgName}, nil, &client.SnapOptions{SnapshotBeforeRefresh: true})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "d728")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps")
+
+	jsonBody = nil
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&jsonBody), check.IsNil)
+	c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+		"action":                  "refresh",
+		"snaps":                   []interface{}{pkgName},
+		"snapshot-before-refresh": true,
+	})
+}
+
+func (cs *clientSuite) TestClientRevertWithData(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	id, err := cs.cli.Revert(pkgName, &client.SnapOptions{WithData: true})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "d728")
+	c.Check(cs.req.URL.Path, check.Equals, fmt.Sprintf("/v2/snaps/%s", pkgName))
+
+	var jsonBody map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&jsonBody), check.IsNil)
+	c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+		"action":    "revert",
+		"with-data": true,
+	})
+}
+
 func (cs *clientSuite) TestClientMultiSnapshot(c *check.C) {
 	// Note body is essentially the same as TestClientMultiOpSnap; keep in sync
 	cs.status = 202
@@ -292,7 +346,7 @@ func (cs *clientSuite) TestClientMultiSnapshot(c *check.C) {
 		"status-code": 202,
 		"type": "async"
 	}`
-	setID, changeID, err := cs.cli.SnapshotMany([]string{pkgName}, nil)
+	setID, changeID, err := cs.cli.SnapshotMany([]string{pkgName}, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(cs.req.Header.Get("Content-Type"), check.Equals, "application/json")
 
@@ -309,6 +363,74 @@ func (cs *clientSuite) TestClientMultiSnapshot(c *check.C) {
 	c.Check(changeID, check.Equals, "d728")
 }
 
+func (cs *clientSuite) TestClientMultiSnapshotPassphrase(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"result": {"set-id": 42},
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	_, _, err := cs.cli.SnapshotMany([]string{pkgName}, nil, &client.SnapshotKeyOptions{Passphrase: "sekrit"})
+	c.Assert(err, check.IsNil)
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	jsonBody := make(map[string]interface{})
+	err = json.Unmarshal(body, &jsonBody)
+	c.Assert(err, check.IsNil)
+	c.Check(jsonBody["snapshot-passphrase"], check.Equals, "sekrit")
+}
+
+func (cs *clientSuite) TestClientRefreshPlan(c *check.C) {
+	cs.status = 200
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"summary": "Refresh snap \"foo\"",
+			"snaps": [{"name": "foo", "type": "app", "revision": "7", "channel": "stable", "download-size": 1024, "prerequisites": ["core22"]}],
+			"tasks": [
+				{"id": "1", "kind": "prerequisites", "summary": "Ensure prerequisites", "snap": "foo"},
+				{"id": "2", "kind": "download-snap", "summary": "Download", "snap": "foo", "wait-for": ["1"]}
+			]
+		}
+	}`
+	plan, err := cs.cli.RefreshPlan([]string{"foo"}, nil, &client.SnapOptions{IgnoreRunning: true})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps")
+	c.Check(cs.req.Header.Get("Content-Type"), check.Equals, "application/json")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	jsonBody := make(map[string]interface{})
+	err = json.Unmarshal(body, &jsonBody)
+	c.Assert(err, check.IsNil)
+	c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+		"action":         "refresh",
+		"snaps":          []interface{}{"foo"},
+		"ignore-running": true,
+		"dry-run":        true,
+	})
+
+	c.Check(plan, check.DeepEquals, &client.RefreshPlan{
+		Summary: `Refresh snap "foo"`,
+		Snaps: []*client.RefreshPlanSnap{{
+			Name:          "foo",
+			Type:          "app",
+			Revision:      snap.R(7),
+			Channel:       "stable",
+			DownloadSize:  1024,
+			Prerequisites: []string{"core22"},
+		}},
+		Tasks: []*client.RefreshPlanTask{
+			{ID: "1", Kind: "prerequisites", Summary: "Ensure prerequisites", Snap: "foo"},
+			{ID: "2", Kind: "download-snap", Summary: "Download", Snap: "foo", WaitFor: []string{"1"}},
+		},
+	})
+}
+
 func (cs *clientSuite) TestClientOpInstallPath(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
@@ -50,6 +50,21 @@ type snapshotAction struct {
 	Action string   `json:"action"`
 	Snaps  []string `json:"snaps,omitempty"`
 	Users  []string `json:"users,omitempty"`
+
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+// SnapshotKeyOptions holds the key material used to encrypt or decrypt
+// a snapshot set protected by a passphrase.
+type SnapshotKeyOptions struct {
+	Passphrase string
+}
+
+func (opts *SnapshotKeyOptions) passphrase() string {
+	if opts == nil {
+		return ""
+	}
+	return opts.Passphrase
 }
 
 // A Snapshot is a collection of archives with a simple metadata json file
@@ -81,6 +96,9 @@ type Snapshot struct {
 	// dynamic snapshot options
 	Options *snap.SnapshotOptions `json:"options,omitempty"`
 
+	// if the snapshot's archives are encrypted, how to decrypt them
+	Encryption *SnapshotEncryption `json:"encryption,omitempty"`
+
 	// if the snapshot failed to open this will be the reason why
 	Broken string `json:"broken,omitempty"`
 
@@ -91,6 +109,18 @@ type Snapshot struct {
 	Auto bool `json:"auto,omitempty"`
 }
 
+// SnapshotEncryption describes how the archives of an encrypted snapshot
+// can be decrypted.
+type SnapshotEncryption struct {
+	// Key is the source of the key protecting the snapshot data key,
+	// either "device" for a key held by the device, or "passphrase".
+	Key string `json:"key"`
+	// Salt used to derive a key from the passphrase.
+	Salt []byte `json:"salt,omitempty"`
+	// WrappedKey is the data key of the snapshot, itself encrypted.
+	WrappedKey []byte `json:"wrapped-key"`
+}
+
 // IsValid checks whether the snapshot is missing information that
 // should be there for a snapshot that's just been opened.
 func (sh *Snapshot) IsValid() bool {
@@ -196,12 +226,13 @@ func (client *Client) ForgetSnapshots(setID uint64, snaps []string) (changeID st
 //
 // If snaps or users are non-empty, limit to checking only those
 // archives of the snapshot.
-func (client *Client) CheckSnapshots(setID uint64, snaps []string, users []string) (changeID string, err error) {
+func (client *Client) CheckSnapshots(setID uint64, snaps []string, users []string, opts *SnapshotKeyOptions) (changeID string, err error) {
 	return client.snapshotAction(&snapshotAction{
-		SetID:  setID,
-		Action: "check",
-		Snaps:  snaps,
-		Users:  users,
+		SetID:      setID,
+		Action:     "check",
+		Snaps:      snaps,
+		Users:      users,
+		Passphrase: opts.passphrase(),
 	})
 }
 
@@ -209,12 +240,22 @@ func (client *Client) CheckSnapshots(setID uint64, snaps []string, users []strin
 //
 // If snaps or users are non-empty, limit to checking only those
 // archives of the snapshot.
-func (client *Client) RestoreSnapshots(setID uint64, snaps []string, users []string) (changeID string, err error) {
+func (client *Client) RestoreSnapshots(setID uint64, snaps []string, users []string, opts *SnapshotKeyOptions) (changeID string, err error) {
+	return client.snapshotAction(&snapshotAction{
+		SetID:      setID,
+		Action:     "restore",
+		Snaps:      snaps,
+		Users:      users,
+		Passphrase: opts.passphrase(),
+	})
+}
+
+// PushSnapshots pushes an export of the given snapshot set to the remote
+// snapshot target configured on the device.
+func (client *Client) PushSnapshots(setID uint64) (changeID string, err error) {
 	return client.snapshotAction(&snapshotAction{
 		SetID:  setID,
-		Action: "restore",
-		Snaps:  snaps,
-		Users:  users,
+		Action: "push",
 	})
 }
 
@@ -260,6 +301,14 @@ func (client *Client) SnapshotExport(setID uint64) (stream io.ReadCloser, conten
 	return rsp.Body, rsp.ContentLength, nil
 }
 
+// RemoteSnapshot is a snapshot set export stored on the remote snapshot
+// target.
+type RemoteSnapshot struct {
+	Name string    `json:"name"`
+	Size int64     `json:"size"`
+	Time time.Time `json:"time"`
+}
+
 // SnapshotImportSet is a snapshot import created by a "snap import-snapshot".
 type SnapshotImportSet struct {
 	ID    uint64   `json:"set-id"`
@@ -267,11 +316,14 @@ type SnapshotImportSet struct {
 }
 
 // SnapshotImport imports an exported snapshot set.
-func (client *Client) SnapshotImport(exportStream io.Reader, size int64) (SnapshotImportSet, error) {
+func (client *Client) SnapshotImport(exportStream io.Reader, size int64, opts *SnapshotKeyOptions) (SnapshotImportSet, error) {
 	headers := map[string]string{
 		"Content-Type":   SnapshotExportMediaType,
 		"Content-Length": strconv.FormatInt(size, 10),
 	}
+	if passphrase := opts.passphrase(); passphrase != "" {
+		headers["X-Snapd-Snapshot-Passphrase"] = passphrase
+	}
 
 	var importSet SnapshotImportSet
 	if _, err := client.doSync("POST", "/v2/snapshots", nil, headers, exportStream, &importSet); err != nil {
@@ -280,3 +332,41 @@ func (client *Client) SnapshotImport(exportStream io.Reader, size int64) (Snapsh
 
 	return importSet, nil
 }
+
+// RemoteSnapshots lists the snapshot set exports on the remote snapshot
+// target configured on the device.
+func (client *Client) RemoteSnapshots() ([]RemoteSnapshot, error) {
+	var exports []RemoteSnapshot
+	_, err := client.doSync("GET", "/v2/snapshots/remote", nil, nil, nil, &exports)
+	return exports, err
+}
+
+// remoteSnapshotAction is used to request an operation on the remote
+// snapshot target.
+type remoteSnapshotAction struct {
+	Action     string `json:"action"`
+	Name       string `json:"name"`
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+// RemoteSnapshotImport imports the snapshot set export of the given name
+// from the remote snapshot target.
+func (client *Client) RemoteSnapshotImport(name string, opts *SnapshotKeyOptions) (SnapshotImportSet, error) {
+	var importSet SnapshotImportSet
+
+	data, err := json.Marshal(&remoteSnapshotAction{
+		Action:     "import",
+		Name:       name,
+		Passphrase: opts.passphrase(),
+	})
+	if err != nil {
+		return importSet, fmt.Errorf("cannot marshal remote snapshot action: %v", err)
+	}
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	if _, err := client.doSync("POST", "/v2/snapshots/remote", nil, headers, bytes.NewBuffer(data), &importSet); err != nil {
+		return importSet, err
+	}
+	return importSet, nil
+}
@@ -21,6 +21,7 @@ package client_test
 
 import (
 	"crypto/sha256"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
@@ -98,7 +99,7 @@ func (cs *clientSuite) TestClientSnapshotSets(c *check.C) {
 	})
 }
 
-func (cs *clientSuite) testClientSnapshotActionFull(c *check.C, action string, users []string, f func() (string, error)) {
+func (cs *clientSuite) testClientSnapshotActionFull(c *check.C, action string, users []string, passphrase string, f func() (string, error)) {
 	cs.status = 202
 	cs.rsp = `{
 		"status-code": 202,
@@ -117,6 +118,7 @@ func (cs *clientSuite) testClientSnapshotActionFull(c *check.C, action string, u
 	c.Check(act.Action, check.Equals, action)
 	c.Check(act.Snaps, check.DeepEquals, []string{"asnap", "bsnap"})
 	c.Check(act.Users, check.DeepEquals, users)
+	c.Check(act.Passphrase, check.Equals, passphrase)
 
 	c.Check(cs.req.Method, check.Equals, "POST")
 	c.Check(cs.req.URL.Path, check.Equals, "/v2/snapshots")
@@ -124,14 +126,37 @@ func (cs *clientSuite) testClientSnapshotActionFull(c *check.C, action string, u
 }
 
 func (cs *clientSuite) TestClientForgetSnapshot(c *check.C) {
-	cs.testClientSnapshotActionFull(c, "forget", nil, func() (string, error) {
+	cs.testClientSnapshotActionFull(c, "forget", nil, "", func() (string, error) {
 		return cs.cli.ForgetSnapshots(42, []string{"asnap", "bsnap"})
 	})
 }
 
-func (cs *clientSuite) testClientSnapshotAction(c *check.C, action string, f func(uint64, []string, []string) (string, error)) {
-	cs.testClientSnapshotActionFull(c, action, []string{"auser", "buser"}, func() (string, error) {
-		return f(42, []string{"asnap", "bsnap"}, []string{"auser", "buser"})
+func (cs *clientSuite) TestClientPushSnapshots(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"status-code": 202,
+		"type": "async",
+		"change": "1too3"
+	}`
+	id, err := cs.cli.PushSnapshots(42)
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "1too3")
+
+	act, err := client.UnmarshalSnapshotAction(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(act.SetID, check.Equals, uint64(42))
+	c.Check(act.Action, check.Equals, "push")
+	c.Check(act.Snaps, check.HasLen, 0)
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snapshots")
+}
+
+func (cs *clientSuite) testClientSnapshotAction(c *check.C, action string, f func(uint64, []string, []string, *client.SnapshotKeyOptions) (string, error)) {
+	cs.testClientSnapshotActionFull(c, action, []string{"auser", "buser"}, "", func() (string, error) {
+		return f(42, []string{"asnap", "bsnap"}, []string{"auser", "buser"}, nil)
+	})
+	cs.testClientSnapshotActionFull(c, action, []string{"auser", "buser"}, "sekrit", func() (string, error) {
+		return f(42, []string{"asnap", "bsnap"}, []string{"auser", "buser"}, &client.SnapshotKeyOptions{Passphrase: "sekrit"})
 	})
 }
 
@@ -212,7 +237,7 @@ func (cs *clientSuite) TestClientSnapshotImport(c *check.C) {
 
 		fakeSnapshotData := "fake"
 		r := strings.NewReader(fakeSnapshotData)
-		importSet, err := cs.cli.SnapshotImport(r, int64(len(fakeSnapshotData)))
+		importSet, err := cs.cli.SnapshotImport(r, int64(len(fakeSnapshotData)), nil)
 		if t.error != "" {
 			c.Assert(err, check.NotNil, comm)
 			c.Check(err.Error(), check.Equals, t.error, comm)
@@ -229,6 +254,14 @@ func (cs *clientSuite) TestClientSnapshotImport(c *check.C) {
 	}
 }
 
+func (cs *clientSuite) TestClientSnapshotImportPassphrase(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": {"set-id": 42, "snaps": ["foo"]}}`
+
+	_, err := cs.cli.SnapshotImport(strings.NewReader("fake"), 4, &client.SnapshotKeyOptions{Passphrase: "sekrit"})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Header.Get("X-Snapd-Snapshot-Passphrase"), check.Equals, "sekrit")
+}
+
 func (cs *clientSuite) TestClientSnapshotContentHash(c *check.C) {
 	now := time.Now()
 	revno := snap.R(1)
@@ -315,3 +348,31 @@ func (cs *clientSuite) TestClientSnapshotSetContentHash(c *check.C) {
 	c.Check(h3, check.Not(check.DeepEquals), h1)
 
 }
+
+func (cs *clientSuite) TestClientRemoteSnapshots(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": [{"name": "1_x.snapshot", "size": 42, "time": "2026-01-01T00:00:00Z"}]}`
+	exports, err := cs.cli.RemoteSnapshots()
+	c.Assert(err, check.IsNil)
+	c.Check(exports, check.DeepEquals, []client.RemoteSnapshot{
+		{Name: "1_x.snapshot", Size: 42, Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	})
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snapshots/remote")
+}
+
+func (cs *clientSuite) TestClientRemoteSnapshotImport(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": {"set-id": 42, "snaps": ["foo"]}}`
+	importSet, err := cs.cli.RemoteSnapshotImport("1_x.snapshot", &client.SnapshotKeyOptions{Passphrase: "sekrit"})
+	c.Assert(err, check.IsNil)
+	c.Check(importSet, check.DeepEquals, client.SnapshotImportSet{ID: 42, Snaps: []string{"foo"}})
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snapshots/remote")
+
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action":     "import",
+		"name":       "1_x.snapshot",
+		"passphrase": "sekrit",
+	})
+}
@@ -23,6 +23,8 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 
@@ -34,6 +36,12 @@ var shortChangesHelp = i18n.G("List system changes")
 var shortTasksHelp = i18n.G("List a change's tasks")
 var longChangesHelp = i18n.G(`
 The changes command displays a summary of system changes performed recently.
+
+The changes can be narrowed down by kind, status, spawn time and data. The
+--data option takes a dotted path into the data of the change or of one of
+its tasks and the expected value, for example
+--data=snap-setup.side-info.revision=5. Use --limit to get at most a given
+number of changes, and --after-id to get the following ones.
 `)
 var longTasksHelp = i18n.G(`
 The tasks command displays a summary of tasks associated with an individual
@@ -43,6 +51,14 @@ change.
 type cmdChanges struct {
 	clientMixin
 	timeMixin
+	Kind    []string `long:"kind"`
+	Status  []string `long:"status"`
+	Since   string   `long:"since"`
+	Until   string   `long:"until"`
+	Data    []string `long:"data"`
+	Limit   int      `long:"limit"`
+	AfterID string   `long:"after-id"`
+
 	Positional struct {
 		Snap string `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
@@ -55,7 +71,22 @@ type cmdTasks struct {
 
 func init() {
 	addCommand("changes", shortChangesHelp, longChangesHelp,
-		func() flags.Commander { return &cmdChanges{} }, timeDescs, nil)
+		func() flags.Commander { return &cmdChanges{} }, timeDescs.also(map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"kind": i18n.G("Only show changes of the given kind"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"status": i18n.G("Only show changes with the given status"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"since": i18n.G("Only show changes spawned after the given time (in RFC 3339 format)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"until": i18n.G("Only show changes spawned before the given time (in RFC 3339 format)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"data": i18n.G("Only show changes with the given <path>=<value> in their data or in the data of one of their tasks"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"limit": i18n.G("Show at most the given number of changes"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"after-id": i18n.G("Only show changes following the one with the given ID"),
+		}), nil)
 	addCommand("tasks", shortTasksHelp, longTasksHelp,
 		func() flags.Commander { return &cmdTasks{} },
 		changeIDMixinOptDesc.also(timeDescs),
@@ -99,6 +130,31 @@ func (c *cmdChanges) Execute(args []string) error {
 	opts := client.ChangesOptions{
 		SnapName: c.Positional.Snap,
 		Selector: client.ChangesAll,
+		Kinds:    c.Kind,
+		Statuses: c.Status,
+		AfterID:  c.AfterID,
+		Limit:    c.Limit,
+	}
+	var err error
+	if c.Since != "" {
+		if opts.SpawnedAfter, err = time.Parse(time.RFC3339, c.Since); err != nil {
+			return fmt.Errorf(i18n.G("cannot parse --since: %v"), err)
+		}
+	}
+	if c.Until != "" {
+		if opts.SpawnedBefore, err = time.Parse(time.RFC3339, c.Until); err != nil {
+			return fmt.Errorf(i18n.G("cannot parse --until: %v"), err)
+		}
+	}
+	for _, data := range c.Data {
+		path, value, ok := strings.Cut(data, "=")
+		if !ok || path == "" {
+			return fmt.Errorf(i18n.G("cannot parse --data %q: expected <path>=<value>"), data)
+		}
+		if opts.Data == nil {
+			opts.Data = make(map[string]string)
+		}
+		opts.Data[path] = value
 	}
 
 	changes, err := queryChanges(c.client, &opts)
@@ -22,6 +22,7 @@ package main_test
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"gopkg.in/check.v1"
@@ -250,3 +251,54 @@ func (s *SnapSuite) TestNoChanges(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Check(s.Stderr(), check.Equals, "no changes found\n")
 }
+
+func (s *SnapSuite) TestChangesQuery(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/changes")
+			c.Check(r.URL.Query(), check.DeepEquals, url.Values{
+				"select":         {"all"},
+				"kind":           {"install,refresh"},
+				"status":         {"Error"},
+				"spawned-after":  {"2016-01-21T00:00:00Z"},
+				"spawned-before": {"2016-05-21T00:00:00Z"},
+				"data":           {"snap-setup.side-info.revision=5"},
+				"after-id":       {"10"},
+				"limit":          {"2"},
+			})
+			fmt.Fprintln(w, mockChangesJSON)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"changes",
+		"--kind=install", "--kind=refresh", "--status=Error",
+		"--since=2016-01-21T00:00:00Z", "--until=2016-05-21T00:00:00Z",
+		"--data=snap-setup.side-info.revision=5", "--after-id=10", "--limit=2"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Matches, `(?ms)ID +Status +Spawn +Ready +Summary
+.*`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestChangesQueryErrors(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request")
+	})
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--since=yesterday"}, `cannot parse --since: .*`},
+		{[]string{"--until=tomorrow"}, `cannot parse --until: .*`},
+		{[]string{"--data=foo"}, `cannot parse --data "foo": expected <path>=<value>`},
+	} {
+		_, err := snap.Parser(snap.Client()).ParseArgs(append([]string{"changes"}, tc.args...))
+		c.Check(err, check.ErrorMatches, tc.err)
+	}
+}
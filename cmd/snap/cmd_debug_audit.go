@@ -0,0 +1,127 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+var shortDebugAuditHelp = i18n.G("Search the log of past changes")
+var longDebugAuditHelp = i18n.G(`
+The audit command searches the log of the changes performed by snapd, which
+is kept after the changes themselves are pruned. Each entry records who
+requested the change, the snaps it affected, its outcome and its timings.
+`)
+
+type cmdDebugAudit struct {
+	clientMixin
+	timeMixin
+	Snap  string `long:"snap"`
+	Kind  string `long:"kind"`
+	UID   string `long:"uid"`
+	Since string `long:"since"`
+}
+
+func init() {
+	addDebugCommand("audit", shortDebugAuditHelp, longDebugAuditHelp,
+		func() flags.Commander {
+			return &cmdDebugAudit{}
+		}, timeDescs.also(map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"snap": i18n.G("Only show changes affecting the given snap"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"kind": i18n.G("Only show changes of the given kind"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"uid": i18n.G("Only show changes requested by the given user ID"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"since": i18n.G("Only show changes ready after the given time (in RFC 3339 format)"),
+		}), nil)
+}
+
+type auditRecord struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Summary   string    `json:"summary"`
+	Status    string    `json:"status"`
+	Err       string    `json:"err,omitempty"`
+	SnapNames []string  `json:"snap-names,omitempty"`
+	UID       *uint32   `json:"uid,omitempty"`
+	SpawnTime time.Time `json:"spawn-time"`
+	ReadyTime time.Time `json:"ready-time"`
+}
+
+func (x *cmdDebugAudit) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	params := make(map[string]string)
+	if x.Snap != "" {
+		params["snap"] = x.Snap
+	}
+	if x.Kind != "" {
+		params["kind"] = x.Kind
+	}
+	if x.UID != "" {
+		if _, err := strconv.ParseUint(x.UID, 10, 32); err != nil {
+			return fmt.Errorf(i18n.G("cannot parse --uid: %q is not a user ID"), x.UID)
+		}
+		params["uid"] = x.UID
+	}
+	if x.Since != "" {
+		if _, err := time.Parse(time.RFC3339, x.Since); err != nil {
+			return fmt.Errorf(i18n.G("cannot parse --since: %v"), err)
+		}
+		params["since"] = x.Since
+	}
+
+	var records []auditRecord
+	if err := x.client.DebugGet("audit", &records, params); err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("no changes found"))
+		return nil
+	}
+
+	w := tabWriter()
+	fmt.Fprint(w, i18n.G("ID\tKind\tStatus\tUID\tSpawn\tReady\tSnaps\n"))
+	for _, rec := range records {
+		uid := "-"
+		if rec.UID != nil {
+			uid = strconv.FormatUint(uint64(*rec.UID), 10)
+		}
+		snaps := "-"
+		if len(rec.SnapNames) > 0 {
+			snaps = strings.Join(rec.SnapNames, ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", rec.ID, rec.Kind, rec.Status, uid, x.fmtTime(rec.SpawnTime), x.fmtTime(rec.ReadyTime), snaps)
+	}
+	w.Flush()
+
+	return nil
+}
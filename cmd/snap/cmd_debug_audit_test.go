@@ -0,0 +1,82 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestDebugAudit(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/debug")
+			c.Check(r.URL.Query(), check.DeepEquals, url.Values{
+				"aspect": {"audit"},
+				"snap":   {"foo"},
+				"uid":    {"1000"},
+				"since":  {"2026-01-01T00:00:00Z"},
+			})
+			fmt.Fprintln(w, `{"type": "sync", "result": [
+{"id":"1","kind":"install-snap","summary":"Install \"foo\" snap","status":"Done","snap-names":["foo"],"uid":1000,"spawn-time":"2026-01-01T10:00:00Z","ready-time":"2026-01-01T10:01:00Z"},
+{"id":"2","kind":"service-control","summary":"Running service command","status":"Error","err":"boom","snap-names":["foo","bar"],"spawn-time":"2026-01-02T10:00:00Z","ready-time":"2026-01-02T10:01:00Z"}
+]}`)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "audit", "--abs-time", "--snap=foo", "--uid=1000", "--since=2026-01-01T00:00:00Z"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `
+ID   Kind             Status  UID   Spawn                 Ready                 Snaps
+1    install-snap     Done    1000  2026-01-01T10:00:00Z  2026-01-01T10:01:00Z  foo
+2    service-control  Error   -     2026-01-02T10:00:00Z  2026-01-02T10:01:00Z  foo,bar
+`[1:])
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(n, check.Equals, 1)
+}
+
+func (s *SnapSuite) TestDebugAuditNone(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "audit"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "no changes found\n")
+}
+
+func (s *SnapSuite) TestDebugAuditErrors(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "audit", "--uid=foo"})
+	c.Check(err, check.ErrorMatches, `cannot parse --uid: "foo" is not a user ID`)
+
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"debug", "audit", "--since=yesterday"})
+	c.Check(err, check.ErrorMatches, `cannot parse --since: .*`)
+}
@@ -0,0 +1,126 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var shortDebugCacheHelp = i18n.G("Inspect and manage the cache of downloaded snaps")
+var longDebugCacheHelp = i18n.G(`
+The cache command lists the entries in the cache of downloaded snaps, oldest
+first, which is the order they are removed in when the cache exceeds its
+limits. Entries that are still in use elsewhere take no space of their own.
+
+With --prune the limits set by the store.cache.* options are applied right
+away. With --pin the entry with the given key is never removed from the
+cache, until it is unpinned with --unpin.
+`)
+
+type cmdDebugCache struct {
+	clientMixin
+	timeMixin
+	Prune bool   `long:"prune"`
+	Pin   string `long:"pin" value-name:"<key>"`
+	Unpin string `long:"unpin" value-name:"<key>"`
+}
+
+func init() {
+	addDebugCommand("cache", shortDebugCacheHelp, longDebugCacheHelp,
+		func() flags.Commander {
+			return &cmdDebugCache{}
+		}, timeDescs.also(map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"prune": i18n.G("Remove the entries exceeding the limits of the cache"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"pin": i18n.G("Never remove the entry with the given key"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"unpin": i18n.G("Allow removing the entry with the given key again"),
+		}), nil)
+}
+
+type downloadCacheEntry struct {
+	Key        string    `json:"key"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod-time"`
+	Referenced bool      `json:"referenced,omitempty"`
+	Pinned     bool      `json:"pinned,omitempty"`
+}
+
+func (x *cmdDebugCache) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	n := 0
+	for _, set := range []bool{x.Prune, x.Pin != "", x.Unpin != ""} {
+		if set {
+			n++
+		}
+	}
+	if n > 1 {
+		return fmt.Errorf(i18n.G("cannot use --prune, --pin and --unpin together"))
+	}
+
+	switch {
+	case x.Prune:
+		return x.client.Debug("prune-download-cache", nil, nil)
+	case x.Pin != "":
+		return x.client.Debug("pin-download-cache", map[string]string{"cache-key": x.Pin}, nil)
+	case x.Unpin != "":
+		return x.client.Debug("unpin-download-cache", map[string]string{"cache-key": x.Unpin}, nil)
+	}
+
+	var entries []downloadCacheEntry
+	if err := x.client.DebugGet("download-cache", &entries, nil); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("the download cache is empty"))
+		return nil
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ModTime.Before(entries[j].ModTime)
+	})
+
+	w := tabWriter()
+	fmt.Fprint(w, i18n.G("Key\tSize\tLast used\tNotes\n"))
+	for _, e := range entries {
+		var notes []string
+		if e.Pinned {
+			notes = append(notes, "pinned")
+		}
+		if e.Referenced {
+			notes = append(notes, "in-use")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Key, strutil.SizeToStr(e.Size), x.fmtTime(e.ModTime), orDash(strings.Join(notes, ",")))
+	}
+	w.Flush()
+
+	return nil
+}
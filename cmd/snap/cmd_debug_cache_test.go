@@ -0,0 +1,104 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestDebugCache(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/debug")
+			c.Check(r.URL.Query(), check.DeepEquals, url.Values{
+				"aspect": {"download-cache"},
+			})
+			fmt.Fprintln(w, `{"type": "sync", "result": [
+{"key":"bbbb","size":2048,"mod-time":"2026-01-02T10:00:00Z","referenced":true,"pinned":true},
+{"key":"aaaa","size":4000000,"mod-time":"2026-01-01T10:00:00Z"}
+]}`)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "cache", "--abs-time"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `
+Key   Size  Last used             Notes
+aaaa  4MB   2026-01-01T10:00:00Z  -
+bbbb  2kB   2026-01-02T10:00:00Z  pinned,in-use
+`[1:])
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(n, check.Equals, 1)
+}
+
+func (s *SnapSuite) TestDebugCacheEmpty(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "cache"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "the download cache is empty\n")
+}
+
+func (s *SnapSuite) TestDebugCacheActions(c *check.C) {
+	for _, tc := range []struct {
+		args   []string
+		action string
+		params interface{}
+	}{
+		{[]string{"--prune"}, "prune-download-cache", nil},
+		{[]string{"--pin=aaaa"}, "pin-download-cache", map[string]interface{}{"cache-key": "aaaa"}},
+		{[]string{"--unpin=aaaa"}, "unpin-download-cache", map[string]interface{}{"cache-key": "aaaa"}},
+	} {
+		n := 0
+		s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+			n++
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/debug")
+			var body map[string]interface{}
+			c.Assert(json.NewDecoder(r.Body).Decode(&body), check.IsNil)
+			c.Check(body["action"], check.Equals, tc.action)
+			c.Check(body["params"], check.DeepEquals, tc.params)
+			fmt.Fprintln(w, `{"type": "sync", "result": true}`)
+		})
+		_, err := snap.Parser(snap.Client()).ParseArgs(append([]string{"debug", "cache"}, tc.args...))
+		c.Assert(err, check.IsNil)
+		c.Check(n, check.Equals, 1)
+	}
+}
+
+func (s *SnapSuite) TestDebugCacheConflictingActions(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "cache", "--prune", "--pin=aaaa"})
+	c.Check(err, check.ErrorMatches, "cannot use --prune, --pin and --unpin together")
+}
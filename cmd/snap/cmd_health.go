@@ -0,0 +1,104 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var shortHealthHelp = i18n.G("Show the health of snaps")
+var longHealthHelp = i18n.G(`
+The health command displays the last health status reported by each snap
+that reports one, and the overall health of the system, which is the most
+worrying of those statuses.
+
+With --history, the last transitions of each snap from one health status to
+another are displayed instead.
+
+The command fails if any of the snaps reports being in error, so it can be
+used as a probe by monitoring tools.
+`)
+
+type cmdHealth struct {
+	clientMixin
+	timeMixin
+	History    bool `long:"history"`
+	Positional struct {
+		Snaps []installedSnapName `positional-arg-name:"<snap>"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	addCommand("health", shortHealthHelp, longHealthHelp, func() flags.Commander { return &cmdHealth{} }, timeDescs.also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"history": i18n.G("Show the last health transitions of each snap"),
+	}), nil)
+}
+
+func (x *cmdHealth) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	health, err := x.client.Health(&client.HealthOptions{Snaps: installedSnapNames(x.Positional.Snaps)})
+	if err != nil {
+		return err
+	}
+
+	var inError []string
+	if len(health.Snaps) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("No snaps reported their health."))
+	} else {
+		w := tabWriter()
+		fmt.Fprintln(w, i18n.G("Name\tStatus\tChecked\tCode\tMessage"))
+		for _, report := range health.Snaps {
+			if report.Status == "error" {
+				inError = append(inError, report.Name)
+			}
+			entries := []*client.SnapHealth{&report.SnapHealth}
+			if x.History && len(report.History) > 0 {
+				entries = report.History
+			}
+			for _, h := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", report.Name, h.Status, x.fmtTime(h.Timestamp), orDash(h.Code), orDash(h.Message))
+			}
+		}
+		w.Flush()
+	}
+	fmt.Fprintf(Stdout, i18n.G("Overall status: %s\n"), health.Status)
+
+	if len(inError) > 0 {
+		return fmt.Errorf(i18n.G("health of %s is in error"), strutil.Quoted(inError))
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,140 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+type healthSuite struct {
+	BaseSnapSuite
+}
+
+var _ = check.Suite(&healthSuite{})
+
+const healthInError = `{
+	"type": "sync",
+	"status-code": 200,
+	"result": {
+		"status": "error",
+		"snaps": [
+			{
+				"name": "baz",
+				"revision": "20",
+				"timestamp": "2026-09-19T13:00:00Z",
+				"status": "waiting",
+				"message": "starting up",
+				"history": [
+					{"revision": "20", "timestamp": "2026-09-19T13:00:00Z", "status": "waiting", "message": "starting up"}
+				]
+			},
+			{
+				"name": "foo",
+				"revision": "10",
+				"timestamp": "2026-09-19T13:00:00Z",
+				"status": "error",
+				"message": "database is gone",
+				"code": "no-db",
+				"history": [
+					{"revision": "10", "timestamp": "2026-09-19T12:00:00Z", "status": "okay"},
+					{"revision": "10", "timestamp": "2026-09-19T13:00:00Z", "status": "error", "message": "database is gone", "code": "no-db"}
+				]
+			}
+		]
+	}
+}`
+
+func mkHealthFakeHandler(c *check.C, snaps, body string) func(w http.ResponseWriter, r *http.Request) {
+	var called bool
+	return func(w http.ResponseWriter, r *http.Request) {
+		if called {
+			c.Fatalf("expected a single request")
+		}
+		called = true
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/health")
+		c.Check(r.URL.Query().Get("snaps"), check.Equals, snaps)
+		w.WriteHeader(200)
+		fmt.Fprintln(w, body)
+	}
+}
+
+func (s *healthSuite) TestHealthInError(c *check.C) {
+	s.RedirectClientToTestServer(mkHealthFakeHandler(c, "", healthInError))
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"health", "--abs-time"})
+	c.Assert(err, check.ErrorMatches, `health of "foo" is in error`)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, `
+Name  Status   Checked               Code   Message
+baz   waiting  2026-09-19T13:00:00Z  -      starting up
+foo   error    2026-09-19T13:00:00Z  no-db  database is gone
+Overall status: error
+`[1:])
+}
+
+func (s *healthSuite) TestHealthHistory(c *check.C) {
+	s.RedirectClientToTestServer(mkHealthFakeHandler(c, "foo", healthInError))
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"health", "--abs-time", "--history", "foo"})
+	c.Assert(err, check.ErrorMatches, `health of "foo" is in error`)
+	c.Check(s.Stdout(), check.Equals, `
+Name  Status   Checked               Code   Message
+baz   waiting  2026-09-19T13:00:00Z  -      starting up
+foo   okay     2026-09-19T12:00:00Z  -      -
+foo   error    2026-09-19T13:00:00Z  no-db  database is gone
+Overall status: error
+`[1:])
+}
+
+func (s *healthSuite) TestHealthOkay(c *check.C) {
+	s.RedirectClientToTestServer(mkHealthFakeHandler(c, "", `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"status": "okay",
+			"snaps": [{"name": "foo", "revision": "10", "timestamp": "2026-09-19T12:00:00Z", "status": "okay"}]
+		}
+	}`))
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"health", "--abs-time"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, `
+Name  Status  Checked               Code  Message
+foo   okay    2026-09-19T12:00:00Z  -     -
+Overall status: okay
+`[1:])
+}
+
+func (s *healthSuite) TestHealthNone(c *check.C) {
+	s.RedirectClientToTestServer(mkHealthFakeHandler(c, "", `{"type": "sync", "status-code": 200, "result": {"status": "okay", "snaps": []}}`))
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"health"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stderr(), check.Equals, "No snaps reported their health.\n")
+	c.Check(s.Stdout(), check.Equals, "Overall status: okay\n")
+}
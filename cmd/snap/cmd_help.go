@@ -218,9 +218,10 @@ var helpCategories = []helpCategory{
 		Description: i18n.G("manage services"),
 		Commands:    []string{"services", "start", "stop", "restart", "logs"},
 	}, {
-		Label:       i18n.G("Permissions"),
-		Description: i18n.G("manage permissions"),
-		Commands:    []string{"connections", "interface", "connect", "disconnect"},
+		Label:           i18n.G("Permissions"),
+		Description:     i18n.G("manage permissions"),
+		Commands:        []string{"connections", "interface", "connect", "disconnect"},
+		AllOnlyCommands: []string{"prompting-rules", "prompting-agent"},
 	}, {
 		Label:       i18n.G("Configuration"),
 		Description: i18n.G("system administration and configuration"),
@@ -237,7 +238,7 @@ var helpCategories = []helpCategory{
 		Label:           i18n.G("Snapshots"),
 		Description:     i18n.G("archives of snap data"),
 		Commands:        []string{"saved", "save", "check-snapshot", "restore", "forget"},
-		AllOnlyCommands: []string{"export-snapshot", "import-snapshot"},
+		AllOnlyCommands: []string{"export-snapshot", "import-snapshot", "push-snapshot"},
 	}, {
 		Label:       i18n.G("Device"),
 		Description: i18n.G("manage device"),
@@ -256,7 +257,7 @@ var helpCategories = []helpCategory{
 		Label:           i18n.G("Introspection"),
 		Other:           true,
 		Description:     i18n.G("introspection and debugging of snapd"),
-		Commands:        []string{"version"},
+		Commands:        []string{"version", "health"},
 		AllOnlyCommands: []string{"debug"},
 	}, {
 		Label:           i18n.G("Development"),
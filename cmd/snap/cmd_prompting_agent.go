@@ -0,0 +1,157 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+)
+
+var shortPromptingAgentHelp = i18n.G("Reply to prompting requests from the terminal")
+var longPromptingAgentHelp = i18n.G(`
+The prompting-agent command waits for snaps of the current user to request
+access to resources which require prompting, and asks in the terminal whether
+each request should be allowed or denied. It allows prompts to be answered
+where no graphical prompting client is available, such as on headless servers
+or over SSH.
+
+A request may be allowed or denied only once, or always, in which case a rule
+is created for the requested path and permissions. Requests which are not
+answered before they time out are replied to according to the
+prompting.default-reply system option.
+
+Asking about a request counts as activity of a prompting client, so once the
+prompting-agent has asked about a request, it times out according to the
+prompting.activity-timeout system option (10 minutes by default), rather than
+the prompting.timeout system option.
+`)
+
+type cmdPromptingAgent struct {
+	clientMixin
+}
+
+func init() {
+	addCommand("prompting-agent", shortPromptingAgentHelp, longPromptingAgentHelp, func() flags.Commander {
+		return &cmdPromptingAgent{}
+	}, nil, nil)
+}
+
+func (x *cmdPromptingAgent) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	in := bufio.NewReader(Stdin)
+	opts := &client.NoticesOptions{
+		Types: []client.NoticeType{client.InterfacesRequestsPromptNotice},
+	}
+	fmt.Fprintln(Stdout, i18n.G("Waiting for prompts, press Ctrl+C to stop."))
+	err := x.client.StreamNotices(context.Background(), opts, func(notice *client.Notice) error {
+		if notice.LastData["resolved"] != "" {
+			// The prompt was replied to or has expired
+			return nil
+		}
+		return x.handlePrompt(in, notice.Key)
+	})
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot wait for prompts: %v"), err)
+	}
+	return nil
+}
+
+func isPromptNotFound(err error) bool {
+	var e *client.Error
+	return errors.As(err, &e) && e.Kind == client.ErrorKindInterfacesRequestsPromptNotFound
+}
+
+// handlePrompt asks the user how to reply to the outstanding prompt with the
+// given ID, if there is any, and sends the reply. Failing to retrieve or reply
+// to the prompt is not fatal, as the prompt may still be resolved otherwise.
+func (x *cmdPromptingAgent) handlePrompt(in *bufio.Reader, id string) error {
+	prompt, err := x.client.Prompt(id)
+	if err != nil {
+		if !isPromptNotFound(err) {
+			fmt.Fprintf(Stderr, i18n.G("WARNING: cannot get prompt %s: %v\n"), id, err)
+		}
+		return nil
+	}
+
+	constraints := &prompt.Constraints
+	fmt.Fprintf(Stdout, i18n.G("Snap %q requests %s access to %s (interface %q).\n"), prompt.Snap, strings.Join(constraints.RequestedPermissions, ", "), constraints.Path, prompt.Interface)
+	reply := &client.PromptReply{
+		Constraints: client.PromptReplyConstraints{
+			PathPattern: pathPatternFor(constraints.Path),
+			Permissions: constraints.RequestedPermissions,
+		},
+	}
+	for reply.Outcome == "" {
+		fmt.Fprint(Stdout, i18n.G("Allow once [y], allow always [a], deny once [n], or deny always [d]? "))
+		line, err := in.ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf(i18n.G("cannot read reply: %v"), err)
+		}
+		switch strings.TrimSpace(line) {
+		case "y":
+			reply.Outcome, reply.Lifespan = "allow", "single"
+		case "a":
+			reply.Outcome, reply.Lifespan = "allow", "forever"
+		case "n":
+			reply.Outcome, reply.Lifespan = "deny", "single"
+		case "d":
+			reply.Outcome, reply.Lifespan = "deny", "forever"
+		}
+	}
+
+	if _, err := x.client.ReplyToPrompt(id, reply); err != nil {
+		if isPromptNotFound(err) {
+			fmt.Fprintln(Stdout, i18n.G("The request was resolved in the meantime."))
+		} else {
+			fmt.Fprintf(Stderr, i18n.G("WARNING: cannot reply to prompt %s: %v\n"), id, err)
+		}
+		return nil
+	}
+	if reply.Outcome == "allow" {
+		fmt.Fprintln(Stdout, i18n.G("Allowed."))
+	} else {
+		fmt.Fprintln(Stdout, i18n.G("Denied."))
+	}
+	return nil
+}
+
+// pathPatternFor returns a path pattern which matches exactly the given path,
+// by escaping the characters which have a special meaning in path patterns.
+func pathPatternFor(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(`\*?[]{},`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,151 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+type promptingAgentSuite struct {
+	BaseSnapSuite
+}
+
+var _ = check.Suite(&promptingAgentSuite{})
+
+func promptNoticeEvent(key string, lastData string) string {
+	return fmt.Sprintf("event: notice\ndata: {\"id\":\"%s\",\"type\":\"interfaces-requests-prompt\",\"key\":\"%s\",\"last-data\":%s}\n\n", key, key, lastData)
+}
+
+const agentPrompt = `{
+	"id": "0000000000000003",
+	"timestamp": "2026-10-18T10:00:00Z",
+	"snap": "firefox",
+	"interface": "home",
+	"constraints": {
+		"path": "/home/test/a*b",
+		"requested-permissions": ["read", "write"],
+		"available-permissions": ["read", "write", "execute"]
+	}
+}`
+
+func (s *promptingAgentSuite) TestPromptingAgent(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		switch n {
+		case 1:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/notices")
+			c.Check(r.URL.Query().Get("types"), check.Equals, "interfaces-requests-prompt")
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(200)
+			fmt.Fprint(w, promptNoticeEvent("0000000000000001", `{"resolved":"replied"}`))
+			fmt.Fprint(w, promptNoticeEvent("0000000000000002", `{}`))
+			fmt.Fprint(w, promptNoticeEvent("0000000000000003", `{}`))
+		case 2:
+			// The prompt was resolved before the agent got to it
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/interfaces/requests/prompts/0000000000000002")
+			w.WriteHeader(404)
+			fmt.Fprintln(w, `{"type": "error", "status-code": 404, "result": {"message": "cannot find prompt with the given ID for the given user", "kind": "interfaces-requests-prompt-not-found"}}`)
+		case 3:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/interfaces/requests/prompts/0000000000000003")
+			w.WriteHeader(200)
+			fmt.Fprintf(w, `{"type": "sync", "status-code": 200, "result": %s}`, agentPrompt)
+		case 4:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/interfaces/requests/prompts/0000000000000003")
+			body, err := io.ReadAll(r.Body)
+			c.Check(err, check.IsNil)
+			c.Check(string(body), check.Equals, `{"action":"allow","lifespan":"forever","constraints":{"path-pattern":"/home/test/a\\*b","permissions":["read","write"]}}`)
+			w.WriteHeader(200)
+			fmt.Fprintln(w, `{"type": "sync", "status-code": 200, "result": ["0000000000000003"]}`)
+		default:
+			c.Fatalf("unexpected request %d", n)
+		}
+	})
+
+	s.stdin.Write([]byte("x\na\n"))
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-agent"})
+	// The notices stream ends with the response of the test server
+	c.Check(err, check.ErrorMatches, "cannot wait for prompts: cannot stream notices: unexpected EOF")
+	c.Check(n, check.Equals, 4)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, `Waiting for prompts, press Ctrl+C to stop.
+Snap "firefox" requests read, write access to /home/test/a*b (interface "home").
+Allow once [y], allow always [a], deny once [n], or deny always [d]? Allow once [y], allow always [a], deny once [n], or deny always [d]? Allowed.
+`)
+}
+
+func (s *promptingAgentSuite) TestPromptingAgentReplyError(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		switch n {
+		case 1:
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(200)
+			fmt.Fprint(w, promptNoticeEvent("0000000000000003", `{}`))
+		case 2:
+			w.WriteHeader(200)
+			fmt.Fprintf(w, `{"type": "sync", "status-code": 200, "result": %s}`, agentPrompt)
+		case 3:
+			c.Check(r.Method, check.Equals, "POST")
+			w.WriteHeader(400)
+			fmt.Fprintln(w, `{"type": "error", "status-code": 400, "result": {"message": "invalid reply", "kind": "interfaces-requests-invalid-fields"}}`)
+		default:
+			c.Fatalf("unexpected request %d", n)
+		}
+	})
+
+	s.stdin.Write([]byte("n\n"))
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-agent"})
+	c.Check(err, check.ErrorMatches, "cannot wait for prompts: cannot stream notices: unexpected EOF")
+	c.Check(n, check.Equals, 3)
+	c.Check(s.Stderr(), check.Equals, "WARNING: cannot reply to prompt 0000000000000003: invalid reply\n")
+}
+
+func (s *promptingAgentSuite) TestPromptingAgentStdinClosed(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		switch n {
+		case 1:
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(200)
+			fmt.Fprint(w, promptNoticeEvent("0000000000000003", `{}`))
+		case 2:
+			w.WriteHeader(200)
+			fmt.Fprintf(w, `{"type": "sync", "status-code": 200, "result": %s}`, agentPrompt)
+		default:
+			c.Fatalf("unexpected request %d", n)
+		}
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-agent"})
+	c.Check(err, check.ErrorMatches, "cannot wait for prompts: cannot read reply: EOF")
+}
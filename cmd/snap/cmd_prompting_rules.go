@@ -0,0 +1,134 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+var shortPromptingRulesHelp = i18n.G("Export or import prompting rules")
+var longPromptingRulesHelp = i18n.G(`
+The prompting-rules command exports the prompting rules of the current user
+to a file, or imports rules from a file previously exported, possibly by
+another user or on another system.
+
+With "export", the rules are written to the given file, or to standard output
+if no file is given. The rules are exported in a stable JSON format.
+
+With "import", the rules are read from the given file, or from standard input
+if no file is given or the file is "-". Either all rules are imported, or none
+of them if any is invalid or conflicts with an existing rule.
+
+Rules exported and imported by root are provisioned by an administrator: they
+apply to all users, take precedence over the rules of users, and cannot be
+modified or removed by them.
+
+Files of exported rules are not signed, so only import files from a trusted
+source.
+`)
+
+type cmdPromptingRules struct {
+	clientMixin
+	Positional struct {
+		Action string         `positional-arg-name:"<action>" required:"yes"`
+		File   flags.Filename `positional-arg-name:"<file>"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	addCommand("prompting-rules", shortPromptingRulesHelp, longPromptingRulesHelp, func() flags.Commander {
+		return &cmdPromptingRules{}
+	}, nil, []argDesc{{
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<action>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("Either export or import"),
+	}, {
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<file>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("File to export rules to or import rules from"),
+	}})
+}
+
+func (x *cmdPromptingRules) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	switch x.Positional.Action {
+	case "export":
+		return x.exportRules(string(x.Positional.File))
+	case "import":
+		return x.importRules(string(x.Positional.File))
+	default:
+		return fmt.Errorf(i18n.G("unknown action %q, must be export or import"), x.Positional.Action)
+	}
+}
+
+func (x *cmdPromptingRules) exportRules(path string) error {
+	export, err := x.client.ExportPromptingRules()
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot export prompting rules: %v"), err)
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, export, "", "  "); err != nil {
+		return fmt.Errorf(i18n.G("cannot export prompting rules: %v"), err)
+	}
+	buf.WriteByte('\n')
+
+	if path == "" || path == "-" {
+		_, err = Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
+
+func (x *cmdPromptingRules) importRules(path string) error {
+	var data []byte
+	var err error
+	if path == "" || path == "-" {
+		data, err = io.ReadAll(Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot read prompting rules: %v"), err)
+	}
+	if !json.Valid(data) {
+		return errors.New(i18n.G("cannot import prompting rules: invalid JSON"))
+	}
+
+	n, err := x.client.ImportPromptingRules(json.RawMessage(data))
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot import prompting rules: %v"), err)
+	}
+	fmt.Fprintf(Stdout, i18n.NG("Imported %d prompting rule.\n", "Imported %d prompting rules.\n", n), n)
+	return nil
+}
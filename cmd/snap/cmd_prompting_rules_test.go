@@ -0,0 +1,162 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+type promptingRulesSuite struct {
+	BaseSnapSuite
+}
+
+var _ = check.Suite(&promptingRulesSuite{})
+
+const promptingRulesExport = `{"version":1,"rules":[{"snap":"firefox","interface":"home","constraints":{"path-pattern":"/home/*/.ssh/**","permissions":{"read":{"outcome":"deny","lifespan":"forever"}}}}]}`
+
+const promptingRulesExportIndented = `{
+  "version": 1,
+  "rules": [
+    {
+      "snap": "firefox",
+      "interface": "home",
+      "constraints": {
+        "path-pattern": "/home/*/.ssh/**",
+        "permissions": {
+          "read": {
+            "outcome": "deny",
+            "lifespan": "forever"
+          }
+        }
+      }
+    }
+  ]
+}
+`
+
+func mkPromptingRulesExportHandler(c *check.C) func(w http.ResponseWriter, r *http.Request) {
+	var called bool
+	return func(w http.ResponseWriter, r *http.Request) {
+		if called {
+			c.Fatalf("expected a single request")
+		}
+		called = true
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/interfaces/requests/rules")
+		c.Check(r.URL.Query().Get("export"), check.Equals, "true")
+		w.WriteHeader(200)
+		fmt.Fprintf(w, `{"type": "sync", "status-code": 200, "result": %s}`, promptingRulesExport)
+	}
+}
+
+func mkPromptingRulesImportHandler(c *check.C, result string) func(w http.ResponseWriter, r *http.Request) {
+	var called bool
+	return func(w http.ResponseWriter, r *http.Request) {
+		if called {
+			c.Fatalf("expected a single request")
+		}
+		called = true
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/interfaces/requests/rules")
+		body, err := io.ReadAll(r.Body)
+		c.Check(err, check.IsNil)
+		c.Check(string(body), check.Equals, fmt.Sprintf(`{"action":"import","import":%s}`, promptingRulesExport))
+		w.WriteHeader(200)
+		fmt.Fprintf(w, `{"type": "sync", "status-code": 200, "result": %s}`, result)
+	}
+}
+
+func (s *promptingRulesSuite) TestExportStdout(c *check.C) {
+	s.RedirectClientToTestServer(mkPromptingRulesExportHandler(c))
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "export"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, promptingRulesExportIndented)
+}
+
+func (s *promptingRulesSuite) TestExportFile(c *check.C) {
+	s.RedirectClientToTestServer(mkPromptingRulesExportHandler(c))
+
+	path := filepath.Join(c.MkDir(), "rules.json")
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "export", path})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	data, err := os.ReadFile(path)
+	c.Assert(err, check.IsNil)
+	c.Check(string(data), check.Equals, promptingRulesExportIndented)
+}
+
+func (s *promptingRulesSuite) TestImportFile(c *check.C) {
+	s.RedirectClientToTestServer(mkPromptingRulesImportHandler(c, `[{"id": "0000000000000001"}]`))
+
+	path := filepath.Join(c.MkDir(), "rules.json")
+	c.Assert(os.WriteFile(path, []byte(promptingRulesExportIndented), 0o644), check.IsNil)
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import", path})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, "Imported 1 prompting rule.\n")
+}
+
+func (s *promptingRulesSuite) TestImportStdin(c *check.C) {
+	s.RedirectClientToTestServer(mkPromptingRulesImportHandler(c, `[{"id": "0000000000000001"}, {"id": "0000000000000002"}]`))
+
+	s.stdin.Write([]byte(promptingRulesExport))
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import", "-"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "Imported 2 prompting rules.\n")
+}
+
+func (s *promptingRulesSuite) TestImportError(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		fmt.Fprintln(w, `{"type": "error", "status-code": 400, "result": {"message": "cannot import rule 0: conflict", "kind": "interfaces-requests-rule-conflict"}}`)
+	})
+
+	s.stdin.Write([]byte(promptingRulesExport))
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import"})
+	c.Assert(err, check.ErrorMatches, "cannot import prompting rules: cannot import rule 0: conflict")
+	c.Check(s.Stdout(), check.Equals, "")
+}
+
+func (s *promptingRulesSuite) TestErrors(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request")
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "frobnicate"})
+	c.Check(err, check.ErrorMatches, `unknown action "frobnicate", must be export or import`)
+
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import", filepath.Join(c.MkDir(), "missing")})
+	c.Check(err, check.ErrorMatches, "cannot read prompting rules: .*no such file or directory")
+
+	s.stdin.Write([]byte(`{"version":`))
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import"})
+	c.Check(err, check.ErrorMatches, "cannot import prompting rules: invalid JSON")
+}
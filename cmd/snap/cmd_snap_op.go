@@ -96,6 +96,16 @@ channels of a snap are listed in its 'snap info' output.
 When --revision is used, a later refresh will typically undo the revision
 override.
 
+With --snapshot, the data of the snaps is saved in an automatic snapshot
+before they are refreshed, which 'snap revert --with-data' can then restore.
+The snapshots.pre-refresh system option lists the snaps for which this is
+always done.
+
+With --dry-run, the snaps that would be refreshed are shown along with their
+download sizes, the prerequisites that would be installed, whether a restart
+would be needed, and the tasks the refresh would be made of. Nothing is
+refreshed.
+
 Hold (--hold) is used to postpone snap refresh updates for all snaps when no
 snaps are specified, or for the specified snaps.
 
@@ -920,9 +930,11 @@ type cmdRefresh struct {
 	Time             bool                   `long:"time"`
 	IgnoreValidation bool                   `long:"ignore-validation"`
 	IgnoreRunning    bool                   `long:"ignore-running" hidden:"yes"`
+	Snapshot         bool                   `long:"snapshot"`
 	Transaction      client.TransactionType `long:"transaction" default:"per-snap" choice:"all-snaps" choice:"per-snap"`
 	Hold             string                 `long:"hold" optional:"yes" optional-value:"forever"`
 	Unhold           bool                   `long:"unhold"`
+	DryRun           bool                   `long:"dry-run"`
 	Positional       struct {
 		Snaps []installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
@@ -1073,6 +1085,44 @@ func (x *cmdRefresh) listRefresh() error {
 	return nil
 }
 
+func (x *cmdRefresh) showRefreshPlan(snaps []string, opts *client.SnapOptions) error {
+	const forInstall = true
+	names, compsBySnap, err := snapInstancesAndComponentsFromNames(snaps, forInstall)
+	if err != nil {
+		return err
+	}
+
+	plan, err := x.client.RefreshPlan(names, compsBySnap, opts)
+	if err != nil {
+		return err
+	}
+	if len(plan.Snaps) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("All snaps up to date."))
+		return nil
+	}
+
+	fmt.Fprintf(Stdout, "%s\n\n", plan.Summary)
+
+	w := tabWriter()
+	var total int64
+	fmt.Fprintln(w, i18n.G("Name\tRev\tSize\tRestart\tPrerequisites"))
+	for _, snap := range plan.Snaps {
+		total += snap.DownloadSize
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", snap.Name, snap.Revision, strutil.SizeToStr(snap.DownloadSize), orDash(snap.Restart), orDash(strings.Join(snap.Prerequisites, ",")))
+	}
+	w.Flush()
+	fmt.Fprintf(Stdout, i18n.G("\nTotal download size: %s\n\n"), strutil.SizeToStr(total))
+
+	w = tabWriter()
+	fmt.Fprintln(w, i18n.G("ID\tSnap\tKind\tWaits for\tSummary"))
+	for _, t := range plan.Tasks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.ID, orDash(t.Snap), t.Kind, orDash(strings.Join(t.WaitFor, ",")), t.Summary)
+	}
+	w.Flush()
+
+	return nil
+}
+
 func (x *cmdRefresh) Execute([]string) error {
 	if err := x.setChannelFromCommandline(); err != nil {
 		return err
@@ -1103,7 +1153,7 @@ func (x *cmdRefresh) Execute([]string) error {
 
 	otherFlags := x.Amend || x.Revision != "" || x.Cohort != "" ||
 		x.LeaveCohort || x.List || x.Time || x.IgnoreValidation || x.IgnoreRunning ||
-		x.Transaction != client.TransactionPerSnap
+		x.Snapshot || x.Transaction != client.TransactionPerSnap || x.DryRun
 
 	if x.Hold != "" && (x.Unhold || otherFlags) {
 		return errors.New(i18n.G("cannot use --hold with other flags"))
@@ -1116,6 +1166,17 @@ func (x *cmdRefresh) Execute([]string) error {
 	}
 
 	names := installedSnapNames(x.Positional.Snaps)
+	if x.DryRun {
+		if x.Amend || x.Revision != "" || x.Cohort != "" || x.LeaveCohort || x.IgnoreValidation || x.asksForMode() || x.asksForChannel() {
+			return errors.New(i18n.G("--dry-run can only be combined with --transaction, --ignore-running and --snapshot"))
+		}
+		opts := &client.SnapOptions{
+			IgnoreRunning:         x.IgnoreRunning,
+			Transaction:           x.Transaction,
+			SnapshotBeforeRefresh: x.Snapshot,
+		}
+		return x.showRefreshPlan(names, opts)
+	}
 	if len(names) == 1 {
 		opts := &client.SnapOptions{
 			Amend:            x.Amend,
@@ -1126,15 +1187,18 @@ func (x *cmdRefresh) Execute([]string) error {
 			CohortKey:        x.Cohort,
 			LeaveCohort:      x.LeaveCohort,
 			Transaction:      x.Transaction,
+
+			SnapshotBeforeRefresh: x.Snapshot,
 		}
 		x.setModes(opts)
 		return x.refreshOne(names[0], opts)
 	}
-	// transaction flag and ignore-running flags are the only ones with meaning when
-	// refreshing many snaps
+	// transaction, ignore-running and snapshot flags are the only ones
+	// with meaning when refreshing many snaps
 	opts := &client.SnapOptions{
-		IgnoreRunning: x.IgnoreRunning,
-		Transaction:   x.Transaction,
+		IgnoreRunning:         x.IgnoreRunning,
+		Transaction:           x.Transaction,
+		SnapshotBeforeRefresh: x.Snapshot,
 	}
 
 	if x.asksForMode() || x.asksForChannel() {
@@ -1388,6 +1452,7 @@ type cmdRevert struct {
 	modeMixin
 	Revision      string `long:"revision"`
 	IgnoreRunning bool   `long:"ignore-running" hidden:"yes"`
+	WithData      bool   `long:"with-data"`
 	Positional    struct {
 		Snap installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes" required:"yes"`
@@ -1401,6 +1466,10 @@ and will use the original data that was associated with that revision,
 discarding any data changes that were done by the latest revision. As
 an exception, data which the snap explicitly chooses to share across
 revisions is not touched by the revert process.
+
+With --with-data, the data of the snap is also restored from the snapshot
+taken before refreshing from the revision reverted to, if any was taken
+(see 'snap refresh --snapshot'), including the data shared across revisions.
 `)
 
 func (x *cmdRevert) Execute(args []string) error {
@@ -1416,6 +1485,7 @@ func (x *cmdRevert) Execute(args []string) error {
 	opts := &client.SnapOptions{
 		Revision:      x.Revision,
 		IgnoreRunning: x.IgnoreRunning,
+		WithData:      x.WithData,
 	}
 	x.setModes(opts)
 	changeID, err := x.client.Revert(name, opts)
@@ -1548,6 +1618,8 @@ func init() {
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"ignore-running": i18n.G("Ignore running hooks or applications blocking the refresh"),
 			// TRANSLATORS: This should not start with a lowercase letter.
+			"snapshot": i18n.G("Take a snapshot of the data of the snaps before refreshing them, for revert --with-data"),
+			// TRANSLATORS: This should not start with a lowercase letter.
 			"cohort": i18n.G("Refresh the snap into the given cohort"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"leave-cohort": i18n.G("Refresh the snap out of its cohort"),
@@ -1557,6 +1629,8 @@ func init() {
 			"hold": i18n.G("Hold refreshes for a specified duration (or forever, if no value is specified)"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"unhold": i18n.G("Remove refresh hold"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"dry-run": i18n.G("Show what refreshing would do, without refreshing"),
 		}), nil)
 	addCommand("try", shortTryHelp, longTryHelp, func() flags.Commander { return &cmdTry{} }, waitDescs.also(modeDescs), nil)
 	addCommand("enable", shortEnableHelp, longEnableHelp, func() flags.Commander { return &cmdEnable{} }, waitDescs, nil)
@@ -1566,6 +1640,8 @@ func init() {
 		"revision": i18n.G("Revert to the given revision"),
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"ignore-running": i18n.G("Ignore running hooks or applications blocking the revert"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"with-data": i18n.G("Also restore the data saved before refreshing from the revision reverted to"),
 	}), nil)
 	addCommand("switch", shortSwitchHelp, longSwitchHelp, func() flags.Commander { return &cmdSwitch{} }, waitDescs.also(channelDescs).also(map[string]string{
 		// TRANSLATORS: This should not start with a lowercase letter.
@@ -1801,6 +1801,25 @@ func (s *SnapOpSuite) TestRevertClassic(c *check.C) {
 	s.runRevertTest(c, &client.SnapOptions{Classic: true})
 }
 
+func (s *SnapOpSuite) TestRevertWithData(c *check.C) {
+	s.srv.checker = func(r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps/foo")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action":    "revert",
+			"with-data": true,
+		})
+	}
+
+	s.RedirectClientToTestServer(s.srv.handle)
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"revert", "--with-data", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, "foo reverted to 1.0\n")
+	c.Check(s.Stderr(), check.Equals, "")
+	// ensure that the fake server api was actually hit
+	c.Check(s.srv.n, check.Equals, s.srv.total)
+}
+
 func (s *SnapOpSuite) TestRevertMissingName(c *check.C) {
 	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"revert"})
 	c.Assert(err, check.NotNil)
@@ -2639,6 +2658,38 @@ func (s *SnapOpSuite) TestRefreshManyIgnoreRunning(c *check.C) {
 	c.Assert(err, check.IsNil)
 }
 
+func (s *SnapOpSuite) TestRefreshOneSnapshot(c *check.C) {
+	s.RedirectClientToTestServer(s.srv.handle)
+	s.srv.checker = func(r *http.Request) {
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps/foo")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action":                  "refresh",
+			"snapshot-before-refresh": true,
+			"transaction":             string(client.TransactionPerSnap),
+		})
+	}
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--snapshot", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Matches, `(?sm).*foo 1.0 from Bar refreshed`)
+}
+
+func (s *SnapOpSuite) TestRefreshManySnapshot(c *check.C) {
+	s.RedirectClientToTestServer(s.srv.handle)
+	s.srv.checker = func(r *http.Request) {
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action":                  "refresh",
+			"snaps":                   []interface{}{"one", "two"},
+			"snapshot-before-refresh": true,
+			"transaction":             "per-snap",
+		})
+	}
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--snapshot", "one", "two"})
+	c.Assert(err, check.IsNil)
+}
+
 func (s *SnapOpSuite) TestRefreshManyChannel(c *check.C) {
 	s.RedirectClientToTestServer(nil)
 	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--beta", "one", "two"})
@@ -2651,6 +2702,84 @@ func (s *SnapOpSuite) TestRefreshManyIgnoreValidation(c *check.C) {
 	c.Assert(err, check.ErrorMatches, `a single snap name must be specified when ignoring validation`)
 }
 
+func (s *SnapSuite) TestRefreshDryRun(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+			c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+				"action":      "refresh",
+				"snaps":       []interface{}{"foo"},
+				"transaction": "per-snap",
+				"dry-run":     true,
+			})
+			fmt.Fprintln(w, `{"type": "sync", "status-code": 200, "result": {
+"summary": "Refresh snap \"foo\"",
+"snaps": [{"name": "foo", "type": "app", "revision": "7", "download-size": 1000000, "prerequisites": ["core22"]},
+          {"name": "pc-kernel", "type": "kernel", "revision": "12", "download-size": 2000000, "restart": "system"}],
+"tasks": [{"id": "1", "kind": "prerequisites", "summary": "Ensure prerequisites for \"foo\"", "snap": "foo"},
+          {"id": "2", "kind": "download-snap", "summary": "Download snap \"foo\"", "snap": "foo", "wait-for": ["1"]},
+          {"id": "3", "kind": "check-rerefresh", "summary": "Monitor refresh", "wait-for": ["1", "2"]}]
+}}`)
+		default:
+			c.Fatalf("expected to get 1 request, now on %d", n+1)
+		}
+		n++
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--dry-run", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `Refresh snap "foo"
+
+Name       Rev  Size  Restart  Prerequisites
+foo        7    1MB   -        core22
+pc-kernel  12   2MB   system   -
+
+Total download size: 3MB
+
+ID   Snap  Kind             Waits for  Summary
+1    foo   prerequisites    -          Ensure prerequisites for "foo"
+2    foo   download-snap    1          Download snap "foo"
+3    -     check-rerefresh  1,2        Monitor refresh
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(n, check.Equals, 1)
+}
+
+func (s *SnapSuite) TestRefreshDryRunNoUpdates(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "status-code": 200, "result": {"summary": "Refresh all snaps: no updates"}}`)
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--dry-run"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "All snaps up to date.\n")
+}
+
+func (s *SnapSuite) TestRefreshDryRunUnsupportedFlags(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatal("expected to get 0 requests")
+	})
+
+	for _, args := range [][]string{
+		{"--beta", "foo"},
+		{"--revision=7", "foo"},
+		{"--amend", "foo"},
+		{"--ignore-validation", "foo"},
+		{"--classic", "foo"},
+	} {
+		_, err := snap.Parser(snap.Client()).ParseArgs(append([]string{"refresh", "--dry-run"}, args...))
+		c.Check(err, check.ErrorMatches, "--dry-run can only be combined with --transaction, --ignore-running and --snapshot")
+	}
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--dry-run", "--hold"})
+	c.Check(err, check.ErrorMatches, "cannot use --hold with other flags")
+}
+
 func (s *SnapOpSuite) TestRefreshAllModeFlags(c *check.C) {
 	s.RedirectClientToTestServer(nil)
 	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--devmode"})
@@ -28,6 +28,7 @@ import (
 
 	"github.com/jessevdk/go-flags"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/strutil/quantity"
@@ -45,6 +46,7 @@ var (
 	shortRestoreHelp        = i18n.G("Restore a snapshot")
 	shortExportSnapshotHelp = i18n.G("Export a snapshot")
 	shortImportSnapshotHelp = i18n.G("Import a snapshot")
+	shortPushSnapshotHelp   = i18n.G("Push a snapshot to the remote target")
 )
 
 var longSavedHelp = i18n.G(`
@@ -109,18 +111,84 @@ Export a snapshot to the given filename.
 var longImportSnapshotHelp = i18n.G(`
 Import an exported snapshot set to the system. The snapshot is imported
 with a new snapshot ID and can be restored using the restore command.
+
+With --remote, the given name is that of an export on the remote
+snapshot target (see 'snap saved --remote').
+`)
+
+var longPushSnapshotHelp = i18n.G(`
+The push-snapshot command pushes an export of the given snapshot set to
+the remote snapshot target configured with snapshots.remote.target.
 `)
 
+// passphraseMixin adds the --passphrase-file option to snapshot commands
+// dealing with snapshot sets protected by a passphrase.
+type passphraseMixin struct {
+	PassphraseFile flags.Filename `long:"passphrase-file"`
+}
+
+var passphraseDescs = mixinDescs{
+	// TRANSLATORS: This should not start with a lowercase letter.
+	"passphrase-file": i18n.G("Read the passphrase protecting the snapshot from the given file"),
+}
+
+func (x passphraseMixin) keyOptions() (*client.SnapshotKeyOptions, error) {
+	if x.PassphraseFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(string(x.PassphraseFile))
+	if err != nil {
+		return nil, fmt.Errorf(i18n.G("cannot read passphrase: %v"), err)
+	}
+	passphrase := strings.TrimRight(string(data), "\r\n")
+	if passphrase == "" {
+		return nil, fmt.Errorf(i18n.G("cannot use empty passphrase from %q"), x.PassphraseFile)
+	}
+	return &client.SnapshotKeyOptions{Passphrase: passphrase}, nil
+}
+
 type savedCmd struct {
 	clientMixin
 	durationMixin
 	ID         snapshotID `long:"id"`
+	Remote     bool       `long:"remote"`
 	Positional struct {
 		Snaps []installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
 }
 
+func (x *savedCmd) listRemote() error {
+	if x.ID != "" || len(x.Positional.Snaps) > 0 {
+		return fmt.Errorf(i18n.G("cannot use --remote with a snapshot id or snaps"))
+	}
+	exports, err := x.client.RemoteSnapshots()
+	if err != nil {
+		return err
+	}
+	if len(exports) == 0 {
+		fmt.Fprintln(Stdout, i18n.G("No remote snapshots found."))
+		return nil
+	}
+
+	w := tabWriter()
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\n",
+		i18n.G("Name"),
+		// TRANSLATORS: 'Age' as in how old something is
+		i18n.G("Age"),
+		i18n.G("Size"))
+	for _, export := range exports {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", export.Name, x.fmtDuration(export.Time), fmtSize(export.Size))
+	}
+	return nil
+}
+
 func (x *savedCmd) Execute([]string) error {
+	if x.Remote {
+		return x.listRemote()
+	}
+
 	var setID uint64
 	var err error
 	if x.ID != "" {
@@ -160,6 +228,9 @@ func (x *savedCmd) Execute([]string) error {
 			if sh.Auto {
 				notes = append(notes, "auto")
 			}
+			if sh.Encryption != nil {
+				notes = append(notes, "encrypted")
+			}
 			if sh.Broken != "" {
 				notes = append(notes, "broken: "+sh.Broken)
 			}
@@ -177,6 +248,7 @@ func (x *savedCmd) Execute([]string) error {
 
 type saveCmd struct {
 	waitMixin
+	passphraseMixin
 	durationMixin
 	Users      string `long:"users"`
 	Positional struct {
@@ -187,7 +259,11 @@ type saveCmd struct {
 func (x *saveCmd) Execute([]string) error {
 	snaps := installedSnapNames(x.Positional.Snaps)
 	users := strutil.CommaSeparatedList(x.Users)
-	setID, changeID, err := x.client.SnapshotMany(snaps, users)
+	opts, err := x.keyOptions()
+	if err != nil {
+		return err
+	}
+	setID, changeID, err := x.client.SnapshotMany(snaps, users, opts)
 	if err != nil {
 		return err
 	}
@@ -243,6 +319,7 @@ func (x *forgetCmd) Execute([]string) error {
 
 type checkSnapshotCmd struct {
 	waitMixin
+	passphraseMixin
 	Users      string `long:"users"`
 	Positional struct {
 		ID    snapshotID          `positional-arg-name:"<id>"`
@@ -257,7 +334,11 @@ func (x *checkSnapshotCmd) Execute([]string) error {
 	}
 	snaps := installedSnapNames(x.Positional.Snaps)
 	users := strutil.CommaSeparatedList(x.Users)
-	changeID, err := x.client.CheckSnapshots(setID, snaps, users)
+	opts, err := x.keyOptions()
+	if err != nil {
+		return err
+	}
+	changeID, err := x.client.CheckSnapshots(setID, snaps, users, opts)
 	if err != nil {
 		return err
 	}
@@ -282,6 +363,7 @@ func (x *checkSnapshotCmd) Execute([]string) error {
 
 type restoreCmd struct {
 	waitMixin
+	passphraseMixin
 	Users      string `long:"users"`
 	Positional struct {
 		ID    snapshotID          `positional-arg-name:"<id>"`
@@ -296,7 +378,11 @@ func (x *restoreCmd) Execute([]string) error {
 	}
 	snaps := installedSnapNames(x.Positional.Snaps)
 	users := strutil.CommaSeparatedList(x.Users)
-	changeID, err := x.client.RestoreSnapshots(setID, snaps, users)
+	opts, err := x.keyOptions()
+	if err != nil {
+		return err
+	}
+	changeID, err := x.client.RestoreSnapshots(setID, snaps, users, opts)
 	if err != nil {
 		return err
 	}
@@ -329,6 +415,8 @@ func init() {
 		durationDescs.also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"id": i18n.G("Show only a specific snapshot."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"remote": i18n.G("Show the snapshots on the remote target instead"),
 		}),
 		nil)
 
@@ -337,7 +425,7 @@ func init() {
 		longSaveHelp,
 		func() flags.Commander {
 			return &saveCmd{}
-		}, durationDescs.also(waitDescs).also(map[string]string{
+		}, durationDescs.also(waitDescs).also(passphraseDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"users": i18n.G("Snapshot data of only specific users (comma-separated) (default: all users)"),
 		}), nil)
@@ -347,7 +435,7 @@ func init() {
 		longRestoreHelp,
 		func() flags.Commander {
 			return &restoreCmd{}
-		}, waitDescs.also(map[string]string{
+		}, waitDescs.also(passphraseDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"users": i18n.G("Restore data of only specific users (comma-separated) (default: all users)"),
 		}), []argDesc{
@@ -384,7 +472,7 @@ func init() {
 		longCheckHelp,
 		func() flags.Commander {
 			return &checkSnapshotCmd{}
-		}, waitDescs.also(map[string]string{
+		}, waitDescs.also(passphraseDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"users": i18n.G("Check data of only specific users (comma-separated) (default: all users)"),
 		}), []argDesc{
@@ -423,13 +511,57 @@ func init() {
 		longImportSnapshotHelp,
 		func() flags.Commander {
 			return &importSnapshotCmd{}
-		}, nil, []argDesc{
+		}, durationDescs.also(passphraseDescs).also(map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"remote": i18n.G("Import the export of the given name from the remote target"),
+		}), []argDesc{
 			{
 				name: "<filename>",
 				// TRANSLATORS: This should not start with a lowercase letter.
 				desc: i18n.G("Name of the snapshot export file to use"),
 			},
 		})
+
+	addCommand("push-snapshot",
+		shortPushSnapshotHelp,
+		longPushSnapshotHelp,
+		func() flags.Commander {
+			return &pushSnapshotCmd{}
+		}, waitDescs, []argDesc{
+			{
+				name: "<id>",
+				// TRANSLATORS: This should not start with a lowercase letter.
+				desc: i18n.G("Set id of snapshot to push (see 'snap help saved')"),
+			},
+		})
+}
+
+type pushSnapshotCmd struct {
+	waitMixin
+	Positional struct {
+		ID snapshotID `positional-arg-name:"<id>"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func (x *pushSnapshotCmd) Execute([]string) error {
+	setID, err := x.Positional.ID.ToUint()
+	if err != nil {
+		return err
+	}
+	changeID, err := x.client.PushSnapshots(setID)
+	if err != nil {
+		return err
+	}
+	_, err = x.wait(changeID)
+	if err == noWait {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(Stdout, i18n.G("Pushed snapshot #%s to the remote target.\n"), x.Positional.ID)
+	return nil
 }
 
 type exportSnapshotCmd struct {
@@ -488,24 +620,39 @@ func (x *exportSnapshotCmd) Execute([]string) (err error) {
 type importSnapshotCmd struct {
 	clientMixin
 	durationMixin
+	passphraseMixin
+	Remote     bool `long:"remote"`
 	Positional struct {
 		Filename string `long:"filename"`
 	} `positional-args:"yes" required:"yes"`
 }
 
-func (x *importSnapshotCmd) Execute([]string) error {
+func (x *importSnapshotCmd) importFile(opts *client.SnapshotKeyOptions) (client.SnapshotImportSet, error) {
 	filename := x.Positional.Filename
 	f, err := os.Open(filename)
 	if err != nil {
-		return fmt.Errorf("error accessing file: %v", err)
+		return client.SnapshotImportSet{}, fmt.Errorf("error accessing file: %v", err)
 	}
 	defer f.Close()
 	st, err := f.Stat()
 	if err != nil {
-		return fmt.Errorf("cannot stat file: %v", err)
+		return client.SnapshotImportSet{}, fmt.Errorf("cannot stat file: %v", err)
 	}
 
-	importSet, err := x.client.SnapshotImport(f, st.Size())
+	return x.client.SnapshotImport(f, st.Size(), opts)
+}
+
+func (x *importSnapshotCmd) Execute([]string) error {
+	opts, err := x.keyOptions()
+	if err != nil {
+		return err
+	}
+	var importSet client.SnapshotImportSet
+	if x.Remote {
+		importSet, err = x.client.RemoteSnapshotImport(x.Positional.Filename, opts)
+	} else {
+		importSet, err = x.importFile(opts)
+	}
 	if err != nil {
 		return err
 	}
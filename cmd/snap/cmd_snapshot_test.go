@@ -20,6 +20,7 @@
 package main_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
@@ -47,6 +48,9 @@ var snapshotsTests = []getCmdArgs{{
 }, {
 	args:   "saved",
 	stdout: "Set  Snap  Age    Version  Rev   Size    Notes\n1    htop  .*  2        1168      1B  -\n",
+}, {
+	args:   "saved --id=5",
+	stdout: "Set  Snap  Age    Version  Rev   Size    Notes\n5    htop  .*  2        1168      1B  encrypted\n",
 }, {
 	args:  "forget x",
 	error: `invalid argument for snapshot set id: expected a non-negative integer argument \(see 'snap help saved'\)`,
@@ -68,6 +72,18 @@ var snapshotsTests = []getCmdArgs{{
 }, {
 	args:   "check-snapshot 4 snap1 snap2",
 	stdout: "Snapshot #4 of snaps \"snap1\", \"snap2\" verified successfully.\n",
+}, {
+	args:   "push-snapshot 4",
+	stdout: "Pushed snapshot #4 to the remote target.\n",
+}, {
+	args:  "push-snapshot x",
+	error: `invalid argument for snapshot set id: expected a non-negative integer argument \(see 'snap help saved'\)`,
+}, {
+	args:   "saved --remote",
+	stdout: "Name          Age    Size\n1_x.snapshot  .*  1B\n",
+}, {
+	args:  "saved --remote --id=1",
+	error: "cannot use --remote with a snapshot id or snaps",
 }, {
 	args:  "export-snapshot x snapshot-export.snapshot",
 	error: `invalid argument for snapshot set id: expected a non-negative integer argument \(see 'snap help saved'\)`,
@@ -120,6 +136,10 @@ func (s *SnapSuite) mockSnapshotsServer(c *C) {
 			if r.Method == "GET" {
 				// simulate a 1-month old snapshot
 				snapshotTime := time.Now().AddDate(0, -1, 0).Format(time.RFC3339)
+				if r.URL.Query().Get("set") == "5" {
+					fmt.Fprintf(w, `{"type":"sync","status-code":200,"status":"OK","result":[{"id":5,"snapshots":[{"set":5,"time":%q,"snap":"htop","revision":"1168","snap-id":"Z","epoch":{"read":[0],"write":[0]},"summary":"","version":"2","sha3-384":{"archive.tgz":""},"size":1,"encryption":{"key":"device","wrapped-key":"a2V5"}}]}]}`, snapshotTime)
+					return
+				}
 				if r.URL.Query().Get("set") == "3" {
 					fmt.Fprintf(w, `{"type":"sync","status-code":200,"status":"OK","result":[{"id":3,"snapshots":[{"set":3,"time":%q,"snap":"htop","revision":"1168","snap-id":"Z","auto":true,"epoch":{"read":[0],"write":[0]},"summary":"","version":"2","sha3-384":{"archive.tgz":""},"size":1}]}]}`, snapshotTime)
 					return
@@ -137,6 +157,13 @@ func (s *SnapSuite) mockSnapshotsServer(c *C) {
 			}
 		case "/v2/changes/9":
 			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done", "data": {}}}`)
+		case "/v2/snapshots/remote":
+			if r.Method == "GET" {
+				snapshotTime := time.Now().AddDate(0, -1, 0).Format(time.RFC3339)
+				fmt.Fprintf(w, `{"type":"sync","status-code":200,"status":"OK","result":[{"name":"1_x.snapshot","size":1,"time":%q}]}`, snapshotTime)
+			} else {
+				fmt.Fprintln(w, `{"type": "sync", "result": {"set-id": 42, "snaps": ["htop"]}}`)
+			}
 		case "/v2/snapshots/1/export":
 			w.Header().Set("Content-Type", client.SnapshotExportMediaType)
 			fmt.Fprint(w, "Hello World!")
@@ -167,3 +194,51 @@ Set  Snap  Age    Version  Rev   Size    Notes
 1    htop  %-6s 2        1168      1B  -
 `, ageStr))
 }
+
+func (s *SnapSuite) TestSnapshotPassphraseFile(c *C) {
+	passphraseFile := filepath.Join(c.MkDir(), "passphrase")
+	c.Assert(os.WriteFile(passphraseFile, []byte("sekrit\n"), 0600), IsNil)
+
+	var passphrases []string
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snapshots":
+			c.Check(r.Method, Equals, "POST")
+			var action map[string]interface{}
+			c.Assert(json.NewDecoder(r.Body).Decode(&action), IsNil)
+			passphrases = append(passphrases, action["passphrase"].(string))
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "9"}`)
+		case "/v2/changes/9":
+			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done", "data": {}}}`)
+		default:
+			c.Errorf("unexpected path %q", r.URL.Path)
+		}
+	})
+
+	for _, cmd := range []string{"check-snapshot", "restore"} {
+		_, err := main.Parser(main.Client()).ParseArgs([]string{cmd, "--passphrase-file", passphraseFile, "4"})
+		c.Assert(err, IsNil)
+	}
+	c.Check(passphrases, DeepEquals, []string{"sekrit", "sekrit"})
+}
+
+func (s *SnapSuite) TestSnapshotPassphraseFileEmpty(c *C) {
+	passphraseFile := filepath.Join(c.MkDir(), "passphrase")
+	c.Assert(os.WriteFile(passphraseFile, []byte("\n"), 0600), IsNil)
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"save", "--passphrase-file", passphraseFile})
+	c.Assert(err, ErrorMatches, `cannot use empty passphrase from ".*/passphrase"`)
+}
+
+func (s *SnapSuite) TestSnapshotImportRemote(c *C) {
+	s.mockSnapshotsServer(c)
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"import-snapshot", "--remote", "1_x.snapshot"})
+	c.Check(err, IsNil)
+	c.Check(s.Stderr(), testutil.EqualsWrapped, "")
+	c.Check(s.Stdout(), testutil.MatchesWrapped, `Imported snapshot as #42
+Set  Snap  Age    Version  Rev   Size    Notes
+1    htop  .*
+`)
+}
@@ -68,6 +68,7 @@ var api = []*Command{
 	debugCmd,
 	snapshotCmd,
 	snapshotExportCmd,
+	snapshotRemoteCmd,
 	connectionsCmd,
 	modelCmd,
 	cohortsCmd,
@@ -85,6 +86,7 @@ var api = []*Command{
 	confdbCmd,
 	noticesCmd,
 	noticeCmd,
+	healthCmd,
 	requestsPromptsCmd,
 	requestsPromptCmd,
 	requestsRulesCmd,
@@ -91,6 +91,8 @@ type apiBaseSuite struct {
 
 	connectivityResult map[string]bool
 
+	downloadCache *store.CacheManager
+
 	restoreSanitize func()
 	restoreMuxVars  func()
 
@@ -166,6 +168,10 @@ func (s *apiBaseSuite) ConnectivityCheck() (map[string]bool, error) {
 	return s.connectivityResult, s.err
 }
 
+func (s *apiBaseSuite) DownloadCache() *store.CacheManager {
+	return s.downloadCache
+}
+
 func (s *apiBaseSuite) muxVars(*http.Request) map[string]string {
 	return s.vars
 }
@@ -224,6 +230,7 @@ func (s *apiBaseSuite) SetUpTest(c *check.C) {
 	s.currentSnaps = nil
 	s.actions = nil
 	s.authUser = nil
+	s.downloadCache = nil
 
 	// TODO: consider making the default ReadAccess expectation
 	// authenticatedAccess, but that would need even more test changes
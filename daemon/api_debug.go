@@ -24,16 +24,19 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/gadget"
 	"github.com/snapcore/snapd/osutil/disks"
 	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/auditstate"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/timings"
 )
 
@@ -52,6 +55,8 @@ type debugAction struct {
 		ChgID string `json:"chg-id"`
 
 		RecoverySystemLabel string `json:"recovery-system-label"`
+
+		CacheKey string `json:"cache-key"`
 	} `json:"params"`
 	Snaps []string `json:"snaps"`
 }
@@ -324,6 +329,105 @@ func getDisks(st *state.State) Response {
 	return SyncResponse(vols)
 }
 
+func getAuditRecords(r *http.Request) Response {
+	// unlike the other debug aspects, the audit log tells what every
+	// user did, so it is only for root
+	uid, err := uidFromRequest(r)
+	if err != nil {
+		return Forbidden("cannot get remote user: %v", err)
+	}
+	if uid != 0 {
+		return Forbidden("access denied")
+	}
+
+	query := r.URL.Query()
+	filter := &auditstate.Filter{
+		Snap: query.Get("snap"),
+		Kind: query.Get("kind"),
+	}
+	if s := query.Get("uid"); s != "" {
+		uid, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return BadRequest("invalid uid %q", s)
+		}
+		uid32 := uint32(uid)
+		filter.UID = &uid32
+	}
+	since, err := parseOptionalTime(query.Get("since"))
+	if err != nil {
+		return BadRequest("invalid since: %v", err)
+	}
+	filter.Since = since
+
+	records, err := auditstate.Records(filter)
+	if err != nil {
+		return InternalError("%v", err)
+	}
+	if records == nil {
+		records = []*auditstate.Record{}
+	}
+	return SyncResponse(records)
+}
+
+// downloadCache returns the cache of downloaded snaps of the store, or nil if
+// there is none.
+func downloadCache(st *state.State) *store.CacheManager {
+	sto, ok := snapstate.Store(st, nil).(interface {
+		DownloadCache() *store.CacheManager
+	})
+	if !ok {
+		return nil
+	}
+	return sto.DownloadCache()
+}
+
+func getDownloadCache(st *state.State) Response {
+	cm := downloadCache(st)
+	if cm == nil {
+		return BadRequest("snap downloads are not cached")
+	}
+	// the cache consults the state for its policy
+	st.Unlock()
+	defer st.Lock()
+	entries, err := cm.Entries()
+	if err != nil {
+		return InternalError("cannot list download cache: %v", err)
+	}
+	if entries == nil {
+		entries = []*store.CacheEntry{}
+	}
+	return SyncResponse(entries)
+}
+
+func pruneDownloadCache(st *state.State) Response {
+	cm := downloadCache(st)
+	if cm == nil {
+		return BadRequest("snap downloads are not cached")
+	}
+	st.Unlock()
+	defer st.Lock()
+	if err := cm.Prune(); err != nil {
+		return InternalError("cannot prune download cache: %v", err)
+	}
+	return SyncResponse(true)
+}
+
+func pinDownloadCache(st *state.State, key string, pin bool) Response {
+	if key == "" {
+		return BadRequest("cannot pin or unpin a download cache entry without a key")
+	}
+	var err error
+	if pin {
+		err = snapstate.PinDownload(st, key)
+	} else {
+		err = snapstate.UnpinDownload(st, key)
+	}
+	if err != nil {
+		return BadRequest("%v", err)
+	}
+	return SyncResponse(true)
+}
+
 func createRecovery(st *state.State, label string) Response {
 	if label == "" {
 		return BadRequest("cannot create a recovery system with no label")
@@ -370,6 +474,10 @@ func getDebug(c *Command, r *http.Request, user *auth.UserState) Response {
 		return getGadgetDiskMapping(st)
 	case "disks":
 		return getDisks(st)
+	case "audit":
+		return getAuditRecords(r)
+	case "download-cache":
+		return getDownloadCache(st)
 	default:
 		return BadRequest("unknown debug aspect %q", aspect)
 	}
@@ -411,6 +519,12 @@ func postDebug(c *Command, r *http.Request, user *auth.UserState) Response {
 		return createRecovery(st, a.Params.RecoverySystemLabel)
 	case "migrate-home":
 		return migrateHome(st, a.Snaps)
+	case "prune-download-cache":
+		return pruneDownloadCache(st)
+	case "pin-download-cache":
+		return pinDownloadCache(st, a.Params.CacheKey, true)
+	case "unpin-download-cache":
+		return pinDownloadCache(st, a.Params.CacheKey, false)
 	default:
 		return BadRequest("unknown debug action: %v", a.Action)
 	}
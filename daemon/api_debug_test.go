@@ -23,15 +23,23 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/auditstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/testutil"
 	"github.com/snapcore/snapd/timings"
 )
@@ -111,6 +119,165 @@ func (s *postDebugSuite) TestGetDebugBaseDeclaration(c *check.C) {
 		testutil.Contains, "type: base-declaration")
 }
 
+func (s *postDebugSuite) TestGetDebugAudit(c *check.C) {
+	_ = s.daemon(c)
+
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapAuditLogFile), 0755), check.IsNil)
+	c.Assert(os.WriteFile(dirs.SnapAuditLogFile, []byte(`{"id":"1","kind":"install-snap","summary":"Install \"foo\" snap","status":"Done","snap-names":["foo"],"uid":1000,"spawn-time":"2026-01-01T10:00:00Z","ready-time":"2026-01-01T10:01:00Z"}
+{"id":"2","kind":"remove-snap","summary":"Remove \"foo\" snap","status":"Error","err":"boom","snap-names":["foo"],"uid":0,"spawn-time":"2026-01-02T10:00:00Z","ready-time":"2026-01-02T10:01:00Z"}
+`), 0600), check.IsNil)
+
+	for _, tc := range []struct {
+		query string
+		ids   []string
+	}{
+		{"", []string{"1", "2"}},
+		{"&snap=foo", []string{"1", "2"}},
+		{"&snap=bar", nil},
+		{"&kind=remove-snap", []string{"2"}},
+		{"&uid=1000", []string{"1"}},
+		{"&since=2026-01-02T00:00:00Z", []string{"2"}},
+	} {
+		req, err := http.NewRequest("GET", "/v2/debug?aspect=audit"+tc.query, nil)
+		c.Assert(err, check.IsNil)
+		s.asRootAuth(req)
+		rsp := s.syncReq(c, req, nil)
+		records, ok := rsp.Result.([]*auditstate.Record)
+		c.Assert(ok, check.Equals, true)
+		var ids []string
+		for _, rec := range records {
+			ids = append(ids, rec.ID)
+		}
+		c.Check(ids, check.DeepEquals, tc.ids, check.Commentf("query %q", tc.query))
+	}
+
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=audit&uid=foo", nil)
+	c.Assert(err, check.IsNil)
+	s.asRootAuth(req)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `invalid uid "foo"`)
+
+	req, err = http.NewRequest("GET", "/v2/debug?aspect=audit&since=yesterday", nil)
+	c.Assert(err, check.IsNil)
+	s.asRootAuth(req)
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Matches, `invalid since: .*`)
+}
+
+func (s *postDebugSuite) TestGetDebugAuditRootOnly(c *check.C) {
+	_ = s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=audit", nil)
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=1000;socket=%s;", dirs.SnapdSocket)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 403)
+	c.Check(rspe.Message, check.Equals, "access denied")
+
+	// the other debug aspects are still open
+	req, err = http.NewRequest("GET", "/v2/debug?aspect=base-declaration", nil)
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=1000;socket=%s;", dirs.SnapdSocket)
+	s.syncReq(c, req, nil)
+}
+
+func (s *postDebugSuite) mockDownloadCache(c *check.C) (cacheDir string) {
+	cacheDir = c.MkDir()
+	cm := store.NewCacheManager(cacheDir, 1)
+	// like the store, consult the state for the policy
+	cm.SetPolicy(func() (*store.CachePolicy, error) {
+		st := s.d.Overlord().State()
+		st.Lock()
+		defer st.Unlock()
+		return snapstate.DownloadCachePolicy(st)
+	})
+	s.downloadCache = cm
+	return cacheDir
+}
+
+func (s *postDebugSuite) TestGetDebugDownloadCache(c *check.C) {
+	_ = s.daemon(c)
+	cacheDir := s.mockDownloadCache(c)
+
+	key1 := strings.Repeat("1", 96)
+	key2 := strings.Repeat("2", 96)
+	c.Assert(os.WriteFile(filepath.Join(cacheDir, key1), []byte("foo"), 0600), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(cacheDir, key2), []byte("quux"), 0600), check.IsNil)
+	c.Assert(os.Link(filepath.Join(cacheDir, key2), filepath.Join(c.MkDir(), "quux.snap")), check.IsNil)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	c.Assert(snapstate.PinDownload(st, key1), check.IsNil)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=download-cache", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	entries, ok := rsp.Result.([]*store.CacheEntry)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(entries, check.HasLen, 2)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	c.Check(entries[0].Key, check.Equals, key1)
+	c.Check(entries[0].Size, check.Equals, int64(3))
+	c.Check(entries[0].Pinned, check.Equals, true)
+	c.Check(entries[0].Referenced, check.Equals, false)
+	c.Check(entries[1].Key, check.Equals, key2)
+	c.Check(entries[1].Pinned, check.Equals, false)
+	c.Check(entries[1].Referenced, check.Equals, true)
+}
+
+func (s *postDebugSuite) TestGetDebugDownloadCacheDisabled(c *check.C) {
+	_ = s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=download-cache", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "snap downloads are not cached")
+}
+
+func (s *postDebugSuite) TestPostDebugDownloadCache(c *check.C) {
+	s.daemon(c)
+	s.expectRootAccess()
+	cacheDir := s.mockDownloadCache(c)
+
+	key1 := strings.Repeat("1", 96)
+	key2 := strings.Repeat("2", 96)
+	key3 := strings.Repeat("3", 96)
+	for _, key := range []string{key1, key2, key3} {
+		c.Assert(os.WriteFile(filepath.Join(cacheDir, key), nil, 0600), check.IsNil)
+	}
+
+	post := func(body string) *http.Request {
+		req, err := http.NewRequest("POST", "/v2/debug", bytes.NewBufferString(body))
+		c.Assert(err, check.IsNil)
+		return req
+	}
+
+	rsp := s.syncReq(c, post(`{"action": "pin-download-cache", "params": {"cache-key": "`+key1+`"}}`), nil)
+	c.Check(rsp.Result, check.Equals, true)
+	rsp = s.syncReq(c, post(`{"action": "pin-download-cache", "params": {"cache-key": "`+key2+`"}}`), nil)
+	c.Check(rsp.Result, check.Equals, true)
+	rsp = s.syncReq(c, post(`{"action": "unpin-download-cache", "params": {"cache-key": "`+key2+`"}}`), nil)
+	c.Check(rsp.Result, check.Equals, true)
+
+	// only one entry is allowed, but the pinned one is kept
+	rsp = s.syncReq(c, post(`{"action": "prune-download-cache"}`), nil)
+	c.Check(rsp.Result, check.Equals, true)
+	c.Check(filepath.Join(cacheDir, key1), testutil.FilePresent)
+	c.Check(filepath.Join(cacheDir, key2), testutil.FileAbsent)
+	c.Check(filepath.Join(cacheDir, key3), testutil.FileAbsent)
+
+	rspe := s.errorReq(c, post(`{"action": "unpin-download-cache", "params": {"cache-key": "`+key2+`"}}`), nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, fmt.Sprintf("download cache entry %q is not pinned", key2))
+
+	rspe = s.errorReq(c, post(`{"action": "pin-download-cache"}`), nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "cannot pin or unpin a download cache entry without a key")
+}
 func mockDurationThreshold() func() {
 	oldDurationThreshold := timings.DurationThreshold
 	restore := func() {
@@ -23,8 +23,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/snapcore/snapd/arch"
@@ -42,6 +47,7 @@ import (
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/sandbox"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var (
@@ -262,20 +268,193 @@ func getChanges(c *Command, r *http.Request, user *auth.UserState) Response {
 		}
 	}
 
+	chgQuery, err := parseChangesQuery(query)
+	if err != nil {
+		return BadRequest("%v", err)
+	}
+
 	state := c.d.overlord.State()
 	state.Lock()
 	defer state.Unlock()
 	chgs := state.Changes()
+	sort.Sort(byChangeID(chgs))
 	chgInfos := make([]*changeInfo, 0, len(chgs))
 	for _, chg := range chgs {
-		if !filter(chg) {
+		if !filter(chg) || !chgQuery.match(chg) {
 			continue
 		}
+		if chgQuery.limit > 0 && len(chgInfos) == chgQuery.limit {
+			break
+		}
 		chgInfos = append(chgInfos, change2changeInfo(chg))
 	}
 	return SyncResponse(chgInfos)
 }
 
+// byChangeID sorts changes in the order they were created.
+type byChangeID []*state.Change
+
+func (c byChangeID) Len() int      { return len(c) }
+func (c byChangeID) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c byChangeID) Less(i, j int) bool {
+	return changeIDLess(c[i].ID(), c[j].ID())
+}
+
+func changeIDLess(a, b string) bool {
+	// change IDs are increasing numbers
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// changesQuery holds the filters of a changes query, beyond the selection
+// of changes by readiness and snap name, and its pagination.
+type changesQuery struct {
+	kinds         []string
+	statuses      []string
+	spawnedAfter  time.Time
+	spawnedBefore time.Time
+	data          []dataFilter
+
+	// afterID and limit paginate the changes, ordered by ID
+	afterID string
+	limit   int
+}
+
+// dataFilter matches changes whose data, or the data of one of their
+// tasks, has value at the given path.
+type dataFilter struct {
+	path  []string
+	value string
+}
+
+func parseChangesQuery(query url.Values) (*changesQuery, error) {
+	q := &changesQuery{
+		kinds:    strutil.MultiCommaSeparatedList(query["kind"]),
+		statuses: strutil.MultiCommaSeparatedList(query["status"]),
+	}
+	for _, status := range q.statuses {
+		if !validStatusName(status) {
+			return nil, fmt.Errorf("invalid status %q", status)
+		}
+	}
+
+	var err error
+	q.spawnedAfter, err = parseOptionalTime(query.Get("spawned-after"))
+	if err != nil {
+		return nil, fmt.Errorf(`invalid "spawned-after" timestamp: %v`, err)
+	}
+	q.spawnedBefore, err = parseOptionalTime(query.Get("spawned-before"))
+	if err != nil {
+		return nil, fmt.Errorf(`invalid "spawned-before" timestamp: %v`, err)
+	}
+
+	for _, filter := range query["data"] {
+		path, value, ok := strings.Cut(filter, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf(`invalid data filter %q, expected <key>[.<subkey>...]=<value>`, filter)
+		}
+		q.data = append(q.data, dataFilter{path: strings.Split(path, "."), value: value})
+	}
+
+	if afterID := query.Get("after-id"); afterID != "" {
+		if !allDigits(afterID) {
+			return nil, fmt.Errorf("invalid change ID %q", afterID)
+		}
+		q.afterID = afterID
+	}
+	if limit := query.Get("limit"); limit != "" {
+		q.limit, err = strconv.Atoi(limit)
+		if err != nil || q.limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %q, expected a positive number", limit)
+		}
+	}
+	return q, nil
+}
+
+var allDigits = regexp.MustCompile(`^[0-9]+$`).MatchString
+
+func validStatusName(name string) bool {
+	for st := state.DefaultStatus; st <= state.WaitStatus; st++ {
+		if strings.EqualFold(st.String(), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *changesQuery) match(chg *state.Change) bool {
+	if q.afterID != "" && !changeIDLess(q.afterID, chg.ID()) {
+		return false
+	}
+	if len(q.kinds) > 0 && !strutil.ListContains(q.kinds, chg.Kind()) {
+		return false
+	}
+	if len(q.statuses) > 0 {
+		status := chg.Status().String()
+		matched := false
+		for _, wanted := range q.statuses {
+			if strings.EqualFold(wanted, status) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if !q.spawnedAfter.IsZero() && !chg.SpawnTime().After(q.spawnedAfter) {
+		return false
+	}
+	if !q.spawnedBefore.IsZero() && !chg.SpawnTime().Before(q.spawnedBefore) {
+		return false
+	}
+	for _, f := range q.data {
+		if !f.matchChange(chg) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *dataFilter) matchChange(chg *state.Change) bool {
+	if f.match(chg.Get) {
+		return true
+	}
+	for _, t := range chg.Tasks() {
+		if f.match(t.Get) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *dataFilter) match(get func(key string, value interface{}) error) bool {
+	var v interface{}
+	if err := get(f.path[0], &v); err != nil {
+		return false
+	}
+	for _, key := range f.path[1:] {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if v, ok = m[key]; !ok {
+			return false
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		return v == f.value
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64) == f.value
+	case bool:
+		return strconv.FormatBool(v) == f.value
+	}
+	return false
+}
+
 func abortChange(c *Command, r *http.Request, user *auth.UserState) Response {
 	chID := muxVars(r)["id"]
 	state := c.d.overlord.State()
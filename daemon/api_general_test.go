@@ -610,6 +610,90 @@ func (s *generalSuite) TestStateChangesForSnapNameWithApp(c *check.C) {
 	c.Assert(rec.Code, check.Equals, 200)
 }
 
+func (s *generalSuite) queryChanges(c *check.C, query string) []*daemon.ChangeInfo {
+	req, err := http.NewRequest("GET", "/v2/changes?"+query, nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 200)
+	c.Assert(rsp.Result, check.FitsTypeOf, []*daemon.ChangeInfo(nil))
+	return rsp.Result.([]*daemon.ChangeInfo)
+}
+
+func changeInfoKinds(infos []*daemon.ChangeInfo) []string {
+	kinds := make([]string, 0, len(infos))
+	for _, info := range infos {
+		kinds = append(kinds, info.Kind)
+	}
+	return kinds
+}
+
+func (s *generalSuite) TestStateChangesQuery(c *check.C) {
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	restore := state.MockTime(time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC))
+	setupChanges(st)
+	restore()
+	restore = state.MockTime(time.Date(2016, 04, 22, 1, 2, 3, 0, time.UTC))
+	chg := st.NewChange("refresh", "refresh...")
+	t := st.NewTask("link-snap", "1...")
+	t.Set("snap-setup", map[string]interface{}{
+		"side-info": map[string]interface{}{"name": "foo", "revision": 5},
+	})
+	chg.AddTask(t)
+	restore()
+	st.Unlock()
+
+	for _, tc := range []struct {
+		query string
+		kinds []string
+	}{
+		{"select=all", []string{"install", "remove", "refresh"}},
+		{"select=all&kind=remove,refresh", []string{"remove", "refresh"}},
+		{"select=all&kind=remove&kind=install", []string{"install", "remove"}},
+		{"select=all&status=error", []string{"remove"}},
+		{"select=all&status=Do,Error", []string{"install", "remove", "refresh"}},
+		{"select=in-progress&status=error", []string{}},
+		{"select=all&spawned-after=2016-04-21T12:00:00Z", []string{"refresh"}},
+		{"select=all&spawned-before=2016-04-21T12:00:00Z", []string{"install", "remove"}},
+		{"select=all&data=snap-setup.side-info.name=foo", []string{"refresh"}},
+		{"select=all&data=snap-setup.side-info.revision=5", []string{"refresh"}},
+		{"select=all&data=snap-setup.side-info.revision=6", []string{}},
+		{"select=all&data=snap-names=funky-snap-name", []string{}},
+		{"select=all&limit=2", []string{"install", "remove"}},
+		{"select=all&limit=2&after-id=2", []string{"refresh"}},
+		{"select=all&kind=install,refresh&limit=1&after-id=1", []string{"refresh"}},
+	} {
+		infos := s.queryChanges(c, tc.query)
+		c.Check(changeInfoKinds(infos), check.DeepEquals, tc.kinds, check.Commentf(tc.query))
+	}
+}
+
+func (s *generalSuite) TestStateChangesQueryErrors(c *check.C) {
+	s.expectChangesReadAccess()
+	s.daemon(c)
+
+	for _, tc := range []struct {
+		query string
+		err   string
+	}{
+		{"status=foo", `invalid status "foo"`},
+		{"spawned-after=yesterday", `invalid "spawned-after" timestamp: .*`},
+		{"spawned-before=tomorrow", `invalid "spawned-before" timestamp: .*`},
+		{"data=foo", `invalid data filter "foo", expected <key>\[.<subkey>...\]=<value>`},
+		{"after-id=a1", `invalid change ID "a1"`},
+		{"limit=0", `invalid limit "0", expected a positive number`},
+		{"limit=-2", `invalid limit "-2", expected a positive number`},
+	} {
+		req, err := http.NewRequest("GET", "/v2/changes?"+tc.query, nil)
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(tc.query))
+		c.Check(rspe.Message, check.Matches, tc.err, check.Commentf(tc.query))
+	}
+}
+
 func (s *generalSuite) TestStateChange(c *check.C) {
 	restore := state.MockTime(time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC))
 	defer restore()
@@ -0,0 +1,97 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/healthstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var healthCmd = &Command{
+	Path:       "/v2/health",
+	GET:        getHealth,
+	ReadAccess: interfaceOpenAccess{Interfaces: []string{"snap-refresh-observe"}},
+}
+
+func getHealth(c *Command, r *http.Request, _ *auth.UserState) Response {
+	var wanted map[string]bool
+	if ns := r.URL.Query().Get("snaps"); len(ns) > 0 {
+		nsl := strutil.CommaSeparatedList(ns)
+		wanted = make(map[string]bool, len(nsl))
+		for _, name := range nsl {
+			wanted[name] = true
+		}
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		return InternalError("cannot get snaps: %v", err)
+	}
+	healths, err := healthstate.All(st)
+	if err != nil {
+		return InternalError("cannot get health of snaps: %v", err)
+	}
+	history, err := healthstate.AllHistory(st)
+	if err != nil {
+		return InternalError("cannot get health history of snaps: %v", err)
+	}
+
+	// the health of snaps that were removed is of no interest
+	considered := make(map[string]*healthstate.HealthState, len(healths))
+	for name, health := range healths {
+		if snapStates[name] == nil || (wanted != nil && !wanted[name]) {
+			continue
+		}
+		considered[name] = health
+	}
+
+	names := make([]string, 0, len(considered))
+	for name := range considered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reports := make([]*client.SnapHealthReport, 0, len(names))
+	for _, name := range names {
+		report := &client.SnapHealthReport{
+			Name:       name,
+			SnapHealth: *clientHealthFromHealthstate(considered[name]),
+		}
+		for _, h := range history[name] {
+			report.History = append(report.History, clientHealthFromHealthstate(h))
+		}
+		reports = append(reports, report)
+	}
+
+	return SyncResponse(&client.SystemHealth{
+		Status: healthstate.Overall(considered).String(),
+		Snaps:  reports,
+	})
+}
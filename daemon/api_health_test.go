@@ -0,0 +1,118 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/healthstate"
+	"github.com/snapcore/snapd/snap"
+)
+
+var _ = check.Suite(&healthSuite{})
+
+type healthSuite struct {
+	apiBaseSuite
+}
+
+func (s *healthSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.InterfaceOpenAccess{Interfaces: []string{"snap-refresh-observe"}})
+}
+
+func (s *healthSuite) TestHealth(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", snap.R(10), true, "")
+	s.mkInstalledInState(c, d, "baz", "bar", "v1", snap.R(20), true, "")
+	s.mkInstalledInState(c, d, "quux", "bar", "v1", snap.R(30), true, "")
+
+	t0 := time.Date(2026, 9, 19, 12, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	okay := &healthstate.HealthState{Revision: snap.R(10), Timestamp: t0, Status: healthstate.OkayStatus}
+	broken := &healthstate.HealthState{Revision: snap.R(10), Timestamp: t1, Status: healthstate.ErrorStatus, Message: "database is gone", Code: "no-db"}
+	waiting := &healthstate.HealthState{Revision: snap.R(20), Timestamp: t1, Status: healthstate.WaitingStatus, Message: "starting up"}
+
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("health", map[string]*healthstate.HealthState{
+		"foo": broken,
+		"baz": waiting,
+		// no longer installed
+		"gone": broken,
+	})
+	st.Set("health-history", map[string][]*healthstate.HealthState{
+		"foo": {okay, broken},
+		"baz": {waiting},
+	})
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/health", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+
+	brokenHealth := client.SnapHealth{Revision: snap.R(10), Timestamp: t1, Status: "error", Message: "database is gone", Code: "no-db"}
+	waitingHealth := client.SnapHealth{Revision: snap.R(20), Timestamp: t1, Status: "waiting", Message: "starting up"}
+	c.Check(rsp.Result, check.DeepEquals, &client.SystemHealth{
+		Status: "error",
+		Snaps: []*client.SnapHealthReport{{
+			Name:       "baz",
+			SnapHealth: waitingHealth,
+			History:    []*client.SnapHealth{&waitingHealth},
+		}, {
+			Name:       "foo",
+			SnapHealth: brokenHealth,
+			History: []*client.SnapHealth{
+				{Revision: snap.R(10), Timestamp: t0, Status: "okay"},
+				&brokenHealth,
+			},
+		}},
+	})
+
+	// only some snaps
+	req, err = http.NewRequest("GET", "/v2/health?snaps=baz,quux", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, &client.SystemHealth{
+		Status: "waiting",
+		Snaps: []*client.SnapHealthReport{{
+			Name:       "baz",
+			SnapHealth: waitingHealth,
+			History:    []*client.SnapHealth{&waitingHealth},
+		}},
+	})
+}
+
+func (s *healthSuite) TestHealthNone(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/health", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, &client.SystemHealth{
+		Status: "okay",
+		Snaps:  []*client.SnapHealthReport{},
+	})
+}
@@ -20,8 +20,11 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/auth"
@@ -36,6 +39,11 @@ var noticeReadInterfaces = map[state.NoticeType][]string{
 	state.SnapRunInhibitNotice:               {"snap-refresh-observe"},
 	state.InterfacesRequestsPromptNotice:     {"snap-interfaces-requests-control"},
 	state.InterfacesRequestsRuleUpdateNotice: {"snap-interfaces-requests-control"},
+	state.SnapInstalledNotice:                {"snap-refresh-observe"},
+	state.SnapRemovedNotice:                  {"snap-refresh-observe"},
+	state.SnapRefreshedNotice:                {"snap-refresh-observe"},
+	state.InterfaceConnectedNotice:           {"snap-refresh-observe"},
+	state.InterfaceDisconnectedNotice:        {"snap-refresh-observe"},
 }
 
 var (
@@ -95,10 +103,15 @@ func getNotices(c *Command, r *http.Request, user *auth.UserState) Response {
 		userID = nil
 	}
 
+	stream := wantsEventStream(r)
+
 	types, err := sanitizeNoticeTypesFilter(query["types"], r)
 	if err != nil {
 		// Caller did provide a types filter, but they're all invalid notice types.
 		// Return no notices, rather than the default of all notices.
+		if stream {
+			return &noticesStreamResponse{none: true, dying: c.d.tomb.Dying()}
+		}
 		return SyncResponse([]*state.Notice{})
 	}
 	if !noticeTypesViewableBySnap(types, r) {
@@ -111,6 +124,13 @@ func getNotices(c *Command, r *http.Request, user *auth.UserState) Response {
 	if err != nil {
 		return BadRequest(`invalid "after" timestamp: %v`, err)
 	}
+	if stream && r.Header.Get("Last-Event-ID") != "" {
+		// a client resuming the stream after getting disconnected
+		after, err = time.Parse(time.RFC3339Nano, r.Header.Get("Last-Event-ID"))
+		if err != nil {
+			return BadRequest(`invalid "Last-Event-ID" header: %v`, err)
+		}
+	}
 
 	filter := &state.NoticeFilter{
 		UserID: userID,
@@ -125,6 +145,13 @@ func getNotices(c *Command, r *http.Request, user *auth.UserState) Response {
 	}
 
 	st := c.d.overlord.State()
+	if stream {
+		if timeout != 0 {
+			return BadRequest("cannot use a timeout when streaming notices")
+		}
+		return &noticesStreamResponse{st: st, filter: *filter, dying: c.d.tomb.Dying()}
+	}
+
 	st.Lock()
 	defer st.Unlock()
 
@@ -155,6 +182,18 @@ func getNotices(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse(notices)
 }
 
+// wantsEventStream returns whether the client asked for notices to be
+// streamed as server-sent events, instead of getting the current ones.
+func wantsEventStream(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(accept)
+		if err == nil && mediaType == "text/event-stream" {
+			return true
+		}
+	}
+	return false
+}
+
 // Get the UID of the request. If the UID is not known, return an error.
 func uidFromRequest(r *http.Request) (uint32, error) {
 	cred, err := ucrednetGet(r.RemoteAddr)
@@ -15,10 +15,12 @@
 package daemon_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"path/filepath"
@@ -337,6 +339,50 @@ func (s *noticesSuite) TestNoticesFilterTypesForSnap(c *C) {
 	c.Check(seenNoticeType["snap-run-inhibit"], Equals, 1)
 }
 
+func (s *noticesSuite) TestNoticesLifecycleTypesForSnap(c *C) {
+	s.daemon(c)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	addNotice(c, st, nil, state.SnapInstalledNotice, "foo", nil)
+	addNotice(c, st, nil, state.SnapRefreshedNotice, "foo", nil)
+	addNotice(c, st, nil, state.SnapRemovedNotice, "bar", nil)
+	addNotice(c, st, nil, state.InterfaceConnectedNotice, "foo:plug core:slot", nil)
+	addNotice(c, st, nil, state.InterfaceDisconnectedNotice, "bar:plug core:slot", nil)
+	st.Unlock()
+
+	// snap-refresh-observe interface allows accessing snap and interface
+	// lifecycle notices
+	req, err := http.NewRequest("GET", "/v2/notices?types=snap-installed,snap-removed,snap-refreshed,interface-connected,interface-disconnected", nil)
+	c.Assert(err, IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=1000;socket=%s;iface=snap-refresh-observe;", dirs.SnapSocket)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, Equals, 200)
+	notices, ok := rsp.Result.([]*state.Notice)
+	c.Assert(ok, Equals, true)
+	c.Assert(notices, HasLen, 5)
+
+	var seen []string
+	for _, notice := range notices {
+		n := noticeToMap(c, notice)
+		seen = append(seen, n["type"].(string)+" "+n["key"].(string))
+	}
+	c.Check(seen, DeepEquals, []string{
+		"snap-installed foo",
+		"snap-refreshed foo",
+		"snap-removed bar",
+		"interface-connected foo:plug core:slot",
+		"interface-disconnected bar:plug core:slot",
+	})
+
+	// without it, they are forbidden
+	req, err = http.NewRequest("GET", "/v2/notices?types=snap-installed", nil)
+	c.Assert(err, IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=1000;socket=%s;iface=snap-interfaces-requests-control;", dirs.SnapSocket)
+	errRsp := s.errorReq(c, req, nil)
+	c.Check(errRsp.Status, Equals, 403)
+}
+
 func (s *noticesSuite) TestNoticesFilterTypesForSnapForbidden(c *C) {
 	s.daemon(c)
 
@@ -1218,3 +1264,155 @@ func addNotice(c *C, st *state.State, userID *uint32, noticeType state.NoticeTyp
 	_, err := st.AddNotice(userID, noticeType, key, options)
 	c.Assert(err, IsNil)
 }
+
+type streamRecorder struct {
+	header http.Header
+	code   int
+	w      *io.PipeWriter
+}
+
+func (sr *streamRecorder) Header() http.Header         { return sr.header }
+func (sr *streamRecorder) WriteHeader(code int)        { sr.code = code }
+func (sr *streamRecorder) Write(b []byte) (int, error) { return sr.w.Write(b) }
+
+// serveNoticesStream serves the notices stream response to req in the
+// background, returning a reader of the stream and a channel closed once
+// the response is done.
+func (s *noticesSuite) serveNoticesStream(c *C, req *http.Request) (*streamRecorder, *bufio.Reader, <-chan struct{}) {
+	req.Header.Set("Accept", "text/event-stream")
+	rsp := s.req(c, req, nil)
+
+	pr, pw := io.Pipe()
+	rec := &streamRecorder{header: make(http.Header), w: pw}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rsp.ServeHTTP(rec, req)
+		pw.Close()
+	}()
+	return rec, bufio.NewReader(pr), done
+}
+
+// readStreamEvent reads the next event of a notices stream, as a map of its
+// fields, or the next comment.
+func readStreamEvent(c *C, br *bufio.Reader) map[string]string {
+	event := make(map[string]string)
+	for {
+		line, err := br.ReadString('\n')
+		c.Assert(err, IsNil)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return event
+		}
+		field, value, _ := strings.Cut(line, ":")
+		event[field] = strings.TrimPrefix(value, " ")
+	}
+}
+
+func (s *noticesSuite) TestNoticesStream(c *C) {
+	s.daemon(c)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	addNotice(c, st, nil, state.WarningNotice, "foo", nil)
+	notice := st.Notices(nil)[0]
+	st.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", "/v2/notices?types=warning", nil)
+	c.Assert(err, IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=1000;socket=%s;", dirs.SnapdSocket)
+	rec, br, done := s.serveNoticesStream(c, req)
+
+	// the current notices come first
+	event := readStreamEvent(c, br)
+	c.Check(rec.code, Equals, 200)
+	c.Check(rec.header.Get("Content-Type"), Equals, "text/event-stream")
+	c.Check(event["event"], Equals, "notice")
+	c.Check(event["id"], Equals, notice.LastRepeated().Format(time.RFC3339Nano))
+	var n map[string]any
+	c.Assert(json.Unmarshal([]byte(event["data"]), &n), IsNil)
+	c.Check(n["type"], Equals, "warning")
+	c.Check(n["key"], Equals, "foo")
+
+	// followed by the new ones as they are recorded
+	st.Lock()
+	addNotice(c, st, nil, state.ChangeUpdateNotice, "123", nil)
+	addNotice(c, st, nil, state.WarningNotice, "bar", nil)
+	st.Unlock()
+	event = readStreamEvent(c, br)
+	c.Assert(json.Unmarshal([]byte(event["data"]), &n), IsNil)
+	c.Check(n["key"], Equals, "bar")
+
+	// until the client goes away
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(testutil.HostScaledTimeout(5 * time.Second)):
+		c.Fatal("notices stream did not stop")
+	}
+}
+
+func (s *noticesSuite) TestNoticesStreamResume(c *C) {
+	s.daemon(c)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	addNotice(c, st, nil, state.WarningNotice, "foo", nil)
+	addNotice(c, st, nil, state.WarningNotice, "bar", nil)
+	notices := st.Notices(nil)
+	st.Unlock()
+	c.Assert(notices, HasLen, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", "/v2/notices", nil)
+	c.Assert(err, IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=1000;socket=%s;", dirs.SnapdSocket)
+	req.Header.Set("Last-Event-ID", notices[0].LastRepeated().Format(time.RFC3339Nano))
+	_, br, done := s.serveNoticesStream(c, req)
+
+	event := readStreamEvent(c, br)
+	c.Check(event["id"], Equals, notices[1].LastRepeated().Format(time.RFC3339Nano))
+
+	cancel()
+	<-done
+}
+
+func (s *noticesSuite) TestNoticesStreamKeepAlive(c *C) {
+	restore := daemon.MockNoticesStreamKeepAlive(time.Millisecond)
+	defer restore()
+	s.daemon(c)
+
+	for _, query := range []string{"", "types=foo"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, "GET", "/v2/notices?"+query, nil)
+		c.Assert(err, IsNil)
+		req.RemoteAddr = fmt.Sprintf("pid=100;uid=1000;socket=%s;", dirs.SnapdSocket)
+		_, br, done := s.serveNoticesStream(c, req)
+
+		c.Check(readStreamEvent(c, br), DeepEquals, map[string]string{"": "keep-alive"})
+
+		cancel()
+		<-done
+	}
+}
+
+func (s *noticesSuite) TestNoticesStreamBadRequest(c *C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/notices?timeout=1s", nil)
+	c.Assert(err, IsNil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=0;socket=%s;", dirs.SnapdSocket)
+	rsp := s.errorReq(c, req, nil)
+	c.Check(rsp.Status, Equals, 400)
+	c.Check(rsp.Message, Equals, "cannot use a timeout when streaming notices")
+
+	req.URL.RawQuery = ""
+	req.Header.Set("Last-Event-ID", "foo")
+	rsp = s.errorReq(c, req, nil)
+	c.Check(rsp.Status, Equals, 400)
+	c.Check(rsp.Message, Matches, `invalid "Last-Event-ID" header: .*`)
+}
@@ -231,6 +231,9 @@ func promptingError(err error) *apiError {
 		// exists for some other user), this error will remain unchanged.
 		apiErr.Status = 404
 		apiErr.Kind = client.ErrorKindInterfacesRequestsRuleNotFound
+	case errors.Is(err, prompting_errors.ErrRuleAdminOwned):
+		apiErr.Status = 403
+		apiErr.Kind = client.ErrorKindInterfacesRequestsRuleAdminOwned
 	case errors.Is(err, prompting_errors.ErrUnsupportedValue):
 		apiErr.Status = 400
 		apiErr.Kind = client.ErrorKindInterfacesRequestsInvalidFields
@@ -312,9 +315,10 @@ type patchRuleContents struct {
 }
 
 type postRulesRequestBody struct {
-	Action         string               `json:"action"`
-	AddRule        *addRuleContents     `json:"rule,omitempty"`
-	RemoveSelector *removeRulesSelector `json:"selector,omitempty"`
+	Action         string                    `json:"action"`
+	AddRule        *addRuleContents          `json:"rule,omitempty"`
+	RemoveSelector *removeRulesSelector      `json:"selector,omitempty"`
+	ImportRules    *requestrules.RulesExport `json:"import,omitempty"`
 }
 
 type postRuleRequestBody struct {
@@ -425,6 +429,17 @@ func getRules(c *Command, r *http.Request, user *auth.UserState) Response {
 	snap := query.Get("snap")
 	iface := query.Get("interface")
 
+	if query.Get("export") == "true" {
+		if snap != "" || iface != "" {
+			return BadRequest(`cannot use "snap" or "interface" parameters when exporting rules`)
+		}
+		export, err := getInterfaceManager(c).InterfacesRequestsManager().ExportRules(userID)
+		if err != nil {
+			return promptingError(err)
+		}
+		return SyncResponse(export)
+	}
+
 	rules, err := getInterfaceManager(c).InterfacesRequestsManager().Rules(userID, snap, iface)
 	if err != nil {
 		// Should be impossible, Rules() always returns nil error
@@ -476,8 +491,17 @@ func postRules(c *Command, r *http.Request, user *auth.UserState) Response {
 			return promptingError(err)
 		}
 		return SyncResponse(removedRules)
+	case "import":
+		if postBody.ImportRules == nil {
+			return BadRequest(`must include "import" field in request body when action is "import"`)
+		}
+		newRules, err := getInterfaceManager(c).InterfacesRequestsManager().ImportRules(userID, postBody.ImportRules)
+		if err != nil {
+			return promptingError(err)
+		}
+		return SyncResponse(newRules)
 	default:
-		return BadRequest(`"action" field must be "create" or "remove"`)
+		return BadRequest(`"action" field must be "add", "remove", or "import"`)
 	}
 }
 
@@ -49,6 +49,7 @@ type fakeInterfacesRequestsManager struct {
 	prompt       *requestprompts.Prompt
 	rule         *requestrules.Rule
 	satisfiedIDs []prompting.IDType
+	export       *requestrules.RulesExport
 	err          error
 
 	// Store most recent received values
@@ -63,6 +64,7 @@ type fakeInterfacesRequestsManager struct {
 	lifespan         prompting.LifespanType
 	duration         string
 	clientActivity   bool
+	importedRules    *requestrules.RulesExport
 }
 
 func (m *fakeInterfacesRequestsManager) Prompts(userID uint32, clientActivity bool) ([]*requestprompts.Prompt, error) {
@@ -130,6 +132,17 @@ func (m *fakeInterfacesRequestsManager) RemoveRule(userID uint32, ruleID prompti
 	return m.rule, m.err
 }
 
+func (m *fakeInterfacesRequestsManager) ExportRules(userID uint32) (*requestrules.RulesExport, error) {
+	m.userID = userID
+	return m.export, m.err
+}
+
+func (m *fakeInterfacesRequestsManager) ImportRules(userID uint32, export *requestrules.RulesExport) ([]*requestrules.Rule, error) {
+	m.userID = userID
+	m.importedRules = export
+	return m.rules, m.err
+}
+
 type promptingSuite struct {
 	apiBaseSuite
 
@@ -315,6 +328,18 @@ func (s *promptingSuite) TestPromptingError(c *C) {
 				"type":        "error",
 			},
 		},
+		{
+			err: prompting_errors.ErrRuleAdminOwned,
+			body: map[string]interface{}{
+				"result": map[string]interface{}{
+					"message": prompting_errors.ErrRuleAdminOwned.Error(),
+					"kind":    string(client.ErrorKindInterfacesRequestsRuleAdminOwned),
+				},
+				"status":      "Forbidden",
+				"status-code": 403.0,
+				"type":        "error",
+			},
+		},
 		{
 			err: prompting_errors.ErrPromptsClosed,
 			body: map[string]interface{}{
@@ -804,6 +829,50 @@ func (s *promptingSuite) TestGetRulesHappy(c *C) {
 	}
 }
 
+func (s *promptingSuite) TestGetRulesExportHappy(c *C) {
+	s.daemon(c)
+
+	s.manager.export = &requestrules.RulesExport{
+		Version: requestrules.RulesExportVersion,
+		Rules: []*requestrules.ExportedRule{
+			{
+				Snap:      "firefox",
+				Interface: "home",
+				Constraints: &prompting.Constraints{
+					PathPattern: mustParsePathPattern(c, "/foo/bar"),
+					Permissions: prompting.PermissionMap{
+						"write": &prompting.PermissionEntry{
+							Outcome:  prompting.OutcomeDeny,
+							Lifespan: prompting.LifespanForever,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	rsp := s.makeSyncReq(c, "GET", "/v2/interfaces/requests/rules?export=true", 1234, nil)
+
+	// Check parameters
+	c.Check(s.manager.userID, Equals, uint32(1234))
+
+	// Check return value
+	export, ok := rsp.Result.(*requestrules.RulesExport)
+	c.Check(ok, Equals, true)
+	c.Check(export, DeepEquals, s.manager.export)
+}
+
+func (s *promptingSuite) TestGetRulesExportUnhappy(c *C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/interfaces/requests/rules?export=true&snap=firefox", nil)
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1234;socket=;"
+	rsp := s.errorReq(c, req, nil)
+	c.Check(rsp.Status, Equals, 400)
+	c.Check(rsp.Message, Equals, `cannot use "snap" or "interface" parameters when exporting rules`)
+}
+
 func (s *promptingSuite) TestPostRulesAddHappy(c *C) {
 	s.expectWriteAccess(daemon.InterfaceAuthenticatedAccess{Interfaces: []string{"snap-interfaces-requests-control"}, Polkit: "io.snapcraft.snapd.manage"})
 
@@ -956,6 +1025,98 @@ func (s *promptingSuite) TestPostRulesRemoveHappy(c *C) {
 	}
 }
 
+func (s *promptingSuite) TestPostRulesImportHappy(c *C) {
+	s.expectWriteAccess(daemon.InterfaceAuthenticatedAccess{Interfaces: []string{"snap-interfaces-requests-control"}, Polkit: "io.snapcraft.snapd.manage"})
+
+	s.daemon(c)
+
+	s.manager.rules = []*requestrules.Rule{
+		{
+			ID:        prompting.IDType(1234),
+			Timestamp: time.Now(),
+			User:      0,
+			Admin:     true,
+			Snap:      "thunderbird",
+			Interface: "home",
+			Constraints: &prompting.RuleConstraints{
+				PathPattern: mustParsePathPattern(c, "/home/*/.ssh/**"),
+				Permissions: prompting.RulePermissionMap{
+					"read": &prompting.RulePermissionEntry{
+						Outcome:  prompting.OutcomeDeny,
+						Lifespan: prompting.LifespanForever,
+					},
+				},
+			},
+		},
+	}
+
+	export := &requestrules.RulesExport{
+		Version: requestrules.RulesExportVersion,
+		Rules: []*requestrules.ExportedRule{
+			{
+				Snap:      "thunderbird",
+				Interface: "home",
+				Constraints: &prompting.Constraints{
+					PathPattern: mustParsePathPattern(c, "/home/*/.ssh/**"),
+					Permissions: prompting.PermissionMap{
+						"read": &prompting.PermissionEntry{
+							Outcome:  prompting.OutcomeDeny,
+							Lifespan: prompting.LifespanForever,
+						},
+					},
+				},
+			},
+		},
+	}
+	postBody := &daemon.PostRulesRequestBody{
+		Action:      "import",
+		ImportRules: export,
+	}
+	marshalled, err := json.Marshal(postBody)
+	c.Assert(err, IsNil)
+
+	rsp := s.makeSyncReq(c, "POST", "/v2/interfaces/requests/rules", 0, marshalled)
+
+	// Check parameters
+	c.Check(s.manager.userID, Equals, uint32(0))
+	c.Check(s.manager.importedRules, DeepEquals, export)
+
+	// Check return value
+	rules, ok := rsp.Result.([]*requestrules.Rule)
+	c.Check(ok, Equals, true)
+	c.Check(rules, DeepEquals, s.manager.rules)
+}
+
+func (s *promptingSuite) TestPostRulesImportUnhappy(c *C) {
+	s.expectWriteAccess(daemon.InterfaceAuthenticatedAccess{Interfaces: []string{"snap-interfaces-requests-control"}, Polkit: "io.snapcraft.snapd.manage"})
+
+	s.daemon(c)
+
+	for _, testCase := range []struct {
+		body   string
+		status int
+		errStr string
+	}{
+		{
+			body:   `{"action": "import"}`,
+			status: 400,
+			errStr: `must include "import" field in request body when action is "import"`,
+		},
+		{
+			body:   `{"action": "foo"}`,
+			status: 400,
+			errStr: `"action" field must be "add", "remove", or "import"`,
+		},
+	} {
+		req, err := http.NewRequest("POST", "/v2/interfaces/requests/rules", bytes.NewBufferString(testCase.body))
+		c.Assert(err, IsNil)
+		req.RemoteAddr = "pid=100;uid=1000;socket=;"
+		rsp := s.errorReq(c, req, nil)
+		c.Check(rsp.Status, Equals, testCase.status)
+		c.Check(rsp.Message, Equals, testCase.errStr)
+	}
+}
+
 func (s *promptingSuite) TestGetRuleHappy(c *C) {
 	s.daemon(c)
 
@@ -35,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/restart"
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
@@ -145,6 +146,9 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 	if err := inst.validate(); err != nil {
 		return BadRequest("%s", err)
 	}
+	if inst.DryRun {
+		return BadRequest("dry-run is only supported for multi-snap operations")
+	}
 
 	impl := inst.dispatch()
 	if impl == nil {
@@ -229,10 +233,14 @@ type snapInstruction struct {
 	Snaps                  []string                         `json:"snaps"`
 	Users                  []string                         `json:"users"`
 	SnapshotOptions        map[string]*snap.SnapshotOptions `json:"snapshot-options"`
+	SnapshotPassphrase     string                           `json:"snapshot-passphrase"`
 	ValidationSets         []string                         `json:"validation-sets"`
 	QuotaGroupName         string                           `json:"quota-group"`
 	Time                   string                           `json:"time"`
 	HoldLevel              string                           `json:"hold-level"`
+	SnapshotBeforeRefresh  bool                             `json:"snapshot-before-refresh"`
+	WithData               bool                             `json:"with-data"`
+	DryRun                 bool                             `json:"dry-run"`
 
 	// The fields below should not be unmarshalled into. Do not export them.
 	userID int
@@ -409,6 +417,18 @@ func (inst *snapInstruction) validate() error {
 	if err := inst.validateSnapshotOptions(); err != nil {
 		return err
 	}
+	if inst.SnapshotPassphrase != "" && inst.Action != "snapshot" {
+		return fmt.Errorf("snapshot-passphrase can only be specified for snapshot action")
+	}
+	if inst.SnapshotBeforeRefresh && inst.Action != "refresh" {
+		return fmt.Errorf("snapshot-before-refresh can only be specified for refresh action")
+	}
+	if inst.WithData && inst.Action != "revert" {
+		return fmt.Errorf("with-data can only be specified for revert action")
+	}
+	if inst.DryRun && inst.Action != "refresh" {
+		return fmt.Errorf("dry-run can only be specified for refresh action")
+	}
 
 	if inst.Action == "snapshot" {
 		inst.cleanSnapshotOptions()
@@ -555,6 +575,9 @@ func snapUpdate(ctx context.Context, inst *snapInstruction, st *state.State) (*s
 	if inst.Amend {
 		flags.Amend = true
 	}
+	if inst.SnapshotBeforeRefresh {
+		flags.SnapshotBeforeRefresh = true
+	}
 
 	// we need refreshed snap-declarations to enforce refresh-control as best as we can
 	if err = assertstateRefreshSnapAssertions(st, inst.userID, nil); err != nil {
@@ -644,6 +667,9 @@ func snapRevert(_ context.Context, inst *snapInstruction, st *state.State) (*sna
 	if err != nil {
 		return nil, err
 	}
+	if inst.WithData {
+		flags.RevertWithData = true
+	}
 
 	if inst.Revision.Unset() {
 		ts, err = snapstateRevert(st, inst.Snaps[0], flags, "")
@@ -822,6 +848,14 @@ func snapOpMany(c *Command, r *http.Request, user *auth.UserState) Response {
 		return inst.errToResponse(err)
 	}
 
+	if inst.DryRun {
+		plan, err := refreshPlan(st, res)
+		if err != nil {
+			return InternalError("%v", err)
+		}
+		return SyncResponse(plan)
+	}
+
 	chg := newChange(st, inst.Action+"-snap", res.Summary, res.Tasksets, res.Affected)
 	if len(res.Tasksets) == 0 {
 		chg.SetStatus(state.DoneStatus)
@@ -994,8 +1028,9 @@ func snapUpdateMany(ctx context.Context, inst *snapInstruction, st *state.State)
 	}
 
 	flags := snapstate.Flags{
-		IgnoreRunning: inst.IgnoreRunning,
-		Transaction:   inst.Transaction,
+		IgnoreRunning:         inst.IgnoreRunning,
+		Transaction:           inst.Transaction,
+		SnapshotBeforeRefresh: inst.SnapshotBeforeRefresh,
 	}
 
 	// TODO: once we completely move away from the old snapstate API, this
@@ -1018,6 +1053,14 @@ func snapUpdateMany(ctx context.Context, inst *snapInstruction, st *state.State)
 	}
 	tasksets := uts.Refresh
 
+	if inst.DryRun && opts.IsRefreshOfAllSnaps {
+		// nothing is going to be refreshed, so go back to the validation
+		// sets tracked before refreshing their assertions
+		if err := assertstateRestoreValidationSetsTracking(st); err != nil && !errors.Is(err, state.ErrNoState) {
+			return nil, err
+		}
+	}
+
 	var msg string
 	switch len(updated) {
 	case 0:
@@ -1040,6 +1083,116 @@ func snapUpdateMany(ctx context.Context, inst *snapInstruction, st *state.State)
 	}, nil
 }
 
+// refreshPlan describes the refresh that the task sets of res would carry
+// out. The tasks are discarded afterwards as they are never run.
+func refreshPlan(st *state.State, res *snapInstructionResult) (*client.RefreshPlan, error) {
+	var tasks []*state.Task
+	for _, ts := range res.Tasksets {
+		tasks = append(tasks, ts.Tasks()...)
+	}
+	defer func() {
+		if err := st.DiscardTasks(tasks); err != nil {
+			logger.Noticef("cannot discard refresh plan tasks: %v", err)
+		}
+	}()
+
+	// the tasks are not part of a change, so snapstate.TaskSnapSetup cannot
+	// follow snap-setup-task references to them
+	setups := make(map[string]*snapstate.SnapSetup, len(tasks))
+	for _, t := range tasks {
+		var snapsup snapstate.SnapSetup
+		if err := t.Get("snap-setup", &snapsup); err == nil {
+			setups[t.ID()] = &snapsup
+		} else if !errors.Is(err, state.ErrNoState) {
+			return nil, err
+		}
+	}
+	taskSetup := func(t *state.Task) *snapstate.SnapSetup {
+		if snapsup := setups[t.ID()]; snapsup != nil {
+			return snapsup
+		}
+		var id string
+		if err := t.Get("snap-setup-task", &id); err != nil {
+			return nil
+		}
+		return setups[id]
+	}
+
+	plan := &client.RefreshPlan{Summary: res.Summary}
+	snaps := make(map[string]*client.RefreshPlanSnap)
+	for _, t := range tasks {
+		pt := &client.RefreshPlanTask{
+			ID:      t.ID(),
+			Kind:    t.Kind(),
+			Summary: t.Summary(),
+		}
+		for _, wt := range t.WaitTasks() {
+			pt.WaitFor = append(pt.WaitFor, wt.ID())
+		}
+		plan.Tasks = append(plan.Tasks, pt)
+
+		snapsup := taskSetup(t)
+		if snapsup == nil {
+			continue
+		}
+		pt.Snap = snapsup.InstanceName()
+
+		ps := snaps[pt.Snap]
+		if ps == nil {
+			prereqs, err := missingPrerequisites(st, snapsup)
+			if err != nil {
+				return nil, err
+			}
+			ps = &client.RefreshPlanSnap{
+				Name:          pt.Snap,
+				Type:          string(snapsup.Type),
+				Revision:      snapsup.Revision(),
+				Channel:       snapsup.Channel,
+				Prerequisites: prereqs,
+			}
+			if snapsup.DownloadInfo != nil {
+				ps.DownloadSize = snapsup.DownloadInfo.Size
+			}
+			if snapsup.Type == snap.TypeSnapd || snapsup.Type == snap.TypeOS {
+				ps.Restart = "daemon"
+			}
+			snaps[pt.Snap] = ps
+			plan.Snaps = append(plan.Snaps, ps)
+		}
+		if restart.TaskIsRestartBoundary(t, restart.RestartBoundaryDirectionDo) {
+			ps.Restart = "system"
+		}
+	}
+
+	return plan, nil
+}
+
+// missingPrerequisites returns the base and default content providers of the
+// snap being set up that are not installed.
+func missingPrerequisites(st *state.State, snapsup *snapstate.SnapSetup) ([]string, error) {
+	names := snapsup.Prereq
+	if snapsup.Base != "" && snapsup.Base != "none" {
+		names = append([]string{snapsup.Base}, names...)
+	}
+
+	var missing []string
+	for _, name := range names {
+		if strutil.ListContains(missing, name) {
+			continue
+		}
+		var snapst snapstate.SnapState
+		err := snapstate.Get(st, name, &snapst)
+		if errors.Is(err, state.ErrNoState) {
+			missing = append(missing, name)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return missing, nil
+}
+
 func snapEnforceValidationSets(ctx context.Context, inst *snapInstruction, st *state.State) (*snapInstructionResult, error) {
 	if len(inst.ValidationSets) > 0 && len(inst.Snaps) != 0 {
 		return nil, fmt.Errorf("snap names cannot be specified with validation sets to enforce")
@@ -45,6 +45,7 @@ import (
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/healthstate"
+	"github.com/snapcore/snapd/overlord/restart"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/snapstate/sequence"
 	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
@@ -519,6 +520,36 @@ func (s *snapsSuite) TestPostSnapsOptionsUnsupportedActionError(c *check.C) {
 	}
 }
 
+func (s *snapsSuite) TestPostSnapsSnapshotPassphraseUnsupportedAction(c *check.C) {
+	s.daemon(c)
+
+	buf := strings.NewReader(`{"action": "refresh", "snaps":["foo"], "snapshot-passphrase": "sekrit"}`)
+	req, err := http.NewRequest("POST", "/v2/snaps", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "snapshot-passphrase can only be specified for snapshot action")
+}
+
+func (s *snapsSuite) TestPostSnapsPreRefreshSnapshotUnsupportedAction(c *check.C) {
+	s.daemon(c)
+
+	for body, expectedErr := range map[string]string{
+		`{"action": "install", "snaps":["foo"], "snapshot-before-refresh": true}`: "snapshot-before-refresh can only be specified for refresh action",
+		`{"action": "remove", "snaps":["foo"], "with-data": true}`:                "with-data can only be specified for revert action",
+	} {
+		req, err := http.NewRequest("POST", "/v2/snaps", strings.NewReader(body))
+		c.Assert(err, check.IsNil)
+		req.Header.Set("Content-Type", "application/json")
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, expectedErr)
+	}
+}
+
 func (s *snapsSuite) TestPostSnapsOptionsOtherErrors(c *check.C) {
 	s.daemon(c)
 	const notListedErr = `cannot use snapshot-options for snap "xyzzy" that is not listed in snaps`
@@ -708,6 +739,119 @@ func (s *snapsSuite) testPostSnapsOp(c *check.C, extraJSON, contentType string)
 	return systemRestartImmediate
 }
 
+func (s *snapsSuite) TestPostSnapsOpRefreshDryRun(c *check.C) {
+	defer daemon.MockAssertstateRefreshSnapAssertions(func(*state.State, int, *assertstate.RefreshAssertionsOptions) error { return nil })()
+	restoredTracking := false
+	defer daemon.MockAssertstateRestoreValidationSetsTracking(func(*state.State) error {
+		restoredTracking = true
+		return nil
+	})()
+	defer daemon.MockSnapstateUpdateWithGoal(func(_ context.Context, st *state.State, g snapstate.UpdateGoal, filter func(*snap.Info, *snapstate.SnapState) bool, opts snapstate.Options) ([]string, *snapstate.UpdateTaskSets, error) {
+		prereq := st.NewTask("prerequisites", "Ensure prerequisites for foo")
+		prereq.Set("snap-setup", &snapstate.SnapSetup{
+			SideInfo:     &snap.SideInfo{RealName: "foo", Revision: snap.R(7)},
+			Type:         snap.TypeApp,
+			Channel:      "stable",
+			Base:         "core22",
+			Prereq:       []string{"content-provider"},
+			DownloadInfo: &snap.DownloadInfo{Size: 1024},
+		})
+		download := st.NewTask("download-snap", "Download foo")
+		download.Set("snap-setup-task", prereq.ID())
+		download.WaitFor(prereq)
+
+		kernelPrereq := st.NewTask("prerequisites", "Ensure prerequisites for pc-kernel")
+		kernelPrereq.Set("snap-setup", &snapstate.SnapSetup{
+			SideInfo:     &snap.SideInfo{RealName: "pc-kernel", Revision: snap.R(12)},
+			Type:         snap.TypeKernel,
+			DownloadInfo: &snap.DownloadInfo{Size: 2048},
+		})
+		link := st.NewTask("link-snap", "Make pc-kernel available")
+		link.Set("snap-setup-task", kernelPrereq.ID())
+		link.WaitFor(kernelPrereq)
+		restart.MarkTaskAsRestartBoundary(link, restart.RestartBoundaryDirectionDo)
+
+		return []string{"foo", "pc-kernel"}, &snapstate.UpdateTaskSets{Refresh: []*state.TaskSet{
+			state.NewTaskSet(prereq, download),
+			state.NewTaskSet(kernelPrereq, link),
+		}}, nil
+	})()
+
+	d := s.daemonWithOverlordMockAndStore()
+	st := d.Overlord().State()
+	st.Lock()
+	snapstate.Set(st, "core22", &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{{RealName: "core22", Revision: snap.R(1)}}),
+		Current:  snap.R(1),
+		SnapType: "base",
+	})
+	st.Unlock()
+
+	buf := bytes.NewBufferString(`{"action": "refresh", "dry-run": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, &client.RefreshPlan{
+		Summary: `Refresh snaps "foo", "pc-kernel"`,
+		Snaps: []*client.RefreshPlanSnap{{
+			Name:          "foo",
+			Type:          "app",
+			Revision:      snap.R(7),
+			Channel:       "stable",
+			DownloadSize:  1024,
+			Prerequisites: []string{"content-provider"},
+		}, {
+			Name:         "pc-kernel",
+			Type:         "kernel",
+			Revision:     snap.R(12),
+			DownloadSize: 2048,
+			Restart:      "system",
+		}},
+		Tasks: []*client.RefreshPlanTask{
+			{ID: "1", Kind: "prerequisites", Summary: "Ensure prerequisites for foo", Snap: "foo"},
+			{ID: "2", Kind: "download-snap", Summary: "Download foo", Snap: "foo", WaitFor: []string{"1"}},
+			{ID: "3", Kind: "prerequisites", Summary: "Ensure prerequisites for pc-kernel", Snap: "pc-kernel"},
+			{ID: "4", Kind: "link-snap", Summary: "Make pc-kernel available", Snap: "pc-kernel", WaitFor: []string{"3"}},
+		},
+	})
+	c.Check(restoredTracking, check.Equals, true)
+
+	// nothing is left behind in the state
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	c.Check(st.TaskCount(), check.Equals, 0)
+}
+
+func (s *snapsSuite) TestPostSnapsOpDryRunOnlyForRefresh(c *check.C) {
+	s.daemon(c)
+
+	buf := bytes.NewBufferString(`{"action": "remove", "snaps": ["foo"], "dry-run": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "dry-run can only be specified for refresh action")
+}
+
+func (s *snapsSuite) TestPostSnapDryRunUnsupported(c *check.C) {
+	s.daemon(c)
+
+	buf := bytes.NewBufferString(`{"action": "refresh", "dry-run": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "dry-run is only supported for multi-snap operations")
+}
+
 func (s *snapsSuite) TestPostSnapsOpInvalidCharset(c *check.C) {
 	s.daemon(c)
 
@@ -917,6 +1061,34 @@ func (s *snapsSuite) TestRefreshManyIgnoreRunning(c *check.C) {
 	c.Check(calledFlags.IgnoreRunning, check.Equals, true)
 }
 
+func (s *snapsSuite) TestRefreshManySnapshotBeforeRefresh(c *check.C) {
+	defer daemon.MockAssertstateRefreshSnapAssertions(func(s *state.State, userID int, opts *assertstate.RefreshAssertionsOptions) error {
+		return nil
+	})()
+
+	var calledFlags *snapstate.Flags
+	defer daemon.MockSnapstateUpdateWithGoal(func(_ context.Context, s *state.State, g snapstate.UpdateGoal, filter func(*snap.Info, *snapstate.SnapState) bool, opts snapstate.Options) ([]string, *snapstate.UpdateTaskSets, error) {
+		calledFlags = &opts.Flags
+
+		goal := g.(*storeUpdateGoalRecorder)
+		t := s.NewTask("fake-refresh-2", "Refreshing two")
+		return goal.names(), &snapstate.UpdateTaskSets{Refresh: []*state.TaskSet{state.NewTaskSet(t)}}, nil
+	})()
+
+	d := s.daemon(c)
+	inst := &daemon.SnapInstruction{
+		Action:                "refresh",
+		Snaps:                 []string{"foo", "bar"},
+		SnapshotBeforeRefresh: true,
+	}
+	st := d.Overlord().State()
+	st.Lock()
+	_, err := inst.DispatchForMany()(context.Background(), inst, st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(calledFlags.SnapshotBeforeRefresh, check.Equals, true)
+}
+
 func (s *snapsSuite) TestRefreshMany1(c *check.C) {
 	refreshSnapAssertions := false
 	defer daemon.MockAssertstateRefreshSnapAssertions(func(s *state.State, userID int, opts *assertstate.RefreshAssertionsOptions) error {
@@ -2821,6 +2993,37 @@ func (s *snapsSuite) TestRefreshIgnoreRunning(c *check.C) {
 	c.Check(res.Summary, check.Equals, `Refresh "some-snap" snap`)
 }
 
+func (s *snapsSuite) TestRefreshSnapshotBeforeRefresh(c *check.C) {
+	var calledFlags snapstate.Flags
+
+	defer daemon.MockSnapstateUpdateOne(func(ctx context.Context, st *state.State, g snapstate.UpdateGoal, filter func(*snap.Info, *snapstate.SnapState) bool, opts snapstate.Options) (*state.TaskSet, error) {
+		calledFlags = opts.Flags
+		t := st.NewTask("fake-refresh-snap", "Doing a fake install")
+		return state.NewTaskSet(t), nil
+	})()
+	defer daemon.MockAssertstateRefreshSnapAssertions(func(s *state.State, userID int, opts *assertstate.RefreshAssertionsOptions) error {
+		return nil
+	})()
+
+	d := s.daemon(c)
+	inst := &daemon.SnapInstruction{
+		Action:                "refresh",
+		SnapshotBeforeRefresh: true,
+		Snaps:                 []string{"some-snap"},
+	}
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	_, err := inst.Dispatch()(context.Background(), inst, st)
+	c.Check(err, check.IsNil)
+
+	c.Check(calledFlags, check.DeepEquals, snapstate.Flags{
+		SnapshotBeforeRefresh: true,
+		Transaction:           client.TransactionPerSnap,
+	})
+}
+
 func (s *snapsSuite) TestRefreshCohort(c *check.C) {
 	cohort := ""
 
@@ -2941,6 +3144,7 @@ func (s *snapsSuite) testRevertSnap(inst *daemon.SnapInstruction, c *check.C) {
 
 	instFlags, err := inst.ModeFlags()
 	c.Assert(err, check.IsNil)
+	instFlags.RevertWithData = inst.WithData
 
 	defer daemon.MockSnapstateRevert(func(s *state.State, name string, flags snapstate.Flags, fromChange string) (*state.TaskSet, error) {
 		c.Check(flags, check.Equals, instFlags)
@@ -2986,6 +3190,16 @@ func (s *snapsSuite) TestRevertSnapClassic(c *check.C) {
 	s.testRevertSnap(&daemon.SnapInstruction{Classic: true}, c)
 }
 
+func (s *snapsSuite) TestRevertSnapWithData(c *check.C) {
+	s.testRevertSnap(&daemon.SnapInstruction{WithData: true}, c)
+}
+
+func (s *snapsSuite) TestRevertSnapToRevisionWithData(c *check.C) {
+	inst := &daemon.SnapInstruction{WithData: true}
+	inst.Revision = snap.R(1)
+	s.testRevertSnap(inst, c)
+}
+
 func (s *snapsSuite) TestRevertSnapToRevision(c *check.C) {
 	inst := &daemon.SnapInstruction{}
 	inst.Revision = snap.R(1)
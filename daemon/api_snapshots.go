@@ -51,6 +51,14 @@ var snapshotExportCmd = &Command{
 	ReadAccess: authenticatedAccess{},
 }
 
+var snapshotRemoteCmd = &Command{
+	Path:        "/v2/snapshots/remote",
+	GET:         listRemoteSnapshots,
+	POST:        importRemoteSnapshot,
+	ReadAccess:  authenticatedAccess{},
+	WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
+}
+
 var (
 	snapshotList    = snapshotstate.List
 	snapshotCheck   = snapshotstate.Check
@@ -59,6 +67,12 @@ var (
 	snapshotSave    = snapshotstate.Save
 	snapshotExport  = snapshotstate.Export
 	snapshotImport  = snapshotstate.Import
+	snapshotPush    = snapshotstate.Push
+
+	snapshotRemoteList   = snapshotstate.RemoteList
+	snapshotRemoteImport = snapshotstate.RemoteImport
+
+	snapshotSetPassphrase = snapshotstate.SetPassphrase
 )
 
 func listSnapshots(c *Command, r *http.Request, user *auth.UserState) Response {
@@ -89,6 +103,8 @@ type snapshotAction struct {
 	Action string   `json:"action"`
 	Snaps  []string `json:"snaps,omitempty"`
 	Users  []string `json:"users,omitempty"`
+	// Passphrase is used to decrypt snapshot sets protected by one
+	Passphrase string `json:"passphrase,omitempty"`
 }
 
 func (action snapshotAction) String() string {
@@ -135,11 +151,20 @@ func changeSnapshots(c *Command, r *http.Request, user *auth.UserState) Response
 	st.Lock()
 	defer st.Unlock()
 
+	if action.Passphrase != "" && action.Action != "check" && action.Action != "restore" {
+		return BadRequest("snapshot %q operation cannot specify a passphrase", action.Action)
+	}
+
 	switch action.Action {
 	case "check":
 		affected, ts, err = snapshotCheck(st, action.SetID, action.Snaps, action.Users)
 	case "restore":
 		affected, ts, err = snapshotRestore(st, action.SetID, action.Snaps, action.Users)
+	case "push":
+		if len(action.Snaps) != 0 || len(action.Users) != 0 {
+			return BadRequest(`snapshot "push" operation cannot specify snaps or users`)
+		}
+		affected, ts, err = snapshotPush(st, action.SetID)
 	case "forget":
 		if len(action.Users) != 0 {
 			return BadRequest(`snapshot "forget" operation cannot specify users`)
@@ -154,10 +179,16 @@ func changeSnapshots(c *Command, r *http.Request, user *auth.UserState) Response
 		// woo
 	case client.ErrSnapshotSetNotFound, client.ErrSnapshotSnapsNotFound:
 		return NotFound("%v", err)
+	case snapshotstate.ErrNoRemoteTarget:
+		return BadRequest("%v", err)
 	default:
 		return InternalError("%v", err)
 	}
 
+	if action.Passphrase != "" {
+		snapshotSetPassphrase(st, action.SetID, action.Passphrase, ts)
+	}
+
 	chg := newChange(st, action.Action+"-snapshot", action.String(), []*state.TaskSet{ts}, affected)
 	chg.Set("api-data", map[string]interface{}{"snap-names": affected})
 	ensureStateSoon(st)
@@ -207,8 +238,60 @@ func doSnapshotImport(c *Command, r *http.Request, user *auth.UserState) Respons
 	limitedBodyReader := io.LimitReader(r.Body, expectedSize)
 
 	// XXX: check that we have enough space to import the compressed snapshots
+	passphrase := r.Header.Get("X-Snapd-Snapshot-Passphrase")
+
+	st := c.d.overlord.State()
+	setID, snapNames, err := snapshotImport(r.Context(), st, limitedBodyReader, passphrase)
+	if err != nil {
+		return BadRequest(err.Error())
+	}
+
+	result := map[string]interface{}{"set-id": setID, "snaps": snapNames}
+	return SyncResponse(result)
+}
+
+func listRemoteSnapshots(c *Command, r *http.Request, user *auth.UserState) Response {
 	st := c.d.overlord.State()
-	setID, snapNames, err := snapshotImport(r.Context(), st, limitedBodyReader)
+	st.Lock()
+	defer st.Unlock()
+
+	exports, err := snapshotRemoteList(r.Context(), st)
+	if err == snapshotstate.ErrNoRemoteTarget {
+		return BadRequest("%v", err)
+	}
+	if err != nil {
+		return InternalError("%v", err)
+	}
+	if exports == nil {
+		exports = []client.RemoteSnapshot{}
+	}
+	return SyncResponse(exports)
+}
+
+// A remoteSnapshotAction is used to request an operation on the remote
+// snapshot target.
+// keep this in sync with client/remoteSnapshotAction...
+type remoteSnapshotAction struct {
+	Action     string `json:"action"`
+	Name       string `json:"name"`
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+func importRemoteSnapshot(c *Command, r *http.Request, user *auth.UserState) Response {
+	var action remoteSnapshotAction
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&action); err != nil {
+		return BadRequest("cannot decode request body into remote snapshot operation: %v", err)
+	}
+	if action.Action != "import" {
+		return BadRequest("unknown remote snapshot operation %q", action.Action)
+	}
+	if action.Name == "" {
+		return BadRequest("remote snapshot import requires a name")
+	}
+
+	st := c.d.overlord.State()
+	setID, snapNames, err := snapshotRemoteImport(r.Context(), st, action.Name, action.Passphrase)
 	if err != nil {
 		return BadRequest(err.Error())
 	}
@@ -222,6 +305,9 @@ func snapshotMany(_ context.Context, inst *snapInstruction, st *state.State) (*s
 	if err != nil {
 		return nil, err
 	}
+	if inst.SnapshotPassphrase != "" {
+		snapshotSetPassphrase(st, setID, inst.SnapshotPassphrase, ts)
+	}
 
 	var msg string
 	if len(inst.Snaps) == 0 {
@@ -28,6 +28,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -99,6 +100,32 @@ func (s *snapshotSuite) TestSnapshotManyOptionsFull(c *check.C) {
 	c.Check(snapshotSaveCalled, check.Equals, 1)
 }
 
+func (s *snapshotSuite) TestSnapshotManyPassphrase(c *check.C) {
+	defer daemon.MockSnapshotSave(func(s *state.State, snaps, users []string,
+		options map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error) {
+		t := s.NewTask("fake-snapshot-2", "Snapshot two")
+		return 7, snaps, state.NewTaskSet(t), nil
+	})()
+	var passphrases map[uint64]string
+	defer daemon.MockSnapshotSetPassphrase(func(_ *state.State, setID uint64, passphrase string, ts *state.TaskSet) {
+		c.Check(ts.Tasks(), check.HasLen, 1)
+		if passphrases == nil {
+			passphrases = make(map[uint64]string)
+		}
+		passphrases[setID] = passphrase
+	})()
+
+	inst := daemon.MustUnmarshalSnapInstruction(c, `{"action": "snapshot", "snaps": ["foo"], "snapshot-passphrase": "sekrit"}`)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	res, err := inst.DispatchForMany()(context.Background(), inst, st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(res.Result, check.DeepEquals, map[string]interface{}{"set-id": uint64(7)})
+	c.Check(passphrases, check.DeepEquals, map[uint64]string{7: "sekrit"})
+}
+
 func (s *snapshotSuite) TestSnapshotManyError(c *check.C) {
 	defer daemon.MockSnapshotSave(func(s *state.State, snaps, users []string,
 		options map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error) {
@@ -236,6 +263,12 @@ func (s *snapshotSuite) TestChangeSnapshots400(c *check.C) {
 		}, {
 			body:  `{"set": 42, "action": "forget", "users": ["foo"]}`,
 			error: `snapshot "forget" operation cannot specify users`,
+		}, {
+			body:  `{"set": 42, "action": "push", "snaps": ["foo"]}`,
+			error: `snapshot "push" operation cannot specify snaps or users`,
+		}, {
+			body:  `{"set": 42, "action": "forget", "passphrase": "sekrit"}`,
+			error: `snapshot "forget" operation cannot specify a passphrase`,
 		},
 	}
 
@@ -355,6 +388,131 @@ func (s *snapshotSuite) TestChangeSnapshot(c *check.C) {
 	}
 }
 
+func (s *snapshotSuite) TestChangeSnapshotPassphrase(c *check.C) {
+	defer daemon.MockSnapshotCheck(func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error) {
+		return []string{"foo"}, state.NewTaskSet(), nil
+	})()
+	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error) {
+		return []string{"foo"}, state.NewTaskSet(), nil
+	})()
+	var passphrases []string
+	defer daemon.MockSnapshotSetPassphrase(func(_ *state.State, setID uint64, passphrase string, ts *state.TaskSet) {
+		c.Check(setID, check.Equals, uint64(42))
+		c.Check(ts, check.NotNil)
+		passphrases = append(passphrases, passphrase)
+	})()
+
+	for _, action := range []string{"check", "restore"} {
+		comm := check.Commentf("%s", action)
+		body := fmt.Sprintf(`{"set": 42, "action": "%s", "passphrase": "%s-sekrit"}`, action, action)
+		req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(body))
+		c.Assert(err, check.IsNil, comm)
+
+		rsp := s.asyncReq(c, req, nil)
+		c.Check(rsp.Status, check.Equals, 202, comm)
+	}
+	c.Check(passphrases, check.DeepEquals, []string{"check-sekrit", "restore-sekrit"})
+}
+
+func (s *snapshotSuite) TestChangeSnapshotPush(c *check.C) {
+	defer daemon.MockSnapshotPush(func(st *state.State, setID uint64) ([]string, *state.TaskSet, error) {
+		c.Check(setID, check.Equals, uint64(42))
+		return []string{"foo"}, state.NewTaskSet(st.NewTask("push-snapshot", "...")), nil
+	})()
+
+	req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(`{"set": 42, "action": "push"}`))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 202)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "push-snapshot")
+	c.Check(chg.Summary(), check.Equals, "Push of snapshot set #42")
+}
+
+func (s *snapshotSuite) TestChangeSnapshotPushNoRemoteTarget(c *check.C) {
+	defer daemon.MockSnapshotPush(func(st *state.State, setID uint64) ([]string, *state.TaskSet, error) {
+		return nil, nil, snapshotstate.ErrNoRemoteTarget
+	})()
+
+	req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(`{"set": 42, "action": "push"}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "no remote snapshot target configured")
+}
+
+func (s *snapshotSuite) TestListRemoteSnapshots(c *check.C) {
+	t := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer daemon.MockSnapshotRemoteList(func(context.Context, *state.State) ([]client.RemoteSnapshot, error) {
+		return []client.RemoteSnapshot{{Name: "1_x.snapshot", Size: 42, Time: t}}, nil
+	})()
+
+	req, err := http.NewRequest("GET", "/v2/snapshots/remote", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 200)
+	c.Check(rsp.Result, check.DeepEquals, []client.RemoteSnapshot{{Name: "1_x.snapshot", Size: 42, Time: t}})
+}
+
+func (s *snapshotSuite) TestListRemoteSnapshotsErrors(c *check.C) {
+	for _, t := range []struct {
+		err     error
+		status  int
+		message string
+	}{
+		{snapshotstate.ErrNoRemoteTarget, 400, "no remote snapshot target configured"},
+		{errors.New("boom"), 500, "boom"},
+	} {
+		restore := daemon.MockSnapshotRemoteList(func(context.Context, *state.State) ([]client.RemoteSnapshot, error) {
+			return nil, t.err
+		})
+		req, err := http.NewRequest("GET", "/v2/snapshots/remote", nil)
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, t.status)
+		c.Check(rspe.Message, check.Equals, t.message)
+		restore()
+	}
+}
+
+func (s *snapshotSuite) TestImportRemoteSnapshot(c *check.C) {
+	defer daemon.MockSnapshotRemoteImport(func(_ context.Context, _ *state.State, name, passphrase string) (uint64, []string, error) {
+		c.Check(name, check.Equals, "1_x.snapshot")
+		c.Check(passphrase, check.Equals, "sekrit")
+		return 3, []string{"foo"}, nil
+	})()
+
+	req, err := http.NewRequest("POST", "/v2/snapshots/remote", strings.NewReader(`{"action": "import", "name": "1_x.snapshot", "passphrase": "sekrit"}`))
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 200)
+	c.Check(rsp.Result, check.DeepEquals, map[string]interface{}{"set-id": uint64(3), "snaps": []string{"foo"}})
+}
+
+func (s *snapshotSuite) TestImportRemoteSnapshotBadRequest(c *check.C) {
+	defer daemon.MockSnapshotRemoteImport(func(context.Context, *state.State, string, string) (uint64, []string, error) {
+		return 0, nil, errors.New("snapshot export not found on remote target")
+	})()
+
+	for body, expected := range map[string]string{
+		`"import"`:                           "cannot decode request body into remote snapshot operation: .*",
+		`{"action": "push"}`:                 `unknown remote snapshot operation "push"`,
+		`{"action": "import"}`:               "remote snapshot import requires a name",
+		`{"action": "import", "name": "xx"}`: "snapshot export not found on remote target",
+	} {
+		req, err := http.NewRequest("POST", "/v2/snapshots/remote", strings.NewReader(body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(body))
+		c.Check(rspe.Message, check.Matches, expected, check.Commentf(body))
+	}
+}
+
 func (s *snapshotSuite) TestExportSnapshots(c *check.C) {
 	var snapshotExportCalled int
 
@@ -403,7 +561,7 @@ func (s *snapshotSuite) TestImportSnapshot(c *check.C) {
 
 	setID := uint64(3)
 	snapNames := []string{"baz", "bar", "foo"}
-	defer daemon.MockSnapshotImport(func(context.Context, *state.State, io.Reader) (uint64, []string, error) {
+	defer daemon.MockSnapshotImport(func(context.Context, *state.State, io.Reader, string) (uint64, []string, error) {
 		return setID, snapNames, nil
 	})()
 
@@ -417,8 +575,26 @@ func (s *snapshotSuite) TestImportSnapshot(c *check.C) {
 	c.Check(rsp.Result, check.DeepEquals, map[string]interface{}{"set-id": setID, "snaps": snapNames})
 }
 
+func (s *snapshotSuite) TestImportSnapshotPassphrase(c *check.C) {
+	data := []byte("mocked snapshot export data file")
+
+	defer daemon.MockSnapshotImport(func(_ context.Context, _ *state.State, _ io.Reader, passphrase string) (uint64, []string, error) {
+		c.Check(passphrase, check.Equals, "sekrit")
+		return 3, []string{"foo"}, nil
+	})()
+
+	req, err := http.NewRequest("POST", "/v2/snapshots", bytes.NewReader(data))
+	c.Assert(err, check.IsNil)
+	req.Header.Add("Content-Length", strconv.Itoa(len(data)))
+	req.Header.Set("Content-Type", client.SnapshotExportMediaType)
+	req.Header.Set("X-Snapd-Snapshot-Passphrase", "sekrit")
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 200)
+}
+
 func (s *snapshotSuite) TestImportSnapshotError(c *check.C) {
-	defer daemon.MockSnapshotImport(func(context.Context, *state.State, io.Reader) (uint64, []string, error) {
+	defer daemon.MockSnapshotImport(func(context.Context, *state.State, io.Reader, string) (uint64, []string, error) {
 		return uint64(0), nil, errors.New("no")
 	})()
 
@@ -447,7 +623,7 @@ func (s *snapshotSuite) TestImportSnapshotNoContentLengthError(c *check.C) {
 func (s *snapshotSuite) TestImportSnapshotLimits(c *check.C) {
 	var dataRead int
 
-	defer daemon.MockSnapshotImport(func(ctx context.Context, st *state.State, r io.Reader) (uint64, []string, error) {
+	defer daemon.MockSnapshotImport(func(ctx context.Context, st *state.State, r io.Reader, passphrase string) (uint64, []string, error) {
 		data, err := io.ReadAll(r)
 		c.Assert(err, check.IsNil)
 		dataRead = len(data)
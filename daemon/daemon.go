@@ -42,6 +42,7 @@ import (
 	"github.com/snapcore/snapd/netutil"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/auditstate"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/restart"
 	"github.com/snapcore/snapd/overlord/snapstate"
@@ -161,12 +162,31 @@ func (c *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if ucred != nil && r.Method != "GET" {
+		// keep the changes created by the request from being audit
+		// logged before their requester is recorded below
+		st.Lock()
+		release := auditstate.HoldLogging(st)
+		st.Unlock()
+		defer func() {
+			st.Lock()
+			defer st.Unlock()
+			release()
+		}()
+	}
+
 	rsp := rspf(c, r, user)
 
 	if srsp, ok := rsp.(StructuredResponse); ok {
 		rjson := srsp.JSON()
 
 		st.Lock()
+		if rjson.Change != "" && ucred != nil {
+			// remember who asked for the change, for the audit log
+			if chg := st.Change(rjson.Change); chg != nil {
+				auditstate.SetRequester(chg, ucred.Uid)
+			}
+		}
 		_, rst := restart.Pending(st)
 		st.Unlock()
 		rjson.addMaintenanceFromRestartType(rst)
@@ -43,6 +43,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/auditstate"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
 	"github.com/snapcore/snapd/overlord/ifacestate"
@@ -374,6 +375,51 @@ func (s *daemonSuite) TestFillsWarnings(c *check.C) {
 	c.Check(rst.WarningTimestamp, check.NotNil)
 }
 
+func (s *daemonSuite) TestRecordsChangeRequester(c *check.C) {
+	d := s.newTestDaemon(c)
+	st := d.overlord.State()
+
+	auditMgr := auditstate.Manager(st)
+	c.Assert(auditMgr.StartUp(), check.IsNil)
+	defer auditMgr.Stop()
+	d.overlord.Loop()
+	defer d.overlord.Stop()
+
+	var chg *state.Change
+	cmd := &Command{d: d}
+	cmd.POST = func(*Command, *http.Request, *auth.UserState) Response {
+		st.Lock()
+		chg = st.NewChange("foo", "...")
+		chg.SetStatus(state.DoneStatus)
+		st.Unlock()
+		// the change is ready before the handler even returns
+		c.Assert(auditMgr.Ensure(), check.IsNil)
+		return AsyncResponse(nil, chg.ID())
+	}
+	cmd.WriteAccess = openAccess{}
+	req, err := http.NewRequest("POST", "", nil)
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=42;socket=%s;", dirs.SnapdSocket)
+
+	rec := httptest.NewRecorder()
+	cmd.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 202)
+
+	st.Lock()
+	var uid uint32
+	c.Assert(chg.Get("requester-uid", &uid), check.IsNil)
+	c.Check(uid, check.Equals, uint32(42))
+	st.Unlock()
+
+	// and is only logged once its requester is known
+	c.Assert(auditMgr.Ensure(), check.IsNil)
+	records, err := auditstate.Records(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(records, check.HasLen, 1)
+	c.Assert(records[0].UID, check.NotNil)
+	c.Check(*records[0].UID, check.Equals, uint32(42))
+}
+
 type accessCheckFunc func(d *Daemon, r *http.Request, ucred *ucrednet, user *auth.UserState) *apiError
 
 func (f accessCheckFunc) CheckAccess(d *Daemon, r *http.Request, ucred *ucrednet, user *auth.UserState) *apiError {
@@ -80,7 +80,7 @@ func MockSnapshotForget(newForget func(*state.State, uint64, []string) ([]string
 	}
 }
 
-func MockSnapshotImport(newImport func(context.Context, *state.State, io.Reader) (uint64, []string, error)) (restore func()) {
+func MockSnapshotImport(newImport func(context.Context, *state.State, io.Reader, string) (uint64, []string, error)) (restore func()) {
 	oldImport := snapshotImport
 	snapshotImport = newImport
 	return func() {
@@ -105,3 +105,35 @@ func MustUnmarshalSnapshotAction(c *check.C, jact string) *snapshotAction {
 }
 
 type SnapshotExportResponse = snapshotExportResponse
+
+func MockSnapshotSetPassphrase(newSetPassphrase func(*state.State, uint64, string, *state.TaskSet)) (restore func()) {
+	oldSetPassphrase := snapshotSetPassphrase
+	snapshotSetPassphrase = newSetPassphrase
+	return func() {
+		snapshotSetPassphrase = oldSetPassphrase
+	}
+}
+
+func MockSnapshotPush(newPush func(*state.State, uint64) ([]string, *state.TaskSet, error)) (restore func()) {
+	oldPush := snapshotPush
+	snapshotPush = newPush
+	return func() {
+		snapshotPush = oldPush
+	}
+}
+
+func MockSnapshotRemoteList(newList func(context.Context, *state.State) ([]client.RemoteSnapshot, error)) (restore func()) {
+	oldList := snapshotRemoteList
+	snapshotRemoteList = newList
+	return func() {
+		snapshotRemoteList = oldList
+	}
+}
+
+func MockSnapshotRemoteImport(newImport func(context.Context, *state.State, string, string) (uint64, []string, error)) (restore func()) {
+	oldImport := snapshotRemoteImport
+	snapshotRemoteImport = newImport
+	return func() {
+		snapshotRemoteImport = oldImport
+	}
+}
@@ -112,6 +112,14 @@ func MockShutdownTimeout(tm time.Duration) (restore func()) {
 	}
 }
 
+func MockNoticesStreamKeepAlive(d time.Duration) (restore func()) {
+	old := noticesStreamKeepAlive
+	noticesStreamKeepAlive = d
+	return func() {
+		noticesStreamKeepAlive = old
+	}
+}
+
 func MockUnsafeReadSnapInfo(mock func(string) (*snap.Info, error)) (restore func()) {
 	oldUnsafeReadSnapInfo := unsafeReadSnapInfo
 	unsafeReadSnapInfo = mock
@@ -21,7 +21,9 @@ package daemon
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
@@ -332,6 +334,91 @@ func (rr *journalLineReaderSeqResponse) ServeHTTP(w http.ResponseWriter, r *http
 	rr.Close()
 }
 
+// noticesStreamKeepAlive is how often a comment is sent to keep a notices
+// stream without activity alive.
+var noticesStreamKeepAlive = 30 * time.Second
+
+// A noticesStreamResponse's ServeHTTP method streams the notices matching
+// the filter as server-sent events, as they are recorded, until the client
+// disconnects or the daemon stops. Each event is a notice in JSON, with the
+// time it last repeated as the event ID, so that a client reconnecting with
+// it in the Last-Event-ID header picks up where it left off.
+type noticesStreamResponse struct {
+	st     *state.State
+	filter state.NoticeFilter
+	dying  <-chan struct{}
+	// none is set when the filter cannot match any notice
+	none bool
+}
+
+func (nr *noticesStreamResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(200)
+
+	flusher, hasFlusher := w.(http.Flusher)
+	flush := func() {
+		if hasFlusher {
+			flusher.Flush()
+		}
+	}
+	flush()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-nr.dying:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	filter := nr.filter
+	for {
+		var notices []*state.Notice
+		var err error
+		waitCtx, waitCancel := context.WithTimeout(ctx, noticesStreamKeepAlive)
+		if nr.none {
+			<-waitCtx.Done()
+			err = waitCtx.Err()
+		} else {
+			nr.st.Lock()
+			notices, err = nr.st.WaitNotices(waitCtx, &filter)
+			nr.st.Unlock()
+		}
+		waitCancel()
+		if ctx.Err() != nil {
+			// the client went away or the daemon is stopping
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flush()
+			continue
+		}
+		if err != nil {
+			logger.Noticef("cannot stream notices: %v", err)
+			return
+		}
+
+		for _, n := range notices {
+			data, err := json.Marshal(n)
+			if err != nil {
+				logger.Noticef("cannot stream notices: %v", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: notice\ndata: %s\n\n", n.LastRepeated().Format(time.RFC3339Nano), data); err != nil {
+				return
+			}
+			filter.After = n.LastRepeated()
+		}
+		flush()
+	}
+}
+
 type assertResponse struct {
 	assertions []asserts.Assertion
 	bundle     bool
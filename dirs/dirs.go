@@ -66,6 +66,8 @@ var (
 
 	SnapdMaintenanceFile string
 
+	SnapRefreshPhasingFile string
+
 	SnapdStoreSSLCertsDir string
 
 	SnapSeedDir   string
@@ -77,9 +79,11 @@ var (
 	SnapAssertsSpoolDir   string
 	SnapSeqDir            string
 
-	SnapStateFile     string
-	SnapStateLockFile string
-	SnapSystemKeyFile string
+	SnapStateFile        string
+	SnapStateJournalFile string
+	SnapStateLockFile    string
+	SnapSystemKeyFile    string
+	SnapAuditLogFile     string
 
 	SnapRepairConfigFile string
 	SnapRepairDir        string
@@ -349,6 +353,12 @@ func SnapStateFileUnder(rootdir string) string {
 	return filepath.Join(rootdir, snappyDir, "state.json")
 }
 
+// SnapStateJournalFileUnder returns the path to snapd state journal file
+// under rootdir.
+func SnapStateJournalFileUnder(rootdir string) string {
+	return filepath.Join(rootdir, snappyDir, "state.journal")
+}
+
 // SnapStateLockFileUnder returns the path to snapd state lock file under rootdir.
 func SnapStateLockFileUnder(rootdir string) string {
 	return filepath.Join(rootdir, snappyDir, "state.lock")
@@ -471,6 +481,7 @@ func SetRootDir(rootdir string) {
 	SnapMountPolicyDir = filepath.Join(rootdir, snappyDir, "mount")
 	SnapCgroupPolicyDir = filepath.Join(rootdir, snappyDir, "cgroup")
 	SnapdMaintenanceFile = filepath.Join(rootdir, snappyDir, "maintenance.json")
+	SnapRefreshPhasingFile = filepath.Join(rootdir, snappyDir, "refresh-phasing.json")
 	SnapBlobDir = SnapBlobDirUnder(rootdir)
 	SnapVoidDir = filepath.Join(rootdir, snappyDir, "void")
 	// ${snappyDir}/desktop is added to $XDG_DATA_DIRS.
@@ -497,8 +508,10 @@ func SetRootDir(rootdir string) {
 	SnapSeqDir = filepath.Join(rootdir, snappyDir, "sequence")
 
 	SnapStateFile = SnapStateFileUnder(rootdir)
+	SnapStateJournalFile = SnapStateJournalFileUnder(rootdir)
 	SnapStateLockFile = SnapStateLockFileUnder(rootdir)
 	SnapSystemKeyFile = filepath.Join(rootdir, snappyDir, "system-key")
+	SnapAuditLogFile = filepath.Join(rootdir, snappyDir, "audit.log")
 
 	SnapCacheDir = filepath.Join(rootdir, "/var/cache/snapd")
 	SnapNamesFile = filepath.Join(SnapCacheDir, "names")
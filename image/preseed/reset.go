@@ -97,6 +97,8 @@ var ResetPreseededChroot = func(preseedChroot string) error {
 	// globs that yield individual files
 	globs := []string{
 		dirs.SnapStateFile,
+		dirs.SnapStateJournalFile,
+		dirs.SnapAuditLogFile + "*",
 		dirs.SnapSystemKeyFile,
 		filepath.Join(dirs.SnapBlobDir, "*.snap"),
 		filepath.Join(dirs.SnapUdevRulesDir, "*-snap.*.rules"),
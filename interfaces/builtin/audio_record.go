@@ -54,6 +54,14 @@ const audioRecordConnectedPlugAppArmor = `
 # interface is connected.
 `
 
+// With prompting, the user can also allow direct access to audio capture
+// devices. The rule is only added when prompting is enabled so that the
+// interface never grants such access without the user being asked.
+const audioRecordConnectedPlugAppArmorPrompt = `
+# Allow direct access to audio capture devices if allowed by the user.
+###PROMPT### /dev/snd/pcmC[0-9]*D[0-9]*c rw,
+`
+
 type audioRecordInterface struct{}
 
 func (iface *audioRecordInterface) Name() string {
@@ -70,6 +78,9 @@ func (iface *audioRecordInterface) StaticInfo() interfaces.StaticInfo {
 
 func (iface *audioRecordInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
 	spec.AddSnippet(audioRecordConnectedPlugAppArmor)
+	if spec.UsePromptPrefix() {
+		spec.AddSnippet(audioRecordConnectedPlugAppArmorPrompt)
+	}
 	return nil
 }
 
@@ -113,6 +113,19 @@ func (s *AudioRecordInterfaceSuite) TestAppArmor(c *C) {
 	c.Assert(spec.SecurityTags(), HasLen, 0)
 }
 
+func (s *AudioRecordInterfaceSuite) TestAppArmorPrompting(c *C) {
+	// without prompting, no direct access to capture devices is granted
+	spec := apparmor.NewSpecification(s.plug.AppSet())
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.coreSlot), IsNil)
+	c.Check(spec.SnippetForTag("snap.consumer.app"), Not(testutil.Contains), "/dev/snd/")
+
+	// with prompting, access to capture devices is prompted for
+	backend := &apparmor.Backend{}
+	spec = backend.NewSpecification(s.plug.AppSet(), interfaces.ConfinementOptions{AppArmorPrompting: true}).(*apparmor.Specification)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.coreSlot), IsNil)
+	c.Check(spec.SnippetForTag("snap.consumer.app"), testutil.Contains, "###PROMPT### /dev/snd/pcmC[0-9]*D[0-9]*c rw,\n")
+}
+
 func (s *AudioRecordInterfaceSuite) TestAppArmorOnClassic(c *C) {
 	restore := release.MockOnClassic(false)
 	defer restore()
@@ -82,7 +82,8 @@ func allowPathAccess(buf *bytes.Buffer, perm filesAAPerm, paths []interface{}) e
 		if err != nil {
 			return err
 		}
-		fmt.Fprintf(buf, "%s %s,\n", p, perm)
+		// access to the paths can be prompted for if prompting is enabled
+		fmt.Fprintf(buf, "###PROMPT### %s %s,\n", p, perm)
 	}
 	return nil
 }
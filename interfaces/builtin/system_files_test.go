@@ -79,11 +79,11 @@ func (s *systemFilesInterfaceSuite) TestConnectedPlugAppArmor(c *C) {
 	c.Check(apparmorSpec.SnippetForTag("snap.other.app"), Equals, `
 # Description: Can access specific system files or directories.
 # This is restricted because it gives file access to arbitrary locations.
-"/etc/read-dir2{,/,/**}" rk,
-"/etc/read-file2{,/,/**}" rk,
-"/etc/write-dir2{,/,/**}" rwkl,
-"/etc/write-file2{,/,/**}" rwkl,
-"/dev/foo@bar{,/,/**}" rwkl,
+###PROMPT### "/etc/read-dir2{,/,/**}" rk,
+###PROMPT### "/etc/read-file2{,/,/**}" rk,
+###PROMPT### "/etc/write-dir2{,/,/**}" rwkl,
+###PROMPT### "/etc/write-file2{,/,/**}" rwkl,
+###PROMPT### "/dev/foo@bar{,/,/**}" rwkl,
 `)
 }
 
@@ -21,9 +21,13 @@ package prompting
 
 import (
 	"fmt"
+	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
+	doublestar "github.com/bmatcuk/doublestar/v4"
+
 	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
 	"github.com/snapcore/snapd/interfaces/prompting/patterns"
 	"github.com/snapcore/snapd/logger"
@@ -78,6 +82,9 @@ func (c *Constraints) ToRuleConstraints(iface string, currTime time.Time) (*Rule
 	if c.PathPattern == nil {
 		return nil, prompting_errors.NewInvalidPathPatternError("", "no path pattern")
 	}
+	if err := validatePathPatternForInterface(c.PathPattern, iface); err != nil {
+		return nil, err
+	}
 	rulePermissions, err := c.Permissions.toRulePermissionMap(iface, currTime)
 	if err != nil {
 		return nil, err
@@ -108,9 +115,42 @@ func (c *RuleConstraints) ValidateForInterface(iface string, currTime time.Time)
 	if c.PathPattern == nil {
 		return false, prompting_errors.NewInvalidPathPatternError("", "no path pattern")
 	}
+	if err := validatePathPatternForInterface(c.PathPattern, iface); err != nil {
+		return false, err
+	}
 	return c.Permissions.validateForInterface(iface, currTime)
 }
 
+// ToConstraints converts the receiving RuleConstraints back to Constraints,
+// from which equivalent rule constraints can be created again later, relative
+// to the time at which they are created. Any permissions which have expired
+// relative to the given current time are omitted, and permissions with a
+// lifespan of timespan are given the duration remaining until they expire.
+func (c *RuleConstraints) ToConstraints(currTime time.Time) *Constraints {
+	permissions := make(PermissionMap, len(c.Permissions))
+	for perm, entry := range c.Permissions {
+		if entry.Expired(currTime) {
+			continue
+		}
+		permissionEntry := &PermissionEntry{
+			Outcome:  entry.Outcome,
+			Lifespan: entry.Lifespan,
+		}
+		if entry.Lifespan == LifespanTimespan {
+			remaining := entry.Expiration.Sub(currTime)
+			if rounded := remaining.Round(time.Second); rounded > 0 {
+				remaining = rounded
+			}
+			permissionEntry.Duration = remaining.String()
+		}
+		permissions[perm] = permissionEntry
+	}
+	return &Constraints{
+		PathPattern: c.PathPattern,
+		Permissions: permissions,
+	}
+}
+
 // Match returns true if the constraints match the given path, otherwise false.
 //
 // If the constraints or path are invalid, returns an error.
@@ -150,7 +190,10 @@ func (c *ReplyConstraints) ToConstraints(iface string, outcome OutcomeType, life
 	}
 	availablePerms, ok := interfacePermissionsAvailable[iface]
 	if !ok {
-		return nil, prompting_errors.NewInvalidInterfaceError(iface, availableInterfaces())
+		return nil, prompting_errors.NewInvalidInterfaceError(iface, AvailableInterfaces())
+	}
+	if err := validatePathPatternForInterface(c.PathPattern, iface); err != nil {
+		return nil, err
 	}
 	if len(c.Permissions) == 0 {
 		return nil, prompting_errors.NewPermissionsListEmptyError(iface, availablePerms)
@@ -209,6 +252,8 @@ func (c *RuleConstraintsPatch) PatchRuleConstraints(existing *RuleConstraints, i
 	}
 	if c.PathPattern == nil {
 		ruleConstraints.PathPattern = existing.PathPattern
+	} else if err := validatePathPatternForInterface(c.PathPattern, iface); err != nil {
+		return nil, err
 	}
 	if c.Permissions == nil {
 		ruleConstraints.Permissions = existing.Permissions
@@ -225,7 +270,7 @@ func (c *RuleConstraintsPatch) PatchRuleConstraints(existing *RuleConstraints, i
 	availablePerms, ok := interfacePermissionsAvailable[iface]
 	if !ok {
 		// Should not occur, as we should use the interface from the existing rule
-		return nil, prompting_errors.NewInvalidInterfaceError(iface, availableInterfaces())
+		return nil, prompting_errors.NewInvalidInterfaceError(iface, AvailableInterfaces())
 	}
 	var errs []error
 	var invalidPerms []string
@@ -270,7 +315,7 @@ type PermissionMap map[string]*PermissionEntry
 func (pm PermissionMap) toRulePermissionMap(iface string, currTime time.Time) (RulePermissionMap, error) {
 	availablePerms, ok := interfacePermissionsAvailable[iface]
 	if !ok {
-		return nil, prompting_errors.NewInvalidInterfaceError(iface, availableInterfaces())
+		return nil, prompting_errors.NewInvalidInterfaceError(iface, AvailableInterfaces())
 	}
 	if len(pm) == 0 {
 		return nil, prompting_errors.NewPermissionsListEmptyError(iface, availablePerms)
@@ -311,7 +356,7 @@ type RulePermissionMap map[string]*RulePermissionEntry
 func (pm RulePermissionMap) validateForInterface(iface string, currTime time.Time) (expired bool, err error) {
 	availablePerms, ok := interfacePermissionsAvailable[iface]
 	if !ok {
-		return false, prompting_errors.NewInvalidInterfaceError(iface, availableInterfaces())
+		return false, prompting_errors.NewInvalidInterfaceError(iface, AvailableInterfaces())
 	}
 	if len(pm) == 0 {
 		return false, prompting_errors.NewPermissionsListEmptyError(iface, availablePerms)
@@ -359,6 +404,17 @@ func (pm RulePermissionMap) Expired(currTime time.Time) bool {
 	return true
 }
 
+// HasLifespan returns true if any of the entries in the receiving permission
+// map has the given lifespan.
+func (pm RulePermissionMap) HasLifespan(lifespan LifespanType) bool {
+	for _, entry := range pm {
+		if entry.Lifespan == lifespan {
+			return true
+		}
+	}
+	return false
+}
+
 // PermissionEntry holds the outcome associated with a particular permission
 // and the lifespan for which that outcome is applicable.
 //
@@ -417,15 +473,16 @@ type RulePermissionEntry struct {
 //
 // This is the case if the permission has a lifespan of timespan and the
 // current time is after its expiration time.
+//
+// Permissions with a lifespan of session do not expire with time. Instead,
+// they are removed from the rule database when the session of the user to
+// whom they apply ends.
 func (e *RulePermissionEntry) Expired(currTime time.Time) bool {
 	switch e.Lifespan {
 	case LifespanTimespan:
 		if !currTime.Before(e.Expiration) {
 			return true
 		}
-		// TODO: add lifespan session
-		//case LifespanSession:
-		// TODO: return true if the user session has changed
 	}
 	return false
 }
@@ -455,7 +512,12 @@ var (
 	// List of permissions available for each interface. This also defines the
 	// order in which the permissions should be presented.
 	interfacePermissionsAvailable = map[string][]string{
-		"home": {"read", "write", "execute"},
+		"home":            {"read", "write", "execute"},
+		"removable-media": {"read", "write", "execute"},
+		"personal-files":  {"read", "write"},
+		"system-files":    {"read", "write"},
+		"camera":          {"access"},
+		"audio-record":    {"access"},
 	}
 
 	// A mapping from interfaces which support AppArmor file permissions to
@@ -470,11 +532,45 @@ var (
 			"write":   notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_CREATE | notify.AA_MAY_DELETE | notify.AA_MAY_RENAME | notify.AA_MAY_SETATTR | notify.AA_MAY_CHMOD | notify.AA_MAY_LOCK | notify.AA_MAY_LINK,
 			"execute": notify.AA_MAY_EXEC | notify.AA_EXEC_MMAP,
 		},
+		"removable-media": {
+			"read":    notify.AA_MAY_READ | notify.AA_MAY_GETATTR,
+			"write":   notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_CREATE | notify.AA_MAY_DELETE | notify.AA_MAY_RENAME | notify.AA_MAY_SETATTR | notify.AA_MAY_CHMOD | notify.AA_MAY_LOCK | notify.AA_MAY_LINK,
+			"execute": notify.AA_MAY_EXEC | notify.AA_EXEC_MMAP,
+		},
+		"personal-files": {
+			"read":  notify.AA_MAY_READ | notify.AA_MAY_GETATTR | notify.AA_MAY_LOCK,
+			"write": notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_CREATE | notify.AA_MAY_DELETE | notify.AA_MAY_RENAME | notify.AA_MAY_SETATTR | notify.AA_MAY_CHMOD | notify.AA_MAY_LINK,
+		},
+		"system-files": {
+			"read":  notify.AA_MAY_READ | notify.AA_MAY_GETATTR | notify.AA_MAY_LOCK,
+			"write": notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_CREATE | notify.AA_MAY_DELETE | notify.AA_MAY_RENAME | notify.AA_MAY_SETATTR | notify.AA_MAY_CHMOD | notify.AA_MAY_LINK,
+		},
+		"camera": {
+			"access": notify.AA_MAY_READ | notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_GETATTR | notify.AA_MAY_SETATTR | notify.AA_MAY_LOCK,
+		},
+		"audio-record": {
+			"access": notify.AA_MAY_READ | notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_GETATTR | notify.AA_MAY_SETATTR | notify.AA_MAY_LOCK,
+		},
+	}
+
+	// Device interfaces mediate access to device nodes rather than to
+	// arbitrary paths, so the path patterns of their rules are keyed on the
+	// device nodes listed here, and access is a single permission.
+	interfaceDeviceNodePatterns = map[string][]string{
+		"camera":       {"/dev/video*", "/dev/vchiq"},
+		"audio-record": {"/dev/snd/pcmC*D*c"},
+	}
+
+	// The locations to which the removable-media interface grants access.
+	removableMediaPathPatterns = []string{
+		"/media/*/**",
+		"/run/media/*/**",
+		"/mnt/**",
 	}
 )
 
-// availableInterfaces returns the list of supported interfaces.
-func availableInterfaces() []string {
+// AvailableInterfaces returns the list of interfaces which support prompting.
+func AvailableInterfaces() []string {
 	interfaces := make([]string, 0, len(interfacePermissionsAvailable))
 	for iface := range interfacePermissionsAvailable {
 		interfaces = append(interfaces, iface)
@@ -493,6 +589,106 @@ func AvailablePermissions(iface string) ([]string, error) {
 	return available, nil
 }
 
+// InterfacePathPatterns returns the path patterns matching the paths to which
+// a connected plug of the given interface with the given attributes grants
+// access. Any $HOME in the paths given by the attributes is replaced with the
+// given home directory.
+//
+// These are the patterns against which requests are associated with the
+// interface, and they make sensible defaults for rules for that interface.
+func InterfacePathPatterns(iface string, attrs map[string]any, homeDir string) ([]*patterns.PathPattern, error) {
+	var pathPatterns []string
+	switch iface {
+	case "home":
+		if homeDir == "" {
+			return nil, fmt.Errorf("cannot get path patterns for the home interface without a home directory")
+		}
+		pathPatterns = append(pathPatterns, filepath.Clean(homeDir)+"{,/**}")
+	case "removable-media":
+		pathPatterns = append(pathPatterns, removableMediaPathPatterns...)
+	case "camera", "audio-record":
+		pathPatterns = append(pathPatterns, interfaceDeviceNodePatterns[iface]...)
+	case "personal-files", "system-files":
+		for _, attr := range []string{"read", "write"} {
+			paths, _ := attrs[attr].([]any)
+			for _, rawPath := range paths {
+				path, ok := rawPath.(string)
+				if !ok {
+					return nil, fmt.Errorf("cannot get path patterns for the %s interface: %q must be a list of strings", iface, attr)
+				}
+				if strings.Contains(path, "$HOME") {
+					if homeDir == "" {
+						return nil, fmt.Errorf("cannot get path patterns for the %s interface without a home directory: %q", iface, path)
+					}
+					path = strings.Replace(path, "$HOME", homeDir, -1)
+				}
+				// the interface grants access to the path and, if it is a
+				// directory, to everything below it
+				pathPatterns = append(pathPatterns, filepath.Clean(path)+"{,/**}")
+			}
+		}
+	default:
+		return nil, prompting_errors.NewInvalidInterfaceError(iface, AvailableInterfaces())
+	}
+	parsed := make([]*patterns.PathPattern, 0, len(pathPatterns))
+	for _, pattern := range pathPatterns {
+		pathPattern, err := patterns.ParsePathPattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get path patterns for the %s interface: %w", iface, err)
+		}
+		parsed = append(parsed, pathPattern)
+	}
+	return parsed, nil
+}
+
+// IsDeviceInterface returns true if the given interface mediates access to
+// device nodes, in which case its rules are keyed on device nodes.
+func IsDeviceInterface(iface string) bool {
+	_, ok := interfaceDeviceNodePatterns[iface]
+	return ok
+}
+
+// validatePathPatternForInterface checks that the given path pattern can be
+// used in constraints for the given interface. Path patterns for device
+// interfaces must only match the device nodes mediated by that interface.
+func validatePathPatternForInterface(pathPattern *patterns.PathPattern, iface string) error {
+	devicePatterns, ok := interfaceDeviceNodePatterns[iface]
+	if !ok {
+		return nil
+	}
+	var err error
+	pathPattern.RenderAllVariants(func(index int, variant patterns.PatternVariant) {
+		if err != nil || variantMatchesOnlyDeviceNodes(variant.String(), devicePatterns) {
+			return
+		}
+		err = prompting_errors.NewInvalidPathPatternError(pathPattern.String(), fmt.Sprintf("must only match device nodes of the %s interface (%s)", iface, strings.Join(devicePatterns, ", ")))
+	})
+	return err
+}
+
+// variantMatchesOnlyDeviceNodes returns true if every path matched by the
+// given rendered variant is also matched by one of the given device node
+// patterns.
+//
+// The device node patterns only contain literal characters and single '*'
+// wildcards, so a variant is covered by a device node pattern exactly when
+// the device node pattern matches the variant taken as a literal path: each
+// literal character must be matched literally, and each '*' or '?' in the
+// variant must fall within a '*' of the device node pattern, which matches
+// any sequence of characters within a path component. Since '**' may match
+// across path components, variants containing it are never covered.
+func variantMatchesOnlyDeviceNodes(variant string, devicePatterns []string) bool {
+	if strings.Contains(variant, "**") {
+		return false
+	}
+	for _, devicePattern := range devicePatterns {
+		if matched, err := doublestar.Match(devicePattern, variant); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
 // AbstractPermissionsFromAppArmorPermissions returns the list of permissions
 // corresponding to the given AppArmor permissions for the given interface.
 func AbstractPermissionsFromAppArmorPermissions(iface string, permissions any) ([]string, error) {
@@ -915,11 +915,86 @@ func (s *constraintsSuite) TestRulePermissionMapExpired(c *C) {
 				Expiration: currTime.Add(time.Second),
 			},
 		},
+		{
+			"read": &prompting.RulePermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanSession,
+			},
+		},
 	} {
 		c.Check(pm.Expired(currTime), Equals, false, Commentf("%+v", pm))
 	}
 }
 
+func (s *constraintsSuite) TestRulePermissionMapHasLifespan(c *C) {
+	pm := prompting.RulePermissionMap{
+		"read": &prompting.RulePermissionEntry{
+			Outcome:  prompting.OutcomeAllow,
+			Lifespan: prompting.LifespanForever,
+		},
+		"write": &prompting.RulePermissionEntry{
+			Outcome:  prompting.OutcomeDeny,
+			Lifespan: prompting.LifespanSession,
+		},
+	}
+	c.Check(pm.HasLifespan(prompting.LifespanForever), Equals, true)
+	c.Check(pm.HasLifespan(prompting.LifespanSession), Equals, true)
+	c.Check(pm.HasLifespan(prompting.LifespanTimespan), Equals, false)
+	c.Check(prompting.RulePermissionMap{}.HasLifespan(prompting.LifespanForever), Equals, false)
+}
+
+func (s *constraintsSuite) TestRuleConstraintsToConstraints(c *C) {
+	currTime := time.Now()
+	pathPattern := mustParsePathPattern(c, "/home/test/{foo,bar}/**")
+	ruleConstraints := &prompting.RuleConstraints{
+		PathPattern: pathPattern,
+		Permissions: prompting.RulePermissionMap{
+			"read": &prompting.RulePermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanForever,
+			},
+			"write": &prompting.RulePermissionEntry{
+				Outcome:    prompting.OutcomeDeny,
+				Lifespan:   prompting.LifespanTimespan,
+				Expiration: currTime.Add(90*time.Minute + 300*time.Millisecond),
+			},
+			"execute": &prompting.RulePermissionEntry{
+				Outcome:    prompting.OutcomeAllow,
+				Lifespan:   prompting.LifespanTimespan,
+				Expiration: currTime.Add(-time.Second),
+			},
+		},
+	}
+	constraints := ruleConstraints.ToConstraints(currTime)
+	c.Check(constraints, DeepEquals, &prompting.Constraints{
+		PathPattern: pathPattern,
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanForever,
+			},
+			"write": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeDeny,
+				Lifespan: prompting.LifespanTimespan,
+				Duration: "1h30m0s",
+			},
+		},
+	})
+
+	// Converting back results in equivalent rule constraints
+	converted, err := constraints.ToRuleConstraints("home", currTime)
+	c.Assert(err, IsNil)
+	c.Check(converted.PathPattern, Equals, pathPattern)
+	c.Check(converted.Permissions["read"], DeepEquals, ruleConstraints.Permissions["read"])
+	c.Check(converted.Permissions["write"].Expiration, Equals, currTime.Add(90*time.Minute))
+	c.Check(converted.Permissions, HasLen, 2)
+
+	// Durations of less than half a second are not rounded down to zero
+	ruleConstraints.Permissions["write"].Expiration = currTime.Add(time.Millisecond)
+	constraints = ruleConstraints.ToConstraints(currTime)
+	c.Check(constraints.Permissions["write"].Duration, Equals, "1ms")
+}
+
 func constructPermissionsMaps() []map[string]map[string]any {
 	var permissionsMaps []map[string]map[string]any
 	// interfaceFilePermissionsMaps
@@ -1029,6 +1104,21 @@ func (s *constraintsSuite) TestAbstractPermissionsFromAppArmorPermissionsHappy(c
 			notify.AA_MAY_EXEC | notify.AA_MAY_WRITE | notify.AA_MAY_READ,
 			[]string{"read", "write", "execute"},
 		},
+		{
+			"removable-media",
+			notify.AA_MAY_EXEC | notify.AA_MAY_WRITE | notify.AA_MAY_READ,
+			[]string{"read", "write", "execute"},
+		},
+		{
+			"personal-files",
+			notify.AA_MAY_READ | notify.AA_MAY_LOCK,
+			[]string{"read"},
+		},
+		{
+			"system-files",
+			notify.AA_MAY_OPEN | notify.AA_MAY_WRITE,
+			[]string{"write"},
+		},
 	}
 	for _, testCase := range cases {
 		perms, err := prompting.AbstractPermissionsFromAppArmorPermissions(testCase.iface, testCase.perms)
@@ -1091,6 +1181,178 @@ func (s *constraintsSuite) TestAbstractPermissionsFromAppArmorPermissionsUnhappy
 	}
 }
 
+func (s *constraintsSuite) TestInterfacePathPatterns(c *C) {
+	for _, testCase := range []struct {
+		iface    string
+		attrs    map[string]any
+		patterns []string
+	}{
+		{
+			iface:    "home",
+			patterns: []string{"/home/test{,/**}"},
+		},
+		{
+			iface:    "removable-media",
+			patterns: []string{"/media/*/**", "/run/media/*/**", "/mnt/**"},
+		},
+		{
+			iface: "personal-files",
+			attrs: map[string]any{
+				"read":  []any{"$HOME/.config/foo", "$HOME/.bar"},
+				"write": []any{"$HOME/.local/share/foo"},
+			},
+			patterns: []string{"/home/test/.config/foo{,/**}", "/home/test/.bar{,/**}", "/home/test/.local/share/foo{,/**}"},
+		},
+		{
+			iface: "system-files",
+			attrs: map[string]any{
+				"write": []any{"/etc/foo"},
+			},
+			patterns: []string{"/etc/foo{,/**}"},
+		},
+		{
+			iface:    "system-files",
+			patterns: []string{},
+		},
+		{
+			iface:    "camera",
+			patterns: []string{"/dev/video*", "/dev/vchiq"},
+		},
+		{
+			iface:    "audio-record",
+			patterns: []string{"/dev/snd/pcmC*D*c"},
+		},
+	} {
+		pathPatterns, err := prompting.InterfacePathPatterns(testCase.iface, testCase.attrs, "/home/test/")
+		c.Assert(err, IsNil, Commentf("testCase: %+v", testCase))
+		strs := make([]string, 0, len(pathPatterns))
+		for _, pathPattern := range pathPatterns {
+			strs = append(strs, pathPattern.String())
+		}
+		c.Check(strs, DeepEquals, testCase.patterns, Commentf("testCase: %+v", testCase))
+	}
+
+	pathPatterns, err := prompting.InterfacePathPatterns("personal-files", map[string]any{"read": []any{"$HOME/.config/foo"}}, "/home/test")
+	c.Assert(err, IsNil)
+	c.Assert(pathPatterns, HasLen, 1)
+	for path, matches := range map[string]bool{
+		"/home/test/.config/foo":         true,
+		"/home/test/.config/foo/bar/baz": true,
+		"/home/test/.config/foobar":      false,
+		"/home/other/.config/foo":        false,
+	} {
+		match, err := pathPatterns[0].Match(path)
+		c.Check(err, IsNil)
+		c.Check(match, Equals, matches, Commentf("path: %s", path))
+	}
+}
+
+func (s *constraintsSuite) TestDeviceInterfaces(c *C) {
+	for iface, isDevice := range map[string]bool{
+		"camera":          true,
+		"audio-record":    true,
+		"home":            false,
+		"removable-media": false,
+		"foo":             false,
+	} {
+		c.Check(prompting.IsDeviceInterface(iface), Equals, isDevice, Commentf("interface: %s", iface))
+	}
+
+	// device interfaces have a single permission
+	for _, iface := range []string{"camera", "audio-record"} {
+		perms, err := prompting.AbstractPermissionsFromAppArmorPermissions(iface, notify.AA_MAY_OPEN|notify.AA_MAY_READ|notify.AA_MAY_WRITE)
+		c.Check(err, IsNil)
+		c.Check(perms, DeepEquals, []string{"access"})
+		aaPerms, err := prompting.AbstractPermissionsToAppArmorPermissions(iface, []string{"access"})
+		c.Check(err, IsNil)
+		c.Check(aaPerms, Equals, notify.AA_MAY_OPEN|notify.AA_MAY_READ|notify.AA_MAY_WRITE|notify.AA_MAY_APPEND|notify.AA_MAY_GETATTR|notify.AA_MAY_SETATTR|notify.AA_MAY_LOCK)
+	}
+
+	// and their rules are keyed on device nodes
+	replyConstraints := &prompting.ReplyConstraints{
+		PathPattern: mustParsePathPattern(c, "/dev/video{0,1}"),
+		Permissions: []string{"access"},
+	}
+	constraints, err := replyConstraints.ToConstraints("camera", prompting.OutcomeAllow, prompting.LifespanForever, "")
+	c.Assert(err, IsNil)
+	ruleConstraints, err := constraints.ToRuleConstraints("camera", time.Now())
+	c.Assert(err, IsNil)
+	expired, err := ruleConstraints.ValidateForInterface("camera", time.Now())
+	c.Check(err, IsNil)
+	c.Check(expired, Equals, false)
+
+	replyConstraints.PathPattern = mustParsePathPattern(c, "/home/test/**")
+	_, err = replyConstraints.ToConstraints("camera", prompting.OutcomeAllow, prompting.LifespanForever, "")
+	c.Check(err, ErrorMatches, `invalid path pattern: must only match device nodes of the camera interface \(/dev/video\*, /dev/vchiq\): "/home/test/\*\*"`)
+	constraints.PathPattern = replyConstraints.PathPattern
+	_, err = constraints.ToRuleConstraints("camera", time.Now())
+	c.Check(err, ErrorMatches, `invalid path pattern: must only match device nodes of the camera interface .*`)
+	patch := &prompting.RuleConstraintsPatch{PathPattern: replyConstraints.PathPattern}
+	_, err = patch.PatchRuleConstraints(ruleConstraints, "camera", time.Now())
+	c.Check(err, ErrorMatches, `invalid path pattern: must only match device nodes of the camera interface .*`)
+	ruleConstraints.PathPattern = replyConstraints.PathPattern
+	_, err = ruleConstraints.ValidateForInterface("camera", time.Now())
+	c.Check(err, ErrorMatches, `invalid path pattern: must only match device nodes of the camera interface .*`)
+
+	// while other interfaces do not care
+	replyConstraints.Permissions = []string{"read"}
+	_, err = replyConstraints.ToConstraints("home", prompting.OutcomeAllow, prompting.LifespanForever, "")
+	c.Check(err, IsNil)
+}
+
+func (s *constraintsSuite) TestDeviceInterfacesPathPatterns(c *C) {
+	for _, testCase := range []struct {
+		iface   string
+		pattern string
+		valid   bool
+	}{
+		{"camera", "/dev/video0", true},
+		{"camera", "/dev/video{0,1,2}", true},
+		{"camera", "/dev/video*", true},
+		{"camera", "/dev/video?", true},
+		{"camera", "/dev/{video*,vchiq}", true},
+		{"camera", "/dev/vchiq", true},
+		{"camera", "/dev/sda", false},
+		{"camera", "/dev/**", false},
+		{"camera", "/dev/*", false},
+		{"camera", "/dev/vid*", false},
+		{"camera", "/dev/video0/**", false},
+		{"camera", "/dev/video**", true},
+		{"camera", "/dev/{video0,snd/pcmC0D0c}", false},
+		{"camera", "/dev/vchiq*", false},
+		{"audio-record", "/dev/snd/pcmC0D0c", true},
+		{"audio-record", "/dev/snd/pcmC*D*c", true},
+		{"audio-record", "/dev/snd/pcmC0D0p", false},
+		{"audio-record", "/dev/snd/*", false},
+		{"audio-record", "/dev/video0", false},
+	} {
+		replyConstraints := &prompting.ReplyConstraints{
+			PathPattern: mustParsePathPattern(c, testCase.pattern),
+			Permissions: []string{"access"},
+		}
+		_, err := replyConstraints.ToConstraints(testCase.iface, prompting.OutcomeAllow, prompting.LifespanForever, "")
+		if testCase.valid {
+			c.Check(err, IsNil, Commentf("interface: %s, pattern: %s", testCase.iface, testCase.pattern))
+		} else {
+			c.Check(err, ErrorMatches, fmt.Sprintf("invalid path pattern: must only match device nodes of the %s interface .*", testCase.iface), Commentf("interface: %s, pattern: %s", testCase.iface, testCase.pattern))
+		}
+	}
+}
+
+func (s *constraintsSuite) TestInterfacePathPatternsUnhappy(c *C) {
+	_, err := prompting.InterfacePathPatterns("foo", nil, "/home/test")
+	c.Check(err, ErrorMatches, "invalid interface: \"foo\"")
+
+	_, err = prompting.InterfacePathPatterns("home", nil, "")
+	c.Check(err, ErrorMatches, "cannot get path patterns for the home interface without a home directory")
+
+	_, err = prompting.InterfacePathPatterns("personal-files", map[string]any{"read": []any{"$HOME/.foo"}}, "")
+	c.Check(err, ErrorMatches, `cannot get path patterns for the personal-files interface without a home directory: "\$HOME/.foo"`)
+
+	_, err = prompting.InterfacePathPatterns("system-files", map[string]any{"write": []any{42}}, "")
+	c.Check(err, ErrorMatches, `cannot get path patterns for the system-files interface: "write" must be a list of strings`)
+}
+
 func (s *constraintsSuite) TestAbstractPermissionsToAppArmorPermissionsHappy(c *C) {
 	cases := []struct {
 		iface string
@@ -25,6 +25,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/snapcore/snapd/strutil"
@@ -36,6 +37,9 @@ var (
 	ErrRuleNotFound   = errors.New("cannot find rule with the given ID")
 	ErrRuleNotAllowed = errors.New("user not allowed to request the rule with the given ID")
 
+	// Forbidden errors when a user tries to modify a rule they do not own
+	ErrRuleAdminOwned = errors.New("cannot modify or remove rule provisioned by an administrator")
+
 	// Validation errors, which should never be used directly apart from
 	// checking errors.Is(), and should otherwise always be wrapped in
 	// dedicated error types defined below.
@@ -105,6 +109,24 @@ func NewRuleLifespanSingleError(supported []string) *UnsupportedValueError {
 	}
 }
 
+func NewAdminRuleLifespanSessionError(supported []string) *UnsupportedValueError {
+	return &UnsupportedValueError{
+		Field:     "lifespan",
+		Msg:       `cannot create admin rule with lifespan "session"`,
+		Value:     []string{"session"},
+		Supported: supported,
+	}
+}
+
+func NewUnsupportedRulesExportVersionError(unsupported int, supported int) *UnsupportedValueError {
+	return &UnsupportedValueError{
+		Field:     "version",
+		Msg:       fmt.Sprintf("unsupported rules export version: %d", unsupported),
+		Value:     []string{strconv.Itoa(unsupported)},
+		Supported: []string{strconv.Itoa(supported)},
+	}
+}
+
 func NewInvalidInterfaceError(unsupported string, supported []string) *UnsupportedValueError {
 	return &UnsupportedValueError{
 		Field:     "interface",
@@ -125,6 +125,7 @@ func (s *promptingSuite) TestUnmarshalLifespanHappy(c *C) {
 		prompting.LifespanForever,
 		prompting.LifespanSingle,
 		prompting.LifespanTimespan,
+		prompting.LifespanSession,
 	} {
 		var flw1 fakeLifespanWrapper
 		data := []byte(fmt.Sprintf(`{"field1": "%s", "field2": "%s"}`, lifespan, lifespan))
@@ -168,6 +169,7 @@ func (s *promptingSuite) TestValidateExpiration(c *C) {
 	for _, lifespan := range []prompting.LifespanType{
 		prompting.LifespanForever,
 		prompting.LifespanSingle,
+		prompting.LifespanSession,
 	} {
 		err := lifespan.ValidateExpiration(unsetExpiration)
 		c.Check(err, IsNil)
@@ -196,6 +198,7 @@ func (s *promptingSuite) TestParseDuration(c *C) {
 	for _, lifespan := range []prompting.LifespanType{
 		prompting.LifespanForever,
 		prompting.LifespanSingle,
+		prompting.LifespanSession,
 	} {
 		expiration, err := lifespan.ParseDuration(unsetDuration, currTime)
 		c.Check(expiration.IsZero(), Equals, true)
@@ -26,7 +26,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/snapcore/snapd/dirs"
@@ -40,14 +42,14 @@ import (
 )
 
 const (
-	// initialTimeout is the duration before which prompts for a given user
-	// will expire if there has been no retrieval of prompt details for that
-	// user since the previous timeout, or if the user prompt DB was just
-	// created.
+	// initialTimeout is the default duration before which prompts for a given
+	// user will expire if there has been no retrieval of prompt details for
+	// that user since the previous timeout, or if the user prompt DB was just
+	// created. It may be overridden by the timeout policy of the prompt DB.
 	initialTimeout = 10 * time.Second
-	// activityTimeout is the duration before which prompts for a given user
-	// will expire after the most recent retrieval of prompt details for that
-	// user.
+	// activityTimeout is the default duration before which prompts for a given
+	// user will expire after the most recent retrieval of prompt details for
+	// that user. It may be overridden by the timeout policy of the prompt DB.
 	activityTimeout = 10 * time.Minute
 	// maxOutstandingPromptsPerUser is an arbitrary limit.
 	// TODO: review this limit after some usage.
@@ -350,17 +352,18 @@ func (udb *userPromptDB) timeoutCallback(pdb *PromptDB, user uint32) {
 	// overwrite a newly-set activity timeout with an initial timeout.
 	// With the lock held, no activity can occur, so no activity timeout
 	// can be set.
-	if udb.expirationTimer.Reset(initialTimeout) {
+	if udb.expirationTimer.Reset(pdb.TimeoutPolicy().Timeout) {
 		// Timer was active again, suggesting that some activity caused
 		// the timer to be reset at some point between the timer firing
 		// and the lock being released and subsequently acquired by this
-		// function. So reset the timer to activityTimeout, and do not
+		// function. So reset the timer to the activity timeout, and do not
 		// purge prompts.
-		udb.activityResetExpiration()
+		udb.activityResetExpiration(pdb.TimeoutPolicy().ActivityTimeout)
 		pdb.mutex.Unlock()
 		return
 	}
 	expiredPrompts := udb.prompts
+	policy := pdb.TimeoutPolicy()
 	// Clear all outstanding prompts for the user
 	udb.prompts = nil
 	udb.ids = make(map[prompting.IDType]int) // TODO: clear() once we're on Go 1.21+
@@ -369,15 +372,79 @@ func (udb *userPromptDB) timeoutCallback(pdb *PromptDB, user uint32) {
 	data := map[string]string{"resolved": "expired"}
 	for _, p := range expiredPrompts {
 		pdb.notifyPrompt(user, p.ID, data)
-		p.sendReply(prompting.OutcomeDeny) // ignore any error, should not occur
+		p.sendReply(policy.defaultReply(p.Interface)) // ignore any error, should not occur
 	}
 }
 
 // activityResetExpiration resets the expiration timer for prompts for the
-// receiving user prompt DB. Returns true if the timer had been active, false
-// if the timer had expired or been stopped.
-func (udb *userPromptDB) activityResetExpiration() bool {
-	return udb.expirationTimer.Reset(activityTimeout)
+// receiving user prompt DB to the given activity timeout. Returns true if the
+// timer had been active, false if the timer had expired or been stopped.
+func (udb *userPromptDB) activityResetExpiration(timeout time.Duration) bool {
+	return udb.expirationTimer.Reset(timeout)
+}
+
+// TimeoutPolicy describes when outstanding prompts expire and how the requests
+// associated with them are replied to when they do.
+type TimeoutPolicy struct {
+	// Timeout is the duration before which prompts for a given user expire if
+	// there has been no retrieval of prompt details for that user since the
+	// previous timeout.
+	Timeout time.Duration
+	// ActivityTimeout is the duration before which prompts for a given user
+	// expire after the most recent retrieval of prompt details for that user,
+	// such as by a prompting client which is about to ask the user.
+	ActivityTimeout time.Duration
+	// DefaultReply is the outcome with which expired prompts are replied to,
+	// unless there is an entry for the interface of the prompt in
+	// InterfaceDefaultReplies.
+	DefaultReply prompting.OutcomeType
+	// InterfaceDefaultReplies maps interface names to the outcome with which
+	// expired prompts for that interface are replied to.
+	InterfaceDefaultReplies map[string]prompting.OutcomeType
+}
+
+// DefaultTimeoutPolicy returns the timeout policy used by a new prompt DB,
+// under which prompts expire after 10 seconds without client activity, or 10
+// minutes after the most recent client activity, and the corresponding
+// requests are denied.
+func DefaultTimeoutPolicy() TimeoutPolicy {
+	return TimeoutPolicy{
+		Timeout:         initialTimeout,
+		ActivityTimeout: activityTimeout,
+		DefaultReply:    prompting.OutcomeDeny,
+	}
+}
+
+// validate returns an error if either timeout is not positive or if any of the
+// default replies is not a valid outcome for a supported interface.
+func (policy *TimeoutPolicy) validate() error {
+	if policy.Timeout <= 0 {
+		return fmt.Errorf("invalid prompt timeout %v: must be positive", policy.Timeout)
+	}
+	if policy.ActivityTimeout <= 0 {
+		return fmt.Errorf("invalid prompt activity timeout %v: must be positive", policy.ActivityTimeout)
+	}
+	if _, err := policy.DefaultReply.AsBool(); err != nil {
+		return err
+	}
+	for iface, outcome := range policy.InterfaceDefaultReplies {
+		if !strutil.ListContains(prompting.AvailableInterfaces(), iface) {
+			return prompting_errors.NewInvalidInterfaceError(iface, prompting.AvailableInterfaces())
+		}
+		if _, err := outcome.AsBool(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultReply returns the outcome with which expired prompts for the given
+// interface should be replied to.
+func (policy *TimeoutPolicy) defaultReply(iface string) prompting.OutcomeType {
+	if outcome, ok := policy.InterfaceDefaultReplies[iface]; ok {
+		return outcome
+	}
+	return policy.DefaultReply
 }
 
 // PromptDB stores outstanding prompts in memory and ensures that new prompts
@@ -394,6 +461,12 @@ type PromptDB struct {
 	// notifyPrompt is a closure which will be called to record a notice when a
 	// prompt is added, merged, modified, or resolved.
 	notifyPrompt func(userID uint32, promptID prompting.IDType, data map[string]string) error
+	// timeoutPolicy holds the TimeoutPolicy which determines when prompts
+	// expire and how they are replied to when they do. It is accessed
+	// atomically rather than under the DB mutex, so that it can be set by
+	// callers which hold locks which the DB mutex may be held while waiting
+	// on, such as the state lock acquired when recording notices.
+	timeoutPolicy atomic.Value
 }
 
 // New creates and returns a new prompt database.
@@ -416,9 +489,34 @@ func New(notifyPrompt func(userID uint32, promptID prompting.IDType, data map[st
 		notifyPrompt: notifyPrompt,
 		maxIDMmap:    maxIDMmap,
 	}
+	pdb.timeoutPolicy.Store(DefaultTimeoutPolicy())
 	return &pdb, nil
 }
 
+// SetTimeoutPolicy sets the policy which determines when prompts expire and
+// how the corresponding requests are replied to when they do. If the given
+// policy is invalid, an error is returned and the existing policy is kept.
+//
+// The new timeout applies from the next time the expiration timer of a user
+// is reset, while the new default replies apply to any prompts which expire
+// after this call.
+//
+// The DB mutex is not acquired, so this may be called while holding the state
+// lock.
+func (pdb *PromptDB) SetTimeoutPolicy(policy TimeoutPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	pdb.timeoutPolicy.Store(policy)
+	return nil
+}
+
+// TimeoutPolicy returns the policy which determines when prompts expire and
+// how the corresponding requests are replied to when they do.
+func (pdb *PromptDB) TimeoutPolicy() TimeoutPolicy {
+	return pdb.timeoutPolicy.Load().(TimeoutPolicy)
+}
+
 var timeAfterFunc = func(d time.Duration, f func()) timeutil.Timer {
 	return timeutil.AfterFunc(d, f)
 }
@@ -457,7 +555,7 @@ func (pdb *PromptDB) AddOrMerge(metadata *prompting.Metadata, path string, reque
 		userEntry = &userPromptDB{
 			ids: make(map[prompting.IDType]int),
 		}
-		userEntry.expirationTimer = timeAfterFunc(initialTimeout, func() {
+		userEntry.expirationTimer = timeAfterFunc(pdb.TimeoutPolicy().Timeout, func() {
 			userEntry.timeoutCallback(pdb, metadata.User)
 		})
 		pdb.perUser[metadata.User] = userEntry
@@ -523,13 +621,28 @@ func (pdb *PromptDB) Prompts(user uint32, clientActivity bool) ([]*Prompt, error
 		return nil, nil
 	}
 	if clientActivity {
-		userEntry.activityResetExpiration()
+		userEntry.activityResetExpiration(pdb.TimeoutPolicy().ActivityTimeout)
 	}
 	promptsCopy := make([]*Prompt, len(userEntry.prompts))
 	copy(promptsCopy, userEntry.prompts)
 	return promptsCopy, nil
 }
 
+// UsersWithPrompts returns the IDs of the users who have outstanding prompts,
+// in ascending order.
+func (pdb *PromptDB) UsersWithPrompts() []uint32 {
+	pdb.mutex.RLock()
+	defer pdb.mutex.RUnlock()
+	var users []uint32
+	for user, userEntry := range pdb.perUser {
+		if len(userEntry.prompts) > 0 {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i] < users[j] })
+	return users
+}
+
 // PromptWithID returns the prompt with the given ID for the given user.
 //
 // If clientActivity is true, reset the expiration timeout for prompts for
@@ -557,7 +670,7 @@ func (pdb *PromptDB) promptWithID(user uint32, id prompting.IDType, clientActivi
 		return nil, nil, prompting_errors.ErrPromptNotFound
 	}
 	if clientActivity {
-		userEntry.activityResetExpiration()
+		userEntry.activityResetExpiration(pdb.TimeoutPolicy().ActivityTimeout)
 	}
 	prompt, err := userEntry.get(id)
 	if err != nil {
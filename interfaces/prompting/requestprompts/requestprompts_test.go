@@ -455,6 +455,47 @@ func (s *requestpromptsSuite) TestAddOrMergeTooMany(c *C) {
 	}
 }
 
+func (s *requestpromptsSuite) TestUsersWithPrompts(c *C) {
+	restore := requestprompts.MockSendReply(func(listenerReq *listener.Request, allowedPermission any) error {
+		return nil
+	})
+	defer restore()
+
+	notifyPrompt := func(userID uint32, promptID prompting.IDType, data map[string]string) error {
+		return nil
+	}
+	pdb, err := requestprompts.New(notifyPrompt)
+	c.Assert(err, IsNil)
+
+	c.Check(pdb.UsersWithPrompts(), HasLen, 0)
+
+	permissions := []string{"read"}
+	var prompts []*requestprompts.Prompt
+	for _, user := range []uint32{s.defaultUser + 1, s.defaultUser, s.defaultUser + 1} {
+		metadata := &prompting.Metadata{
+			User:      user,
+			Snap:      "nextcloud",
+			Interface: "home",
+		}
+		path := fmt.Sprintf("/home/test/%d.txt", len(prompts))
+		prompt, merged, err := pdb.AddOrMerge(metadata, path, permissions, permissions, &listener.Request{})
+		c.Assert(err, IsNil)
+		c.Assert(merged, Equals, false)
+		prompts = append(prompts, prompt)
+	}
+
+	c.Check(pdb.UsersWithPrompts(), DeepEquals, []uint32{s.defaultUser, s.defaultUser + 1})
+
+	// Users whose prompts have all been resolved are omitted
+	clientActivity := false
+	_, err = pdb.Reply(s.defaultUser, prompts[1].ID, prompting.OutcomeAllow, clientActivity)
+	c.Assert(err, IsNil)
+	c.Check(pdb.UsersWithPrompts(), DeepEquals, []uint32{s.defaultUser + 1})
+
+	c.Assert(pdb.Close(), IsNil)
+	c.Check(pdb.UsersWithPrompts(), HasLen, 0)
+}
+
 func (s *requestpromptsSuite) TestPromptWithIDErrors(c *C) {
 	restore := requestprompts.MockSendReply(func(listenerReq *listener.Request, allowedPermission any) error {
 		c.Fatalf("should not have called sendReply")
@@ -1186,6 +1227,143 @@ func (s *requestpromptsSuite) TestPromptExpiration(c *C) {
 	c.Assert(timer.FireCount(), Equals, 3)
 }
 
+func (s *requestpromptsSuite) TestPromptExpirationTimeoutPolicy(c *C) {
+	var timer *testtime.TestTimer
+	restore := requestprompts.MockTimeAfterFunc(func(d time.Duration, f func()) timeutil.Timer {
+		if timer != nil {
+			c.Fatalf("created more than one timer")
+		}
+		c.Check(d, Equals, 30*time.Second)
+		timer = testtime.AfterFunc(d, f)
+		return timer
+	})
+	defer restore()
+
+	replyChan := make(chan any, 1)
+	restore = requestprompts.MockSendReply(func(listenerReq *listener.Request, allowedPermission any) error {
+		replyChan <- allowedPermission
+		return nil
+	})
+	defer restore()
+
+	pdb, err := requestprompts.New(func(userID uint32, promptID prompting.IDType, data map[string]string) error {
+		return nil
+	})
+	c.Assert(err, IsNil)
+	defer pdb.Close()
+
+	err = pdb.SetTimeoutPolicy(requestprompts.TimeoutPolicy{
+		Timeout:         30 * time.Second,
+		ActivityTimeout: 2 * time.Minute,
+		DefaultReply:    prompting.OutcomeAllow,
+	})
+	c.Assert(err, IsNil)
+
+	metadata := &prompting.Metadata{
+		User:      s.defaultUser,
+		Snap:      "firefox",
+		Interface: "home",
+	}
+	path := "/home/test/foo"
+	requestedPermissions := []string{"read", "write", "execute"}
+	outstandingPermissions := []string{"write", "execute"}
+
+	_, _, err = pdb.AddOrMerge(metadata, path, requestedPermissions, outstandingPermissions, &listener.Request{})
+	c.Assert(err, IsNil)
+
+	// Prompt should not expire after the default timeout
+	timer.Elapse(requestprompts.InitialTimeout)
+	c.Assert(timer.FireCount(), Equals, 0)
+
+	// Prompt should expire after the configured timeout, and all requested
+	// permissions should be allowed by the default reply
+	timer.Elapse(30*time.Second - requestprompts.InitialTimeout)
+	c.Assert(timer.FireCount(), Equals, 1)
+	expected, err := prompting.AbstractPermissionsToAppArmorPermissions("home", requestedPermissions)
+	c.Assert(err, IsNil)
+	c.Check(<-replyChan, Equals, expected)
+
+	// A default reply for the interface takes precedence
+	err = pdb.SetTimeoutPolicy(requestprompts.TimeoutPolicy{
+		Timeout:         30 * time.Second,
+		ActivityTimeout: 2 * time.Minute,
+		DefaultReply:    prompting.OutcomeAllow,
+		InterfaceDefaultReplies: map[string]prompting.OutcomeType{
+			"home": prompting.OutcomeDeny,
+		},
+	})
+	c.Assert(err, IsNil)
+
+	_, _, err = pdb.AddOrMerge(metadata, path, requestedPermissions, outstandingPermissions, &listener.Request{})
+	c.Assert(err, IsNil)
+
+	timer.Elapse(30 * time.Second)
+	c.Assert(timer.FireCount(), Equals, 2)
+	// Only the permission which was not outstanding is allowed
+	expected, err = prompting.AbstractPermissionsToAppArmorPermissions("home", []string{"read"})
+	c.Assert(err, IsNil)
+	c.Check(<-replyChan, Equals, expected)
+
+	// Client activity extends the timeout to the configured activity timeout
+	_, _, err = pdb.AddOrMerge(metadata, path, requestedPermissions, outstandingPermissions, &listener.Request{})
+	c.Assert(err, IsNil)
+	clientActivity := true
+	_, err = pdb.Prompts(s.defaultUser, clientActivity)
+	c.Assert(err, IsNil)
+
+	timer.Elapse(30 * time.Second)
+	c.Assert(timer.FireCount(), Equals, 2)
+	timer.Elapse(2*time.Minute - 30*time.Second)
+	c.Assert(timer.FireCount(), Equals, 3)
+	c.Check(<-replyChan, Equals, expected)
+}
+
+func (s *requestpromptsSuite) TestSetTimeoutPolicyErrors(c *C) {
+	pdb, err := requestprompts.New(func(userID uint32, promptID prompting.IDType, data map[string]string) error {
+		return nil
+	})
+	c.Assert(err, IsNil)
+	defer pdb.Close()
+
+	for _, testCase := range []struct {
+		policy requestprompts.TimeoutPolicy
+		errStr string
+	}{
+		{
+			requestprompts.TimeoutPolicy{DefaultReply: prompting.OutcomeDeny},
+			"invalid prompt timeout 0s: must be positive",
+		},
+		{
+			requestprompts.TimeoutPolicy{Timeout: time.Second, DefaultReply: prompting.OutcomeDeny},
+			"invalid prompt activity timeout 0s: must be positive",
+		},
+		{
+			requestprompts.TimeoutPolicy{Timeout: time.Second, ActivityTimeout: time.Minute},
+			`invalid outcome: "".*`,
+		},
+		{
+			requestprompts.TimeoutPolicy{
+				Timeout:                 time.Second,
+				ActivityTimeout:         time.Minute,
+				DefaultReply:            prompting.OutcomeDeny,
+				InterfaceDefaultReplies: map[string]prompting.OutcomeType{"foo": prompting.OutcomeAllow},
+			},
+			`invalid interface: "foo".*`,
+		},
+		{
+			requestprompts.TimeoutPolicy{
+				Timeout:                 time.Second,
+				ActivityTimeout:         time.Minute,
+				DefaultReply:            prompting.OutcomeDeny,
+				InterfaceDefaultReplies: map[string]prompting.OutcomeType{"camera": "maybe"},
+			},
+			`invalid outcome: "maybe".*`,
+		},
+	} {
+		c.Check(pdb.SetTimeoutPolicy(testCase.policy), ErrorMatches, testCase.errStr)
+	}
+}
+
 func (s *requestpromptsSuite) TestPromptExpirationRace(c *C) {
 	callbackSignaller := make(chan bool, 0)
 	var timer *testtime.TestTimer
@@ -28,6 +28,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -40,11 +41,24 @@ import (
 	"github.com/snapcore/snapd/strutil"
 )
 
+// AdminUser is the user ID under which admin-provisioned rules are stored.
+//
+// Requests from root are always denied without consulting any rules, so root
+// has no use for rules of its own. Instead, rules created by root are admin
+// rules, which are provisioned by an administrator and apply to the requests
+// of all users. Users can see these rules, but cannot modify or remove them.
+//
+// Whether a rule is an admin rule is recorded in the rule itself when it is
+// created, and only that record is trusted afterwards: rules of root which
+// were stored without it, such as by an older snapd, are not admin rules.
+const AdminUser uint32 = 0
+
 // Rule stores the contents of a request rule.
 type Rule struct {
 	ID          prompting.IDType           `json:"id"`
 	Timestamp   time.Time                  `json:"timestamp"`
 	User        uint32                     `json:"user"`
+	Admin       bool                       `json:"admin,omitempty"`
 	Snap        string                     `json:"snap"`
 	Interface   string                     `json:"interface"`
 	Constraints *prompting.RuleConstraints `json:"constraints"`
@@ -62,6 +76,37 @@ func (rule *Rule) expired(currTime time.Time) bool {
 	return rule.Constraints.Permissions.Expired(currTime)
 }
 
+// appliesTo returns true if the receiving rule applies to the given user,
+// either because it is a rule of that user or because it is an admin rule.
+func (rule *Rule) appliesTo(user uint32) bool {
+	return rule.User == user || rule.Admin
+}
+
+// owner returns the owner under which the receiving rule is stored in the
+// tree of rules.
+func (rule *Rule) owner() ruleOwner {
+	return ruleOwner{user: rule.User, admin: rule.Admin}
+}
+
+// ruleOwner identifies the subtree in which the rules of a user are stored.
+//
+// Admin rules are stored apart from any other rules of the admin user, so that
+// only rules which are explicitly marked as admin rules apply to the requests
+// of all users.
+type ruleOwner struct {
+	user  uint32
+	admin bool
+}
+
+// adminOwner is the owner of all admin rules.
+var adminOwner = ruleOwner{user: AdminUser, admin: true}
+
+// hasSessionPermissions returns true if any of the permissions of the
+// receiving rule has a lifespan of session.
+func (rule *Rule) hasSessionPermissions() bool {
+	return rule.Constraints.Permissions.HasLifespan(prompting.LifespanSession)
+}
+
 // variantEntry stores the actual pattern variant struct which can be used to
 // match paths, and a map from rule IDs whose path patterns render to this
 // variant to the relevant permission entry from that rule. All non-expired
@@ -116,15 +161,16 @@ type RuleDB struct {
 	indexByID map[prompting.IDType]int
 	rules     []*Rule
 
-	// Rules are stored in a tree according to user, snap, interface, and
+	// Rules are stored in a tree according to owner, snap, interface, and
 	// permission to simplify the process of checking whether a given request
 	// is matched by existing rules, and which of those rules has precedence.
-	perUser map[uint32]*userDB
+	perOwner map[ruleOwner]*userDB
 
 	dbPath string
 	// notifyRule is a closure which will be called to record a notice when a
-	// rule is added, patched, or removed.
-	notifyRule func(userID uint32, ruleID prompting.IDType, data map[string]string) error
+	// rule is added, patched, or removed. A nil userID means that the notice
+	// is public, as it is for admin rules.
+	notifyRule func(userID *uint32, ruleID prompting.IDType, data map[string]string) error
 }
 
 // New creates a new rule database, loads existing rules from the database file,
@@ -137,7 +183,10 @@ type RuleDB struct {
 // expired, or removed. In order to guarantee the order of notices, notifyRule
 // is called with the prompt DB lock held, so it should not block for a
 // substantial amount of time (such as to lock and modify snapd state).
-func New(notifyRule func(userID uint32, ruleID prompting.IDType, data map[string]string) error) (*RuleDB, error) {
+//
+// Notices for admin rules are recorded with a nil user ID, since admin rules
+// apply to all users, so every user must be notified when they change.
+func New(notifyRule func(userID *uint32, ruleID prompting.IDType, data map[string]string) error) (*RuleDB, error) {
 	maxIDFilepath := filepath.Join(prompting.StateDir(), "request-rule-max-id")
 
 	if err := prompting.EnsureStateDir(); err != nil {
@@ -181,7 +230,7 @@ type rulesDBJSON struct {
 func (rdb *RuleDB) load() (retErr error) {
 	rdb.indexByID = make(map[prompting.IDType]int)
 	rdb.rules = make([]*Rule, 0)
-	rdb.perUser = make(map[uint32]*userDB)
+	rdb.perOwner = make(map[ruleOwner]*userDB)
 
 	expiredRules := make(map[prompting.IDType]bool)
 
@@ -209,6 +258,10 @@ func (rdb *RuleDB) load() (retErr error) {
 
 	var errInvalid error
 	for _, rule := range wrapped.Rules {
+		if rule.Admin && rule.User != AdminUser {
+			errInvalid = fmt.Errorf("internal error: admin rule %s stored for user %d", rule.ID, rule.User)
+			break
+		}
 		expired, err := rule.validate(currTime)
 		if err != nil {
 			// we're loading previously saved rules, so this should not happen
@@ -232,11 +285,11 @@ func (rdb *RuleDB) load() (retErr error) {
 		// The DB on disk was invalid, so drop every rule and start over
 		data := map[string]string{"removed": "dropped"}
 		for _, rule := range wrapped.Rules {
-			rdb.notifyRule(rule.User, rule.ID, data)
+			rdb.notify(rule, data)
 		}
 		rdb.indexByID = make(map[prompting.IDType]int)
 		rdb.rules = make([]*Rule, 0)
-		rdb.perUser = make(map[uint32]*userDB)
+		rdb.perOwner = make(map[ruleOwner]*userDB)
 
 		// Save the empty rule DB to disk to overwrite the previous one which
 		// was invalid.
@@ -249,7 +302,7 @@ func (rdb *RuleDB) load() (retErr error) {
 		if expiredRules[rule.ID] {
 			data = expiredData
 		}
-		rdb.notifyRule(rule.User, rule.ID, data)
+		rdb.notify(rule, data)
 	}
 
 	if len(expiredRules) > 0 {
@@ -259,6 +312,19 @@ func (rdb *RuleDB) load() (retErr error) {
 	return nil
 }
 
+// notify records a notice for the given rule with the given data.
+//
+// Admin rules apply to all users, so their notices are public, rather than
+// being recorded for the admin user alone.
+func (rdb *RuleDB) notify(rule *Rule, data map[string]string) {
+	if rule.owner() == adminOwner {
+		rdb.notifyRule(nil, rule.ID, data)
+		return
+	}
+	userID := rule.User
+	rdb.notifyRule(&userID, rule.ID, data)
+}
+
 // save writes the current state of the rule database to the database file.
 //
 // The caller must ensure that the database lock is held.
@@ -440,7 +506,7 @@ func (rdb *RuleDB) addRuleToTree(rule *Rule) *prompting_errors.RuleConflictError
 // The caller must ensure that the database lock is held for writing, and that
 // the given entry is not expired.
 func (rdb *RuleDB) addRulePermissionToTree(rule *Rule, permission string, permissionEntry *prompting.RulePermissionEntry) []prompting_errors.RuleConflict {
-	permVariants := rdb.ensurePermissionDBForUserSnapInterfacePermission(rule.User, rule.Snap, rule.Interface, permission)
+	permVariants := rdb.ensurePermissionDBForOwnerSnapInterfacePermission(rule.owner(), rule.Snap, rule.Interface, permission)
 
 	newVariantEntries := make(map[string]variantEntry, rule.Constraints.PathPattern.NumVariants())
 	partiallyExpiredRules := make(map[prompting.IDType]bool)
@@ -513,7 +579,7 @@ func (rdb *RuleDB) addRulePermissionToTree(rule *Rule, permission string, permis
 		_, err = rdb.removeRuleByID(ruleID)
 		// Error shouldn't occur. If it does, the rule was already removed.
 		if err == nil {
-			rdb.notifyRule(maybeExpired.User, maybeExpired.ID, expiredData)
+			rdb.notify(maybeExpired, expiredData)
 		}
 	}
 
@@ -555,7 +621,7 @@ func (rdb *RuleDB) removeRuleFromTree(rule *Rule) error {
 //
 // The caller must ensure that the database lock is held for writing.
 func (rdb *RuleDB) removeRulePermissionFromTree(rule *Rule, permission string) error {
-	permVariants, ok := rdb.permissionDBForUserSnapInterfacePermission(rule.User, rule.Snap, rule.Interface, permission)
+	permVariants, ok := rdb.permissionDBForOwnerSnapInterfacePermission(rule.owner(), rule.Snap, rule.Interface, permission)
 	if !ok || permVariants == nil {
 		err := fmt.Errorf("internal error: no rules in the rule tree for user %d, snap %q, interface %q, permission %q", rule.User, rule.Snap, rule.Interface, permission)
 		return err
@@ -595,12 +661,12 @@ func joinInternalErrors(errs []error) error {
 	return fmt.Errorf("%w\n%v", prompting_errors.ErrRuleDBInconsistent, joinedErr)
 }
 
-// permissionDBForUserSnapInterfacePermission returns the permission DB for the
-// given user, snap, interface, and permission, if it exists.
+// permissionDBForOwnerSnapInterfacePermission returns the permission DB for the
+// given owner, snap, interface, and permission, if it exists.
 //
 // The caller must ensure that the database lock is held.
-func (rdb *RuleDB) permissionDBForUserSnapInterfacePermission(user uint32, snap string, iface string, permission string) (*permissionDB, bool) {
-	userSnaps := rdb.perUser[user]
+func (rdb *RuleDB) permissionDBForOwnerSnapInterfacePermission(owner ruleOwner, snap string, iface string, permission string) (*permissionDB, bool) {
+	userSnaps := rdb.perOwner[owner]
 	if userSnaps == nil {
 		return nil, false
 	}
@@ -619,18 +685,18 @@ func (rdb *RuleDB) permissionDBForUserSnapInterfacePermission(user uint32, snap
 	return permVariants, true
 }
 
-// ensurePermissionDBForUserSnapInterfacePermission returns the permission DB
-// for the given user, snap, interface, and permission, or creates it if it
+// ensurePermissionDBForOwnerSnapInterfacePermission returns the permission DB
+// for the given owner, snap, interface, and permission, or creates it if it
 // does not yet exist.
 //
 // The caller must ensure that the database lock is held for writing.
-func (rdb *RuleDB) ensurePermissionDBForUserSnapInterfacePermission(user uint32, snap string, iface string, permission string) *permissionDB {
-	userSnaps := rdb.perUser[user]
+func (rdb *RuleDB) ensurePermissionDBForOwnerSnapInterfacePermission(owner ruleOwner, snap string, iface string, permission string) *permissionDB {
+	userSnaps := rdb.perOwner[owner]
 	if userSnaps == nil {
 		userSnaps = &userDB{
 			PerSnap: make(map[string]*snapDB),
 		}
-		rdb.perUser[user] = userSnaps
+		rdb.perOwner[owner] = userSnaps
 	}
 	snapInterfaces := userSnaps.PerSnap[snap]
 	if snapInterfaces == nil {
@@ -703,7 +769,7 @@ func (rdb *RuleDB) AddRule(user uint32, snap string, iface string, constraints *
 		return nil, err
 	}
 
-	rdb.notifyRule(user, newRule.ID, nil)
+	rdb.notify(newRule, nil)
 	return newRule, nil
 }
 
@@ -719,6 +785,11 @@ func (rdb *RuleDB) makeNewRule(user uint32, snap string, iface string, constrain
 	if err != nil {
 		return nil, err
 	}
+	admin := user == AdminUser
+	if admin && ruleConstraints.Permissions.HasLifespan(prompting.LifespanSession) {
+		// Admin rules apply to all users, not to the session of the admin
+		return nil, prompting_errors.NewAdminRuleLifespanSessionError(prompting.SupportedAdminRuleLifespans)
+	}
 
 	// Don't consume an ID until now, when we know the rule is valid
 	id, _ := rdb.maxIDMmap.NextID()
@@ -727,6 +798,7 @@ func (rdb *RuleDB) makeNewRule(user uint32, snap string, iface string, constrain
 		ID:          id,
 		Timestamp:   currTime,
 		User:        user,
+		Admin:       admin,
 		Snap:        snap,
 		Interface:   iface,
 		Constraints: ruleConstraints,
@@ -771,10 +843,28 @@ var isPathPermAllowedByRuleDB = func(rdb *RuleDB, user uint32, snap string, ifac
 // isPathPermAllowed checks whether the given path with the given permission is
 // allowed or denied by existing rules for the given user, snap, and interface.
 // If no rule applies, returns prompting_errors.ErrNoMatchingRule.
+//
+// Admin rules take precedence over the rules of the user, so that users cannot
+// override the policy provisioned by an administrator. The rules of the user
+// are only considered if no admin rule applies.
 func (rdb *RuleDB) isPathPermAllowed(user uint32, snap string, iface string, path string, permission string) (bool, error) {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
-	permissionMap, ok := rdb.permissionDBForUserSnapInterfacePermission(user, snap, iface, permission)
+	allowed, err := rdb.isPathPermAllowedForOwner(adminOwner, snap, iface, path, permission)
+	if !errors.Is(err, prompting_errors.ErrNoMatchingRule) {
+		return allowed, err
+	}
+	return rdb.isPathPermAllowedForOwner(ruleOwner{user: user}, snap, iface, path, permission)
+}
+
+// isPathPermAllowedForOwner checks whether the given path with the given
+// permission is allowed or denied by the rules stored for the given owner,
+// snap, and interface. If no rule applies, returns
+// prompting_errors.ErrNoMatchingRule.
+//
+// The caller must ensure that the database lock is held.
+func (rdb *RuleDB) isPathPermAllowedForOwner(owner ruleOwner, snap string, iface string, path string, permission string) (bool, error) {
+	permissionMap, ok := rdb.permissionDBForOwnerSnapInterfacePermission(owner, snap, iface, permission)
 	if !ok || permissionMap == nil {
 		return false, prompting_errors.ErrNoMatchingRule
 	}
@@ -828,12 +918,12 @@ func (rdb *RuleDB) RuleWithID(user uint32, id prompting.IDType) (*Rule, error) {
 	return rdb.lookupRuleByIDForUser(user, id)
 }
 
-// Rules returns all rules which apply to the given user.
+// Rules returns all rules which apply to the given user, including admin rules.
 func (rdb *RuleDB) Rules(user uint32) []*Rule {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user
+		return rule.appliesTo(user)
 	}
 	return rdb.rulesInternal(ruleFilter)
 }
@@ -865,40 +955,42 @@ func (rdb *RuleDB) rulesInternal(ruleFilter func(rule *Rule) bool) []*Rule {
 	return rules
 }
 
-// RulesForSnap returns all rules which apply to the given user and snap.
+// RulesForSnap returns all rules which apply to the given user and snap,
+// including admin rules.
 func (rdb *RuleDB) RulesForSnap(user uint32, snap string) []*Rule {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user && rule.Snap == snap
+		return rule.appliesTo(user) && rule.Snap == snap
 	}
 	return rdb.rulesInternal(ruleFilter)
 }
 
 // RulesForInterface returns all rules which apply to the given user and
-// interface.
+// interface, including admin rules.
 func (rdb *RuleDB) RulesForInterface(user uint32, iface string) []*Rule {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user && rule.Interface == iface
+		return rule.appliesTo(user) && rule.Interface == iface
 	}
 	return rdb.rulesInternal(ruleFilter)
 }
 
 // RulesForSnapInterface returns all rules which apply to the given user, snap,
-// and interface.
+// and interface, including admin rules.
 func (rdb *RuleDB) RulesForSnapInterface(user uint32, snap string, iface string) []*Rule {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user && rule.Snap == snap && rule.Interface == iface
+		return rule.appliesTo(user) && rule.Snap == snap && rule.Interface == iface
 	}
 	return rdb.rulesInternal(ruleFilter)
 }
 
-// lookupRuleByIDForUser returns the rule with the given ID, if it exists, for the
-// given user. Otherwise, returns an error.
+// lookupRuleByIDForUser returns the rule with the given ID, if it exists and
+// applies to the given user. Admin rules apply to all users. Otherwise, returns
+// an error.
 //
 // The caller must ensure that the database lock is held.
 func (rdb *RuleDB) lookupRuleByIDForUser(user uint32, id prompting.IDType) (*Rule, error) {
@@ -906,15 +998,33 @@ func (rdb *RuleDB) lookupRuleByIDForUser(user uint32, id prompting.IDType) (*Rul
 	if err != nil {
 		return nil, err
 	}
-	if rule.User != user {
+	if !rule.appliesTo(user) {
 		return nil, prompting_errors.ErrRuleNotAllowed
 	}
 	return rule, nil
 }
 
+// lookupRuleByIDForOwner returns the rule with the given ID, if it exists and
+// can be modified by the given user. Admin rules can only be modified by the
+// admin user. Otherwise, returns an error.
+//
+// The caller must ensure that the database lock is held.
+func (rdb *RuleDB) lookupRuleByIDForOwner(user uint32, id prompting.IDType) (*Rule, error) {
+	rule, err := rdb.lookupRuleByIDForUser(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule.User != user {
+		return nil, prompting_errors.ErrRuleAdminOwned
+	}
+	return rule, nil
+}
+
 // RemoveRule the rule with the given ID from the rule database. If the rule
 // does not apply to the given user, returns prompting_errors.ErrRuleNotAllowed.
-// If successful, saves the database to disk.
+// If the rule is an admin rule and the given user is not the admin user,
+// returns prompting_errors.ErrRuleAdminOwned. If successful, saves the database
+// to disk.
 func (rdb *RuleDB) RemoveRule(user uint32, id prompting.IDType) (*Rule, error) {
 	rdb.mutex.Lock()
 	defer rdb.mutex.Unlock()
@@ -923,7 +1033,7 @@ func (rdb *RuleDB) RemoveRule(user uint32, id prompting.IDType) (*Rule, error) {
 		return nil, prompting_errors.ErrRulesClosed
 	}
 
-	rule, err := rdb.lookupRuleByIDForUser(user, id)
+	rule, err := rdb.lookupRuleByIDForOwner(user, id)
 	if err != nil {
 		// The rule doesn't exist or the user doesn't have access
 		return nil, err
@@ -945,7 +1055,7 @@ func (rdb *RuleDB) RemoveRule(user uint32, id prompting.IDType) (*Rule, error) {
 	// rule was affected. We want the rule fully removed, so this is fine.
 
 	data := map[string]string{"removed": "removed"}
-	rdb.notifyRule(user, id, data)
+	rdb.notify(rule, data)
 	return rule, nil
 }
 
@@ -1000,7 +1110,7 @@ func (rdb *RuleDB) removeRulesInternal(user uint32, rules []*Rule) error {
 		rdb.removeRuleFromTree(rule)
 		// If error occurs, rule was still fully removed from tree, and no other
 		// rule was affected. We want the rule fully removed, so this is fine.
-		rdb.notifyRule(user, rule.ID, data)
+		rdb.notify(rule, data)
 	}
 	return nil
 }
@@ -1035,6 +1145,107 @@ func (rdb *RuleDB) RemoveRulesForSnapInterface(user uint32, snap string, iface s
 	return rules, nil
 }
 
+// UsersWithSessionRules returns the IDs of all users who have rules with at
+// least one permission which has a lifespan of session, in ascending order.
+func (rdb *RuleDB) UsersWithSessionRules() []uint32 {
+	rdb.mutex.RLock()
+	defer rdb.mutex.RUnlock()
+
+	seen := make(map[uint32]bool)
+	var users []uint32
+	for _, rule := range rdb.rules {
+		if seen[rule.User] || !rule.hasSessionPermissions() {
+			continue
+		}
+		seen[rule.User] = true
+		users = append(users, rule.User)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i] < users[j] })
+	return users
+}
+
+// ExpireSessionRules removes all permissions with a lifespan of session from
+// the rules of the user with the given user ID, as the session of that user has
+// ended. Rules left without any permissions are removed from the rule database
+// entirely.
+//
+// A notice is recorded for each rule which was modified or removed. If any rule
+// changed, saves the database to disk. If an error occurs, the database is
+// left unchanged.
+func (rdb *RuleDB) ExpireSessionRules(user uint32) error {
+	rdb.mutex.Lock()
+	defer rdb.mutex.Unlock()
+
+	if rdb.maxIDMmap.IsClosed() {
+		return prompting_errors.ErrRulesClosed
+	}
+
+	var origRules []*Rule
+	for _, rule := range rdb.rules {
+		if rule.User == user && rule.hasSessionPermissions() {
+			origRules = append(origRules, rule)
+		}
+	}
+	if len(origRules) == 0 {
+		return nil
+	}
+
+	var modifiedRules []*Rule
+	var expiredRules []*Rule
+	for _, origRule := range origRules {
+		// We know the rule exists, so this should not error
+		rdb.removeRuleByID(origRule.ID)
+
+		permissions := make(prompting.RulePermissionMap, len(origRule.Constraints.Permissions))
+		for perm, entry := range origRule.Constraints.Permissions {
+			if entry.Lifespan != prompting.LifespanSession {
+				permissions[perm] = entry
+			}
+		}
+		if len(permissions) == 0 {
+			expiredRules = append(expiredRules, origRule)
+			continue
+		}
+
+		newRule := &Rule{
+			ID:        origRule.ID,
+			Timestamp: origRule.Timestamp,
+			User:      origRule.User,
+			Snap:      origRule.Snap,
+			Interface: origRule.Interface,
+			Constraints: &prompting.RuleConstraints{
+				PathPattern: origRule.Constraints.PathPattern,
+				Permissions: permissions,
+			},
+		}
+		// The remaining permissions were previously part of the tree without
+		// conflicts, so this should not error.
+		rdb.addRule(newRule)
+		modifiedRules = append(modifiedRules, newRule)
+	}
+
+	if err := rdb.save(); err != nil {
+		// Roll back to the original state. All of the following should
+		// succeed, since we're reversing what we just successfully completed.
+		for _, rule := range modifiedRules {
+			rdb.removeRuleByID(rule.ID)
+		}
+		for _, rule := range origRules {
+			rdb.addRule(rule)
+		}
+		return err
+	}
+
+	for _, rule := range modifiedRules {
+		rdb.notify(rule, nil)
+	}
+	expiredData := map[string]string{"removed": "expired"}
+	for _, rule := range expiredRules {
+		rdb.notify(rule, expiredData)
+	}
+	return nil
+}
+
 // PatchRule modifies the rule with the given ID by updating the rule's
 // constraints for any patch field or permission which is set/non-empty.
 //
@@ -1054,6 +1265,9 @@ func (rdb *RuleDB) RemoveRulesForSnapInterface(user uint32, snap string, iface s
 // unmodified state, leaving the database unchanged. If the database is changed,
 // it is saved to disk.
 //
+// Admin rules can only be patched by the admin user, otherwise returns
+// prompting_errors.ErrRuleAdminOwned.
+//
 // XXX: Is there a client use-case for this API method?
 // Clients can always delete a rule and re-add it later, which is basically what
 // this method already does.
@@ -1065,7 +1279,7 @@ func (rdb *RuleDB) PatchRule(user uint32, id prompting.IDType, constraintsPatch
 		return nil, prompting_errors.ErrRulesClosed
 	}
 
-	origRule, err := rdb.lookupRuleByIDForUser(user, id)
+	origRule, err := rdb.lookupRuleByIDForOwner(user, id)
 	if err != nil {
 		return nil, err
 	}
@@ -1085,11 +1299,15 @@ func (rdb *RuleDB) PatchRule(user uint32, id prompting.IDType, constraintsPatch
 	if err != nil {
 		return nil, err
 	}
+	if origRule.Admin && ruleConstraints.Permissions.HasLifespan(prompting.LifespanSession) {
+		return nil, prompting_errors.NewAdminRuleLifespanSessionError(prompting.SupportedAdminRuleLifespans)
+	}
 
 	newRule := &Rule{
 		ID:          origRule.ID,
 		Timestamp:   currTime,
 		User:        origRule.User,
+		Admin:       origRule.Admin,
 		Snap:        origRule.Snap,
 		Interface:   origRule.Interface,
 		Constraints: ruleConstraints,
@@ -1118,6 +1336,6 @@ func (rdb *RuleDB) PatchRule(user uint32, id prompting.IDType, constraintsPatch
 		return nil, err
 	}
 
-	rdb.notifyRule(newRule.User, newRule.ID, nil)
+	rdb.notify(newRule, nil)
 	return newRule, nil
 }
@@ -45,18 +45,19 @@ func Test(t *testing.T) { TestingT(t) }
 
 type noticeInfo struct {
 	userID uint32
+	public bool
 	ruleID prompting.IDType
 	data   map[string]string
 }
 
 func (ni *noticeInfo) String() string {
-	return fmt.Sprintf("{\n\tuserID: %x\n\truleID: %s\n\tdata:   %#v\n}", ni.userID, ni.ruleID, ni.data)
+	return fmt.Sprintf("{\n\tuserID: %x\n\tpublic: %t\n\truleID: %s\n\tdata:   %#v\n}", ni.userID, ni.public, ni.ruleID, ni.data)
 }
 
 type requestrulesSuite struct {
 	testutil.BaseTest
 
-	defaultNotifyRule func(userID uint32, ruleID prompting.IDType, data map[string]string) error
+	defaultNotifyRule func(userID *uint32, ruleID prompting.IDType, data map[string]string) error
 	defaultUser       uint32
 	ruleNotices       []*noticeInfo
 }
@@ -65,12 +66,15 @@ var _ = Suite(&requestrulesSuite{})
 
 func (s *requestrulesSuite) SetUpTest(c *C) {
 	s.defaultUser = 1000
-	s.defaultNotifyRule = func(userID uint32, ruleID prompting.IDType, data map[string]string) error {
+	s.defaultNotifyRule = func(userID *uint32, ruleID prompting.IDType, data map[string]string) error {
 		info := &noticeInfo{
-			userID: userID,
+			public: userID == nil,
 			ruleID: ruleID,
 			data:   data,
 		}
+		if userID != nil {
+			info.userID = *userID
+		}
 		s.ruleNotices = append(s.ruleNotices, info)
 		return nil
 	}
@@ -161,6 +165,7 @@ func (s *requestrulesSuite) checkNewNoticesSimple(c *C, data map[string]string,
 	for i, rule := range rules {
 		info := &noticeInfo{
 			userID: rule.User,
+			public: rule.Admin && rule.User == requestrules.AdminUser,
 			ruleID: rule.ID,
 			data:   data,
 		}
@@ -746,6 +751,54 @@ func (s *requestrulesSuite) TestAddRuleErrors(c *C) {
 	s.checkNewNoticesSimple(c, nil)
 }
 
+func (s *requestrulesSuite) TestAddRuleInterfaces(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "lxd",
+		Interface:   "home",
+		PathPattern: "/home/test/.config/lxd/**",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+
+	// Rules for different interfaces with the same pattern do not conflict
+	var rules []*requestrules.Rule
+	for _, iface := range []string{"home", "personal-files", "system-files", "removable-media"} {
+		outcome := prompting.OutcomeAllow
+		if iface == "personal-files" {
+			outcome = prompting.OutcomeDeny
+		}
+		rule, err := addRuleFromTemplate(c, rdb, template, &addRuleContents{Interface: iface, Outcome: outcome})
+		c.Assert(err, IsNil, Commentf("interface: %s", iface))
+		rules = append(rules, rule)
+	}
+	s.checkWrittenRuleDB(c, rules)
+	s.checkNewNoticesSimple(c, nil, rules...)
+
+	// and each only applies to requests for its interface
+	for _, iface := range []string{"home", "system-files", "removable-media"} {
+		allowed, err := rdb.IsPathPermAllowed(s.defaultUser, "lxd", iface, "/home/test/.config/lxd/foo", "read")
+		c.Check(err, IsNil)
+		c.Check(allowed, Equals, true, Commentf("interface: %s", iface))
+	}
+	allowed, err := rdb.IsPathPermAllowed(s.defaultUser, "lxd", "personal-files", "/home/test/.config/lxd/foo", "read")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, false)
+
+	// execute is only available for some interfaces
+	rule, err := addRuleFromTemplate(c, rdb, template, &addRuleContents{Interface: "removable-media", PathPattern: "/media/test/**", Permissions: []string{"execute"}})
+	c.Check(err, IsNil)
+	c.Check(rule, NotNil)
+	for _, iface := range []string{"personal-files", "system-files"} {
+		_, err := addRuleFromTemplate(c, rdb, template, &addRuleContents{Interface: iface, Permissions: []string{"read", "execute"}})
+		c.Check(err, ErrorMatches, fmt.Sprintf(`invalid permissions for %s interface: "execute"`, iface))
+	}
+}
+
 func (s *requestrulesSuite) TestAddRuleOverlapping(c *C) {
 	rdb, err := requestrules.New(s.defaultNotifyRule)
 	c.Assert(err, IsNil)
@@ -1160,6 +1213,11 @@ func (s *requestrulesSuite) TestIsPathPermAllowedSimple(c *C) {
 			allowed:      false,
 			err:          prompting_errors.ErrNoMatchingRule,
 		},
+		{ // Rule with wrong interface
+			ruleContents: &addRuleContents{Interface: "personal-files"},
+			allowed:      false,
+			err:          prompting_errors.ErrNoMatchingRule,
+		},
 		{ // Rule with wrong pattern
 			ruleContents: &addRuleContents{PathPattern: "/home/test/path/to/other.txt"},
 			allowed:      false,
@@ -1762,6 +1820,103 @@ func (s *requestrulesSuite) TestRemoveRulesForSnapInterfaceErrors(c *C) {
 	s.checkNewNoticesSimple(c, nil)
 }
 
+func (s *requestrulesSuite) TestExpireSessionRules(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+	c.Assert(rdb, NotNil)
+
+	c.Check(rdb.UsersWithSessionRules(), HasLen, 0)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "lxd",
+		Interface:   "home",
+		PathPattern: "/home/test/foo",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanSession,
+	}
+
+	sessionRule, err := addRuleFromTemplate(c, rdb, template, &addRuleContents{})
+	c.Assert(err, IsNil)
+	mixedRule, err := rdb.AddRule(s.defaultUser, "lxd", "home", &prompting.Constraints{
+		PathPattern: mustParsePathPattern(c, "/home/test/bar"),
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanSession,
+			},
+			"write": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeDeny,
+				Lifespan: prompting.LifespanForever,
+			},
+		},
+	})
+	c.Assert(err, IsNil)
+	foreverRule, err := addRuleFromTemplate(c, rdb, template, &addRuleContents{
+		PathPattern: "/home/test/baz",
+		Lifespan:    prompting.LifespanForever,
+	})
+	c.Assert(err, IsNil)
+	otherUserRule, err := addRuleFromTemplate(c, rdb, template, &addRuleContents{
+		User: s.defaultUser + 1,
+	})
+	c.Assert(err, IsNil)
+	s.checkNewNoticesSimple(c, nil, sessionRule, mixedRule, foreverRule, otherUserRule)
+
+	c.Check(rdb.UsersWithSessionRules(), DeepEquals, []uint32{s.defaultUser, s.defaultUser + 1})
+
+	err = rdb.ExpireSessionRules(s.defaultUser)
+	c.Assert(err, IsNil)
+
+	s.checkNewNotices(c, []*noticeInfo{
+		{userID: s.defaultUser, ruleID: mixedRule.ID, data: nil},
+		{userID: s.defaultUser, ruleID: sessionRule.ID, data: map[string]string{"removed": "expired"}},
+	})
+
+	_, err = rdb.RuleWithID(s.defaultUser, sessionRule.ID)
+	c.Check(err, Equals, prompting_errors.ErrRuleNotFound)
+
+	modified, err := rdb.RuleWithID(s.defaultUser, mixedRule.ID)
+	c.Assert(err, IsNil)
+	c.Check(modified.Timestamp, Equals, mixedRule.Timestamp)
+	c.Check(modified.Constraints.PathPattern, DeepEquals, mixedRule.Constraints.PathPattern)
+	c.Check(modified.Constraints.Permissions, DeepEquals, prompting.RulePermissionMap{
+		"write": mixedRule.Constraints.Permissions["write"],
+	})
+
+	// The session permissions no longer match requests, but others still do
+	allowed, anyDenied, outstanding, err := rdb.IsRequestAllowed(s.defaultUser, "lxd", "home", "/home/test/bar", []string{"read", "write"})
+	c.Check(err, IsNil)
+	c.Check(allowed, HasLen, 0)
+	c.Check(anyDenied, Equals, true)
+	c.Check(outstanding, DeepEquals, []string{"read"})
+	allowed, _, outstanding, err = rdb.IsRequestAllowed(s.defaultUser, "lxd", "home", "/home/test/foo", []string{"read"})
+	c.Check(err, IsNil)
+	c.Check(allowed, HasLen, 0)
+	c.Check(outstanding, DeepEquals, []string{"read"})
+
+	// Rules of other users are unaffected
+	c.Check(rdb.Rules(s.defaultUser+1), DeepEquals, []*requestrules.Rule{otherUserRule})
+	c.Check(rdb.UsersWithSessionRules(), DeepEquals, []uint32{s.defaultUser + 1})
+
+	var written requestrules.RulesDBJSON
+	data, err := os.ReadFile(filepath.Join(prompting.StateDir(), "request-rules.json"))
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(data, &written), IsNil)
+	c.Check(written.Rules, HasLen, 3)
+
+	// Expiring again is a no-op
+	err = rdb.ExpireSessionRules(s.defaultUser)
+	c.Check(err, IsNil)
+	s.checkNewNoticesSimple(c, nil)
+
+	c.Assert(rdb.Close(), IsNil)
+	err = rdb.ExpireSessionRules(s.defaultUser + 1)
+	c.Check(err, Equals, prompting_errors.ErrRulesClosed)
+	s.checkNewNoticesSimple(c, nil)
+}
+
 func (s *requestrulesSuite) TestPatchRule(c *C) {
 	rdb, err := requestrules.New(s.defaultNotifyRule)
 	c.Assert(err, IsNil)
@@ -2134,3 +2289,169 @@ func (s *requestrulesSuite) TestPatchRuleExpired(c *C) {
 	}
 	c.Check(patched, DeepEquals, rule)
 }
+
+func (s *requestrulesSuite) TestAdminRules(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+	c.Assert(rdb, NotNil)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		PathPattern: "/home/test/Documents/**",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+
+	userRule, err := addRuleFromTemplate(c, rdb, template, &addRuleContents{})
+	c.Assert(err, IsNil)
+	c.Check(userRule.Admin, Equals, false)
+
+	// An admin rule may overlap with the rules of users without conflict
+	adminRule, err := rdb.AddRule(requestrules.AdminUser, "firefox", "home", &prompting.Constraints{
+		PathPattern: mustParsePathPattern(c, "/home/*/Documents/secret/**"),
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeDeny,
+				Lifespan: prompting.LifespanForever,
+			},
+		},
+	})
+	c.Assert(err, IsNil)
+	c.Check(adminRule.User, Equals, requestrules.AdminUser)
+	c.Check(adminRule.Admin, Equals, true)
+	// Admin rules apply to all users, so their notices are public
+	s.checkNewNotices(c, []*noticeInfo{
+		{userID: s.defaultUser, ruleID: userRule.ID},
+		{public: true, ruleID: adminRule.ID},
+	})
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{userRule, adminRule})
+
+	// Admin rules take precedence over the rules of the user, even if the
+	// rule of the user has a more specific path pattern
+	_, err = addRuleFromTemplate(c, rdb, template, &addRuleContents{
+		PathPattern: "/home/test/Documents/secret/file.txt",
+	})
+	c.Assert(err, IsNil)
+	s.checkNewNotices(c, s.ruleNotices) // discard
+	allowed, err := rdb.IsPathPermAllowed(s.defaultUser, "firefox", "home", "/home/test/Documents/secret/file.txt", "read")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, false)
+	allowed, err = rdb.IsPathPermAllowed(s.defaultUser, "firefox", "home", "/home/test/Documents/other.txt", "read")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, true)
+	// Admin rules apply to other users as well
+	allowed, err = rdb.IsPathPermAllowed(s.defaultUser+1, "firefox", "home", "/home/other/Documents/secret/file.txt", "read")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, false)
+	_, err = rdb.IsPathPermAllowed(s.defaultUser+1, "firefox", "home", "/home/other/Documents/other.txt", "read")
+	c.Check(err, Equals, prompting_errors.ErrNoMatchingRule)
+
+	// Admin rules are visible to all users
+	c.Check(rdb.Rules(s.defaultUser+1), DeepEquals, []*requestrules.Rule{adminRule})
+	c.Check(rdb.RulesForSnap(s.defaultUser+1, "firefox"), DeepEquals, []*requestrules.Rule{adminRule})
+	c.Check(rdb.RulesForInterface(s.defaultUser+1, "home"), DeepEquals, []*requestrules.Rule{adminRule})
+	c.Check(rdb.RulesForSnapInterface(s.defaultUser+1, "firefox", "home"), DeepEquals, []*requestrules.Rule{adminRule})
+	c.Check(rdb.RulesForSnap(s.defaultUser+1, "thunderbird"), HasLen, 0)
+	c.Check(rdb.Rules(requestrules.AdminUser), DeepEquals, []*requestrules.Rule{adminRule})
+	retrieved, err := rdb.RuleWithID(s.defaultUser+1, adminRule.ID)
+	c.Check(err, IsNil)
+	c.Check(retrieved, Equals, adminRule)
+
+	// But cannot be modified or removed by users
+	_, err = rdb.PatchRule(s.defaultUser, adminRule.ID, &prompting.RuleConstraintsPatch{})
+	c.Check(err, Equals, prompting_errors.ErrRuleAdminOwned)
+	_, err = rdb.RemoveRule(s.defaultUser, adminRule.ID)
+	c.Check(err, Equals, prompting_errors.ErrRuleAdminOwned)
+	removed, err := rdb.RemoveRulesForSnap(s.defaultUser+1, "firefox")
+	c.Check(err, IsNil)
+	c.Check(removed, HasLen, 0)
+	s.checkNewNoticesSimple(c, nil)
+
+	// Admin rules cannot have lifespan session, since they apply to all users
+	_, err = rdb.AddRule(requestrules.AdminUser, "firefox", "home", &prompting.Constraints{
+		PathPattern: mustParsePathPattern(c, "/home/*/Downloads/**"),
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanSession,
+			},
+		},
+	})
+	c.Check(err, ErrorMatches, `cannot create admin rule with lifespan "session"`)
+	sessionPatch := &prompting.RuleConstraintsPatch{
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeDeny,
+				Lifespan: prompting.LifespanSession,
+			},
+		},
+	}
+	_, err = rdb.PatchRule(requestrules.AdminUser, adminRule.ID, sessionPatch)
+	c.Check(err, ErrorMatches, `cannot create admin rule with lifespan "session"`)
+
+	// The admin can modify and remove admin rules
+	patched, err := rdb.PatchRule(requestrules.AdminUser, adminRule.ID, &prompting.RuleConstraintsPatch{
+		PathPattern: mustParsePathPattern(c, "/home/*/Documents/private/**"),
+	})
+	c.Assert(err, IsNil)
+	c.Check(patched.Admin, Equals, true)
+	removedRule, err := rdb.RemoveRule(requestrules.AdminUser, adminRule.ID)
+	c.Check(err, IsNil)
+	c.Check(removedRule, DeepEquals, patched)
+	c.Check(rdb.Rules(s.defaultUser+1), HasLen, 0)
+	s.checkNewNotices(c, []*noticeInfo{
+		{public: true, ruleID: adminRule.ID},
+		{public: true, ruleID: adminRule.ID, data: map[string]string{"removed": "removed"}},
+	})
+}
+
+func (s *requestrulesSuite) TestLoadAdminRules(c *C) {
+	dbPath := s.prepDBPath(c)
+	adminRule := s.ruleTemplateWithRead(c, prompting.IDType(1))
+	adminRule.User = requestrules.AdminUser
+	adminRule.Admin = true
+	// A rule of the admin user which is not marked as an admin rule, such as
+	// one stored by an older snapd, is not an admin rule
+	rootRule := s.ruleTemplateWithRead(c, prompting.IDType(2))
+	rootRule.User = requestrules.AdminUser
+	rootRule.Constraints.PathPattern = mustParsePathPattern(c, "/home/test/bar")
+	s.writeRules(c, dbPath, []*requestrules.Rule{adminRule, rootRule})
+
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+	c.Assert(rdb, NotNil)
+
+	loadedAdminRule, err := rdb.RuleWithID(s.defaultUser, adminRule.ID)
+	c.Assert(err, IsNil)
+	c.Check(loadedAdminRule.Admin, Equals, true)
+	allowed, _, _, err := rdb.IsRequestAllowed(s.defaultUser, "firefox", "home", "/home/test/foo", []string{"read"})
+	c.Check(err, IsNil)
+	c.Check(allowed, DeepEquals, []string{"read"})
+
+	loadedRootRule, err := rdb.RuleWithID(requestrules.AdminUser, rootRule.ID)
+	c.Assert(err, IsNil)
+	c.Check(loadedRootRule.Admin, Equals, false)
+	_, err = rdb.RuleWithID(s.defaultUser, rootRule.ID)
+	c.Check(err, Equals, prompting_errors.ErrRuleNotAllowed)
+	_, _, outstanding, err := rdb.IsRequestAllowed(s.defaultUser, "firefox", "home", "/home/test/bar", []string{"read"})
+	c.Check(err, IsNil)
+	c.Check(outstanding, DeepEquals, []string{"read"})
+}
+
+func (s *requestrulesSuite) TestLoadErrorAdminRuleOfUser(c *C) {
+	dbPath := s.prepDBPath(c)
+	good := s.ruleTemplateWithRead(c, prompting.IDType(1))
+	// Only rules of the admin user may be admin rules
+	bad := s.ruleTemplateWithRead(c, prompting.IDType(2))
+	bad.Constraints.PathPattern = mustParsePathPattern(c, "/home/test/bar")
+	bad.Admin = true
+
+	rules := []*requestrules.Rule{good, bad}
+	s.writeRules(c, dbPath, rules)
+
+	checkWritten := true
+	s.testLoadError(c, fmt.Sprintf("internal error: admin rule %s stored for user %d", bad.ID, s.defaultUser), rules, checkWritten)
+}
@@ -0,0 +1,148 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package requestrules
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/interfaces/prompting"
+	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
+)
+
+// RulesExportVersion is the version of the format in which rules are exported.
+const RulesExportVersion = 1
+
+// RulesExport is the stable format in which rules are exported from a rule
+// database and imported into another one, possibly for another user or on
+// another system. It omits everything which is specific to the rule database
+// from which the rules were exported, such as rule IDs, timestamps, and users.
+//
+// Exports are not signed. Admin rules can only be imported by root, or are
+// provided by the gadget snap, so the origin of the rules is trusted as much
+// as the root user and the gadget are.
+type RulesExport struct {
+	Version int             `json:"version"`
+	Rules   []*ExportedRule `json:"rules"`
+}
+
+// ExportedRule holds the contents of a rule in a RulesExport.
+type ExportedRule struct {
+	Snap        string                 `json:"snap"`
+	Interface   string                 `json:"interface"`
+	Constraints *prompting.Constraints `json:"constraints"`
+}
+
+// ExportRules returns the rules of the given user, in the order in which they
+// were added. Admin rules are only exported for the admin user.
+//
+// Permissions which have expired are omitted, and permissions with a lifespan
+// of timespan are exported with the duration remaining until they expire.
+func (rdb *RuleDB) ExportRules(user uint32) *RulesExport {
+	rdb.mutex.RLock()
+	defer rdb.mutex.RUnlock()
+
+	ruleFilter := func(rule *Rule) bool {
+		return rule.User == user
+	}
+	rules := rdb.rulesInternal(ruleFilter)
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	currTime := time.Now()
+	export := &RulesExport{
+		Version: RulesExportVersion,
+		Rules:   make([]*ExportedRule, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		constraints := rule.Constraints.ToConstraints(currTime)
+		if len(constraints.Permissions) == 0 {
+			// All permissions expired since the rules were retrieved
+			continue
+		}
+		export.Rules = append(export.Rules, &ExportedRule{
+			Snap:        rule.Snap,
+			Interface:   rule.Interface,
+			Constraints: constraints,
+		})
+	}
+	return export
+}
+
+// ImportRules adds the rules from the given export to the rule database for
+// the given user. Rules imported for the admin user are admin rules, which
+// apply to all users.
+//
+// Either all rules are imported or none of them. If any of the rules is
+// invalid, or conflicts with an existing rule or with another imported rule,
+// returns an error and none of the rules is added. Otherwise, records a notice
+// for each new rule, saves the database to disk, and returns the new rules.
+//
+// As when adding a single rule, existing rules which had already expired and
+// overlap with an imported rule are pruned from the database while the rules
+// are added, along with a notice. Since they no longer had any effect, they
+// are not restored if a later rule cannot be imported.
+func (rdb *RuleDB) ImportRules(user uint32, export *RulesExport) ([]*Rule, error) {
+	rdb.mutex.Lock()
+	defer rdb.mutex.Unlock()
+
+	if rdb.maxIDMmap.IsClosed() {
+		return nil, prompting_errors.ErrRulesClosed
+	}
+
+	if export.Version != RulesExportVersion {
+		return nil, prompting_errors.NewUnsupportedRulesExportVersionError(export.Version, RulesExportVersion)
+	}
+
+	newRules := make([]*Rule, 0, len(export.Rules))
+	rollback := func() {
+		for _, rule := range newRules {
+			rdb.removeRuleByID(rule.ID)
+		}
+	}
+
+	for i, exported := range export.Rules {
+		constraints := exported.Constraints
+		if constraints == nil {
+			// Let the constraints report what is missing
+			constraints = &prompting.Constraints{}
+		}
+		newRule, err := rdb.makeNewRule(user, exported.Snap, exported.Interface, constraints)
+		if err != nil {
+			rollback()
+			return nil, fmt.Errorf("cannot import rule %d: %w", i, err)
+		}
+		if err := rdb.addRule(newRule); err != nil {
+			rollback()
+			return nil, fmt.Errorf("cannot import rule %d: %w", i, err)
+		}
+		newRules = append(newRules, newRule)
+	}
+
+	if err := rdb.save(); err != nil {
+		rollback()
+		return nil, err
+	}
+
+	for _, rule := range newRules {
+		rdb.notify(rule, nil)
+	}
+	return newRules, nil
+}
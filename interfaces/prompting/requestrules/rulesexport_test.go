@@ -0,0 +1,317 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package requestrules_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces/prompting"
+	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
+	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
+)
+
+func (s *requestrulesSuite) TestExportRules(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+	c.Assert(rdb, NotNil)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		PathPattern: "/home/test/foo",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+	_, err = addRuleFromTemplate(c, rdb, template, &addRuleContents{})
+	c.Assert(err, IsNil)
+	_, err = addRuleFromTemplate(c, rdb, template, &addRuleContents{
+		Snap:     "thunderbird",
+		Outcome:  prompting.OutcomeDeny,
+		Lifespan: prompting.LifespanTimespan,
+		Duration: "1h",
+	})
+	c.Assert(err, IsNil)
+	_, err = addRuleFromTemplate(c, rdb, template, &addRuleContents{
+		PathPattern: "/home/test/bar",
+		Lifespan:    prompting.LifespanTimespan,
+		Duration:    "1ns",
+	})
+	c.Assert(err, IsNil)
+	// Rules of other users are not exported
+	_, err = addRuleFromTemplate(c, rdb, template, &addRuleContents{
+		User: s.defaultUser + 1,
+	})
+	c.Assert(err, IsNil)
+	// Nor are admin rules exported for users
+	_, err = rdb.AddRule(requestrules.AdminUser, "firefox", "home", &prompting.Constraints{
+		PathPattern: mustParsePathPattern(c, "/home/*/baz"),
+		Permissions: prompting.PermissionMap{
+			"write": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeDeny,
+				Lifespan: prompting.LifespanForever,
+			},
+		},
+	})
+	c.Assert(err, IsNil)
+
+	// Let the rule with duration "1ns" expire
+	time.Sleep(time.Millisecond)
+
+	export := rdb.ExportRules(s.defaultUser)
+	c.Assert(export, NotNil)
+	c.Check(export.Version, Equals, requestrules.RulesExportVersion)
+	c.Assert(export.Rules, HasLen, 2)
+	c.Check(export.Rules[0], DeepEquals, &requestrules.ExportedRule{
+		Snap:      "firefox",
+		Interface: "home",
+		Constraints: &prompting.Constraints{
+			PathPattern: mustParsePathPattern(c, "/home/test/foo"),
+			Permissions: prompting.PermissionMap{
+				"read": &prompting.PermissionEntry{
+					Outcome:  prompting.OutcomeAllow,
+					Lifespan: prompting.LifespanForever,
+				},
+			},
+		},
+	})
+	c.Check(export.Rules[1].Snap, Equals, "thunderbird")
+	entry := export.Rules[1].Constraints.Permissions["read"]
+	c.Assert(entry, NotNil)
+	c.Check(entry.Outcome, Equals, prompting.OutcomeDeny)
+	c.Check(entry.Lifespan, Equals, prompting.LifespanTimespan)
+	duration, err := time.ParseDuration(entry.Duration)
+	c.Assert(err, IsNil)
+	c.Check(duration > 59*time.Minute && duration <= time.Hour, Equals, true, Commentf("duration: %s", duration))
+
+	// The export format is stable
+	export.Rules = export.Rules[:1]
+	marshalled, err := json.Marshal(export)
+	c.Assert(err, IsNil)
+	c.Check(string(marshalled), Equals, `{"version":1,"rules":[{"snap":"firefox","interface":"home","constraints":{"path-pattern":"/home/test/foo","permissions":{"read":{"outcome":"allow","lifespan":"forever"}}}}]}`)
+
+	adminExport := rdb.ExportRules(requestrules.AdminUser)
+	c.Assert(adminExport.Rules, HasLen, 1)
+	c.Check(adminExport.Rules[0].Constraints.PathPattern.String(), Equals, "/home/*/baz")
+
+	emptyExport := rdb.ExportRules(s.defaultUser + 2)
+	c.Check(emptyExport, DeepEquals, &requestrules.RulesExport{
+		Version: requestrules.RulesExportVersion,
+		Rules:   []*requestrules.ExportedRule{},
+	})
+}
+
+func (s *requestrulesSuite) TestImportRules(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+	c.Assert(rdb, NotNil)
+
+	var export requestrules.RulesExport
+	err = json.Unmarshal([]byte(`{
+	"version": 1,
+	"rules": [
+		{
+			"snap": "firefox",
+			"interface": "home",
+			"constraints": {
+				"path-pattern": "/home/*/Downloads/**",
+				"permissions": {
+					"read": {"outcome": "allow", "lifespan": "forever"},
+					"write": {"outcome": "deny", "lifespan": "timespan", "duration": "1h"}
+				}
+			}
+		},
+		{
+			"snap": "thunderbird",
+			"interface": "home",
+			"constraints": {
+				"path-pattern": "/home/*/.ssh/**",
+				"permissions": {
+					"read": {"outcome": "deny", "lifespan": "forever"}
+				}
+			}
+		}
+	]
+}`), &export)
+	c.Assert(err, IsNil)
+
+	rules, err := rdb.ImportRules(s.defaultUser, &export)
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 2)
+	for _, rule := range rules {
+		c.Check(rule.User, Equals, s.defaultUser)
+		c.Check(rule.Admin, Equals, false)
+	}
+	c.Check(rules[0].Snap, Equals, "firefox")
+	c.Check(rules[1].Snap, Equals, "thunderbird")
+	s.checkNewNoticesSimple(c, nil, rules...)
+	s.checkWrittenRuleDB(c, rules)
+	c.Check(rdb.Rules(s.defaultUser), DeepEquals, rules)
+
+	allowed, err := rdb.IsPathPermAllowed(s.defaultUser, "thunderbird", "home", "/home/test/.ssh/id_rsa", "read")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, false)
+
+	// Exporting the imported rules round-trips, up to the remaining duration
+	reexported := rdb.ExportRules(s.defaultUser)
+	c.Assert(reexported.Rules, HasLen, 2)
+	reexported.Rules[0].Constraints.Permissions["write"].Duration = "1h"
+	c.Check(reexported, DeepEquals, &export)
+
+	// Rules imported for the admin user are admin rules
+	adminRules, err := rdb.ImportRules(requestrules.AdminUser, &export)
+	c.Assert(err, IsNil)
+	c.Assert(adminRules, HasLen, 2)
+	for _, rule := range adminRules {
+		c.Check(rule.User, Equals, requestrules.AdminUser)
+		c.Check(rule.Admin, Equals, true)
+	}
+	s.checkNewNoticesSimple(c, nil, adminRules...)
+	c.Check(rdb.Rules(s.defaultUser+1), DeepEquals, adminRules)
+}
+
+func (s *requestrulesSuite) TestImportRulesErrors(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+	c.Assert(rdb, NotNil)
+
+	existing, err := rdb.AddRule(s.defaultUser, "firefox", "home", &prompting.Constraints{
+		PathPattern: mustParsePathPattern(c, "/home/test/foo"),
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanForever,
+			},
+		},
+	})
+	c.Assert(err, IsNil)
+	s.checkNewNoticesSimple(c, nil, existing)
+
+	validRule := func(pattern string) *requestrules.ExportedRule {
+		return &requestrules.ExportedRule{
+			Snap:      "firefox",
+			Interface: "home",
+			Constraints: &prompting.Constraints{
+				PathPattern: mustParsePathPattern(c, pattern),
+				Permissions: prompting.PermissionMap{
+					"read": &prompting.PermissionEntry{
+						Outcome:  prompting.OutcomeDeny,
+						Lifespan: prompting.LifespanForever,
+					},
+				},
+			},
+		}
+	}
+	sessionRule := validRule("/home/test/session")
+	sessionRule.Constraints.Permissions["read"].Lifespan = prompting.LifespanSession
+	// Conflicts with another imported rule
+	conflictingRule := validRule("/home/test/{bar,baz}")
+	conflictingRule.Constraints.Permissions["read"].Outcome = prompting.OutcomeAllow
+
+	for _, testCase := range []struct {
+		user   uint32
+		export *requestrules.RulesExport
+		errStr string
+	}{
+		{
+			user: s.defaultUser,
+			export: &requestrules.RulesExport{
+				Version: 2,
+				Rules:   []*requestrules.ExportedRule{validRule("/home/test/bar")},
+			},
+			errStr: "unsupported rules export version: 2",
+		},
+		{
+			user: s.defaultUser,
+			export: &requestrules.RulesExport{
+				Version: 1,
+				Rules: []*requestrules.ExportedRule{
+					validRule("/home/test/bar"),
+					{Snap: "firefox", Interface: "home"},
+				},
+			},
+			errStr: "cannot import rule 1: invalid path pattern: no path pattern.*",
+		},
+		{
+			user: s.defaultUser,
+			export: &requestrules.RulesExport{
+				Version: 1,
+				Rules: []*requestrules.ExportedRule{
+					validRule("/home/test/bar"),
+					validRule("/home/test/foo"),
+				},
+			},
+			errStr: "cannot import rule 1: a rule with conflicting path pattern and permission already exists.*",
+		},
+		{
+			user: s.defaultUser,
+			export: &requestrules.RulesExport{
+				Version: 1,
+				Rules: []*requestrules.ExportedRule{
+					validRule("/home/test/bar"),
+					conflictingRule,
+				},
+			},
+			errStr: "cannot import rule 1: a rule with conflicting path pattern and permission already exists.*",
+		},
+		{
+			user: requestrules.AdminUser,
+			export: &requestrules.RulesExport{
+				Version: 1,
+				Rules:   []*requestrules.ExportedRule{validRule("/home/test/bar"), sessionRule},
+			},
+			errStr: `cannot import rule 1: cannot create admin rule with lifespan "session"`,
+		},
+	} {
+		rules, err := rdb.ImportRules(testCase.user, testCase.export)
+		c.Check(err, ErrorMatches, testCase.errStr)
+		c.Check(rules, IsNil)
+		// No rules were added
+		c.Check(rdb.Rules(s.defaultUser), DeepEquals, []*requestrules.Rule{existing})
+		s.checkWrittenRuleDB(c, []*requestrules.Rule{existing})
+		s.checkNewNoticesSimple(c, nil)
+	}
+
+	// Failure to save leaves the rule database unchanged
+	dbPath := filepath.Join(prompting.StateDir(), "request-rules.json")
+	c.Assert(os.Rename(dbPath, dbPath+".bak"), IsNil)
+	c.Assert(os.MkdirAll(dbPath, 0o700), IsNil)
+	rules, err := rdb.ImportRules(s.defaultUser, &requestrules.RulesExport{
+		Version: 1,
+		Rules:   []*requestrules.ExportedRule{validRule("/home/test/bar")},
+	})
+	c.Check(err, NotNil)
+	c.Check(rules, IsNil)
+	c.Check(rdb.Rules(s.defaultUser), DeepEquals, []*requestrules.Rule{existing})
+	s.checkNewNoticesSimple(c, nil)
+	c.Assert(os.Remove(dbPath), IsNil)
+	c.Assert(os.Rename(dbPath+".bak", dbPath), IsNil)
+
+	c.Assert(rdb.Close(), IsNil)
+	rules, err = rdb.ImportRules(s.defaultUser, &requestrules.RulesExport{Version: 1})
+	c.Check(err, Equals, prompting_errors.ErrRulesClosed)
+	c.Check(rules, IsNil)
+}
@@ -387,6 +387,8 @@ func delayedCrossMgrInit() {
 	snapstate.AutoAliases = AutoAliases
 	// hook the helper for getting enforced validation sets
 	snapstate.EnforcedValidationSets = TrackedEnforcedValidationSets
+	// hook the helper for keeping required revisions in the download cache
+	snapstate.EnforcedSnapRevisionDigests = EnforcedSnapRevisionDigests
 	// hook the helper for saving current validation sets to the stack
 	snapstate.AddCurrentTrackingToValidationSetsStack = addCurrentTrackingToValidationSetsHistory
 	// hook the helper for restoring validation sets tracking from the stack
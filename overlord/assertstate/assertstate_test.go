@@ -5782,3 +5782,73 @@ func (s *assertMgrSuite) TestSnapResourcePair(c *C) {
 	c.Assert(found.Provenance(), Equals, info.Provenance())
 	c.Assert(found.DeveloperID(), Equals, s.dev1Acct.AccountID())
 }
+
+func (s *assertMgrSuite) TestEnforcedSnapRevisionDigests(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	const snapID = "qOqKhntON3vR7kwEbVPsILm7bUViPDzz"
+	c.Assert(assertstate.Add(s.state, s.storeSigning.StoreAccountKey("")), IsNil)
+	c.Assert(assertstate.Add(s.state, s.dev1Acct), IsNil)
+	c.Assert(assertstate.Add(s.state, s.dev1AcctKey), IsNil)
+
+	snapDecl, err := s.storeSigning.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"series":       "16",
+		"snap-id":      snapID,
+		"snap-name":    "foo",
+		"publisher-id": s.dev1Acct.AccountID(),
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	c.Assert(assertstate.Add(s.state, snapDecl), IsNil)
+
+	digests := make(map[int]string)
+	for _, rev := range []int{7, 8} {
+		sum := sha3.Sum384([]byte(fmt.Sprintf("foo-%d", rev)))
+		digest, err := asserts.EncodeDigest(crypto.SHA3_384, sum[:])
+		c.Assert(err, IsNil)
+		snapRev, err := s.storeSigning.Sign(asserts.SnapRevisionType, map[string]interface{}{
+			"snap-id":       snapID,
+			"snap-sha3-384": digest,
+			"snap-size":     "1000",
+			"snap-revision": fmt.Sprintf("%d", rev),
+			"developer-id":  s.dev1Acct.AccountID(),
+			"timestamp":     time.Now().Format(time.RFC3339),
+		}, nil, "")
+		c.Assert(err, IsNil)
+		c.Assert(assertstate.Add(s.state, snapRev), IsNil)
+		digests[rev] = fmt.Sprintf("%x", sum)
+	}
+
+	// nothing enforced
+	enforced, err := assertstate.EnforcedSnapRevisionDigests(s.state)
+	c.Assert(err, IsNil)
+	c.Check(enforced, HasLen, 0)
+
+	vsetAs := s.validationSetAssert(c, "bar", "1", "1", "required", "8")
+	c.Assert(assertstate.Add(s.state, vsetAs), IsNil)
+	assertstate.UpdateValidationSet(s.state, &assertstate.ValidationSetTracking{
+		AccountID: s.dev1Acct.AccountID(),
+		Name:      "bar",
+		Mode:      assertstate.Enforce,
+		Current:   1,
+	})
+
+	enforced, err = assertstate.EnforcedSnapRevisionDigests(s.state)
+	c.Assert(err, IsNil)
+	c.Check(enforced, DeepEquals, []string{digests[8]})
+
+	// a required revision without a known snap-revision assertion is skipped
+	vsetAs = s.validationSetAssert(c, "bar", "2", "1", "required", "9")
+	c.Assert(assertstate.Add(s.state, vsetAs), IsNil)
+	assertstate.UpdateValidationSet(s.state, &assertstate.ValidationSetTracking{
+		AccountID: s.dev1Acct.AccountID(),
+		Name:      "bar",
+		Mode:      assertstate.Enforce,
+		Current:   2,
+	})
+
+	enforced, err = assertstate.EnforcedSnapRevisionDigests(s.state)
+	c.Assert(err, IsNil)
+	c.Check(enforced, HasLen, 0)
+}
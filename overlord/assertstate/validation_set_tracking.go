@@ -20,6 +20,8 @@
 package assertstate
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -236,6 +238,44 @@ func TrackedEnforcedValidationSetsForModel(st *state.State, model *asserts.Model
 	return sets, nil
 }
 
+// EnforcedSnapRevisionDigests returns the sha3-384 digests, hex encoded, of
+// the snap revisions required by the validation sets in enforcing mode for
+// which the snap-revision assertions are known.
+func EnforcedSnapRevisionDigests(st *state.State) ([]string, error) {
+	sets, err := TrackedEnforcedValidationSets(st)
+	if err != nil {
+		return nil, err
+	}
+
+	db := DB(st)
+	var digests []string
+	for _, vs := range sets.Sets() {
+		for _, sn := range vs.Snaps() {
+			if sn.Presence == asserts.PresenceInvalid || sn.Revision == 0 || sn.SnapID == "" {
+				continue
+			}
+			revs, err := db.FindMany(asserts.SnapRevisionType, map[string]string{
+				"snap-id":       sn.SnapID,
+				"snap-revision": fmt.Sprintf("%d", sn.Revision),
+			})
+			if err != nil {
+				if errors.Is(err, &asserts.NotFoundError{}) {
+					continue
+				}
+				return nil, err
+			}
+			for _, a := range revs {
+				digest, err := base64.RawURLEncoding.DecodeString(a.(*asserts.SnapRevision).SnapSHA3_384())
+				if err != nil {
+					return nil, err
+				}
+				digests = append(digests, hex.EncodeToString(digest))
+			}
+		}
+	}
+	return digests, nil
+}
+
 func trackedEnforcedValidationSets(st *state.State, skip func(string) bool, sets *snapasserts.ValidationSets) error {
 	valsets, err := ValidationSets(st)
 	if err != nil {
@@ -0,0 +1,360 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package auditstate implements the manager keeping an append-only log of
+// the changes performed by snapd, which outlives the changes themselves
+// being pruned from the state.
+package auditstate
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var (
+	// the audit log is rotated once it would grow beyond this size
+	auditLogMaxSize int64 = 1024 * 1024
+	// number of rotated audit logs kept besides the current one
+	auditLogRotations = 4
+)
+
+// Record is the entry of the audit log describing a change once ready.
+type Record struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Summary   string    `json:"summary"`
+	Status    string    `json:"status"`
+	Err       string    `json:"err,omitempty"`
+	SnapNames []string  `json:"snap-names,omitempty"`
+	UID       *uint32   `json:"uid,omitempty"`
+	SpawnTime time.Time `json:"spawn-time"`
+	ReadyTime time.Time `json:"ready-time"`
+}
+
+// SetRequester records on the change the uid of the user that requested it.
+func SetRequester(chg *state.Change, uid uint32) {
+	chg.Set("requester-uid", uid)
+}
+
+type holdsKey struct{}
+
+type heldKey struct{}
+
+// HoldLogging keeps the changes that become ready from being written to
+// the audit log until the returned function is called, so that the
+// requester of the changes created while serving an API request can be
+// recorded with SetRequester before they are logged. Both must be called
+// with the state lock held.
+func HoldLogging(st *state.State) (release func()) {
+	holds, _ := st.Cached(holdsKey{}).(int)
+	st.Cache(holdsKey{}, holds+1)
+	return func() {
+		holds, _ := st.Cached(holdsKey{}).(int)
+		if holds > 1 {
+			st.Cache(holdsKey{}, holds-1)
+			return
+		}
+		st.Cache(holdsKey{}, nil)
+		if st.Cached(heldKey{}) != nil {
+			st.Cache(heldKey{}, nil)
+			st.EnsureBefore(0)
+		}
+	}
+}
+
+func newRecord(chg *state.Change) (*Record, error) {
+	rec := &Record{
+		ID:        chg.ID(),
+		Kind:      chg.Kind(),
+		Summary:   chg.Summary(),
+		Status:    chg.Status().String(),
+		SpawnTime: chg.SpawnTime(),
+		ReadyTime: chg.ReadyTime(),
+	}
+	if err := chg.Err(); err != nil {
+		rec.Err = err.Error()
+	}
+	if err := chg.Get("snap-names", &rec.SnapNames); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	var uid uint32
+	if err := chg.Get("requester-uid", &uid); err == nil {
+		rec.UID = &uid
+	} else if !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// AuditManager writes every change that becomes ready to the audit log.
+type AuditManager struct {
+	state *state.State
+	// changes ready but not logged yet
+	pending map[string]bool
+
+	changeCallbackID int
+}
+
+// Manager returns a new AuditManager.
+func Manager(st *state.State) *AuditManager {
+	return &AuditManager{
+		state:   st,
+		pending: make(map[string]bool),
+	}
+}
+
+// StartUp implements StateStarterUp.Startup.
+func (m *AuditManager) StartUp() error {
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	// pick up the changes that became ready before snapd was stopped
+	for _, chg := range st.Changes() {
+		if chg.IsReady() && !isLogged(chg) {
+			m.pending[chg.ID()] = true
+		}
+	}
+	// the callback is run by the task runner, so the actual writing is
+	// left to the next Ensure
+	m.changeCallbackID = st.AddChangeStatusChangedHandler(func(chg *state.Change, old, new state.Status) {
+		if new.Ready() && !old.Ready() {
+			m.pending[chg.ID()] = true
+		}
+	})
+	return nil
+}
+
+// Stop implements StateStopper. It will unregister the change callback
+// handler from state.
+func (m *AuditManager) Stop() {
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	st.RemoveChangeStatusChangedHandler(m.changeCallbackID)
+}
+
+func isLogged(chg *state.Change) bool {
+	var logged bool
+	chg.Get("audit-logged", &logged)
+	return logged
+}
+
+// Ensure implements StateManager.Ensure. It writes the changes that became
+// ready since the previous call to the audit log.
+func (m *AuditManager) Ensure() error {
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	if len(m.pending) == 0 {
+		return nil
+	}
+	if st.Cached(holdsKey{}) != nil {
+		// retried once the logging is released
+		st.Cache(heldKey{}, true)
+		return nil
+	}
+
+	ids := make([]string, 0, len(m.pending))
+	for id := range m.pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return changeIDLess(ids[i], ids[j]) })
+
+	var logged []*state.Change
+	var records []*Record
+	for _, id := range ids {
+		chg := st.Change(id)
+		// pruned in the meantime, or already logged before a restart
+		if chg == nil || !chg.IsReady() || isLogged(chg) {
+			delete(m.pending, id)
+			continue
+		}
+		rec, err := newRecord(chg)
+		if err != nil {
+			logger.Noticef("cannot build audit record of change %s: %v", id, err)
+			delete(m.pending, id)
+			continue
+		}
+		logged = append(logged, chg)
+		records = append(records, rec)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	if err := appendRecords(records); err != nil {
+		// keep them pending to retry in the next ensure
+		return fmt.Errorf("cannot write audit log: %v", err)
+	}
+	for _, chg := range logged {
+		chg.Set("audit-logged", true)
+		delete(m.pending, chg.ID())
+	}
+	return nil
+}
+
+func changeIDLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+func rotatedLog(n int) string {
+	return fmt.Sprintf("%s.%d", dirs.SnapAuditLogFile, n)
+}
+
+func appendRecords(records []*Record) error {
+	var buf []byte
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, data...)
+		buf = append(buf, '\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dirs.SnapAuditLogFile), 0755); err != nil {
+		return err
+	}
+	if fi, err := os.Stat(dirs.SnapAuditLogFile); err == nil && fi.Size() > 0 && fi.Size()+int64(len(buf)) > auditLogMaxSize {
+		if err := rotate(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(dirs.SnapAuditLogFile, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// do not glue the records to one torn by a crash
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, fi.Size()-1); err != nil {
+			return err
+		}
+		if last[0] != '\n' {
+			buf = append([]byte{'\n'}, buf...)
+		}
+	}
+	if _, err := f.Write(buf); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// rotate moves audit.log to audit.log.1, audit.log.1 to audit.log.2 and so
+// on, dropping the oldest one.
+func rotate() error {
+	for n := auditLogRotations; n > 0; n-- {
+		from := dirs.SnapAuditLogFile
+		if n > 1 {
+			from = rotatedLog(n - 1)
+		}
+		if err := os.Rename(from, rotatedLog(n)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Filter selects records of the audit log. Empty fields match any record.
+type Filter struct {
+	Snap  string
+	Kind  string
+	UID   *uint32
+	Since time.Time
+}
+
+func (f *Filter) match(rec *Record) bool {
+	if f.Snap != "" && !strutil.ListContains(rec.SnapNames, f.Snap) {
+		return false
+	}
+	if f.Kind != "" && f.Kind != rec.Kind {
+		return false
+	}
+	if f.UID != nil && (rec.UID == nil || *rec.UID != *f.UID) {
+		return false
+	}
+	if !f.Since.IsZero() && rec.ReadyTime.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// Records returns the records of the audit log matching the filter, oldest
+// first.
+func Records(filter *Filter) ([]*Record, error) {
+	if filter == nil {
+		filter = &Filter{}
+	}
+	paths := make([]string, 0, auditLogRotations+1)
+	for n := auditLogRotations; n > 0; n-- {
+		paths = append(paths, rotatedLog(n))
+	}
+	paths = append(paths, dirs.SnapAuditLogFile)
+
+	var records []*Record
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read audit log: %v", err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, int(auditLogMaxSize))
+		for scanner.Scan() {
+			var rec Record
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				// a record torn by a crash while it was written
+				continue
+			}
+			if filter.match(&rec) {
+				records = append(records, &rec)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read audit log: %v", err)
+		}
+	}
+	return records, nil
+}
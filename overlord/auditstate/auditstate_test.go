@@ -0,0 +1,275 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package auditstate_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/auditstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func TestAuditState(t *testing.T) { TestingT(t) }
+
+type auditSuite struct {
+	st  *state.State
+	mgr *auditstate.AuditManager
+}
+
+var _ = Suite(&auditSuite{})
+
+func (s *auditSuite) SetUpTest(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	s.st = state.New(nil)
+	s.mgr = auditstate.Manager(s.st)
+	c.Assert(s.mgr.StartUp(), IsNil)
+}
+
+func (s *auditSuite) TearDownTest(c *C) {
+	s.mgr.Stop()
+	dirs.SetRootDir("")
+}
+
+func (s *auditSuite) newChange(kind string, snapNames []string) (*state.Change, *state.Task) {
+	chg := s.st.NewChange(kind, "summary of "+kind)
+	t := s.st.NewTask("foo", "...")
+	chg.AddTask(t)
+	if snapNames != nil {
+		chg.Set("snap-names", snapNames)
+	}
+	return chg, t
+}
+
+func (s *auditSuite) auditLogLines(c *C) []string {
+	data, err := os.ReadFile(dirs.SnapAuditLogFile)
+	c.Assert(err, IsNil)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func (s *auditSuite) TestEnsureLogsReadyChanges(c *C) {
+	s.st.Lock()
+	chg1, t1 := s.newChange("install-snap", []string{"foo"})
+	auditstate.SetRequester(chg1, 1000)
+	chg2, t2 := s.newChange("remove-snap", []string{"bar"})
+	t1.SetStatus(state.DoneStatus)
+	c.Check(chg2.Status(), Equals, state.DoStatus)
+	s.st.Unlock()
+
+	// only the ready change is logged
+	c.Assert(s.mgr.Ensure(), IsNil)
+	c.Check(s.auditLogLines(c), HasLen, 1)
+
+	s.st.Lock()
+	t2.Errorf("boom")
+	t2.SetStatus(state.ErrorStatus)
+	c.Check(chg2.Status(), Equals, state.ErrorStatus)
+	s.st.Unlock()
+	c.Assert(s.mgr.Ensure(), IsNil)
+	// nothing is logged twice
+	c.Assert(s.mgr.Ensure(), IsNil)
+	c.Check(s.auditLogLines(c), HasLen, 2)
+
+	records, err := auditstate.Records(nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+
+	uid := uint32(1000)
+	c.Check(records[0].ID, Equals, chg1.ID())
+	c.Check(records[0].Kind, Equals, "install-snap")
+	c.Check(records[0].Summary, Equals, "summary of install-snap")
+	c.Check(records[0].Status, Equals, "Done")
+	c.Check(records[0].Err, Equals, "")
+	c.Check(records[0].SnapNames, DeepEquals, []string{"foo"})
+	c.Check(records[0].UID, DeepEquals, &uid)
+	s.st.Lock()
+	c.Check(records[0].SpawnTime.Equal(chg1.SpawnTime()), Equals, true)
+	s.st.Unlock()
+	c.Check(records[0].ReadyTime.IsZero(), Equals, false)
+
+	c.Check(records[1].ID, Equals, chg2.ID())
+	c.Check(records[1].Status, Equals, "Error")
+	c.Check(records[1].Err, Matches, `(?s).*boom.*`)
+	c.Check(records[1].UID, IsNil)
+}
+
+func (s *auditSuite) TestHoldLogging(c *C) {
+	s.st.Lock()
+	release1 := auditstate.HoldLogging(s.st)
+	release2 := auditstate.HoldLogging(s.st)
+	chg, t := s.newChange("install-snap", []string{"foo"})
+	t.SetStatus(state.DoneStatus)
+	s.st.Unlock()
+
+	// nothing is logged while held
+	c.Assert(s.mgr.Ensure(), IsNil)
+	c.Check(dirs.SnapAuditLogFile, testutil.FileAbsent)
+
+	s.st.Lock()
+	auditstate.SetRequester(chg, 1000)
+	release1()
+	s.st.Unlock()
+	c.Assert(s.mgr.Ensure(), IsNil)
+	c.Check(dirs.SnapAuditLogFile, testutil.FileAbsent)
+
+	s.st.Lock()
+	release2()
+	s.st.Unlock()
+	c.Assert(s.mgr.Ensure(), IsNil)
+
+	records, err := auditstate.Records(nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+	uid := uint32(1000)
+	c.Check(records[0].UID, DeepEquals, &uid)
+}
+
+func (s *auditSuite) TestStartUpPicksUpUnloggedChanges(c *C) {
+	s.mgr.Stop()
+
+	s.st.Lock()
+	chg1, t1 := s.newChange("install-snap", nil)
+	t1.SetStatus(state.DoneStatus)
+	chg1.Status()
+	chg2, t2 := s.newChange("install-snap", nil)
+	t2.SetStatus(state.DoneStatus)
+	chg2.Status()
+	// already logged before snapd was stopped
+	chg2.Set("audit-logged", true)
+	s.st.Unlock()
+
+	mgr := auditstate.Manager(s.st)
+	c.Assert(mgr.StartUp(), IsNil)
+	defer mgr.Stop()
+	c.Assert(mgr.Ensure(), IsNil)
+
+	records, err := auditstate.Records(nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Check(records[0].ID, Equals, chg1.ID())
+}
+
+func (s *auditSuite) TestEnsureRetriesOnError(c *C) {
+	c.Assert(os.MkdirAll(dirs.SnapAuditLogFile, 0755), IsNil)
+
+	s.st.Lock()
+	_, t := s.newChange("install-snap", nil)
+	t.SetStatus(state.DoneStatus)
+	s.st.Unlock()
+
+	c.Check(s.mgr.Ensure(), ErrorMatches, "cannot write audit log: .*")
+
+	c.Assert(os.Remove(dirs.SnapAuditLogFile), IsNil)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	c.Check(s.auditLogLines(c), HasLen, 1)
+}
+
+func (s *auditSuite) TestRotation(c *C) {
+	restore := auditstate.MockAuditLogRotation(400, 2)
+	defer restore()
+
+	for i := 0; i < 8; i++ {
+		s.st.Lock()
+		_, t := s.newChange("install-snap", []string{"foo"})
+		t.SetStatus(state.DoneStatus)
+		s.st.Unlock()
+		c.Assert(s.mgr.Ensure(), IsNil)
+	}
+
+	for _, path := range []string{dirs.SnapAuditLogFile, dirs.SnapAuditLogFile + ".1", dirs.SnapAuditLogFile + ".2"} {
+		fi, err := os.Stat(path)
+		c.Assert(err, IsNil)
+		c.Check(fi.Size() <= 400, Equals, true)
+	}
+	c.Check(dirs.SnapAuditLogFile+".3", testutil.FileAbsent)
+
+	// the oldest records were dropped, the others are in order
+	records, err := auditstate.Records(nil)
+	c.Assert(err, IsNil)
+	c.Assert(len(records) < 8, Equals, true)
+	c.Check(records[len(records)-1].ID, Equals, "8")
+	for i := 1; i < len(records); i++ {
+		c.Check(records[i].ReadyTime.Before(records[i-1].ReadyTime), Equals, false)
+	}
+}
+
+func (s *auditSuite) TestRecordsFilter(c *C) {
+	s.st.Lock()
+	chg1, t1 := s.newChange("install-snap", []string{"foo"})
+	auditstate.SetRequester(chg1, 1000)
+	t1.SetStatus(state.DoneStatus)
+	chg2, t2 := s.newChange("remove-snap", []string{"foo", "bar"})
+	auditstate.SetRequester(chg2, 0)
+	t2.SetStatus(state.DoneStatus)
+	s.st.Unlock()
+	c.Assert(s.mgr.Ensure(), IsNil)
+
+	ids := func(f *auditstate.Filter) []string {
+		records, err := auditstate.Records(f)
+		c.Assert(err, IsNil)
+		var ids []string
+		for _, rec := range records {
+			ids = append(ids, rec.ID)
+		}
+		return ids
+	}
+	root, user := uint32(0), uint32(1000)
+	c.Check(ids(&auditstate.Filter{}), DeepEquals, []string{"1", "2"})
+	c.Check(ids(&auditstate.Filter{Snap: "bar"}), DeepEquals, []string{"2"})
+	c.Check(ids(&auditstate.Filter{Snap: "baz"}), HasLen, 0)
+	c.Check(ids(&auditstate.Filter{Kind: "install-snap"}), DeepEquals, []string{"1"})
+	c.Check(ids(&auditstate.Filter{UID: &root}), DeepEquals, []string{"2"})
+	c.Check(ids(&auditstate.Filter{UID: &user, Snap: "foo"}), DeepEquals, []string{"1"})
+	c.Check(ids(&auditstate.Filter{Since: time.Now().Add(-time.Hour)}), DeepEquals, []string{"1", "2"})
+	c.Check(ids(&auditstate.Filter{Since: time.Now().Add(time.Hour)}), HasLen, 0)
+}
+
+func (s *auditSuite) TestTornRecord(c *C) {
+	s.st.Lock()
+	_, t := s.newChange("install-snap", nil)
+	t.SetStatus(state.DoneStatus)
+	s.st.Unlock()
+	c.Assert(s.mgr.Ensure(), IsNil)
+
+	// a record interrupted half-way by a crash
+	f, err := os.OpenFile(dirs.SnapAuditLogFile, os.O_WRONLY|os.O_APPEND, 0600)
+	c.Assert(err, IsNil)
+	_, err = f.WriteString(`{"id":"42","kind":"ins`)
+	c.Assert(err, IsNil)
+	c.Assert(f.Close(), IsNil)
+
+	s.st.Lock()
+	_, t = s.newChange("remove-snap", nil)
+	t.SetStatus(state.DoneStatus)
+	s.st.Unlock()
+	c.Assert(s.mgr.Ensure(), IsNil)
+
+	c.Check(s.auditLogLines(c), HasLen, 3)
+	records, err := auditstate.Records(nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Check(records[1].Kind, Equals, "remove-snap")
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/prompting"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/restart"
@@ -37,6 +38,15 @@ import (
 	"github.com/snapcore/snapd/snap"
 )
 
+func init() {
+	supportedConfigurations["core.prompting.timeout"] = true
+	supportedConfigurations["core.prompting.activity-timeout"] = true
+	supportedConfigurations["core.prompting.default-reply"] = true
+	for _, iface := range prompting.AvailableInterfaces() {
+		supportedConfigurations[fmt.Sprintf("core.prompting.%s.default-reply", iface)] = true
+	}
+}
+
 var restartRequest = restart.Request
 
 var servicestateControl = servicestate.Control
@@ -192,3 +202,38 @@ func doExperimentalApparmorPromptingDaemonRestart(c RunTransaction, opts *fsOnly
 
 	return nil
 }
+
+// validatePromptingTimeoutPolicy validates the durations after which prompts
+// which have not been replied to expire, without and after client activity,
+// and the replies with which they are then resolved, overall and per
+// interface.
+func validatePromptingTimeoutPolicy(tr RunTransaction) error {
+	for _, key := range []string{"prompting.timeout", "prompting.activity-timeout"} {
+		timeout, err := coreCfg(tr, key)
+		if err != nil {
+			return err
+		}
+		if timeout != "" {
+			if d, err := time.ParseDuration(timeout); err != nil || d <= 0 {
+				return fmt.Errorf("%s must be a positive duration: %q", key, timeout)
+			}
+		}
+	}
+
+	keys := []string{"prompting.default-reply"}
+	for _, iface := range prompting.AvailableInterfaces() {
+		keys = append(keys, fmt.Sprintf("prompting.%s.default-reply", iface))
+	}
+	for _, key := range keys {
+		reply, err := coreCfg(tr, key)
+		if err != nil {
+			return err
+		}
+		switch prompting.OutcomeType(reply) {
+		case prompting.OutcomeUnset, prompting.OutcomeAllow, prompting.OutcomeDeny:
+		default:
+			return fmt.Errorf("%s can only be set to 'allow' or 'deny'", key)
+		}
+	}
+	return nil
+}
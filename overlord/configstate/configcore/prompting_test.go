@@ -552,6 +552,39 @@ func (s *promptingSuite) TestDoExperimentalApparmorPromptingHandlerServicesTimeo
 	})
 }
 
+func (s *promptingSuite) TestPromptingTimeoutPolicyHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]interface{}{
+			"prompting.timeout":              "1m30s",
+			"prompting.activity-timeout":     "30m",
+			"prompting.default-reply":        "allow",
+			"prompting.camera.default-reply": "deny",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *promptingSuite) TestPromptingTimeoutPolicyUnhappy(c *C) {
+	for _, tc := range []struct {
+		key, value, err string
+	}{
+		{"prompting.timeout", "soon", `prompting.timeout must be a positive duration: "soon"`},
+		{"prompting.timeout", "0s", `prompting.timeout must be a positive duration: "0s"`},
+		{"prompting.activity-timeout", "-1m", `prompting.activity-timeout must be a positive duration: "-1m"`},
+		{"prompting.default-reply", "maybe", `prompting.default-reply can only be set to 'allow' or 'deny'`},
+		{"prompting.home.default-reply", "yes", `prompting.home.default-reply can only be set to 'allow' or 'deny'`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			changes: map[string]interface{}{
+				tc.key: tc.value,
+			},
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%s=%s", tc.key, tc.value))
+	}
+}
+
 func (s *promptingSuite) mockSnapd(c *C) {
 	const snapdSnapYaml = `
 name: snapd
@@ -23,9 +23,11 @@ package configcore
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/timeutil"
 )
@@ -43,6 +45,9 @@ func init() {
 	supportedConfigurations["core.refresh.retain"] = true
 	supportedConfigurations["core.refresh.rate-limit"] = true
 	supportedConfigurations["core.refresh.max-inhibition-days"] = true
+	supportedConfigurations["core.refresh.health-rollback-window"] = true
+	supportedConfigurations["core.refresh.snap-windows"] = true
+	supportedConfigurations["core.refresh.quota-windows"] = true
 }
 
 func reportOrIgnoreInvalidManageRefreshes(tr RunTransaction, optName string) error {
@@ -163,3 +168,63 @@ func validateRefreshRateLimit(tr RunTransaction) error {
 	}
 	return nil
 }
+
+func validateRefreshHealthRollbackWindow(tr RunTransaction) error {
+	windowStr, err := coreCfg(tr, "refresh.health-rollback-window")
+	if err != nil {
+		return err
+	}
+	// unset disables rolling back
+	if windowStr == "" {
+		return nil
+	}
+	window, err := time.ParseDuration(windowStr)
+	if err != nil || window <= 0 {
+		return fmt.Errorf("health-rollback-window must be a positive duration, not %q", windowStr)
+	}
+	return nil
+}
+
+// isRefreshWindowChange returns whether the option is the refresh window of
+// a snap or of a quota group.
+func isRefreshWindowChange(k string) bool {
+	return strings.HasPrefix(k, "core.refresh.snap-windows.") || strings.HasPrefix(k, "core.refresh.quota-windows.")
+}
+
+func validateRefreshWindows(tr RunTransaction) error {
+	for _, k := range tr.Changes() {
+		if !isRefreshWindowChange(k) {
+			continue
+		}
+		option := strings.TrimPrefix(k, "core.")
+		// the windows are keyed by snap or quota group name only, which
+		// also rules out nested options as names do not contain dots
+		var err error
+		if name := strings.TrimPrefix(option, "refresh.snap-windows."); name != option {
+			err = naming.ValidateInstance(name)
+		} else {
+			err = naming.ValidateQuotaGroup(strings.TrimPrefix(option, "refresh.quota-windows."))
+		}
+		if err != nil {
+			return fmt.Errorf("cannot set %s: %v", option, err)
+		}
+		window, err := coreCfg(tr, option)
+		if err != nil {
+			return err
+		}
+		// unset removes the window
+		if window == "" {
+			continue
+		}
+		schedule, err := timeutil.ParseSchedule(window)
+		if err != nil {
+			return fmt.Errorf("cannot parse %s: %v", option, err)
+		}
+		for _, sched := range schedule {
+			if len(sched.ClockSpans) == 0 {
+				return fmt.Errorf("cannot use %s: %q does not have a time range", option, window)
+			}
+		}
+	}
+	return nil
+}
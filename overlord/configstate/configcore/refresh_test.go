@@ -21,6 +21,7 @@
 package configcore_test
 
 import (
+	"fmt"
 	"time"
 
 	. "gopkg.in/check.v1"
@@ -252,3 +253,78 @@ func (s *refreshSuite) TestConfigureRefreshMaxInhibitionDays(c *C) {
 		}
 	}
 }
+
+func (s *refreshSuite) TestConfigureRefreshHealthRollbackWindow(c *C) {
+	for _, window := range []string{"", "30m", "2h"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"refresh.health-rollback-window": window,
+			},
+		})
+		c.Check(err, IsNil, Commentf("%q", window))
+	}
+
+	for _, window := range []string{"invalid", "-1h", "0s", "30"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"refresh.health-rollback-window": window,
+			},
+		})
+		c.Check(err, ErrorMatches, fmt.Sprintf(`health-rollback-window must be a positive duration, not %q`, window))
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshWindows(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]interface{}{
+			"refresh.snap-windows.postgres": "sun,02:00-04:00",
+			"refresh.quota-windows.db":      "sat,9:00-11:00,,sun,9:00-11:00",
+			"refresh.snap-windows.gone":     "",
+		},
+	})
+	c.Check(err, IsNil)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]interface{}{
+			"refresh.snap-windows.postgres": "invalid",
+		},
+	})
+	c.Check(err, ErrorMatches, `cannot parse refresh.snap-windows.postgres: cannot parse "invalid": .*`)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]interface{}{
+			"refresh.quota-windows.db": "mon,25:00",
+		},
+	})
+	c.Check(err, ErrorMatches, `cannot parse refresh.quota-windows.db: .*`)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]interface{}{
+			"refresh.snap-windows.postgres": "sun",
+		},
+	})
+	c.Check(err, ErrorMatches, `cannot use refresh.snap-windows.postgres: "sun" does not have a time range`)
+
+	for _, tc := range []struct {
+		key, err string
+	}{
+		{"refresh.snap-windows.postgres.nested", `cannot set refresh.snap-windows.postgres.nested: invalid snap name: "postgres.nested"`},
+		{"refresh.quota-windows.db.nested", `cannot set refresh.quota-windows.db.nested: invalid quota group name: .*`},
+		{"refresh.snap-windows.Postgres", `cannot set refresh.snap-windows.Postgres: invalid snap name: "Postgres"`},
+		{"refresh.quota-windows.-db", `cannot set refresh.quota-windows.-db: invalid quota group name: .*`},
+	} {
+		err = configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			changes: map[string]interface{}{
+				tc.key: "sun,02:00-04:00",
+			},
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf(tc.key))
+	}
+}
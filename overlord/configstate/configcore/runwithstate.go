@@ -59,7 +59,16 @@ func init() {
 	validateOnly := &flags{validatedOnlyStateConfig: true}
 	addWithStateHandler(validateRefreshSchedule, nil, validateOnly)
 	addWithStateHandler(validateRefreshRateLimit, nil, validateOnly)
+	addWithStateHandler(validateRefreshHealthRollbackWindow, nil, validateOnly)
+	addWithStateHandler(validateRefreshWindows, nil, validateOnly)
 	addWithStateHandler(validateAutomaticSnapshotsExpiration, nil, validateOnly)
+	addWithStateHandler(validateIncrementalSnapshots, nil, validateOnly)
+	addWithStateHandler(validateSnapshotsEncryption, nil, validateOnly)
+	addWithStateHandler(validateSnapshotsRemoteTarget, nil, validateOnly)
+	addWithStateHandler(validateSnapshotsSchedule, nil, validateOnly)
+	addWithStateHandler(validateSnapshotsPreRefresh, nil, validateOnly)
+	addWithStateHandler(validateStorePeerCache, nil, validateOnly)
+	addWithStateHandler(validateStoreCache, nil, validateOnly)
 
 	// netplan.*
 	addWithStateHandler(validateNetplanSettings, handleNetplanConfiguration, coreOnly)
@@ -75,6 +84,10 @@ func init() {
 
 	// experimental.apparmor-prompting
 	addWithStateHandler(nil, doExperimentalApparmorPromptingDaemonRestart, nil)
+
+	// prompting.timeout, prompting.default-reply and
+	// prompting.<interface>.default-reply
+	addWithStateHandler(validatePromptingTimeoutPolicy, nil, validateOnly)
 }
 
 // RunTransaction is an interface describing how to access
@@ -165,6 +178,10 @@ func applyHandlers(dev sysconfig.Device, cfg RunTransaction, handlers []configHa
 			if !validCertOption(k) {
 				return fmt.Errorf("cannot set store ssl certificate under name %q: name must only contain word characters or a dash", k)
 			}
+		case isRefreshWindowChange(k):
+			// validated by validateRefreshWindows
+		case isScheduledSnapshotRetentionChange(k):
+			// validated by validateSnapshotsSchedule
 		case isNetplanChange(k):
 			if release.OnClassic {
 				return fmt.Errorf("cannot set netplan configuration on classic")
@@ -22,12 +22,31 @@ package configcore
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/snapcore/snapd/overlord/snapshotstate/remote"
+	"github.com/snapcore/snapd/snap/naming"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 func init() {
 	// add supported configuration of this module
 	supportedConfigurations["core.snapshots.automatic.retention"] = true
+	supportedConfigurations["core.snapshots.incremental"] = true
+	supportedConfigurations["core.snapshots.encryption"] = true
+	supportedConfigurations["core.snapshots.remote.target"] = true
+	supportedConfigurations["core.snapshots.remote.access-key-id"] = true
+	supportedConfigurations["core.snapshots.remote.secret-access-key"] = true
+	supportedConfigurations["core.snapshots.remote.region"] = true
+	supportedConfigurations["core.snapshots.schedule"] = true
+	supportedConfigurations["core.snapshots.scheduled.include"] = true
+	supportedConfigurations["core.snapshots.scheduled.exclude"] = true
+	supportedConfigurations["core.snapshots.scheduled.retention"] = true
+	supportedConfigurations["core.snapshots.scheduled.snap-retention"] = true
+	supportedConfigurations["core.snapshots.pre-refresh"] = true
 }
 
 func validateAutomaticSnapshotsExpiration(tr RunTransaction) error {
@@ -46,3 +65,107 @@ func validateAutomaticSnapshotsExpiration(tr RunTransaction) error {
 	}
 	return nil
 }
+
+func validateIncrementalSnapshots(tr RunTransaction) error {
+	return validateBoolFlag(tr, "snapshots.incremental")
+}
+
+func validateSnapshotsEncryption(tr RunTransaction) error {
+	encryption, err := coreCfg(tr, "snapshots.encryption")
+	if err != nil {
+		return err
+	}
+	switch encryption {
+	case "", "none", "device":
+		return nil
+	}
+	return fmt.Errorf("snapshots.encryption can only be set to 'none' or 'device'")
+}
+
+func validateSnapshotsRemoteTarget(tr RunTransaction) error {
+	target, err := coreCfg(tr, "snapshots.remote.target")
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		return nil
+	}
+	return remote.Validate(target)
+}
+
+func validateSnapshotsSchedule(tr RunTransaction) error {
+	scheduleStr, err := coreCfg(tr, "snapshots.schedule")
+	if err != nil {
+		return err
+	}
+	if scheduleStr != "" {
+		if _, err := timeutil.ParseSchedule(scheduleStr); err != nil {
+			return fmt.Errorf("cannot parse snapshots.schedule: %v", err)
+		}
+	}
+
+	for _, key := range []string{"snapshots.scheduled.include", "snapshots.scheduled.exclude"} {
+		if err := validateSnapNameList(tr, key); err != nil {
+			return err
+		}
+	}
+
+	retentionKeys := []string{"snapshots.scheduled.retention"}
+	for _, k := range tr.Changes() {
+		if !isScheduledSnapshotRetentionChange(k) {
+			continue
+		}
+		key := strings.TrimPrefix(k, "core.")
+		// names do not contain dots, which rules out nested options
+		if err := naming.ValidateInstance(strings.TrimPrefix(key, "snapshots.scheduled.snap-retention.")); err != nil {
+			return fmt.Errorf("cannot set %s: %v", key, err)
+		}
+		retentionKeys = append(retentionKeys, key)
+	}
+	for _, key := range retentionKeys {
+		if err := validateScheduledSnapshotRetention(tr, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isScheduledSnapshotRetentionChange returns whether the option is the
+// scheduled snapshot retention of a snap.
+func isScheduledSnapshotRetentionChange(k string) bool {
+	return strings.HasPrefix(k, "core.snapshots.scheduled.snap-retention.")
+}
+
+func validateScheduledSnapshotRetention(tr RunTransaction, key string) error {
+	retentionStr, err := coreCfg(tr, key)
+	if err != nil {
+		return err
+	}
+	// unset falls back to the default retention
+	if retentionStr == "" {
+		return nil
+	}
+	if n, err := strconv.ParseUint(retentionStr, 10, 8); err != nil || n < 1 || n > 100 {
+		return fmt.Errorf("%s must be a number between 1 and 100, not %q", key, retentionStr)
+	}
+	return nil
+}
+
+func validateSnapshotsPreRefresh(tr RunTransaction) error {
+	return validateSnapNameList(tr, "snapshots.pre-refresh")
+}
+
+// validateSnapNameList checks that the given option is a comma-separated
+// list of snap names.
+func validateSnapNameList(tr RunTransaction, key string) error {
+	names, err := coreCfg(tr, key)
+	if err != nil {
+		return err
+	}
+	for _, name := range strutil.CommaSeparatedList(names) {
+		if err := naming.ValidateInstance(name); err != nil {
+			return fmt.Errorf("%s contains an invalid snap name: %v", key, err)
+		}
+	}
+	return nil
+}
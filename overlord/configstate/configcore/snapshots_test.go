@@ -21,6 +21,8 @@
 package configcore_test
 
 import (
+	"fmt"
+
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/overlord/configstate/configcore"
@@ -71,3 +73,149 @@ func (s *refreshSuite) TestConfigureAutomaticSnapshotsExpirationInvalid(c *C) {
 	})
 	c.Assert(err, ErrorMatches, `snapshots.automatic.retention cannot be parsed:.*`)
 }
+
+func (s *snapshotsSuite) TestConfigureIncrementalSnapshots(c *C) {
+	for _, v := range []string{"true", "false"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"snapshots.incremental": v,
+			},
+		})
+		c.Check(err, IsNil)
+	}
+}
+
+func (s *snapshotsSuite) TestConfigureIncrementalSnapshotsInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"snapshots.incremental": "sometimes",
+		},
+	})
+	c.Assert(err, ErrorMatches, `snapshots.incremental can only be set to 'true' or 'false'`)
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsEncryption(c *C) {
+	for _, v := range []string{"none", "device"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"snapshots.encryption": v,
+			},
+		})
+		c.Check(err, IsNil)
+	}
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsEncryptionInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"snapshots.encryption": "rot13",
+		},
+	})
+	c.Assert(err, ErrorMatches, `snapshots.encryption can only be set to 'none' or 'device'`)
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsRemoteTarget(c *C) {
+	for _, v := range []string{"", "/media/backups", "s3+https://s3.example.com/bucket/prefix"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"snapshots.remote.target": v,
+			},
+		})
+		c.Check(err, IsNil)
+	}
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsRemoteTargetInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"snapshots.remote.target": "ftp://example.com/backups",
+		},
+	})
+	c.Assert(err, ErrorMatches, `unsupported remote snapshot target "ftp://example.com/backups"`)
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsSchedule(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"snapshots.schedule":            "mon,02:00",
+			"snapshots.scheduled.include":   "foo,bar_instance",
+			"snapshots.scheduled.exclude":   "baz",
+			"snapshots.scheduled.retention": 7,
+		},
+	})
+	c.Check(err, IsNil)
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsScheduleInvalid(c *C) {
+	for _, tc := range []struct {
+		conf     map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"snapshots.schedule": "nope"}, `cannot parse snapshots.schedule: .*`},
+		{map[string]interface{}{"snapshots.scheduled.include": "foo,Bad!"}, `snapshots.scheduled.include contains an invalid snap name: .*`},
+		{map[string]interface{}{"snapshots.scheduled.exclude": "-x"}, `snapshots.scheduled.exclude contains an invalid snap name: .*`},
+		{map[string]interface{}{"snapshots.scheduled.retention": 0}, `snapshots.scheduled.retention must be a number between 1 and 100, not "0"`},
+		{map[string]interface{}{"snapshots.scheduled.retention": "many"}, `snapshots.scheduled.retention must be a number between 1 and 100, not "many"`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  tc.conf,
+		})
+		c.Check(err, ErrorMatches, tc.expected, Commentf("%v", tc.conf))
+	}
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsScheduledSnapRetention(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]interface{}{
+			"snapshots.scheduled.snap-retention.postgres": 10,
+			"snapshots.scheduled.snap-retention.nginx":    "1",
+			"snapshots.scheduled.snap-retention.gone":     "",
+		},
+	})
+	c.Check(err, IsNil)
+
+	for _, retention := range []interface{}{0, 101, "many"} {
+		err = configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			changes: map[string]interface{}{
+				"snapshots.scheduled.snap-retention.postgres": retention,
+			},
+		})
+		c.Check(err, ErrorMatches, fmt.Sprintf(`snapshots.scheduled.snap-retention.postgres must be a number between 1 and 100, not "%v"`, retention))
+	}
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]interface{}{
+			"snapshots.scheduled.snap-retention.postgres.nested": 10,
+		},
+	})
+	c.Check(err, ErrorMatches, `cannot set snapshots.scheduled.snap-retention.postgres.nested: invalid snap name: "postgres.nested"`)
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsPreRefresh(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"snapshots.pre-refresh": "foo,bar_instance",
+		},
+	})
+	c.Check(err, IsNil)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"snapshots.pre-refresh": "foo,Bad!",
+		},
+	})
+	c.Check(err, ErrorMatches, `snapshots.pre-refresh contains an invalid snap name: .*`)
+}
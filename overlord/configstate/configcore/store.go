@@ -22,16 +22,28 @@ package configcore
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/sysconfig"
 )
 
 func init() {
 	supportedConfigurations["core.store.access"] = true
+	supportedConfigurations["core.store.peer-cache.listen"] = true
+	supportedConfigurations["core.store.peer-cache.peers"] = true
+	supportedConfigurations["core.store.peer-cache.token"] = true
+	supportedConfigurations["core.store.cache.max-items"] = true
+	supportedConfigurations["core.store.cache.max-size"] = true
+	supportedConfigurations["core.store.cache.max-age"] = true
 }
 
 func validateStoreAccess(cfg ConfGetter) error {
@@ -80,3 +92,74 @@ func handleStoreAccess(_ sysconfig.Device, cfg ConfGetter, opts *fsOnlyContext)
 
 	return osutil.AtomicWriteFile(configFilePath, data, 0644, 0)
 }
+
+// minPeerCacheTokenLen is the minimum length of the token shared by peers.
+const minPeerCacheTokenLen = 16
+
+// validateStorePeerCache validates the address to serve the download cache to
+// peers on, the peers to fetch downloads from and the token they share.
+func validateStorePeerCache(tr RunTransaction) error {
+	listen, err := coreCfg(tr, "store.peer-cache.listen")
+	if err != nil {
+		return err
+	}
+	if listen != "" {
+		if _, _, err := net.SplitHostPort(listen); err != nil {
+			return fmt.Errorf("cannot use %q as store.peer-cache.listen: %v", listen, err)
+		}
+	}
+
+	peers, err := coreCfg(tr, "store.peer-cache.peers")
+	if err != nil {
+		return err
+	}
+	for _, peer := range strutil.CommaSeparatedList(peers) {
+		u, err := url.Parse(peer)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("cannot use %q as a peer in store.peer-cache.peers: must be an http or https URL", peer)
+		}
+	}
+
+	token, err := coreCfg(tr, "store.peer-cache.token")
+	if err != nil {
+		return err
+	}
+	if token != "" && len(token) < minPeerCacheTokenLen {
+		return fmt.Errorf("store.peer-cache.token must be at least %d characters long", minPeerCacheTokenLen)
+	}
+	return nil
+}
+
+// validateStoreCache validates the limits of the cache of downloaded snaps.
+func validateStoreCache(tr RunTransaction) error {
+	maxItems, err := coreCfg(tr, "store.cache.max-items")
+	if err != nil {
+		return err
+	}
+	if maxItems != "" {
+		if n, err := strconv.Atoi(maxItems); err != nil || n < 1 {
+			return fmt.Errorf("store.cache.max-items must be a positive number: %q", maxItems)
+		}
+	}
+
+	maxSize, err := coreCfg(tr, "store.cache.max-size")
+	if err != nil {
+		return err
+	}
+	if maxSize != "" {
+		if _, err := strutil.ParseByteSize(maxSize); err != nil {
+			return fmt.Errorf("cannot use %q as store.cache.max-size: %v", maxSize, err)
+		}
+	}
+
+	maxAge, err := coreCfg(tr, "store.cache.max-age")
+	if err != nil {
+		return err
+	}
+	if maxAge != "" {
+		if d, err := time.ParseDuration(maxAge); err != nil || d <= 0 {
+			return fmt.Errorf("store.cache.max-age must be a positive duration: %q", maxAge)
+		}
+	}
+	return nil
+}
@@ -96,3 +96,68 @@ func (s *storeSuite) TestFilesystemOnlyApply(c *C) {
 
 	c.Check(repairConfig.StoreOffline, Equals, true)
 }
+
+func (s *storeSuite) TestStorePeerCacheHappy(c *C) {
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		changes: map[string]interface{}{
+			"store.peer-cache.listen": ":7353",
+			"store.peer-cache.peers":  "http://192.168.1.10:7353, http://[fd00::2]:7353",
+			"store.peer-cache.token":  "shared-peer-cache-token",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *storeSuite) TestStorePeerCacheUnhappy(c *C) {
+	for _, tc := range []struct {
+		key, value, err string
+	}{
+		{"store.peer-cache.listen", "7353", `cannot use "7353" as store.peer-cache.listen: address 7353: missing port in address`},
+		{"store.peer-cache.peers", "192.168.1.10:7353", `cannot use "192.168.1.10:7353" as a peer in store.peer-cache.peers: must be an http or https URL`},
+		{"store.peer-cache.peers", "http://a:1,ftp://b", `cannot use "ftp://b" as a peer in store.peer-cache.peers: must be an http or https URL`},
+		{"store.peer-cache.token", "short", `store.peer-cache.token must be at least 16 characters long`},
+	} {
+		err := configcore.Run(coreDev, &mockConf{
+			state: s.state,
+			changes: map[string]interface{}{
+				tc.key: tc.value,
+			},
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%s=%s", tc.key, tc.value))
+	}
+}
+
+func (s *storeSuite) TestStoreCacheHappy(c *C) {
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		changes: map[string]interface{}{
+			"store.cache.max-items": json.Number("20"),
+			"store.cache.max-size":  "4GB",
+			"store.cache.max-age":   "720h",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *storeSuite) TestStoreCacheUnhappy(c *C) {
+	for _, tc := range []struct {
+		key   string
+		value interface{}
+		err   string
+	}{
+		{"store.cache.max-items", json.Number("0"), `store.cache.max-items must be a positive number: "0"`},
+		{"store.cache.max-items", "many", `store.cache.max-items must be a positive number: "many"`},
+		{"store.cache.max-size", "4XB", `cannot use "4XB" as store.cache.max-size: .*`},
+		{"store.cache.max-age", "forever", `store.cache.max-age must be a positive duration: "forever"`},
+		{"store.cache.max-age", "-1h", `store.cache.max-age must be a positive duration: "-1h"`},
+	} {
+		err := configcore.Run(coreDev, &mockConf{
+			state: s.state,
+			changes: map[string]interface{}{
+				tc.key: tc.value,
+			},
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%s=%v", tc.key, tc.value))
+	}
+}
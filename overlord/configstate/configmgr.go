@@ -22,11 +22,14 @@ package configstate
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/configstate/configcore"
 	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/ifacestate/apparmorprompting"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/sysconfig"
@@ -42,6 +45,32 @@ func MockConfigcoreRun(f func(sysconfig.Device, configcore.RunTransaction) error
 	}
 }
 
+var promptingUpdateTimeoutPolicy = apparmorprompting.UpdateTimeoutPolicy
+
+// updatePromptingTimeoutPolicyOnDone arranges for any change to the prompting
+// timeout policy options to be applied to the running interfaces requests
+// manager once the configuration has been committed, so that the policy used
+// by the manager always matches the stored configuration. The transaction of
+// the context must have been retrieved already, so that its commit runs first.
+func updatePromptingTimeoutPolicyOnDone(ctx *hookstate.Context, tr configcore.RunTransaction) {
+	for _, name := range tr.Changes() {
+		if !strings.HasPrefix(name, "core.prompting.") {
+			continue
+		}
+		st := ctx.State()
+		ctx.OnDone(func() error {
+			// the options have been validated by configcore already and
+			// the configuration is committed at this point, so do not
+			// fail the change if the manager cannot use them
+			if err := promptingUpdateTimeoutPolicy(st, config.NewTransaction(st)); err != nil {
+				logger.Noticef("cannot update prompting timeout policy: %v", err)
+			}
+			return nil
+		})
+		return
+	}
+}
+
 func Init(st *state.State, hookManager *hookstate.HookManager) error {
 	delayedCrossMgrInit()
 
@@ -79,7 +108,14 @@ func Init(st *state.State, hookManager *hookstate.HookManager) error {
 		if err != nil {
 			return err
 		}
-		return configcoreRun(dev, tr)
+		if err := configcoreRun(dev, tr); err != nil {
+			return err
+		}
+
+		ctx.Lock()
+		defer ctx.Unlock()
+		updatePromptingTimeoutPolicyOnDone(ctx, tr)
+		return nil
 	})
 
 	return nil
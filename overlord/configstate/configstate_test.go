@@ -21,6 +21,7 @@
 package configstate_test
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -34,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/configstate/configcore"
 	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/ifacestate/apparmorprompting"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
 	"github.com/snapcore/snapd/overlord/state"
@@ -392,6 +394,59 @@ func (s *configcoreHijackSuite) TestHijack(c *C) {
 	c.Check(configcoreRan, Equals, true)
 }
 
+func (s *configcoreHijackSuite) TestHijackPromptingTimeoutPolicyAfterCommit(c *C) {
+	var updated []string
+	restore := configstate.MockPromptingUpdateTimeoutPolicy(func(st *state.State, tr apparmorprompting.ConfGetter) error {
+		// called with the state locked, once the configuration has been
+		// committed
+		var committed string
+		c.Check(config.NewTransaction(st).Get("core", "prompting.timeout", &committed), IsNil)
+		var timeout string
+		c.Check(tr.GetMaybe("core", "prompting.timeout", &timeout), IsNil)
+		c.Check(timeout, Equals, committed)
+		updated = append(updated, timeout)
+		return errors.New("ignored")
+	})
+	defer restore()
+
+	var configcoreErr error
+	r := configstate.MockConfigcoreRun(func(dev sysconfig.Device, conf configcore.RunTransaction) error {
+		return configcoreErr
+	})
+	defer r()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for _, tc := range []struct {
+		patch   map[string]interface{}
+		err     error
+		updated []string
+	}{
+		{map[string]interface{}{"witness": true}, nil, nil},
+		{map[string]interface{}{"prompting.timeout": "1m"}, errors.New("boom"), nil},
+		{map[string]interface{}{"prompting.timeout": "2m"}, nil, []string{"2m"}},
+	} {
+		updated = nil
+		configcoreErr = tc.err
+
+		chg := s.state.NewChange("configure-core", "configure core")
+		chg.AddAll(configstate.Configure(s.state, "core", tc.patch, 0))
+
+		s.state.Unlock()
+		err := s.o.Settle(5 * time.Second)
+		s.state.Lock()
+		c.Assert(err, IsNil)
+
+		if tc.err != nil {
+			c.Check(chg.Err(), NotNil)
+		} else {
+			c.Check(chg.Err(), IsNil)
+		}
+		c.Check(updated, DeepEquals, tc.updated, Commentf("%v", tc.patch))
+	}
+}
+
 type miscSuite struct{}
 
 func (s *miscSuite) TestRemappingFuncs(c *C) {
@@ -22,6 +22,8 @@ package configstate
 
 import (
 	"github.com/snapcore/snapd/overlord/configstate/configcore"
+	"github.com/snapcore/snapd/overlord/ifacestate/apparmorprompting"
+	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/sysconfig"
 )
 
@@ -43,3 +45,11 @@ func MockConfigcoreEarly(mock func(dev sysconfig.Device, cfg configcore.RunTrans
 		configcoreEarly = old
 	}
 }
+
+func MockPromptingUpdateTimeoutPolicy(mock func(st *state.State, tr apparmorprompting.ConfGetter) error) (restore func()) {
+	old := promptingUpdateTimeoutPolicy
+	promptingUpdateTimeoutPolicy = mock
+	return func() {
+		promptingUpdateTimeoutPolicy = old
+	}
+}
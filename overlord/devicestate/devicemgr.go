@@ -58,6 +58,7 @@ import (
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snapfile"
 	"github.com/snapcore/snapd/snapdenv"
+	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/sysconfig"
 	"github.com/snapcore/snapd/systemd"
@@ -2562,6 +2563,41 @@ func (scb storeContextBackend) StoreOffline() (bool, error) {
 	return access == "offline", nil
 }
 
+func (scb storeContextBackend) PeerCachePeers() ([]string, error) {
+	tr := config.NewTransaction(scb.state)
+
+	var peers string
+	if err := tr.GetMaybe("core", "store.peer-cache.peers", &peers); err != nil {
+		return nil, err
+	}
+
+	if peers == "" {
+		return nil, state.ErrNoState
+	}
+
+	return strutil.CommaSeparatedList(peers), nil
+}
+
+func (scb storeContextBackend) PeerCacheToken() (string, error) {
+	tr := config.NewTransaction(scb.state)
+
+	var token string
+	if err := tr.GetMaybe("core", "store.peer-cache.token", &token); err != nil {
+		return "", err
+	}
+
+	if token == "" {
+		return "", state.ErrNoState
+	}
+
+	return token, nil
+}
+
+// DownloadCachePolicy returns the policy of the cache of downloaded snaps.
+func (scb storeContextBackend) DownloadCachePolicy() (*store.CachePolicy, error) {
+	return snapstate.DownloadCachePolicy(scb.state)
+}
+
 // SignDeviceSessionRequest produces a signed device-session-request with for given serial assertion and nonce.
 func (scb storeContextBackend) SignDeviceSessionRequest(serial *asserts.Serial, nonce string) (*asserts.DeviceSessionRequest, error) {
 	if serial == nil {
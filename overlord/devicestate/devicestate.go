@@ -273,6 +273,16 @@ func delayedCrossMgrInit() {
 	snapstate.IsOnMeteredConnection = netutil.IsOnMeteredConnection
 	snapstate.DeviceCtx = DeviceCtx
 	snapstate.RemodelingChange = RemodelingChange
+	snapstate.DeviceSerial = deviceSerial
+}
+
+// deviceSerial returns the serial of the device, if it got one already.
+func deviceSerial(st *state.State) (string, error) {
+	device, err := internal.Device(st)
+	if err != nil {
+		return "", err
+	}
+	return device.Serial, nil
 }
 
 // proxyStore returns the store assertion for the proxy store if one is set.
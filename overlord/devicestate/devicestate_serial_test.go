@@ -1706,6 +1706,62 @@ func (s *deviceMgrSerialSuite) TestStoreContextBackendStoreAccess(c *C) {
 	c.Check(offline, Equals, true)
 }
 
+func (s *deviceMgrSerialSuite) TestStoreContextBackendPeerCachePeers(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	scb := s.mgr.StoreContextBackend()
+
+	// nothing in the state
+	_, err := scb.PeerCachePeers()
+	c.Check(err, testutil.ErrorIs, state.ErrNoState)
+
+	tr := config.NewTransaction(s.state)
+	err = tr.Set("core", "store.peer-cache.peers", "http://192.168.1.10:7353, http://192.168.1.11:7353")
+	tr.Commit()
+	c.Assert(err, IsNil)
+
+	peers, err := scb.PeerCachePeers()
+	c.Check(err, IsNil)
+	c.Check(peers, DeepEquals, []string{"http://192.168.1.10:7353", "http://192.168.1.11:7353"})
+}
+
+func (s *deviceMgrSerialSuite) TestStoreContextBackendPeerCacheToken(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	scb := s.mgr.StoreContextBackend()
+
+	// nothing in the state
+	_, err := scb.PeerCacheToken()
+	c.Check(err, testutil.ErrorIs, state.ErrNoState)
+
+	tr := config.NewTransaction(s.state)
+	err = tr.Set("core", "store.peer-cache.token", "shared-peer-cache-token")
+	tr.Commit()
+	c.Assert(err, IsNil)
+
+	token, err := scb.PeerCacheToken()
+	c.Check(err, IsNil)
+	c.Check(token, Equals, "shared-peer-cache-token")
+}
+
+func (s *deviceMgrSerialSuite) TestStoreContextBackendDownloadCachePolicy(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	scb := s.mgr.StoreContextBackend()
+
+	tr := config.NewTransaction(s.state)
+	err := tr.Set("core", "store.cache.max-size", "1GB")
+	tr.Commit()
+	c.Assert(err, IsNil)
+
+	policy, err := scb.DownloadCachePolicy()
+	c.Check(err, IsNil)
+	c.Check(policy.MaxSize, Equals, int64(1000*1000*1000))
+}
+
 func (s *deviceMgrSerialSuite) TestInitialRegistrationContext(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
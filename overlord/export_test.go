@@ -42,6 +42,24 @@ func MockEnsureInterval(d time.Duration) (restore func()) {
 	return func() { ensureInterval = old }
 }
 
+// MockJournalCompaction sets when the state journal gets compacted.
+func MockJournalCompaction(minSize, ratio int64) (restore func()) {
+	r := testutil.BackupMany(&journalMinCompactSize, &journalCompactRatio)
+	journalMinCompactSize = minSize
+	journalCompactRatio = ratio
+	return r
+}
+
+// OpenJournalStateBackend opens the state journal at path, applying to
+// the state file at statePath, for tests.
+func OpenJournalStateBackend(path, statePath string) (state.JournalBackend, []byte, []state.JournalEntry, error) {
+	jb, base, entries, err := openJournalStateBackend(path, statePath, func(time.Duration) {})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return jb, base, entries, nil
+}
+
 // MockPruneInterval sets the overlord prune interval for tests.
 func MockPruneInterval(prunei, prunew, abortw time.Duration) (restore func()) {
 	r := testutil.BackupMany(&pruneInterval, &pruneWait, &abortWait)
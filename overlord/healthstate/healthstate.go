@@ -47,6 +47,7 @@ func init() {
 	}
 
 	snapstate.CheckHealthHook = Hook
+	snapstate.IsHealthError = IsError
 }
 
 func Hook(st *state.State, snapName string, snapRev snap.Revision) *state.Task {
@@ -100,6 +101,8 @@ type HealthState struct {
 
 func Init(hookManager *hookstate.HookManager) {
 	hookManager.Register(regexp.MustCompile("^check-health$"), newHealthHandler)
+	// snaps can ask for check-health to be run on a schedule
+	hookManager.RegisterPeriodic("check-health", Hook)
 }
 
 func newHealthHandler(ctx *hookstate.Context) hookstate.Handler {
@@ -166,6 +169,7 @@ func (h *healthHandler) appendHealth(health *HealthState) error {
 
 func appendHealth(ctx *hookstate.Context, health *HealthState) error {
 	st := ctx.State()
+	snapName := ctx.InstanceName()
 
 	var hs map[string]*HealthState
 	if err := st.Get("health", &hs); err != nil {
@@ -174,12 +178,79 @@ func appendHealth(ctx *hookstate.Context, health *HealthState) error {
 		}
 		hs = map[string]*HealthState{}
 	}
-	hs[ctx.InstanceName()] = health
+	old := hs[snapName]
+	hs[snapName] = health
 	st.Set("health", hs)
 
+	if old == nil || old.Status != health.Status {
+		if err := appendHistory(st, snapName, health); err != nil {
+			return err
+		}
+		healthChanged(st, snapName, health)
+	}
+
 	return nil
 }
 
+// maxHistory is how many health status transitions are kept for each snap.
+const maxHistory = 10
+
+func appendHistory(st *state.State, snapName string, health *HealthState) error {
+	history, err := AllHistory(st)
+	if err != nil {
+		return err
+	}
+	if history == nil {
+		history = map[string][]*HealthState{}
+	}
+	transitions := append(history[snapName], health)
+	if len(transitions) > maxHistory {
+		transitions = transitions[len(transitions)-maxHistory:]
+	}
+	history[snapName] = transitions
+	st.Set("health-history", history)
+	return nil
+}
+
+// healthChanged records a notice about the new health status of the snap,
+// and a warning if it is an error.
+func healthChanged(st *state.State, snapName string, health *HealthState) {
+	data := map[string]string{
+		"status": health.Status.String(),
+	}
+	if health.Code != "" {
+		data["code"] = health.Code
+	}
+	if health.Message != "" {
+		data["message"] = health.Message
+	}
+	if _, err := st.AddNotice(nil, state.SnapHealthNotice, snapName, &state.AddNoticeOptions{Data: data}); err != nil {
+		logger.Noticef("cannot record health notice for snap %q: %v", snapName, err)
+	}
+
+	if health.Status == ErrorStatus {
+		st.Warnf("snap %q health check reported an error: %s", snapName, health.Message)
+		if err := snapstate.HealthErrorReported(st, snapName); err != nil {
+			logger.Noticef("cannot consider reverting snap %q: %v", snapName, err)
+		}
+	}
+}
+
+// IsError returns whether the given revision of the snap last reported its
+// health as being in error. Health reported without a revision is assumed
+// to be about the given one.
+func IsError(st *state.State, snapName string, rev snap.Revision) bool {
+	health, err := Get(st, snapName)
+	if err != nil {
+		logger.Noticef("cannot get health of snap %q: %v", snapName, err)
+		return false
+	}
+	if health == nil || health.Status != ErrorStatus {
+		return false
+	}
+	return health.Revision.Unset() || health.Revision == rev
+}
+
 // SetFromHookContext extracts the health of a snap from a hook
 // context, and saves it in snapd's state.
 // Must be called with the context lock held.
@@ -204,6 +275,37 @@ func All(st *state.State) (map[string]*HealthState, error) {
 	return hs, nil
 }
 
+// AllHistory returns the last health status transitions of each snap,
+// oldest first.
+func AllHistory(st *state.State) (map[string][]*HealthState, error) {
+	var history map[string][]*HealthState
+	if err := st.Get("health-history", &history); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return history, nil
+}
+
+// severity orders the statuses from the least to the most worrying.
+var severity = map[HealthStatus]int{
+	OkayStatus:    0,
+	UnknownStatus: 1,
+	WaitingStatus: 2,
+	BlockedStatus: 3,
+	ErrorStatus:   4,
+}
+
+// Overall returns the most worrying of the given health statuses, or
+// okay if there are none.
+func Overall(hs map[string]*HealthState) HealthStatus {
+	overall := OkayStatus
+	for _, health := range hs {
+		if health != nil && severity[health.Status] > severity[overall] {
+			overall = health.Status
+		}
+	}
+	return overall
+}
+
 func Get(st *state.State, snap string) (*HealthState, error) {
 	var hs map[string]json.RawMessage
 	if err := st.Get("health", &hs); err != nil {
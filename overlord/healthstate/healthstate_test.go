@@ -20,6 +20,8 @@
 package healthstate_test
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -251,3 +253,125 @@ func (s *healthSuite) TestSetFromHookContextEmpty(c *check.C) {
 	// no health in the context -> no health in state
 	c.Check(s.state.Get("health", &hs), testutil.ErrorIs, state.ErrNoState)
 }
+
+func (s *healthSuite) TestTransitionsRecordNoticesAndWarnings(c *check.C) {
+	ctx, err := hookstate.NewContext(nil, s.state, &hookstate.HookSetup{Snap: "test-snap"}, nil, "")
+	c.Assert(err, check.IsNil)
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	setHealth := func(status healthstate.HealthStatus, message, code string) {
+		ctx.Set("health", &healthstate.HealthState{
+			Revision:  snap.R(42),
+			Timestamp: time.Now(),
+			Status:    status,
+			Message:   message,
+			Code:      code,
+		})
+		c.Assert(healthstate.SetFromHookContext(ctx), check.IsNil)
+	}
+	noticesData := func() []map[string]string {
+		var data []map[string]string
+		for _, n := range s.state.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.SnapHealthNotice}}) {
+			buf, err := json.Marshal(n)
+			c.Assert(err, check.IsNil)
+			var m struct {
+				Key         string            `json:"key"`
+				Occurrences int               `json:"occurrences"`
+				LastData    map[string]string `json:"last-data"`
+			}
+			c.Assert(json.Unmarshal(buf, &m), check.IsNil)
+			c.Check(m.Key, check.Equals, "test-snap")
+			m.LastData["occurrences"] = fmt.Sprint(m.Occurrences)
+			data = append(data, m.LastData)
+		}
+		return data
+	}
+
+	setHealth(healthstate.OkayStatus, "", "")
+	c.Check(noticesData(), check.DeepEquals, []map[string]string{
+		{"status": "okay", "occurrences": "1"},
+	})
+	c.Check(healthstate.IsError(s.state, "test-snap", snap.R(42)), check.Equals, false)
+
+	// no transition, no new occurrence
+	setHealth(healthstate.OkayStatus, "", "")
+	c.Check(noticesData(), check.DeepEquals, []map[string]string{
+		{"status": "okay", "occurrences": "1"},
+	})
+
+	setHealth(healthstate.ErrorStatus, "database is gone", "no-db")
+	c.Check(noticesData(), check.DeepEquals, []map[string]string{
+		{"status": "error", "message": "database is gone", "code": "no-db", "occurrences": "2"},
+	})
+	c.Check(healthstate.IsError(s.state, "test-snap", snap.R(42)), check.Equals, true)
+	c.Check(healthstate.IsError(s.state, "test-snap", snap.R(41)), check.Equals, false)
+	c.Check(healthstate.IsError(s.state, "other-snap", snap.R(42)), check.Equals, false)
+
+	warnings := s.state.AllWarnings()
+	c.Assert(warnings, check.HasLen, 1)
+	c.Check(warnings[0].String(), check.Equals, `snap "test-snap" health check reported an error: database is gone`)
+
+	setHealth(healthstate.OkayStatus, "", "")
+	c.Check(noticesData(), check.DeepEquals, []map[string]string{
+		{"status": "okay", "occurrences": "3"},
+	})
+	c.Check(healthstate.IsError(s.state, "test-snap", snap.R(42)), check.Equals, false)
+}
+
+func (s *healthSuite) TestHistoryKeepsLastTransitions(c *check.C) {
+	ctx, err := hookstate.NewContext(nil, s.state, &hookstate.HookSetup{Snap: "test-snap"}, nil, "")
+	c.Assert(err, check.IsNil)
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	history, err := healthstate.AllHistory(s.state)
+	c.Assert(err, check.IsNil)
+	c.Check(history, check.HasLen, 0)
+
+	statuses := []healthstate.HealthStatus{healthstate.OkayStatus, healthstate.ErrorStatus}
+	for i := 0; i < 12; i++ {
+		ctx.Set("health", &healthstate.HealthState{
+			Revision: snap.R(42),
+			Status:   statuses[i%2],
+			Message:  "something happened",
+			Code:     fmt.Sprintf("code-%d", i),
+		})
+		c.Assert(healthstate.SetFromHookContext(ctx), check.IsNil)
+		// not a transition
+		ctx.Set("health", &healthstate.HealthState{
+			Revision: snap.R(42),
+			Status:   statuses[i%2],
+			Message:  "something happened",
+			Code:     "same-status",
+		})
+		c.Assert(healthstate.SetFromHookContext(ctx), check.IsNil)
+	}
+
+	history, err = healthstate.AllHistory(s.state)
+	c.Assert(err, check.IsNil)
+	c.Assert(history["test-snap"], check.HasLen, 10)
+	for i, health := range history["test-snap"] {
+		c.Check(health.Code, check.Equals, fmt.Sprintf("code-%d", i+2))
+		c.Check(health.Status, check.Equals, statuses[i%2])
+	}
+}
+
+func (s *healthSuite) TestOverall(c *check.C) {
+	c.Check(healthstate.Overall(nil), check.Equals, healthstate.OkayStatus)
+	c.Check(healthstate.Overall(map[string]*healthstate.HealthState{
+		"foo": {Status: healthstate.OkayStatus},
+		"bar": {Status: healthstate.UnknownStatus},
+	}), check.Equals, healthstate.UnknownStatus)
+	c.Check(healthstate.Overall(map[string]*healthstate.HealthState{
+		"foo": {Status: healthstate.WaitingStatus},
+		"bar": {Status: healthstate.BlockedStatus},
+		"baz": {Status: healthstate.UnknownStatus},
+	}), check.Equals, healthstate.BlockedStatus)
+	c.Check(healthstate.Overall(map[string]*healthstate.HealthState{
+		"foo": {Status: healthstate.ErrorStatus},
+		"bar": {Status: healthstate.BlockedStatus},
+	}), check.Equals, healthstate.ErrorStatus)
+}
@@ -38,3 +38,11 @@ func MockDefaultHookTimeout(timeout time.Duration) func() {
 		defaultHookTimeout = oldDefaultTimeout
 	}
 }
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	old := timeNow
+	timeNow = f
+	return func() {
+		timeNow = old
+	}
+}
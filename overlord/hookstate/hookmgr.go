@@ -60,6 +60,10 @@ type HookManager struct {
 
 	runningHooks int32
 	runner       *state.TaskRunner
+
+	periodicHooks     map[string]PeriodicHookTaskFunc
+	periodicLastRun   map[periodicHookKey]time.Time
+	periodicIntervals map[string]*periodicHookIntervals
 }
 
 // Handler is the interface a client must satisfy to handle hooks.
@@ -137,6 +141,10 @@ func Manager(s *state.State, runner *state.TaskRunner) (*HookManager, error) {
 		contexts:   make(map[string]*Context),
 		hijackMap:  make(map[hijackKey]hijackFunc),
 		runner:     runner,
+
+		periodicHooks:     make(map[string]PeriodicHookTaskFunc),
+		periodicLastRun:   make(map[periodicHookKey]time.Time),
+		periodicIntervals: make(map[string]*periodicHookIntervals),
 	}
 
 	runner.AddHandler("run-hook", manager.doRunHook, manager.undoRunHook)
@@ -162,7 +170,7 @@ func (m *HookManager) Register(pattern *regexp.Regexp, generator HandlerGenerato
 
 // Ensure implements StateManager.Ensure.
 func (m *HookManager) Ensure() error {
-	return nil
+	return m.ensurePeriodicHooks()
 }
 
 // StopHooks kills all currently running hooks and returns after
@@ -0,0 +1,196 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package hookstate
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+var timeNow = time.Now
+
+// PeriodicHookTaskFunc returns the task running the given revision of a
+// snap's periodic hook.
+type PeriodicHookTaskFunc func(st *state.State, snapName string, rev snap.Revision) *state.Task
+
+type periodicHookKey struct {
+	snapName string
+	hookName string
+}
+
+// periodicHookIntervals holds the intervals declared for the periodic hooks
+// of a revision of a snap, so that its snap.yaml is only read again once
+// another revision of the snap is linked.
+type periodicHookIntervals struct {
+	revision  snap.Revision
+	intervals map[string]time.Duration
+}
+
+// RegisterPeriodic registers a hook snaps can ask snapd to run on its own,
+// every interval declared for the hook in their snap.yaml. The given
+// function builds the task running the hook.
+func (m *HookManager) RegisterPeriodic(hookName string, taskFunc PeriodicHookTaskFunc) {
+	m.periodicHooks[hookName] = taskFunc
+}
+
+// periodicHookChanges returns the changes running the given periodic hook of
+// the given snap.
+func periodicHookChanges(st *state.State, snapName, hookName string) []*state.Change {
+	var chgs []*state.Change
+	for _, chg := range st.Changes() {
+		if chg.Kind() != "run-periodic-hook" {
+			continue
+		}
+		var snapNames []string
+		var chgHookName string
+		if err := chg.Get("snap-names", &snapNames); err != nil {
+			continue
+		}
+		if err := chg.Get("hook-name", &chgHookName); err != nil {
+			continue
+		}
+		if chgHookName == hookName && len(snapNames) == 1 && snapNames[0] == snapName {
+			chgs = append(chgs, chg)
+		}
+	}
+	return chgs
+}
+
+// ensurePeriodicHooks starts a change running each periodic hook that is
+// due, replacing the change of its previous run, and makes sure the next
+// ensure happens by the time the next one is.
+func (m *HookManager) ensurePeriodicHooks() error {
+	if len(m.periodicHooks) == 0 {
+		return nil
+	}
+
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	var seeded bool
+	if err := st.Get("seeded", &seeded); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if !seeded {
+		return nil
+	}
+
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		return err
+	}
+	snapNames := make([]string, 0, len(snapStates))
+	for snapName := range snapStates {
+		snapNames = append(snapNames, snapName)
+	}
+	sort.Strings(snapNames)
+
+	now := timeNow()
+	var nextDue time.Time
+	seen := make(map[periodicHookKey]bool)
+	for _, snapName := range snapNames {
+		snapst := snapStates[snapName]
+		if !snapst.Active {
+			continue
+		}
+		cached := m.periodicIntervals[snapName]
+		if cached == nil || cached.revision != snapst.Current {
+			info, err := snapst.CurrentInfo()
+			if err != nil {
+				logger.Noticef("cannot get info of snap %q: %v", snapName, err)
+				continue
+			}
+			cached = &periodicHookIntervals{revision: info.Revision}
+			for hookName := range m.periodicHooks {
+				if hook := info.Hooks[hookName]; hook != nil && hook.Interval > 0 {
+					if cached.intervals == nil {
+						cached.intervals = make(map[string]time.Duration)
+					}
+					cached.intervals[hookName] = hook.Interval
+				}
+			}
+			m.periodicIntervals[snapName] = cached
+		}
+		for hookName, interval := range cached.intervals {
+			key := periodicHookKey{snapName: snapName, hookName: hookName}
+			seen[key] = true
+			lastRun, ok := m.periodicLastRun[key]
+			if !ok {
+				// the hook was just run if the snap was just
+				// installed, otherwise snapd was just started
+				// and there is no hurry
+				lastRun = now
+				m.periodicLastRun[key] = lastRun
+			}
+			due := lastRun.Add(interval)
+			if !now.Before(due) {
+				// let whatever else is operating on the snap
+				// finish first, and retry at the next ensure
+				if err := snapstate.CheckChangeConflict(st, snapName, nil); err != nil {
+					logger.Debugf("cannot run %q hook of snap %q now: %v", hookName, snapName, err)
+					continue
+				}
+				// only the latest run of the hook is kept, so
+				// that frequent runs do not flood the changes
+				for _, chg := range periodicHookChanges(st, snapName, hookName) {
+					if chg.IsReady() {
+						if err := st.DiscardChange(chg); err != nil {
+							return err
+						}
+					}
+				}
+				task := m.periodicHooks[hookName](st, snapName, cached.revision)
+				chg := st.NewChange("run-periodic-hook", task.Summary())
+				chg.AddTask(task)
+				chg.Set("snap-names", []string{snapName})
+				chg.Set("hook-name", hookName)
+				m.periodicLastRun[key] = now
+				due = now.Add(interval)
+			}
+			if nextDue.IsZero() || due.Before(nextDue) {
+				nextDue = due
+			}
+		}
+	}
+
+	// forget about the hooks of snaps that went away
+	for key := range m.periodicLastRun {
+		if !seen[key] {
+			delete(m.periodicLastRun, key)
+		}
+	}
+	for snapName := range m.periodicIntervals {
+		if snapst := snapStates[snapName]; snapst == nil || !snapst.Active {
+			delete(m.periodicIntervals, snapName)
+		}
+	}
+
+	if !nextDue.IsZero() {
+		st.EnsureBefore(nextDue.Sub(now))
+	}
+	return nil
+}
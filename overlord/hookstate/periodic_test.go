@@ -0,0 +1,288 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package hookstate_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+type periodicHooksSuite struct {
+	baseHookManagerSuite
+
+	now time.Time
+}
+
+var _ = Suite(&periodicHooksSuite{})
+
+func (s *periodicHooksSuite) SetUpTest(c *C) {
+	s.commonSetUpTest(c)
+
+	s.now = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	s.AddCleanup(hookstate.MockTimeNow(func() time.Time { return s.now }))
+
+	s.manager.RegisterPeriodic("check-health", func(st *state.State, snapName string, rev snap.Revision) *state.Task {
+		return hookstate.HookTask(st, "periodic check-health of "+snapName, &hookstate.HookSetup{
+			Snap:     snapName,
+			Revision: rev,
+			Hook:     "check-health",
+			Optional: true,
+		}, nil)
+	})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("seeded", true)
+	s.mockSnap(c, "periodic-snap", `name: periodic-snap
+version: 1
+hooks:
+ check-health:
+  interval: 10m
+`)
+	s.mockSnap(c, "other-snap", `name: other-snap
+version: 1
+hooks:
+ check-health:
+`)
+}
+
+func (s *periodicHooksSuite) TearDownTest(c *C) {
+	s.commonTearDownTest(c)
+}
+
+func (s *periodicHooksSuite) mockSnap(c *C, snapName, yaml string) {
+	si := &snap.SideInfo{RealName: snapName, Revision: snap.R(1)}
+	snaptest.MockSnap(c, yaml, si)
+	snapstate.Set(s.state, snapName, &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  si.Revision,
+	})
+}
+
+func (s *periodicHooksSuite) periodicChanges() []*state.Change {
+	var chgs []*state.Change
+	for _, chg := range s.state.Changes() {
+		if chg.Kind() == "run-periodic-hook" {
+			chgs = append(chgs, chg)
+		}
+	}
+	return chgs
+}
+
+func (s *periodicHooksSuite) TestRunsOnSchedule(c *C) {
+	// the schedule starts when the hook is first seen
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.state.Lock()
+	c.Check(s.periodicChanges(), HasLen, 0)
+	s.state.Unlock()
+
+	s.now = s.now.Add(9 * time.Minute)
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.state.Lock()
+	c.Check(s.periodicChanges(), HasLen, 0)
+	s.state.Unlock()
+
+	s.now = s.now.Add(time.Minute)
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.state.Lock()
+	chgs := s.periodicChanges()
+	c.Assert(chgs, HasLen, 1)
+	c.Check(chgs[0].Summary(), Equals, "periodic check-health of periodic-snap")
+	var snapNames []string
+	c.Assert(chgs[0].Get("snap-names", &snapNames), IsNil)
+	c.Check(snapNames, DeepEquals, []string{"periodic-snap"})
+	tasks := chgs[0].Tasks()
+	c.Assert(tasks, HasLen, 1)
+	var hooksup hookstate.HookSetup
+	c.Assert(tasks[0].Get("hook-setup", &hooksup), IsNil)
+	c.Check(hooksup.Snap, Equals, "periodic-snap")
+	c.Check(hooksup.Hook, Equals, "check-health")
+	c.Check(hooksup.Revision, Equals, snap.R(1))
+	tasks[0].SetStatus(state.DoneStatus)
+	s.state.Unlock()
+
+	// not due again yet
+	s.now = s.now.Add(time.Minute)
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.state.Lock()
+	c.Check(s.periodicChanges(), HasLen, 1)
+	s.state.Unlock()
+
+	s.now = s.now.Add(9 * time.Minute)
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.state.Lock()
+	defer s.state.Unlock()
+	// the change of the previous run is replaced
+	newChgs := s.periodicChanges()
+	c.Assert(newChgs, HasLen, 1)
+	c.Check(newChgs[0].ID(), Not(Equals), chgs[0].ID())
+	c.Check(s.state.Change(chgs[0].ID()), IsNil)
+	c.Check(s.state.Task(tasks[0].ID()), IsNil)
+}
+
+func (s *periodicHooksSuite) TestKeepsOnlyLatestRun(c *C) {
+	c.Assert(s.manager.Ensure(), IsNil)
+
+	s.state.Lock()
+	// changes of other hooks are left alone
+	other := s.state.NewChange("run-periodic-hook", "...")
+	other.Set("snap-names", []string{"other-snap"})
+	other.Set("hook-name", "check-health")
+	other.SetStatus(state.DoneStatus)
+	s.state.Unlock()
+
+	for i := 0; i < 10; i++ {
+		s.now = s.now.Add(10 * time.Minute)
+		c.Assert(s.manager.Ensure(), IsNil)
+
+		s.state.Lock()
+		chgs := s.periodicChanges()
+		c.Assert(chgs, HasLen, 2)
+		for _, chg := range chgs {
+			for _, t := range chg.Tasks() {
+				t.SetStatus(state.DoneStatus)
+			}
+		}
+		s.state.Unlock()
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Change(other.ID()), NotNil)
+	c.Check(s.state.TaskCount(), Equals, 1)
+}
+
+func (s *periodicHooksSuite) TestKeepsRunInFlight(c *C) {
+	c.Assert(s.manager.Ensure(), IsNil)
+
+	s.now = s.now.Add(10 * time.Minute)
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.state.Lock()
+	chgs := s.periodicChanges()
+	c.Assert(chgs, HasLen, 1)
+	s.state.Unlock()
+
+	// the previous run has not finished, so it is neither replaced nor
+	// run again
+	s.now = s.now.Add(10 * time.Minute)
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.periodicChanges(), DeepEquals, chgs)
+}
+
+func (s *periodicHooksSuite) TestWaitsForConflicts(c *C) {
+	c.Assert(s.manager.Ensure(), IsNil)
+
+	s.state.Lock()
+	chg := s.state.NewChange("other", "...")
+	t := s.state.NewTask("foo", "...")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{RealName: "periodic-snap", Revision: snap.R(1)},
+	})
+	chg.AddTask(t)
+	s.state.Unlock()
+
+	s.now = s.now.Add(time.Hour)
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.state.Lock()
+	c.Check(s.periodicChanges(), HasLen, 0)
+	t.SetStatus(state.DoneStatus)
+	s.state.Unlock()
+
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.state.Lock()
+	c.Check(s.periodicChanges(), HasLen, 1)
+	s.state.Unlock()
+}
+
+func (s *periodicHooksSuite) TestReadsSnapYamlOncePerRevision(c *C) {
+	c.Assert(s.manager.Ensure(), IsNil)
+
+	// the declared intervals are not read again for the same revision
+	s.state.Lock()
+	info, err := snapstate.CurrentInfo(s.state, "periodic-snap")
+	c.Assert(err, IsNil)
+	c.Assert(os.Remove(filepath.Join(info.MountDir(), "meta", "snap.yaml")), IsNil)
+	s.state.Unlock()
+
+	s.now = s.now.Add(10 * time.Minute)
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.state.Lock()
+	chgs := s.periodicChanges()
+	c.Assert(chgs, HasLen, 1)
+	chgs[0].Tasks()[0].SetStatus(state.DoneStatus)
+
+	// but they are once another revision is linked
+	si := &snap.SideInfo{RealName: "periodic-snap", Revision: snap.R(2)}
+	snaptest.MockSnap(c, `name: periodic-snap
+version: 2
+hooks:
+ check-health:
+  interval: 1h
+`, si)
+	snapstate.Set(s.state, "periodic-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  si.Revision,
+	})
+	s.state.Unlock()
+
+	s.now = s.now.Add(10 * time.Minute)
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.state.Lock()
+	c.Check(s.periodicChanges(), DeepEquals, chgs)
+	s.state.Unlock()
+
+	s.now = s.now.Add(50 * time.Minute)
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.state.Lock()
+	defer s.state.Unlock()
+	chgs = s.periodicChanges()
+	c.Assert(chgs, HasLen, 1)
+	var hooksup hookstate.HookSetup
+	c.Assert(chgs[0].Tasks()[0].Get("hook-setup", &hooksup), IsNil)
+	c.Check(hooksup.Revision, Equals, snap.R(2))
+}
+
+func (s *periodicHooksSuite) TestNotBeforeSeeded(c *C) {
+	s.state.Lock()
+	s.state.Set("seeded", nil)
+	s.state.Unlock()
+
+	c.Assert(s.manager.Ensure(), IsNil)
+	s.now = s.now.Add(time.Hour)
+	c.Assert(s.manager.Ensure(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.periodicChanges(), HasLen, 0)
+}
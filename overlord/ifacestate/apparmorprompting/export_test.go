@@ -22,10 +22,25 @@ package apparmorprompting
 import (
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
+	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify/listener"
 	"github.com/snapcore/snapd/testutil"
 )
 
+var TimeoutPolicyFromConfig = timeoutPolicyFromConfig
+
+func MockUserHomeDir(f func(userID uint32) (string, error)) (restore func()) {
+	return testutil.Mock(&userHomeDir, f)
+}
+
+func MockConnectedPlugsAttrs(f func(st *state.State, snap string, iface string) ([]map[string]any, error)) (restore func()) {
+	return testutil.Mock(&connectedPlugsAttrs, f)
+}
+
+func MockMonitorUserSessionEnded(f func(uid uint32, channel chan<- string, done <-chan struct{}) error) (restore func()) {
+	return testutil.Mock(&monitorUserSessionEnded, f)
+}
+
 func MockListenerRegister(f func() (*listener.Listener, error)) (restore func()) {
 	return testutil.Mock(&listenerRegister, f)
 }
@@ -112,3 +127,7 @@ func (m *InterfacesRequestsManager) PromptDB() *requestprompts.PromptDB {
 func (m *InterfacesRequestsManager) RuleDB() *requestrules.RuleDB {
 	return m.rules
 }
+
+func MockGadgetPromptingRulesFile(f func(st *state.State) (string, error)) (restore func()) {
+	return testutil.Mock(&gadgetPromptingRulesFile, f)
+}
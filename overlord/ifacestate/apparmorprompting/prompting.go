@@ -20,8 +20,16 @@
 package apparmorprompting
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
 	"sync"
+	"time"
 
 	"gopkg.in/tomb.v2"
 
@@ -30,8 +38,13 @@ import (
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
+	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify/listener"
+	"github.com/snapcore/snapd/sandbox/cgroup"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/strutil"
 )
@@ -44,8 +57,148 @@ var (
 	listenerReqs     = func(l *listener.Listener) <-chan *listener.Request { return l.Reqs() }
 
 	requestReply = func(req *listener.Request, allowedPermission any) error { return req.Reply(allowedPermission) }
+
+	userHomeDir = func(userID uint32) (string, error) {
+		u, err := user.LookupId(strconv.FormatUint(uint64(userID), 10))
+		if err != nil {
+			return "", err
+		}
+		return u.HomeDir, nil
+	}
+
+	connectedPlugsAttrs = connectedPlugsAttrsImpl
+
+	gadgetPromptingRulesFile = gadgetPromptingRulesFileImpl
+
+	monitorUserSessionEnded = cgroup.MonitorUserSessionEnded
 )
 
+// interfacesByPrecedence lists the interfaces other than "home" for which
+// requests can be prompted, in the order in which they are tried when
+// associating a request with an interface. Device interfaces come first,
+// then interfaces granting access to specific paths, and then those granting
+// access to whole locations. Requests which do not match any of them are
+// associated with "home".
+var interfacesByPrecedence = []string{"camera", "audio-record", "personal-files", "system-files", "removable-media"}
+
+// connectedPlugsAttrsImpl returns the attributes of the connected plugs of
+// the given snap for the given interface.
+func connectedPlugsAttrsImpl(st *state.State, snap string, iface string) ([]map[string]any, error) {
+	st.Lock()
+	defer st.Unlock()
+	repo := ifacerepo.Get(st)
+	var attrsList []map[string]any
+	for _, plug := range repo.Plugs(snap) {
+		if plug.Interface != iface {
+			continue
+		}
+		connRefs, err := repo.Connected(snap, plug.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(connRefs) == 0 {
+			continue
+		}
+		attrsList = append(attrsList, plug.Attrs)
+	}
+	return attrsList, nil
+}
+
+// gadgetPromptingRulesFileImpl returns the path of the file in which the gadget
+// snap may provide default prompting rules, or "" if there is no gadget.
+func gadgetPromptingRulesFileImpl(st *state.State) (string, error) {
+	st.Lock()
+	defer st.Unlock()
+	var info *snap.Info
+	deviceCtx, err := snapstate.DeviceCtx(st, nil, nil)
+	if err == nil {
+		info, err = snapstate.GadgetInfo(st, deviceCtx)
+	}
+	if err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			// Device not yet seeded, or no gadget snap
+			return "", nil
+		}
+		return "", err
+	}
+	return filepath.Join(info.MountDir(), "meta", "prompting-rules.json"), nil
+}
+
+// ConfGetter is the part of a configuration transaction from which the
+// prompting system options are read.
+type ConfGetter interface {
+	GetMaybe(snapName, key string, result any) error
+}
+
+// timeoutPolicyFromConfig returns the policy for expiring outstanding prompts
+// according to the prompting.timeout, prompting.activity-timeout,
+// prompting.default-reply, and prompting.<interface>.default-reply system
+// options. Options which are not set keep the values of the default policy.
+func timeoutPolicyFromConfig(tr ConfGetter) (requestprompts.TimeoutPolicy, error) {
+	policy := requestprompts.DefaultTimeoutPolicy()
+	for key, timeout := range map[string]*time.Duration{
+		"prompting.timeout":          &policy.Timeout,
+		"prompting.activity-timeout": &policy.ActivityTimeout,
+	} {
+		var value string
+		if err := tr.GetMaybe("core", key, &value); err != nil {
+			return policy, err
+		}
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return policy, fmt.Errorf("cannot parse %s: %w", key, err)
+		}
+		*timeout = d
+	}
+	var reply string
+	if err := tr.GetMaybe("core", "prompting.default-reply", &reply); err != nil {
+		return policy, err
+	}
+	if reply != "" {
+		policy.DefaultReply = prompting.OutcomeType(reply)
+	}
+	for _, iface := range prompting.AvailableInterfaces() {
+		var reply string
+		if err := tr.GetMaybe("core", fmt.Sprintf("prompting.%s.default-reply", iface), &reply); err != nil {
+			return policy, err
+		}
+		if reply == "" {
+			continue
+		}
+		if policy.InterfaceDefaultReplies == nil {
+			policy.InterfaceDefaultReplies = make(map[string]prompting.OutcomeType)
+		}
+		policy.InterfaceDefaultReplies[iface] = prompting.OutcomeType(reply)
+	}
+	return policy, nil
+}
+
+// managerKey is the key under which the running interfaces requests manager
+// is cached in the state.
+type managerKey struct{}
+
+// UpdateTimeoutPolicy sets the policy for expiring outstanding prompts of the
+// running interfaces requests manager, if any, according to the prompting
+// system options of the given configuration. If the options cannot be used,
+// returns an error and the previous policy is kept.
+//
+// The state must be locked by the caller. The policy is set without acquiring
+// the lock of the prompt DB, which may be held while recording notices.
+func UpdateTimeoutPolicy(st *state.State, tr ConfGetter) error {
+	m, _ := st.Cached(managerKey{}).(*InterfacesRequestsManager)
+	if m == nil {
+		return nil
+	}
+	policy, err := timeoutPolicyFromConfig(tr)
+	if err != nil {
+		return err
+	}
+	return m.prompts.SetTimeoutPolicy(policy)
+}
+
 // A Manager holds outstanding prompts and mediates their replies, further it
 // stores and applies persistent rules.
 type Manager interface {
@@ -58,6 +211,8 @@ type Manager interface {
 	RuleWithID(userID uint32, ruleID prompting.IDType) (*requestrules.Rule, error)
 	PatchRule(userID uint32, ruleID prompting.IDType, constraintsPatch *prompting.RuleConstraintsPatch) (*requestrules.Rule, error)
 	RemoveRule(userID uint32, ruleID prompting.IDType) (*requestrules.Rule, error)
+	ExportRules(userID uint32) (*requestrules.RulesExport, error)
+	ImportRules(userID uint32, export *requestrules.RulesExport) ([]*requestrules.Rule, error)
 }
 
 // verify that InterfacesRequestsManager implements Manager
@@ -70,12 +225,22 @@ type InterfacesRequestsManager struct {
 	// or when removing those databases. The lock can be held for reading when
 	// acting on just one or the other, as each has an internal mutex as well.
 	lock     sync.RWMutex
+	state    *state.State
 	listener *listener.Listener
 	prompts  *requestprompts.PromptDB
 	rules    *requestrules.RuleDB
 
+	// sessionEnded receives the IDs of users whose login sessions have ended,
+	// for each user in monitoredSessions.
+	sessionEnded      chan string
+	monitoredSessions map[uint32]bool
+
+	// gadgetRulesProvisioned is set once there is nothing left for
+	// ProvisionGadgetRules to do.
+	gadgetRulesProvisioned bool
+
 	notifyPrompt func(userID uint32, promptID prompting.IDType, data map[string]string) error
-	notifyRule   func(userID uint32, ruleID prompting.IDType, data map[string]string) error
+	notifyRule   func(userID *uint32, ruleID prompting.IDType, data map[string]string) error
 }
 
 func New(s *state.State) (m *InterfacesRequestsManager, retErr error) {
@@ -90,7 +255,7 @@ func New(s *state.State) (m *InterfacesRequestsManager, retErr error) {
 		_, err := s.AddNotice(&userID, state.InterfacesRequestsPromptNotice, promptID.String(), &options)
 		return err
 	}
-	notifyRule := func(userID uint32, ruleID prompting.IDType, data map[string]string) error {
+	notifyRule := func(userID *uint32, ruleID prompting.IDType, data map[string]string) error {
 		// TODO: add some sort of queue so that notifyRule calls can return
 		// quickly without waiting for state lock and AddNotice() to return.
 		s.Lock()
@@ -98,7 +263,8 @@ func New(s *state.State) (m *InterfacesRequestsManager, retErr error) {
 		options := state.AddNoticeOptions{
 			Data: data,
 		}
-		_, err := s.AddNotice(&userID, state.InterfacesRequestsRuleUpdateNotice, ruleID.String(), &options)
+		// A nil userID makes the notice public, as it is for admin rules
+		_, err := s.AddNotice(userID, state.InterfacesRequestsRuleUpdateNotice, ruleID.String(), &options)
 		return err
 	}
 
@@ -133,18 +299,128 @@ func New(s *state.State) (m *InterfacesRequestsManager, retErr error) {
 	}()
 
 	m = &InterfacesRequestsManager{
+		state:        s,
 		listener:     listenerBackend,
 		prompts:      promptsBackend,
 		rules:        rulesBackend,
 		notifyPrompt: notifyPrompt,
 		notifyRule:   notifyRule,
+
+		sessionEnded:      make(chan string),
+		monitoredSessions: make(map[uint32]bool),
 	}
 
+	for _, userID := range rulesBackend.UsersWithSessionRules() {
+		if err := m.monitorSession(userID); err != nil {
+			// We cannot tell when the session ends, so rather than keeping
+			// the session rules forever, expire them right away.
+			logger.Noticef("%v; expiring session rules", err)
+			if err := rulesBackend.ExpireSessionRules(userID); err != nil {
+				logger.Noticef("cannot expire session rules of user %d: %v", userID, err)
+			}
+		}
+	}
+
+	m.ProvisionGadgetRules()
+
+	// Apply the current prompting system options, which are then kept up to
+	// date by UpdateTimeoutPolicy whenever they change.
+	s.Lock()
+	s.Cache(managerKey{}, m)
+	if err := UpdateTimeoutPolicy(s, config.NewTransaction(s)); err != nil {
+		logger.Noticef("cannot set prompt timeout policy: %v", err)
+	}
+	s.Unlock()
+
 	m.tomb.Go(m.run)
 
 	return m, nil
 }
 
+// ProvisionGadgetRules imports the default prompting rules provided by the
+// gadget snap in meta/prompting-rules.json, if any, as admin rules, and checks
+// them against outstanding prompts.
+//
+// The gadget rules are only imported once, the first time the gadget is
+// present while prompting is running, so that an administrator may
+// subsequently modify or remove them without them reappearing. As the gadget
+// is only installed while seeding, this is retried, e.g. on every Ensure of
+// the interface manager, until the device is seeded.
+//
+// Must be called without the state lock held.
+func (m *InterfacesRequestsManager) ProvisionGadgetRules() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.gadgetRulesProvisioned || m.rules == nil {
+		return
+	}
+
+	m.state.Lock()
+	var provisioned, seeded bool
+	err := m.state.Get("prompting-gadget-rules-provisioned", &provisioned)
+	if err == nil || errors.Is(err, state.ErrNoState) {
+		err = m.state.Get("seeded", &seeded)
+	}
+	m.state.Unlock()
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		logger.Noticef("cannot check whether gadget prompting rules were provisioned: %v", err)
+		return
+	}
+	if provisioned {
+		m.gadgetRulesProvisioned = true
+		return
+	}
+
+	path, err := gadgetPromptingRulesFile(m.state)
+	if err != nil {
+		logger.Noticef("cannot find gadget prompting rules: %v", err)
+		return
+	}
+	if path == "" {
+		// No gadget yet, try again next time unless the device is seeded
+		// without one
+		m.gadgetRulesProvisioned = seeded
+		return
+	}
+	newRules, err := importRulesFile(m.rules, requestrules.AdminUser, path)
+	if err != nil {
+		logger.Noticef("cannot provision gadget prompting rules: %v", err)
+		return
+	}
+	for _, rule := range newRules {
+		m.applyRuleToOutstandingPrompts(rule)
+	}
+
+	m.state.Lock()
+	defer m.state.Unlock()
+	m.state.Set("prompting-gadget-rules-provisioned", true)
+	m.gadgetRulesProvisioned = true
+}
+
+// importRulesFile imports the rules exported in the file at the given path
+// for the given user and returns the new rules. If the file does not exist,
+// does nothing.
+func importRulesFile(rules *requestrules.RuleDB, userID uint32, path string) ([]*requestrules.Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var export requestrules.RulesExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("cannot decode %s: %w", path, err)
+	}
+	newRules, err := rules.ImportRules(userID, &export)
+	if err != nil {
+		return nil, err
+	}
+	logger.Noticef("imported %d prompting rules from %s", len(newRules), path)
+	return newRules, nil
+}
+
 // Run is the main run loop for the manager, and must be called using tomb.Go.
 func (m *InterfacesRequestsManager) run() error {
 	m.lock.Lock()
@@ -179,6 +455,9 @@ run_loop:
 			if err := m.handleListenerReq(req); err != nil {
 				logger.Noticef("error while handling request: %+v", err)
 			}
+		case userIDStr := <-m.sessionEnded:
+			logger.Debugf("session ended for user %s", userIDStr)
+			m.handleSessionEnded(userIDStr)
 		case <-m.tomb.Dying():
 			logger.Noticef("InterfacesRequestsManager tomb is dying, disconnecting")
 			break run_loop
@@ -200,11 +479,10 @@ func (m *InterfacesRequestsManager) handleListenerReq(req *listener.Request) err
 		snap = tag.InstanceName()
 	}
 
-	// TODO: when we support interfaces beyond "home", do a proper selection here
-	iface := "home"
-
 	path := req.Path
 
+	iface := m.interfaceForRequest(userID, snap, path)
+
 	permissions, err := prompting.AbstractPermissionsFromAppArmorPermissions(iface, req.Permission)
 	if err != nil {
 		logger.Noticef("error while parsing AppArmor permissions: %v", err)
@@ -266,6 +544,88 @@ func (m *InterfacesRequestsManager) handleListenerReq(req *listener.Request) err
 	return nil
 }
 
+// interfaceForRequest returns the interface with which a request from the
+// given user and snap for the given path should be associated. This is the
+// first interface in interfacesByPrecedence for which the snap has a
+// connected plug granting access to the path, and otherwise "home".
+func (m *InterfacesRequestsManager) interfaceForRequest(userID uint32, snap string, path string) string {
+	homeDir, err := userHomeDir(userID)
+	if err != nil {
+		logger.Debugf("cannot get home directory of user %d: %v", userID, err)
+	}
+	for _, iface := range interfacesByPrecedence {
+		attrsList, err := connectedPlugsAttrs(m.state, snap, iface)
+		if err != nil {
+			logger.Noticef("cannot get connected %s plugs of snap %q: %v", iface, snap, err)
+			continue
+		}
+		for _, attrs := range attrsList {
+			pathPatterns, err := prompting.InterfacePathPatterns(iface, attrs, homeDir)
+			if err != nil {
+				logger.Debugf("cannot get path patterns for a %s plug of snap %q: %v", iface, snap, err)
+				continue
+			}
+			for _, pathPattern := range pathPatterns {
+				if match, err := pathPattern.Match(path); err == nil && match {
+					return iface
+				}
+			}
+		}
+	}
+	return "home"
+}
+
+// monitorSession starts monitoring the login session of the user with the
+// given ID, unless it is already being monitored, so that rules with a
+// lifespan of session can be expired once the session ends.
+//
+// The caller must ensure that the manager lock is held for writing, or that
+// the run loop has not yet been started. This does not deadlock with the run
+// loop waiting for the lock, since the session is monitored, and its end sent
+// over sessionEnded, from a goroutine which does not hold the lock. That
+// goroutine gives up once the manager is dying, as the run loop no longer
+// receives from sessionEnded.
+func (m *InterfacesRequestsManager) monitorSession(userID uint32) error {
+	if m.monitoredSessions[userID] {
+		return nil
+	}
+	if err := monitorUserSessionEnded(userID, m.sessionEnded, m.tomb.Dying()); err != nil {
+		return fmt.Errorf("cannot monitor session of user %d: %w", userID, err)
+	}
+	m.monitoredSessions[userID] = true
+	return nil
+}
+
+// handleSessionEnded expires the rules with a lifespan of session for the user
+// with the given ID, whose login session has ended.
+func (m *InterfacesRequestsManager) handleSessionEnded(userIDStr string) {
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		// Should not occur, we only monitor sessions by user ID
+		logger.Noticef("internal error: cannot parse user ID of ended session: %v", err)
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.monitoredSessions, uint32(userID))
+	if err := m.rules.ExpireSessionRules(uint32(userID)); err != nil {
+		logger.Noticef("cannot expire session rules of user %d: %v", userID, err)
+	}
+}
+
+// hasSessionLifespan returns true if any of the given permission entries has a
+// lifespan of session.
+func hasSessionLifespan(permissions prompting.PermissionMap) bool {
+	for _, entry := range permissions {
+		if entry != nil && entry.Lifespan == prompting.LifespanSession {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *InterfacesRequestsManager) disconnect() error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -290,6 +650,12 @@ func (m *InterfacesRequestsManager) disconnect() error {
 // Stop closes the listener, prompt DB, and rule DB. Stop is idempotent, and
 // the receiver cannot be started or used after it has been stopped.
 func (m *InterfacesRequestsManager) Stop() error {
+	m.state.Lock()
+	if m.state.Cached(managerKey{}) == m {
+		m.state.Cache(managerKey{}, nil)
+	}
+	m.state.Unlock()
+
 	m.tomb.Kill(nil)
 	// Kill causes the run loop to exit and call disconnect()
 	return m.tomb.Wait()
@@ -374,6 +740,12 @@ func (m *InterfacesRequestsManager) HandleReply(userID uint32, promptID promptin
 	// no prompts can have been matched against it in the meantime.
 	var newRule *requestrules.Rule
 	if lifespan != prompting.LifespanSingle {
+		if lifespan == prompting.LifespanSession {
+			if err := m.monitorSession(userID); err != nil {
+				return nil, err
+			}
+		}
+
 		// Check that adding the rule doesn't conflict with other rules
 		newRule, err = m.rules.AddRule(userID, prompt.Snap, prompt.Interface, constraints)
 		if err != nil {
@@ -408,16 +780,25 @@ func (m *InterfacesRequestsManager) HandleReply(userID uint32, promptID promptin
 }
 
 func (m *InterfacesRequestsManager) applyRuleToOutstandingPrompts(rule *requestrules.Rule) []prompting.IDType {
-	metadata := &prompting.Metadata{
-		User:      rule.User,
-		Snap:      rule.Snap,
-		Interface: rule.Interface,
-	}
-	satisfiedPromptIDs, err := m.prompts.HandleNewRule(metadata, rule.Constraints)
-	if err != nil {
-		// The rule's constraints and outcome were already validated, so an
-		// error should not occur here unless the prompt DB was already closed.
-		logger.Noticef("error when handling new rule: %v", err)
+	users := []uint32{rule.User}
+	if rule.Admin {
+		// Admin rules apply to the prompts of all users
+		users = m.prompts.UsersWithPrompts()
+	}
+	var satisfiedPromptIDs []prompting.IDType
+	for _, user := range users {
+		metadata := &prompting.Metadata{
+			User:      user,
+			Snap:      rule.Snap,
+			Interface: rule.Interface,
+		}
+		satisfied, err := m.prompts.HandleNewRule(metadata, rule.Constraints)
+		if err != nil {
+			// The rule's constraints and outcome were already validated, so an
+			// error should not occur here unless the prompt DB was already closed.
+			logger.Noticef("error when handling new rule: %v", err)
+		}
+		satisfiedPromptIDs = append(satisfiedPromptIDs, satisfied...)
 	}
 	return satisfiedPromptIDs
 }
@@ -450,6 +831,12 @@ func (m *InterfacesRequestsManager) AddRule(userID uint32, snap string, iface st
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
+	if userID != requestrules.AdminUser && constraints != nil && hasSessionLifespan(constraints.Permissions) {
+		if err := m.monitorSession(userID); err != nil {
+			return nil, err
+		}
+	}
+
 	newRule, err := m.rules.AddRule(userID, snap, iface, constraints)
 	if err != nil {
 		return nil, err
@@ -497,6 +884,12 @@ func (m *InterfacesRequestsManager) PatchRule(userID uint32, ruleID prompting.ID
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
+	if userID != requestrules.AdminUser && constraintsPatch != nil && hasSessionLifespan(constraintsPatch.Permissions) {
+		if err := m.monitorSession(userID); err != nil {
+			return nil, err
+		}
+	}
+
 	patchedRule, err := m.rules.PatchRule(userID, ruleID, constraintsPatch)
 	if err != nil {
 		return nil, err
@@ -516,3 +909,45 @@ func (m *InterfacesRequestsManager) RemoveRule(userID uint32, ruleID prompting.I
 	rule, err := m.rules.RemoveRule(userID, ruleID)
 	return rule, err
 }
+
+// ExportRules returns the rules of the user with the given user ID in a stable
+// format from which they can later be imported again.
+func (m *InterfacesRequestsManager) ExportRules(userID uint32) (*requestrules.RulesExport, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	export := m.rules.ExportRules(userID)
+	return export, nil
+}
+
+// ImportRules creates new rules for the user with the given user ID from the
+// given export and then checks them against outstanding prompts, resolving any
+// prompts which they satisfy. Either all rules are imported or none of them.
+//
+// Rules imported by the admin user are admin rules, which apply to all users
+// and cannot be modified or removed by them.
+func (m *InterfacesRequestsManager) ImportRules(userID uint32, export *requestrules.RulesExport) ([]*requestrules.Rule, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if userID != requestrules.AdminUser {
+		for _, exported := range export.Rules {
+			if exported.Constraints != nil && hasSessionLifespan(exported.Constraints.Permissions) {
+				if err := m.monitorSession(userID); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+
+	newRules, err := m.rules.ImportRules(userID, export)
+	if err != nil {
+		return nil, err
+	}
+	// Apply new rules to outstanding prompts.
+	for _, rule := range newRules {
+		m.applyRuleToOutstandingPrompts(rule)
+	}
+	return newRules, nil
+}
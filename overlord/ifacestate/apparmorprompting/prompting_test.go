@@ -32,13 +32,16 @@ import (
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/prompting"
 	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
 	"github.com/snapcore/snapd/interfaces/prompting/patterns"
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/ifacestate/apparmorprompting"
+	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify/listener"
@@ -53,6 +56,10 @@ type apparmorpromptingSuite struct {
 	st *state.State
 
 	defaultUser uint32
+
+	monitoredSessions []uint32
+	sessionEnded      chan<- string
+	sessionDone       <-chan struct{}
 }
 
 var _ = Suite(&apparmorpromptingSuite{})
@@ -66,6 +73,26 @@ func (s *apparmorpromptingSuite) SetUpTest(c *C) {
 
 	s.st = state.New(nil)
 	s.defaultUser = 1000
+
+	s.st.Lock()
+	ifacerepo.Replace(s.st, interfaces.NewRepository())
+	s.st.Unlock()
+	s.AddCleanup(apparmorprompting.MockUserHomeDir(func(userID uint32) (string, error) {
+		return fmt.Sprintf("/home/user%d", userID), nil
+	}))
+
+	s.monitoredSessions = nil
+	s.sessionEnded = nil
+	s.sessionDone = nil
+	s.AddCleanup(apparmorprompting.MockMonitorUserSessionEnded(func(uid uint32, channel chan<- string, done <-chan struct{}) error {
+		s.monitoredSessions = append(s.monitoredSessions, uid)
+		s.sessionEnded = channel
+		s.sessionDone = done
+		return nil
+	}))
+	s.AddCleanup(apparmorprompting.MockGadgetPromptingRulesFile(func(st *state.State) (string, error) {
+		return "", nil
+	}))
 }
 
 func (s *apparmorpromptingSuite) TestNew(c *C) {
@@ -292,6 +319,90 @@ func (s *apparmorpromptingSuite) TestHandleReplySimple(c *C) {
 	c.Assert(mgr.Stop(), IsNil)
 }
 
+func (s *apparmorpromptingSuite) TestHandleListenerRequestInterfaces(c *C) {
+	reqChan, replyChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	restore = apparmorprompting.MockConnectedPlugsAttrs(func(st *state.State, snap string, iface string) ([]map[string]any, error) {
+		c.Check(st, Equals, s.st)
+		c.Check(snap, Equals, "firefox")
+		switch iface {
+		case "personal-files":
+			return []map[string]any{
+				{"read": []any{"$HOME/.config/foo"}},
+				{"write": []any{"$HOME/.local/share/foo"}},
+			}, nil
+		case "system-files":
+			return nil, fmt.Errorf("boom")
+		case "removable-media", "camera":
+			return []map[string]any{nil}, nil
+		case "audio-record":
+			return nil, nil
+		}
+		c.Errorf("unexpected interface %q", iface)
+		return nil, nil
+	})
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+
+	for _, testCase := range []struct {
+		path  string
+		iface string
+	}{
+		{"/home/user1000/.config/foo", "personal-files"},
+		{"/home/user1000/.local/share/foo/bar", "personal-files"},
+		{"/media/user/usb/foo", "removable-media"},
+		{"/mnt/foo", "removable-media"},
+		{"/dev/video0", "camera"},
+		{"/dev/snd/pcmC0D0c", "home"},
+		{"/home/user1000/.config/foobar", "home"},
+		{"/home/user1000/foo", "home"},
+	} {
+		whenSent := time.Now()
+		req := &listener.Request{Path: testCase.path}
+		s.fillInPartialRequest(req)
+		reqChan <- req
+
+		s.st.Lock()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		n, err := s.st.WaitNotices(ctx, &state.NoticeFilter{
+			Types: []state.NoticeType{state.InterfacesRequestsPromptNotice},
+			After: whenSent,
+		})
+		cancel()
+		s.st.Unlock()
+		c.Assert(err, IsNil)
+		c.Assert(n, HasLen, 1)
+
+		// previous prompts have been replied to
+		prompts, err := mgr.Prompts(s.defaultUser, false)
+		c.Assert(err, IsNil)
+		c.Assert(prompts, HasLen, 1)
+		prompt := prompts[0]
+		c.Check(prompt.Interface, Equals, testCase.iface, Commentf("path: %s", testCase.path))
+		c.Check(prompt.Constraints.Path(), Equals, testCase.path)
+
+		// replying creates a rule for the interface of the prompt
+		available, err := prompting.AvailablePermissions(testCase.iface)
+		c.Assert(err, IsNil)
+		constraints := prompting.ReplyConstraints{
+			PathPattern: mustParsePathPattern(c, testCase.path),
+			Permissions: available[:1],
+		}
+		_, err = mgr.HandleReply(s.defaultUser, prompt.ID, &constraints, prompting.OutcomeAllow, prompting.LifespanForever, "", false)
+		c.Assert(err, IsNil)
+		_, err = waitForReply(replyChan)
+		c.Assert(err, IsNil)
+		rules, err := mgr.Rules(s.defaultUser, "firefox", testCase.iface)
+		c.Assert(err, IsNil)
+		c.Check(rules, Not(HasLen), 0)
+	}
+
+	c.Assert(mgr.Stop(), IsNil)
+}
+
 func (s *apparmorpromptingSuite) simulateRequest(c *C, reqChan chan *listener.Request, mgr *apparmorprompting.InterfacesRequestsManager, req *listener.Request, shouldMerge bool) (*listener.Request, *requestprompts.Prompt) {
 	clientActivity := false
 	prompts, err := mgr.Prompts(s.defaultUser, clientActivity)
@@ -1371,3 +1482,515 @@ func (s *apparmorpromptingSuite) TestAddRuleWithIDPatchRemove(c *C) {
 
 	c.Assert(mgr.Stop(), IsNil)
 }
+
+func (s *apparmorpromptingSuite) TestSessionRulesExpireWhenSessionEnds(c *C) {
+	_, _, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	c.Check(s.monitoredSessions, HasLen, 0)
+
+	addRule := func(path string, lifespan prompting.LifespanType) *requestrules.Rule {
+		constraints := &prompting.Constraints{
+			PathPattern: mustParsePathPattern(c, path),
+			Permissions: prompting.PermissionMap{
+				"read": &prompting.PermissionEntry{
+					Outcome:  prompting.OutcomeAllow,
+					Lifespan: lifespan,
+				},
+			},
+		}
+		rule, err := mgr.AddRule(s.defaultUser, "firefox", "home", constraints)
+		c.Assert(err, IsNil)
+		return rule
+	}
+
+	addRule("/home/test/foo", prompting.LifespanSession)
+	c.Check(s.monitoredSessions, DeepEquals, []uint32{s.defaultUser})
+
+	// The session of a user is only monitored once
+	foreverRule := addRule("/home/test/bar", prompting.LifespanForever)
+	addRule("/home/test/baz", prompting.LifespanSession)
+	c.Check(s.monitoredSessions, DeepEquals, []uint32{s.defaultUser})
+
+	rules, err := mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	c.Check(rules, HasLen, 3)
+
+	whenEnded := time.Now()
+	s.sessionEnded <- fmt.Sprintf("%d", s.defaultUser)
+
+	for i := 0; ; i++ {
+		rules, err = mgr.Rules(s.defaultUser, "", "")
+		c.Assert(err, IsNil)
+		if len(rules) == 1 {
+			break
+		}
+		if i >= 500 {
+			c.Fatalf("session rules were not expired: %+v", rules)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Check(rules, DeepEquals, []*requestrules.Rule{foreverRule})
+	s.checkRecordedRuleUpdateNotices(c, whenEnded, 2)
+
+	// A new session rule starts monitoring the new session
+	addRule("/home/test/foo", prompting.LifespanSession)
+	c.Check(s.monitoredSessions, DeepEquals, []uint32{s.defaultUser, s.defaultUser})
+
+	// Monitoring is done once the manager stops, so that monitors do not
+	// wait for the run loop to receive ended sessions
+	select {
+	case <-s.sessionDone:
+		c.Fatal("session monitoring done before the manager stopped")
+	default:
+	}
+	c.Assert(mgr.Stop(), IsNil)
+	select {
+	case <-s.sessionDone:
+	default:
+		c.Fatal("session monitoring not done after the manager stopped")
+	}
+}
+
+func (s *apparmorpromptingSuite) TestSessionRulesReplyAndPatch(c *C) {
+	reqChan, replyChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+
+	_, prompt := s.simulateRequest(c, reqChan, mgr, &listener.Request{}, false)
+
+	constraints := prompting.ReplyConstraints{
+		PathPattern: mustParsePathPattern(c, "/home/test/**"),
+		Permissions: []string{"read"},
+	}
+	_, err = mgr.HandleReply(s.defaultUser, prompt.ID, &constraints, prompting.OutcomeAllow, prompting.LifespanSession, "", false)
+	c.Assert(err, IsNil)
+	_, err = waitForReply(replyChan)
+	c.Assert(err, IsNil)
+	c.Check(s.monitoredSessions, DeepEquals, []uint32{s.defaultUser})
+
+	rule, err := mgr.AddRule(s.defaultUser+1, "firefox", "home", &prompting.Constraints{
+		PathPattern: mustParsePathPattern(c, "/home/test/**"),
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanForever,
+			},
+		},
+	})
+	c.Assert(err, IsNil)
+	c.Check(s.monitoredSessions, DeepEquals, []uint32{s.defaultUser})
+
+	_, err = mgr.PatchRule(rule.User, rule.ID, &prompting.RuleConstraintsPatch{
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanSession,
+			},
+		},
+	})
+	c.Assert(err, IsNil)
+	c.Check(s.monitoredSessions, DeepEquals, []uint32{s.defaultUser, s.defaultUser + 1})
+
+	c.Assert(mgr.Stop(), IsNil)
+}
+
+func (s *apparmorpromptingSuite) TestSessionRulesOnStartup(c *C) {
+	_, _, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+
+	constraints := &prompting.Constraints{
+		PathPattern: mustParsePathPattern(c, "/home/test/**"),
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanSession,
+			},
+		},
+	}
+	_, err = mgr.AddRule(s.defaultUser, "firefox", "home", constraints)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.Stop(), IsNil)
+
+	// Sessions of users with session rules are monitored on startup
+	s.monitoredSessions = nil
+	_, _, restore = apparmorprompting.MockListener()
+	defer restore()
+	mgr, err = apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	c.Check(s.monitoredSessions, DeepEquals, []uint32{s.defaultUser})
+	rules, err := mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	c.Check(rules, HasLen, 1)
+	c.Assert(mgr.Stop(), IsNil)
+
+	// If a session cannot be monitored, its session rules are expired
+	restoreMonitor := apparmorprompting.MockMonitorUserSessionEnded(func(uid uint32, channel chan<- string, done <-chan struct{}) error {
+		return fmt.Errorf("boom")
+	})
+	defer restoreMonitor()
+	_, _, restore = apparmorprompting.MockListener()
+	defer restore()
+	mgr, err = apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	rules, err = mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	c.Check(rules, HasLen, 0)
+
+	// And new session rules cannot be added
+	_, err = mgr.AddRule(s.defaultUser, "firefox", "home", constraints)
+	c.Check(err, ErrorMatches, "cannot monitor session of user 1000: boom")
+	rules, err = mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	c.Check(rules, HasLen, 0)
+
+	c.Assert(mgr.Stop(), IsNil)
+}
+
+func (s *apparmorpromptingSuite) TestTimeoutPolicyFromConfig(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+	tr := config.NewTransaction(s.st)
+	policy, err := apparmorprompting.TimeoutPolicyFromConfig(tr)
+	c.Assert(err, IsNil)
+	c.Check(policy, DeepEquals, requestprompts.DefaultTimeoutPolicy())
+
+	tr.Set("core", "prompting.timeout", "2m")
+	tr.Set("core", "prompting.activity-timeout", "1h")
+	tr.Set("core", "prompting.default-reply", "allow")
+	tr.Set("core", "prompting.camera.default-reply", "deny")
+
+	policy, err = apparmorprompting.TimeoutPolicyFromConfig(tr)
+	c.Assert(err, IsNil)
+	c.Check(policy, DeepEquals, requestprompts.TimeoutPolicy{
+		Timeout:         2 * time.Minute,
+		ActivityTimeout: time.Hour,
+		DefaultReply:    prompting.OutcomeAllow,
+		InterfaceDefaultReplies: map[string]prompting.OutcomeType{
+			"camera": prompting.OutcomeDeny,
+		},
+	})
+
+	tr.Set("core", "prompting.activity-timeout", "later")
+
+	_, err = apparmorprompting.TimeoutPolicyFromConfig(tr)
+	c.Check(err, ErrorMatches, `cannot parse prompting.activity-timeout: .*`)
+}
+
+func (s *apparmorpromptingSuite) TestUpdateTimeoutPolicy(c *C) {
+	// Without a running manager, there is nothing to update
+	s.st.Lock()
+	tr := config.NewTransaction(s.st)
+	tr.Set("core", "prompting.timeout", "2m")
+	tr.Commit()
+	c.Check(apparmorprompting.UpdateTimeoutPolicy(s.st, config.NewTransaction(s.st)), IsNil)
+	s.st.Unlock()
+
+	_, _, restore := apparmorprompting.MockListener()
+	defer restore()
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+
+	// The manager starts with the policy of the current options
+	expected := requestprompts.DefaultTimeoutPolicy()
+	expected.Timeout = 2 * time.Minute
+	c.Check(mgr.PromptDB().TimeoutPolicy(), DeepEquals, expected)
+
+	// And is updated when the options change
+	s.st.Lock()
+	tr = config.NewTransaction(s.st)
+	tr.Set("core", "prompting.default-reply", "allow")
+	c.Check(apparmorprompting.UpdateTimeoutPolicy(s.st, tr), IsNil)
+	s.st.Unlock()
+	expected.DefaultReply = prompting.OutcomeAllow
+	c.Check(mgr.PromptDB().TimeoutPolicy(), DeepEquals, expected)
+
+	// Options which cannot be used keep the previous policy
+	s.st.Lock()
+	tr.Set("core", "prompting.timeout", "later")
+	c.Check(apparmorprompting.UpdateTimeoutPolicy(s.st, tr), ErrorMatches, `cannot parse prompting.timeout: .*`)
+	s.st.Unlock()
+	c.Check(mgr.PromptDB().TimeoutPolicy(), DeepEquals, expected)
+
+	// Once stopped, the manager is no longer updated
+	pdb := mgr.PromptDB()
+	c.Assert(mgr.Stop(), IsNil)
+	s.st.Lock()
+	tr = config.NewTransaction(s.st)
+	tr.Set("core", "prompting.timeout", "5m")
+	c.Check(apparmorprompting.UpdateTimeoutPolicy(s.st, tr), IsNil)
+	s.st.Unlock()
+	c.Check(pdb.TimeoutPolicy(), DeepEquals, expected)
+}
+
+func (s *apparmorpromptingSuite) TestImportExportRules(c *C) {
+	reqChan, replyChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+
+	export := &requestrules.RulesExport{
+		Version: requestrules.RulesExportVersion,
+		Rules: []*requestrules.ExportedRule{
+			{
+				Snap:      "firefox",
+				Interface: "home",
+				Constraints: &prompting.Constraints{
+					PathPattern: mustParsePathPattern(c, "/home/test/Downloads/**"),
+					Permissions: prompting.PermissionMap{
+						"read": &prompting.PermissionEntry{
+							Outcome:  prompting.OutcomeAllow,
+							Lifespan: prompting.LifespanForever,
+						},
+						"write": &prompting.PermissionEntry{
+							Outcome:  prompting.OutcomeAllow,
+							Lifespan: prompting.LifespanSession,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	whenSent := time.Now()
+	rules, err := mgr.ImportRules(s.defaultUser, export)
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 1)
+	c.Check(rules[0].User, Equals, s.defaultUser)
+	c.Check(rules[0].Admin, Equals, false)
+	s.checkRecordedRuleUpdateNotices(c, whenSent, 1)
+	// The session of the user is monitored for the session permission
+	c.Check(s.monitoredSessions, DeepEquals, []uint32{s.defaultUser})
+
+	exported, err := mgr.ExportRules(s.defaultUser)
+	c.Assert(err, IsNil)
+	c.Check(exported, DeepEquals, export)
+
+	// Importing the same rules again is harmless, as they do not conflict
+	_, err = mgr.ImportRules(s.defaultUser, export)
+	c.Assert(err, IsNil)
+
+	// Admin rules apply to outstanding prompts of all users
+	req := &listener.Request{
+		Path:       "/home/test/Documents/secret.txt",
+		Permission: notify.AA_MAY_READ,
+	}
+	_, prompt := s.simulateRequest(c, reqChan, mgr, req, false)
+
+	adminExport := &requestrules.RulesExport{
+		Version: requestrules.RulesExportVersion,
+		Rules: []*requestrules.ExportedRule{
+			{
+				Snap:      "firefox",
+				Interface: "home",
+				Constraints: &prompting.Constraints{
+					PathPattern: mustParsePathPattern(c, "/home/*/Documents/secret*"),
+					Permissions: prompting.PermissionMap{
+						"read": &prompting.PermissionEntry{
+							Outcome:  prompting.OutcomeDeny,
+							Lifespan: prompting.LifespanForever,
+						},
+					},
+				},
+			},
+		},
+	}
+	adminRules, err := mgr.ImportRules(requestrules.AdminUser, adminExport)
+	c.Assert(err, IsNil)
+	c.Assert(adminRules, HasLen, 1)
+	c.Check(adminRules[0].Admin, Equals, true)
+
+	resp, err := waitForReply(replyChan)
+	c.Assert(err, IsNil)
+	c.Check(resp.Request, Equals, req)
+	c.Check(resp.AllowedPermission, DeepEquals, notify.FilePermission(0))
+	clientActivity := false
+	_, err = mgr.PromptWithID(s.defaultUser, prompt.ID, clientActivity)
+	c.Check(err, Equals, prompting_errors.ErrPromptNotFound)
+
+	// The user can see but not remove the admin rule
+	userRules, err := mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	c.Check(userRules, HasLen, 3)
+	_, err = mgr.RemoveRule(s.defaultUser, adminRules[0].ID)
+	c.Check(err, Equals, prompting_errors.ErrRuleAdminOwned)
+
+	// Admin rules are not exported along with the rules of the user
+	exported, err = mgr.ExportRules(s.defaultUser)
+	c.Assert(err, IsNil)
+	c.Check(exported.Rules, HasLen, 2)
+	exported, err = mgr.ExportRules(requestrules.AdminUser)
+	c.Assert(err, IsNil)
+	c.Check(exported, DeepEquals, adminExport)
+
+	c.Assert(mgr.Stop(), IsNil)
+}
+
+func (s *apparmorpromptingSuite) TestProvisionGadgetRules(c *C) {
+	gadgetDir := c.MkDir()
+	rulesFile := filepath.Join(gadgetDir, "meta", "prompting-rules.json")
+	gadgetPresent := false
+	restore := apparmorprompting.MockGadgetPromptingRulesFile(func(st *state.State) (string, error) {
+		if !gadgetPresent {
+			return "", nil
+		}
+		return rulesFile, nil
+	})
+	defer restore()
+
+	startManager := func() *apparmorprompting.InterfacesRequestsManager {
+		_, _, restore := apparmorprompting.MockListener()
+		s.AddCleanup(restore)
+		mgr, err := apparmorprompting.New(s.st)
+		c.Assert(err, IsNil)
+		return mgr
+	}
+	isProvisioned := func() bool {
+		s.st.Lock()
+		defer s.st.Unlock()
+		var provisioned bool
+		err := s.st.Get("prompting-gadget-rules-provisioned", &provisioned)
+		if !errors.Is(err, state.ErrNoState) {
+			c.Assert(err, IsNil)
+		}
+		return provisioned
+	}
+
+	c.Assert(os.MkdirAll(filepath.Dir(rulesFile), 0o755), IsNil)
+	c.Assert(os.WriteFile(rulesFile, []byte(`{
+	"version": 1,
+	"rules": [
+		{
+			"snap": "firefox",
+			"interface": "home",
+			"constraints": {
+				"path-pattern": "/home/*/.ssh/**",
+				"permissions": {
+					"read": {"outcome": "deny", "lifespan": "forever"}
+				}
+			}
+		}
+	]
+}`), 0o644), IsNil)
+
+	// No gadget yet
+	mgr := startManager()
+	rules, err := mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	c.Check(rules, HasLen, 0)
+	c.Check(isProvisioned(), Equals, false)
+
+	// Gadget rules are imported as admin rules once the gadget is present,
+	// while prompting is running
+	gadgetPresent = true
+	mgr.ProvisionGadgetRules()
+	rules, err = mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 1)
+	c.Check(rules[0].Admin, Equals, true)
+	c.Check(rules[0].Constraints.PathPattern.String(), Equals, "/home/*/.ssh/**")
+	c.Check(isProvisioned(), Equals, true)
+
+	// Admin rules apply to all users, so their notices are visible to them
+	s.st.Lock()
+	otherUser := s.defaultUser + 1
+	notices := s.st.Notices(&state.NoticeFilter{
+		UserID: &otherUser,
+		Types:  []state.NoticeType{state.InterfacesRequestsRuleUpdateNotice},
+	})
+	s.st.Unlock()
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].String(), Matches, fmt.Sprintf(`Notice .* \(public:interfaces-requests-rule-update:%s\)`, rules[0].ID))
+
+	// Once removed by the admin, gadget rules are not imported again
+	_, err = mgr.RemoveRule(requestrules.AdminUser, rules[0].ID)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.Stop(), IsNil)
+	mgr = startManager()
+	rules, err = mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	c.Check(rules, HasLen, 0)
+	c.Assert(mgr.Stop(), IsNil)
+}
+
+func (s *apparmorpromptingSuite) TestProvisionGadgetRulesSeededWithoutGadget(c *C) {
+	calls := 0
+	restore := apparmorprompting.MockGadgetPromptingRulesFile(func(st *state.State) (string, error) {
+		calls++
+		return "", nil
+	})
+	defer restore()
+
+	_, _, restore = apparmorprompting.MockListener()
+	defer restore()
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	defer mgr.Stop()
+	c.Check(calls, Equals, 1)
+
+	// Not seeded yet, so the gadget may still be installed
+	mgr.ProvisionGadgetRules()
+	c.Check(calls, Equals, 2)
+
+	s.st.Lock()
+	s.st.Set("seeded", true)
+	s.st.Unlock()
+	mgr.ProvisionGadgetRules()
+	c.Check(calls, Equals, 3)
+
+	// Seeded without a gadget, so there is nothing left to do
+	mgr.ProvisionGadgetRules()
+	c.Check(calls, Equals, 3)
+}
+
+func (s *apparmorpromptingSuite) TestProvisionGadgetRulesErrors(c *C) {
+	rulesFile := filepath.Join(c.MkDir(), "prompting-rules.json")
+	restore := apparmorprompting.MockGadgetPromptingRulesFile(func(st *state.State) (string, error) {
+		return rulesFile, nil
+	})
+	defer restore()
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	for _, contents := range []string{
+		`{"version": 1, "rules": [`,
+		`{"version": 2, "rules": []}`,
+		`{"version": 1, "rules": [{"snap": "firefox", "interface": "home"}]}`,
+	} {
+		c.Assert(os.WriteFile(rulesFile, []byte(contents), 0o644), IsNil)
+		_, _, restore := apparmorprompting.MockListener()
+		mgr, err := apparmorprompting.New(s.st)
+		c.Assert(err, IsNil)
+		rules, err := mgr.Rules(s.defaultUser, "", "")
+		c.Assert(err, IsNil)
+		c.Check(rules, HasLen, 0)
+		c.Assert(mgr.Stop(), IsNil)
+		restore()
+	}
+	c.Check(logbuf.String(), Matches, `(?s).*cannot provision gadget prompting rules: cannot decode .*`+
+		`.*cannot provision gadget prompting rules: unsupported rules export version: 2.*`+
+		`.*cannot provision gadget prompting rules: cannot import rule 0: .*`)
+
+	// Provisioning is retried after failures, and a gadget which does not
+	// provide prompting rules is considered provisioned
+	c.Assert(os.Remove(rulesFile), IsNil)
+	_, _, restoreListener := apparmorprompting.MockListener()
+	defer restoreListener()
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.Stop(), IsNil)
+	s.st.Lock()
+	defer s.st.Unlock()
+	var provisioned bool
+	c.Assert(s.st.Get("prompting-gadget-rules-provisioned", &provisioned), IsNil)
+	c.Check(provisioned, Equals, true)
+}
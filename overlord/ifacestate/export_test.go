@@ -136,6 +136,10 @@ func MockInterfacesRequestsManagerStop(new func(m *apparmorprompting.InterfacesR
 	return testutil.Mock(&interfacesRequestsManagerStop, new)
 }
 
+func MockInterfacesRequestsManagerProvisionGadgetRules(new func(m *apparmorprompting.InterfacesRequestsManager)) (restore func()) {
+	return testutil.Mock(&interfacesRequestsManagerProvisionGadgetRules, new)
+}
+
 func MockAssessAppArmorPrompting(new func(m *InterfaceManager) bool) (restore func()) {
 	return testutil.Mock(&assessAppArmorPrompting, new)
 }
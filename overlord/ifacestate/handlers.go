@@ -734,6 +734,7 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) (err error)
 		HotplugKey:       slot.HotplugKey,
 	}
 	setConns(st, conns)
+	addConnectionNotice(st, state.InterfaceConnectedNotice, connRef, conn.Interface())
 
 	// the dynamic attributes might have been updated by the interface's BeforeConnectPlug/Slot code,
 	// so we need to update the task for connect-plug- and connect-slot- hooks to see new values.
@@ -853,6 +854,7 @@ func (m *InterfaceManager) doDisconnect(task *state.Task, _ *tomb.Tomb) error {
 		delete(conns, cref.ID())
 	}
 	setConns(st, conns)
+	addConnectionNotice(st, state.InterfaceDisconnectedNotice, &cref, conn.Interface)
 
 	return nil
 }
@@ -959,6 +961,7 @@ func (m *InterfaceManager) undoDisconnect(task *state.Task, _ *tomb.Tomb) error
 
 	conns[connRef.ID()] = &oldconn
 	setConns(st, conns)
+	addConnectionNotice(st, state.InterfaceConnectedNotice, connRef, oldconn.Interface)
 
 	return nil
 }
@@ -981,6 +984,11 @@ func (m *InterfaceManager) undoConnect(task *state.Task, _ *tomb.Tomb) error {
 		return err
 	}
 
+	var ifaceName string
+	if cur, ok := conns[connRef.ID()]; ok {
+		ifaceName = cur.Interface
+	}
+
 	var old schema.ConnState
 	err = task.Get("old-conn", &old)
 	if err != nil && !errors.Is(err, state.ErrNoState) {
@@ -996,6 +1004,7 @@ func (m *InterfaceManager) undoConnect(task *state.Task, _ *tomb.Tomb) error {
 	if err := m.repo.Disconnect(connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name); err != nil {
 		return err
 	}
+	addConnectionNotice(st, state.InterfaceDisconnectedNotice, &connRef, ifaceName)
 
 	var delayedSetupProfiles bool
 	if err := task.Get("delayed-setup-profiles", &delayedSetupProfiles); err != nil && !errors.Is(err, state.ErrNoState) {
@@ -1058,6 +1058,25 @@ func setConns(st *state.State, conns map[string]*schema.ConnState) {
 	st.Set("conns", remapped)
 }
 
+// addConnectionNotice records a notice of the given type about the
+// connection, keyed by its ID as found in the state. Failing to do so is
+// logged but does not fail the task.
+func addConnectionNotice(st *state.State, noticeType state.NoticeType, connRef *interfaces.ConnRef, ifaceName string) {
+	cref := *connRef
+	cref.PlugRef.Snap = RemapSnapToState(cref.PlugRef.Snap)
+	cref.SlotRef.Snap = RemapSnapToState(cref.SlotRef.Snap)
+	data := map[string]string{
+		"plug": cref.PlugRef.String(),
+		"slot": cref.SlotRef.String(),
+	}
+	if ifaceName != "" {
+		data["interface"] = ifaceName
+	}
+	if _, err := st.AddNotice(nil, noticeType, cref.ID(), &state.AddNoticeOptions{Data: data}); err != nil {
+		logger.Noticef("cannot record %s notice for connection %q: %v", noticeType, cref.ID(), err)
+	}
+}
+
 // snapsWithSecurityProfiles returns all snaps that have active
 // security profiles: these are either snaps that are active,
 // inactive snaps that are being operated on, whose profile state
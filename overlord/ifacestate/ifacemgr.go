@@ -255,6 +255,8 @@ func (m *InterfaceManager) Ensure() error {
 		return nil
 	}
 
+	m.provisionGadgetPromptingRules()
+
 	if m.udevMonitorDisabled {
 		return nil
 	}
@@ -315,6 +317,25 @@ var interfacesRequestsManagerStop = func(interfacesRequestsManager *apparmorprom
 	return interfacesRequestsManager.Stop()
 }
 
+// interfacesRequestsManagerProvisionGadgetRules calls ProvisionGadgetRules on
+// the given manager. The state lock must not be held while this function is
+// called.
+var interfacesRequestsManagerProvisionGadgetRules = func(interfacesRequestsManager *apparmorprompting.InterfacesRequestsManager) {
+	interfacesRequestsManager.ProvisionGadgetRules()
+}
+
+// provisionGadgetPromptingRules imports the prompting rules of the gadget, if
+// prompting is running. The gadget may only become available after prompting
+// was started, while seeding.
+func (m *InterfaceManager) provisionGadgetPromptingRules() {
+	m.interfacesRequestsManagerMu.Lock()
+	defer m.interfacesRequestsManagerMu.Unlock()
+	if m.interfacesRequestsManager == nil {
+		return
+	}
+	interfacesRequestsManagerProvisionGadgetRules(m.interfacesRequestsManager)
+}
+
 func (m *InterfaceManager) stopInterfacesRequestsManager() {
 	m.interfacesRequestsManagerMu.Lock()
 	defer m.interfacesRequestsManagerMu.Unlock()
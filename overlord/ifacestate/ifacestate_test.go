@@ -21,6 +21,7 @@ package ifacestate_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -415,6 +416,38 @@ func (s *interfaceManagerSuite) TestSmokeAppArmorPromptingEnabled(c *C) {
 	c.Check(mgr.InterfacesRequestsManager(), testutil.IsInterfaceNil)
 }
 
+func (s *interfaceManagerSuite) TestEnsureProvisionsGadgetPromptingRules(c *C) {
+	restore := ifacestate.MockAssessAppArmorPrompting(func(m *ifacestate.InterfaceManager) bool {
+		return true
+	})
+	defer restore()
+	fakeManager := &apparmorprompting.InterfacesRequestsManager{}
+	restore = ifacestate.MockCreateInterfacesRequestsManager(func(s *state.State) (*apparmorprompting.InterfacesRequestsManager, error) {
+		return fakeManager, nil
+	})
+	defer restore()
+	provisionCount := 0
+	restore = ifacestate.MockInterfacesRequestsManagerProvisionGadgetRules(func(m *apparmorprompting.InterfacesRequestsManager) {
+		provisionCount++
+		c.Check(m, Equals, fakeManager)
+		// the gadget is looked up with the state lock
+		s.state.Lock()
+		defer s.state.Unlock()
+	})
+	defer restore()
+
+	mgr := s.manager(c)
+	c.Check(provisionCount, Equals, 0)
+
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(provisionCount, Equals, 1)
+
+	// no longer once prompting is stopped
+	mgr.Stop()
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(provisionCount, Equals, 1)
+}
+
 func (s *interfaceManagerSuite) TestSmokeAppArmorPromptingDisabled(c *C) {
 	restore := ifacestate.MockAssessAppArmorPrompting(func(m *ifacestate.InterfaceManager) bool {
 		return false
@@ -1825,6 +1858,38 @@ func (s *interfaceManagerSuite) testDisconnect(c *C, plugSnap, plugName, slotSna
 	c.Check(producerAppSet.InstanceName(), Equals, "producer")
 	c.Check(producerAppSet.Runnables(), testutil.DeepUnsortedMatches, producerRunnablesFullSet)
 
+	// Ensure that the disconnection was notified
+	c.Check(connectionNotices(c, s.state), DeepEquals, []map[string]any{{
+		"type": "interface-disconnected",
+		"key":  "consumer:plug producer:slot",
+		"last-data": map[string]any{
+			"interface": "test",
+			"plug":      "consumer:plug",
+			"slot":      "producer:slot",
+		},
+	}})
+}
+
+// connectionNotices returns the relevant fields of the interface connection
+// notices.
+func connectionNotices(c *C, st *state.State) []map[string]any {
+	notices := st.Notices(&state.NoticeFilter{Types: []state.NoticeType{
+		state.InterfaceConnectedNotice,
+		state.InterfaceDisconnectedNotice,
+	}})
+	var result []map[string]any
+	for _, notice := range notices {
+		buf, err := json.Marshal(notice)
+		c.Assert(err, IsNil)
+		var n map[string]any
+		c.Assert(json.Unmarshal(buf, &n), IsNil)
+		result = append(result, map[string]any{
+			"type":      n["type"],
+			"key":       n["key"],
+			"last-data": n["last-data"],
+		})
+	}
+	return result
 }
 
 func (s *interfaceManagerSuite) TestDisconnectUndo(c *C) {
@@ -6753,6 +6818,17 @@ func (s *interfaceManagerSuite) TestUndoConnect(c *C) {
 	c.Check(s.secBackend.SetupCalls[3].Options, DeepEquals, interfaces.ConfinementOptions{})
 	c.Check(s.secBackend.SetupCalls[2].AppSet.Runnables(), testutil.DeepUnsortedMatches, producerRunnablesFullSet)
 	c.Check(s.secBackend.SetupCalls[3].AppSet.Runnables(), testutil.DeepUnsortedMatches, consumerRunnablesFullSet)
+
+	// both the connection and its undoing were notified
+	data := map[string]any{
+		"interface": "test",
+		"plug":      "consumer:plug",
+		"slot":      "producer:slot",
+	}
+	c.Check(connectionNotices(c, s.state), DeepEquals, []map[string]any{
+		{"type": "interface-connected", "key": "consumer:plug producer:slot", "last-data": data},
+		{"type": "interface-disconnected", "key": "consumer:plug producer:slot", "last-data": data},
+	})
 }
 
 func (s *interfaceManagerSuite) TestUndoConnectUndesired(c *C) {
@@ -0,0 +1,290 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package overlord
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// The state journal is a write-ahead log of state entries on top of
+// state.json. Each record is a line made of the CRC32 (Castagnoli) of its
+// payload in hex, a space and the payload, a JSON list of the entries
+// written by one checkpoint:
+//
+//	<crc32> [{"key":"data/foo","value":{...}},{"key":"task/1","value":null}]
+//
+// The first record only holds the SHA256 digest of the state.json the
+// journal applies to, as the value of the "base" entry, and replaying the
+// records in order on top of it yields the state. Once the journal grows
+// too large compared to the state it is compacted, by atomically writing
+// the whole state to state.json and starting a new journal based on it.
+//
+// state.json is thus always a complete, if possibly outdated, copy of the
+// state that tools reading it keep working with, and it is brought up to
+// date when the overlord stops, for instance before downgrading to a
+// snapd that does not know about the journal. A journal that does not
+// apply to the current state.json, as left behind by such a downgrade,
+// is discarded.
+
+var (
+	// the journal is not compacted below this size
+	journalMinCompactSize int64 = 4 * 1024 * 1024
+	// the journal is compacted once its records are this many times
+	// larger than the state they describe
+	journalCompactRatio int64 = 4
+)
+
+const journalBaseKey = "base"
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+type journalStateBackend struct {
+	path         string
+	statePath    string
+	ensureBefore func(d time.Duration)
+
+	f *os.File
+	// size of the valid records in the journal
+	size int64
+	// size of state.json as last written
+	fullSize int64
+}
+
+// openJournalStateBackend opens the state journal at path, creating it if
+// needed, and returns the backend together with the content of the
+// state.json at statePath it applies to, if any, and the state entries
+// replayed from it to apply on top.
+func openJournalStateBackend(path, statePath string, ensureBefore func(d time.Duration)) (jb *journalStateBackend, base []byte, entries []state.JournalEntry, err error) {
+	base, err = os.ReadFile(statePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, nil, fmt.Errorf("cannot read the state file: %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("cannot open state journal: %v", err)
+	}
+	baseDigest, entries, size, err := replayJournal(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, nil, fmt.Errorf("cannot read state journal %q: %v", path, err)
+	}
+	if size > 0 && (base == nil || baseDigest != journalBaseDigest(base)) {
+		logger.Noticef("discarding state journal %s not applying to the current %s", path, statePath)
+		entries = nil
+		size = 0
+	}
+	// drop anything left by a checkpoint interrupted half-way
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, nil, nil, fmt.Errorf("cannot truncate state journal: %v", err)
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, nil, fmt.Errorf("cannot seek state journal: %v", err)
+	}
+	jb = &journalStateBackend{
+		path:         path,
+		statePath:    statePath,
+		ensureBefore: ensureBefore,
+		f:            f,
+		size:         size,
+		fullSize:     int64(len(base)),
+	}
+	return jb, base, entries, nil
+}
+
+func journalBaseDigest(full []byte) string {
+	h := sha256.Sum256(full)
+	return hex.EncodeToString(h[:])
+}
+
+// replayJournal reads the records of the journal in order and returns the
+// digest of the state.json they apply to, the resulting entries, sorted
+// by key, with a nil Value for those removed, and the size of the valid
+// records. A trailing incomplete or corrupted record is the result of a
+// checkpoint that never completed, and is ignored.
+func replayJournal(r io.Reader) (base string, entries []state.JournalEntry, size int64, err error) {
+	values := make(map[string]json.RawMessage)
+	br := bufio.NewReader(r)
+	var torn bool
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return "", nil, 0, err
+		}
+		if len(line) == 0 {
+			break
+		}
+		if torn {
+			return "", nil, 0, fmt.Errorf("corrupted record at offset %d", size)
+		}
+		record, ok := decodeJournalRecord(line)
+		if !ok {
+			torn = true
+			continue
+		}
+		for _, e := range record {
+			if size == 0 && e.Key == journalBaseKey {
+				if err := json.Unmarshal(e.Value, &base); err != nil {
+					return "", nil, 0, fmt.Errorf("invalid base record: %v", err)
+				}
+				continue
+			}
+			values[e.Key] = e.Value
+		}
+		size += int64(len(line))
+	}
+
+	entries = make([]state.JournalEntry, 0, len(values))
+	for k, v := range values {
+		entries = append(entries, state.JournalEntry{Key: k, Value: v})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return base, entries, size, nil
+}
+
+func decodeJournalRecord(line []byte) (record []state.JournalEntry, ok bool) {
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		return nil, false
+	}
+	line = line[:len(line)-1]
+	sum, payload, found := bytes.Cut(line, []byte(" "))
+	if !found {
+		return nil, false
+	}
+	if string(sum) != fmt.Sprintf("%08x", crc32.Checksum(payload, crc32cTable)) {
+		return nil, false
+	}
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, false
+	}
+	// JSON null and a missing value are both a removal
+	for i := range record {
+		if bytes.Equal(record[i].Value, []byte("null")) {
+			record[i].Value = nil
+		}
+	}
+	return record, true
+}
+
+func encodeJournalRecord(entries []state.JournalEntry) ([]byte, error) {
+	payload, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%08x ", crc32.Checksum(payload, crc32cTable))
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func (jb *journalStateBackend) needsCompaction() bool {
+	return jb.size == 0 || (jb.size >= journalMinCompactSize && jb.size >= journalCompactRatio*jb.fullSize)
+}
+
+// CheckpointEntries appends the changed entries to the journal, or
+// replaces the journal with all of them when it has grown too large.
+func (jb *journalStateBackend) CheckpointEntries(changed []state.JournalEntry, full func() []byte) error {
+	if jb.needsCompaction() {
+		return jb.compact(full())
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	record, err := encodeJournalRecord(changed)
+	if err != nil {
+		return err
+	}
+	if _, err := jb.f.Write(record); err != nil {
+		return jb.rollback(err)
+	}
+	if err := jb.f.Sync(); err != nil {
+		return jb.rollback(err)
+	}
+	jb.size += int64(len(record))
+	return nil
+}
+
+// rollback drops whatever part of a record was written before failing,
+// so that the checkpoint can be retried.
+func (jb *journalStateBackend) rollback(err error) error {
+	if terr := jb.f.Truncate(jb.size); terr != nil {
+		return fmt.Errorf("%v (and cannot truncate state journal: %v)", err, terr)
+	}
+	if _, serr := jb.f.Seek(jb.size, io.SeekStart); serr != nil {
+		return fmt.Errorf("%v (and cannot seek state journal: %v)", err, serr)
+	}
+	return err
+}
+
+// compact writes the whole state to state.json and starts a new journal
+// applying to it.
+func (jb *journalStateBackend) compact(full []byte) error {
+	if err := osutil.AtomicWriteFile(jb.statePath, full, 0600, 0); err != nil {
+		return err
+	}
+	base, err := json.Marshal(journalBaseDigest(full))
+	if err != nil {
+		return err
+	}
+	record, err := encodeJournalRecord([]state.JournalEntry{{Key: journalBaseKey, Value: base}})
+	if err != nil {
+		return err
+	}
+	// a crash before the journal is replaced leaves it applying to the
+	// previous state.json, and so discarded in favour of the new one
+	if err := osutil.AtomicWriteFile(jb.path, record, 0600, 0); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(jb.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	jb.f.Close()
+	jb.f = f
+	jb.size = int64(len(record))
+	jb.fullSize = int64(len(full))
+	return nil
+}
+
+// Checkpoint is not used with a journal, see CheckpointEntries.
+func (jb *journalStateBackend) Checkpoint(data []byte) error {
+	return errors.New("internal error: cannot checkpoint the whole state into a journal")
+}
+
+func (jb *journalStateBackend) EnsureBefore(d time.Duration) {
+	jb.ensureBefore(d)
+}
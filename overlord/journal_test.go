@@ -0,0 +1,191 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package overlord_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type journalSuite struct {
+	path      string
+	statePath string
+}
+
+var _ = Suite(&journalSuite{})
+
+func (s *journalSuite) SetUpTest(c *C) {
+	dir := c.MkDir()
+	s.path = filepath.Join(dir, "state.journal")
+	s.statePath = filepath.Join(dir, "state.json")
+}
+
+func journalEntry(key, value string) state.JournalEntry {
+	e := state.JournalEntry{Key: key}
+	if value != "" {
+		e.Value = json.RawMessage(value)
+	}
+	return e
+}
+
+func fullState(full string) func() []byte {
+	return func() []byte { return []byte(full) }
+}
+
+func (s *journalSuite) journalLines(c *C) []string {
+	data, err := os.ReadFile(s.path)
+	c.Assert(err, IsNil)
+	return strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func (s *journalSuite) open(c *C) (jb state.JournalBackend, base string, entries []state.JournalEntry) {
+	jb, data, entries, err := overlord.OpenJournalStateBackend(s.path, s.statePath)
+	c.Assert(err, IsNil)
+	return jb, string(data), entries
+}
+
+func (s *journalSuite) TestAppendAndReplay(c *C) {
+	jb, base, entries := s.open(c)
+	c.Check(base, Equals, "")
+	c.Check(entries, HasLen, 0)
+
+	// the first checkpoint writes the whole state to state.json
+	a := journalEntry("data/a", `1`)
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{a}, fullState(`{"a":1}`)), IsNil)
+	c.Check(s.statePath, testutil.FileEquals, `{"a":1}`)
+	c.Check(s.journalLines(c), HasLen, 1)
+
+	// and the following ones are appended to the journal
+	b := journalEntry("data/b", `{"b":2}`)
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{b}, fullState(`{"a":1,"b":{"b":2}}`)), IsNil)
+	c.Check(s.journalLines(c), HasLen, 2)
+
+	a2 := journalEntry("data/a", `10`)
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{a2, journalEntry("data/b", "")}, fullState(`{"a":10}`)), IsNil)
+	c.Check(s.journalLines(c), HasLen, 3)
+	c.Check(s.statePath, testutil.FileEquals, `{"a":1}`)
+
+	// replaying yields the entries to apply on top of state.json
+	_, base, entries = s.open(c)
+	c.Check(base, Equals, `{"a":1}`)
+	c.Check(entries, DeepEquals, []state.JournalEntry{a2, journalEntry("data/b", "")})
+}
+
+func (s *journalSuite) TestReplayIgnoresTornRecord(c *C) {
+	jb, _, _ := s.open(c)
+	a := journalEntry("data/a", `1`)
+	c.Assert(jb.CheckpointEntries(nil, fullState(`{}`)), IsNil)
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{a}, fullState(`{"a":1}`)), IsNil)
+	st, err := os.Stat(s.path)
+	c.Assert(err, IsNil)
+
+	// a checkpoint interrupted half-way
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0600)
+	c.Assert(err, IsNil)
+	_, err = f.WriteString(`0badc0de [{"key":"data/b","val`)
+	c.Assert(err, IsNil)
+	c.Assert(f.Close(), IsNil)
+
+	jb, _, entries := s.open(c)
+	c.Check(entries, DeepEquals, []state.JournalEntry{a})
+	// the incomplete record was dropped
+	st2, err := os.Stat(s.path)
+	c.Assert(err, IsNil)
+	c.Check(st2.Size(), Equals, st.Size())
+
+	b := journalEntry("data/b", `2`)
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{b}, fullState(`{"a":1,"b":2}`)), IsNil)
+	_, _, entries = s.open(c)
+	c.Check(entries, DeepEquals, []state.JournalEntry{a, b})
+}
+
+func (s *journalSuite) TestReplayCorrupted(c *C) {
+	jb, _, _ := s.open(c)
+	c.Assert(jb.CheckpointEntries(nil, fullState(`{}`)), IsNil)
+	a := journalEntry("data/a", `1`)
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{a}, fullState(`{"a":1}`)), IsNil)
+	b := journalEntry("data/b", `2`)
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{b}, fullState(`{"a":1,"b":2}`)), IsNil)
+
+	lines := s.journalLines(c)
+	c.Assert(lines, HasLen, 3)
+	corrupted := lines[0] + strings.Replace(lines[1], `"data/a"`, `"data/x"`, 1) + lines[2]
+	c.Assert(os.WriteFile(s.path, []byte(corrupted), 0600), IsNil)
+
+	_, _, _, err := overlord.OpenJournalStateBackend(s.path, s.statePath)
+	c.Check(err, ErrorMatches, `cannot read state journal ".*": corrupted record at offset [1-9][0-9]*`)
+}
+
+func (s *journalSuite) TestCompaction(c *C) {
+	restore := overlord.MockJournalCompaction(0, 2)
+	defer restore()
+
+	// so that the journal is twice as large as state.json once it holds
+	// a record besides its base
+	padding := strings.Repeat("x", 50)
+	jb, _, _ := s.open(c)
+	a := journalEntry("data/a", `1`)
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{a}, fullState(`{"a":1}`+padding)), IsNil)
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{a}, fullState(`{"a":1}`+padding)), IsNil)
+	c.Check(s.journalLines(c), HasLen, 2)
+
+	// the journal is now twice as large as the state it applies to
+	b := journalEntry("data/b", `2`)
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{b}, fullState(`{"a":1,"b":2}`+padding)), IsNil)
+	c.Check(s.journalLines(c), HasLen, 1)
+	c.Check(s.statePath, testutil.FileEquals, `{"a":1,"b":2}`+padding)
+
+	// and appending continues after compaction
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{journalEntry("data/a", "")}, fullState(`{"b":2}`+padding)), IsNil)
+	c.Check(s.journalLines(c), HasLen, 2)
+
+	_, base, entries := s.open(c)
+	c.Check(base, Equals, `{"a":1,"b":2}`+padding)
+	c.Check(entries, DeepEquals, []state.JournalEntry{journalEntry("data/a", "")})
+}
+
+func (s *journalSuite) TestDiscardStaleJournal(c *C) {
+	jb, _, _ := s.open(c)
+	c.Assert(jb.CheckpointEntries(nil, fullState(`{}`)), IsNil)
+	a := journalEntry("data/a", `1`)
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{a}, fullState(`{"a":1}`)), IsNil)
+
+	// state.json written without the journal, for instance by a snapd
+	// not knowing about it
+	c.Assert(os.WriteFile(s.statePath, []byte(`{"a":2}`), 0600), IsNil)
+
+	jb, base, entries := s.open(c)
+	c.Check(base, Equals, `{"a":2}`)
+	c.Check(entries, HasLen, 0)
+	c.Check(s.path, testutil.FileEquals, "")
+
+	// a new journal applying to it is started
+	c.Assert(jb.CheckpointEntries([]state.JournalEntry{a}, fullState(`{"a":1}`)), IsNil)
+	c.Check(s.statePath, testutil.FileEquals, `{"a":1}`)
+	c.Check(s.journalLines(c), HasLen, 1)
+}
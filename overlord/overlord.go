@@ -22,6 +22,8 @@
 package overlord
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -38,6 +40,7 @@ import (
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/auditstate"
 	"github.com/snapcore/snapd/overlord/cmdstate"
 	"github.com/snapcore/snapd/overlord/confdbstate"
 	"github.com/snapcore/snapd/overlord/configstate"
@@ -88,6 +91,8 @@ var pruneTickerC = func(t *time.Ticker) <-chan time.Time {
 // track of all available state managers and related helpers.
 type Overlord struct {
 	stateFLock *osutil.FileLock
+	// stateJournal is set when the state is persisted through a journal
+	stateJournal *journalStateBackend
 
 	stateEng *StateEngine
 	// ensure loop
@@ -126,11 +131,7 @@ func New(restartHandler restart.Handler) (*Overlord, error) {
 		inited: true,
 	}
 
-	backend := &overlordStateBackend{
-		path:         dirs.SnapStateFile,
-		ensureBefore: o.ensureBefore,
-	}
-	s, restartMgr, err := o.loadState(backend, restartHandler)
+	s, restartMgr, err := o.loadState(restartHandler)
 	if err != nil {
 		return nil, err
 	}
@@ -182,6 +183,7 @@ func New(restartHandler restart.Handler) (*Overlord, error) {
 	o.addManager(cmdstate.Manager(s, o.runner))
 	o.addManager(snapshotstate.Manager(s, o.runner))
 	o.addManager(confdbstate.Manager(s, hookMgr, o.runner))
+	o.addManager(auditstate.Manager(s))
 
 	if err := configstateInit(s, hookMgr); err != nil {
 		return nil, err
@@ -264,7 +266,15 @@ func lockWithTimeout(l *osutil.FileLock, timeout time.Duration) error {
 	}
 }
 
-func (o *Overlord) loadState(backend state.Backend, restartHandler restart.Handler) (*state.State, *restart.RestartManager, error) {
+// useStateJournal returns whether the state is to be persisted through a
+// journal on top of state.json, see journal.go. Once migrated to it, the
+// state keeps using the journal until the latter is removed while snapd
+// is stopped.
+func useStateJournal() bool {
+	return osutil.GetenvBool("SNAPD_STATE_JOURNAL") || osutil.FileExists(dirs.SnapStateJournalFile)
+}
+
+func (o *Overlord) loadState(restartHandler restart.Handler) (*state.State, *restart.RestartManager, error) {
 	flock, err := initStateFileLock()
 	if err != nil {
 		return nil, nil, fmt.Errorf("fatal: error opening lock file: %v", err)
@@ -285,6 +295,29 @@ func (o *Overlord) loadState(backend state.Backend, restartHandler restart.Handl
 
 	perfTimings := timings.New(map[string]string{"startup": "load-state"})
 
+	var backend state.Backend = &overlordStateBackend{
+		path:         dirs.SnapStateFile,
+		ensureBefore: o.ensureBefore,
+	}
+	if useStateJournal() {
+		jb, base, entries, err := openJournalStateBackend(dirs.SnapStateJournalFile, dirs.SnapStateFile, o.ensureBefore)
+		if err != nil {
+			return nil, nil, err
+		}
+		o.stateJournal = jb
+		backend = jb
+		if base != nil {
+			var s *state.State
+			timings.Run(perfTimings, "read-state", "read snapd state and its journal from disk", func(tm timings.Measurer) {
+				s, err = state.ReadStateJournal(backend, bytes.NewReader(base), entries)
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			return finishLoadState(s, perfTimings, curBootID, restartHandler)
+		}
+	}
+
 	if !osutil.FileExists(dirs.SnapStateFile) {
 		// fail fast, mostly interesting for tests, this dir is setup
 		// by the snapd package
@@ -314,6 +347,10 @@ func (o *Overlord) loadState(backend state.Backend, restartHandler restart.Handl
 	if err != nil {
 		return nil, nil, err
 	}
+	return finishLoadState(s, perfTimings, curBootID, restartHandler)
+}
+
+func finishLoadState(s *state.State, perfTimings *timings.Timings, curBootID string, restartHandler restart.Handler) (*state.State, *restart.RestartManager, error) {
 	s.Lock()
 	perfTimings.Save(s)
 	s.Unlock()
@@ -512,6 +549,20 @@ func (o *Overlord) Stop() error {
 		err = o.loopTomb.Wait()
 	}
 	o.stateEng.Stop()
+	if o.stateJournal != nil {
+		// bring state.json up to date, so that it can be used as is
+		// without the journal
+		st := o.State()
+		st.Lock()
+		data, jerr := json.Marshal(st)
+		if jerr == nil {
+			jerr = o.stateJournal.compact(data)
+		}
+		st.Unlock()
+		if jerr != nil {
+			logger.Noticef("cannot write the state file on stop: %v", jerr)
+		}
+	}
 	if o.stateFLock != nil {
 		// This will also unlock the file
 		o.stateFLock.Close()
@@ -20,6 +20,7 @@
 package overlord_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -871,6 +872,124 @@ func (ovs *overlordSuite) TestCheckpoint(c *C) {
 	c.Check(dirs.SnapStateFile, testutil.FileContains, `"mark":1`)
 }
 
+func (ovs *overlordSuite) readStateJournal(c *C) *state.State {
+	_, base, entries, err := overlord.OpenJournalStateBackend(dirs.SnapStateJournalFile, dirs.SnapStateFile)
+	c.Assert(err, IsNil)
+	s, err := state.ReadStateJournal(nil, bytes.NewReader(base), entries)
+	c.Assert(err, IsNil)
+	return s
+}
+
+func (ovs *overlordSuite) TestCheckpointJournal(c *C) {
+	os.Setenv("SNAPD_STATE_JOURNAL", "1")
+	defer os.Unsetenv("SNAPD_STATE_JOURNAL")
+	dirs.SnapStateJournalFile = filepath.Join(filepath.Dir(dirs.SnapStateFile), "test.journal")
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+
+	s := o.State()
+	s.Lock()
+	s.Set("mark", 1)
+	s.Unlock()
+
+	st, err := os.Stat(dirs.SnapStateJournalFile)
+	c.Assert(err, IsNil)
+	c.Assert(st.Mode(), Equals, os.FileMode(0600))
+	// state.json holds the state the journal applies to
+	st, err = os.Stat(dirs.SnapStateFile)
+	c.Assert(err, IsNil)
+	c.Assert(st.Mode(), Equals, os.FileMode(0600))
+	c.Check(dirs.SnapStateFile, Not(testutil.FileContains), `"mark":1`)
+
+	s2 := ovs.readStateJournal(c)
+	s2.Lock()
+	var mark int
+	c.Assert(s2.Get("mark", &mark), IsNil)
+	c.Check(mark, Equals, 1)
+	s2.Unlock()
+
+	// state.json is brought up to date on stop
+	c.Assert(o.Stop(), IsNil)
+	c.Check(dirs.SnapStateFile, testutil.FileContains, `"mark":1`)
+	_, _, entries, err := overlord.OpenJournalStateBackend(dirs.SnapStateJournalFile, dirs.SnapStateFile)
+	c.Assert(err, IsNil)
+	c.Check(entries, HasLen, 0)
+}
+
+func (ovs *overlordSuite) TestNewMigratesStateToJournal(c *C) {
+	os.Setenv("SNAPD_STATE_JOURNAL", "1")
+	defer os.Unsetenv("SNAPD_STATE_JOURNAL")
+	dirs.SnapStateJournalFile = filepath.Join(filepath.Dir(dirs.SnapStateFile), "test.journal")
+
+	fakeState := []byte(fmt.Sprintf(`{"data":{"patch-level":%d,"some":"data"},"changes":null,"tasks":null,"last-change-id":0,"last-task-id":0,"last-lane-id":0}`, patch.Level))
+	err := os.WriteFile(dirs.SnapStateFile, fakeState, 0600)
+	c.Assert(err, IsNil)
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+
+	// the journal now applies to state.json
+	c.Check(dirs.SnapStateJournalFile, testutil.FilePresent)
+	s := ovs.readStateJournal(c)
+	s.Lock()
+	var some string
+	c.Check(s.Get("some", &some), IsNil)
+	c.Check(some, Equals, "data")
+	s.Unlock()
+
+	// and the state is read back from both
+	st := o.State()
+	st.Lock()
+	st.Set("some", "other data")
+	st.Unlock()
+	c.Assert(o.Stop(), IsNil)
+	c.Assert(os.Remove(dirs.SnapStateLockFile), IsNil)
+
+	o, err = overlord.New(nil)
+	c.Assert(err, IsNil)
+	st = o.State()
+	st.Lock()
+	c.Check(st.Get("some", &some), IsNil)
+	c.Check(some, Equals, "other data")
+	st.Set("some", "more data")
+	st.Unlock()
+	c.Assert(o.Stop(), IsNil)
+	c.Assert(os.Remove(dirs.SnapStateLockFile), IsNil)
+
+	// a snapd without the journal can use state.json as is after
+	// stopping
+	c.Check(dirs.SnapStateFile, testutil.FileContains, `"some":"more data"`)
+}
+
+func (ovs *overlordSuite) TestNewDiscardsStaleStateJournal(c *C) {
+	os.Setenv("SNAPD_STATE_JOURNAL", "1")
+	defer os.Unsetenv("SNAPD_STATE_JOURNAL")
+	dirs.SnapStateJournalFile = filepath.Join(filepath.Dir(dirs.SnapStateFile), "test.journal")
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+	st := o.State()
+	st.Lock()
+	st.Set("some", "data")
+	st.Unlock()
+	c.Assert(o.Stop(), IsNil)
+	c.Assert(os.Remove(dirs.SnapStateLockFile), IsNil)
+
+	// state.json written by a snapd without the journal
+	fakeState := []byte(fmt.Sprintf(`{"data":{"patch-level":%d,"some":"downgraded data"},"changes":null,"tasks":null,"last-change-id":0,"last-task-id":0,"last-lane-id":0}`, patch.Level))
+	c.Assert(os.WriteFile(dirs.SnapStateFile, fakeState, 0600), IsNil)
+
+	o, err = overlord.New(nil)
+	c.Assert(err, IsNil)
+	st = o.State()
+	st.Lock()
+	defer st.Unlock()
+	var some string
+	c.Check(st.Get("some", &some), IsNil)
+	c.Check(some, Equals, "downgraded data")
+}
+
 type sampleManager struct {
 	ensureCallback func()
 }
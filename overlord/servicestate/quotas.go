@@ -25,6 +25,7 @@ import (
 	"github.com/snapcore/snapd/overlord/servicestate/internal"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var ErrQuotaNotFound = errors.New("quota not found")
@@ -49,3 +50,18 @@ func GetQuota(st *state.State, name string) (*quota.Group, error) {
 
 	return group, nil
 }
+
+// SnapQuotaGroup returns the name of the quota group the snap is in, or an
+// empty string if it is in none.
+func SnapQuotaGroup(st *state.State, snapName string) (string, error) {
+	allGrps, err := internal.AllQuotas(st)
+	if err != nil {
+		return "", err
+	}
+	for name, grp := range allGrps {
+		if strutil.ListContains(grp.Snaps, snapName) {
+			return name, nil
+		}
+	}
+	return "", nil
+}
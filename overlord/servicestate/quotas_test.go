@@ -113,3 +113,29 @@ func (s *servicestateQuotasSuite) TestQuotas(c *C) {
 	_, err = servicestate.GetQuota(st, "unknown")
 	c.Assert(err, Equals, servicestate.ErrQuotaNotFound)
 }
+
+func (s *servicestateQuotasSuite) TestSnapQuotaGroup(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	name, err := servicestate.SnapQuotaGroup(st, "test-snap")
+	c.Assert(err, IsNil)
+	c.Check(name, Equals, "")
+
+	grp := &quota.Group{
+		Name:        "foogroup",
+		MemoryLimit: quantity.SizeGiB,
+		Snaps:       []string{"test-snap"},
+	}
+	_, err = servicestatetest.PatchQuotas(st, grp)
+	c.Assert(err, IsNil)
+
+	name, err = servicestate.SnapQuotaGroup(st, "test-snap")
+	c.Assert(err, IsNil)
+	c.Check(name, Equals, "foogroup")
+
+	name, err = servicestate.SnapQuotaGroup(st, "other-snap")
+	c.Assert(err, IsNil)
+	c.Check(name, Equals, "")
+}
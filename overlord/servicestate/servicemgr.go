@@ -212,6 +212,7 @@ func delayedCrossMgrInit() {
 	snapstate.RegisterAffectedSnapsByAttr("service-action", serviceControlAffectedSnaps)
 	snapstate.SnapServiceOptions = SnapServiceOptions
 	snapstate.EnsureSnapAbsentFromQuotaGroup = EnsureSnapAbsentFromQuota
+	snapstate.SnapQuotaGroup = SnapQuotaGroup
 }
 
 func serviceControlAffectedSnaps(t *state.Task) ([]string, error) {
@@ -301,8 +301,29 @@ func EstimateSnapshotSize(si *snap.Info, usernames []string, dirOpts *dirs.SnapD
 	return total, nil
 }
 
+// SaveFlags carries extra flags to drive save behavior.
+type SaveFlags struct {
+	// Incremental tells save to store the snapshot archives in the
+	// shared, content-addressed chunk store, so that only the chunks
+	// that changed since a previous snapshot take up additional space.
+	// It has no effect on encrypted snapshots: each of them has its own
+	// key, so their chunks could never be shared.
+	Incremental bool
+	// Encryption, if set, tells save to encrypt the snapshot archives.
+	Encryption *EncryptionFlags
+
+	// dataKey is the key the archives are encrypted with
+	dataKey []byte
+}
+
 // Save a snapshot
-func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, dynSnapshotOpts *snap.SnapshotOptions, dirOpts *dirs.SnapDirOptions) (*client.Snapshot, error) {
+func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, dynSnapshotOpts *snap.SnapshotOptions, dirOpts *dirs.SnapDirOptions, flags *SaveFlags) (*client.Snapshot, error) {
+	if flags == nil {
+		flags = &SaveFlags{}
+	}
+	// flags.dataKey is set below, so work on a copy
+	flagsCopy := *flags
+	flags = &flagsCopy
 	if err := os.MkdirAll(dirs.SnapshotsDir, 0700); err != nil {
 		return nil, err
 	}
@@ -335,6 +356,20 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interfac
 		}
 	}
 
+	if flags.Encryption != nil {
+		flags.dataKey, snapshot.Encryption, err = newSnapshotKey(flags.Encryption)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create snapshot key: %v", err)
+		}
+	}
+
+	if flags.Incremental && flags.dataKey == nil {
+		// the chunks stored below are only referenced once the
+		// snapshot is committed, do not let them be pruned until then
+		chunksMu.RLock()
+		defer chunksMu.RUnlock()
+	}
+
 	aw, err := osutil.NewAtomicFile(Filename(snapshot), 0600, 0, osutil.NoChown, osutil.NoChown)
 	if err != nil {
 		return nil, err
@@ -346,7 +381,7 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interfac
 	defer w.Close() // note this does not close the file descriptor (that's done by hand on the atomic writer, above)
 	savingUserData := false
 	baseDataDir := snap.BaseDataDir(si.InstanceName())
-	if err := addSnapDirToZip(ctx, snapshot, w, "root", archiveName, baseDataDir, savingUserData, snapshotOptions.Exclude); err != nil {
+	if err := addSnapDirToZip(ctx, snapshot, w, "root", archiveName, baseDataDir, savingUserData, snapshotOptions.Exclude, flags); err != nil {
 		return nil, err
 	}
 
@@ -358,7 +393,7 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interfac
 	savingUserData = true
 	for _, usr := range users {
 		snapDataDir := filepath.Dir(si.UserDataDir(usr.HomeDir, dirOpts))
-		if err := addSnapDirToZip(ctx, snapshot, w, usr.Username, userArchiveName(usr), snapDataDir, savingUserData, snapshotOptions.Exclude); err != nil {
+		if err := addSnapDirToZip(ctx, snapshot, w, usr.Username, userArchiveName(usr), snapDataDir, savingUserData, snapshotOptions.Exclude, flags); err != nil {
 			return nil, err
 		}
 	}
@@ -399,7 +434,7 @@ var isTesting = snapdenv.Testing()
 // addSnapDirToZip adds the 'common' and the 'rev' revisioned dir under 'snapDir'
 // to the snapshot. If one doesn't exist, it's ignored. If none exists, the
 // operation is skipped.
-func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username, entry, snapDir string, savingUserData bool, excludePaths []string) error {
+func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username, entry, snapDir string, savingUserData bool, excludePaths []string, flags *SaveFlags) error {
 	paths, err := pathsForSnapshot(snapDir, snapshot)
 	if err != nil {
 		return err
@@ -442,15 +477,45 @@ func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writ
 		expExcludePaths = append(expExcludePaths, expandedPath)
 	}
 
-	return addToZip(ctx, snapshot, w, username, entry, paths, expExcludePaths)
+	return addToZip(ctx, snapshot, w, username, entry, paths, expExcludePaths, flags)
 }
 
 // addToZip adds 'paths' to the snapshot. tar will change into the paths' parent
 // directory before creating the archive so that parent dirs are not added.
-func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username, entry string, paths []string, excludePaths []string) error {
-	archiveWriter, err := w.CreateHeader(&zip.FileHeader{Name: entry})
-	if err != nil {
-		return err
+//
+// For incremental snapshots the archive goes to the chunk store, and only its
+// chunk index is added to the zip. For encrypted snapshots the archive is
+// encrypted and always added to the zip, as the encrypted data of different
+// snapshots has no chunks in common.
+func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username, entry string, paths []string, excludePaths []string, flags *SaveFlags) error {
+	if flags == nil {
+		flags = &SaveFlags{}
+	}
+	member := entry
+	if flags.dataKey != nil {
+		member += encryptedSuffix
+	}
+
+	var archiveWriter io.Writer
+	var chunks *chunkWriter
+	if flags.Incremental && flags.dataKey == nil {
+		chunks = &chunkWriter{}
+		archiveWriter = chunks
+	} else {
+		var err error
+		archiveWriter, err = w.CreateHeader(&zip.FileHeader{Name: member})
+		if err != nil {
+			return err
+		}
+	}
+	var encrypter *encryptWriter
+	if flags.dataKey != nil {
+		var err error
+		encrypter, err = newEncryptWriter(archiveWriter, flags.dataKey)
+		if err != nil {
+			return err
+		}
+		archiveWriter = encrypter
 	}
 
 	tarArgs := []string{
@@ -504,6 +569,24 @@ func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, use
 		return fmt.Errorf("tar failed: %v", err)
 	}
 
+	if encrypter != nil {
+		if err := encrypter.Close(); err != nil {
+			return fmt.Errorf("cannot encrypt archive: %v", err)
+		}
+	}
+	if chunks != nil {
+		if err := chunks.Close(); err != nil {
+			return fmt.Errorf("cannot store archive chunks: %v", err)
+		}
+		indexWriter, err := w.Create(member + chunksSuffix)
+		if err != nil {
+			return err
+		}
+		if err := json.NewEncoder(indexWriter).Encode(&chunks.index); err != nil {
+			return err
+		}
+	}
+
 	snapshot.SHA3_384[entry] = fmt.Sprintf("%x", hasher.Sum(nil))
 	snapshot.Size += sz.Size()
 
@@ -731,6 +814,9 @@ type ImportFlags struct {
 	// noDuplicatedImportCheck tells import not to check for existing snapshot
 	// with same content hash (and not report DuplicatedSnapshotImportError).
 	NoDuplicatedImportCheck bool
+	// Passphrase is used to decrypt, for validation, snapshots
+	// protected by a passphrase.
+	Passphrase string
 }
 
 // Import a snapshot from the export file format
@@ -899,7 +985,10 @@ func unpackVerifySnapshotImport(ctx context.Context, r io.Reader, realSetID uint
 		if err != nil {
 			return snapNames, fmt.Errorf("cannot open snapshot: %v", err)
 		}
-		err = r.Check(context.TODO(), nil)
+		err = r.Unlock(flags.Passphrase)
+		if err == nil {
+			err = r.Check(context.TODO(), nil)
+		}
 		r.Close()
 		snapNames = append(snapNames, r.Snap)
 		if err != nil {
@@ -1071,10 +1160,29 @@ func (se *SnapshotExport) StreamTo(w io.Writer) error {
 		if err != nil {
 			return fmt.Errorf("symlink: %v", stat.Name())
 		}
+		arch, err := zip.NewReader(snapshotFile, stat.Size())
+		if err != nil {
+			return fmt.Errorf("cannot read %v: %v", stat.Name(), err)
+		}
+		// the export needs to be self-contained, so archives kept in
+		// the chunk store are put back into the snapshot file
+		chunked := isChunkedSnapshot(arch)
+		if chunked {
+			var sz osutil.Sizer
+			if err := writeSelfContained(&sz, arch); err != nil {
+				return fmt.Errorf("cannot reassemble %v: %v", stat.Name(), err)
+			}
+			hdr.Size = sz.Size()
+		}
 		if err = tw.WriteHeader(hdr); err != nil {
 			return fmt.Errorf("cannot write header for %v: %v", stat.Name(), err)
 		}
-		if _, err := io.Copy(tw, snapshotFile); err != nil {
+		if chunked {
+			err = writeSelfContained(tw, arch)
+		} else {
+			_, err = io.Copy(tw, snapshotFile)
+		}
+		if err != nil {
 			return fmt.Errorf("cannot write data for %v: %v", stat.Name(), err)
 		}
 
@@ -0,0 +1,361 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// Incremental snapshots do not store the archives of a snapshot inside the
+// snapshot zip file. Instead, the archive stream is split into
+// content-defined chunks which are stored, named by their hash, in a chunk
+// store shared by all snapshots. The zip file then only carries an index
+// (the "<entry>.chunks" member) listing the chunks that make up the
+// archive. Chunks already present in the store (e.g. because a previous
+// snapshot of the same snap had the same data) are not written again.
+//
+// The metadata of the snapshot is the same as for regular snapshots, in
+// particular the hashes and sizes are those of the reassembled archive.
+//
+// Encrypted snapshots are never incremental.
+
+const (
+	chunksDirName = "chunks"
+	chunksSuffix  = ".chunks"
+)
+
+var (
+	// chunk sizes used by the content-defined chunker
+	chunkMinSize = 256 * 1024
+	chunkMaxSize = 4 * 1024 * 1024
+	// chunkMask determines the average chunk size (1MiB)
+	chunkMask uint64 = 1<<20 - 1
+
+	// chunks more recent than this are never pruned, as they might
+	// belong to a snapshot that is still being saved
+	chunkPruneGrace = 24 * time.Hour
+)
+
+// chunksMu is held for reading by incremental saves, from the time they
+// store their first chunk until the snapshot referencing the chunks is in
+// place, and for writing by PruneChunks, so that the chunks of a snapshot
+// still being saved are never pruned.
+var chunksMu sync.RWMutex
+
+// gearTable is the table used by the rolling hash of the chunker. It is
+// generated deterministically so that the same data is always chunked the
+// same way.
+var gearTable = func() (t [256]uint64) {
+	// splitmix64
+	x := uint64(0x736e617073686f74) // "snapshot"
+	for i := range t {
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		t[i] = z ^ (z >> 31)
+	}
+	return t
+}()
+
+func chunksDir() string {
+	return filepath.Join(dirs.SnapshotsDir, chunksDirName)
+}
+
+func chunkPath(sum string) string {
+	return filepath.Join(chunksDir(), sum[:2], sum)
+}
+
+type chunkRef struct {
+	SHA3_384 string `json:"sha3-384"`
+	Size     int64  `json:"size"`
+}
+
+type chunkIndex struct {
+	Chunks []chunkRef `json:"chunks"`
+}
+
+func (idx *chunkIndex) size() int64 {
+	var sz int64
+	for _, ch := range idx.Chunks {
+		sz += ch.Size
+	}
+	return sz
+}
+
+// chunkWriter splits what is written to it into content-defined chunks and
+// stores the ones that are not yet in the chunk store.
+type chunkWriter struct {
+	buf   []byte
+	hash  uint64
+	index chunkIndex
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		cut := cw.boundary(p)
+		if cut < 0 {
+			cw.buf = append(cw.buf, p...)
+			break
+		}
+		cw.buf = append(cw.buf, p[:cut]...)
+		if err := cw.flush(); err != nil {
+			return 0, err
+		}
+		p = p[cut:]
+	}
+	return n, nil
+}
+
+// boundary returns the offset into p just after the end of the current
+// chunk, or -1 if the chunk does not end within p.
+func (cw *chunkWriter) boundary(p []byte) int {
+	for i, b := range p {
+		cw.hash = (cw.hash << 1) + gearTable[b]
+		sz := len(cw.buf) + i + 1
+		if sz < chunkMinSize {
+			continue
+		}
+		if sz >= chunkMaxSize || cw.hash&chunkMask == 0 {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func (cw *chunkWriter) flush() error {
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	hasher := crypto.SHA3_384.New()
+	hasher.Write(cw.buf)
+	sum := fmt.Sprintf("%x", hasher.Sum(nil))
+
+	if err := storeChunk(sum, cw.buf); err != nil {
+		return err
+	}
+	cw.index.Chunks = append(cw.index.Chunks, chunkRef{SHA3_384: sum, Size: int64(len(cw.buf))})
+	cw.buf = cw.buf[:0]
+	cw.hash = 0
+	return nil
+}
+
+// Close stores the last (partial) chunk.
+func (cw *chunkWriter) Close() error {
+	return cw.flush()
+}
+
+func storeChunk(sum string, data []byte) error {
+	p := chunkPath(sum)
+	if osutil.FileExists(p) {
+		// refresh the mtime so that the chunk is not pruned as
+		// soon as it is unreferenced again
+		now := timeNow()
+		if err := os.Chtimes(p, now, now); err == nil {
+			return nil
+		}
+		// fall through and rewrite it
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(p, data, 0600, 0)
+}
+
+// chunkReader reads back the data described by a chunkIndex, checking
+// every chunk against its hash.
+type chunkReader struct {
+	chunks []chunkRef
+	cur    io.Reader
+}
+
+func (cr *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if cr.cur != nil {
+			n, err := cr.cur.Read(p)
+			if err != io.EOF {
+				return n, err
+			}
+			cr.cur = nil
+			if n > 0 {
+				return n, nil
+			}
+		}
+		if len(cr.chunks) == 0 {
+			return 0, io.EOF
+		}
+		ch := cr.chunks[0]
+		cr.chunks = cr.chunks[1:]
+		data, err := os.ReadFile(chunkPath(ch.SHA3_384))
+		if err != nil {
+			return 0, fmt.Errorf("cannot read snapshot chunk: %v", err)
+		}
+		hasher := crypto.SHA3_384.New()
+		hasher.Write(data)
+		if actual := fmt.Sprintf("%x", hasher.Sum(nil)); actual != ch.SHA3_384 || int64(len(data)) != ch.Size {
+			return 0, fmt.Errorf("snapshot chunk %.7s… is corrupted", ch.SHA3_384)
+		}
+		cr.cur = bytes.NewReader(data)
+	}
+}
+
+func (cr *chunkReader) Close() error {
+	cr.chunks = nil
+	cr.cur = nil
+	return nil
+}
+
+func readChunkIndex(fh *zip.File) (*chunkIndex, error) {
+	r, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var idx chunkIndex
+	if err := json.NewDecoder(r).Decode(&idx); err != nil {
+		return nil, fmt.Errorf("cannot decode chunk index %q: %v", fh.Name, err)
+	}
+	return &idx, nil
+}
+
+// isChunkedSnapshot returns whether any of the archives of the given
+// snapshot zip are stored in the chunk store.
+func isChunkedSnapshot(arch *zip.Reader) bool {
+	for _, fh := range arch.File {
+		if strings.HasSuffix(fh.Name, chunksSuffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSelfContained writes out the given snapshot zip, reassembling any
+// chunked archives so that the result does not depend on the chunk store.
+func writeSelfContained(w io.Writer, arch *zip.Reader) error {
+	zw := zip.NewWriter(w)
+	for _, fh := range arch.File {
+		if !strings.HasSuffix(fh.Name, chunksSuffix) {
+			raw, err := fh.OpenRaw()
+			if err != nil {
+				return err
+			}
+			hdr := fh.FileHeader
+			out, err := zw.CreateRaw(&hdr)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, raw); err != nil {
+				return err
+			}
+			continue
+		}
+
+		idx, err := readChunkIndex(fh)
+		if err != nil {
+			return err
+		}
+		out, err := zw.CreateHeader(&zip.FileHeader{Name: strings.TrimSuffix(fh.Name, chunksSuffix)})
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, &chunkReader{chunks: idx.Chunks}); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// PruneChunks removes the chunks in the chunk store that are no longer
+// referenced by any snapshot. It returns the number of chunks removed.
+func PruneChunks(ctx context.Context) (pruned int, err error) {
+	chunksMu.Lock()
+	defer chunksMu.Unlock()
+
+	if !osutil.IsDirectory(chunksDir()) {
+		return 0, nil
+	}
+
+	referenced := map[string]bool{}
+	err = Iter(ctx, func(r *Reader) error {
+		fi, err := r.Stat()
+		if err != nil {
+			return err
+		}
+		arch, err := zip.NewReader(r.File, fi.Size())
+		if err != nil {
+			// nothing we can do about it here
+			logger.Debugf("Cannot read snapshot %q when pruning chunks: %v.", r.Name(), err)
+			return nil
+		}
+		for _, fh := range arch.File {
+			if !strings.HasSuffix(fh.Name, chunksSuffix) {
+				continue
+			}
+			idx, err := readChunkIndex(fh)
+			if err != nil {
+				return err
+			}
+			for _, ch := range idx.Chunks {
+				referenced[ch.SHA3_384] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("cannot determine referenced snapshot chunks: %v", err)
+	}
+	// an import in progress never uses chunks, and a save using them
+	// holds chunksMu
+
+	cutoff := timeNow().Add(-chunkPruneGrace)
+	err = filepath.Walk(chunksDir(), func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() || referenced[fi.Name()] || fi.ModTime().After(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		pruned++
+		return nil
+	})
+	return pruned, err
+}
@@ -0,0 +1,272 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func zipMembers(c *check.C, fn string) []string {
+	r, err := zip.OpenReader(fn)
+	c.Assert(err, check.IsNil)
+	defer r.Close()
+	var names []string
+	for _, fh := range r.File {
+		names = append(names, fh.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func countChunks(c *check.C) int {
+	n := 0
+	err := filepath.Walk(filepath.Join(dirs.SnapshotsDir, "chunks"), func(_ string, fi os.FileInfo, err error) error {
+		if err == nil && fi.Mode().IsRegular() {
+			n++
+		}
+		return err
+	})
+	if os.IsNotExist(err) {
+		return 0
+	}
+	c.Assert(err, check.IsNil)
+	return n
+}
+
+func (s *snapshotSuite) TestChunkerDeduplicates(c *check.C) {
+	defer backend.MockChunkSizes(1024, 16*1024, 1<<12-1)()
+
+	data := make([]byte, 256*1024)
+	rand.New(rand.NewSource(42)).Read(data)
+
+	sums1, err := backend.ChunkData(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(sums1) > 4, check.Equals, true)
+	for _, sum := range sums1 {
+		c.Check(backend.ChunkPath(sum), testutil.FilePresent)
+	}
+	c.Check(countChunks(c), check.Equals, len(sums1))
+
+	// same data, no new chunks
+	sums2, err := backend.ChunkData(data)
+	c.Assert(err, check.IsNil)
+	c.Check(sums2, check.DeepEquals, sums1)
+	c.Check(countChunks(c), check.Equals, len(sums1))
+
+	// change a few bytes in the middle: only the chunks around the change
+	// are new
+	data[128*1024] ^= 0xff
+	sums3, err := backend.ChunkData(data)
+	c.Assert(err, check.IsNil)
+	shared := 0
+	known := map[string]bool{}
+	for _, sum := range sums1 {
+		known[sum] = true
+	}
+	for _, sum := range sums3 {
+		if known[sum] {
+			shared++
+		}
+	}
+	c.Check(shared >= len(sums3)-2, check.Equals, true, check.Commentf("%d of %d shared", shared, len(sums3)))
+	c.Check(countChunks(c), check.Equals, len(sums1)+len(sums3)-shared)
+}
+
+func (s *snapshotSuite) TestIncrementalSave(c *check.C) {
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	flags := &backend.SaveFlags{Incremental: true}
+
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, flags)
+	c.Assert(err, check.IsNil)
+	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tgz", "user/snapuser.tgz"})
+	c.Check(zipMembers(c, backend.Filename(shw)), check.DeepEquals, []string{
+		"archive.tgz.chunks", "meta.json", "meta.sha3_384", "user/snapuser.tgz.chunks",
+	})
+	chunks := countChunks(c)
+	c.Check(chunks > 0, check.Equals, true)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Size, check.Equals, shw.Size)
+	c.Check(shr.Check(ctx, nil), check.IsNil)
+
+	// a new set with the same data does not need new chunks
+	shw2, err := backend.Save(ctx, 13, info, nil, []string{"snapuser"}, nil, nil, flags)
+	c.Assert(err, check.IsNil)
+	c.Check(shw2.SHA3_384, check.DeepEquals, shw.SHA3_384)
+	c.Check(countChunks(c), check.Equals, chunks)
+}
+
+func (s *snapshotSuite) TestIncrementalCheckCorruptedChunk(c *check.C) {
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, &backend.SaveFlags{Incremental: true})
+	c.Assert(err, check.IsNil)
+
+	err = filepath.Walk(filepath.Join(dirs.SnapshotsDir, "chunks"), func(p string, fi os.FileInfo, err error) error {
+		if err == nil && fi.Mode().IsRegular() {
+			err = os.WriteFile(p, []byte("garbage"), 0600)
+		}
+		return err
+	})
+	c.Assert(err, check.IsNil)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Check(ctx, nil), check.ErrorMatches, `snapshot chunk .* is corrupted`)
+}
+
+func (s *snapshotSuite) TestIncrementalExportIsSelfContained(c *check.C) {
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, &backend.SaveFlags{Incremental: true})
+	c.Assert(err, check.IsNil)
+
+	export, err := backend.NewSnapshotExport(ctx, shw.SetID)
+	c.Assert(err, check.IsNil)
+	c.Assert(export.Init(), check.IsNil)
+	buf := bytes.NewBuffer(nil)
+	c.Assert(export.StreamTo(buf), check.IsNil)
+	c.Check(buf.Len(), check.Equals, int(export.Size()))
+	export.Close()
+
+	// drop the snapshot and all the chunks before importing
+	c.Assert(os.Remove(backend.Filename(shw)), check.IsNil)
+	c.Assert(os.RemoveAll(filepath.Join(dirs.SnapshotsDir, "chunks")), check.IsNil)
+
+	names, err := backend.Import(ctx, 123, buf, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(names, check.DeepEquals, []string{"hello-snap"})
+
+	fn := filepath.Join(dirs.SnapshotsDir, "123_hello-snap_v1.33_42.zip")
+	c.Check(zipMembers(c, fn), check.DeepEquals, []string{
+		"archive.tgz", "meta.json", "meta.sha3_384", "user/snapuser.tgz",
+	})
+	rdr, err := backend.Open(fn, backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer rdr.Close()
+	c.Check(rdr.SHA3_384, check.DeepEquals, shw.SHA3_384)
+	c.Check(rdr.Check(ctx, nil), check.IsNil)
+}
+
+func (s *snapshotSuite) TestPruneChunks(c *check.C) {
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+
+	// nothing to do without a chunk store
+	pruned, err := backend.PruneChunks(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(pruned, check.Equals, 0)
+
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, &backend.SaveFlags{Incremental: true})
+	c.Assert(err, check.IsNil)
+	chunks := countChunks(c)
+
+	defer backend.MockTimeNow(func() time.Time { return time.Now().Add(48 * time.Hour) })()
+
+	// all chunks are still referenced
+	pruned, err = backend.PruneChunks(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(pruned, check.Equals, 0)
+	c.Check(countChunks(c), check.Equals, chunks)
+
+	c.Assert(os.Remove(backend.Filename(shw)), check.IsNil)
+
+	pruned, err = backend.PruneChunks(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(pruned, check.Equals, chunks)
+	c.Check(countChunks(c), check.Equals, 0)
+}
+
+func (s *snapshotSuite) TestPruneChunksGracePeriod(c *check.C) {
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, &backend.SaveFlags{Incremental: true})
+	c.Assert(err, check.IsNil)
+	chunks := countChunks(c)
+	c.Assert(os.Remove(backend.Filename(shw)), check.IsNil)
+
+	// unreferenced, but too recent
+	pruned, err := backend.PruneChunks(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(pruned, check.Equals, 0)
+	c.Check(countChunks(c), check.Equals, chunks)
+}
+
+func (s *snapshotSuite) TestPruneChunksWaitsForSaves(c *check.C) {
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, &backend.SaveFlags{Incremental: true})
+	c.Assert(err, check.IsNil)
+	chunks := countChunks(c)
+	c.Assert(os.Remove(backend.Filename(shw)), check.IsNil)
+
+	defer backend.MockTimeNow(func() time.Time { return time.Now().Add(48 * time.Hour) })()
+
+	// the chunks could be those of a save still in progress
+	unlock := backend.LockChunksForSave()
+	type result struct {
+		pruned int
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		pruned, err := backend.PruneChunks(ctx)
+		done <- result{pruned, err}
+	}()
+
+	select {
+	case <-done:
+		c.Fatal("chunks pruned while a save is in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+	c.Check(countChunks(c), check.Equals, chunks)
+
+	unlock()
+	select {
+	case res := <-done:
+		c.Assert(res.err, check.IsNil)
+		c.Check(res.pruned, check.Equals, chunks)
+	case <-time.After(5 * time.Second):
+		c.Fatal("chunks not pruned after the save is done")
+	}
+	c.Check(countChunks(c), check.Equals, 0)
+}
@@ -0,0 +1,413 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// The archives of an encrypted snapshot are stored as "<entry>.enc"
+// members. Each snapshot has its own random data key, which is stored in
+// the snapshot metadata wrapped (encrypted) either with a key the device
+// holds, or with a key derived from a passphrase.
+//
+// An encrypted archive is a random nonce prefix followed by the archive
+// split into segments, each one sealed with AES-256-GCM. The nonce of a
+// segment is made of the prefix, the segment number and a flag marking
+// the last segment, so that segments cannot be reordered, dropped or
+// truncated without it being noticed.
+
+const (
+	// EncryptionDeviceKey is used for snapshots encrypted with the key
+	// held by the device.
+	EncryptionDeviceKey = "device"
+	// EncryptionPassphrase is used for snapshots encrypted with a key
+	// derived from a passphrase.
+	EncryptionPassphrase = "passphrase"
+
+	encryptedSuffix = ".enc"
+
+	keySize          = 32
+	noncePrefixSize  = 7
+	encSegmentSize   = 64 * 1024
+	encOverhead      = 16 // the GCM tag
+	encSealedSegSize = encSegmentSize + encOverhead
+
+	deviceKeyName = "snapshot.key"
+)
+
+var (
+	// ErrWrongKey is returned when the data key of a snapshot cannot be
+	// recovered with the key that was provided.
+	ErrWrongKey = errors.New("cannot decrypt snapshot: wrong key or passphrase")
+	// ErrPassphraseRequired is returned when reading an encrypted
+	// snapshot that needs a passphrase without one.
+	ErrPassphraseRequired = errors.New("cannot decrypt snapshot: snapshot is protected by a passphrase")
+
+	// scrypt parameters
+	scryptN = 1 << 15
+
+	randRead = rand.Read
+)
+
+// EncryptionFlags carries the options for encrypting a snapshot.
+type EncryptionFlags struct {
+	// Key is the source of the key encryption key, one of
+	// EncryptionDeviceKey or EncryptionPassphrase.
+	Key string
+	// Passphrase, when using EncryptionPassphrase.
+	Passphrase string
+}
+
+func deviceKeyPath() string {
+	return filepath.Join(dirs.SnapDeviceDir, deviceKeyName)
+}
+
+// deviceKey returns the key held by the device for protecting snapshots,
+// creating it if asked to.
+//
+// The key lives with the other device keys; on systems with full disk
+// encryption that storage is itself protected by the sealed keys.
+func deviceKey(create bool) ([]byte, error) {
+	key, err := os.ReadFile(deviceKeyPath())
+	if err == nil {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("invalid snapshot device key size %d", len(key))
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) || !create {
+		return nil, fmt.Errorf("cannot read snapshot device key: %v", err)
+	}
+
+	key = make([]byte, keySize)
+	if _, err := randRead(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(deviceKeyPath()), 0700); err != nil {
+		return nil, err
+	}
+	if err := osutil.AtomicWriteFile(deviceKeyPath(), key, 0600, 0); err != nil {
+		return nil, fmt.Errorf("cannot write snapshot device key: %v", err)
+	}
+	return key, nil
+}
+
+func passphraseKey(passphrase string, salt []byte) ([]byte, error) {
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+	return scrypt.Key([]byte(passphrase), salt, scryptN, 8, 1, keySize)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// newSnapshotKey creates the data key for a new snapshot, returning it
+// together with the encryption metadata for the snapshot.
+func newSnapshotKey(flags *EncryptionFlags) (dataKey []byte, enc *client.SnapshotEncryption, err error) {
+	enc = &client.SnapshotEncryption{Key: flags.Key}
+	var kek []byte
+	switch flags.Key {
+	case EncryptionDeviceKey:
+		kek, err = deviceKey(true)
+	case EncryptionPassphrase:
+		if flags.Passphrase == "" {
+			return nil, nil, errors.New("passphrase cannot be empty")
+		}
+		enc.Salt = make([]byte, 16)
+		if _, err := randRead(enc.Salt); err != nil {
+			return nil, nil, err
+		}
+		kek, err = passphraseKey(flags.Passphrase, enc.Salt)
+	default:
+		return nil, nil, fmt.Errorf("internal error: unknown snapshot encryption key %q", flags.Key)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dataKey = make([]byte, keySize)
+	if _, err := randRead(dataKey); err != nil {
+		return nil, nil, err
+	}
+	aead, err := newGCM(kek)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := randRead(nonce); err != nil {
+		return nil, nil, err
+	}
+	enc.WrappedKey = aead.Seal(nonce, nonce, dataKey, nil)
+
+	return dataKey, enc, nil
+}
+
+// unwrapSnapshotKey recovers the data key of a snapshot.
+func unwrapSnapshotKey(enc *client.SnapshotEncryption, passphrase string) ([]byte, error) {
+	var kek []byte
+	var err error
+	switch enc.Key {
+	case EncryptionDeviceKey:
+		kek, err = deviceKey(false)
+	case EncryptionPassphrase:
+		kek, err = passphraseKey(passphrase, enc.Salt)
+	default:
+		return nil, fmt.Errorf("cannot decrypt snapshot: unknown key %q", enc.Key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(enc.WrappedKey) < aead.NonceSize() {
+		return nil, ErrWrongKey
+	}
+	nonce, wrapped := enc.WrappedKey[:aead.NonceSize()], enc.WrappedKey[aead.NonceSize():]
+	dataKey, err := aead.Open(nil, nonce, wrapped, nil)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	return dataKey, nil
+}
+
+func segmentNonce(prefix []byte, seg uint32, last bool) []byte {
+	nonce := make([]byte, noncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], seg)
+	if last {
+		nonce[noncePrefixSize+4] = 1
+	}
+	return nonce
+}
+
+// encryptWriter encrypts what is written to it into w. It must be closed
+// to write out the last segment.
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	prefix []byte
+	seg    uint32
+	buf    []byte
+}
+
+func newEncryptWriter(w io.Writer, dataKey []byte) (*encryptWriter, error) {
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := randRead(prefix); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(prefix); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		prefix: prefix,
+		buf:    make([]byte, 0, encSegmentSize),
+	}, nil
+}
+
+func (ew *encryptWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		// a full segment is only written out once we know more data
+		// follows, as the last segment is sealed differently
+		if len(ew.buf) == encSegmentSize {
+			if err := ew.writeSegment(false); err != nil {
+				return 0, err
+			}
+		}
+		m := copy(ew.buf[len(ew.buf):encSegmentSize], p)
+		ew.buf = ew.buf[:len(ew.buf)+m]
+		p = p[m:]
+	}
+	return n, nil
+}
+
+func (ew *encryptWriter) writeSegment(last bool) error {
+	sealed := ew.aead.Seal(nil, segmentNonce(ew.prefix, ew.seg, last), ew.buf, nil)
+	if _, err := ew.w.Write(sealed); err != nil {
+		return err
+	}
+	ew.seg++
+	ew.buf = ew.buf[:0]
+	return nil
+}
+
+// Close writes out the last segment.
+func (ew *encryptWriter) Close() error {
+	return ew.writeSegment(true)
+}
+
+// decryptReader decrypts what was written by an encryptWriter.
+type decryptReader struct {
+	r      io.Reader
+	aead   cipher.AEAD
+	prefix []byte
+	seg    uint32
+	sealed []byte
+	// next holds the first byte of the following segment, if any
+	next    []byte
+	plain   []byte
+	done    bool
+	checked bool
+}
+
+func newDecryptReader(r io.Reader, dataKey []byte) (*decryptReader, error) {
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, fmt.Errorf("cannot read encrypted archive: %v", err)
+	}
+	return &decryptReader{
+		r:      r,
+		aead:   aead,
+		prefix: prefix,
+		sealed: make([]byte, encSealedSegSize+1),
+	}, nil
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.plain) == 0 {
+		if dr.done {
+			return 0, io.EOF
+		}
+		if err := dr.readSegment(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, dr.plain)
+	dr.plain = dr.plain[n:]
+	return n, nil
+}
+
+func (dr *decryptReader) readSegment() error {
+	// read one more byte than a sealed segment to know if it is the
+	// last one
+	buf := dr.sealed[:0]
+	buf = append(buf, dr.next...)
+	n, err := io.ReadFull(dr.r, dr.sealed[len(buf):encSealedSegSize+1])
+	buf = dr.sealed[:len(buf)+n]
+	last := false
+	switch err {
+	case nil:
+		dr.next = []byte{buf[encSealedSegSize]}
+		buf = buf[:encSealedSegSize]
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+		dr.next = nil
+	default:
+		return err
+	}
+	plain, err := dr.aead.Open(buf[:0], segmentNonce(dr.prefix, dr.seg, last), buf, nil)
+	if err != nil {
+		return fmt.Errorf("cannot decrypt archive: segment %d is corrupted", dr.seg)
+	}
+	dr.seg++
+	dr.plain = plain
+	dr.done = last
+	return nil
+}
+
+func (dr *decryptReader) Close() error {
+	if c, ok := dr.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// decryptedSize returns the size of the plain data of an encrypted archive
+// of the given size.
+func decryptedSize(size int64) int64 {
+	size -= noncePrefixSize
+	if size < encOverhead {
+		return 0
+	}
+	segs := (size + encSealedSegSize - 1) / encSealedSegSize
+	return size - segs*encOverhead
+}
+
+// Unlock recovers the data key of an encrypted snapshot, using the
+// passphrase if the snapshot is protected by one. Snapshots protected with
+// the device key are unlocked on demand, but can also be unlocked to check
+// early whether the key is available.
+func (r *Reader) Unlock(passphrase string) error {
+	if r.Encryption == nil || r.dataKey != nil {
+		return nil
+	}
+	dataKey, err := unwrapSnapshotKey(r.Encryption, passphrase)
+	if err != nil {
+		return err
+	}
+	r.dataKey = dataKey
+	return nil
+}
+
+// member returns an io.ReadCloser for the given archive of the snapshot,
+// decrypting it if needed, together with its size.
+func (r *Reader) member(entry string) (io.ReadCloser, int64, error) {
+	if r.Encryption == nil {
+		return zipMember(r.File, entry)
+	}
+	if r.dataKey == nil {
+		if err := r.Unlock(""); err != nil {
+			return nil, -1, err
+		}
+	}
+	body, sz, err := zipMember(r.File, entry+encryptedSuffix)
+	if err != nil {
+		return nil, -1, err
+	}
+	dr, err := newDecryptReader(body, r.dataKey)
+	if err != nil {
+		body.Close()
+		return nil, -1, err
+	}
+	return dr, decryptedSize(sz), nil
+}
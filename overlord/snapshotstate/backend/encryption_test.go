@@ -0,0 +1,262 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend_test
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *snapshotSuite) TestEncryptDecryptRoundtrip(c *check.C) {
+	key := bytes.Repeat([]byte{7}, 32)
+	rnd := rand.New(rand.NewSource(1))
+	for _, sz := range []int{0, 1, 1000, 64 * 1024, 64*1024 + 1, 3*64*1024 - 5} {
+		data := make([]byte, sz)
+		rnd.Read(data)
+
+		enc, err := backend.EncryptData(data, key)
+		c.Assert(err, check.IsNil)
+		c.Check(backend.DecryptedSize(int64(len(enc))), check.Equals, int64(sz))
+
+		dec, err := backend.DecryptData(enc, key)
+		c.Assert(err, check.IsNil, check.Commentf("size %d", sz))
+		c.Check(bytes.Equal(dec, data), check.Equals, true, check.Commentf("size %d", sz))
+	}
+}
+
+func (s *snapshotSuite) TestDecryptDetectsTampering(c *check.C) {
+	key := bytes.Repeat([]byte{7}, 32)
+	data := make([]byte, 3*64*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+	enc, err := backend.EncryptData(data, key)
+	c.Assert(err, check.IsNil)
+
+	// wrong key
+	_, err = backend.DecryptData(enc, bytes.Repeat([]byte{8}, 32))
+	c.Check(err, check.ErrorMatches, `cannot decrypt archive: segment 0 is corrupted`)
+
+	// flipped bit
+	tampered := append([]byte(nil), enc...)
+	tampered[100000] ^= 1
+	_, err = backend.DecryptData(tampered, key)
+	c.Check(err, check.ErrorMatches, `cannot decrypt archive: segment 1 is corrupted`)
+
+	// truncated at a segment boundary
+	_, err = backend.DecryptData(enc[:7+2*(64*1024+16)], key)
+	c.Check(err, check.ErrorMatches, `cannot decrypt archive: segment 1 is corrupted`)
+}
+
+func (s *snapshotSuite) TestEncryptedSaveDeviceKey(c *check.C) {
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	flags := &backend.SaveFlags{Encryption: &backend.EncryptionFlags{Key: backend.EncryptionDeviceKey}}
+
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, flags)
+	c.Assert(err, check.IsNil)
+	c.Assert(shw.Encryption, check.NotNil)
+	c.Check(shw.Encryption.Key, check.Equals, "device")
+	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tgz", "user/snapuser.tgz"})
+	c.Check(zipMembers(c, backend.Filename(shw)), check.DeepEquals, []string{
+		"archive.tgz.enc", "meta.json", "meta.sha3_384", "user/snapuser.tgz.enc",
+	})
+
+	fi, err := os.Stat(backend.DeviceKeyPath())
+	c.Assert(err, check.IsNil)
+	c.Check(fi.Mode().Perm(), check.Equals, os.FileMode(0600))
+	c.Check(fi.Size(), check.Equals, int64(32))
+
+	// the device key is used transparently
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Encryption, check.DeepEquals, shw.Encryption)
+	c.Check(shr.Check(ctx, nil), check.IsNil)
+
+	// a second snapshot reuses the device key
+	key, err := os.ReadFile(backend.DeviceKeyPath())
+	c.Assert(err, check.IsNil)
+	_, err = backend.Save(ctx, 13, info, nil, []string{"snapuser"}, nil, nil, flags)
+	c.Assert(err, check.IsNil)
+	c.Check(backend.DeviceKeyPath(), testutil.FileEquals, key)
+}
+
+func (s *snapshotSuite) TestEncryptedCheckWrongDeviceKey(c *check.C) {
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	flags := &backend.SaveFlags{Encryption: &backend.EncryptionFlags{Key: backend.EncryptionDeviceKey}}
+
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, flags)
+	c.Assert(err, check.IsNil)
+
+	// pretend the snapshot comes from another device
+	c.Assert(os.WriteFile(backend.DeviceKeyPath(), bytes.Repeat([]byte{1}, 32), 0600), check.IsNil)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Check(ctx, nil), check.Equals, backend.ErrWrongKey)
+
+	// or from a device without a key at all
+	c.Assert(os.Remove(backend.DeviceKeyPath()), check.IsNil)
+	c.Check(shr.Check(ctx, nil), check.ErrorMatches, `cannot read snapshot device key: .* no such file or directory`)
+}
+
+func (s *snapshotSuite) TestEncryptedPassphrase(c *check.C) {
+	defer backend.MockScryptN(16)()
+
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	flags := &backend.SaveFlags{Encryption: &backend.EncryptionFlags{Key: backend.EncryptionPassphrase, Passphrase: "sekrit"}}
+
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, flags)
+	c.Assert(err, check.IsNil)
+	c.Check(shw.Encryption.Key, check.Equals, "passphrase")
+	c.Check(shw.Encryption.Salt, check.HasLen, 16)
+	// no device key was needed
+	c.Check(backend.DeviceKeyPath(), testutil.FileAbsent)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+
+	c.Check(shr.Check(ctx, nil), check.Equals, backend.ErrPassphraseRequired)
+	c.Check(shr.Unlock("wrong"), check.Equals, backend.ErrWrongKey)
+	c.Check(shr.Check(ctx, nil), check.Equals, backend.ErrPassphraseRequired)
+	c.Check(shr.Unlock("sekrit"), check.IsNil)
+	c.Check(shr.Check(ctx, nil), check.IsNil)
+}
+
+func (s *snapshotSuite) TestEncryptedPassphraseEmpty(c *check.C) {
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	flags := &backend.SaveFlags{Encryption: &backend.EncryptionFlags{Key: backend.EncryptionPassphrase}}
+
+	_, err := backend.Save(context.TODO(), 12, info, nil, []string{"snapuser"}, nil, nil, flags)
+	c.Assert(err, check.ErrorMatches, `cannot create snapshot key: passphrase cannot be empty`)
+}
+
+func (s *snapshotSuite) TestEncryptedIncrementalIsNotChunked(c *check.C) {
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	flags := &backend.SaveFlags{
+		Incremental: true,
+		Encryption:  &backend.EncryptionFlags{Key: backend.EncryptionDeviceKey},
+	}
+
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, flags)
+	c.Assert(err, check.IsNil)
+	// the encrypted archives are stored in the zip, not in the chunk store
+	c.Check(zipMembers(c, backend.Filename(shw)), check.DeepEquals, []string{
+		"archive.tgz.enc", "meta.json", "meta.sha3_384", "user/snapuser.tgz.enc",
+	})
+	c.Check(countChunks(c), check.Equals, 0)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Check(ctx, nil), check.IsNil)
+}
+
+func (s *snapshotSuite) TestEncryptedIncrementalExportImport(c *check.C) {
+	defer backend.MockScryptN(16)()
+
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	flags := &backend.SaveFlags{
+		Incremental: true,
+		Encryption:  &backend.EncryptionFlags{Key: backend.EncryptionPassphrase, Passphrase: "sekrit"},
+	}
+
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, flags)
+	c.Assert(err, check.IsNil)
+	c.Check(zipMembers(c, backend.Filename(shw)), check.DeepEquals, []string{
+		"archive.tgz.enc", "meta.json", "meta.sha3_384", "user/snapuser.tgz.enc",
+	})
+
+	export, err := backend.NewSnapshotExport(ctx, shw.SetID)
+	c.Assert(err, check.IsNil)
+	c.Assert(export.Init(), check.IsNil)
+	buf := bytes.NewBuffer(nil)
+	c.Assert(export.StreamTo(buf), check.IsNil)
+	export.Close()
+	exported := buf.Bytes()
+
+	c.Assert(os.Remove(backend.Filename(shw)), check.IsNil)
+	c.Assert(os.RemoveAll(filepath.Join(dirs.SnapshotsDir, "chunks")), check.IsNil)
+
+	// the exported data is still encrypted
+	_, err = backend.Import(ctx, 123, bytes.NewReader(exported), nil)
+	c.Assert(err, check.ErrorMatches, `cannot import snapshot 123: validation failed for .*: cannot decrypt snapshot: snapshot is protected by a passphrase`)
+	_, err = backend.Import(ctx, 123, bytes.NewReader(exported), &backend.ImportFlags{Passphrase: "wrong"})
+	c.Assert(err, check.ErrorMatches, `cannot import snapshot 123: validation failed for .*: cannot decrypt snapshot: wrong key or passphrase`)
+
+	names, err := backend.Import(ctx, 123, bytes.NewReader(exported), &backend.ImportFlags{Passphrase: "sekrit"})
+	c.Assert(err, check.IsNil)
+	c.Check(names, check.DeepEquals, []string{"hello-snap"})
+
+	fn := filepath.Join(dirs.SnapshotsDir, "123_hello-snap_v1.33_42.zip")
+	c.Check(zipMembers(c, fn), check.DeepEquals, []string{
+		"archive.tgz.enc", "meta.json", "meta.sha3_384", "user/snapuser.tgz.enc",
+	})
+}
+
+func (s *snapshotSuite) TestEncryptedRestoreRoundtrip(c *check.C) {
+	if os.Geteuid() == 0 {
+		c.Skip("this test cannot run as root (runuser will fail)")
+	}
+	logger.SimpleSetup(nil)
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	flags := &backend.SaveFlags{Encryption: &backend.EncryptionFlags{Key: backend.EncryptionDeviceKey}}
+
+	shw, err := backend.Save(context.TODO(), 12, info, nil, []string{"snapuser"}, nil, nil, flags)
+	c.Assert(err, check.IsNil)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+
+	// scribble over the data, restore brings it back
+	marker := filepath.Join(info.DataDir(), "foo")
+	orig, err := os.ReadFile(marker)
+	c.Assert(err, check.IsNil)
+	c.Assert(os.WriteFile(marker, []byte("scribble\n"), 0644), check.IsNil)
+
+	rs, err := shr.Restore(context.TODO(), snap.R(0), nil, logger.Debugf, nil)
+	c.Assert(err, check.IsNil)
+	rs.Cleanup()
+	c.Check(marker, testutil.FileEquals, orig)
+
+	// sanity check that there are no leftovers
+	out, err := exec.Command("find", filepath.Dir(info.DataDir()), "-name", ".snapshot*").CombinedOutput()
+	c.Assert(err, check.IsNil)
+	c.Check(string(out), check.Equals, "")
+}
@@ -20,6 +20,8 @@
 package backend
 
 import (
+	"bytes"
+	"io"
 	"os"
 	"os/exec"
 	"time"
@@ -155,3 +157,71 @@ func MockReadSnapshotYaml(f func(si *snap.Info) (*snap.SnapshotOptions, error))
 		snapReadSnapshotYaml = oldReadSnapshotYaml
 	}
 }
+
+func MockChunkSizes(min, max int, mask uint64) (restore func()) {
+	oldMin, oldMax, oldMask := chunkMinSize, chunkMaxSize, chunkMask
+	chunkMinSize, chunkMaxSize, chunkMask = min, max, mask
+	return func() {
+		chunkMinSize, chunkMaxSize, chunkMask = oldMin, oldMax, oldMask
+	}
+}
+
+// ChunkData splits data into chunks in the chunk store, returning their
+// hashes.
+func ChunkData(data []byte) ([]string, error) {
+	cw := &chunkWriter{}
+	if _, err := cw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := cw.Close(); err != nil {
+		return nil, err
+	}
+	sums := make([]string, 0, len(cw.index.Chunks))
+	for _, ch := range cw.index.Chunks {
+		sums = append(sums, ch.SHA3_384)
+	}
+	return sums, nil
+}
+
+// LockChunksForSave holds the chunk store like an incremental save does.
+func LockChunksForSave() (unlock func()) {
+	chunksMu.RLock()
+	return chunksMu.RUnlock
+}
+
+func ChunkPath(sum string) string {
+	return chunkPath(sum)
+}
+
+func MockScryptN(n int) (restore func()) {
+	return testutil.Mock(&scryptN, n)
+}
+
+func EncryptData(data, key []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	ew, err := newEncryptWriter(&buf, key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ew.Write(data); err != nil {
+		return nil, err
+	}
+	if err := ew.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func DecryptData(data, key []byte) ([]byte, error) {
+	dr, err := newDecryptReader(bytes.NewReader(data), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(dr)
+}
+
+var DecryptedSize = decryptedSize
+
+func DeviceKeyPath() string {
+	return deviceKeyPath()
+}
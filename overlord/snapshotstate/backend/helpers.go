@@ -37,6 +37,8 @@ import (
 )
 
 // zipMember returns an io.ReadCloser for the 'member' file in the 'f' zip file.
+// If the member was stored in the chunk store the returned reader reassembles
+// it from its chunks.
 func zipMember(f *os.File, member string) (r io.ReadCloser, sz int64, err error) {
 	// rewind the file
 	// (shouldn't be needed, but doesn't hurt too much)
@@ -60,6 +62,16 @@ func zipMember(f *os.File, member string) (r io.ReadCloser, sz int64, err error)
 			return r, int64(fh.UncompressedSize64), err
 		}
 	}
+	// the member might have been stored in the chunk store
+	for _, fh := range arch.File {
+		if fh.Name == member+chunksSuffix {
+			idx, err := readChunkIndex(fh)
+			if err != nil {
+				return nil, -1, err
+			}
+			return &chunkReader{chunks: idx.Chunks}, idx.size(), nil
+		}
+	}
 
 	return nil, -1, fmt.Errorf("missing archive member %q", member)
 }
@@ -50,6 +50,9 @@ const ExtractFnameSetID = 0
 type Reader struct {
 	*os.File
 	client.Snapshot
+
+	// dataKey is the key of an encrypted snapshot, once unlocked
+	dataKey []byte
 }
 
 // Open a Snapshot given its full filename.
@@ -143,7 +146,7 @@ func Open(fn string, setID uint64) (reader *Reader, e error) {
 }
 
 func (r *Reader) checkOne(ctx context.Context, entry string, hasher hash.Hash) error {
-	body, reportedSize, err := zipMember(r.File, entry)
+	body, reportedSize, err := r.member(entry)
 	if err != nil {
 		return err
 	}
@@ -310,7 +313,7 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 
 		logger.Debugf("Restoring %q from %q into %q.", entry, r.Name(), tempdir)
 
-		body, expectedSize, err := zipMember(r.File, entry)
+		body, expectedSize, err := r.member(entry)
 		if err != nil {
 			return rs, err
 		}
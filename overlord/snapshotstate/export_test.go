@@ -75,6 +75,14 @@ func MockOsRemove(f func(string) error) (restore func()) {
 	}
 }
 
+func MockTimeNow(f func() time.Time) (restore func()) {
+	old := timeNow
+	timeNow = f
+	return func() {
+		timeNow = old
+	}
+}
+
 func MockSnapstateAll(f func(*state.State) (map[string]*snapstate.SnapState, error)) (restore func()) {
 	old := snapstateAll
 	snapstateAll = f
@@ -139,6 +147,14 @@ func MockBackendCheck(f func(*backend.Reader, context.Context, []string) error)
 	}
 }
 
+func MockBackendUnlock(f func(*backend.Reader, string) error) (restore func()) {
+	old := backendUnlock
+	backendUnlock = f
+	return func() {
+		backendUnlock = old
+	}
+}
+
 func MockBackendRevert(f func(*backend.RestoreState)) (restore func()) {
 	old := backendRevert
 	backendRevert = f
@@ -208,6 +224,32 @@ func SetLastForgetExpiredSnapshotTime(mgr *SnapshotManager, t time.Time) {
 	mgr.lastForgetExpiredSnapshotTime = t
 }
 
+func MockBackendPruneChunks(f func(context.Context) (int, error)) (restore func()) {
+	old := backendPruneChunks
+	backendPruneChunks = f
+	return func() {
+		backendPruneChunks = old
+	}
+}
+
+// For testing only
+func SetLastPruneChunksTime(mgr *SnapshotManager, t time.Time) {
+	mgr.pruneChunksMu.Lock()
+	defer mgr.pruneChunksMu.Unlock()
+	mgr.lastPruneChunksTime = t
+}
+
+// WaitPruneChunks waits for the chunks being pruned in the background, if
+// any.
+func WaitPruneChunks(mgr *SnapshotManager) {
+	mgr.pruneChunksMu.Lock()
+	t := mgr.pruneChunksTomb
+	mgr.pruneChunksMu.Unlock()
+	if t != nil {
+		t.Wait()
+	}
+}
+
 func MockGetSnapDirOptions(f func(*state.State, string) (*dirs.SnapDirOptions, error)) (restore func()) {
 	old := getSnapDirOpts
 	getSnapDirOpts = f
@@ -215,3 +257,9 @@ func MockGetSnapDirOptions(f func(*state.State, string) (*dirs.SnapDirOptions, e
 		getSnapDirOpts = old
 	}
 }
+
+var SnapshotPassphrase = snapshotPassphrase
+
+func SetNextScheduledSnapshot(mgr *SnapshotManager, t time.Time) {
+	mgr.nextScheduledSnapshot = t
+}
@@ -0,0 +1,124 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// dirTarget stores exports as files in a directory, which is typically
+// a mounted network filesystem or removable drive.
+type dirTarget struct {
+	dir string
+}
+
+func (t *dirTarget) String() string {
+	return t.dir
+}
+
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+func (t *dirTarget) Push(ctx context.Context, name string, r io.Reader, size int64) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return fmt.Errorf("cannot create remote snapshot directory: %v", err)
+	}
+	aw, err := osutil.NewAtomicFile(filepath.Join(t.dir, name), 0600, 0, osutil.NoChown, osutil.NoChown)
+	if err != nil {
+		return fmt.Errorf("cannot push snapshot export: %v", err)
+	}
+	// Cancel once committed is a no-op
+	defer aw.Cancel()
+
+	n, err := io.Copy(aw, &ctxReader{ctx: ctx, r: r})
+	if err != nil {
+		return fmt.Errorf("cannot push snapshot export: %v", err)
+	}
+	if n != size {
+		return fmt.Errorf("cannot push snapshot export: expected %d bytes, got %d", size, n)
+	}
+	if err := aw.Commit(); err != nil {
+		return fmt.Errorf("cannot push snapshot export: %v", err)
+	}
+	return nil
+}
+
+func (t *dirTarget) List(ctx context.Context) ([]client.RemoteSnapshot, error) {
+	entries, err := os.ReadDir(t.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot list remote snapshot exports: %v", err)
+	}
+	var exports []client.RemoteSnapshot
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || validateName(entry.Name()) != nil {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			// removed underneath us
+			continue
+		}
+		exports = append(exports, client.RemoteSnapshot{
+			Name: entry.Name(),
+			Size: fi.Size(),
+			Time: fi.ModTime(),
+		})
+	}
+	sort.Slice(exports, func(i, j int) bool { return strings.Compare(exports[i].Name, exports[j].Name) < 0 })
+	return exports, nil
+}
+
+func (t *dirTarget) Fetch(ctx context.Context, name string) (io.ReadCloser, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(t.dir, name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch snapshot export: %v", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,160 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package remote implements the targets snapshot set exports can be
+// pushed to, listed from and fetched back from.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/client"
+)
+
+// ExportSuffix is the suffix of the names of snapshot exports stored on
+// a remote target.
+const ExportSuffix = ".snapshot"
+
+var (
+	// ErrNotFound is returned when fetching an export that the target
+	// does not have.
+	ErrNotFound = errors.New("snapshot export not found on remote target")
+
+	timeNow = time.Now
+)
+
+// Target is a place snapshot set exports can be stored in.
+type Target interface {
+	// Push stores the export read from r, of the given size, under the
+	// given name, replacing any existing export of the same name.
+	Push(ctx context.Context, name string, r io.Reader, size int64) error
+	// List returns the exports stored in the target.
+	List(ctx context.Context) ([]client.RemoteSnapshot, error)
+	// Fetch opens the export of the given name for reading.
+	Fetch(ctx context.Context, name string) (io.ReadCloser, error)
+	// String returns a description of the target suitable for messages.
+	String() string
+}
+
+// Options carries the settings of a target besides its location.
+type Options struct {
+	// AccessKeyID and SecretAccessKey are the credentials used for
+	// S3-compatible targets.
+	AccessKeyID     string
+	SecretAccessKey string
+	// Region is the region of S3-compatible targets, defaulting to
+	// us-east-1.
+	Region string
+}
+
+// Validate checks that the given target location is well formed.
+func Validate(location string) error {
+	_, err := parse(location)
+	return err
+}
+
+type location struct {
+	kind string
+	// path is the directory of file targets
+	path string
+	// endpoint, bucket and prefix describe s3 targets
+	endpoint *url.URL
+	bucket   string
+	prefix   string
+}
+
+// parse parses the location of a target, which is either an absolute
+// directory path (possibly as a file:// URL), or an URL of the form
+// s3+https://host[:port]/bucket[/prefix] (or s3+http://) for
+// S3-compatible object stores.
+func parse(loc string) (*location, error) {
+	if strings.HasPrefix(loc, "/") {
+		return &location{kind: "file", path: filepath.Clean(loc)}, nil
+	}
+	u, err := url.Parse(loc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse remote snapshot target: %v", err)
+	}
+	switch u.Scheme {
+	case "file":
+		if u.Host != "" || !filepath.IsAbs(u.Path) {
+			return nil, fmt.Errorf("remote snapshot target %q must be an absolute path", loc)
+		}
+		return &location{kind: "file", path: filepath.Clean(u.Path)}, nil
+	case "s3+https", "s3+http":
+		if u.Host == "" {
+			return nil, fmt.Errorf("remote snapshot target %q is missing a host", loc)
+		}
+		if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("remote snapshot target %q must not carry credentials, query or fragment", loc)
+		}
+		parts := strings.SplitN(strings.Trim(u.Path, "/"), "/", 2)
+		if parts[0] == "" {
+			return nil, fmt.Errorf("remote snapshot target %q is missing a bucket", loc)
+		}
+		l := &location{
+			kind:     "s3",
+			endpoint: &url.URL{Scheme: strings.TrimPrefix(u.Scheme, "s3+"), Host: u.Host},
+			bucket:   parts[0],
+		}
+		if len(parts) == 2 && parts[1] != "" {
+			l.prefix = strings.Trim(parts[1], "/") + "/"
+		}
+		return l, nil
+	}
+	return nil, fmt.Errorf("unsupported remote snapshot target %q", loc)
+}
+
+// New returns the target for the given location.
+func New(loc string, opts *Options) (Target, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	l, err := parse(loc)
+	if err != nil {
+		return nil, err
+	}
+	switch l.kind {
+	case "file":
+		return &dirTarget{dir: l.path}, nil
+	case "s3":
+		return newS3Target(l, opts)
+	}
+	panic(fmt.Sprintf("internal error: unknown remote target kind %q", l.kind))
+}
+
+// ExportName returns the name the export of the given snapshot set, made
+// at the given time, gets on a remote target.
+func ExportName(setID uint64, t time.Time) string {
+	return fmt.Sprintf("%d_%s%s", setID, t.UTC().Format("20060102T150405Z"), ExportSuffix)
+}
+
+func validateName(name string) error {
+	if !strings.HasSuffix(name, ExportSuffix) || name == ExportSuffix || strings.ContainsAny(name, "/\\") || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid remote snapshot export name %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package remote_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/snapshotstate/remote"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type remoteSuite struct {
+	testutil.BaseTest
+}
+
+var _ = check.Suite(&remoteSuite{})
+
+func (s *remoteSuite) TestValidate(c *check.C) {
+	for _, loc := range []string{
+		"/srv/backups",
+		"file:///srv/backups",
+		"s3+https://s3.example.com/bucket",
+		"s3+http://localhost:9000/bucket/some/prefix",
+	} {
+		c.Check(remote.Validate(loc), check.IsNil, check.Commentf(loc))
+	}
+
+	for loc, expected := range map[string]string{
+		"backups":                            `unsupported remote snapshot target "backups"`,
+		"ftp://example.com/x":                `unsupported remote snapshot target "ftp://example.com/x"`,
+		"file://host/srv":                    `remote snapshot target "file://host/srv" must be an absolute path`,
+		"s3+https:///bucket":                 `remote snapshot target "s3\+https:///bucket" is missing a host`,
+		"s3+https://s3.example.com":          `remote snapshot target "s3\+https://s3.example.com" is missing a bucket`,
+		"s3+https://k:s@s3.example.com/b":    `remote snapshot target ".*" must not carry credentials, query or fragment`,
+		"s3+https://s3.example.com/b?x=y":    `remote snapshot target ".*" must not carry credentials, query or fragment`,
+		"s3+https://s3.example.com:xx/b%zz/": `cannot parse remote snapshot target: .*`,
+	} {
+		c.Check(remote.Validate(loc), check.ErrorMatches, expected, check.Commentf(loc))
+	}
+}
+
+func (s *remoteSuite) TestNewS3NeedsCredentials(c *check.C) {
+	_, err := remote.New("s3+https://s3.example.com/bucket", nil)
+	c.Check(err, check.ErrorMatches, "cannot use remote snapshot target: missing credentials")
+}
+
+func (s *remoteSuite) TestExportName(c *check.C) {
+	t := time.Date(2026, 10, 17, 12, 30, 1, 0, time.FixedZone("X", 3600))
+	c.Check(remote.ExportName(42, t), check.Equals, "42_20261017T113001Z.snapshot")
+}
+
+func (s *remoteSuite) TestDirTargetRoundtrip(c *check.C) {
+	dir := filepath.Join(c.MkDir(), "backups")
+	tgt, err := remote.New("file://"+dir, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(tgt.String(), check.Equals, dir)
+
+	// nothing there yet
+	exports, err := tgt.List(context.TODO())
+	c.Assert(err, check.IsNil)
+	c.Check(exports, check.HasLen, 0)
+
+	data := "exported snapshot data"
+	err = tgt.Push(context.TODO(), "2_x.snapshot", strings.NewReader(data), int64(len(data)))
+	c.Assert(err, check.IsNil)
+	err = tgt.Push(context.TODO(), "1_x.snapshot", strings.NewReader(data), int64(len(data)))
+	c.Assert(err, check.IsNil)
+	// not an export
+	c.Assert(os.WriteFile(filepath.Join(dir, "README"), nil, 0644), check.IsNil)
+
+	exports, err = tgt.List(context.TODO())
+	c.Assert(err, check.IsNil)
+	c.Assert(exports, check.HasLen, 2)
+	c.Check(exports[0].Name, check.Equals, "1_x.snapshot")
+	c.Check(exports[1].Name, check.Equals, "2_x.snapshot")
+	c.Check(exports[0].Size, check.Equals, int64(len(data)))
+
+	rc, err := tgt.Fetch(context.TODO(), "2_x.snapshot")
+	c.Assert(err, check.IsNil)
+	defer rc.Close()
+	fetched, err := io.ReadAll(rc)
+	c.Assert(err, check.IsNil)
+	c.Check(string(fetched), check.Equals, data)
+
+	_, err = tgt.Fetch(context.TODO(), "3_x.snapshot")
+	c.Check(err, check.Equals, remote.ErrNotFound)
+}
+
+func (s *remoteSuite) TestDirTargetPushShort(c *check.C) {
+	dir := c.MkDir()
+	tgt, err := remote.New(dir, nil)
+	c.Assert(err, check.IsNil)
+
+	err = tgt.Push(context.TODO(), "1_x.snapshot", strings.NewReader("short"), 100)
+	c.Check(err, check.ErrorMatches, "cannot push snapshot export: expected 100 bytes, got 5")
+
+	// nothing is left behind
+	entries, err := os.ReadDir(dir)
+	c.Assert(err, check.IsNil)
+	c.Check(entries, check.HasLen, 0)
+}
+
+func (s *remoteSuite) TestInvalidNames(c *check.C) {
+	tgt, err := remote.New(c.MkDir(), nil)
+	c.Assert(err, check.IsNil)
+
+	for _, name := range []string{"", ".snapshot", "x.zip", "../x.snapshot", "a/b.snapshot", ".x.snapshot"} {
+		err := tgt.Push(context.TODO(), name, strings.NewReader(""), 0)
+		c.Check(err, check.ErrorMatches, "invalid remote snapshot export name .*", check.Commentf(name))
+		_, err = tgt.Fetch(context.TODO(), name)
+		c.Check(err, check.ErrorMatches, "invalid remote snapshot export name .*", check.Commentf(name))
+	}
+}
@@ -0,0 +1,332 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package remote
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/httputil"
+)
+
+const (
+	defaultS3Region = "us-east-1"
+	// unsignedPayload lets exports be streamed without hashing them
+	// beforehand
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// emptyPayloadHash is the sha256 of an empty body
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+var newHTTPClient = func() *http.Client {
+	return httputil.NewHTTPClient(&httputil.ClientOptions{
+		Proxy: http.ProxyFromEnvironment,
+	})
+}
+
+// s3Target stores exports as objects in a bucket of an S3-compatible
+// object store, using path-style requests signed with AWS signature
+// version 4.
+type s3Target struct {
+	endpoint *url.URL
+	bucket   string
+	prefix   string
+
+	accessKeyID     string
+	secretAccessKey string
+	region          string
+
+	client *http.Client
+}
+
+func newS3Target(l *location, opts *Options) (*s3Target, error) {
+	if opts.AccessKeyID == "" || opts.SecretAccessKey == "" {
+		return nil, fmt.Errorf("cannot use remote snapshot target: missing credentials")
+	}
+	region := opts.Region
+	if region == "" {
+		region = defaultS3Region
+	}
+	return &s3Target{
+		endpoint:        l.endpoint,
+		bucket:          l.bucket,
+		prefix:          l.prefix,
+		accessKeyID:     opts.AccessKeyID,
+		secretAccessKey: opts.SecretAccessKey,
+		region:          region,
+		client:          newHTTPClient(),
+	}, nil
+}
+
+func (t *s3Target) String() string {
+	return fmt.Sprintf("s3+%s://%s/%s/%s", t.endpoint.Scheme, t.endpoint.Host, t.bucket, t.prefix)
+}
+
+func (t *s3Target) objectURL(key string) *url.URL {
+	u := *t.endpoint
+	u.Path = "/" + t.bucket + "/" + key
+	return &u
+}
+
+// s3Error is the body of error responses of S3.
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func responseError(rsp *http.Response) error {
+	var s3err s3Error
+	body, _ := io.ReadAll(io.LimitReader(rsp.Body, 64*1024))
+	if xml.Unmarshal(body, &s3err) == nil && s3err.Code != "" {
+		if s3err.Message != "" {
+			return fmt.Errorf("%s: %s", s3err.Code, s3err.Message)
+		}
+		return fmt.Errorf("%s", s3err.Code)
+	}
+	return fmt.Errorf("unexpected status %q", rsp.Status)
+}
+
+func (t *s3Target) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	signRequest(req, t.accessKeyID, t.secretAccessKey, t.region, payloadHash, timeNow())
+	return t.client.Do(req)
+}
+
+func (t *s3Target) Push(ctx context.Context, name string, r io.Reader, size int64) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "PUT", t.objectURL(t.prefix+name).String(), io.NopCloser(r))
+	if err != nil {
+		return fmt.Errorf("cannot push snapshot export: %v", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", client.SnapshotExportMediaType)
+	rsp, err := t.do(req, unsignedPayload)
+	if err != nil {
+		return fmt.Errorf("cannot push snapshot export: %v", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != 200 {
+		return fmt.Errorf("cannot push snapshot export: %v", responseError(rsp))
+	}
+	return nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (t *s3Target) List(ctx context.Context) ([]client.RemoteSnapshot, error) {
+	var exports []client.RemoteSnapshot
+	var token string
+	for {
+		q := url.Values{
+			"list-type": {"2"},
+			"prefix":    {t.prefix},
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		u := t.objectURL("")
+		u.Path = "/" + t.bucket
+		u.RawQuery = q.Encode()
+		req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list remote snapshot exports: %v", err)
+		}
+		rsp, err := t.do(req, emptyPayloadHash)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list remote snapshot exports: %v", err)
+		}
+		var result listBucketResult
+		if rsp.StatusCode != 200 {
+			err = responseError(rsp)
+		} else {
+			err = xml.NewDecoder(rsp.Body).Decode(&result)
+		}
+		rsp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot list remote snapshot exports: %v", err)
+		}
+		for _, obj := range result.Contents {
+			name := strings.TrimPrefix(obj.Key, t.prefix)
+			if validateName(name) != nil {
+				// not an export, or in a "subdirectory"
+				continue
+			}
+			exports = append(exports, client.RemoteSnapshot{
+				Name: name,
+				Size: obj.Size,
+				Time: obj.LastModified,
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	sort.Slice(exports, func(i, j int) bool { return exports[i].Name < exports[j].Name })
+	return exports, nil
+}
+
+func (t *s3Target) Fetch(ctx context.Context, name string) (io.ReadCloser, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", t.objectURL(t.prefix+name).String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch snapshot export: %v", err)
+	}
+	rsp, err := t.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch snapshot export: %v", err)
+	}
+	switch rsp.StatusCode {
+	case 200:
+		return rsp.Body, nil
+	case 404:
+		rsp.Body.Close()
+		return nil, ErrNotFound
+	}
+	defer rsp.Body.Close()
+	return nil, fmt.Errorf("cannot fetch snapshot export: %v", responseError(rsp))
+}
+
+// uriEncode encodes s as required by the canonical request of AWS
+// signature version 4, leaving slashes alone if so asked.
+func uriEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// signRequest adds the headers of an AWS signature version 4 to req.
+// payloadHash is the hex encoded sha256 of the body, or UNSIGNED-PAYLOAD.
+func signRequest(req *http.Request, accessKeyID, secretAccessKey, region, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// sign the host, range, content type and all the x-amz headers
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lname := strings.ToLower(name)
+		if lname == "range" || lname == "content-type" || lname == "content-md5" || strings.HasPrefix(lname, "x-amz-") {
+			headers[lname] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, uriEncode(k, false)+"="+uriEncode(v, false))
+		}
+	}
+
+	path := req.URL.EscapedPath()
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(path, true),
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, signature))
+}
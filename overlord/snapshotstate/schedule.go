@@ -0,0 +1,332 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapshotstate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
+)
+
+var (
+	// Default number of scheduled snapshots kept for each snap, if not
+	// set by the user
+	defaultScheduledSnapshotRetention = 3
+
+	// scheduledSnapshotMaxPostponement is the longest a scheduled
+	// snapshot is postponed after the previous one, whatever the schedule
+	scheduledSnapshotMaxPostponement = time.Hour * 24 * 31
+
+	timeNow = time.Now
+)
+
+// snapshotSchedule returns the schedule of scheduled snapshots along with
+// its textual form. An empty schedule means scheduled snapshots are off.
+func snapshotSchedule(st *state.State) ([]*timeutil.Schedule, string, error) {
+	var scheduleStr string
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "snapshots.schedule", &scheduleStr); err != nil && !config.IsNoOption(err) {
+		return nil, "", err
+	}
+	if scheduleStr == "" {
+		return nil, "", nil
+	}
+	schedule, err := timeutil.ParseSchedule(scheduleStr)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot parse snapshots.schedule: %v", err)
+	}
+	return schedule, scheduleStr, nil
+}
+
+// scheduledSnapshotRetention returns how many scheduled snapshots are kept
+// for each snap by default, and for the snaps that have their own retention
+// as set with snapshots.scheduled.snap-retention.<snap>.
+func scheduledSnapshotRetention(st *state.State) (int, map[string]int, error) {
+	var val interface{}
+	tr := config.NewTransaction(st)
+	if err := tr.GetMaybe("core", "snapshots.scheduled.retention", &val); err != nil {
+		return 0, nil, err
+	}
+	retention, err := parseScheduledSnapshotRetention(val)
+	if err != nil {
+		return 0, nil, fmt.Errorf("snapshots.scheduled.retention is not valid: %v", err)
+	}
+	if retention <= 0 {
+		retention = defaultScheduledSnapshotRetention
+	}
+
+	var snapVals map[string]interface{}
+	if err := tr.GetMaybe("core", "snapshots.scheduled.snap-retention", &snapVals); err != nil {
+		return 0, nil, err
+	}
+	var snapRetention map[string]int
+	for snapName, val := range snapVals {
+		n, err := parseScheduledSnapshotRetention(val)
+		if err != nil {
+			return 0, nil, fmt.Errorf("snapshots.scheduled.snap-retention.%s is not valid: %v", snapName, err)
+		}
+		// unset falls back to the default retention
+		if n <= 0 {
+			continue
+		}
+		if snapRetention == nil {
+			snapRetention = make(map[string]int)
+		}
+		snapRetention[snapName] = n
+	}
+	return retention, snapRetention, nil
+}
+
+// parseScheduledSnapshotRetention returns the retention in the given option
+// value, or 0 if it is unset.
+func parseScheduledSnapshotRetention(val interface{}) (int, error) {
+	// validation accepts both numbers and strings representing them
+	switch v := val.(type) {
+	case json.Number:
+		return strconv.Atoi(string(v))
+	case string:
+		if v == "" {
+			return 0, nil
+		}
+		return strconv.Atoi(v)
+	}
+	return 0, nil
+}
+
+// scheduledSnapNames returns the names of the active snaps that scheduled
+// snapshots should include.
+func scheduledSnapNames(st *state.State) ([]string, error) {
+	names, err := allActiveSnapNames(st)
+	if err != nil {
+		return nil, err
+	}
+
+	var include, exclude string
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "snapshots.scheduled.include", &include); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	if err := tr.Get("core", "snapshots.scheduled.exclude", &exclude); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	included := strutil.CommaSeparatedList(include)
+	excluded := strutil.CommaSeparatedList(exclude)
+
+	selected := make([]string, 0, len(names))
+	for _, name := range names {
+		if len(included) > 0 && !strutil.ListContains(included, name) {
+			continue
+		}
+		if strutil.ListContains(excluded, name) {
+			continue
+		}
+		selected = append(selected, name)
+	}
+	return selected, nil
+}
+
+// scheduledSnapshotSets returns the snapshot sets taken on schedule. The
+// state needs to be locked by the caller.
+func scheduledSnapshotSets(st *state.State) (map[uint64]bool, error) {
+	var snapshots map[uint64]*snapshotState
+	err := st.Get("snapshots", &snapshots)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	scheduled := make(map[uint64]bool)
+	for setID, snapshotSet := range snapshots {
+		if snapshotSet.Scheduled {
+			scheduled[setID] = true
+		}
+	}
+	return scheduled, nil
+}
+
+func scheduledSnapshotInFlight(st *state.State) bool {
+	for _, chg := range st.Changes() {
+		if chg.Kind() == "scheduled-snapshot" && !chg.IsReady() {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureScheduledSnapshots takes a new snapshot set of the selected snaps
+// when snapshots.schedule says so, and drops the scheduled snapshots
+// exceeding the retention once they are done.
+func (mgr *SnapshotManager) ensureScheduledSnapshots() error {
+	st := mgr.state
+	st.Lock()
+	defer st.Unlock()
+
+	if scheduledSnapshotInFlight(st) {
+		return nil
+	}
+
+	if mgr.pruneScheduledNeeded {
+		if err := mgr.pruneScheduledSnapshots(); err != nil {
+			logger.Noticef("cannot prune scheduled snapshots: %v", err)
+		}
+	}
+
+	schedule, scheduleStr, err := snapshotSchedule(st)
+	if err != nil {
+		return err
+	}
+	if len(schedule) == 0 {
+		mgr.nextScheduledSnapshot = time.Time{}
+		return nil
+	}
+	if scheduleStr != mgr.lastSnapshotSchedule {
+		// the schedule has changed
+		mgr.nextScheduledSnapshot = time.Time{}
+		mgr.lastSnapshotSchedule = scheduleStr
+	}
+
+	now := timeNow()
+	if mgr.nextScheduledSnapshot.IsZero() {
+		var last time.Time
+		if err := st.Get("last-scheduled-snapshot", &last); err != nil && !errors.Is(err, state.ErrNoState) {
+			return err
+		}
+		if last.IsZero() {
+			// the schedule starts now, there is no point in taking a
+			// snapshot right away
+			last = now
+			st.Set("last-scheduled-snapshot", last)
+		}
+		mgr.nextScheduledSnapshot = now.Add(timeutil.Next(schedule, last, scheduledSnapshotMaxPostponement))
+		logger.Debugf("Next scheduled snapshot at %s.", mgr.nextScheduledSnapshot.Format(time.RFC3339))
+	}
+	if mgr.nextScheduledSnapshot.After(now) {
+		return nil
+	}
+
+	names, err := scheduledSnapNames(st)
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		setID, _, ts, err := save(st, names, nil, nil, true)
+		if err != nil {
+			// most likely a conflict with a change operating on one
+			// of the snaps, try again on the next ensure
+			logger.Debugf("Cannot take scheduled snapshot: %v.", err)
+			return nil
+		}
+		chg := st.NewChange("scheduled-snapshot", fmt.Sprintf("Save scheduled snapshot set #%d", setID))
+		chg.AddAll(ts)
+		chg.Set("api-data", map[string]interface{}{"snap-names": names})
+		st.EnsureBefore(0)
+		mgr.pruneScheduledNeeded = true
+	}
+
+	st.Set("last-scheduled-snapshot", now)
+	mgr.nextScheduledSnapshot = time.Time{}
+	return nil
+}
+
+// pruneScheduledSnapshots removes the scheduled snapshots of each snap
+// beyond the newest ones to retain for it. The state needs to be locked by the
+// caller.
+func (mgr *SnapshotManager) pruneScheduledSnapshots() error {
+	st := mgr.state
+
+	scheduled, err := scheduledSnapshotSets(st)
+	if err != nil {
+		return err
+	}
+	if len(scheduled) == 0 {
+		mgr.pruneScheduledNeeded = false
+		return nil
+	}
+	retention, snapRetention, err := scheduledSnapshotRetention(st)
+	if err != nil {
+		return err
+	}
+
+	type scheduledSnapshot struct {
+		setID    uint64
+		filename string
+	}
+	bySnap := make(map[string][]scheduledSnapshot)
+	// the sets still on disk, and how many of their snapshots remain
+	remaining := make(map[uint64]int)
+	err = backendIter(context.TODO(), func(r *backend.Reader) error {
+		if !scheduled[r.SetID] {
+			return nil
+		}
+		bySnap[r.Snap] = append(bySnap[r.Snap], scheduledSnapshot{setID: r.SetID, filename: r.Name()})
+		remaining[r.SetID]++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	conflicts := false
+	for snapName, snapshots := range bySnap {
+		keep := retention
+		if n, ok := snapRetention[snapName]; ok {
+			keep = n
+		}
+		if len(snapshots) <= keep {
+			continue
+		}
+		// newest first
+		sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].setID > snapshots[j].setID })
+		for _, sh := range snapshots[keep:] {
+			if err := checkSnapshotConflict(st, sh.setID, "export-snapshot",
+				"check-snapshot", "restore-snapshot", "push-snapshot"); err != nil {
+				// try again later
+				conflicts = true
+				continue
+			}
+			if err := osRemove(sh.filename); err != nil {
+				return fmt.Errorf("cannot remove snapshot file %q: %v", sh.filename, err)
+			}
+			remaining[sh.setID]--
+		}
+	}
+
+	var gone []uint64
+	for setID := range scheduled {
+		if remaining[setID] == 0 {
+			gone = append(gone, setID)
+		}
+	}
+	if err := removeSnapshotState(st, gone...); err != nil {
+		return err
+	}
+
+	mgr.pruneScheduledNeeded = conflicts
+	return nil
+}
@@ -0,0 +1,433 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapshotstate_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapshotstate"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timeutil"
+)
+
+func mockScheduledSnaps(st *state.State) {
+	st.Lock()
+	defer st.Unlock()
+	for _, name := range []string{"a-snap", "b-snap", "c-snap", "d-snap"} {
+		snapstate.Set(st, name, &snapstate.SnapState{
+			// d-snap is disabled
+			Active: name != "d-snap",
+			Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+				{RealName: name, Revision: snap.R(1)},
+			}),
+			Current: snap.R(1),
+		})
+	}
+}
+
+func setCoreConfig(st *state.State, conf map[string]interface{}) {
+	tr := config.NewTransaction(st)
+	for k, v := range conf {
+		tr.Set("core", k, v)
+	}
+	tr.Commit()
+}
+
+// mockScheduleTimeNow mocks the time of both the manager and the schedule.
+func mockScheduleTimeNow(f func() time.Time) (restore func()) {
+	restoreMgr := snapshotstate.MockTimeNow(f)
+	restoreSchedule := timeutil.MockTimeNow(f)
+	return func() {
+		restoreSchedule()
+		restoreMgr()
+	}
+}
+
+func (snapshotSuite) TestEnsureScheduledSnapshotNoSchedule(c *check.C) {
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+	mockScheduledSnaps(st)
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	var last time.Time
+	c.Check(st.Get("last-scheduled-snapshot", &last), testutil.ErrorIs, state.ErrNoState)
+}
+
+func (snapshotSuite) TestEnsureScheduledSnapshotStartsSchedule(c *check.C) {
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+	mockScheduledSnaps(st)
+	st.Lock()
+	setCoreConfig(st, map[string]interface{}{"snapshots.schedule": "00:00-24:00"})
+	st.Unlock()
+
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	defer mockScheduleTimeNow(func() time.Time { return now })()
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	// no snapshot is taken right away
+	c.Check(st.Changes(), check.HasLen, 0)
+	var last time.Time
+	c.Assert(st.Get("last-scheduled-snapshot", &last), check.IsNil)
+	c.Check(last.Equal(now), check.Equals, true)
+}
+
+func (snapshotSuite) TestEnsureScheduledSnapshotFollowsSchedule(c *check.C) {
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+	mockScheduledSnaps(st)
+	st.Lock()
+	setCoreConfig(st, map[string]interface{}{"snapshots.schedule": "02:00-03:00"})
+	st.Unlock()
+
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.Local)
+	defer mockScheduleTimeNow(func() time.Time { return now })()
+	// starts the schedule
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	// not due yet later in the day
+	now = time.Date(2026, 3, 4, 23, 0, 0, 0, time.Local)
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	st.Unlock()
+
+	// but once the window of the next day has passed
+	now = time.Date(2026, 3, 5, 3, 0, 0, 0, time.Local)
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 1)
+	var last time.Time
+	c.Assert(st.Get("last-scheduled-snapshot", &last), check.IsNil)
+	c.Check(last.Equal(now), check.Equals, true)
+}
+
+func (snapshotSuite) TestEnsureScheduledSnapshot(c *check.C) {
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+	mockScheduledSnaps(st)
+	st.Lock()
+	setCoreConfig(st, map[string]interface{}{
+		"snapshots.schedule":          "00:00-24:00",
+		"snapshots.scheduled.exclude": "b-snap",
+	})
+	st.Set("last-scheduled-snapshot", time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC))
+	st.Unlock()
+
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	defer mockScheduleTimeNow(func() time.Time { return now })()
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	chgs := st.Changes()
+	c.Assert(chgs, check.HasLen, 1)
+	chg := chgs[0]
+	c.Check(chg.Kind(), check.Equals, "scheduled-snapshot")
+	c.Check(chg.Summary(), check.Equals, "Save scheduled snapshot set #1")
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	for i, name := range []string{"a-snap", "c-snap"} {
+		var snapshot map[string]interface{}
+		c.Assert(tasks[i].Get("snapshot-setup", &snapshot), check.IsNil)
+		c.Check(snapshot, check.DeepEquals, map[string]interface{}{
+			"set-id":    1.,
+			"snap":      name,
+			"current":   "unset",
+			"scheduled": true,
+		})
+	}
+	var last time.Time
+	c.Assert(st.Get("last-scheduled-snapshot", &last), check.IsNil)
+	c.Check(last.Equal(now), check.Equals, true)
+
+	// nothing else happens while the change is in flight
+	snapshotstate.SetNextScheduledSnapshot(mgr, time.Time{})
+	st.Set("last-scheduled-snapshot", time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC))
+	st.Unlock()
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+	c.Check(st.Changes(), check.HasLen, 1)
+}
+
+func (snapshotSuite) TestEnsureScheduledSnapshotInclude(c *check.C) {
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+	mockScheduledSnaps(st)
+	st.Lock()
+	setCoreConfig(st, map[string]interface{}{
+		"snapshots.schedule":          "00:00-24:00",
+		"snapshots.scheduled.include": "b-snap,c-snap,d-snap",
+		"snapshots.scheduled.exclude": "c-snap",
+	})
+	st.Set("last-scheduled-snapshot", time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC))
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	chgs := st.Changes()
+	c.Assert(chgs, check.HasLen, 1)
+	tasks := chgs[0].Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	c.Check(tasks[0].Summary(), check.Equals, `Save data of snap "b-snap" in snapshot set #1`)
+}
+
+func (snapshotSuite) TestEnsureScheduledSnapshotConflict(c *check.C) {
+	conflict := errors.New("conflict")
+	defer snapshotstate.MockSnapstateCheckChangeConflictMany(func(*state.State, []string, string) error {
+		return conflict
+	})()
+
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+	mockScheduledSnaps(st)
+	st.Lock()
+	setCoreConfig(st, map[string]interface{}{"snapshots.schedule": "00:00-24:00"})
+	old := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	st.Set("last-scheduled-snapshot", old)
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	// the snapshot is still due
+	var last time.Time
+	c.Assert(st.Get("last-scheduled-snapshot", &last), check.IsNil)
+	c.Check(last.Equal(old), check.Equals, true)
+	st.Unlock()
+
+	conflict = nil
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 1)
+}
+
+func (snapshotSuite) TestEnsurePrunesScheduledSnapshots(c *check.C) {
+	var sets []client.Snapshot
+	for setID := uint64(1); setID <= 4; setID++ {
+		sets = append(sets, client.Snapshot{SetID: setID, Snap: "a-snap"})
+	}
+	// b-snap was only in the later sets
+	sets = append(sets, client.Snapshot{SetID: 4, Snap: "b-snap"})
+	// set 5 was not scheduled
+	sets = append(sets, client.Snapshot{SetID: 5, Snap: "a-snap"})
+
+	dir := c.MkDir()
+	defer snapshotstate.MockBackendIter(func(_ context.Context, f func(*backend.Reader) error) error {
+		for _, sh := range sets {
+			shotfile, err := os.Create(filepath.Join(dir, fmt.Sprintf("%d_%s.zip", sh.SetID, sh.Snap)))
+			c.Assert(err, check.IsNil)
+			r := &backend.Reader{Snapshot: sh, File: shotfile}
+			err = f(r)
+			shotfile.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})()
+	var removed []string
+	defer snapshotstate.MockOsRemove(func(name string) error {
+		removed = append(removed, filepath.Base(name))
+		return nil
+	})()
+
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+	st.Lock()
+	setCoreConfig(st, map[string]interface{}{"snapshots.scheduled.retention": 2})
+	st.Set("snapshots", map[uint64]interface{}{
+		1: map[string]interface{}{"scheduled": true},
+		2: map[string]interface{}{"scheduled": true},
+		3: map[string]interface{}{"scheduled": true},
+		4: map[string]interface{}{"scheduled": true},
+		// gone already
+		6: map[string]interface{}{"scheduled": true},
+	})
+	// set 2 is being checked
+	chg := st.NewChange("check-snapshot", "...")
+	task := st.NewTask("check-snapshot", "...")
+	task.Set("snapshot-setup", map[string]interface{}{"set-id": 2})
+	chg.AddTask(task)
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	c.Check(removed, check.DeepEquals, []string{"1_a-snap.zip"})
+	var snapshots map[uint64]interface{}
+	c.Assert(st.Get("snapshots", &snapshots), check.IsNil)
+	c.Check(snapshots, check.HasLen, 3)
+	c.Check(snapshots[2], check.NotNil)
+	c.Check(snapshots[3], check.NotNil)
+	c.Check(snapshots[4], check.NotNil)
+
+	// set 2 goes once the check is done
+	chg.SetStatus(state.DoneStatus)
+	task.SetStatus(state.DoneStatus)
+	st.Unlock()
+	removed = nil
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(removed, check.DeepEquals, []string{"2_a-snap.zip"})
+	snapshots = nil
+	c.Assert(st.Get("snapshots", &snapshots), check.IsNil)
+	c.Check(snapshots, check.HasLen, 2)
+
+	// and nothing is done until there are new scheduled snapshots
+	st.Unlock()
+	removed = nil
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+	c.Check(removed, check.HasLen, 0)
+}
+
+func (snapshotSuite) TestEnsurePrunesScheduledSnapshotsSnapRetention(c *check.C) {
+	var sets []client.Snapshot
+	for setID := uint64(1); setID <= 4; setID++ {
+		for _, name := range []string{"a-snap", "b-snap", "c-snap"} {
+			sets = append(sets, client.Snapshot{SetID: setID, Snap: name})
+		}
+	}
+
+	dir := c.MkDir()
+	defer snapshotstate.MockBackendIter(func(_ context.Context, f func(*backend.Reader) error) error {
+		for _, sh := range sets {
+			shotfile, err := os.Create(filepath.Join(dir, fmt.Sprintf("%d_%s.zip", sh.SetID, sh.Snap)))
+			c.Assert(err, check.IsNil)
+			r := &backend.Reader{Snapshot: sh, File: shotfile}
+			err = f(r)
+			shotfile.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})()
+	var removed []string
+	defer snapshotstate.MockOsRemove(func(name string) error {
+		removed = append(removed, filepath.Base(name))
+		return nil
+	})()
+
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+	st.Lock()
+	setCoreConfig(st, map[string]interface{}{
+		"snapshots.scheduled.retention":             2,
+		"snapshots.scheduled.snap-retention.a-snap": 3,
+		"snapshots.scheduled.snap-retention.b-snap": "1",
+		// falls back to the default retention
+		"snapshots.scheduled.snap-retention.c-snap": "",
+	})
+	st.Set("snapshots", map[uint64]interface{}{
+		1: map[string]interface{}{"scheduled": true},
+		2: map[string]interface{}{"scheduled": true},
+		3: map[string]interface{}{"scheduled": true},
+		4: map[string]interface{}{"scheduled": true},
+	})
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	sort.Strings(removed)
+	c.Check(removed, check.DeepEquals, []string{
+		"1_a-snap.zip",
+		"1_b-snap.zip", "1_c-snap.zip",
+		"2_b-snap.zip", "2_c-snap.zip",
+		"3_b-snap.zip",
+	})
+	var snapshots map[uint64]interface{}
+	c.Assert(st.Get("snapshots", &snapshots), check.IsNil)
+	c.Check(snapshots, check.HasLen, 3)
+	c.Check(snapshots[1], check.IsNil)
+}
+
+func (snapshotSuite) TestDoSaveScheduled(c *check.C) {
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snap.Info{SideInfo: snap.SideInfo{RealName: "a-snap", Revision: snap.R(1)}}, nil
+	})()
+	saveErr := errors.New("bzzt")
+	defer snapshotstate.MockBackendSave(func(context.Context, uint64, *snap.Info, map[string]interface{}, []string, *snap.SnapshotOptions, *dirs.SnapDirOptions, *backend.SaveFlags) (*client.Snapshot, error) {
+		return nil, saveErr
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	task := st.NewTask("save-snapshot", "...")
+	task.Set("snapshot-setup", map[string]interface{}{
+		"set-id":    42,
+		"snap":      "a-snap",
+		"scheduled": true,
+	})
+	st.Unlock()
+
+	// the set does not stay around on failure
+	c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.Equals, saveErr)
+	st.Lock()
+	var snapshots map[uint64]interface{}
+	c.Assert(st.Get("snapshots", &snapshots), check.IsNil)
+	c.Check(snapshots, check.HasLen, 0)
+	st.Unlock()
+
+	saveErr = nil
+	c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.IsNil)
+	st.Lock()
+	defer st.Unlock()
+	snapshots = nil
+	c.Assert(st.Get("snapshots", &snapshots), check.IsNil)
+	c.Check(snapshots, check.DeepEquals, map[uint64]interface{}{
+		42: map[string]interface{}{"expiry-time": "0001-01-01T00:00:00Z", "scheduled": true},
+	})
+}
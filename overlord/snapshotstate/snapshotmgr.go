@@ -23,7 +23,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sync"
 	"time"
 
 	"gopkg.in/tomb.v2"
@@ -33,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/overlord/snapshotstate/remote"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
@@ -48,12 +51,15 @@ var (
 	backendImport        = backend.Import
 	backendRestore       = (*backend.Reader).Restore // TODO: look into using an interface instead
 	backendCheck         = (*backend.Reader).Check
+	backendUnlock        = (*backend.Reader).Unlock
 	backendRevert        = (*backend.RestoreState).Revert // ditto
 	backendCleanup       = (*backend.RestoreState).Cleanup
 
 	backendCleanupAbandonedImports = backend.CleanupAbandonedImports
+	backendPruneChunks             = backend.PruneChunks
 
 	autoExpirationInterval = time.Hour * 24 // interval between forgetExpiredSnapshots runs as part of Ensure()
+	pruneChunksInterval    = time.Hour * 24 // interval between pruneChunks runs as part of Ensure()
 
 	getSnapDirOpts = snapstate.GetSnapDirOpts
 )
@@ -63,6 +69,20 @@ type SnapshotManager struct {
 	state *state.State
 
 	lastForgetExpiredSnapshotTime time.Time
+
+	// pruneChunksMu guards the fields below, as chunks are pruned in
+	// the background
+	pruneChunksMu       sync.Mutex
+	lastPruneChunksTime time.Time
+	pruneChunksTomb     *tomb.Tomb
+
+	// nextScheduledSnapshot is when the next scheduled snapshot is due,
+	// as per lastSnapshotSchedule
+	nextScheduledSnapshot time.Time
+	lastSnapshotSchedule  string
+	// pruneScheduledNeeded is set when scheduled snapshots beyond the
+	// retention may be around
+	pruneScheduledNeeded bool
 }
 
 // Manager returns a new SnapshotManager
@@ -74,9 +94,11 @@ func Manager(st *state.State, runner *state.TaskRunner) *SnapshotManager {
 	runner.AddHandler("check-snapshot", doCheck, nil)
 	runner.AddHandler("restore-snapshot", doRestore, undoRestore)
 	runner.AddHandler("cleanup-after-restore", doCleanupAfterRestore, nil)
+	runner.AddHandler("push-snapshot", doPush, nil)
 
 	manager := &SnapshotManager{
-		state: st,
+		state:                st,
+		pruneScheduledNeeded: true,
 	}
 	snapstate.RegisterAffectedSnapsByAttr("snapshot-setup", manager.affectedSnaps)
 
@@ -87,12 +109,72 @@ func Manager(st *state.State, runner *state.TaskRunner) *SnapshotManager {
 func (mgr *SnapshotManager) Ensure() error {
 	// process expired snapshots once a day.
 	if time.Now().After(mgr.lastForgetExpiredSnapshotTime.Add(autoExpirationInterval)) {
-		return mgr.forgetExpiredSnapshots()
+		if err := mgr.forgetExpiredSnapshots(); err != nil {
+			return err
+		}
+	}
+
+	mgr.state.Lock()
+	forgetUnusedPassphrases(mgr.state)
+	mgr.state.Unlock()
+
+	// drop chunks of incremental snapshots that are gone, also once a day.
+	mgr.startPruneChunks()
+
+	if err := mgr.ensureScheduledSnapshots(); err != nil {
+		logger.Noticef("cannot take scheduled snapshots: %v", err)
 	}
 
 	return nil
 }
 
+// startPruneChunks starts pruning the unused chunks of incremental snapshots
+// in the background, as it walks the whole chunk store and waits for the
+// saves in progress, unless it was done recently or is still going on.
+func (mgr *SnapshotManager) startPruneChunks() {
+	mgr.pruneChunksMu.Lock()
+	defer mgr.pruneChunksMu.Unlock()
+
+	if mgr.pruneChunksTomb != nil && mgr.pruneChunksTomb.Alive() {
+		return
+	}
+	if !time.Now().After(mgr.lastPruneChunksTime.Add(pruneChunksInterval)) {
+		return
+	}
+	pruneChunks := backendPruneChunks
+	t := &tomb.Tomb{}
+	t.Go(func() error {
+		// note the backend takes care of not removing chunks of
+		// snapshots that are still being saved, so no state lock is
+		// needed here
+		pruned, err := pruneChunks(t.Context(nil))
+		if err != nil {
+			logger.Noticef("cannot prune snapshot chunks: %v", err)
+			return nil
+		}
+		if pruned > 0 {
+			logger.Debugf("Pruned %d unused snapshot chunks.", pruned)
+		}
+		mgr.pruneChunksMu.Lock()
+		mgr.lastPruneChunksTime = time.Now()
+		mgr.pruneChunksMu.Unlock()
+		return nil
+	})
+	mgr.pruneChunksTomb = t
+}
+
+// Stop implements StateStopper. It stops pruning chunks in the background.
+func (mgr *SnapshotManager) Stop() {
+	mgr.pruneChunksMu.Lock()
+	t := mgr.pruneChunksTomb
+	mgr.pruneChunksMu.Unlock()
+
+	if t != nil {
+		t.Kill(nil)
+		t.Wait()
+	}
+}
+
 func (mgr *SnapshotManager) StartUp() error {
 	if _, err := backendCleanupAbandonedImports(); err != nil {
 		logger.Noticef("cannot cleanup incomplete imports: %v", err)
@@ -148,9 +230,9 @@ func (mgr *SnapshotManager) forgetExpiredSnapshots() error {
 	return nil
 }
 
-func (SnapshotManager) affectedSnaps(t *state.Task) ([]string, error) {
-	if k := t.Kind(); k == "check-snapshot" || k == "forget-snapshot" {
-		// check and forget don't affect snaps
+func (*SnapshotManager) affectedSnaps(t *state.Task) ([]string, error) {
+	if k := t.Kind(); k == "check-snapshot" || k == "forget-snapshot" || k == "push-snapshot" {
+		// check, forget and push don't affect snaps
 		// (this could also be written k != save && k != restore, but it's safer this way around)
 		return nil, nil
 	}
@@ -170,6 +252,13 @@ type snapshotSetup struct {
 	Filename string                `json:"filename,omitempty"`
 	Current  snap.Revision         `json:"current"`
 	Auto     bool                  `json:"auto,omitempty"`
+	// Scheduled is set for snapshots taken as per snapshots.schedule
+	Scheduled bool `json:"scheduled,omitempty"`
+	// PreRefresh is set for automatic snapshots taken before a refresh
+	PreRefresh bool `json:"pre-refresh,omitempty"`
+	// Encryption is set to backend.EncryptionPassphrase if a passphrase
+	// was given for the set, which is only kept in memory
+	Encryption string `json:"encryption,omitempty"`
 }
 
 func filename(setID uint64, si *snap.Info) string {
@@ -206,12 +295,24 @@ func prepareSave(task *state.Task) (snapshot *snapshotSetup, cur *snap.Info, cfg
 	}
 
 	// this should be done last because of it modifies the state and the caller needs to undo this if other operation fails.
-	if snapshot.Auto {
-		expiration, err := AutomaticSnapshotExpiration(st)
-		if err != nil {
-			return nil, nil, nil, err
+	if snapshot.Auto || snapshot.Scheduled {
+		sstate := &snapshotState{
+			Scheduled:  snapshot.Scheduled,
+			PreRefresh: snapshot.PreRefresh,
+		}
+		if snapshot.Auto {
+			expiration, err := AutomaticSnapshotExpiration(st)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if expiration == 0 {
+				// only snapshots taken before refreshing, when asked
+				// to, get here with automatic snapshots disabled
+				expiration = defaultAutomaticSnapshotExpiration
+			}
+			sstate.ExpiryTime = time.Now().Add(expiration)
 		}
-		if err := saveExpiration(st, snapshot.SetID, time.Now().Add(expiration)); err != nil {
+		if err := saveSnapshotState(st, snapshot.SetID, sstate); err != nil {
 			return nil, nil, nil, err
 		}
 	}
@@ -228,12 +329,36 @@ func doSave(task *state.Task, tomb *tomb.Tomb) error {
 
 	st.Lock()
 	opts, err := getSnapDirOpts(st, snapshot.Snap)
+	if err != nil {
+		st.Unlock()
+		return err
+	}
+	incremental, err := incrementalSnapshots(st)
+	if err != nil {
+		st.Unlock()
+		return err
+	}
+	encryption, err := snapshotEncryption(st)
+	if err != nil {
+		st.Unlock()
+		return err
+	}
+	passphrase, err := setupPassphrase(st, snapshot)
 	st.Unlock()
 	if err != nil {
 		return err
 	}
+	flags := &backend.SaveFlags{Incremental: incremental}
+	// a passphrase given for the set takes precedence over the key
+	// held by the device
+	switch {
+	case passphrase != "":
+		flags.Encryption = &backend.EncryptionFlags{Key: backend.EncryptionPassphrase, Passphrase: passphrase}
+	case encryption != "":
+		flags.Encryption = &backend.EncryptionFlags{Key: encryption}
+	}
 
-	_, err = backendSave(tomb.Context(nil), snapshot.SetID, cur, cfg, snapshot.Users, snapshot.Options, opts)
+	_, err = backendSave(tomb.Context(nil), snapshot.SetID, cur, cfg, snapshot.Users, snapshot.Options, opts, flags)
 	if err != nil {
 		st.Lock()
 		defer st.Unlock()
@@ -262,6 +387,15 @@ func prepareRestore(task *state.Task) (snapshot *snapshotSetup, oldCfg map[strin
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("cannot open snapshot: %v", err)
 	}
+	passphrase, err := setupPassphrase(st, snapshot)
+	if err != nil {
+		reader.Close()
+		return nil, nil, nil, err
+	}
+	if err := backendUnlock(reader, passphrase); err != nil {
+		reader.Close()
+		return nil, nil, nil, err
+	}
 	// note given the Open succeeded, caller needs to close it when done
 
 	return snapshot, oldCfg, reader, nil
@@ -422,10 +556,15 @@ func doCheck(task *state.Task, tomb *tomb.Tomb) error {
 	st := task.State()
 	st.Lock()
 	err := task.Get("snapshot-setup", &snapshot)
-	st.Unlock()
 	if err != nil {
+		st.Unlock()
 		return taskGetErrMsg(task, err, "snapshot")
 	}
+	passphrase, err := setupPassphrase(st, &snapshot)
+	st.Unlock()
+	if err != nil {
+		return err
+	}
 
 	reader, err := backendOpen(snapshot.Filename, backend.ExtractFnameSetID)
 	if err != nil {
@@ -433,6 +572,10 @@ func doCheck(task *state.Task, tomb *tomb.Tomb) error {
 	}
 	defer reader.Close()
 
+	if err := backendUnlock(reader, passphrase); err != nil {
+		return err
+	}
+
 	return backendCheck(reader, tomb.Context(nil), snapshot.Users)
 }
 
@@ -461,14 +604,61 @@ func doForget(task *state.Task, _ *tomb.Tomb) error {
 	return osRemove(snapshot.Filename)
 }
 
+func doPush(task *state.Task, tomb *tomb.Tomb) error {
+	var snapshot snapshotSetup
+
+	st := task.State()
+	st.Lock()
+	err := task.Get("snapshot-setup", &snapshot)
+	if err != nil {
+		st.Unlock()
+		return taskGetErrMsg(task, err, "snapshot")
+	}
+	target, err := remoteTarget(st)
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+
+	ctx := tomb.Context(nil)
+	export, err := backendNewSnapshotExport(ctx, snapshot.SetID)
+	if err != nil {
+		return fmt.Errorf("cannot export snapshot set #%d: %v", snapshot.SetID, err)
+	}
+	defer export.Close()
+	if err := export.Init(); err != nil {
+		return fmt.Errorf("cannot export snapshot set #%d: %v", snapshot.SetID, err)
+	}
+
+	name := remote.ExportName(snapshot.SetID, time.Now())
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(export.StreamTo(pw))
+	}()
+	err = target.Push(ctx, name, pr, export.Size())
+	// unblock the exporter if the push stopped reading
+	pr.Close()
+	if err != nil {
+		return err
+	}
+
+	st.Lock()
+	defer st.Unlock()
+	task.Set("remote-name", name)
+	task.Logf("Pushed snapshot set #%d to %s as %q.", snapshot.SetID, target, name)
+	return nil
+}
+
 func delayedCrossMgrInit() {
 	// hook automatic snapshots into snapstate logic
 	snapstate.AutomaticSnapshot = AutomaticSnapshot
 	snapstate.AutomaticSnapshotExpiration = AutomaticSnapshotExpiration
 	snapstate.EstimateSnapshotSize = EstimateSnapshotSize
+	snapstate.PreRefreshSnapshot = PreRefreshSnapshot
+	snapstate.RestorePreRefreshSnapshot = RestorePreRefreshSnapshot
 }
 
-func MockBackendSave(f func(context.Context, uint64, *snap.Info, map[string]interface{}, []string, *snap.SnapshotOptions, *dirs.SnapDirOptions) (*client.Snapshot, error)) (restore func()) {
+func MockBackendSave(f func(context.Context, uint64, *snap.Info, map[string]interface{}, []string, *snap.SnapshotOptions, *dirs.SnapDirOptions, *backend.SaveFlags) (*client.Snapshot, error)) (restore func()) {
 	old := backendSave
 	backendSave = f
 	return func() {
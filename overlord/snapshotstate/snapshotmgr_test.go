@@ -35,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapshotstate"
 	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
 	"github.com/snapcore/snapd/overlord/state"
@@ -55,6 +56,7 @@ func (snapshotSuite) TestManager(c *check.C) {
 		"check-snapshot",
 		"cleanup-after-restore",
 		"forget-snapshot",
+		"push-snapshot",
 		"restore-snapshot",
 		"save-snapshot",
 	})
@@ -168,6 +170,80 @@ func (snapshotSuite) TestEnsureForgetsSnapshotsRunsRegularly(c *check.C) {
 	c.Check(backendIterCalls, check.Equals, 2)
 }
 
+func (snapshotSuite) TestEnsurePrunesChunksRegularly(c *check.C) {
+	var pruneCalls int
+	defer snapshotstate.MockBackendPruneChunks(func(context.Context) (int, error) {
+		pruneCalls++
+		return 3, nil
+	})()
+
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+	c.Assert(mgr, check.NotNil)
+
+	for i := 0; i < 3; i++ {
+		c.Assert(mgr.Ensure(), check.IsNil)
+		snapshotstate.WaitPruneChunks(mgr)
+		c.Check(pruneCalls, check.Equals, 1)
+	}
+
+	// pretend we haven't run for a while
+	snapshotstate.SetLastPruneChunksTime(mgr, time.Now().Add(-25*time.Hour))
+	c.Assert(mgr.Ensure(), check.IsNil)
+	snapshotstate.WaitPruneChunks(mgr)
+	c.Check(pruneCalls, check.Equals, 2)
+}
+
+func (snapshotSuite) TestEnsurePrunesChunksInBackground(c *check.C) {
+	pruneStarted := make(chan struct{})
+	var pruneCalls int
+	defer snapshotstate.MockBackendPruneChunks(func(ctx context.Context) (int, error) {
+		pruneCalls++
+		close(pruneStarted)
+		// pruning waits for saves in progress
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})()
+
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+
+	// Ensure does not wait for pruning, nor starts it again while it is
+	// going on
+	c.Assert(mgr.Ensure(), check.IsNil)
+	<-pruneStarted
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	// and stopping the manager stops pruning
+	mgr.Stop()
+	c.Check(pruneCalls, check.Equals, 1)
+}
+
+func (snapshotSuite) TestEnsurePruneChunksErrorRetried(c *check.C) {
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	var pruneCalls int
+	defer snapshotstate.MockBackendPruneChunks(func(context.Context) (int, error) {
+		pruneCalls++
+		return 0, errors.New("boom")
+	})()
+
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+
+	// errors are logged but do not fail Ensure, and are retried
+	c.Assert(mgr.Ensure(), check.IsNil)
+	snapshotstate.WaitPruneChunks(mgr)
+	c.Assert(mgr.Ensure(), check.IsNil)
+	snapshotstate.WaitPruneChunks(mgr)
+	c.Check(pruneCalls, check.Equals, 2)
+	c.Check(logbuf.String(), testutil.Contains, "cannot prune snapshot chunks: boom")
+}
+
 func (snapshotSuite) testEnsureForgetSnapshotsConflict(c *check.C, snapshotOp string) {
 	removeCalled := 0
 	restoreOsRemove := snapshotstate.MockOsRemove(func(string) error {
@@ -282,7 +358,7 @@ func (snapshotSuite) TestDoSave(c *check.C) {
 
 	expectedOptions := &snap.SnapshotOptions{}
 	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string,
-		options *snap.SnapshotOptions, _ *dirs.SnapDirOptions) (*client.Snapshot, error) {
+		options *snap.SnapshotOptions, _ *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		c.Check(id, check.Equals, uint64(42))
 		c.Check(si, check.DeepEquals, &snapInfo)
 		c.Check(cfg, check.DeepEquals, map[string]interface{}{"hello": "there"})
@@ -338,7 +414,7 @@ func (snapshotSuite) TestDoSaveGetsSnapDirOpts(c *check.C) {
 	})()
 
 	var checkOpts bool
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, opts *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, opts *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		c.Check(opts.HiddenSnapDataDir, check.Equals, true)
 		checkOpts = true
 		return nil, nil
@@ -357,12 +433,215 @@ func (snapshotSuite) TestDoSaveGetsSnapDirOpts(c *check.C) {
 	c.Check(checkOpts, check.Equals, true)
 }
 
+func (snapshotSuite) TestDoSaveIncremental(c *check.C) {
+	snapInfo := snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "a-snap",
+			Revision: snap.R(-1),
+		},
+		Version: "1.33",
+	}
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snapInfo, nil
+	})()
+
+	var saveFlags []*backend.SaveFlags
+	defer snapshotstate.MockBackendSave(func(_ context.Context, _ uint64, _ *snap.Info, _ map[string]interface{}, _ []string, _ *snap.SnapshotOptions, _ *dirs.SnapDirOptions, flags *backend.SaveFlags) (*client.Snapshot, error) {
+		saveFlags = append(saveFlags, flags)
+		return nil, nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	task := st.NewTask("save-snapshot", "...")
+	task.Set("snapshot-setup", map[string]interface{}{
+		"snap": "a-snap",
+	})
+	st.Unlock()
+
+	c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.IsNil)
+
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.incremental", true)
+	tr.Commit()
+	st.Unlock()
+
+	c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.IsNil)
+	c.Check(saveFlags, check.DeepEquals, []*backend.SaveFlags{
+		{Incremental: false},
+		{Incremental: true},
+	})
+}
+
+func (snapshotSuite) TestDoSavePreRefresh(c *check.C) {
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snap.Info{SideInfo: snap.SideInfo{RealName: "a-snap", Revision: snap.R(1)}}, nil
+	})()
+	defer snapshotstate.MockBackendSave(func(context.Context, uint64, *snap.Info, map[string]interface{}, []string, *snap.SnapshotOptions, *dirs.SnapDirOptions, *backend.SaveFlags) (*client.Snapshot, error) {
+		return nil, nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	// automatic snapshots are disabled, but this one was asked for
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.automatic.retention", "no")
+	tr.Commit()
+	task := st.NewTask("save-snapshot", "...")
+	task.Set("snapshot-setup", map[string]interface{}{
+		"set-id":      42,
+		"snap":        "a-snap",
+		"auto":        true,
+		"pre-refresh": true,
+	})
+	st.Unlock()
+
+	before := time.Now()
+	c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	var snapshots map[uint64]struct {
+		ExpiryTime time.Time `json:"expiry-time"`
+		PreRefresh bool      `json:"pre-refresh"`
+	}
+	c.Assert(st.Get("snapshots", &snapshots), check.IsNil)
+	c.Assert(snapshots, check.HasLen, 1)
+	c.Check(snapshots[42].PreRefresh, check.Equals, true)
+	c.Check(snapshots[42].ExpiryTime.Before(before.Add(snapshotstate.DefaultAutomaticSnapshotExpiration)), check.Equals, false)
+}
+
+func (snapshotSuite) TestDoSaveEncryption(c *check.C) {
+	snapInfo := snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "a-snap",
+			Revision: snap.R(-1),
+		},
+		Version: "1.33",
+	}
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snapInfo, nil
+	})()
+
+	var encryption []*backend.EncryptionFlags
+	defer snapshotstate.MockBackendSave(func(_ context.Context, _ uint64, _ *snap.Info, _ map[string]interface{}, _ []string, _ *snap.SnapshotOptions, _ *dirs.SnapDirOptions, flags *backend.SaveFlags) (*client.Snapshot, error) {
+		encryption = append(encryption, flags.Encryption)
+		return nil, nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	task := st.NewTask("save-snapshot", "...")
+	task.Set("snapshot-setup", map[string]interface{}{
+		"set-id": 42,
+		"snap":   "a-snap",
+	})
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.encryption", "none")
+	tr.Commit()
+	st.Unlock()
+
+	// not encrypted
+	c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.IsNil)
+
+	st.Lock()
+	tr = config.NewTransaction(st)
+	tr.Set("core", "snapshots.encryption", "device")
+	tr.Commit()
+	st.Unlock()
+
+	// encrypted with the device key
+	c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.IsNil)
+
+	st.Lock()
+	snapshotstate.SetPassphrase(st, 42, "sekrit", state.NewTaskSet(task))
+	st.Unlock()
+
+	// a passphrase wins
+	c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.IsNil)
+
+	c.Check(encryption, check.DeepEquals, []*backend.EncryptionFlags{
+		nil,
+		{Key: "device"},
+		{Key: "passphrase", Passphrase: "sekrit"},
+	})
+}
+
+func (snapshotSuite) TestDoSavePassphraseLost(c *check.C) {
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snap.Info{SideInfo: snap.SideInfo{RealName: "a-snap", Revision: snap.R(-1)}, Version: "1.33"}, nil
+	})()
+	defer snapshotstate.MockBackendSave(func(context.Context, uint64, *snap.Info, map[string]interface{}, []string, *snap.SnapshotOptions, *dirs.SnapDirOptions, *backend.SaveFlags) (*client.Snapshot, error) {
+		c.Fatal("unexpected call to backend.Save")
+		return nil, nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	task := st.NewTask("save-snapshot", "...")
+	task.Set("snapshot-setup", map[string]interface{}{
+		"set-id": 42,
+		"snap":   "a-snap",
+	})
+	otherTask := st.NewTask("save-snapshot", "...")
+	otherTask.Set("snapshot-setup", map[string]interface{}{
+		"set-id": 43,
+		"snap":   "a-snap",
+	})
+	snapshotstate.SetPassphrase(st, 42, "sekrit", state.NewTaskSet(task, otherTask))
+
+	var snapshot map[string]interface{}
+	c.Assert(task.Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot["encryption"], check.Equals, "passphrase")
+	snapshot = nil
+	c.Assert(otherTask.Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot["encryption"], check.IsNil)
+
+	// the passphrase is only kept in memory, so it is gone after a restart
+	st.Cache("snapshot-passphrases", nil)
+	st.Unlock()
+
+	err := snapshotstate.DoSave(task, &tomb.Tomb{})
+	c.Check(err, check.ErrorMatches, `cannot use snapshot set #42: the passphrase given for it is no longer available, please retry`)
+}
+
+func (snapshotSuite) TestEnsureForgetsUnusedPassphrases(c *check.C) {
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+
+	st.Lock()
+	chg := st.NewChange("check-snapshot", "...")
+	task := st.NewTask("check-snapshot", "...")
+	task.Set("snapshot-setup", map[string]interface{}{"set-id": 1, "snap": "a-snap"})
+	chg.AddTask(task)
+
+	snapshotstate.SetPassphrase(st, 1, "one", state.NewTaskSet(task))
+	snapshotstate.SetPassphrase(st, 2, "two", state.NewTaskSet())
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	c.Check(snapshotstate.SnapshotPassphrase(st, 1), check.Equals, "one")
+	c.Check(snapshotstate.SnapshotPassphrase(st, 2), check.Equals, "")
+	task.SetStatus(state.DoneStatus)
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	c.Check(snapshotstate.SnapshotPassphrase(st, 1), check.Equals, "")
+	st.Unlock()
+}
+
 func (snapshotSuite) TestDoSaveFailsWithNoSnap(c *check.C) {
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
 		return nil, errors.New("bzzt")
 	})()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -389,7 +668,7 @@ func (snapshotSuite) TestDoSaveFailsWithNoSnapshot(c *check.C) {
 	}
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) { return &snapInfo, nil })()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -413,7 +692,7 @@ func (snapshotSuite) TestDoSaveFailsBackendError(c *check.C) {
 	}
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) { return &snapInfo, nil })()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, errors.New("bzzt")
 	})()
 
@@ -442,7 +721,7 @@ func (snapshotSuite) TestDoSaveFailsConfigError(c *check.C) {
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) {
 		return nil, errors.New("bzzt")
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -473,7 +752,7 @@ func (snapshotSuite) TestDoSaveFailsBadConfig(c *check.C) {
 		buf := json.RawMessage(`"hello-there"`)
 		return &buf, nil
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -506,7 +785,7 @@ func (snapshotSuite) TestDoSaveFailureRemovesStateEntry(c *check.C) {
 	defer snapshotstate.MockConfigGetSnapConfig(func(_ *state.State, snapname string) (*json.RawMessage, error) {
 		return nil, nil
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		var expirations map[uint64]interface{}
 		st.Lock()
 		defer st.Unlock()
@@ -838,6 +1117,64 @@ func (rs *readerSuite) TestDoCheck(c *check.C) {
 	c.Check(rs.calls, check.DeepEquals, []string{"open", "check"})
 }
 
+func (rs *readerSuite) TestDoCheckPassphrase(c *check.C) {
+	st := rs.task.State()
+	st.Lock()
+	// the task is for set 0
+	snapshotstate.SetPassphrase(st, 0, "sekrit", state.NewTaskSet(rs.task))
+	st.Unlock()
+
+	defer snapshotstate.MockBackendUnlock(func(_ *backend.Reader, passphrase string) error {
+		rs.calls = append(rs.calls, "unlock")
+		c.Check(passphrase, check.Equals, "sekrit")
+		return nil
+	})()
+
+	err := snapshotstate.DoCheck(rs.task, &tomb.Tomb{})
+	c.Assert(err, check.IsNil)
+	c.Check(rs.calls, check.DeepEquals, []string{"open", "unlock", "check"})
+}
+
+func (rs *readerSuite) TestDoCheckPassphraseLost(c *check.C) {
+	st := rs.task.State()
+	st.Lock()
+	snapshotstate.SetPassphrase(st, 0, "sekrit", state.NewTaskSet(rs.task))
+	// the passphrase is only kept in memory, so it is gone after a restart
+	st.Cache("snapshot-passphrases", nil)
+	st.Unlock()
+
+	defer snapshotstate.MockBackendUnlock(func(*backend.Reader, string) error {
+		c.Fatal("unexpected call to backend.Unlock")
+		return nil
+	})()
+
+	err := snapshotstate.DoCheck(rs.task, &tomb.Tomb{})
+	c.Assert(err, check.ErrorMatches, `cannot use snapshot set #0: the passphrase given for it is no longer available, please retry`)
+}
+
+func (rs *readerSuite) TestDoCheckUnlockError(c *check.C) {
+	defer snapshotstate.MockBackendUnlock(func(_ *backend.Reader, passphrase string) error {
+		rs.calls = append(rs.calls, "unlock")
+		c.Check(passphrase, check.Equals, "")
+		return backend.ErrPassphraseRequired
+	})()
+
+	err := snapshotstate.DoCheck(rs.task, &tomb.Tomb{})
+	c.Assert(err, check.Equals, backend.ErrPassphraseRequired)
+	c.Check(rs.calls, check.DeepEquals, []string{"open", "unlock"})
+}
+
+func (rs *readerSuite) TestDoRestoreUnlockError(c *check.C) {
+	defer snapshotstate.MockBackendUnlock(func(_ *backend.Reader, passphrase string) error {
+		rs.calls = append(rs.calls, "unlock")
+		return backend.ErrWrongKey
+	})()
+
+	err := snapshotstate.DoRestore(rs.task, &tomb.Tomb{})
+	c.Assert(err, check.ErrorMatches, "cannot decrypt snapshot: wrong key or passphrase")
+	c.Check(rs.calls, check.DeepEquals, []string{"get config", "open", "unlock"})
+}
+
 func (rs *readerSuite) TestDoRemove(c *check.C) {
 	defer snapshotstate.MockOsRemove(func(filename string) error {
 		c.Check(filename, check.Equals, "/some/1_file.zip")
@@ -32,6 +32,7 @@ import (
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/overlord/snapshotstate/remote"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
@@ -46,6 +47,11 @@ var (
 	backendEstimateSnapshotSize      = backend.EstimateSnapshotSize
 	backendList                      = backend.List
 	backendNewSnapshotExport         = backend.NewSnapshotExport
+	remoteNew                        = remote.New
+
+	// ErrNoRemoteTarget is returned when operating on the remote
+	// snapshot target without one being configured.
+	ErrNoRemoteTarget = errors.New("no remote snapshot target configured")
 
 	// Default expiration time for automatic snapshots, if not set by the user
 	defaultAutomaticSnapshotExpiration = time.Hour * 24 * 31
@@ -53,6 +59,12 @@ var (
 
 type snapshotState struct {
 	ExpiryTime time.Time `json:"expiry-time"`
+	// Scheduled is set for the snapshot sets taken according to
+	// snapshots.schedule
+	Scheduled bool `json:"scheduled,omitempty"`
+	// PreRefresh is set for the automatic snapshot sets taken before
+	// refreshing a snap, that a revert can restore
+	PreRefresh bool `json:"pre-refresh,omitempty"`
 }
 
 func newSnapshotSetID(st *state.State) (uint64, error) {
@@ -151,9 +163,150 @@ func AutomaticSnapshotExpiration(st *state.State) (time.Duration, error) {
 	return defaultAutomaticSnapshotExpiration, nil
 }
 
+// incrementalSnapshots returns whether snapshots should be saved
+// incrementally, i.e. using the shared chunk store of the backend. This does
+// not apply to encrypted snapshots.
+func incrementalSnapshots(st *state.State) (bool, error) {
+	var incremental bool
+	tr := config.NewTransaction(st)
+	err := tr.Get("core", "snapshots.incremental", &incremental)
+	if err != nil && !config.IsNoOption(err) {
+		return false, err
+	}
+	return incremental, nil
+}
+
+// snapshotEncryption returns how new snapshots should be encrypted: either
+// not at all (""), or with the key held by the device.
+func snapshotEncryption(st *state.State) (string, error) {
+	var encryption string
+	tr := config.NewTransaction(st)
+	err := tr.Get("core", "snapshots.encryption", &encryption)
+	if err != nil && !config.IsNoOption(err) {
+		return "", err
+	}
+	if encryption == "none" {
+		encryption = ""
+	}
+	return encryption, nil
+}
+
+// remoteTarget returns the configured remote snapshot target, if any.
+func remoteTarget(st *state.State) (remote.Target, error) {
+	var target string
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "snapshots.remote.target", &target); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	if target == "" {
+		return nil, ErrNoRemoteTarget
+	}
+	var opts remote.Options
+	for key, val := range map[string]*string{
+		"snapshots.remote.access-key-id":     &opts.AccessKeyID,
+		"snapshots.remote.secret-access-key": &opts.SecretAccessKey,
+		"snapshots.remote.region":            &opts.Region,
+	} {
+		if err := tr.Get("core", key, val); err != nil && !config.IsNoOption(err) {
+			return nil, err
+		}
+	}
+	return remoteNew(target, &opts)
+}
+
+// SetPassphrase makes the passphrase used to encrypt or decrypt the given
+// snapshot set available to the snapshot tasks operating on it, and records
+// in the tasks of the given task set that they require it. The passphrase is
+// only kept in memory, until no change operates on the set anymore, so the
+// tasks fail rather than proceed without it after a restart.
+// Note that the state must be locked by the caller.
+func SetPassphrase(st *state.State, setID uint64, passphrase string, ts *state.TaskSet) {
+	var passphrases map[uint64]string
+	if val := st.Cached("snapshot-passphrases"); val != nil {
+		passphrases, _ = val.(map[uint64]string)
+	} else {
+		passphrases = make(map[uint64]string)
+	}
+	passphrases[setID] = passphrase
+	st.Cache("snapshot-passphrases", passphrases)
+
+	for _, task := range ts.Tasks() {
+		var snapshot snapshotSetup
+		if err := task.Get("snapshot-setup", &snapshot); err != nil || snapshot.SetID != setID {
+			continue
+		}
+		snapshot.Encryption = backend.EncryptionPassphrase
+		task.Set("snapshot-setup", &snapshot)
+	}
+}
+
+// snapshotPassphrase returns the passphrase set for the given snapshot set,
+// if any. The state must be locked by the caller.
+func snapshotPassphrase(st *state.State, setID uint64) string {
+	if val := st.Cached("snapshot-passphrases"); val != nil {
+		passphrases, _ := val.(map[uint64]string)
+		return passphrases[setID]
+	}
+	return ""
+}
+
+// setupPassphrase returns the passphrase for the snapshot set the given
+// setup operates on, if any. If the setup requires a passphrase which is not
+// available anymore, for example because snapd was restarted, an error is
+// returned. The state must be locked by the caller.
+func setupPassphrase(st *state.State, snapshot *snapshotSetup) (string, error) {
+	passphrase := snapshotPassphrase(st, snapshot.SetID)
+	if passphrase == "" && snapshot.Encryption == backend.EncryptionPassphrase {
+		return "", fmt.Errorf("cannot use snapshot set #%d: the passphrase given for it is no longer available, please retry", snapshot.SetID)
+	}
+	return passphrase, nil
+}
+
+// forgetUnusedPassphrases drops the passphrases of snapshot sets no change
+// operates on anymore. The state must be locked by the caller.
+func forgetUnusedPassphrases(st *state.State) {
+	val := st.Cached("snapshot-passphrases")
+	if val == nil {
+		return
+	}
+	passphrases, _ := val.(map[uint64]string)
+	inUse := make(map[uint64]bool, len(passphrases))
+	for _, task := range st.Tasks() {
+		if chg := task.Change(); chg != nil && chg.IsReady() {
+			continue
+		}
+		switch task.Kind() {
+		case "save-snapshot", "check-snapshot", "restore-snapshot":
+		default:
+			continue
+		}
+		var snapshot snapshotSetup
+		if err := task.Get("snapshot-setup", &snapshot); err != nil {
+			continue
+		}
+		inUse[snapshot.SetID] = true
+	}
+	for setID := range passphrases {
+		if !inUse[setID] {
+			delete(passphrases, setID)
+		}
+	}
+	if len(passphrases) == 0 {
+		st.Cache("snapshot-passphrases", nil)
+	}
+}
+
 // saveExpiration saves expiration date of the given snapshot set, in the state.
 // The state needs to be locked by the caller.
 func saveExpiration(st *state.State, setID uint64, expiryTime time.Time) error {
+	return saveSnapshotState(st, setID, &snapshotState{
+		ExpiryTime: expiryTime,
+	})
+}
+
+// saveSnapshotState saves the state of the given snapshot set. The state
+// needs to be locked by the caller.
+func saveSnapshotState(st *state.State, setID uint64, sstate *snapshotState) error {
 	var snapshots map[uint64]*json.RawMessage
 	err := st.Get("snapshots", &snapshots)
 	if err != nil && !errors.Is(err, state.ErrNoState) {
@@ -162,9 +315,7 @@ func saveExpiration(st *state.State, setID uint64, expiryTime time.Time) error {
 	if snapshots == nil {
 		snapshots = make(map[uint64]*json.RawMessage)
 	}
-	data, err := json.Marshal(&snapshotState{
-		ExpiryTime: expiryTime,
-	})
+	data, err := json.Marshal(sstate)
 	if err != nil {
 		return err
 	}
@@ -207,7 +358,8 @@ func expiredSnapshotSets(st *state.State, cutoffTime time.Time) (map[uint64]bool
 
 	expired := make(map[uint64]bool)
 	for setID, snapshotSet := range snapshots {
-		if snapshotSet.ExpiryTime.Before(cutoffTime) {
+		// scheduled snapshot sets have no expiry time
+		if !snapshotSet.ExpiryTime.IsZero() && snapshotSet.ExpiryTime.Before(cutoffTime) {
 			expired[setID] = true
 		}
 	}
@@ -335,8 +487,9 @@ func List(ctx context.Context, st *state.State, setID uint64, snapNames []string
 	return sets, nil
 }
 
-// Import a given snapshot ID from an exported snapshot
-func Import(ctx context.Context, st *state.State, r io.Reader) (setID uint64, snapNames []string, err error) {
+// Import a given snapshot ID from an exported snapshot. The passphrase is
+// only needed for snapshots protected by one.
+func Import(ctx context.Context, st *state.State, r io.Reader, passphrase string) (setID uint64, snapNames []string, err error) {
 	st.Lock()
 	setID, err = newSnapshotSetID(st)
 	// note, this is a new set id which is not exposed yet, no need to mark it
@@ -347,7 +500,11 @@ func Import(ctx context.Context, st *state.State, r io.Reader) (setID uint64, sn
 		return 0, nil, err
 	}
 
-	snapNames, err = backendImport(ctx, setID, r, nil)
+	var flags *backend.ImportFlags
+	if passphrase != "" {
+		flags = &backend.ImportFlags{Passphrase: passphrase}
+	}
+	snapNames, err = backendImport(ctx, setID, r, flags)
 	if err != nil {
 		if dupErr, ok := err.(backend.DuplicatedSnapshotImportError); ok {
 			st.Lock()
@@ -356,7 +513,7 @@ func Import(ctx context.Context, st *state.State, r io.Reader) (setID uint64, sn
 			if err := checkSnapshotConflict(st, dupErr.SetID, "forget-snapshot"); err != nil {
 				// we found an existing snapshot but it's being forgotten, so
 				// retry the import without checking for existing snapshot.
-				flags := &backend.ImportFlags{NoDuplicatedImportCheck: true}
+				flags := &backend.ImportFlags{NoDuplicatedImportCheck: true, Passphrase: passphrase}
 				st.Unlock()
 				snapNames, err = backendImport(ctx, setID, r, flags)
 				st.Lock()
@@ -381,6 +538,10 @@ func Import(ctx context.Context, st *state.State, r io.Reader) (setID uint64, sn
 // Save creates a taskset for taking snapshots of snaps' data.
 // Note that the state must be locked by the caller.
 func Save(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions) (setID uint64, snapsSaved []string, ts *state.TaskSet, err error) {
+	return save(st, instanceNames, users, options, false)
+}
+
+func save(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions, scheduled bool) (setID uint64, snapsSaved []string, ts *state.TaskSet, err error) {
 	if len(instanceNames) == 0 {
 		instanceNames, err = allActiveSnapNames(st)
 		if err != nil {
@@ -416,10 +577,11 @@ func Save(st *state.State, instanceNames []string, users []string, options map[s
 		task := st.NewTask("save-snapshot", desc)
 
 		snapshot := snapshotSetup{
-			SetID:   setID,
-			Snap:    name,
-			Users:   users,
-			Options: options[name],
+			SetID:     setID,
+			Snap:      name,
+			Users:     users,
+			Options:   options[name],
+			Scheduled: scheduled,
 		}
 
 		task.Set("snapshot-setup", &snapshot)
@@ -467,9 +629,91 @@ func AutomaticSnapshot(st *state.State, snapName string) (ts *state.TaskSet, err
 	return ts, nil
 }
 
+// preRefreshSnapshotsEnabled returns whether snapshots.pre-refresh asks for
+// the data of the given snap to be snapshotted before refreshing it.
+func preRefreshSnapshotsEnabled(st *state.State, snapName string) (bool, error) {
+	var names string
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "snapshots.pre-refresh", &names); err != nil && !config.IsNoOption(err) {
+		return false, err
+	}
+	return strutil.ListContains(strutil.CommaSeparatedList(names), snapName), nil
+}
+
+// PreRefreshSnapshot returns a taskset taking an automatic snapshot of the
+// data of the given snap before it is refreshed, if requested or if the
+// snap is listed in snapshots.pre-refresh; otherwise it returns
+// snapstate.ErrNothingToDo.
+func PreRefreshSnapshot(st *state.State, snapName string, requested bool) (ts *state.TaskSet, err error) {
+	if !requested {
+		enabled, err := preRefreshSnapshotsEnabled(st, snapName)
+		if err != nil {
+			return nil, err
+		}
+		if !enabled {
+			return nil, snapstate.ErrNothingToDo
+		}
+	}
+	setID, err := newSnapshotSetID(st)
+	if err != nil {
+		return nil, err
+	}
+
+	ts = state.NewTaskSet()
+	desc := fmt.Sprintf("Save data of snap %q in automatic snapshot set #%d before refreshing it", snapName, setID)
+	task := st.NewTask("save-snapshot", desc)
+	snapshot := snapshotSetup{
+		SetID:      setID,
+		Snap:       snapName,
+		Auto:       true,
+		PreRefresh: true,
+	}
+	task.Set("snapshot-setup", &snapshot)
+	ts.AddTask(task)
+
+	return ts, nil
+}
+
+// RestorePreRefreshSnapshot returns a taskset restoring the data of the
+// given snap from the latest snapshot taken before refreshing it from the
+// given revision. Note that the state must be locked by the caller.
+func RestorePreRefreshSnapshot(st *state.State, snapName string, rev snap.Revision) (ts *state.TaskSet, err error) {
+	var snapshots map[uint64]*snapshotState
+	if err := st.Get("snapshots", &snapshots); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+
+	var setID uint64
+	err = backendIter(context.TODO(), func(r *backend.Reader) error {
+		if r.Snap != snapName || r.Revision != rev || r.SetID <= setID {
+			return nil
+		}
+		if sstate := snapshots[r.SetID]; sstate != nil && sstate.PreRefresh {
+			setID = r.SetID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if setID == 0 {
+		return nil, fmt.Errorf("cannot find a snapshot of snap %q taken before refreshing from revision %s", snapName, rev)
+	}
+
+	_, ts, err = restore(st, setID, []string{snapName}, nil, rev)
+	return ts, err
+}
+
 // Restore creates a taskset for restoring a snapshot's data.
 // Note that the state must be locked by the caller.
 func Restore(st *state.State, setID uint64, snapNames []string, users []string) (snapsFound []string, ts *state.TaskSet, err error) {
+	return restore(st, setID, snapNames, users, snap.R(0))
+}
+
+// restore creates a taskset for restoring a snapshot's data. If given, rev
+// is the revision the snaps will be at by the time their data is restored,
+// and the snapshot is expected to be of that very revision.
+func restore(st *state.State, setID uint64, snapNames []string, users []string, rev snap.Revision) (snapsFound []string, ts *state.TaskSet, err error) {
 	summaries, err := snapSummariesInSnapshotSet(setID, snapNames)
 	if err != nil {
 		return nil, nil, err
@@ -493,8 +737,8 @@ func Restore(st *state.State, setID uint64, snapNames []string, users []string)
 	ts = state.NewTaskSet()
 
 	for _, summary := range summaries {
-		var current snap.Revision
-		if snapst, ok := all[summary.snap]; ok {
+		current := rev
+		if snapst, ok := all[summary.snap]; ok && rev.Unset() {
 			info, err := snapst.CurrentInfo()
 			if err != nil {
 				// how?
@@ -569,12 +813,70 @@ func Check(st *state.State, setID uint64, snapNames []string, users []string) (s
 	return summaries.snapNames(), ts, nil
 }
 
+// Push creates a taskset for pushing an export of a snapshot set to the
+// configured remote target.
+// Note that the state must be locked by the caller.
+func Push(st *state.State, setID uint64) (snapsFound []string, ts *state.TaskSet, err error) {
+	if _, err := remoteTarget(st); err != nil {
+		return nil, nil, err
+	}
+
+	// push needs to conflict with forget of itself
+	if err := checkSnapshotConflict(st, setID, "forget-snapshot"); err != nil {
+		return nil, nil, err
+	}
+
+	summaries, err := snapSummariesInSnapshotSet(setID, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	desc := fmt.Sprintf("Push snapshot set #%d to remote target", setID)
+	task := st.NewTask("push-snapshot", desc)
+	task.Set("snapshot-setup", &snapshotSetup{SetID: setID})
+
+	return summaries.snapNames(), state.NewTaskSet(task), nil
+}
+
+// RemoteList lists the snapshot set exports on the configured remote
+// target.
+// Note that the state must be locked by the caller.
+func RemoteList(ctx context.Context, st *state.State) ([]client.RemoteSnapshot, error) {
+	target, err := remoteTarget(st)
+	if err != nil {
+		return nil, err
+	}
+	// listing might be slow, don't hold the lock meanwhile
+	st.Unlock()
+	defer st.Lock()
+	return target.List(ctx)
+}
+
+// RemoteImport imports the snapshot set export with the given name from the
+// configured remote target, see Import.
+func RemoteImport(ctx context.Context, st *state.State, name string, passphrase string) (setID uint64, snapNames []string, err error) {
+	st.Lock()
+	target, err := remoteTarget(st)
+	st.Unlock()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	r, err := target.Fetch(ctx, name)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer r.Close()
+
+	return Import(ctx, st, r, passphrase)
+}
+
 // Forget creates a taskset for deletinig a snapshot.
 // Note that the state must be locked by the caller.
 func Forget(st *state.State, setID uint64, snapNames []string) (snapsFound []string, ts *state.TaskSet, err error) {
-	// forget needs to conflict with check, restore, import and export.
+	// forget needs to conflict with check, restore, import, export and push.
 	if err := checkSnapshotConflict(st, setID, "export-snapshot",
-		"check-snapshot", "restore-snapshot"); err != nil {
+		"check-snapshot", "restore-snapshot", "push-snapshot"); err != nil {
 		return nil, nil, err
 	}
 
@@ -76,6 +76,11 @@ func (s *snapshotSuite) SetUpTest(c *check.C) {
 	snapstate.EnforcedValidationSets = func(st *state.State, extraVss ...*asserts.ValidationSet) (*snapasserts.ValidationSets, error) {
 		return nil, nil
 	}
+
+	// Ensure prunes chunks in the background, keep it off the test dirs
+	s.AddCleanup(snapshotstate.MockBackendPruneChunks(func(context.Context) (int, error) {
+		return 0, nil
+	}))
 }
 
 func (s *snapshotSuite) TearDownTest(c *check.C) {
@@ -1261,7 +1266,7 @@ func testRestoreIntegration(c *check.C, snapDataDir string, opts *dirs.SnapDirOp
 			c.Assert(os.MkdirAll(filepath.Join(home, snapDataDir, name, "common", "common-"+name), 0755), check.IsNil)
 		}
 
-		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user", "b-user"}, nil, opts)
+		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user", "b-user"}, nil, opts, nil)
 		c.Assert(err, check.IsNil)
 	}
 
@@ -1340,7 +1345,7 @@ func (snapshotSuite) TestRestoreIntegrationFails(c *check.C) {
 		c.Assert(os.MkdirAll(filepath.Join(homedir, "snap", name, fmt.Sprint(i+1), "canary-"+name), 0755), check.IsNil)
 		c.Assert(os.MkdirAll(filepath.Join(homedir, "snap", name, "common", "common-"+name), 0755), check.IsNil)
 
-		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user"}, nil, nil)
+		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user"}, nil, nil, nil)
 		c.Assert(err, check.IsNil)
 	}
 
@@ -1748,6 +1753,100 @@ func (snapshotSuite) TestAutomaticSnapshotDefaultUbuntuCore(c *check.C) {
 	c.Assert(du, check.Equals, time.Duration(0))
 }
 
+func (snapshotSuite) TestPreRefreshSnapshot(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	// not requested nor configured
+	_, err := snapshotstate.PreRefreshSnapshot(st, "foo", false)
+	c.Assert(err, check.Equals, snapstate.ErrNothingToDo)
+
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.pre-refresh", "bar,foo")
+	tr.Commit()
+
+	for setID, requested := range []bool{true, false} {
+		ts, err := snapshotstate.PreRefreshSnapshot(st, "foo", requested)
+		c.Assert(err, check.IsNil)
+
+		tasks := ts.Tasks()
+		c.Assert(tasks, check.HasLen, 1)
+		c.Check(tasks[0].Kind(), check.Equals, "save-snapshot")
+		c.Check(tasks[0].Summary(), check.Equals, fmt.Sprintf(`Save data of snap "foo" in automatic snapshot set #%d before refreshing it`, setID+1))
+		var snapshot map[string]interface{}
+		c.Check(tasks[0].Get("snapshot-setup", &snapshot), check.IsNil)
+		c.Check(snapshot, check.DeepEquals, map[string]interface{}{
+			"set-id":      float64(setID + 1),
+			"snap":        "foo",
+			"current":     "unset",
+			"auto":        true,
+			"pre-refresh": true,
+		})
+	}
+
+	_, err = snapshotstate.PreRefreshSnapshot(st, "baz", false)
+	c.Assert(err, check.Equals, snapstate.ErrNothingToDo)
+}
+
+func (snapshotSuite) TestRestorePreRefreshSnapshot(c *check.C) {
+	dir := c.MkDir()
+	shots := []client.Snapshot{
+		{SetID: 1, Snap: "a-snap", Revision: snap.R(1)},
+		{SetID: 2, Snap: "a-snap", Revision: snap.R(1)},
+		{SetID: 3, Snap: "b-snap", Revision: snap.R(1)},
+		// not taken before a refresh
+		{SetID: 4, Snap: "a-snap", Revision: snap.R(1)},
+		{SetID: 5, Snap: "a-snap", Revision: snap.R(2)},
+	}
+	defer snapshotstate.MockBackendIter(func(_ context.Context, f func(*backend.Reader) error) error {
+		for _, sh := range shots {
+			shotfile, err := os.Create(filepath.Join(dir, fmt.Sprintf("%d_%s.zip", sh.SetID, sh.Snap)))
+			c.Assert(err, check.IsNil)
+			err = f(&backend.Reader{Snapshot: sh, File: shotfile})
+			shotfile.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	st.Set("snapshots", map[uint64]interface{}{
+		1: map[string]interface{}{"expiry-time": "2037-02-12T12:50:00Z", "pre-refresh": true},
+		2: map[string]interface{}{"expiry-time": "2037-02-12T12:50:00Z", "pre-refresh": true},
+		3: map[string]interface{}{"expiry-time": "2037-02-12T12:50:00Z", "pre-refresh": true},
+		4: map[string]interface{}{"expiry-time": "2037-02-12T12:50:00Z"},
+		5: map[string]interface{}{"expiry-time": "2037-02-12T12:50:00Z", "pre-refresh": true},
+	})
+
+	ts, err := snapshotstate.RestorePreRefreshSnapshot(st, "a-snap", snap.R(1))
+	c.Assert(err, check.IsNil)
+	tasks := ts.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	c.Check(tasks[0].Kind(), check.Equals, "restore-snapshot")
+	c.Check(tasks[0].Summary(), check.Equals, `Restore data of snap "a-snap" from snapshot set #2`)
+	var snapshot map[string]interface{}
+	c.Check(tasks[0].Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot, check.DeepEquals, map[string]interface{}{
+		"set-id":   2.,
+		"snap":     "a-snap",
+		"filename": filepath.Join(dir, "2_a-snap.zip"),
+		// the revision reverted to
+		"current": "1",
+	})
+	c.Check(tasks[1].Kind(), check.Equals, "cleanup-after-restore")
+
+	_, err = snapshotstate.RestorePreRefreshSnapshot(st, "a-snap", snap.R(3))
+	c.Assert(err, check.ErrorMatches, `cannot find a snapshot of snap "a-snap" taken before refreshing from revision 3`)
+	_, err = snapshotstate.RestorePreRefreshSnapshot(st, "c-snap", snap.R(1))
+	c.Assert(err, check.ErrorMatches, `cannot find a snapshot of snap "c-snap" taken before refreshing from revision 1`)
+}
+
 func (snapshotSuite) TestListError(c *check.C) {
 	restore := snapshotstate.MockBackendList(func(context.Context, uint64, []string) ([]client.SnapshotSet, error) {
 		return nil, fmt.Errorf("boom")
@@ -1850,12 +1949,26 @@ func (snapshotSuite) TestImportSnapshotHappy(c *check.C) {
 	})
 	defer restore()
 
-	sid, names, err := snapshotstate.Import(context.TODO(), st, buf)
+	sid, names, err := snapshotstate.Import(context.TODO(), st, buf, "")
 	c.Assert(err, check.IsNil)
 	c.Check(sid, check.Equals, uint64(1))
 	c.Check(names, check.DeepEquals, fakeSnapNames)
 }
 
+func (snapshotSuite) TestImportSnapshotPassphrase(c *check.C) {
+	st := state.New(nil)
+
+	restore := snapshotstate.MockBackendImport(func(ctx context.Context, id uint64, r io.Reader, flags *backend.ImportFlags) ([]string, error) {
+		c.Check(flags, check.DeepEquals, &backend.ImportFlags{Passphrase: "sekrit"})
+		return []string{"foo"}, nil
+	})
+	defer restore()
+
+	_, names, err := snapshotstate.Import(context.TODO(), st, bytes.NewBufferString(""), "sekrit")
+	c.Assert(err, check.IsNil)
+	c.Check(names, check.DeepEquals, []string{"foo"})
+}
+
 func (snapshotSuite) TestImportSnapshotImportError(c *check.C) {
 	st := state.New(nil)
 
@@ -1865,7 +1978,7 @@ func (snapshotSuite) TestImportSnapshotImportError(c *check.C) {
 	defer restore()
 
 	r := bytes.NewBufferString("faked-import-data")
-	sid, _, err := snapshotstate.Import(context.TODO(), st, r)
+	sid, _, err := snapshotstate.Import(context.TODO(), st, r, "")
 	c.Assert(err, check.NotNil)
 	c.Assert(err.Error(), check.Equals, "some-error")
 	c.Check(sid, check.Equals, uint64(0))
@@ -1886,7 +1999,7 @@ func (snapshotSuite) TestImportSnapshotDuplicate(c *check.C) {
 	})
 	st.Unlock()
 
-	sid, snapNames, err := snapshotstate.Import(context.TODO(), st, bytes.NewBufferString(""))
+	sid, snapNames, err := snapshotstate.Import(context.TODO(), st, bytes.NewBufferString(""), "")
 	c.Assert(err, check.IsNil)
 	c.Check(sid, check.Equals, uint64(3))
 	c.Check(snapNames, check.DeepEquals, []string{"foo-snap"})
@@ -2020,7 +2133,7 @@ func (snapshotSuite) TestImportSnapshotDuplicatedNoConflict(c *check.C) {
 	defer restore()
 
 	st := state.New(nil)
-	setID, snaps, err := snapshotstate.Import(context.TODO(), st, buf)
+	setID, snaps, err := snapshotstate.Import(context.TODO(), st, buf, "")
 	c.Check(importCalls, check.Equals, 1)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(42))
@@ -2060,7 +2173,7 @@ func (snapshotSuite) TestImportSnapshotConflictsWithForget(c *check.C) {
 	chg.AddTask(tsk)
 
 	st.Unlock()
-	setID, snaps, err := snapshotstate.Import(context.TODO(), st, buf)
+	setID, snaps, err := snapshotstate.Import(context.TODO(), st, buf, "")
 	st.Lock()
 	c.Check(importCalls, check.Equals, 2)
 	c.Assert(err, check.IsNil)
@@ -2115,3 +2228,115 @@ func (snapshotSuite) TestSetSnapshotOpInProgress(c *check.C) {
 	val = st.Cached("snapshot-ops")
 	c.Check(val, check.HasLen, 0)
 }
+
+func (snapshotSuite) TestPushNoRemoteTarget(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, _, err := snapshotstate.Push(st, 42)
+	c.Check(err, check.Equals, snapshotstate.ErrNoRemoteTarget)
+
+	_, err = snapshotstate.RemoteList(context.TODO(), st)
+	c.Check(err, check.Equals, snapshotstate.ErrNoRemoteTarget)
+}
+
+func (snapshotSuite) TestPushConflictsWithForget(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.remote.target", c.MkDir())
+	tr.Commit()
+
+	chg := st.NewChange("forget-snapshot-change", "...")
+	tsk := st.NewTask("forget-snapshot", "...")
+	tsk.SetStatus(state.DoingStatus)
+	tsk.Set("snapshot-setup", map[string]int{"set-id": 42})
+	chg.AddTask(tsk)
+
+	_, _, err := snapshotstate.Push(st, 42)
+	c.Assert(err, check.ErrorMatches, `cannot operate on snapshot set #42 while change "1" is in progress`)
+}
+
+func (snapshotSuite) TestForgetConflictsWithPush(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("push-snapshot-change", "...")
+	tsk := st.NewTask("push-snapshot", "...")
+	tsk.SetStatus(state.DoingStatus)
+	tsk.Set("snapshot-setup", map[string]int{"set-id": 42})
+	chg.AddTask(tsk)
+
+	_, _, err := snapshotstate.Forget(st, 42, nil)
+	c.Assert(err, check.ErrorMatches, `cannot operate on snapshot set #42 while change "1" is in progress`)
+}
+
+func (snapshotSuite) TestPushAndRemoteImportIntegration(c *check.C) {
+	o := overlord.Mock()
+	st := o.State()
+	shmgr := snapshotstate.Manager(st, o.TaskRunner())
+	o.AddManager(shmgr)
+	o.AddManager(o.TaskRunner())
+
+	remoteDir := filepath.Join(c.MkDir(), "remote")
+
+	st.Lock()
+	defer st.Unlock()
+
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.remote.target", "file://"+remoteDir)
+	tr.Commit()
+
+	sideInfo := &snap.SideInfo{RealName: "one-snap", Revision: snap.R(1)}
+	snapInfo := snaptest.MockSnap(c, "{name: one-snap, version: v1}", sideInfo)
+	c.Assert(os.MkdirAll(snapInfo.DataDir(), 0755), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(snapInfo.DataDir(), "canary"), []byte("tweet"), 0644), check.IsNil)
+	shw, err := backend.Save(context.TODO(), 42, snapInfo, nil, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	found, ts, err := snapshotstate.Push(st, 42)
+	c.Assert(err, check.IsNil)
+	c.Check(found, check.DeepEquals, []string{"one-snap"})
+	chg := st.NewChange("push-snapshot", "...")
+	chg.AddAll(ts)
+
+	st.Unlock()
+	c.Assert(o.Settle(5*time.Second), check.IsNil)
+	st.Lock()
+	c.Assert(chg.Err(), check.IsNil)
+
+	var name string
+	c.Assert(chg.Tasks()[0].Get("remote-name", &name), check.IsNil)
+	c.Check(name, check.Matches, `42_[0-9]{8}T[0-9]{6}Z\.snapshot`)
+	c.Check(filepath.Join(remoteDir, name), testutil.FilePresent)
+
+	exports, err := snapshotstate.RemoteList(context.TODO(), st)
+	c.Assert(err, check.IsNil)
+	c.Assert(exports, check.HasLen, 1)
+	c.Check(exports[0].Name, check.Equals, name)
+
+	// drop the local copy and bring it back from the remote
+	c.Assert(os.Remove(backend.Filename(shw)), check.IsNil)
+
+	st.Unlock()
+	setID, snapNames, err := snapshotstate.RemoteImport(context.TODO(), st, name, "")
+	st.Lock()
+	c.Assert(err, check.IsNil)
+	// the original set is gone, so its ID is reused
+	c.Check(setID, check.Equals, uint64(1))
+	c.Check(snapNames, check.DeepEquals, []string{"one-snap"})
+
+	sets, err := snapshotstate.List(context.TODO(), st, setID, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(sets, check.HasLen, 1)
+	c.Check(sets[0].Snapshots[0].SHA3_384, check.DeepEquals, shw.SHA3_384)
+
+	st.Unlock()
+	_, _, err = snapshotstate.RemoteImport(context.TODO(), st, "7_x.snapshot", "")
+	st.Lock()
+	c.Check(err, check.ErrorMatches, "snapshot export not found on remote target")
+}
@@ -130,6 +130,9 @@ type autoRefresh struct {
 	lastRefreshSchedule string
 	nextRefresh         time.Time
 	lastRefreshAttempt  time.Time
+	// lastWindowRefresh is when the snaps with an open refresh window
+	// were last auto-refreshed
+	lastWindowRefresh time.Time
 
 	restoredMonitoring bool
 }
@@ -395,7 +398,7 @@ func (m *autoRefresh) Ensure() (err error) {
 				return nil
 			}
 
-			err = m.launchAutoRefresh()
+			err = m.launchAutoRefresh(false)
 			if _, ok := err.(*httputil.PersistentNetworkError); ok {
 				// refresh will be retried after refreshRetryDelay
 				return err
@@ -406,9 +409,42 @@ func (m *autoRefresh) Ensure() (err error) {
 
 			// refreshed or hit an non-persistent network error, so reset nextRefresh
 			m.nextRefresh = time.Time{}
+			// this also covered the snaps with an open refresh window
+			m.lastWindowRefresh = now
+			return err
 		}
+
+		return m.maybeLaunchWindowRefresh(now, lastRefresh)
+	}
+
+	return err
+}
+
+// maybeLaunchWindowRefresh launches an auto-refresh of the snaps with a
+// refresh window if one of the windows opened since the last one.
+func (m *autoRefresh) maybeLaunchWindowRefresh(now, lastRefresh time.Time) error {
+	windows, err := refreshWindows(m.state)
+	if err != nil {
+		return err
+	}
+	if !windowRefreshDue(windows, m.lastWindowRefresh, now) {
+		return nil
 	}
 
+	can, err := m.canRefreshRespectingMetered(now, lastRefresh)
+	if err != nil || !can {
+		return err
+	}
+
+	err = m.launchAutoRefresh(true)
+	if _, ok := err.(*httputil.PersistentNetworkError); ok {
+		// refresh will be retried after refreshRetryDelay
+		return err
+	} else if errors.Is(err, tooSoonError{}) {
+		// ignore error, retry the auto-refresh later
+		return nil
+	}
+	m.lastWindowRefresh = now
 	return err
 }
 
@@ -580,8 +616,9 @@ func (tooSoonError) Is(err error) bool {
 	return ok
 }
 
-// launchAutoRefresh creates the auto-refresh taskset and a change for it.
-func (m *autoRefresh) launchAutoRefresh() error {
+// launchAutoRefresh starts an auto-refresh, of the snaps with an open
+// refresh window only if fromWindow is set.
+func (m *autoRefresh) launchAutoRefresh(fromWindow bool) error {
 	// Check that we have reasonable delays between attempts.
 	// If the store is under stress we need to make sure we do not
 	// hammer it too often
@@ -600,7 +637,7 @@ func (m *autoRefresh) launchAutoRefresh() error {
 	}()
 
 	// NOTE: this will unlock and re-lock state for network ops
-	updated, updateTss, err := AutoRefresh(auth.EnsureContextTODO(), m.state)
+	updated, updateTss, err := autoRefreshSnaps(auth.EnsureContextTODO(), m.state, fromWindow)
 
 	// TODO: we should have some way to lock just creating and starting changes,
 	//       as that would alleviate this race condition we are guarding against
@@ -625,7 +662,10 @@ func (m *autoRefresh) launchAutoRefresh() error {
 		logger.Noticef("Cannot prepare auto-refresh change due to a permanent network error: %s", err)
 		return err
 	}
-	m.state.Set("last-refresh", timeNow())
+	if !fromWindow {
+		// refresh.timer is based on the refreshes it started
+		m.state.Set("last-refresh", timeNow())
+	}
 	if err != nil {
 		logger.Noticef("Cannot prepare auto-refresh change: %s", err)
 		return err
@@ -799,6 +839,13 @@ func inhibitRefresh(st *state.State, snapst *SnapState, snapsup *SnapSetup, info
 		return false, checkerErr
 	}
 
+	// a snap reporting an error is not left running while a new revision
+	// that might fix it is available
+	if IsHealthError != nil && IsHealthError(st, info.InstanceName(), info.Revision) {
+		logger.Noticef("snap %q health is in error, not postponing its auto-refresh", info.InstanceName())
+		return false, nil
+	}
+
 	// Decide on what to do depending on the state of the snap and the remaining
 	// inhibition time.
 	now := time.Now()
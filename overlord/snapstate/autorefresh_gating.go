@@ -486,6 +486,27 @@ func HeldSnaps(st *state.State, level HoldLevel) (map[string][]string, error) {
 	return held, nil
 }
 
+// phasedAutoRefreshAllowed returns whether the device is in the part of its
+// fleet that is currently allowed to auto-refresh, as set by the refresh
+// phasing policy of the device administrator. Auto-refreshes are not phased
+// unless there is such a policy.
+func phasedAutoRefreshAllowed(st *state.State) (bool, error) {
+	percentage, phased := refreshPhasingPercentage()
+	if !phased {
+		return true, nil
+	}
+
+	var serial string
+	if DeviceSerial != nil {
+		var err error
+		serial, err = DeviceSerial(st)
+		if err != nil {
+			return false, err
+		}
+	}
+	return deviceRefreshBucket(serial) < percentage, nil
+}
+
 // SystemHold returns the time until which the snap's refreshes have been held
 // by the sysadmin. If no such hold exists, returns a zero time.Time value.
 func SystemHold(st *state.State, snap string) (time.Time, error) {
@@ -810,7 +831,7 @@ func AutoRefreshForGatingSnap(st *state.State, gatingSnap string) error {
 
 	// NOTE: this will unlock and re-lock state for network ops
 	// XXX: should we refresh assertions (just call AutoRefresh()?)
-	updated, tasksets, err := autoRefreshPhase1(auth.EnsureContextTODO(), st, gatingSnap)
+	updated, tasksets, err := autoRefreshPhase1(auth.EnsureContextTODO(), st, gatingSnap, false)
 	if err != nil {
 		return err
 	}
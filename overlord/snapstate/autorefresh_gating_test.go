@@ -1555,7 +1555,7 @@ func (s *autorefreshGatingSuite) TestAutoRefreshPhase1(c *C) {
 			Monitored: true,
 		},
 	})
-	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "")
+	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
 	c.Assert(err, IsNil)
 	c.Check(names, DeepEquals, []string{"base-snap-b", "snap-a", "snap-c", "snap-f"})
 	c.Assert(tss, HasLen, 2)
@@ -1691,7 +1691,7 @@ func (s *autorefreshGatingSuite) TestAffectedByRefreshUsesCurrentSnapInfo(c *C)
 	restore := snapstatetest.MockDeviceModel(DefaultModel())
 	defer restore()
 
-	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "")
+	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
 	c.Assert(err, IsNil)
 	c.Check(names, DeepEquals, []string{"base-snap-b", "snap-b"})
 	c.Assert(tss, HasLen, 2)
@@ -1784,7 +1784,7 @@ func (s *autorefreshGatingSuite) TestAutoRefreshPhase1ConflictsFilteredOut(c *C)
 	logbuf, restoreLogger := logger.MockLogger()
 	defer restoreLogger()
 
-	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "")
+	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
 	c.Assert(err, IsNil)
 	c.Check(names, DeepEquals, []string{"snap-a"})
 	c.Assert(tss, HasLen, 2)
@@ -1853,7 +1853,7 @@ func (s *autorefreshGatingSuite) TestAutoRefreshPhase1NoHooks(c *C) {
 	restore := snapstatetest.MockDeviceModel(DefaultModel())
 	defer restore()
 
-	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "")
+	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
 	c.Assert(err, IsNil)
 	c.Check(names, DeepEquals, []string{"base-snap-b", "snap-c"})
 	c.Assert(tss, HasLen, 1)
@@ -2013,7 +2013,7 @@ func (s *snapmgrTestSuite) testAutoRefreshPhase2(c *C, beforePhase1 func(), gate
 		beforePhase1()
 	}
 
-	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "")
+	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
 	c.Assert(err, IsNil)
 	c.Check(names, DeepEquals, []string{"base-snap-b", "snap-a"})
 
@@ -2310,7 +2310,7 @@ func (s *snapmgrTestSuite) testAutoRefreshPhase2DiskSpaceCheck(c *C, fail bool)
 
 	snapstate.MockSnapReadInfo(fakeReadInfo)
 
-	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "")
+	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
 	c.Assert(err, IsNil)
 	c.Check(names, DeepEquals, []string{"base-snap-b", "snap-a"})
 
@@ -2375,7 +2375,7 @@ func (s *snapmgrTestSuite) TestAutoRefreshPhase2Conflict(c *C) {
 	restore := snapstatetest.MockDeviceModel(DefaultModel())
 	defer restore()
 
-	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "")
+	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
 	c.Assert(err, IsNil)
 	c.Check(names, DeepEquals, []string{"base-snap-b", "snap-a"})
 
@@ -2452,7 +2452,7 @@ func (s *snapmgrTestSuite) TestAutoRefreshPhase2ConflictOtherSnapOp(c *C) {
 	restore := snapstatetest.MockDeviceModel(DefaultModel())
 	defer restore()
 
-	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "")
+	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
 	c.Assert(err, IsNil)
 	c.Check(names, DeepEquals, []string{"snap-a"})
 
@@ -2551,7 +2551,7 @@ func (s *snapmgrTestSuite) TestAutoRefreshPhase2GatedSnaps(c *C) {
 	restoreModel := snapstatetest.MockDeviceModel(DefaultModel())
 	defer restoreModel()
 
-	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "")
+	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
 	c.Assert(err, IsNil)
 	c.Check(names, DeepEquals, []string{"base-snap-b", "snap-a"})
 
@@ -3160,7 +3160,7 @@ func (s *validationSetsSuite) TestAutoRefreshPhase1WithValidationSets(c *C) {
 
 	refreshedDate := fakeRevDateEpoch.AddDate(0, 0, 1)
 	requiredRevision = "1"
-	names, _, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "")
+	names, _, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
 	c.Assert(err, IsNil)
 	// some-snap is already at the required revision 1, so not refreshed
 	c.Check(names, DeepEquals, []string{"snap-c", "some-other-snap"})
@@ -3218,7 +3218,7 @@ func (s *validationSetsSuite) TestAutoRefreshPhase1WithValidationSets(c *C) {
 
 	s.fakeBackend.ops = nil
 	requiredRevision = "11"
-	names, _, err = snapstate.AutoRefreshPhase1(context.TODO(), st, "")
+	names, _, err = snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
 	c.Assert(err, IsNil)
 	c.Check(names, DeepEquals, []string{"snap-c", "some-other-snap", "some-snap"})
 
@@ -3252,3 +3252,201 @@ func (s *validationSetsSuite) TestAutoRefreshPhase1WithValidationSets(c *C) {
 		revno: snap.R(11),
 	})
 }
+
+func (s *autorefreshGatingSuite) TestDeviceRefreshBucket(c *C) {
+	// devices without a serial are the last to refresh
+	c.Check(snapstate.DeviceRefreshBucket(""), Equals, 99)
+
+	buckets := make(map[int]int)
+	for i := 0; i < 1000; i++ {
+		serial := fmt.Sprintf("serial-%d", i)
+		bucket := snapstate.DeviceRefreshBucket(serial)
+		c.Assert(bucket >= 0 && bucket < 100, Equals, true)
+		// stable
+		c.Check(snapstate.DeviceRefreshBucket(serial), Equals, bucket)
+		buckets[bucket]++
+	}
+	// and spread
+	c.Check(len(buckets) > 90, Equals, true)
+}
+
+func writeRefreshPhasingPolicy(c *C, content string, perm os.FileMode) {
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapRefreshPhasingFile), 0755), IsNil)
+	c.Assert(os.WriteFile(dirs.SnapRefreshPhasingFile, []byte(content), perm), IsNil)
+	// not affected by umask
+	c.Assert(os.Chmod(dirs.SnapRefreshPhasingFile, perm), IsNil)
+}
+
+func (s *autorefreshGatingSuite) TestReadRefreshPhasingPolicy(c *C) {
+	restore := snapstate.MockRefreshPhasingPolicyOwner(uint32(os.Getuid()))
+	defer restore()
+
+	// no policy
+	policy, err := snapstate.ReadRefreshPhasingPolicy()
+	c.Assert(err, IsNil)
+	c.Check(policy, IsNil)
+
+	writeRefreshPhasingPolicy(c, `{"percentage": 30}`, 0644)
+	policy, err = snapstate.ReadRefreshPhasingPolicy()
+	c.Assert(err, IsNil)
+	c.Assert(policy, NotNil)
+	c.Check(policy.Percentage, Equals, 30)
+
+	for _, tc := range []struct {
+		content string
+		perm    os.FileMode
+		err     string
+	}{
+		{`{"percentage": 30}`, 0664, `.*/refresh-phasing.json is writable by group or others`},
+		{`{"percentage": 30}`, 0666, `.*/refresh-phasing.json is writable by group or others`},
+		{`{"percentage": 101}`, 0644, `invalid phased refresh percentage 101 in .*: must be between 0 and 100`},
+		{`{"percentage": -1}`, 0644, `invalid phased refresh percentage -1 in .*: must be between 0 and 100`},
+		{`{"percentage": "30"}`, 0644, `cannot decode .*/refresh-phasing.json: .*`},
+	} {
+		writeRefreshPhasingPolicy(c, tc.content, tc.perm)
+		_, err := snapstate.ReadRefreshPhasingPolicy()
+		c.Check(err, ErrorMatches, tc.err, Commentf("%s %v", tc.content, tc.perm))
+	}
+
+	// not owned by the expected owner
+	writeRefreshPhasingPolicy(c, `{"percentage": 30}`, 0644)
+	restore = snapstate.MockRefreshPhasingPolicyOwner(uint32(os.Getuid()) + 1)
+	defer restore()
+	_, err = snapstate.ReadRefreshPhasingPolicy()
+	c.Check(err, ErrorMatches, `.*/refresh-phasing.json is not owned by root`)
+}
+
+func (s *autorefreshGatingSuite) TestAutoRefreshPhase1PhasedUntrustedPolicy(c *C) {
+	s.store.refreshedSnaps = []*snap.Info{{
+		Architectures: []string{"all"},
+		SnapType:      snap.TypeBase,
+		SideInfo: snap.SideInfo{
+			RealName: "snap-c",
+			Revision: snap.R(5),
+		},
+	}}
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	mockInstalledSnap(c, s.state, snapCyaml, noHook)
+
+	restore := snapstatetest.MockDeviceModel(DefaultModel())
+	defer restore()
+
+	restore = snapstate.MockRefreshPhasingPolicyOwner(uint32(os.Getuid()))
+	defer restore()
+
+	// a policy that would hold back every device, but that anyone could
+	// have written, is ignored
+	writeRefreshPhasingPolicy(c, `{"percentage": 0}`, 0666)
+
+	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{"snap-c"})
+	c.Assert(tss, HasLen, 1)
+}
+
+func (s *autorefreshGatingSuite) TestAutoRefreshPhase1Phased(c *C) {
+	s.store.refreshedSnaps = []*snap.Info{{
+		Architectures: []string{"all"},
+		SnapType:      snap.TypeBase,
+		SideInfo: snap.SideInfo{
+			RealName: "snap-c",
+			Revision: snap.R(5),
+		},
+	}}
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	mockInstalledSnap(c, s.state, snapCyaml, noHook)
+
+	restore := snapstatetest.MockDeviceModel(DefaultModel())
+	defer restore()
+
+	serial := "serial-1"
+	bucket := snapstate.DeviceRefreshBucket(serial)
+	oldDeviceSerial := snapstate.DeviceSerial
+	snapstate.DeviceSerial = func(*state.State) (string, error) { return serial, nil }
+	defer func() { snapstate.DeviceSerial = oldDeviceSerial }()
+
+	restore = snapstate.MockRefreshPhasingPolicyOwner(uint32(os.Getuid()))
+	defer restore()
+
+	// the device is not in the part of the fleet allowed to refresh
+	writeRefreshPhasingPolicy(c, fmt.Sprintf(`{"percentage": %d}`, bucket), 0644)
+
+	names, tss, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
+	c.Assert(err, IsNil)
+	c.Check(names, HasLen, 0)
+	c.Check(tss, HasLen, 0)
+
+	// but the refresh is known about
+	var candidates map[string]*snapstate.RefreshCandidate
+	c.Assert(st.Get("refresh-candidates", &candidates), IsNil)
+	c.Check(candidates, HasLen, 1)
+	c.Check(candidates["snap-c"], NotNil)
+
+	// now it is
+	writeRefreshPhasingPolicy(c, fmt.Sprintf(`{"percentage": %d}`, bucket+1), 0644)
+
+	names, tss, err = snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{"snap-c"})
+	c.Assert(tss, HasLen, 1)
+}
+
+func (s *autorefreshGatingSuite) TestAutoRefreshPhase1RefreshWindows(c *C) {
+	s.store.refreshedSnaps = []*snap.Info{{
+		Architectures: []string{"all"},
+		SnapType:      snap.TypeBase,
+		SideInfo: snap.SideInfo{
+			RealName: "base-snap-b",
+			Revision: snap.R(3),
+		},
+	}, {
+		Architectures: []string{"all"},
+		SnapType:      snap.TypeBase,
+		SideInfo: snap.SideInfo{
+			RealName: "snap-c",
+			Revision: snap.R(5),
+		},
+	}}
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	mockInstalledSnap(c, s.state, snapByaml, noHook)
+	mockInstalledSnap(c, s.state, snapCyaml, noHook)
+	mockInstalledSnap(c, s.state, baseSnapByaml, noHook)
+
+	restore := snapstatetest.MockDeviceModel(DefaultModel())
+	defer restore()
+
+	// snap-c can only be refreshed on a day that is not today
+	tr := config.NewTransaction(st)
+	tr.Set("core", "refresh.snap-windows.snap-c", closedRefreshWindow())
+	tr.Commit()
+
+	names, _, err := snapstate.AutoRefreshPhase1(context.TODO(), st, "", false)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{"base-snap-b"})
+
+	// nothing has an open window
+	names, _, err = snapstate.AutoRefreshPhase1(context.TODO(), st, "", true)
+	c.Assert(err, IsNil)
+	c.Check(names, HasLen, 0)
+
+	tr = config.NewTransaction(st)
+	tr.Set("core", "refresh.snap-windows.snap-c", openRefreshWindow)
+	tr.Commit()
+
+	// only the snaps with an open window are refreshed when it opens
+	names, _, err = snapstate.AutoRefreshPhase1(context.TODO(), st, "", true)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{"snap-c"})
+}
@@ -0,0 +1,112 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+)
+
+// phasedRefreshBuckets is how many parts a fleet of devices is split into
+// for phased auto-refreshes.
+const phasedRefreshBuckets = 100
+
+// refreshPhasingPolicyOwner is the uid that must own the refresh phasing
+// policy file for it to be trusted.
+var refreshPhasingPolicyOwner uint32 = 0
+
+// refreshPhasingPolicy is the local policy, written by the device
+// administrator, that phases auto-refreshes across a fleet of devices.
+type refreshPhasingPolicy struct {
+	// Percentage is the part of the fleet that is allowed to auto-refresh.
+	Percentage int `json:"percentage"`
+}
+
+// deviceRefreshBucket returns which of the phased auto-refresh buckets the
+// device with the given serial is in. The buckets are stable, so the same
+// devices are always the first to auto-refresh. Devices without a serial
+// are in the last bucket.
+func deviceRefreshBucket(serial string) int {
+	if serial == "" {
+		return phasedRefreshBuckets - 1
+	}
+	h := sha256.Sum256([]byte(serial))
+	return int(binary.BigEndian.Uint64(h[:8]) % phasedRefreshBuckets)
+}
+
+// readRefreshPhasingPolicy reads the refresh phasing policy file. It returns
+// nil if there is no policy. The file is only trusted if it is a regular file
+// owned by root that no one else can write to, so that unprivileged users
+// cannot hold back the refreshes of a device.
+func readRefreshPhasingPolicy() (*refreshPhasingPolicy, error) {
+	f, err := os.Open(dirs.SnapRefreshPhasingFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", dirs.SnapRefreshPhasingFile)
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); !ok || st.Uid != refreshPhasingPolicyOwner {
+		return nil, fmt.Errorf("%s is not owned by root", dirs.SnapRefreshPhasingFile)
+	}
+	if fi.Mode().Perm()&0022 != 0 {
+		return nil, fmt.Errorf("%s is writable by group or others", dirs.SnapRefreshPhasingFile)
+	}
+
+	var policy refreshPhasingPolicy
+	if err := json.NewDecoder(f).Decode(&policy); err != nil {
+		return nil, fmt.Errorf("cannot decode %s: %v", dirs.SnapRefreshPhasingFile, err)
+	}
+	if policy.Percentage < 0 || policy.Percentage > 100 {
+		return nil, fmt.Errorf("invalid phased refresh percentage %d in %s: must be between 0 and 100", policy.Percentage, dirs.SnapRefreshPhasingFile)
+	}
+	return &policy, nil
+}
+
+// refreshPhasingPercentage returns the part of the fleet that is allowed to
+// auto-refresh, and false if auto-refreshes are not phased. An untrusted or
+// invalid policy is ignored, so that it cannot hold back refreshes.
+func refreshPhasingPercentage() (int, bool) {
+	policy, err := readRefreshPhasingPolicy()
+	if err != nil {
+		logger.Noticef("ignoring refresh phasing policy: %v", err)
+		return 0, false
+	}
+	if policy == nil {
+		return 0, false
+	}
+	return policy.Percentage, true
+}
@@ -1064,6 +1064,49 @@ func (s *autoRefreshTestSuite) TestInhibitRefreshRefreshesWhenOverdue(c *C) {
 	c.Check(notificationCount, Equals, 1)
 }
 
+func (s *autoRefreshTestSuite) TestInhibitRefreshNotPostponedWhenHealthError(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	restore := snapstate.MockAsyncPendingRefreshNotification(func(ctx context.Context, refreshInfo *userclient.PendingSnapRefreshInfo) {
+		c.Fatal("shouldn't trigger pending refresh notification for a snap in error")
+	})
+	defer restore()
+
+	var healthChecked []string
+	oldIsHealthError := snapstate.IsHealthError
+	snapstate.IsHealthError = func(st *state.State, snapName string, rev snap.Revision) bool {
+		healthChecked = append(healthChecked, snapName)
+		return snapName == "pkg" && rev == snap.R(1)
+	}
+	defer func() { snapstate.IsHealthError = oldIsHealthError }()
+
+	si := &snap.SideInfo{RealName: "pkg", Revision: snap.R(1)}
+	info := &snap.Info{SideInfo: *si}
+	snapst := &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  si.Revision,
+	}
+	snapsup := &snapstate.SnapSetup{Flags: snapstate.Flags{IsAutoRefresh: true}}
+
+	restore = snapstate.MockRefreshAppsCheck(func(si *snap.Info) error {
+		return snapstate.NewBusySnapError(si, []int{123}, nil, nil)
+	})
+	defer restore()
+
+	inhibitionTimeout, err := snapstate.InhibitRefresh(s.state, snapst, snapsup, info)
+	c.Assert(err, IsNil)
+	c.Check(inhibitionTimeout, Equals, false)
+	c.Check(healthChecked, DeepEquals, []string{"pkg"})
+	// the inhibition window was not started
+	c.Check(snapst.RefreshInhibitedTime, IsNil)
+
+	// a manual refresh is still told about the running apps
+	snapsup.IsAutoRefresh = false
+	_, err = snapstate.InhibitRefresh(s.state, snapst, snapsup, info)
+	c.Assert(err, ErrorMatches, `snap "pkg" has running apps or hooks, pids: 123`)
+}
+
 func (s *autoRefreshTestSuite) TestInhibitNoNotificationOnManualRefresh(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -1645,3 +1688,126 @@ func (s *autoRefreshTestSuite) TestAutoRefreshWithConfdbs(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(snapsup.Confdbs, DeepEquals, []snapstate.ConfdbID{{Account: "my-publisher", Confdb: "my-reg"}})
 }
+
+// closedRefreshWindow returns a refresh window that is not open today.
+func closedRefreshWindow() string {
+	day := strings.ToLower(time.Now().Add(48 * time.Hour).Weekday().String()[:3])
+	return day + ",00:00-24:00"
+}
+
+const openRefreshWindow = "00:00-24:00"
+
+func (s *autoRefreshTestSuite) TestEnsureRefreshWindowOpens(c *C) {
+	s.addRefreshableSnap("windowed-snap", "other-snap")
+
+	s.state.Lock()
+	// no refresh.timer slot is due
+	lastRefresh := time.Now()
+	s.state.Set("last-refresh", lastRefresh)
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.snap-windows.windowed-snap", closedRefreshWindow())
+	tr.Commit()
+	s.state.Unlock()
+
+	af := snapstate.NewAutoRefresh(s.state)
+	c.Assert(af.Ensure(), IsNil)
+	c.Check(s.store.ops, HasLen, 0)
+
+	s.state.Lock()
+	tr = config.NewTransaction(s.state)
+	tr.Set("core", "refresh.snap-windows.windowed-snap", openRefreshWindow)
+	tr.Commit()
+	s.state.Unlock()
+
+	c.Assert(af.Ensure(), IsNil)
+	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	chgs := s.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	c.Check(chgs[0].Kind(), Equals, "auto-refresh")
+	var snapNames []string
+	c.Assert(chgs[0].Get("snap-names", &snapNames), IsNil)
+	// only the snap with an open window is refreshed
+	c.Check(snapNames, DeepEquals, []string{"windowed-snap"})
+
+	// the refresh.timer schedule is unaffected
+	var storedLastRefresh time.Time
+	c.Assert(s.state.Get("last-refresh", &storedLastRefresh), IsNil)
+	c.Check(storedLastRefresh.Equal(lastRefresh), Equals, true)
+}
+
+func (s *autoRefreshTestSuite) TestEnsureRefreshTimerSkipsClosedWindows(c *C) {
+	s.addRefreshableSnap("windowed-snap", "other-snap")
+
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.snap-windows.windowed-snap", closedRefreshWindow())
+	tr.Commit()
+	s.state.Unlock()
+
+	// immediate refresh.timer refresh
+	af := snapstate.NewAutoRefresh(s.state)
+	c.Assert(af.Ensure(), IsNil)
+	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	chgs := s.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	var snapNames []string
+	c.Assert(chgs[0].Get("snap-names", &snapNames), IsNil)
+	c.Check(snapNames, DeepEquals, []string{"other-snap"})
+}
+
+func (s *autoRefreshTestSuite) TestRefreshWindowsQuotaGroups(c *C) {
+	s.addRefreshableSnap("db-snap", "other-db-snap")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	windows, err := snapstate.RefreshWindows(s.state)
+	c.Assert(err, IsNil)
+	c.Check(windows, HasLen, 0)
+
+	oldSnapQuotaGroup := snapstate.SnapQuotaGroup
+	snapstate.SnapQuotaGroup = func(st *state.State, snapName string) (string, error) {
+		if strings.HasSuffix(snapName, "db-snap") {
+			return "db", nil
+		}
+		return "", nil
+	}
+	defer func() { snapstate.SnapQuotaGroup = oldSnapQuotaGroup }()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.quota-windows.db", "sun,02:00-04:00")
+	// the window of the snap takes precedence
+	tr.Set("core", "refresh.snap-windows.other-db-snap", "sat,10:00-12:00")
+	tr.Commit()
+
+	windows, err = snapstate.RefreshWindows(s.state)
+	c.Assert(err, IsNil)
+	c.Assert(windows, HasLen, 2)
+	sunday := time.Date(2026, 10, 18, 3, 0, 0, 0, time.Local)
+	saturday := time.Date(2026, 10, 17, 11, 0, 0, 0, time.Local)
+	c.Check(timeutil.Includes(windows["db-snap"], sunday), Equals, true)
+	c.Check(timeutil.Includes(windows["db-snap"], saturday), Equals, false)
+	c.Check(timeutil.Includes(windows["other-db-snap"], sunday), Equals, false)
+	c.Check(timeutil.Includes(windows["other-db-snap"], saturday), Equals, true)
+}
+
+func (s *autoRefreshTestSuite) TestWindowRefreshDue(c *C) {
+	schedule, err := timeutil.ParseSchedule("sun,02:00-04:00")
+	c.Assert(err, IsNil)
+	windows := map[string][]*timeutil.Schedule{"db-snap": schedule}
+
+	sunday := time.Date(2026, 10, 18, 2, 30, 0, 0, time.Local)
+	c.Check(snapstate.WindowRefreshDue(windows, time.Time{}, sunday), Equals, true)
+	c.Check(snapstate.WindowRefreshDue(windows, sunday.Add(-25*time.Minute), sunday), Equals, false)
+	c.Check(snapstate.WindowRefreshDue(windows, sunday.Add(-time.Hour), sunday), Equals, true)
+	c.Check(snapstate.WindowRefreshDue(windows, sunday.Add(-7*24*time.Hour), sunday), Equals, true)
+	// closed
+	c.Check(snapstate.WindowRefreshDue(windows, time.Time{}, sunday.Add(2*time.Hour)), Equals, false)
+	c.Check(snapstate.WindowRefreshDue(nil, time.Time{}, sunday), Equals, false)
+}
@@ -197,6 +197,8 @@ type fakeStore struct {
 	snapResourcesFn func(*snap.Info) []store.SnapResourceResult
 
 	downloadCallback func()
+	// called with the checkpointer of downloads that have one
+	downloadCheckpointCallback func(name string, checkpointer store.DownloadCheckpointer)
 
 	namesToAssertedIDs map[string]string
 	idsToNames         map[string]string
@@ -906,6 +908,15 @@ func (f *fakeStore) Download(ctx context.Context, name, targetFn string, snapInf
 	if user != nil {
 		macaroon = user.StoreMacaroon
 	}
+	if dlOpts != nil && dlOpts.Checkpointer != nil {
+		if f.downloadCheckpointCallback != nil {
+			f.downloadCheckpointCallback(name, dlOpts.Checkpointer)
+		}
+		// the checkpointer is not interesting to compare
+		opts := *dlOpts
+		opts.Checkpointer = nil
+		dlOpts = &opts
+	}
 	// only add the options if they contain anything interesting
 	if dlOpts != nil && *dlOpts == (store.DownloadOptions{}) {
 		dlOpts = nil
@@ -61,6 +61,12 @@ var (
 	RemodelingChange func(st *state.State) *state.Change
 )
 
+// Hook setup by devicestate to get the serial of the device, or an empty
+// string if it has none yet.
+var (
+	DeviceSerial func(st *state.State) (string, error)
+)
+
 // ModelFromTask returns a model assertion through the device context for the task.
 func ModelFromTask(task *state.Task) (*asserts.Model, error) {
 	deviceCtx, err := DeviceCtx(task.State(), task, nil)
@@ -0,0 +1,153 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// EnforcedSnapRevisionDigests allows to hook getting the sha3-384 digests of
+// the snap revisions required by the validation sets in enforce mode, so that
+// they are kept in the download cache. It gets hooked from assertstate.
+var EnforcedSnapRevisionDigests func(st *state.State) ([]string, error)
+
+var downloadCacheKeyRegexp = regexp.MustCompile(`^[0-9a-f]{96}$`)
+
+// DownloadCachePolicy returns the policy of the cache of downloaded snaps
+// according to the store.cache.* options, the explicitly pinned entries and
+// the snap revisions required by the enforced validation sets.
+func DownloadCachePolicy(st *state.State) (*store.CachePolicy, error) {
+	tr := config.NewTransaction(st)
+	policy := &store.CachePolicy{}
+
+	maxItems, err := downloadCacheOption(tr, "store.cache.max-items")
+	if err != nil {
+		return nil, err
+	}
+	if maxItems != "" {
+		if policy.MaxItems, err = strconv.Atoi(maxItems); err != nil {
+			return nil, fmt.Errorf("cannot parse store.cache.max-items: %v", err)
+		}
+	}
+	maxSize, err := downloadCacheOption(tr, "store.cache.max-size")
+	if err != nil {
+		return nil, err
+	}
+	if maxSize != "" {
+		if policy.MaxSize, err = strutil.ParseByteSize(maxSize); err != nil {
+			return nil, fmt.Errorf("cannot parse store.cache.max-size: %v", err)
+		}
+	}
+	maxAge, err := downloadCacheOption(tr, "store.cache.max-age")
+	if err != nil {
+		return nil, err
+	}
+	if maxAge != "" {
+		if policy.MaxAge, err = time.ParseDuration(maxAge); err != nil {
+			return nil, fmt.Errorf("cannot parse store.cache.max-age: %v", err)
+		}
+	}
+
+	pins, err := downloadCachePins(st)
+	if err != nil {
+		return nil, err
+	}
+	policy.Pinned = pins
+	if EnforcedSnapRevisionDigests != nil {
+		digests, err := EnforcedSnapRevisionDigests(st)
+		if err != nil {
+			// keep what is pinned explicitly, the limits still apply
+			logger.Noticef("cannot get the snap revisions required by validation sets: %v", err)
+		}
+		for _, digest := range digests {
+			if !strutil.ListContains(policy.Pinned, digest) {
+				policy.Pinned = append(policy.Pinned, digest)
+			}
+		}
+	}
+
+	return policy, nil
+}
+
+func downloadCacheOption(tr *config.Transaction, key string) (string, error) {
+	var value interface{}
+	if err := tr.GetMaybe("core", key, &value); err != nil {
+		return "", err
+	}
+	if value == nil {
+		return "", nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
+func downloadCachePins(st *state.State) ([]string, error) {
+	var pins []string
+	if err := st.Get("download-cache-pins", &pins); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return pins, nil
+}
+
+// PinDownload pins the entry with the given sha3-384 key in the cache of
+// downloaded snaps, so that it is never removed.
+func PinDownload(st *state.State, key string) error {
+	if !downloadCacheKeyRegexp.MatchString(key) {
+		return fmt.Errorf("invalid download cache key %q: expected a sha3-384 digest", key)
+	}
+	pins, err := downloadCachePins(st)
+	if err != nil {
+		return err
+	}
+	if strutil.ListContains(pins, key) {
+		return nil
+	}
+	pins = append(pins, key)
+	sort.Strings(pins)
+	st.Set("download-cache-pins", pins)
+	return nil
+}
+
+// UnpinDownload removes the pin of the entry with the given key in the cache
+// of downloaded snaps.
+func UnpinDownload(st *state.State, key string) error {
+	pins, err := downloadCachePins(st)
+	if err != nil {
+		return err
+	}
+	for i, pin := range pins {
+		if pin == key {
+			pins = append(pins[:i], pins[i+1:]...)
+			st.Set("download-cache-pins", pins)
+			return nil
+		}
+	}
+	return fmt.Errorf("download cache entry %q is not pinned", key)
+}
@@ -0,0 +1,114 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *snapmgrTestSuite) TestDownloadCachePolicy(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	pinned := strings.Repeat("a", 96)
+	enforced := strings.Repeat("b", 96)
+
+	defer testutil.Mock(&snapstate.EnforcedSnapRevisionDigests, func(st *state.State) ([]string, error) {
+		return []string{enforced, pinned}, nil
+	})()
+
+	// nothing configured
+	policy, err := snapstate.DownloadCachePolicy(s.state)
+	c.Assert(err, IsNil)
+	c.Check(policy, DeepEquals, &store.CachePolicy{Pinned: []string{enforced, pinned}})
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "store.cache.max-items", 20)
+	tr.Set("core", "store.cache.max-size", "2GB")
+	tr.Set("core", "store.cache.max-age", "720h")
+	tr.Commit()
+	c.Assert(snapstate.PinDownload(s.state, pinned), IsNil)
+
+	policy, err = snapstate.DownloadCachePolicy(s.state)
+	c.Assert(err, IsNil)
+	c.Check(policy, DeepEquals, &store.CachePolicy{
+		MaxItems: 20,
+		MaxSize:  2000 * 1000 * 1000,
+		MaxAge:   720 * time.Hour,
+		Pinned:   []string{pinned, enforced},
+	})
+}
+
+func (s *snapmgrTestSuite) TestDownloadCachePolicyEnforcedError(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	pinned := strings.Repeat("a", 96)
+	c.Assert(snapstate.PinDownload(s.state, pinned), IsNil)
+	defer testutil.Mock(&snapstate.EnforcedSnapRevisionDigests, func(st *state.State) ([]string, error) {
+		return nil, errors.New("boom")
+	})()
+
+	policy, err := snapstate.DownloadCachePolicy(s.state)
+	c.Assert(err, IsNil)
+	c.Check(policy.Pinned, DeepEquals, []string{pinned})
+	c.Check(logbuf.String(), testutil.Contains, "cannot get the snap revisions required by validation sets: boom")
+}
+
+func (s *snapmgrTestSuite) TestPinUnpinDownload(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	key1 := strings.Repeat("1", 96)
+	key2 := strings.Repeat("0", 96)
+
+	c.Assert(snapstate.PinDownload(s.state, key1), IsNil)
+	c.Assert(snapstate.PinDownload(s.state, key2), IsNil)
+	// pinning is idempotent
+	c.Assert(snapstate.PinDownload(s.state, key1), IsNil)
+
+	var pins []string
+	c.Assert(s.state.Get("download-cache-pins", &pins), IsNil)
+	c.Check(pins, DeepEquals, []string{key2, key1})
+
+	c.Assert(snapstate.UnpinDownload(s.state, key2), IsNil)
+	c.Assert(s.state.Get("download-cache-pins", &pins), IsNil)
+	c.Check(pins, DeepEquals, []string{key1})
+
+	err := snapstate.UnpinDownload(s.state, key2)
+	c.Check(err, ErrorMatches, `download cache entry "0+" is not pinned`)
+
+	err = snapstate.PinDownload(s.state, "not-a-digest")
+	c.Check(err, ErrorMatches, `invalid download cache key "not-a-digest": expected a sha3-384 digest`)
+}
@@ -356,6 +356,10 @@ func (m *SnapManager) MaybeUndoRemodelBootChanges(t *state.Task) (restartRequest
 	return false, false, err
 }
 
+func (m *SnapManager) EnsureHealthRollbacks() error {
+	return m.ensureHealthRollbacks()
+}
+
 func MockEnsuredDesktopFilesUpdated(m *SnapManager, ensured bool) (restore func()) {
 	old := m.ensuredDesktopFilesUpdated
 	m.ensuredDesktopFilesUpdated = ensured
@@ -417,6 +421,17 @@ var (
 	MaybeAsyncPendingRefreshNotification = maybeAsyncPendingRefreshNotification
 )
 
+var (
+	DeviceRefreshBucket      = deviceRefreshBucket
+	ReadRefreshPhasingPolicy = readRefreshPhasingPolicy
+	RefreshWindows           = refreshWindows
+	WindowRefreshDue         = windowRefreshDue
+)
+
+func MockRefreshPhasingPolicyOwner(uid uint32) (restore func()) {
+	return testutil.Mock(&refreshPhasingPolicyOwner, uid)
+}
+
 type RefreshCandidate = refreshCandidate
 type TimedBusySnapError = timedBusySnapError
 
@@ -609,3 +624,9 @@ type CustomInstallGoal struct {
 func (c *CustomInstallGoal) toInstall(ctx context.Context, st *state.State, opts Options) ([]Target, error) {
 	return c.ToInstall(ctx, st, opts)
 }
+
+type PeerCacheServer = peerCacheServer
+
+func MockNewPeerCacheServer(f func(token string) PeerCacheServer) (restore func()) {
+	return testutil.Mock(&newPeerCacheServer, f)
+}
@@ -113,6 +113,15 @@ type Flags struct {
 
 	// Lane is the lane that tasks should join if Transaction is set to "all-snaps".
 	Lane int `json:"lane,omitempty"`
+
+	// SnapshotBeforeRefresh is set to request a snapshot of the data of
+	// the snap before refreshing it, that a revert can restore.
+	SnapshotBeforeRefresh bool `json:"snapshot-before-refresh,omitempty"`
+
+	// RevertWithData is set to request a revert to also restore the
+	// data of the snapshot taken before refreshing from the revision
+	// being reverted to.
+	RevertWithData bool `json:"revert-with-data,omitempty"`
 }
 
 // DevModeAllowed returns whether a snap can be installed with devmode
@@ -683,6 +683,50 @@ func autoRefreshRateLimited(st *state.State) (rate int64) {
 	return val
 }
 
+// taskDownloadCheckpointer keeps the checkpoints of a download in the state
+// of its task, so that the download can be resumed where it stopped after a
+// restart.
+type taskDownloadCheckpointer struct {
+	t *state.Task
+}
+
+func (c taskDownloadCheckpointer) Checkpoint() (*store.DownloadCheckpoint, error) {
+	st := c.t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var cp store.DownloadCheckpoint
+	if err := c.t.Get("download-checkpoint", &cp); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (c taskDownloadCheckpointer) SaveCheckpoint(cp *store.DownloadCheckpoint) error {
+	st := c.t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	c.t.Set("download-checkpoint", cp)
+	return nil
+}
+
+// maybeDiscardPartialDownload removes the partial download of an aborted
+// task, which is kept when the download is cancelled so that it can be resumed
+// after a restart. The state must be locked.
+func maybeDiscardPartialDownload(t *state.Task, targetFn string) {
+	if t.Status() != state.AbortStatus {
+		return
+	}
+	t.Set("download-checkpoint", nil)
+	if err := os.Remove(targetFn + ".partial"); err != nil && !os.IsNotExist(err) {
+		logger.Noticef("Cannot remove partial download %q: %v", targetFn+".partial", err)
+	}
+}
+
 func downloadSnapParams(st *state.State, t *state.Task) (*SnapSetup, StoreService, *auth.UserState, error) {
 	snapsup, err := TaskSnapSetup(t)
 	if err != nil {
@@ -728,8 +772,9 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	targetFn := snapsup.BlobPath()
 
 	dlOpts := &store.DownloadOptions{
-		Scheduled: snapsup.IsAutoRefresh,
-		RateLimit: rate,
+		Scheduled:    snapsup.IsAutoRefresh,
+		RateLimit:    rate,
+		Checkpointer: taskDownloadCheckpointer{t},
 	}
 	if snapsup.DownloadInfo == nil {
 		vsets, err := EnforcedValidationSets(st)
@@ -763,6 +808,9 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 		})
 	}
 	if err != nil {
+		st.Lock()
+		maybeDiscardPartialDownload(t, targetFn)
+		st.Unlock()
 		return err
 	}
 
@@ -770,6 +818,7 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 
 	// update the snap setup for the follow up tasks
 	st.Lock()
+	t.Set("download-checkpoint", nil)
 	t.Set("snap-setup", snapsup)
 	perfTimings.Save(st)
 	st.Unlock()
@@ -824,8 +873,9 @@ func (m *SnapManager) doPreDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	targetFn := snapsup.BlobPath()
 	dlOpts := &store.DownloadOptions{
 		// pre-downloads are only triggered in auto-refreshes
-		Scheduled: true,
-		RateLimit: autoRefreshRateLimited(st),
+		Scheduled:    true,
+		RateLimit:    autoRefreshRateLimited(st),
+		Checkpointer: taskDownloadCheckpointer{t},
 	}
 
 	perfTimings := state.TimingsForTask(t)
@@ -835,8 +885,10 @@ func (m *SnapManager) doPreDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	})
 	st.Lock()
 	if err != nil {
+		maybeDiscardPartialDownload(t, targetFn)
 		return err
 	}
+	t.Set("download-checkpoint", nil)
 	perfTimings.Save(st)
 
 	var waitingTasks []string
@@ -2061,6 +2113,14 @@ func MockLinkSnapParticipants(ps []LinkSnapParticipant) (restore func()) {
 	}
 }
 
+// addSnapNotice records a notice of the given type about the snap. Failing
+// to do so is logged but does not fail the task.
+func addSnapNotice(st *state.State, noticeType state.NoticeType, instanceName string, data map[string]string) {
+	if _, err := st.AddNotice(nil, noticeType, instanceName, &state.AddNoticeOptions{Data: data}); err != nil {
+		logger.Noticef("cannot record %s notice for snap %q: %v", noticeType, instanceName, err)
+	}
+}
+
 func notifyLinkParticipants(t *state.Task, snapsup *SnapSetup) {
 	st := t.State()
 	for _, p := range linkSnapParticipants {
@@ -2168,6 +2228,7 @@ func (m *SnapManager) doLinkSnap(t *state.Task, _ *tomb.Tomb) (err error) {
 	}
 	oldRefreshInhibitedTime := snapst.RefreshInhibitedTime
 	oldLastRefreshTime := snapst.LastRefreshTime
+	oldLastRefreshReverted := snapst.LastRefreshReverted
 	// only set userID if unset or logged out in snapst and if we
 	// actually have an associated user
 	if snapsup.UserID > 0 {
@@ -2352,6 +2413,7 @@ func (m *SnapManager) doLinkSnap(t *state.Task, _ *tomb.Tomb) (err error) {
 	t.Set("old-refresh-inhibited-time", oldRefreshInhibitedTime)
 	t.Set("old-cohort-key", oldCohortKey)
 	t.Set("old-last-refresh-time", oldLastRefreshTime)
+	t.Set("old-last-refresh-reverted", oldLastRefreshReverted)
 	t.Set("old-revs-before-cand", oldRevsBeforeCand)
 	if snapsup.Revert {
 		t.Set("old-revert-status", snapst.RevertStatus)
@@ -2374,6 +2436,7 @@ func (m *SnapManager) doLinkSnap(t *state.Task, _ *tomb.Tomb) (err error) {
 		now := timeNow()
 		snapst.LastRefreshTime = &now
 	}
+	snapst.LastRefreshReverted = snapsup.Revert
 
 	if cand.Snap.SnapID != "" {
 		// write the auxiliary store info
@@ -2429,6 +2492,18 @@ func (m *SnapManager) doLinkSnap(t *state.Task, _ *tomb.Tomb) (err error) {
 	// Notify link snap participants about link changes.
 	notifyLinkParticipants(t, snapsup)
 
+	switch {
+	case !isInstalled:
+		addSnapNotice(st, state.SnapInstalledNotice, snapsup.InstanceName(), map[string]string{
+			"revision": cand.Snap.Revision.String(),
+		})
+	case oldInfo != nil && oldInfo.Revision != cand.Snap.Revision:
+		addSnapNotice(st, state.SnapRefreshedNotice, snapsup.InstanceName(), map[string]string{
+			"revision":     cand.Snap.Revision.String(),
+			"old-revision": oldInfo.Revision.String(),
+		})
+	}
+
 	// Unfortunately this is needed to make sure we actually request a reboot as a part
 	// of link-snap for the gadget (which is the task that has a restart-boundary set).
 	// The gadget does not by default set `rebootInfo.RebootRequired` as its difficult for
@@ -2780,6 +2855,10 @@ func (m *SnapManager) undoLinkSnap(t *state.Task, _ *tomb.Tomb) error {
 	if err := t.Get("old-last-refresh-time", &oldLastRefreshTime); err != nil && !errors.Is(err, state.ErrNoState) {
 		return err
 	}
+	var oldLastRefreshReverted bool
+	if err := t.Get("old-last-refresh-reverted", &oldLastRefreshReverted); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
 	var oldCohortKey string
 	if err := t.Get("old-cohort-key", &oldCohortKey); err != nil && !errors.Is(err, state.ErrNoState) {
 		return err
@@ -2837,6 +2916,7 @@ func (m *SnapManager) undoLinkSnap(t *state.Task, _ *tomb.Tomb) error {
 	snapst.Classic = oldClassic
 	snapst.RefreshInhibitedTime = oldRefreshInhibitedTime
 	snapst.LastRefreshTime = oldLastRefreshTime
+	snapst.LastRefreshReverted = oldLastRefreshReverted
 	snapst.CohortKey = oldCohortKey
 
 	if isRevert {
@@ -2928,6 +3008,18 @@ func (m *SnapManager) undoLinkSnap(t *state.Task, _ *tomb.Tomb) error {
 	// Notify link snap participants about link changes.
 	notifyLinkParticipants(t, snapsup)
 
+	switch {
+	case len(snapst.Sequence.Revisions) == 0:
+		addSnapNotice(st, state.SnapRemovedNotice, snapsup.InstanceName(), map[string]string{
+			"revision": snapsup.Revision().String(),
+		})
+	case oldCurrent != snapsup.Revision():
+		addSnapNotice(st, state.SnapRefreshedNotice, snapsup.InstanceName(), map[string]string{
+			"revision":     oldCurrent.String(),
+			"old-revision": snapsup.Revision().String(),
+		})
+	}
+
 	// Finish task: set status, possibly restart
 
 	// Make sure if state commits and snapst is mutated we won't be rerun
@@ -3808,6 +3900,11 @@ func (m *SnapManager) doDiscardSnap(t *state.Task, _ *tomb.Tomb) error {
 		return err
 	}
 	Set(st, snapsup.InstanceName(), snapst)
+	if len(snapst.Sequence.Revisions) == 0 {
+		addSnapNotice(st, state.SnapRemovedNotice, snapsup.InstanceName(), map[string]string{
+			"revision": snapsup.Revision().String(),
+		})
+	}
 	return nil
 }
 
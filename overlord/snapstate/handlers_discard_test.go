@@ -87,6 +87,9 @@ func (s *discardSnapSuite) TestDoDiscardSnapSuccess(c *C) {
 	c.Check(snapst.Sequence.Revisions, HasLen, 1)
 	c.Check(snapst.Current, Equals, snap.R(3))
 	c.Check(t.Status(), Equals, state.DoneStatus)
+
+	// the snap is still installed
+	c.Check(snapNotices(c, s.state), HasLen, 0)
 }
 
 func (s *discardSnapSuite) TestDoDiscardSnapInQuotaGroup(c *C) {
@@ -163,6 +166,10 @@ func (s *discardSnapSuite) TestDoDiscardSnapToEmpty(c *C) {
 	var snapst snapstate.SnapState
 	err := snapstate.Get(s.state, "foo", &snapst)
 	c.Assert(err, testutil.ErrorIs, state.ErrNoState)
+
+	c.Check(snapNotices(c, s.state), DeepEquals, []map[string]any{
+		{"type": "snap-removed", "key": "foo", "occurrences": 1.0, "last-data": map[string]any{"revision": "33"}},
+	})
 }
 
 func (s *discardSnapSuite) TestDoDiscardSnapErrorsForActive(c *C) {
@@ -20,6 +20,8 @@
 package snapstate_test
 
 import (
+	"errors"
+	"os"
 	"path/filepath"
 	"time"
 
@@ -521,3 +523,136 @@ func (s *downloadSnapSuite) TestDoDownloadRateLimitedIntegration(c *C) {
 	})
 
 }
+
+func (s *downloadSnapSuite) TestDoDownloadSnapCheckpoints(c *C) {
+	s.state.Lock()
+
+	si := &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "foo-id",
+		Revision: snap.R(11),
+	}
+	t := s.state.NewTask("download-snap", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: si,
+		DownloadInfo: &snap.DownloadInfo{
+			DownloadURL: "http://some-url.com/snap",
+		},
+	})
+	// left by a download interrupted by a restart
+	t.Set("download-checkpoint", &store.DownloadCheckpoint{Offset: 1024, Sha3_384: "sha3-of-1024"})
+	chg := s.state.NewChange("sample", "...")
+	chg.AddTask(t)
+
+	s.fakeStore.downloadCheckpointCallback = func(name string, checkpointer store.DownloadCheckpointer) {
+		c.Check(name, Equals, "foo")
+		cp, err := checkpointer.Checkpoint()
+		c.Assert(err, IsNil)
+		c.Check(cp, DeepEquals, &store.DownloadCheckpoint{Offset: 1024, Sha3_384: "sha3-of-1024"})
+
+		c.Assert(checkpointer.SaveCheckpoint(&store.DownloadCheckpoint{Offset: 2048, Sha3_384: "sha3-of-2048"}), IsNil)
+		s.state.Lock()
+		var saved store.DownloadCheckpoint
+		c.Check(t.Get("download-checkpoint", &saved), IsNil)
+		s.state.Unlock()
+		c.Check(saved, DeepEquals, store.DownloadCheckpoint{Offset: 2048, Sha3_384: "sha3-of-2048"})
+	}
+
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(chg.Err(), IsNil)
+	c.Check(s.fakeStore.downloads, HasLen, 1)
+	// the checkpoint is forgotten once the download is done
+	var cp store.DownloadCheckpoint
+	c.Check(t.Get("download-checkpoint", &cp), testutil.ErrorIs, state.ErrNoState)
+}
+
+func (s *downloadSnapSuite) TestDoDownloadSnapAbortDiscardsPartial(c *C) {
+	s.state.Lock()
+
+	si := &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "foo-id",
+		Revision: snap.R(11),
+	}
+	t := s.state.NewTask("download-snap", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: si,
+		DownloadInfo: &snap.DownloadInfo{
+			DownloadURL: "http://some-url.com/snap",
+		},
+	})
+	chg := s.state.NewChange("sample", "...")
+	chg.AddTask(t)
+
+	partial := filepath.Join(dirs.SnapBlobDir, "foo_11.snap.partial")
+	s.fakeStore.downloadError = map[string]error{"foo": errors.New("the download has been cancelled")}
+	s.fakeStore.downloadCheckpointCallback = func(name string, checkpointer store.DownloadCheckpointer) {
+		c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+		c.Assert(os.WriteFile(partial, []byte("partial"), 0644), IsNil)
+		c.Assert(checkpointer.SaveCheckpoint(&store.DownloadCheckpoint{Offset: 7, Sha3_384: "sha3"}), IsNil)
+
+		s.state.Lock()
+		chg.Abort()
+		s.state.Unlock()
+	}
+
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(partial, testutil.FileAbsent)
+	var cp store.DownloadCheckpoint
+	c.Check(t.Get("download-checkpoint", &cp), testutil.ErrorIs, state.ErrNoState)
+}
+
+func (s *downloadSnapSuite) TestDoDownloadSnapErrorKeepsCheckpoint(c *C) {
+	s.state.Lock()
+
+	si := &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "foo-id",
+		Revision: snap.R(11),
+	}
+	t := s.state.NewTask("download-snap", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: si,
+		DownloadInfo: &snap.DownloadInfo{
+			DownloadURL: "http://some-url.com/snap",
+		},
+	})
+	chg := s.state.NewChange("sample", "...")
+	chg.AddTask(t)
+
+	partial := filepath.Join(dirs.SnapBlobDir, "foo_11.snap.partial")
+	s.fakeStore.downloadError = map[string]error{"foo": errors.New("the download has been cancelled")}
+	s.fakeStore.downloadCheckpointCallback = func(name string, checkpointer store.DownloadCheckpointer) {
+		c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+		c.Assert(os.WriteFile(partial, []byte("partial"), 0644), IsNil)
+		c.Assert(checkpointer.SaveCheckpoint(&store.DownloadCheckpoint{Offset: 7, Sha3_384: "sha3"}), IsNil)
+	}
+
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// what the store left behind is kept for when the download is retried
+	c.Check(partial, testutil.FilePresent)
+	var cp store.DownloadCheckpoint
+	c.Check(t.Get("download-checkpoint", &cp), IsNil)
+	c.Check(cp.Offset, Equals, int64(7))
+}
@@ -153,6 +153,34 @@ func (s *linkSnapSuite) TestDoLinkSnapSuccess(c *C) {
 
 	// link snap participant was invoked
 	c.Check(lp.instanceNames, DeepEquals, []string{"foo"})
+
+	// the installation was notified
+	c.Check(snapNotices(c, s.state), DeepEquals, []map[string]any{
+		{"type": "snap-installed", "key": "foo", "occurrences": 1.0, "last-data": map[string]any{"revision": "33"}},
+	})
+}
+
+// snapNotices returns the relevant fields of the snap lifecycle notices.
+func snapNotices(c *C, st *state.State) []map[string]any {
+	notices := st.Notices(&state.NoticeFilter{Types: []state.NoticeType{
+		state.SnapInstalledNotice,
+		state.SnapRemovedNotice,
+		state.SnapRefreshedNotice,
+	}})
+	var result []map[string]any
+	for _, notice := range notices {
+		n := noticeToMap(c, notice)
+		m := map[string]any{
+			"type":        n["type"],
+			"key":         n["key"],
+			"occurrences": n["occurrences"],
+		}
+		if data, ok := n["last-data"]; ok {
+			m["last-data"] = data
+		}
+		result = append(result, m)
+	}
+	return result
 }
 
 func (s *linkSnapSuite) TestDoLinkSnapSuccessWithCohort(c *C) {
@@ -424,6 +452,11 @@ func (s *linkSnapSuite) TestDoUndoLinkSnap(c *C) {
 
 	// link snap participant was invoked, once for do, once for undo.
 	c.Check(lp.instanceNames, DeepEquals, []string{"foo", "foo"})
+
+	c.Check(snapNotices(c, s.state), DeepEquals, []map[string]any{
+		{"type": "snap-installed", "key": "foo", "occurrences": 1.0, "last-data": map[string]any{"revision": "33"}},
+		{"type": "snap-removed", "key": "foo", "occurrences": 1.0, "last-data": map[string]any{"revision": "33"}},
+	})
 }
 
 func (s *linkSnapSuite) TestDoUnlinkCurrentSnapWithIgnoreRunning(c *C) {
@@ -1752,6 +1785,11 @@ func (s *linkSnapSuite) TestDoUndoLinkSnapSequenceDidNotHaveCandidate(c *C) {
 	c.Check(snapst.Sequence.Revisions, HasLen, 1)
 	c.Check(snapst.Current, Equals, snap.R(1))
 	c.Check(t.Status(), Equals, state.UndoneStatus)
+
+	// both the refresh and its undoing were notified
+	c.Check(snapNotices(c, s.state), DeepEquals, []map[string]any{
+		{"type": "snap-refreshed", "key": "foo", "occurrences": 2.0, "last-data": map[string]any{"revision": "1", "old-revision": "2"}},
+	})
 }
 
 func (s *linkSnapSuite) TestDoUndoLinkSnapSequenceHadCandidate(c *C) {
@@ -2279,6 +2317,8 @@ func (s *linkSnapSuite) TestDoUndoLinkSnapRestoresLastRefreshTime(c *C) {
 		Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
 		Current:         si.Revision,
 		LastRefreshTime: &lastRefresh,
+		// the snap was reverted since
+		LastRefreshReverted: true,
 	})
 
 	task := s.state.NewTask("link-snap", "")
@@ -2304,6 +2344,7 @@ func (s *linkSnapSuite) TestDoUndoLinkSnapRestoresLastRefreshTime(c *C) {
 	c.Assert(snapstate.Get(s.state, "snap", &snapst), IsNil)
 	// the original last-refresh-time has been restored.
 	c.Check(snapst.LastRefreshTime.Equal(lastRefresh), Equals, true)
+	c.Check(snapst.LastRefreshReverted, Equals, true)
 }
 
 func (s *linkSnapSuite) TestUndoLinkSnapdFirstInstall(c *C) {
@@ -0,0 +1,178 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// healthRollbackWindow returns for how long after being refreshed a snap
+// reporting an error health status is reverted to its previous revision,
+// as set by refresh.health-rollback-window. It is 0 unless opted in.
+func healthRollbackWindow(st *state.State) (time.Duration, error) {
+	var windowStr string
+	err := config.NewTransaction(st).Get("core", "refresh.health-rollback-window", &windowStr)
+	if err != nil && !config.IsNoOption(err) {
+		return 0, err
+	}
+	if windowStr == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(windowStr)
+}
+
+// HealthErrorReported is called when a snap starts reporting an error
+// health status. If this happens within the health rollback window after
+// the snap was refreshed, a revert of the snap to its previous revision is
+// queued, to be started once nothing else operates on the snap.
+func HealthErrorReported(st *state.State, instanceName string) error {
+	window, err := healthRollbackWindow(st)
+	if err != nil {
+		return err
+	}
+	if window == 0 {
+		return nil
+	}
+
+	var snapst SnapState
+	if err := Get(st, instanceName, &snapst); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return nil
+		}
+		return err
+	}
+	if snapst.LastRefreshTime == nil || timeNow().Sub(*snapst.LastRefreshTime) > window {
+		return nil
+	}
+	// only the revision installed by the refresh is rolled back, not one
+	// the snap was reverted to since, such as by an earlier rollback
+	if snapst.LastRefreshReverted {
+		return nil
+	}
+	if snapst.previousSideInfo() == nil {
+		return nil
+	}
+
+	pending, err := pendingHealthRollbacks(st)
+	if err != nil {
+		return err
+	}
+	pending[instanceName] = snapst.Current
+	st.Set("health-rollbacks", pending)
+	st.EnsureBefore(0)
+	return nil
+}
+
+func pendingHealthRollbacks(st *state.State) (map[string]snap.Revision, error) {
+	var pending map[string]snap.Revision
+	if err := st.Get("health-rollbacks", &pending); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	if pending == nil {
+		pending = make(map[string]snap.Revision)
+	}
+	return pending, nil
+}
+
+// ensureHealthRollbacks starts the reverts queued by HealthErrorReported.
+func (m *SnapManager) ensureHealthRollbacks() error {
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	pending, err := pendingHealthRollbacks(st)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	instanceNames := make([]string, 0, len(pending))
+	for instanceName := range pending {
+		instanceNames = append(instanceNames, instanceName)
+	}
+	sort.Strings(instanceNames)
+
+	for _, instanceName := range instanceNames {
+		rev := pending[instanceName]
+		// something else is operating on the snap, like the refresh
+		// whose check-health hook reported the error, retry once done
+		if err := CheckChangeConflict(st, instanceName, nil); err != nil {
+			continue
+		}
+		delete(pending, instanceName)
+		if err := healthRollback(st, instanceName, rev); err != nil {
+			logger.Noticef("cannot revert snap %q with an error health status: %v", instanceName, err)
+		}
+	}
+	st.Set("health-rollbacks", pending)
+	return nil
+}
+
+func healthRollback(st *state.State, instanceName string, rev snap.Revision) error {
+	var snapst SnapState
+	if err := Get(st, instanceName, &snapst); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return nil
+		}
+		return err
+	}
+	// the snap moved on or got healthy again in the meantime
+	if snapst.Current != rev || snapst.LastRefreshReverted {
+		return nil
+	}
+	if IsHealthError != nil && !IsHealthError(st, instanceName, rev) {
+		return nil
+	}
+	prev := snapst.previousSideInfo()
+	if prev == nil {
+		return nil
+	}
+
+	ts, err := Revert(st, instanceName, Flags{}, "")
+	if err != nil {
+		return err
+	}
+	// back off from refreshing to the revision in error again
+	snapName, instanceKey := snap.SplitInstanceName(instanceName)
+	badSnapsup := &SnapSetup{
+		SideInfo:    &snap.SideInfo{RealName: snapName, Revision: rev},
+		InstanceKey: instanceKey,
+	}
+	if err := incrementSnapRefreshFailures(st, badSnapsup, snap.RefreshFailureSeverityNone); err != nil {
+		return err
+	}
+
+	summary := fmt.Sprintf("Revert %q snap to revision %s as revision %s reported an error health status", instanceName, prev.Revision, rev)
+	chg := st.NewChange("revert-snap", summary)
+	chg.AddAll(ts)
+	chg.Set("snap-names", []string{instanceName})
+	st.Warnf("snap %q is being reverted to revision %s as revision %s reported an error health status after being refreshed", instanceName, prev.Revision, rev)
+	return nil
+}
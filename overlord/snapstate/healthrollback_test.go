@@ -0,0 +1,227 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *snapmgrTestSuite) mockHealthRollbackSnap(c *C, window string, refreshedAgo time.Duration) {
+	if window != "" {
+		tr := config.NewTransaction(s.state)
+		tr.Set("core", "refresh.health-rollback-window", window)
+		tr.Commit()
+	}
+
+	refreshTime := time.Now().Add(-refreshedAgo)
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:   true,
+		SnapType: "app",
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "some-snap", Revision: snap.R(2)},
+			{RealName: "some-snap", Revision: snap.R(7)},
+		}),
+		Current:         snap.R(7),
+		LastRefreshTime: &refreshTime,
+	})
+}
+
+func (s *snapmgrTestSuite) mockIsHealthError(healthError bool) {
+	old := snapstate.IsHealthError
+	snapstate.IsHealthError = func(st *state.State, snapName string, rev snap.Revision) bool {
+		return healthError
+	}
+	s.AddCleanup(func() { snapstate.IsHealthError = old })
+}
+
+func (s *snapmgrTestSuite) revertChanges() []*state.Change {
+	var chgs []*state.Change
+	for _, chg := range s.state.Changes() {
+		if chg.Kind() == "revert-snap" {
+			chgs = append(chgs, chg)
+		}
+	}
+	return chgs
+}
+
+func (s *snapmgrTestSuite) TestHealthErrorReportedRevertsRefresh(c *C) {
+	s.mockIsHealthError(true)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.mockHealthRollbackSnap(c, "1h", 5*time.Minute)
+
+	c.Assert(snapstate.HealthErrorReported(s.state, "some-snap"), IsNil)
+	var pending map[string]snap.Revision
+	c.Assert(s.state.Get("health-rollbacks", &pending), IsNil)
+	c.Check(pending, DeepEquals, map[string]snap.Revision{"some-snap": snap.R(7)})
+
+	s.state.Unlock()
+	err := s.snapmgr.EnsureHealthRollbacks()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+
+	chgs := s.revertChanges()
+	c.Assert(chgs, HasLen, 1)
+	c.Check(chgs[0].Summary(), Equals, `Revert "some-snap" snap to revision 2 as revision 7 reported an error health status`)
+	var snapNames []string
+	c.Assert(chgs[0].Get("snap-names", &snapNames), IsNil)
+	c.Check(snapNames, DeepEquals, []string{"some-snap"})
+
+	var stillPending map[string]snap.Revision
+	c.Assert(s.state.Get("health-rollbacks", &stillPending), IsNil)
+	c.Check(stillPending, HasLen, 0)
+
+	// the revision in error is not auto-refreshed to again right away
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Assert(snapst.RefreshFailures, NotNil)
+	c.Check(snapst.RefreshFailures.Revision, Equals, snap.R(7))
+	c.Check(snapst.RefreshFailures.FailureCount, Equals, 1)
+
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Equals, `snap "some-snap" is being reverted to revision 2 as revision 7 reported an error health status after being refreshed`)
+
+	s.settle(c)
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Check(snapst.Current, Equals, snap.R(2))
+}
+
+func (s *snapmgrTestSuite) TestHealthErrorReportedNoRollbackWindow(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.mockHealthRollbackSnap(c, "", 5*time.Minute)
+
+	c.Assert(snapstate.HealthErrorReported(s.state, "some-snap"), IsNil)
+	var pending map[string]snap.Revision
+	c.Check(s.state.Get("health-rollbacks", &pending), testutil.ErrorIs, state.ErrNoState)
+}
+
+func (s *snapmgrTestSuite) TestHealthErrorReportedOutsideRollbackWindow(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.mockHealthRollbackSnap(c, "1h", 2*time.Hour)
+
+	c.Assert(snapstate.HealthErrorReported(s.state, "some-snap"), IsNil)
+	var pending map[string]snap.Revision
+	c.Check(s.state.Get("health-rollbacks", &pending), testutil.ErrorIs, state.ErrNoState)
+}
+
+func (s *snapmgrTestSuite) TestHealthRollbackSkippedWhenHealthyAgain(c *C) {
+	s.mockIsHealthError(false)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.mockHealthRollbackSnap(c, "1h", 5*time.Minute)
+	c.Assert(snapstate.HealthErrorReported(s.state, "some-snap"), IsNil)
+
+	s.state.Unlock()
+	err := s.snapmgr.EnsureHealthRollbacks()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+
+	c.Check(s.revertChanges(), HasLen, 0)
+	var pending map[string]snap.Revision
+	c.Assert(s.state.Get("health-rollbacks", &pending), IsNil)
+	c.Check(pending, HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestHealthRollbackWaitsForConflicts(c *C) {
+	s.mockIsHealthError(true)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.mockHealthRollbackSnap(c, "1h", 5*time.Minute)
+	c.Assert(snapstate.HealthErrorReported(s.state, "some-snap"), IsNil)
+
+	chg := s.state.NewChange("refresh-snap", "...")
+	t := s.state.NewTask("foo", "...")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{RealName: "some-snap", Revision: snap.R(7)},
+	})
+	chg.AddTask(t)
+
+	s.state.Unlock()
+	err := s.snapmgr.EnsureHealthRollbacks()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(s.revertChanges(), HasLen, 0)
+
+	t.SetStatus(state.DoneStatus)
+	s.state.Unlock()
+	err = s.snapmgr.EnsureHealthRollbacks()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(s.revertChanges(), HasLen, 1)
+}
+
+func (s *snapmgrTestSuite) TestHealthErrorReportedAfterRollbackDoesNotRevertAgain(c *C) {
+	s.mockIsHealthError(true)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.mockHealthRollbackSnap(c, "1h", 5*time.Minute)
+	// there is a revision to go back to from the reverted to one as well
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	snapst.Sequence = snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+		{RealName: "some-snap", Revision: snap.R(1)},
+		{RealName: "some-snap", Revision: snap.R(2)},
+		{RealName: "some-snap", Revision: snap.R(7)},
+	})
+	snapstate.Set(s.state, "some-snap", &snapst)
+
+	c.Assert(snapstate.HealthErrorReported(s.state, "some-snap"), IsNil)
+	s.state.Unlock()
+	err := s.snapmgr.EnsureHealthRollbacks()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Assert(s.revertChanges(), HasLen, 1)
+
+	s.settle(c)
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Check(snapst.Current, Equals, snap.R(2))
+	c.Check(snapst.LastRefreshReverted, Equals, true)
+
+	// the reverted to revision reports an error too, still within the
+	// window of the refresh to revision 7
+	c.Assert(snapstate.HealthErrorReported(s.state, "some-snap"), IsNil)
+	var pending map[string]snap.Revision
+	c.Assert(s.state.Get("health-rollbacks", &pending), IsNil)
+	c.Check(pending, HasLen, 0)
+
+	s.state.Unlock()
+	err = s.snapmgr.EnsureHealthRollbacks()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(s.revertChanges(), HasLen, 1)
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Check(snapst.Current, Equals, snap.R(2))
+}
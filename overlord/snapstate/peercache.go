@@ -0,0 +1,86 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/store"
+)
+
+// peerCacheServer serves the download cache to peers on the local network.
+type peerCacheServer interface {
+	Start(addr string) error
+	Stop() error
+}
+
+var newPeerCacheServer = func(token string) peerCacheServer {
+	return store.NewPeerCacheServer(dirs.SnapDownloadCacheDir, token)
+}
+
+// ensurePeerCache starts, stops or moves the server of the download cache to
+// peers according to store.peer-cache.listen and store.peer-cache.token.
+func (m *SnapManager) ensurePeerCache() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	var addr, token string
+	tr := config.NewTransaction(m.state)
+	if err := tr.GetMaybe("core", "store.peer-cache.listen", &addr); err != nil {
+		return err
+	}
+	if err := tr.GetMaybe("core", "store.peer-cache.token", &token); err != nil {
+		return err
+	}
+	if addr == m.peerCacheAddr && token == m.peerCacheToken {
+		return nil
+	}
+
+	m.stopPeerCache()
+	// remember the settings even if serving with them fails, to not
+	// retry on every ensure
+	m.peerCacheAddr = addr
+	m.peerCacheToken = token
+	if addr == "" {
+		return nil
+	}
+
+	srv := newPeerCacheServer(token)
+	if err := srv.Start(addr); err != nil {
+		logger.Noticef("Cannot serve the download cache to peers: %v", err)
+		return nil
+	}
+	logger.Noticef("Serving the download cache to peers on %s.", addr)
+	m.peerCache = srv
+	return nil
+}
+
+func (m *SnapManager) stopPeerCache() {
+	if m.peerCache == nil {
+		return
+	}
+	if err := m.peerCache.Stop(); err != nil {
+		logger.Noticef("Cannot stop serving the download cache to peers: %v", err)
+	}
+	m.peerCache = nil
+	m.peerCacheAddr = ""
+	m.peerCacheToken = ""
+}
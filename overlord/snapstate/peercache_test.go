@@ -0,0 +1,155 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+)
+
+type fakePeerCacheServer struct {
+	ops      *[]string
+	startErr error
+}
+
+func (f *fakePeerCacheServer) Start(addr string) error {
+	*f.ops = append(*f.ops, "start:"+addr)
+	return f.startErr
+}
+
+func (f *fakePeerCacheServer) Stop() error {
+	*f.ops = append(*f.ops, "stop")
+	return nil
+}
+
+func (s *snapmgrTestSuite) setPeerCacheListen(addr string) {
+	s.setPeerCacheConfig("store.peer-cache.listen", addr)
+}
+
+func (s *snapmgrTestSuite) setPeerCacheConfig(key, value string) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", key, value)
+	tr.Commit()
+}
+
+func (s *snapmgrTestSuite) TestEnsurePeerCache(c *C) {
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	var ops []string
+	var startErr error
+	defer snapstate.MockNewPeerCacheServer(func(token string) snapstate.PeerCacheServer {
+		c.Check(token, Equals, "shared-peer-cache-token")
+		return &fakePeerCacheServer{ops: &ops, startErr: startErr}
+	})()
+	s.setPeerCacheConfig("store.peer-cache.token", "shared-peer-cache-token")
+
+	// not configured
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	c.Check(ops, HasLen, 0)
+
+	s.setPeerCacheListen(":7353")
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	c.Check(ops, DeepEquals, []string{"start::7353"})
+
+	// moving to another address restarts the server
+	s.setPeerCacheListen("127.0.0.1:7354")
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	c.Check(ops, DeepEquals, []string{"start::7353", "stop", "start:127.0.0.1:7354"})
+
+	// failing to serve is not retried until the address changes
+	ops = nil
+	startErr = errors.New("address in use")
+	s.setPeerCacheListen(":7355")
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	c.Check(ops, DeepEquals, []string{"stop", "start::7355"})
+	c.Check(logbuf.String(), Matches, `(?s).*Cannot serve the download cache to peers: address in use.*`)
+
+	// unsetting stops serving
+	ops = nil
+	startErr = nil
+	s.setPeerCacheListen("127.0.0.1:7356")
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	s.setPeerCacheListen("")
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	c.Check(ops, DeepEquals, []string{"start:127.0.0.1:7356", "stop"})
+
+	// and so does stopping the manager
+	ops = nil
+	s.setPeerCacheListen(":7357")
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	s.snapmgr.Stop()
+	c.Check(ops, DeepEquals, []string{"start::7357", "stop"})
+}
+
+func (s *snapmgrTestSuite) TestEnsurePeerCacheTokenChange(c *C) {
+	var ops []string
+	var tokens []string
+	defer snapstate.MockNewPeerCacheServer(func(token string) snapstate.PeerCacheServer {
+		tokens = append(tokens, token)
+		return &fakePeerCacheServer{ops: &ops}
+	})()
+
+	s.setPeerCacheConfig("store.peer-cache.token", "shared-peer-cache-token")
+	s.setPeerCacheListen(":7353")
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+
+	// changing the token restarts the server with the new one
+	s.setPeerCacheConfig("store.peer-cache.token", "other-peer-cache-token")
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	c.Check(ops, DeepEquals, []string{"start::7353", "stop", "start::7353"})
+	c.Check(tokens, DeepEquals, []string{"shared-peer-cache-token", "other-peer-cache-token"})
+}
+
+func (s *snapmgrTestSuite) TestCanStandbyNotWhileServingPeerCache(c *C) {
+	var ops []string
+	defer snapstate.MockNewPeerCacheServer(func(token string) snapstate.PeerCacheServer {
+		return &fakePeerCacheServer{ops: &ops}
+	})()
+
+	// no snaps
+	s.state.Lock()
+	s.state.Set("snaps", nil)
+	c.Check(s.snapmgr.CanStandby(), Equals, true)
+	s.state.Unlock()
+
+	s.setPeerCacheConfig("store.peer-cache.token", "shared-peer-cache-token")
+	s.setPeerCacheListen(":7353")
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	s.state.Lock()
+	c.Check(s.snapmgr.CanStandby(), Equals, false)
+	s.state.Unlock()
+
+	s.setPeerCacheListen("")
+	c.Assert(s.snapmgr.Ensure(), IsNil)
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.snapmgr.CanStandby(), Equals, true)
+}
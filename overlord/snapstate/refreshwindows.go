@@ -0,0 +1,122 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/timeutil"
+)
+
+// Hook setup by servicestate to get the name of the quota group a snap is
+// in, or an empty string if it is in none.
+var SnapQuotaGroup func(st *state.State, snapName string) (string, error)
+
+// refreshWindows returns the schedules of the refresh windows of the
+// installed snaps that have one, either of their own as set with
+// refresh.snap-windows.<snap> or of their quota group as set with
+// refresh.quota-windows.<group>. Snaps are only auto-refreshed while their
+// window is open.
+func refreshWindows(st *state.State) (map[string][]*timeutil.Schedule, error) {
+	tr := config.NewTransaction(st)
+	var snapWindows, quotaWindows map[string]string
+	if err := tr.GetMaybe("core", "refresh.snap-windows", &snapWindows); err != nil {
+		return nil, err
+	}
+	if err := tr.GetMaybe("core", "refresh.quota-windows", &quotaWindows); err != nil {
+		return nil, err
+	}
+	if len(snapWindows) == 0 && len(quotaWindows) == 0 {
+		return nil, nil
+	}
+
+	snapStates, err := All(st)
+	if err != nil {
+		return nil, err
+	}
+	windows := make(map[string][]*timeutil.Schedule)
+	for instanceName := range snapStates {
+		// the window of the snap takes precedence over the one of its
+		// quota group
+		window := snapWindows[instanceName]
+		if window == "" && len(quotaWindows) > 0 && SnapQuotaGroup != nil {
+			group, err := SnapQuotaGroup(st, instanceName)
+			if err != nil {
+				return nil, err
+			}
+			window = quotaWindows[group]
+		}
+		if window == "" {
+			continue
+		}
+		schedule, err := timeutil.ParseSchedule(window)
+		if err != nil {
+			// validated when set, but do not block the refreshes
+			// of other snaps if it got broken somehow
+			logger.Noticef("cannot parse refresh window of snap %q: %v", instanceName, err)
+			continue
+		}
+		windows[instanceName] = schedule
+	}
+	return windows, nil
+}
+
+// refreshWindowsFilter returns the filter letting an auto-refresh started at
+// the given time through for the snaps whose refresh window is open. Snaps
+// without a window are let through only if the auto-refresh was started by
+// refresh.timer, not by the opening of a refresh window.
+func refreshWindowsFilter(st *state.State, now time.Time, fromWindow bool) (updateFilter, error) {
+	windows, err := refreshWindows(st)
+	if err != nil {
+		return nil, err
+	}
+	return func(info *snap.Info, snapst *SnapState) bool {
+		window, ok := windows[info.InstanceName()]
+		if !ok {
+			return !fromWindow
+		}
+		return timeutil.Includes(window, now)
+	}, nil
+}
+
+// windowRefreshDue returns whether an auto-refresh of the snaps with a
+// refresh window should be started at the given time, because one of the
+// windows is open and no such auto-refresh was started during its current
+// opening.
+func windowRefreshDue(windows map[string][]*timeutil.Schedule, lastWindowRefresh, now time.Time) bool {
+	for _, window := range windows {
+		if !timeutil.Includes(window, now) {
+			continue
+		}
+		if lastWindowRefresh.IsZero() || !timeutil.Includes(window, lastWindowRefresh) {
+			return true
+		}
+		// windows open at most once a day, unless they span several
+		// days in which case refreshing once a day is plenty
+		if now.Sub(lastWindowRefresh) >= 24*time.Hour {
+			return true
+		}
+	}
+	return false
+}
@@ -67,6 +67,10 @@ type SnapManager struct {
 	ensuredDesktopFilesUpdated bool
 	ensuredDownloadsCleaned    bool
 
+	peerCache      peerCacheServer
+	peerCacheAddr  string
+	peerCacheToken string
+
 	changeCallbackID int
 }
 
@@ -385,6 +389,10 @@ type SnapState struct {
 
 	// LastRefreshTime records the time when the snap was last refreshed.
 	LastRefreshTime *time.Time `json:"last-refresh-time,omitempty"`
+	// LastRefreshReverted is set if the snap was reverted since it was
+	// last refreshed, so that its current revision is not the one
+	// installed at LastRefreshTime.
+	LastRefreshReverted bool `json:"last-refresh-reverted,omitempty"`
 
 	// LastCompRefreshTime is a map of component names to times that records the
 	// time when a component was last refreshed.
@@ -598,6 +606,15 @@ var AutomaticSnapshot func(st *state.State, instanceName string) (ts *state.Task
 var AutomaticSnapshotExpiration func(st *state.State) (time.Duration, error)
 var EstimateSnapshotSize func(st *state.State, instanceName string, users []string) (uint64, error)
 
+// PreRefreshSnapshot allows to hook snapshot manager's PreRefreshSnapshot,
+// which returns ErrNothingToDo if no snapshot is to be taken before the
+// refresh of the given snap.
+var PreRefreshSnapshot func(st *state.State, instanceName string, requested bool) (ts *state.TaskSet, err error)
+
+// RestorePreRefreshSnapshot allows to hook snapshot manager's
+// RestorePreRefreshSnapshot.
+var RestorePreRefreshSnapshot func(st *state.State, instanceName string, rev snap.Revision) (ts *state.TaskSet, err error)
+
 func readInfo(name string, si *snap.SideInfo, flags int) (*snap.Info, error) {
 	info, err := snapReadInfo(name, si)
 	if err != nil && flags&errorOnBroken != 0 {
@@ -922,9 +939,14 @@ func (m *SnapManager) Stop() {
 	defer st.Unlock()
 
 	st.RemoveChangeStatusChangedHandler(m.changeCallbackID)
+	m.stopPeerCache()
 }
 
 func (m *SnapManager) CanStandby() bool {
+	// peers cannot reach the download cache while in standby
+	if m.peerCache != nil {
+		return false
+	}
 	if n, err := NumSnaps(m.state); err == nil && n == 0 {
 		return true
 	}
@@ -1524,6 +1546,8 @@ func (m *SnapManager) Ensure() error {
 		m.ensureMountsUpdated(),
 		m.ensureDesktopFilesUpdated(),
 		m.ensureDownloadsCleaned(),
+		m.ensureHealthRollbacks(),
+		m.ensurePeerCache(),
 	}
 
 	//FIXME: use firstErr helper
@@ -554,6 +554,18 @@ func doInstall(st *state.State, snapst *SnapState, snapsup SnapSetup, compsups [
 		stop.Set("stop-reason", snap.StopReasonRefresh)
 		addTask(stop)
 
+		// snapshot the data with the services stopped, before the
+		// refresh alters it
+		if runRefreshHooks && snapsup.Type == snap.TypeApp && PreRefreshSnapshot != nil {
+			ts, err := PreRefreshSnapshot(st, snapsup.InstanceName(), snapsup.Flags.SnapshotBeforeRefresh)
+			if err != nil && err != ErrNothingToDo {
+				return nil, err
+			}
+			if err == nil {
+				addTasksFromTaskSet(ts)
+			}
+		}
+
 		removeAliases := st.NewTask("remove-aliases", fmt.Sprintf(i18n.G("Remove aliases for snap %q"), snapsup.InstanceName()))
 		removeAliases.Set("remove-reason", removeAliasesReasonRefresh)
 		addTask(removeAliases)
@@ -598,6 +610,17 @@ func doInstall(st *state.State, snapst *SnapState, snapsup SnapSetup, compsups [
 	if !snapsup.Flags.Revert {
 		copyData := st.NewTask("copy-snap-data", fmt.Sprintf(i18n.G("Copy snap %q data"), snapsup.InstanceName()))
 		addTask(copyData)
+	} else if snapsup.Flags.RevertWithData {
+		// bring back the data as it was before refreshing from the
+		// revision we revert to
+		if RestorePreRefreshSnapshot == nil {
+			return nil, fmt.Errorf("internal error: cannot restore data of snap %q: no snapshot support", snapsup.InstanceName())
+		}
+		ts, err := RestorePreRefreshSnapshot(st, snapsup.InstanceName(), snapsup.Revision())
+		if err != nil {
+			return nil, err
+		}
+		addTasksFromTaskSet(ts)
 	}
 
 	// security
@@ -1002,6 +1025,11 @@ var CheckHealthHook = func(st *state.State, snapName string, rev snap.Revision)
 	panic("internal error: snapstate.CheckHealthHook is unset")
 }
 
+// IsHealthError allows to hook the health of snaps into auto-refresh
+// decisions. It returns whether the given revision of the snap last
+// reported an error health status.
+var IsHealthError func(st *state.State, snapName string, rev snap.Revision) bool
+
 var SetupGateAutoRefreshHook = func(st *state.State, snapName string) *state.Task {
 	panic("internal error: snapstate.SetupAutoRefreshGatingHook is unset")
 }
@@ -2955,6 +2983,14 @@ type AutoRefreshOptions struct {
 // snaps on the system. In addition to that it will also refresh important
 // assertions.
 func AutoRefresh(ctx context.Context, st *state.State) ([]string, *UpdateTaskSets, error) {
+	return autoRefreshSnaps(ctx, st, false)
+}
+
+// autoRefreshSnaps does the work of AutoRefresh. fromWindow is whether the
+// auto-refresh was started by the opening of a snap refresh window instead
+// of refresh.timer, in which case only the snaps with an open refresh window
+// are refreshed.
+func autoRefreshSnaps(ctx context.Context, st *state.State, fromWindow bool) ([]string, *UpdateTaskSets, error) {
 	userID := 0
 
 	if AutoRefreshAssertions != nil {
@@ -2971,15 +3007,27 @@ func AutoRefresh(ctx context.Context, st *state.State) ([]string, *UpdateTaskSet
 		return nil, nil, err
 	}
 	if !gateAutoRefreshHook {
+		phasedAllowed, err := phasedAutoRefreshAllowed(st)
+		if err != nil {
+			return nil, nil, err
+		}
+		filter, err := refreshWindowsFilter(st, timeNow(), fromWindow)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !phasedAllowed {
+			logger.Noticef("auto-refresh is phased and the device is not in the part of the fleet allowed to auto-refresh yet")
+			filter = func(*snap.Info, *SnapState) bool { return false }
+		}
 		// old-style refresh (gate-auto-refresh-hook feature disabled)
-		return updateManyFiltered(ctx, st, nil, nil, userID, nil, &Flags{IsAutoRefresh: true}, "")
+		return updateManyFiltered(ctx, st, nil, nil, userID, filter, &Flags{IsAutoRefresh: true}, "")
 	}
 
 	// TODO: rename to autoRefreshTasks when old auto refresh logic gets removed.
 	// TODO2: pass "IsContinuedAutoRefresh" so that the SnapSetup of
 	//        gate-auto-refresh contains this field (required so that
 	//        the update-finished notifications work)
-	updated, tss, err := autoRefreshPhase1(ctx, st, "")
+	updated, tss, err := autoRefreshPhase1(ctx, st, "", fromWindow)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -2990,8 +3038,9 @@ func AutoRefresh(ctx context.Context, st *state.State) ([]string, *UpdateTaskSet
 // autoRefreshPhase1 creates gate-auto-refresh hooks and conditional-auto-refresh
 // task that initiates actual refresh. forGatingSnap is optional and limits auto-refresh
 // to the snaps affecting the given snap only; it defaults to all snaps if nil.
+// fromWindow limits auto-refresh to the snaps with an open refresh window.
 // The state needs to be locked by the caller.
-func autoRefreshPhase1(ctx context.Context, st *state.State, forGatingSnap string) ([]string, []*state.TaskSet, error) {
+func autoRefreshPhase1(ctx context.Context, st *state.State, forGatingSnap string, fromWindow bool) ([]string, []*state.TaskSet, error) {
 	user, err := userFromUserID(st, 0)
 	if err != nil {
 		return nil, nil, err
@@ -3026,6 +3075,22 @@ func autoRefreshPhase1(ctx context.Context, st *state.State, forGatingSnap strin
 		return nil, nil, err
 	}
 
+	// the refresh candidates are kept even if the device cannot pick them
+	// up yet, so that they are reported
+	phasedAllowed, err := phasedAutoRefreshAllowed(st)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !phasedAllowed {
+		logger.Noticef("auto-refresh is phased and the device is not in the part of the fleet allowed to auto-refresh yet")
+		return nil, nil, nil
+	}
+
+	inWindow, err := refreshWindowsFilter(st, timeNow(), fromWindow)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	updates := make([]string, 0, len(hints))
 
 	// check conflicts
@@ -3036,6 +3101,10 @@ func autoRefreshPhase1(ctx context.Context, st *state.State, forGatingSnap strin
 			// filtered out by refreshHintsFromCandidates
 			continue
 		}
+		if !inWindow(t.info, &t.snapst) {
+			logger.Debugf("cannot auto-refresh snap %q outside of its refresh window", name)
+			continue
+		}
 
 		if err := checkChangeConflictIgnoringOneChange(st, name, &t.snapst, fromChange); err != nil {
 			logger.Noticef("cannot refresh snap %q: %v", name, err)
@@ -785,6 +785,82 @@ func (s *snapmgrTestSuite) TestRevertTasksJailMode(c *C) {
 	s.testRevertTasks(snapstate.Flags{JailMode: true}, c)
 }
 
+func (s *snapmgrTestSuite) TestRevertWithDataTasks(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var restoredRev snap.Revision
+	old := snapstate.RestorePreRefreshSnapshot
+	defer func() { snapstate.RestorePreRefreshSnapshot = old }()
+	snapstate.RestorePreRefreshSnapshot = func(st *state.State, instanceName string, rev snap.Revision) (*state.TaskSet, error) {
+		c.Check(instanceName, Equals, "some-snap")
+		restoredRev = rev
+		restore := st.NewTask("restore-snapshot", "...")
+		cleanup := st.NewTask("cleanup-after-restore", "...")
+		cleanup.WaitFor(restore)
+		return state.NewTaskSet(restore, cleanup), nil
+	}
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "some-snap", Revision: snap.R(7)},
+			{RealName: "some-snap", Revision: snap.R(11)},
+		}),
+		Current:  snap.R(11),
+		SnapType: "app",
+	})
+
+	ts, err := snapstate.Revert(s.state, "some-snap", snapstate.Flags{RevertWithData: true}, "")
+	c.Assert(err, IsNil)
+	c.Check(restoredRev, Equals, snap.R(7))
+
+	tasks := ts.Tasks()
+	c.Assert(s.state.TaskCount(), Equals, len(tasks))
+	c.Assert(taskKinds(tasks), DeepEquals, []string{
+		"prerequisites",
+		"prepare-snap",
+		"stop-snap-services",
+		"remove-aliases",
+		"unlink-current-snap",
+		"restore-snapshot",
+		"cleanup-after-restore",
+		"setup-profiles",
+		"link-snap",
+		"auto-connect",
+		"set-auto-aliases",
+		"setup-aliases",
+		"start-snap-services",
+		"run-hook[configure]",
+		"run-hook[check-health]",
+	})
+	c.Check(tasks[7].WaitTasks(), DeepEquals, []*state.Task{tasks[6]})
+}
+
+func (s *snapmgrTestSuite) TestRevertWithDataNoSnapshot(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	old := snapstate.RestorePreRefreshSnapshot
+	defer func() { snapstate.RestorePreRefreshSnapshot = old }()
+	snapstate.RestorePreRefreshSnapshot = func(st *state.State, instanceName string, rev snap.Revision) (*state.TaskSet, error) {
+		return nil, fmt.Errorf("cannot find a snapshot")
+	}
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "some-snap", Revision: snap.R(7)},
+			{RealName: "some-snap", Revision: snap.R(11)},
+		}),
+		Current:  snap.R(11),
+		SnapType: "app",
+	})
+
+	_, err := snapstate.Revert(s.state, "some-snap", snapstate.Flags{RevertWithData: true}, "")
+	c.Assert(err, ErrorMatches, "cannot find a snapshot")
+}
+
 func (s *snapmgrTestSuite) TestRevertTasksClassic(c *C) {
 	restore := maybeMockClassicSupport(c)
 	defer restore()
@@ -777,6 +777,53 @@ func (s *snapmgrTestSuite) TestUpdateTasks(c *C) {
 	c.Check(snapsup.Channel, Equals, "some-channel")
 }
 
+func (s *snapmgrTestSuite) TestUpdateSnapshotBeforeRefresh(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:          true,
+		TrackingChannel: "latest/edge",
+		Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(7)}}),
+		Current:         snap.R(7),
+		SnapType:        "app",
+	})
+
+	var requested []bool
+	old := snapstate.PreRefreshSnapshot
+	defer func() { snapstate.PreRefreshSnapshot = old }()
+	snapstate.PreRefreshSnapshot = func(st *state.State, instanceName string, req bool) (*state.TaskSet, error) {
+		c.Check(instanceName, Equals, "some-snap")
+		requested = append(requested, req)
+		if !req {
+			return nil, snapstate.ErrNothingToDo
+		}
+		return state.NewTaskSet(st.NewTask("save-snapshot", "...")), nil
+	}
+
+	// not asked for
+	ts, err := snapstate.Update(s.state, "some-snap", &snapstate.RevisionOptions{Channel: "some-channel"}, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	c.Check(taskKinds(ts.Tasks()), Not(testutil.Contains), "save-snapshot")
+	for _, t := range ts.Tasks() {
+		t.SetStatus(state.DoneStatus)
+	}
+
+	ts, err = snapstate.Update(s.state, "some-snap", &snapstate.RevisionOptions{Channel: "some-channel"}, s.user.ID, snapstate.Flags{SnapshotBeforeRefresh: true})
+	c.Assert(err, IsNil)
+	c.Check(requested, DeepEquals, []bool{false, true})
+
+	// the snapshot is taken once the services are stopped
+	kinds := taskKinds(ts.Tasks())
+	i := 0
+	for ; i < len(kinds) && kinds[i] != "save-snapshot"; i++ {
+	}
+	c.Assert(i > 0 && i < len(kinds)-1, Equals, true)
+	c.Check(kinds[i-1], Equals, "stop-snap-services")
+	c.Check(kinds[i+1], Equals, "remove-aliases")
+	c.Check(ts.Tasks()[i].WaitTasks(), DeepEquals, []*state.Task{ts.Tasks()[i-1]})
+}
+
 func (s *snapmgrTestSuite) TestUpdateAmendRunThrough(c *C) {
 	const tryMode = false
 	s.testUpdateAmendRunThrough(c, tryMode, nil)
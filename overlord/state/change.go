@@ -249,6 +249,19 @@ func (c *Change) finishUnmarshal() {
 	}
 }
 
+// writing marks the change as modified.
+func (c *Change) writing() {
+	c.state.writingEntries(journalChangePrefix + c.id)
+}
+
+// writingTasks marks the change as modified along with all its tasks.
+func (c *Change) writingTasks() {
+	c.writing()
+	for _, tid := range c.taskIDs {
+		c.state.markDirty(journalTaskPrefix + tid)
+	}
+}
+
 // ID returns the individual random key for the change.
 func (c *Change) ID() string {
 	return c.id
@@ -267,7 +280,7 @@ func (c *Change) Summary() string {
 // Set associates value with key for future consulting by managers.
 // The provided value must properly marshal and unmarshal with encoding/json.
 func (c *Change) Set(key string, value interface{}) {
-	c.state.writing()
+	c.writing()
 	c.data.set(key, value)
 }
 
@@ -460,7 +473,7 @@ func (c *Change) notifyStatusChange(new Status) {
 
 // SetStatus sets the change status, overriding the default behavior (see Status method).
 func (c *Change) SetStatus(s Status) {
-	c.state.writing()
+	c.writing()
 	c.status = s
 	if s.Ready() {
 		c.markReady()
@@ -625,18 +638,19 @@ func (c *Change) State() *State {
 // AddTask registers a task as required for the state change to
 // be accomplished.
 func (c *Change) AddTask(t *Task) {
-	c.state.writing()
+	c.writing()
 	if t.change != "" {
 		panic(fmt.Sprintf("internal error: cannot add one %q task to multiple changes", t.Kind()))
 	}
 	t.change = c.id
 	c.taskIDs = addOnce(c.taskIDs, t.ID())
+	c.state.markDirty(journalTaskPrefix + t.id)
 }
 
 // AddAll registers all tasks in the set as required for the state
 // change to be accomplished.
 func (c *Change) AddAll(ts *TaskSet) {
-	c.state.writing()
+	c.writing()
 	for _, t := range ts.tasks {
 		c.AddTask(t)
 	}
@@ -675,7 +689,7 @@ func (c *Change) LaneTasks(lanes ...int) []*Task {
 // Abort flags the change for cancellation, whether in progress or not.
 // Cancellation will proceed at the next ensure pass.
 func (c *Change) Abort() {
-	c.state.writing()
+	c.writingTasks()
 	tasks := make([]*Task, len(c.taskIDs))
 	for i, tid := range c.taskIDs {
 		tasks[i] = c.state.tasks[tid]
@@ -687,14 +701,14 @@ func (c *Change) Abort() {
 // except for tasks that are also in a healthy lane (not aborted, and not waiting
 // on aborted).
 func (c *Change) AbortLanes(lanes []int) {
-	c.state.writing()
+	c.writingTasks()
 	c.abortLanes(lanes, make(map[int]bool), make(map[string]bool))
 }
 
 // AbortUnreadyLanes aborts the tasks from lanes that aren't fully ready, where
 // a ready lane is one in which all tasks are ready.
 func (c *Change) AbortUnreadyLanes() {
-	c.state.writing()
+	c.writingTasks()
 	c.abortUnreadyLanes()
 }
 
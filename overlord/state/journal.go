@@ -0,0 +1,314 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+)
+
+// A JournalBackend is a Backend that persists the state as separate
+// entries on top of a full copy of it, so that a checkpoint only needs to
+// write the entries modified since the previous one instead of the whole
+// state.
+//
+// The state uses CheckpointEntries instead of Checkpoint when its backend
+// implements JournalBackend.
+type JournalBackend interface {
+	Backend
+	// CheckpointEntries persists the entries modified since the previous
+	// successful checkpoint, an entry with a nil Value having been
+	// removed. It must either persist all of them or none. full returns
+	// the whole state, as passed to Checkpoint, for when the backend
+	// needs to rewrite it as a whole, and must only be called from
+	// within CheckpointEntries.
+	CheckpointEntries(changed []JournalEntry, full func() []byte) error
+}
+
+// JournalEntry is a part of the state persisted independently through a
+// JournalBackend.
+type JournalEntry struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+const (
+	journalMetaKey       = "meta"
+	journalDataPrefix    = "data/"
+	journalChangePrefix  = "change/"
+	journalTaskPrefix    = "task/"
+	journalWarningPrefix = "warning/"
+	journalNoticePrefix  = "notice/"
+)
+
+// journalMeta holds the state counters, persisted as a single entry.
+type journalMeta struct {
+	LastChangeId int `json:"last-change-id"`
+	LastTaskId   int `json:"last-task-id"`
+	LastLaneId   int `json:"last-lane-id"`
+	LastNoticeId int `json:"last-notice-id"`
+
+	LastNoticeTimestamp time.Time `json:"last-notice-timestamp,omitempty"`
+}
+
+func marshalJournalValue(key string, v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// this shouldn't happen, because the actual delicate serializing happens at various Set()s
+		logger.Panicf("internal error: could not marshal state entry %q for checkpointing: %v", key, err)
+	}
+	return data
+}
+
+// journalMetaEntry returns the entry holding the state counters.
+func (s *State) journalMetaEntry() JournalEntry {
+	return JournalEntry{Key: journalMetaKey, Value: marshalJournalValue(journalMetaKey, journalMeta{
+		LastChangeId: s.lastChangeId,
+		LastTaskId:   s.lastTaskId,
+		LastLaneId:   s.lastLaneId,
+		LastNoticeId: s.lastNoticeId,
+
+		LastNoticeTimestamp: s.lastNoticeTimestamp,
+	})}
+}
+
+// allJournalEntries returns all the entries of the state, sorted by key.
+func (s *State) allJournalEntries() []JournalEntry {
+	s.reading()
+
+	all := make([]JournalEntry, 0, 1+len(s.data)+len(s.changes)+len(s.tasks)+len(s.warnings)+len(s.notices))
+	all = append(all, s.journalMetaEntry())
+	for k, v := range s.data {
+		if v == nil {
+			continue
+		}
+		all = append(all, JournalEntry{Key: journalDataPrefix + k, Value: *v})
+	}
+	for id, chg := range s.changes {
+		key := journalChangePrefix + id
+		all = append(all, JournalEntry{Key: key, Value: marshalJournalValue(key, chg)})
+	}
+	for id, t := range s.tasks {
+		key := journalTaskPrefix + id
+		all = append(all, JournalEntry{Key: key, Value: marshalJournalValue(key, t)})
+	}
+	for _, w := range s.flattenWarnings() {
+		key := journalWarningPrefix + w.message
+		all = append(all, JournalEntry{Key: key, Value: marshalJournalValue(key, w)})
+	}
+	for _, n := range s.flattenNotices(nil) {
+		key := journalNoticePrefix + n.id
+		all = append(all, JournalEntry{Key: key, Value: marshalJournalValue(key, n)})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Key < all[j].Key })
+	return all
+}
+
+// dirtyJournalEntries returns the current entries for the keys marked as
+// dirty, sorted by key, with a nil Value for those no longer in the state.
+func (s *State) dirtyJournalEntries() []JournalEntry {
+	s.reading()
+
+	keys := make([]string, 0, len(s.dirty))
+	for key := range s.dirty {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	now := time.Now()
+	var noticesByID map[string]*Notice
+	entries := make([]JournalEntry, 0, len(keys))
+	for _, key := range keys {
+		var v interface{}
+		switch {
+		case key == journalMetaKey:
+			entries = append(entries, s.journalMetaEntry())
+			continue
+		case strings.HasPrefix(key, journalDataPrefix):
+			if raw := s.data[strings.TrimPrefix(key, journalDataPrefix)]; raw != nil {
+				entries = append(entries, JournalEntry{Key: key, Value: *raw})
+				continue
+			}
+		case strings.HasPrefix(key, journalChangePrefix):
+			if chg := s.changes[strings.TrimPrefix(key, journalChangePrefix)]; chg != nil {
+				v = chg
+			}
+		case strings.HasPrefix(key, journalTaskPrefix):
+			if t := s.tasks[strings.TrimPrefix(key, journalTaskPrefix)]; t != nil {
+				v = t
+			}
+		case strings.HasPrefix(key, journalWarningPrefix):
+			if w := s.warnings[strings.TrimPrefix(key, journalWarningPrefix)]; w != nil && !w.ExpiredBefore(now) {
+				v = w
+			}
+		case strings.HasPrefix(key, journalNoticePrefix):
+			if noticesByID == nil {
+				noticesByID = make(map[string]*Notice, len(s.notices))
+				for _, n := range s.notices {
+					noticesByID[n.id] = n
+				}
+			}
+			if n := noticesByID[strings.TrimPrefix(key, journalNoticePrefix)]; n != nil && !n.expired(now) {
+				v = n
+			}
+		}
+		if v == nil {
+			entries = append(entries, JournalEntry{Key: key})
+			continue
+		}
+		entries = append(entries, JournalEntry{Key: key, Value: marshalJournalValue(key, v)})
+	}
+	return entries
+}
+
+// journalEntries returns the entries modified since the last checkpoint
+// through a JournalBackend, an entry with a nil Value having been removed.
+//
+// Only the entries marked as dirty are marshalled, unless the state was
+// modified in a way that could not be tracked down to its entries.
+func (s *State) journalEntries() (changed []JournalEntry) {
+	var current []JournalEntry
+	if s.dirtyAll {
+		current = s.allJournalEntries()
+	} else {
+		current = s.dirtyJournalEntries()
+	}
+
+	seen := make(map[string]bool, len(current))
+	for _, e := range current {
+		seen[e.Key] = true
+		old, ok := s.persisted[e.Key]
+		if e.Value == nil {
+			if ok {
+				changed = append(changed, e)
+			}
+			continue
+		}
+		if !ok || old != sha256.Sum256(e.Value) {
+			changed = append(changed, e)
+		}
+	}
+	if s.dirtyAll {
+		var removed []string
+		for key := range s.persisted {
+			if !seen[key] {
+				removed = append(removed, key)
+			}
+		}
+		sort.Strings(removed)
+		for _, key := range removed {
+			changed = append(changed, JournalEntry{Key: key})
+		}
+	}
+	return changed
+}
+
+// ReadStateJournal returns the state persisted through a JournalBackend,
+// made of the full state read from base, if any, with the given entries
+// applied on top of it, an entry with a nil Value having been removed.
+func ReadStateJournal(backend Backend, base io.Reader, entries []JournalEntry) (*State, error) {
+	values := make(map[string]json.RawMessage, len(entries))
+	if base != nil {
+		bs, err := ReadState(nil, base)
+		if err != nil {
+			return nil, err
+		}
+		bs.Lock()
+		for _, e := range bs.allJournalEntries() {
+			values[e.Key] = e.Value
+		}
+		bs.unlock()
+	}
+	for _, e := range entries {
+		if e.Value == nil {
+			delete(values, e.Key)
+		} else {
+			values[e.Key] = e.Value
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	s := new(State)
+	s.Lock()
+	defer s.unlock()
+
+	unmarshalled := marshalledState{
+		Data:    make(map[string]*json.RawMessage),
+		Changes: make(map[string]*Change),
+		Tasks:   make(map[string]*Task),
+	}
+	persisted := make(map[string][sha256.Size]byte, len(values))
+	for _, key := range keys {
+		e := JournalEntry{Key: key, Value: values[key]}
+		var err error
+		switch {
+		case e.Key == journalMetaKey:
+			var meta journalMeta
+			err = json.Unmarshal(e.Value, &meta)
+			unmarshalled.LastChangeId = meta.LastChangeId
+			unmarshalled.LastTaskId = meta.LastTaskId
+			unmarshalled.LastLaneId = meta.LastLaneId
+			unmarshalled.LastNoticeId = meta.LastNoticeId
+			unmarshalled.LastNoticeTimestamp = meta.LastNoticeTimestamp
+		case strings.HasPrefix(e.Key, journalDataPrefix):
+			value := append(json.RawMessage(nil), e.Value...)
+			unmarshalled.Data[strings.TrimPrefix(e.Key, journalDataPrefix)] = &value
+		case strings.HasPrefix(e.Key, journalChangePrefix):
+			chg := new(Change)
+			err = json.Unmarshal(e.Value, chg)
+			unmarshalled.Changes[strings.TrimPrefix(e.Key, journalChangePrefix)] = chg
+		case strings.HasPrefix(e.Key, journalTaskPrefix):
+			t := new(Task)
+			err = json.Unmarshal(e.Value, t)
+			unmarshalled.Tasks[strings.TrimPrefix(e.Key, journalTaskPrefix)] = t
+		case strings.HasPrefix(e.Key, journalWarningPrefix):
+			w := new(Warning)
+			err = json.Unmarshal(e.Value, w)
+			unmarshalled.Warnings = append(unmarshalled.Warnings, w)
+		case strings.HasPrefix(e.Key, journalNoticePrefix):
+			n := new(Notice)
+			err = json.Unmarshal(e.Value, n)
+			unmarshalled.Notices = append(unmarshalled.Notices, n)
+		default:
+			return nil, fmt.Errorf("cannot read state: unknown journal entry %q", e.Key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read state: cannot decode journal entry %q: %v", e.Key, err)
+		}
+		persisted[e.Key] = sha256.Sum256(e.Value)
+	}
+
+	s.fromMarshalled(&unmarshalled)
+	s.finishRead(backend)
+	s.persisted = persisted
+	s.dirtyAll = false
+	return s, nil
+}
@@ -0,0 +1,250 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type journalSuite struct{}
+
+var _ = Suite(&journalSuite{})
+
+type fakeJournalBackend struct {
+	fakeStateBackend
+	changed [][]state.JournalEntry
+	full    []byte
+}
+
+func (b *fakeJournalBackend) CheckpointEntries(changed []state.JournalEntry, full func() []byte) error {
+	if b.error != nil {
+		if err := b.error(); err != nil {
+			return err
+		}
+	}
+	b.changed = append(b.changed, changed)
+	b.full = full()
+	return nil
+}
+
+func journalKeys(entries []state.JournalEntry) []string {
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+func (js *journalSuite) TestCheckpointEntries(c *C) {
+	b := new(fakeJournalBackend)
+	st := state.New(b)
+	st.Lock()
+	st.Set("a", 1)
+	st.Set("b", 2)
+	chg := st.NewChange("install", "...")
+	t := st.NewTask("download", "...")
+	chg.AddTask(t)
+	st.Unlock()
+
+	// the whole state is written the first time
+	c.Assert(b.changed, HasLen, 1)
+	c.Check(journalKeys(b.changed[0]), DeepEquals, []string{"change/1", "data/a", "data/b", "meta", "notice/1", "task/1"})
+	// and never through Checkpoint
+	c.Check(b.checkpoints, HasLen, 0)
+
+	st.Lock()
+	st.Set("a", 10)
+	st.Unlock()
+
+	// only the modified entry is written afterwards
+	c.Assert(b.changed, HasLen, 2)
+	c.Check(b.changed[1], DeepEquals, []state.JournalEntry{{Key: "data/a", Value: json.RawMessage("10")}})
+
+	st.Lock()
+	t.Set("foo", "bar")
+	st.Set("b", nil)
+	st.Unlock()
+
+	// removed entries have no value
+	c.Assert(b.changed, HasLen, 3)
+	c.Check(journalKeys(b.changed[2]), DeepEquals, []string{"data/b", "task/1"})
+	c.Check(b.changed[2][0].Value, IsNil)
+
+	// the whole state is available to the backend as well
+	var full map[string]interface{}
+	c.Assert(json.Unmarshal(b.full, &full), IsNil)
+	c.Check(full["data"], DeepEquals, map[string]interface{}{"a": 10.0})
+}
+
+func (js *journalSuite) TestCheckpointEntriesRetry(c *C) {
+	restore := state.MockCheckpointRetryDelay(2*time.Millisecond, 1*time.Second)
+	defer restore()
+
+	b := new(fakeJournalBackend)
+	st := state.New(b)
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+
+	retries := 0
+	b.error = func() error {
+		retries++
+		if retries == 2 {
+			return nil
+		}
+		return errors.New("boom")
+	}
+	st.Lock()
+	st.Set("a", 2)
+	st.Unlock()
+
+	c.Check(retries, Equals, 2)
+	c.Assert(b.changed, HasLen, 2)
+	c.Check(journalKeys(b.changed[1]), DeepEquals, []string{"data/a"})
+}
+
+func (js *journalSuite) TestReadStateJournal(c *C) {
+	b := new(fakeJournalBackend)
+	st := state.New(b)
+	st.Lock()
+	st.Set("a", 1)
+	chg := st.NewChange("install", "...")
+	t := st.NewTask("download", "...")
+	chg.AddTask(t)
+	st.Warnf("hello")
+	st.AddNotice(nil, state.ChangeUpdateNotice, "1", nil)
+	st.Unlock()
+	base := b.full
+
+	// entries journaled on top of the full state
+	st.Lock()
+	st.Set("a", 10)
+	st.Set("c", 3)
+	t.SetStatus(state.DoingStatus)
+	c.Assert(st.RemoveWarning("hello"), IsNil)
+	st.Unlock()
+	var entries []state.JournalEntry
+	for _, changed := range b.changed[1:] {
+		entries = append(entries, changed...)
+	}
+
+	b2 := new(fakeJournalBackend)
+	st2, err := state.ReadStateJournal(b2, bytes.NewReader(base), entries)
+	c.Assert(err, IsNil)
+	c.Check(st2.Modified(), Equals, false)
+
+	// the same state as read from state.json
+	st.Lock()
+	data, err := st.MarshalJSON()
+	st.Unlock()
+	c.Assert(err, IsNil)
+	st1, err := state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	st1.Lock()
+	data1, err := st1.MarshalJSON()
+	st1.Unlock()
+	c.Assert(err, IsNil)
+
+	st2.Lock()
+	data2, err := st2.MarshalJSON()
+	c.Assert(err, IsNil)
+	c.Check(string(data2), Equals, string(data1))
+	c.Check(st2.Change(chg.ID()).Tasks(), HasLen, 1)
+
+	var a int
+	c.Assert(st2.Get("a", &a), IsNil)
+	c.Check(a, Equals, 10)
+	c.Check(st2.AllWarnings(), HasLen, 0)
+
+	st2.Set("b", 2)
+	st2.Unlock()
+	c.Assert(b2.changed, HasLen, 1)
+	// only the modified entry is written
+	c.Check(journalKeys(b2.changed[0]), DeepEquals, []string{"data/b"})
+}
+
+func (js *journalSuite) TestCheckpointEntriesOnlyDirty(c *C) {
+	b := new(fakeJournalBackend)
+	st := state.New(b)
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("download", "...")
+	t2 := st.NewTask("link", "...")
+	chg.AddTask(t1)
+	chg.AddTask(t2)
+	st.Unlock()
+	c.Assert(b.changed, HasLen, 1)
+
+	st.Lock()
+	// non-final progress doesn't mark the task for checkpointing
+	t1.SetProgress("", 1, 2)
+	st.Set("a", 1)
+	st.Unlock()
+
+	// so the task is not even looked at
+	c.Assert(b.changed, HasLen, 2)
+	c.Check(journalKeys(b.changed[1]), DeepEquals, []string{"data/a"})
+
+	st.Lock()
+	t1.SetStatus(state.DoingStatus)
+	st.Unlock()
+
+	// the task and its change are written, along with the
+	// change-update notice
+	c.Assert(b.changed, HasLen, 3)
+	c.Check(journalKeys(b.changed[2]), DeepEquals, []string{"change/1", "meta", "notice/1", "task/1"})
+
+	st.Lock()
+	chg.Abort()
+	st.Unlock()
+
+	// aborting the change touches all its tasks
+	c.Assert(b.changed, HasLen, 4)
+	c.Check(journalKeys(b.changed[3]), DeepEquals, []string{"change/1", "meta", "notice/1", "task/1", "task/2"})
+
+	st.Lock()
+	st.Warnf("hello")
+	st.OkayWarnings(time.Now())
+	c.Check(st.RemoveWarning("hello"), IsNil)
+	st.Unlock()
+
+	// an entry added and removed between checkpoints is not written
+	c.Assert(b.changed, HasLen, 5)
+	c.Check(b.changed[4], HasLen, 0)
+}
+
+func (js *journalSuite) TestReadStateJournalErrors(c *C) {
+	_, err := state.ReadStateJournal(nil, nil, []state.JournalEntry{{Key: "foo", Value: json.RawMessage("1")}})
+	c.Check(err, ErrorMatches, `cannot read state: unknown journal entry "foo"`)
+
+	_, err = state.ReadStateJournal(nil, nil, []state.JournalEntry{{Key: "task/1", Value: json.RawMessage("1")}})
+	c.Check(err, ErrorMatches, `cannot read state: cannot decode journal entry "task/1": .*`)
+
+	_, err = state.ReadStateJournal(nil, strings.NewReader("{"), nil)
+	c.Check(err, ErrorMatches, `cannot read state: .*`)
+}
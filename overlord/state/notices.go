@@ -108,6 +108,12 @@ func (n *Notice) Type() NoticeType {
 	return n.noticeType
 }
 
+// LastRepeated returns the time the notice last repeated, the time it is
+// ordered by and filtered on by NoticeFilter.After.
+func (n *Notice) LastRepeated() time.Time {
+	return n.lastRepeated
+}
+
 func flattenUserID(userID *uint32) (uid uint32, isSet bool) {
 	if userID == nil {
 		return 0, false
@@ -214,11 +220,41 @@ const (
 	// expired. The key for interfaces-requests-rule-update notices is the
 	// rule ID.
 	InterfacesRequestsRuleUpdateNotice NoticeType = "interfaces-requests-rule-update"
+
+	// Recorded whenever a snap is installed. The key for snap-installed
+	// notices is the snap instance name.
+	SnapInstalledNotice NoticeType = "snap-installed"
+
+	// Recorded whenever a snap is removed, or its installation is undone.
+	// The key for snap-removed notices is the snap instance name.
+	SnapRemovedNotice NoticeType = "snap-removed"
+
+	// Recorded whenever the current revision of a snap changes, when it is
+	// refreshed or reverted. The key for snap-refreshed notices is the snap
+	// instance name.
+	SnapRefreshedNotice NoticeType = "snap-refreshed"
+
+	// Recorded whenever a plug is connected to a slot. The key for
+	// interface-connected notices is the connection ID, in the
+	// "<snap>:<plug> <snap>:<slot>" form.
+	InterfaceConnectedNotice NoticeType = "interface-connected"
+
+	// Recorded whenever a plug is disconnected from a slot. The key for
+	// interface-disconnected notices is the connection ID, in the
+	// "<snap>:<plug> <snap>:<slot>" form.
+	InterfaceDisconnectedNotice NoticeType = "interface-disconnected"
+
+	// Recorded whenever the health status of a snap changes, as reported
+	// by its check-health hook or "snapctl set-health". The key for
+	// snap-health notices is the snap instance name.
+	SnapHealthNotice NoticeType = "snap-health"
 )
 
 func (t NoticeType) Valid() bool {
 	switch t {
-	case ChangeUpdateNotice, WarningNotice, RefreshInhibitNotice, SnapRunInhibitNotice, InterfacesRequestsPromptNotice, InterfacesRequestsRuleUpdateNotice:
+	case ChangeUpdateNotice, WarningNotice, RefreshInhibitNotice, SnapRunInhibitNotice, InterfacesRequestsPromptNotice, InterfacesRequestsRuleUpdateNotice,
+		SnapInstalledNotice, SnapRemovedNotice, SnapRefreshedNotice, InterfaceConnectedNotice, InterfaceDisconnectedNotice,
+		SnapHealthNotice:
 		return true
 	}
 	return false
@@ -248,7 +284,7 @@ func (s *State) AddNotice(userID *uint32, noticeType NoticeType, key string, opt
 		return "", fmt.Errorf("internal error: %w", err)
 	}
 
-	s.writing()
+	s.writingEntries(journalMetaKey)
 
 	now := options.Time
 	if now.IsZero() {
@@ -300,6 +336,7 @@ func (s *State) AddNotice(userID *uint32, noticeType NoticeType, key string, opt
 			newOrRepeated = true
 		}
 	}
+	s.markDirty(journalNoticePrefix + notice.id)
 	notice.lastOccurred = now
 	notice.lastData = options.Data
 	notice.repeatAfter = options.RepeatAfter
@@ -673,6 +673,41 @@ func (s *noticesSuite) TestValidateNotice(c *C) {
 	c.Check(id, Equals, "")
 }
 
+func (s *noticesSuite) TestLifecycleNoticeTypes(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	for _, noticeType := range []state.NoticeType{
+		state.SnapInstalledNotice,
+		state.SnapRemovedNotice,
+		state.SnapRefreshedNotice,
+		state.SnapHealthNotice,
+	} {
+		c.Check(noticeType.Valid(), Equals, true)
+		addNotice(c, st, nil, noticeType, "snap-name", nil)
+	}
+	for _, noticeType := range []state.NoticeType{
+		state.InterfaceConnectedNotice,
+		state.InterfaceDisconnectedNotice,
+	} {
+		c.Check(noticeType.Valid(), Equals, true)
+		addNotice(c, st, nil, noticeType, "consumer:plug producer:slot", nil)
+	}
+
+	notices := st.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.SnapRefreshedNotice}})
+	c.Assert(notices, HasLen, 1)
+	n := noticeToMap(c, notices[0])
+	c.Check(n["type"], Equals, "snap-refreshed")
+	c.Check(n["key"], Equals, "snap-name")
+
+	notices = st.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.InterfaceDisconnectedNotice}})
+	c.Assert(notices, HasLen, 1)
+	n = noticeToMap(c, notices[0])
+	c.Check(n["type"], Equals, "interface-disconnected")
+	c.Check(n["key"], Equals, "consumer:plug producer:slot")
+}
+
 func (s *noticesSuite) TestAvoidTwoNoticesWithSameDateTime(c *C) {
 	st := state.New(nil)
 	st.Lock()
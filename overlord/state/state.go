@@ -21,6 +21,7 @@
 package state
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -103,6 +104,14 @@ type State struct {
 	noticeCond *sync.Cond
 
 	modified bool
+	// persisted holds the digests of the entries last checkpointed
+	// through a JournalBackend
+	persisted map[string][sha256.Size]byte
+	// dirty holds the journal entries modified since the last
+	// checkpoint, unless dirtyAll is set because the modification
+	// could not be tracked down to the entries it touched
+	dirty    map[string]bool
+	dirtyAll bool
 
 	cache map[interface{}]interface{}
 
@@ -123,6 +132,8 @@ func New(backend Backend) *State {
 		warnings:            make(map[string]*Warning),
 		notices:             make(map[noticeKey]*Notice),
 		modified:            true,
+		dirty:               make(map[string]bool),
+		dirtyAll:            true,
 		cache:               make(map[interface{}]interface{}),
 		pendingChangeByAttr: make(map[string]func(*Change) bool),
 		taskHandlers:        make(map[int]func(t *Task, old Status, new Status) bool),
@@ -149,11 +160,20 @@ func (s *State) reading() {
 	}
 }
 
+// writing marks the state as modified, as a whole.
 func (s *State) writing() {
+	s.writingEntries()
+	s.dirtyAll = true
+}
+
+// writingEntries marks the state as modified, journaling only the given
+// entries on the next checkpoint if the backend is a JournalBackend.
+func (s *State) writingEntries(keys ...string) {
 	s.modified = true
 	if atomic.LoadInt32(&s.muC) != 1 {
 		panic("internal error: accessing state without lock")
 	}
+	s.markDirty(keys...)
 }
 
 func (s *State) unlock() {
@@ -203,6 +223,11 @@ func (s *State) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	s.fromMarshalled(&unmarshalled)
+	return nil
+}
+
+func (s *State) fromMarshalled(unmarshalled *marshalledState) {
 	s.data = unmarshalled.Data
 	s.changes = unmarshalled.Changes
 	s.tasks = unmarshalled.Tasks
@@ -221,7 +246,49 @@ func (s *State) UnmarshalJSON(data []byte) error {
 		chg.state = s
 		chg.finishUnmarshal()
 	}
-	return nil
+}
+
+// markDirty records that the given journal entries need to be written on
+// the next checkpoint, if the backend is a JournalBackend.
+func (s *State) markDirty(keys ...string) {
+	if _, ok := s.backend.(JournalBackend); !ok {
+		return
+	}
+	for _, key := range keys {
+		s.dirty[key] = true
+	}
+}
+
+// checkpointFunc returns a function persisting the state through its
+// backend, journaling only the modified entries if the backend supports it.
+func (s *State) checkpointFunc() func() error {
+	if jb, ok := s.backend.(JournalBackend); ok {
+		changed := s.journalEntries()
+		return func() error {
+			if err := jb.CheckpointEntries(changed, s.checkpointData); err != nil {
+				return err
+			}
+			if s.persisted == nil {
+				s.persisted = make(map[string][sha256.Size]byte, len(changed))
+			}
+			for _, e := range changed {
+				if e.Value == nil {
+					delete(s.persisted, e.Key)
+				} else {
+					s.persisted[e.Key] = sha256.Sum256(e.Value)
+				}
+			}
+			for key := range s.dirty {
+				delete(s.dirty, key)
+			}
+			s.dirtyAll = false
+			return nil
+		}
+	}
+	data := s.checkpointData()
+	return func() error {
+		return s.backend.Checkpoint(data)
+	}
 }
 
 func (s *State) checkpointData() []byte {
@@ -258,11 +325,11 @@ func (s *State) Unlock() {
 		return
 	}
 
-	data := s.checkpointData()
+	checkpoint := s.checkpointFunc()
 	var err error
 	start := time.Now()
 	for time.Since(start) <= unlockCheckpointRetryMaxTime {
-		if err = s.backend.Checkpoint(data); err == nil {
+		if err = checkpoint(); err == nil {
 			s.modified = false
 			return
 		}
@@ -320,7 +387,7 @@ func (s *State) Has(key string) bool {
 // Set associates value with key for future consulting by managers.
 // The provided value must properly marshal and unmarshal with encoding/json.
 func (s *State) Set(key string, value interface{}) {
-	s.writing()
+	s.writingEntries(journalDataPrefix + key)
 	s.data.set(key, value)
 }
 
@@ -344,9 +411,10 @@ func (s *State) Cache(key, value interface{}) {
 
 // NewChange adds a new change to the state.
 func (s *State) NewChange(kind, summary string) *Change {
-	s.writing()
+	s.writingEntries(journalMetaKey)
 	s.lastChangeId++
 	id := strconv.Itoa(s.lastChangeId)
+	s.markDirty(journalChangePrefix + id)
 	chg := newChange(s, id, kind, summary)
 	s.changes[id] = chg
 	// Add change-update notice for newly spawned change
@@ -359,7 +427,7 @@ func (s *State) NewChange(kind, summary string) *Change {
 
 // NewLane creates a new lane in the state.
 func (s *State) NewLane() int {
-	s.writing()
+	s.writingEntries(journalMetaKey)
 	s.lastLaneId++
 	return s.lastLaneId
 }
@@ -384,9 +452,10 @@ func (s *State) Change(id string) *Change {
 // It usually will be registered with a Change using AddTask or
 // through a TaskSet.
 func (s *State) NewTask(kind, summary string) *Task {
-	s.writing()
+	s.writingEntries(journalMetaKey)
 	s.lastTaskId++
 	id := strconv.Itoa(s.lastTaskId)
+	s.markDirty(journalTaskPrefix + id)
 	t := newTask(s, id, kind, summary)
 	s.tasks[id] = t
 	return t
@@ -422,6 +491,37 @@ func (s *State) TaskCount() int {
 	return len(s.tasks)
 }
 
+// DiscardTasks removes the given tasks from the state. None of them may be
+// linked to a change. This is useful to drop tasks that were only built to
+// inspect what an operation would do, instead of leaving them to Prune.
+func (s *State) DiscardTasks(tasks []*Task) error {
+	for _, t := range tasks {
+		if chg := t.Change(); chg != nil {
+			return fmt.Errorf("internal error: cannot discard task %s linked to change %s", t.ID(), chg.ID())
+		}
+	}
+	s.writing()
+	for _, t := range tasks {
+		delete(s.tasks, t.ID())
+	}
+	return nil
+}
+
+// DiscardChange removes the given change and its tasks from the state. The
+// change must be ready. This is useful to drop changes that are superseded
+// by a newer one of the same kind, instead of leaving them to Prune.
+func (s *State) DiscardChange(chg *Change) error {
+	s.writing()
+	if !chg.IsReady() {
+		return fmt.Errorf("internal error: cannot discard change %s that is not ready", chg.ID())
+	}
+	for _, t := range chg.Tasks() {
+		delete(s.tasks, t.ID())
+	}
+	delete(s.changes, chg.ID())
+	return nil
+}
+
 func (s *State) tasksIn(tids []string) []*Task {
 	res := make([]*Task, len(tids))
 	for i, tid := range tids {
@@ -603,12 +703,20 @@ func ReadState(backend Backend, r io.Reader) (*State, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot read state: %s", err)
 	}
+	s.finishRead(backend)
+	return s, err
+}
+
+// finishRead sets up the runtime parts of a state just read from disk.
+func (s *State) finishRead(backend Backend) {
 	s.backend = backend
 	s.noticeCond = sync.NewCond(s)
 	s.modified = false
+	s.persisted = make(map[string][sha256.Size]byte)
+	s.dirty = make(map[string]bool)
+	s.dirtyAll = true
 	s.cache = make(map[interface{}]interface{})
 	s.pendingChangeByAttr = make(map[string]func(*Change) bool)
 	s.changeHandlers = make(map[int]func(chg *Change, old Status, new Status))
 	s.taskHandlers = make(map[int]func(t *Task, old Status, new Status) bool)
-	return s, err
 }
@@ -577,6 +577,8 @@ func (ss *stateSuite) TestEmptyStateDataAndCheckpointReadAndSet(c *C) {
 		"tasks",
 		"warnings",
 		"notices",
+		"persisted",
+		"dirty",
 		"cache",
 		"pendingChangeByAttr",
 		"taskHandlers",
@@ -743,6 +745,54 @@ func (ss *stateSuite) TestNewTaskHiddenUntilLinked(c *C) {
 	c.Check(st.Task(t1.ID()), IsNil)
 }
 
+func (ss *stateSuite) TestDiscardTasks(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	t1 := st.NewTask("check", "...")
+	t2 := st.NewTask("check", "...")
+	t3 := st.NewTask("check", "...")
+	c.Assert(st.TaskCount(), Equals, 3)
+
+	err := st.DiscardTasks([]*state.Task{t1, t2})
+	c.Assert(err, IsNil)
+	c.Check(st.TaskCount(), Equals, 1)
+
+	chg := st.NewChange("install", "...")
+	chg.AddTask(t3)
+
+	err = st.DiscardTasks([]*state.Task{t3})
+	c.Assert(err, ErrorMatches, `internal error: cannot discard task 3 linked to change 1`)
+	c.Check(st.TaskCount(), Equals, 1)
+	c.Check(st.Task(t3.ID()), Equals, t3)
+}
+
+func (ss *stateSuite) TestDiscardChange(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("check", "...")
+	chg.AddTask(t1)
+	other := st.NewChange("remove", "...")
+	t2 := st.NewTask("check", "...")
+	other.AddTask(t2)
+
+	err := st.DiscardChange(chg)
+	c.Assert(err, ErrorMatches, `internal error: cannot discard change 1 that is not ready`)
+	c.Check(st.Changes(), HasLen, 2)
+
+	t1.SetStatus(state.DoneStatus)
+	err = st.DiscardChange(chg)
+	c.Assert(err, IsNil)
+	c.Check(st.Change(chg.ID()), IsNil)
+	c.Check(st.Task(t1.ID()), IsNil)
+	c.Check(st.Changes(), DeepEquals, []*state.Change{other})
+	c.Check(st.TaskCount(), Equals, 1)
+}
+
 func (ss *stateSuite) TestMethodEntrance(c *C) {
 	st := state.New(&fakeStateBackend{})
 
@@ -759,6 +809,8 @@ func (ss *stateSuite) TestMethodEntrance(c *C) {
 		func() { st.Warnf("hello") },
 		func() { st.OkayWarnings(time.Time{}) },
 		func() { st.UnshowAllWarnings() },
+		func() { st.DiscardTasks(nil) },
+		func() { st.DiscardChange(nil) },
 	}
 
 	reads := []func(){
@@ -202,7 +202,7 @@ func (s *State) AddWarning(message string, options *AddWarningOptions) {
 		options = &AddWarningOptions{}
 	}
 
-	s.writing()
+	s.writingEntries(journalWarningPrefix + message)
 
 	now := options.Time
 	if now.IsZero() {
@@ -233,7 +233,7 @@ func (s *State) AddWarning(message string, options *AddWarningOptions) {
 //
 // Returns state.ErrNoState if no warning exists with given message.
 func (s *State) RemoveWarning(message string) error {
-	s.writing()
+	s.writingEntries(journalWarningPrefix + message)
 	_, ok := s.warnings[message]
 	if !ok {
 		return ErrNoState
@@ -263,12 +263,13 @@ func (s *State) AllWarnings() []*Warning {
 // OkayWarnings marks warnings that were showable at the given time as shown.
 func (s *State) OkayWarnings(t time.Time) int {
 	t = t.UTC()
-	s.writing()
+	s.writingEntries()
 
 	n := 0
 	for _, w := range s.warnings {
 		if w.ShowAfter(t) {
 			w.lastShown = t
+			s.markDirty(journalWarningPrefix + w.message)
 			n++
 		}
 	}
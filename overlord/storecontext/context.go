@@ -76,6 +76,17 @@ type StoreOptions interface {
 	// StoreOffline returns a string indicating whether the store should have
 	// network access or not
 	StoreOffline() (bool, error)
+
+	// PeerCachePeers returns the peers to fetch snap downloads from before
+	// the store
+	PeerCachePeers() ([]string, error)
+
+	// PeerCacheToken returns the token shared by the peers
+	PeerCacheToken() (string, error)
+
+	// DownloadCachePolicy returns the limits and pinned entries of the
+	// cache of downloaded snaps
+	DownloadCachePolicy() (*store.CachePolicy, error)
 }
 
 // storeContext implements store.DeviceAndAuthContext.
@@ -263,6 +274,48 @@ func (sc *storeContext) StoreOffline() (bool, error) {
 	return offline, nil
 }
 
+// PeerCachePeers returns the URLs of the peers to fetch snap downloads from
+// before the store.
+func (sc *storeContext) PeerCachePeers() ([]string, error) {
+	sc.state.Lock()
+	defer sc.state.Unlock()
+
+	peers, err := sc.storeOptions.PeerCachePeers()
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+
+	return peers, nil
+}
+
+// DownloadCachePolicy returns the policy of the cache of downloaded snaps,
+// nil if there is none.
+func (sc *storeContext) DownloadCachePolicy() (*store.CachePolicy, error) {
+	sc.state.Lock()
+	defer sc.state.Unlock()
+
+	policy, err := sc.storeOptions.DownloadCachePolicy()
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// PeerCacheToken returns the token shared by the peers, which they require
+// to serve snap downloads.
+func (sc *storeContext) PeerCacheToken() (string, error) {
+	sc.state.Lock()
+	defer sc.state.Unlock()
+
+	token, err := sc.storeOptions.PeerCacheToken()
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return "", err
+	}
+
+	return token, nil
+}
+
 // CloudInfo returns the cloud instance information (if available).
 func (sc *storeContext) CloudInfo() (*auth.CloudInfo, error) {
 	sc.state.Lock()
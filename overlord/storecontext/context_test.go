@@ -308,6 +308,10 @@ type testBackend struct {
 	noSerial     bool
 	storeOffline bool
 	device       *auth.DeviceState
+
+	peerCachePeers      []string
+	peerCacheToken      string
+	downloadCachePolicy *store.CachePolicy
 }
 
 func (b *testBackend) Device() (*auth.DeviceState, error) {
@@ -379,6 +383,30 @@ func (b *testBackend) StoreOffline() (bool, error) {
 	return b.storeOffline, nil
 }
 
+func (b *testBackend) PeerCachePeers() ([]string, error) {
+	if b.nothing {
+		return nil, state.ErrNoState
+	}
+
+	return b.peerCachePeers, nil
+}
+
+func (b *testBackend) PeerCacheToken() (string, error) {
+	if b.nothing {
+		return "", state.ErrNoState
+	}
+
+	return b.peerCacheToken, nil
+}
+
+func (b *testBackend) DownloadCachePolicy() (*store.CachePolicy, error) {
+	if b.nothing {
+		return nil, state.ErrNoState
+	}
+
+	return b.downloadCachePolicy, nil
+}
+
 func (s *storeCtxSuite) TestMissingDeviceAssertions(c *C) {
 	// no assertions in state
 	storeCtx := storecontext.New(s.state, &testBackend{nothing: true})
@@ -506,3 +534,54 @@ func (s *storeCtxSuite) TestStoreOffline(c *C) {
 	c.Check(err, IsNil)
 	c.Check(offline, Equals, true)
 }
+
+func (s *storeCtxSuite) TestPeerCachePeers(c *C) {
+	b := &testBackend{
+		peerCachePeers: []string{"http://192.168.1.10:7353"},
+	}
+	storeCtx := storecontext.NewComposed(s.state, b, b, b)
+
+	peers, err := storeCtx.PeerCachePeers()
+	c.Check(err, IsNil)
+	c.Check(peers, DeepEquals, []string{"http://192.168.1.10:7353"})
+
+	// no peers configured
+	storeCtx = storecontext.NewComposed(s.state, b, b, &testBackend{nothing: true})
+	peers, err = storeCtx.PeerCachePeers()
+	c.Check(err, IsNil)
+	c.Check(peers, HasLen, 0)
+}
+
+func (s *storeCtxSuite) TestPeerCacheToken(c *C) {
+	b := &testBackend{
+		peerCacheToken: "shared-peer-cache-token",
+	}
+	storeCtx := storecontext.NewComposed(s.state, b, b, b)
+
+	token, err := storeCtx.PeerCacheToken()
+	c.Check(err, IsNil)
+	c.Check(token, Equals, "shared-peer-cache-token")
+
+	// no token configured
+	storeCtx = storecontext.NewComposed(s.state, b, b, &testBackend{nothing: true})
+	token, err = storeCtx.PeerCacheToken()
+	c.Check(err, IsNil)
+	c.Check(token, Equals, "")
+}
+
+func (s *storeCtxSuite) TestDownloadCachePolicy(c *C) {
+	b := &testBackend{
+		downloadCachePolicy: &store.CachePolicy{MaxItems: 10, Pinned: []string{"some-key"}},
+	}
+	storeCtx := storecontext.NewComposed(s.state, b, b, b)
+
+	policy, err := storeCtx.DownloadCachePolicy()
+	c.Check(err, IsNil)
+	c.Check(policy, DeepEquals, &store.CachePolicy{MaxItems: 10, Pinned: []string{"some-key"}})
+
+	// no policy
+	storeCtx = storecontext.NewComposed(s.state, b, b, &testBackend{nothing: true})
+	policy, err = storeCtx.DownloadCachePolicy()
+	c.Check(err, IsNil)
+	c.Check(policy, IsNil)
+}
@@ -23,6 +23,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"sync"
 
 	"github.com/snapcore/snapd/logger"
@@ -280,3 +281,86 @@ func MonitorSnapEnded(snapName string, channel chan<- string) error {
 	logger.Debugf("snap %s has %d processes: %v", snapName, len(paths), paths)
 	return currentWatcher.monitorDelete(paths, snapName, channel)
 }
+
+// userSessionScopes returns the cgroups of the login sessions in the given
+// user slice. These are the session-<id>.scope units which logind creates for
+// each session of the user, as opposed to the user@<uid>.service unit of the
+// user's service manager, which remains while lingering is enabled for the
+// user.
+func userSessionScopes(userSlice string) []string {
+	scopes, err := filepath.Glob(filepath.Join(userSlice, "session-*.scope"))
+	if err != nil {
+		// Only possible if the pattern is malformed
+		logger.Noticef("internal error: cannot list session scopes: %v", err)
+	}
+	return scopes
+}
+
+// MonitorUserSessionEnded monitors the login sessions of the user with the
+// given UID. Logind creates a scope in the slice of the user in the tracking
+// cgroup hierarchy for each session, and the scope is removed when the session
+// ends. Once there are no scopes left, the UID is pushed through the supplied
+// channel. This allows the caller to use the same channel to monitor several
+// users.
+//
+// Sessions are monitored from a dedicated goroutine, so that the caller is
+// never blocked by the shared watcher, and neither is the watcher blocked by a
+// caller which is busy when the sessions end. If the sessions cannot be
+// monitored, they are considered to have ended. Once the done channel is
+// closed, the goroutine exits without pushing anything through the channel,
+// so that it does not wait for a caller which stopped receiving.
+func MonitorUserSessionEnded(uid uint32, channel chan<- string, done <-chan struct{}) error {
+	ver, err := Version()
+	if err != nil {
+		return err
+	}
+	trackingRoot := filepath.Join(rootPath, cgroupMountPoint)
+	if ver != V2 {
+		// In v1 mode user slices are tracked by the name=systemd hierarchy
+		trackingRoot = filepath.Join(trackingRoot, "systemd")
+	}
+	userSlice := filepath.Join(trackingRoot, "user.slice", fmt.Sprintf("user-%d.slice", uid))
+	name := strconv.FormatUint(uint64(uid), 10)
+
+	go func() {
+		ended := make(chan string, 1)
+		for {
+			// The user may have logged in again while the previously
+			// known sessions were active, so look for sessions again
+			// once they have all ended.
+			scopes := userSessionScopes(userSlice)
+			logger.Debugf("user %d has %d active sessions: %v", uid, len(scopes), scopes)
+			if len(scopes) == 0 {
+				break
+			}
+			if err := currentWatcher.monitorDelete(scopes, name, ended); err != nil {
+				logger.Noticef("cannot monitor sessions of user %d, considering them ended: %v", uid, err)
+				break
+			}
+			select {
+			case <-ended:
+			case <-done:
+				// the watcher does not block on the buffered
+				// channel once the sessions end
+				return
+			}
+		}
+		select {
+		case <-done:
+			// do not race the send against a done channel which
+			// was closed while waiting for the sessions
+			return
+		default:
+		}
+		defer func() {
+			if err := recover(); err != nil {
+				logger.Noticef("cannot send session ended notification for user %d", uid)
+			}
+		}()
+		select {
+		case channel <- name:
+		case <-done:
+		}
+	}()
+	return nil
+}
@@ -21,6 +21,7 @@ package cgroup_test
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path"
 	"path/filepath"
@@ -370,6 +371,163 @@ func (s *monitorSuite) TestMonitorSnapEndedIntegration(c *C) {
 	c.Check(snapName, Equals, "firefox")
 }
 
+func (s *monitorSuite) TestMonitorUserSessionEndedNonExisting(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.AddCleanup(cgroup.MockInotifyWatcher(ctx, nil))
+	defer cancel()
+
+	restore := cgroup.MockVersion(cgroup.V2, nil)
+	s.AddCleanup(restore)
+
+	err := cgroup.MonitorUserSessionEnded(1234, s.eventsCh, nil)
+	c.Assert(err, IsNil)
+
+	event := <-s.eventsCh
+	c.Check(event, Equals, "1234")
+}
+
+func (s *monitorSuite) TestMonitorUserSessionEndedIntegration(c *C) {
+	for _, tc := range []struct {
+		ver  int
+		root string
+	}{
+		{cgroup.V2, "/sys/fs/cgroup"},
+		{cgroup.V1, "/sys/fs/cgroup/systemd"},
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		restoreWatcher := cgroup.MockInotifyWatcher(ctx, nil)
+		restoreVersion := cgroup.MockVersion(tc.ver, nil)
+
+		userSlice := filepath.Join(dirs.GlobalRootDir, tc.root, "user.slice/user-1000.slice")
+		session2 := filepath.Join(userSlice, "session-2.scope")
+		session3 := filepath.Join(userSlice, "session-3.scope")
+		c.Assert(os.MkdirAll(session2, 0755), IsNil)
+		c.Assert(os.MkdirAll(filepath.Join(userSlice, "user@1000.service"), 0755), IsNil)
+
+		err := cgroup.MonitorUserSessionEnded(1000, s.eventsCh, nil)
+		c.Assert(err, IsNil)
+
+		select {
+		case event := <-s.eventsCh:
+			c.Fatalf("unexpected event %q while the session is active", event)
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		// the user logs in again, then out of the first session
+		c.Assert(os.Mkdir(session3, 0755), IsNil)
+		c.Assert(os.Remove(session2), IsNil)
+
+		select {
+		case event := <-s.eventsCh:
+			c.Fatalf("unexpected event %q while another session is active", event)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		// the user logs out of the last session, while the service
+		// manager of the user keeps running
+		c.Assert(os.Remove(session3), IsNil)
+
+		event := <-s.eventsCh
+		c.Check(event, Equals, "1000")
+
+		c.Assert(os.RemoveAll(userSlice), IsNil)
+		restoreVersion()
+		restoreWatcher()
+		cancel()
+	}
+}
+
+func (s *monitorSuite) TestMonitorUserSessionEndedLingering(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.AddCleanup(cgroup.MockInotifyWatcher(ctx, nil))
+	defer cancel()
+
+	restore := cgroup.MockVersion(cgroup.V2, nil)
+	s.AddCleanup(restore)
+
+	// With lingering enabled, the slice of the user and the service manager
+	// of the user remain without any session
+	userSlice := filepath.Join(dirs.GlobalRootDir, "/sys/fs/cgroup/user.slice/user-1000.slice")
+	c.Assert(os.MkdirAll(filepath.Join(userSlice, "user@1000.service"), 0755), IsNil)
+
+	err := cgroup.MonitorUserSessionEnded(1000, s.eventsCh, nil)
+	c.Assert(err, IsNil)
+
+	event := <-s.eventsCh
+	c.Check(event, Equals, "1000")
+}
+
+func (s *monitorSuite) TestMonitorUserSessionEndedBusyReceiver(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.AddCleanup(cgroup.MockInotifyWatcher(ctx, nil))
+	defer cancel()
+
+	restore := cgroup.MockVersion(cgroup.V2, nil)
+	s.AddCleanup(restore)
+
+	// Nobody receives from this channel, as when the caller is busy
+	busyCh := make(chan string)
+	err := cgroup.MonitorUserSessionEnded(1234, busyCh, nil)
+	c.Assert(err, IsNil)
+
+	// The watcher can still monitor other groups
+	folder := makeTestFolder(c, s.tempDir, "folder")
+	err = cgroup.MonitorDelete([]string{folder}, "test", s.eventsCh)
+	c.Assert(err, IsNil)
+	c.Assert(os.Remove(folder), IsNil)
+	c.Check(<-s.eventsCh, Equals, "test")
+
+	// And the session end is delivered once the caller receives
+	c.Check(<-busyCh, Equals, "1234")
+}
+
+func (s *monitorSuite) TestMonitorUserSessionEndedDone(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.AddCleanup(cgroup.MockInotifyWatcher(ctx, nil))
+	defer cancel()
+
+	restore := cgroup.MockVersion(cgroup.V2, nil)
+	s.AddCleanup(restore)
+
+	userSlice := filepath.Join(dirs.GlobalRootDir, "/sys/fs/cgroup/user.slice/user-1000.slice")
+	session := filepath.Join(userSlice, "session-2.scope")
+	c.Assert(os.MkdirAll(session, 0755), IsNil)
+
+	// Nobody receives from this channel once monitoring is done
+	busyCh := make(chan string)
+	done := make(chan struct{})
+	err := cgroup.MonitorUserSessionEnded(1000, busyCh, done)
+	c.Assert(err, IsNil)
+	close(done)
+
+	// The session ending is still processed by the watcher, which is not
+	// blocked and keeps notifying other callers
+	err = cgroup.MonitorUserSessionEnded(1000, s.eventsCh, nil)
+	c.Assert(err, IsNil)
+	c.Assert(os.Remove(session), IsNil)
+
+	select {
+	case name := <-s.eventsCh:
+		c.Check(name, Equals, "1000")
+	case <-time.After(5 * time.Second):
+		c.Fatal("session end not detected")
+	}
+
+	select {
+	case name := <-busyCh:
+		c.Errorf("unexpected notification for %q after monitoring is done", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func (s *monitorSuite) TestMonitorUserSessionEndedError(c *C) {
+	restore := cgroup.MockVersion(cgroup.Unknown, errors.New("boom"))
+	defer restore()
+
+	err := cgroup.MonitorUserSessionEnded(1000, s.eventsCh, nil)
+	c.Check(err, ErrorMatches, "boom")
+}
+
 func (s *monitorSuite) TestMonitorClose(c *C) {
 	w := cgroup.NewInotifyWatcher(context.Background())
 	f := makeTestFolder(c, s.tempDir, "foo")
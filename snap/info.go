@@ -1386,6 +1386,10 @@ type HookInfo struct {
 	Environment  strutil.OrderedMap
 	CommandChain []string
 
+	// Interval is how often snapd runs the hook on its own, if set. Only
+	// supported by the check-health hook.
+	Interval time.Duration
+
 	Explicit bool
 }
 
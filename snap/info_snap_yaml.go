@@ -25,6 +25,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
@@ -115,6 +116,7 @@ type hookYaml struct {
 	SlotNames    []string           `yaml:"slots,omitempty"`
 	Environment  strutil.OrderedMap `yaml:"environment,omitempty"`
 	CommandChain []string           `yaml:"command-chain,omitempty"`
+	Interval     timeout.Timeout    `yaml:"interval,omitempty"`
 }
 
 type componentYaml struct {
@@ -368,6 +370,10 @@ func setComponentsFromSnapYaml(y snapYaml, snap *Info, strk *scopedTracker) erro
 				return fmt.Errorf("component hooks cannot have slots")
 			}
 
+			if hookData.Interval != 0 {
+				return fmt.Errorf("component hooks cannot have an interval")
+			}
+
 			if len(hookData.PlugNames) > 0 {
 				componentHook.Plugs = make(map[string]*PlugInfo, len(hookData.PlugNames))
 			}
@@ -558,6 +564,7 @@ func setHooksFromSnapYaml(y snapYaml, snap *Info, strk *scopedTracker) {
 			Name:         hookName,
 			Environment:  yHook.Environment,
 			CommandChain: yHook.CommandChain,
+			Interval:     time.Duration(yHook.Interval),
 			Explicit:     true,
 		}
 		if len(y.Plugs) > 0 || len(yHook.PlugNames) > 0 {
@@ -1953,6 +1953,20 @@ hooks:
 	c.Check(hook.CommandChain, DeepEquals, []string{"hookchain1", "hookchain2"})
 }
 
+func (s *YamlSuite) TestSnapYamlHookInterval(c *C) {
+	y := []byte(`name: wat
+version: 42
+hooks:
+ check-health:
+  interval: 10m
+ configure:
+`)
+	info, err := snap.InfoFromSnapYaml(y)
+	c.Assert(err, IsNil)
+	c.Check(info.Hooks["check-health"].Interval, Equals, 10*time.Minute)
+	c.Check(info.Hooks["configure"].Interval, Equals, time.Duration(0))
+}
+
 func (s *YamlSuite) TestSnapYamlRestartDelay(c *C) {
 	yAutostart := []byte(`name: wat
 version: 42
@@ -29,6 +29,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/snapcore/snapd/osutil"
@@ -191,9 +192,21 @@ func ValidateHook(hook *HookInfo) error {
 		}
 	}
 
+	if hook.Interval != 0 {
+		if hook.Name != "check-health" {
+			return fmt.Errorf("hook %q cannot have an interval", hook.Name)
+		}
+		if hook.Interval < minHookInterval {
+			return fmt.Errorf("hook %q interval must be at least %v", hook.Name, minHookInterval)
+		}
+	}
+
 	return nil
 }
 
+// minHookInterval is the shortest interval a hook can be run at.
+const minHookInterval = time.Minute
+
 // ValidateAlias checks if a string can be used as an alias name.
 func ValidateAlias(alias string) error {
 	return naming.ValidateAlias(alias)
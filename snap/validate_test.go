@@ -25,6 +25,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	. "gopkg.in/check.v1"
@@ -175,6 +176,12 @@ func (s *ValidateSuite) TestValidateHook(c *C) {
 	}
 }
 
+func (s *ValidateSuite) TestValidateHookInterval(c *C) {
+	c.Check(ValidateHook(&HookInfo{Name: "check-health", Interval: 5 * time.Minute}), IsNil)
+	c.Check(ValidateHook(&HookInfo{Name: "check-health", Interval: time.Second}), ErrorMatches, `hook "check-health" interval must be at least 1m0s`)
+	c.Check(ValidateHook(&HookInfo{Name: "configure", Interval: time.Hour}), ErrorMatches, `hook "configure" cannot have an interval`)
+}
+
 // ValidateApp
 
 func (s *ValidateSuite) TestValidateAppSockets(c *C) {
@@ -0,0 +1,188 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// bsdiffMagic is the magic at the start of deltas in the format of the
+// classic bsdiff tool.
+var bsdiffMagic = []byte("BSDIFF40")
+
+// bsdiffHeaderSize is the size of the header of bsdiff deltas, that is the
+// magic followed by the lengths of the compressed control and diff blocks
+// and the size of the target.
+const bsdiffHeaderSize = 32
+
+// applyBsdiff reconstructs targetPath from sourcePath and the bsdiff delta
+// at deltaPath. The source is read at random and the target is written as it
+// is reconstructed, so neither needs to be held in memory.
+func applyBsdiff(sourcePath, deltaPath, targetPath string) (err error) {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	sourceInfo, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	delta, err := os.Open(deltaPath)
+	if err != nil {
+		return err
+	}
+	defer delta.Close()
+	deltaInfo, err := delta.Stat()
+	if err != nil {
+		return err
+	}
+
+	target, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := target.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	w := bufio.NewWriter(target)
+	if err := bspatch(source, sourceInfo.Size(), delta, deltaInfo.Size(), w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+var errCorruptBsdiff = errors.New("corrupt bsdiff delta")
+
+// bsdiffOfftin decodes the sign-magnitude integers used by bsdiff.
+func bsdiffOfftin(buf []byte) int64 {
+	y := int64(binary.LittleEndian.Uint64(buf) &^ (1 << 63))
+	if buf[7]&0x80 != 0 {
+		y = -y
+	}
+	return y
+}
+
+// bspatch writes to w the result of applying the bsdiff delta, of deltaSize
+// bytes, to source, of sourceSize bytes.
+func bspatch(source io.ReaderAt, sourceSize int64, delta io.ReaderAt, deltaSize int64, w io.Writer) error {
+	header := make([]byte, bsdiffHeaderSize)
+	if _, err := delta.ReadAt(header, 0); err != nil {
+		if err == io.EOF {
+			return errCorruptBsdiff
+		}
+		return err
+	}
+	if !bytes.Equal(header[:len(bsdiffMagic)], bsdiffMagic) {
+		return fmt.Errorf("cannot apply bsdiff delta: invalid magic %q", header[:len(bsdiffMagic)])
+	}
+	ctrlLen := bsdiffOfftin(header[8:])
+	diffLen := bsdiffOfftin(header[16:])
+	targetSize := bsdiffOfftin(header[24:])
+	if ctrlLen < 0 || diffLen < 0 || targetSize < 0 ||
+		ctrlLen > deltaSize-bsdiffHeaderSize ||
+		diffLen > deltaSize-bsdiffHeaderSize-ctrlLen {
+		return errCorruptBsdiff
+	}
+
+	ctrlStart := int64(bsdiffHeaderSize)
+	diffStart := ctrlStart + ctrlLen
+	extraStart := diffStart + diffLen
+	ctrl := bzip2.NewReader(io.NewSectionReader(delta, ctrlStart, ctrlLen))
+	diff := bzip2.NewReader(io.NewSectionReader(delta, diffStart, diffLen))
+	extra := bzip2.NewReader(io.NewSectionReader(delta, extraStart, deltaSize-extraStart))
+
+	buf := make([]byte, 32*1024)
+	sourceBuf := make([]byte, len(buf))
+	ctrlBuf := make([]byte, 24)
+	var targetPos, sourcePos int64
+	for targetPos < targetSize {
+		if _, err := io.ReadFull(ctrl, ctrlBuf); err != nil {
+			return errCorruptBsdiff
+		}
+		// the number of bytes to add from diff to source, the number of
+		// bytes to copy from extra, and how far to then move in source
+		addLen := bsdiffOfftin(ctrlBuf[0:])
+		copyLen := bsdiffOfftin(ctrlBuf[8:])
+		seekLen := bsdiffOfftin(ctrlBuf[16:])
+		if addLen < 0 || copyLen < 0 || addLen > targetSize-targetPos {
+			return errCorruptBsdiff
+		}
+
+		for addLen > 0 {
+			n := int64(len(buf))
+			if n > addLen {
+				n = addLen
+			}
+			chunk := buf[:n]
+			if _, err := io.ReadFull(diff, chunk); err != nil {
+				return errCorruptBsdiff
+			}
+			// bytes outside of source are taken from diff as they are
+			start, end := sourcePos, sourcePos+n
+			if start < 0 {
+				start = 0
+			}
+			if end > sourceSize {
+				end = sourceSize
+			}
+			if start < end {
+				old := sourceBuf[:end-start]
+				if _, err := source.ReadAt(old, start); err != nil && err != io.EOF {
+					return err
+				}
+				off := start - sourcePos
+				for i, b := range old {
+					chunk[off+int64(i)] += b
+				}
+			}
+			if _, err := w.Write(chunk); err != nil {
+				return err
+			}
+			addLen -= n
+			targetPos += n
+			sourcePos += n
+		}
+
+		if copyLen > targetSize-targetPos {
+			return errCorruptBsdiff
+		}
+		if _, err := io.CopyN(w, extra, copyLen); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return errCorruptBsdiff
+			}
+			return err
+		}
+		targetPos += copyLen
+		sourcePos += seekLen
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store_test
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type bsdiffSuite struct{}
+
+var _ = Suite(&bsdiffSuite{})
+
+const (
+	bsdiffSource = "The quick brown fox jumps over the lazy dog.\n"
+	bsdiffTarget = "The QUICK red fox jumps over the lazy cat.\n!The\n"
+	// a delta from bsdiffSource to bsdiffTarget that moves both forwards
+	// and backwards in the source and reads past its end
+	bsdiffDelta = "QlNESUZGNDA8AAAAAAAAADgAAAAAAAAAMAAAAAAAAABCWmg5MUFZJlNZomfq9gAAEvBAfRgAAQABQAAgADEA000DRqNlNqb1prXQoK5YBEIJnxdyRThQkKJn6vZCWmg5MUFZJlNZE+8WMgAAAfAB4AICACAAQAAQAKAAMQwIEpkGmKmgtBvMdKTxdyRThQkBPvFjIEJaaDkxQVkmU1lyn2ReAAACUYAAEEAABgAQACAAIYNBmglwcXckU4UJByn2ReA="
+)
+
+func (s *bsdiffSuite) writeFiles(c *C, delta []byte) (sourcePath, deltaPath, targetPath string) {
+	dir := c.MkDir()
+	sourcePath = filepath.Join(dir, "source")
+	deltaPath = filepath.Join(dir, "delta")
+	targetPath = filepath.Join(dir, "target")
+	c.Assert(os.WriteFile(sourcePath, []byte(bsdiffSource), 0644), IsNil)
+	c.Assert(os.WriteFile(deltaPath, delta, 0644), IsNil)
+	return sourcePath, deltaPath, targetPath
+}
+
+func (s *bsdiffSuite) delta(c *C) []byte {
+	delta, err := base64.StdEncoding.DecodeString(bsdiffDelta)
+	c.Assert(err, IsNil)
+	return delta
+}
+
+func (s *bsdiffSuite) TestApplyBsdiff(c *C) {
+	sourcePath, deltaPath, targetPath := s.writeFiles(c, s.delta(c))
+
+	err := store.ApplyBsdiff(sourcePath, deltaPath, targetPath)
+	c.Assert(err, IsNil)
+	c.Check(targetPath, testutil.FileEquals, bsdiffTarget)
+	st, err := os.Stat(targetPath)
+	c.Assert(err, IsNil)
+	c.Check(st.Mode().Perm(), Equals, os.FileMode(0600))
+}
+
+func (s *bsdiffSuite) TestApplyBsdiffBadMagic(c *C) {
+	delta := s.delta(c)
+	copy(delta, "BSDIFF39")
+	sourcePath, deltaPath, targetPath := s.writeFiles(c, delta)
+
+	err := store.ApplyBsdiff(sourcePath, deltaPath, targetPath)
+	c.Check(err, ErrorMatches, `cannot apply bsdiff delta: invalid magic "BSDIFF39"`)
+}
+
+func (s *bsdiffSuite) TestApplyBsdiffCorrupt(c *C) {
+	delta := s.delta(c)
+	for _, corrupt := range [][]byte{
+		// too short for the header
+		delta[:20],
+		// missing the extra block
+		delta[:len(delta)-40],
+		// the target is bigger than the delta can produce
+		append(append(append([]byte{}, delta[:24]...), 0xff, 0, 0, 0, 0, 0, 0, 0), delta[32:]...),
+		// a negative control block length
+		append(append(append([]byte{}, delta[:8]...), 1, 0, 0, 0, 0, 0, 0, 0x80), delta[16:]...),
+	} {
+		sourcePath, deltaPath, targetPath := s.writeFiles(c, corrupt)
+
+		err := store.ApplyBsdiff(sourcePath, deltaPath, targetPath)
+		c.Check(err, ErrorMatches, "corrupt bsdiff delta|bzip2 data invalid.*")
+	}
+}
+
+func (s *bsdiffSuite) TestApplyBsdiffMissingSource(c *C) {
+	_, deltaPath, targetPath := s.writeFiles(c, s.delta(c))
+
+	err := store.ApplyBsdiff(filepath.Join(c.MkDir(), "missing"), deltaPath, targetPath)
+	c.Check(os.IsNotExist(err), Equals, true)
+}
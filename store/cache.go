@@ -30,6 +30,7 @@ import (
 
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/strutil"
 )
 
 // overridden in the unit tests
@@ -64,10 +65,40 @@ func (s changesByMtime) Len() int           { return len(s) }
 func (s changesByMtime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 func (s changesByMtime) Less(i, j int) bool { return s[i].ModTime().Before(s[j].ModTime()) }
 
+// CachePolicy describes the limits that a CacheManager enforces on the
+// entries of the cache that are not referenced from elsewhere.
+type CachePolicy struct {
+	// MaxItems is the maximum number of entries, if zero the maximum
+	// given to NewCacheManager is used.
+	MaxItems int
+	// MaxSize is the maximum total size in bytes of the entries, zero
+	// means no limit.
+	MaxSize int64
+	// MaxAge is the maximum time since an entry was last used, zero means
+	// no limit.
+	MaxAge time.Duration
+	// Pinned lists the keys of entries that are never removed.
+	Pinned []string
+}
+
+// CacheEntry describes an entry of the cache.
+type CacheEntry struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod-time"`
+	// Referenced is set if the entry is hardlinked from elsewhere, so it
+	// does not use space of its own.
+	Referenced bool `json:"referenced,omitempty"`
+	// Pinned is set if the policy forbids removing the entry.
+	Pinned bool `json:"pinned,omitempty"`
+}
+
 // cacheManager implements a downloadCache via content based hard linking
 type CacheManager struct {
 	cacheDir string
 	maxItems int
+
+	policy func() (*CachePolicy, error)
 }
 
 // NewCacheManager returns a new CacheManager with the given cacheDir
@@ -82,6 +113,9 @@ type CacheManager struct {
 //  5. If cache dir has more than maxItems entries, remove oldest mtimes
 //     until it has maxItems
 //
+// A policy set with SetPolicy can further limit the total size and the age
+// of the entries, and pin entries so that they are never removed.
+//
 // The caching part is done here, the downloading happens in the store.go
 // code.
 func NewCacheManager(cacheDir string, maxItems int) *CacheManager {
@@ -91,6 +125,73 @@ func NewCacheManager(cacheDir string, maxItems int) *CacheManager {
 	}
 }
 
+// SetPolicy sets the function that is consulted for the current policy
+// whenever the cache is cleaned up.
+func (cm *CacheManager) SetPolicy(policy func() (*CachePolicy, error)) {
+	cm.policy = policy
+}
+
+// currentPolicy returns the policy to enforce, falling back to only the
+// maximum number of items if no policy is set or it cannot be retrieved.
+func (cm *CacheManager) currentPolicy() *CachePolicy {
+	policy := &CachePolicy{}
+	if cm.policy != nil {
+		p, err := cm.policy()
+		if err != nil {
+			logger.Noticef("cannot get download cache policy: %v", err)
+		} else if p != nil {
+			policy = p
+		}
+	}
+	if policy.MaxItems <= 0 {
+		policy.MaxItems = cm.maxItems
+	}
+	return policy
+}
+
+// Entries returns the entries of the cache.
+func (cm *CacheManager) Entries() ([]*CacheEntry, error) {
+	dirEntries, err := os.ReadDir(cm.cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	policy := cm.currentPolicy()
+
+	entries := make([]*CacheEntry, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		fi, err := dirEntry.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		n, err := hardLinkCount(fi)
+		if err != nil {
+			logger.Noticef("cannot inspect cache: %s", err)
+		}
+		entries = append(entries, &CacheEntry{
+			Key:        fi.Name(),
+			Size:       fi.Size(),
+			ModTime:    fi.ModTime(),
+			Referenced: n > 1,
+			Pinned:     strutil.ListContains(policy.Pinned, fi.Name()),
+		})
+	}
+	return entries, nil
+}
+
+// Prune removes the entries that exceed the limits of the policy.
+func (cm *CacheManager) Prune() error {
+	if err := cm.cleanup(); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 // GetPath returns the full path of the given content in the cache
 // or empty string
 func (cm *CacheManager) GetPath(cacheKey string) string {
@@ -158,20 +259,24 @@ func (cm *CacheManager) path(cacheKey string) string {
 	return filepath.Join(cm.cacheDir, cacheKey)
 }
 
-// cleanup ensures that only maxItems are stored in the cache
+// cleanup ensures that the entries not referenced from elsewhere are within
+// the limits of the policy, by removing the oldest ones that are not pinned
 func (cm *CacheManager) cleanup() error {
 	entries, err := os.ReadDir(cm.cacheDir)
 	if err != nil {
 		return err
 	}
 
-	if len(entries) <= cm.maxItems {
+	policy := cm.currentPolicy()
+	if policy.MaxSize <= 0 && policy.MaxAge <= 0 && len(entries) <= policy.MaxItems {
 		return nil
 	}
 
 	// most of the entries will have more than one hardlink, but a minority may
 	// be referenced only the cache and thus be a candidate for pruning
 	pruneCandidates := make([]os.FileInfo, 0, len(entries)/5)
+	numOwned := 0
+	var sizeOwned int64
 
 	for _, entry := range entries {
 		fi, err := entry.Info()
@@ -185,21 +290,28 @@ func (cm *CacheManager) cleanup() error {
 		}
 		// If the file is referenced in the filesystem somewhere else our copy
 		// is "free" so skip it.
-		if n <= 1 {
+		if n > 1 {
+			continue
+		}
+		numOwned++
+		sizeOwned += fi.Size()
+		// pinned entries count against the limits but are never removed
+		if !strutil.ListContains(policy.Pinned, fi.Name()) {
 			pruneCandidates = append(pruneCandidates, fi)
 		}
 	}
 
-	if len(pruneCandidates) <= cm.maxItems {
-		// nothing to prune
-		return nil
-	}
-
 	var lastErr error
 	sort.Sort(changesByMtime(pruneCandidates))
-	numOwned := len(pruneCandidates)
-	deleted := 0
+	now := time.Now()
 	for _, fi := range pruneCandidates {
+		tooMany := numOwned > policy.MaxItems
+		tooBig := policy.MaxSize > 0 && sizeOwned > policy.MaxSize
+		tooOld := policy.MaxAge > 0 && now.Sub(fi.ModTime()) > policy.MaxAge
+		if !tooMany && !tooBig && !tooOld {
+			// the candidates are sorted by age, so nothing else to prune
+			break
+		}
 		path := cm.path(fi.Name())
 		if err := osRemove(path); err != nil {
 			if !os.IsNotExist(err) {
@@ -210,10 +322,8 @@ func (cm *CacheManager) cleanup() error {
 			}
 			continue
 		}
-		deleted++
-		if numOwned-deleted <= cm.maxItems {
-			break
-		}
+		numOwned--
+		sizeOwned -= fi.Size()
 	}
 	return lastErr
 }
@@ -28,6 +28,7 @@ import (
 
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/testutil"
@@ -227,3 +228,126 @@ func (s *cacheSuite) TestCacheHitOnErrExist(c *C) {
 	cacheHit := s.cm.Get("foo", targetPath)
 	c.Assert(cacheHit, Equals, true)
 }
+
+func (s *cacheSuite) removeTestFiles(c *C, testFiles []string) {
+	for _, p := range testFiles {
+		err := os.Remove(p)
+		c.Assert(err, IsNil)
+	}
+}
+
+func (s *cacheSuite) TestCleanupPolicyMaxItems(c *C) {
+	cacheKeys, testFiles := s.makeTestFiles(c, s.maxItems)
+	s.removeTestFiles(c, testFiles)
+
+	s.cm.SetPolicy(func() (*store.CachePolicy, error) {
+		return &store.CachePolicy{MaxItems: 2}, nil
+	})
+	c.Assert(s.cm.Cleanup(), IsNil)
+
+	c.Check(s.cm.Count(), Equals, 2)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[len(cacheKeys)-1])), Equals, true)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[len(cacheKeys)-2])), Equals, true)
+}
+
+func (s *cacheSuite) TestCleanupPolicyMaxSize(c *C) {
+	// each test file is a single byte
+	cacheKeys, testFiles := s.makeTestFiles(c, s.maxItems)
+	s.removeTestFiles(c, testFiles)
+
+	s.cm.SetPolicy(func() (*store.CachePolicy, error) {
+		return &store.CachePolicy{MaxSize: 3}, nil
+	})
+	c.Assert(s.cm.Cleanup(), IsNil)
+
+	c.Check(s.cm.Count(), Equals, 3)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[1])), Equals, false)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[2])), Equals, true)
+}
+
+func (s *cacheSuite) TestCleanupPolicyMaxAge(c *C) {
+	cacheKeys, testFiles := s.makeTestFiles(c, 3)
+	s.removeTestFiles(c, testFiles)
+
+	old := time.Now().Add(-2 * time.Hour)
+	err := os.Chtimes(filepath.Join(s.cm.CacheDir(), cacheKeys[0]), old, old)
+	c.Assert(err, IsNil)
+
+	s.cm.SetPolicy(func() (*store.CachePolicy, error) {
+		return &store.CachePolicy{MaxAge: time.Hour}, nil
+	})
+	c.Assert(s.cm.Cleanup(), IsNil)
+
+	c.Check(s.cm.Count(), Equals, 2)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[0])), Equals, false)
+}
+
+func (s *cacheSuite) TestCleanupPolicyPinned(c *C) {
+	cacheKeys, testFiles := s.makeTestFiles(c, s.maxItems+2)
+	s.removeTestFiles(c, testFiles)
+
+	s.cm.SetPolicy(func() (*store.CachePolicy, error) {
+		return &store.CachePolicy{Pinned: []string{cacheKeys[0]}}, nil
+	})
+	c.Assert(s.cm.Cleanup(), IsNil)
+
+	// the pinned entry counts against the limit, but is kept even though
+	// it is the oldest
+	c.Check(s.cm.Count(), Equals, s.maxItems)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[0])), Equals, true)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[1])), Equals, false)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[2])), Equals, false)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[3])), Equals, true)
+}
+
+func (s *cacheSuite) TestCleanupPolicyErrorFallsBack(c *C) {
+	_, testFiles := s.makeTestFiles(c, s.maxItems+2)
+	s.removeTestFiles(c, testFiles)
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	s.cm.SetPolicy(func() (*store.CachePolicy, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	c.Assert(s.cm.Cleanup(), IsNil)
+
+	c.Check(s.cm.Count(), Equals, s.maxItems)
+	c.Check(logbuf.String(), testutil.Contains, "cannot get download cache policy: boom")
+}
+
+func (s *cacheSuite) TestEntriesAndPrune(c *C) {
+	cacheKeys, testFiles := s.makeTestFiles(c, 3)
+	// the newest entry is still referenced from its test file
+	s.removeTestFiles(c, testFiles[:2])
+
+	s.cm.SetPolicy(func() (*store.CachePolicy, error) {
+		return &store.CachePolicy{MaxItems: 1, Pinned: []string{cacheKeys[1]}}, nil
+	})
+
+	entries, err := s.cm.Entries()
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 3)
+	byKey := make(map[string]*store.CacheEntry)
+	for _, e := range entries {
+		c.Check(e.Size, Equals, int64(1))
+		byKey[e.Key] = e
+	}
+	c.Check(byKey[cacheKeys[0]].Referenced, Equals, false)
+	c.Check(byKey[cacheKeys[0]].Pinned, Equals, false)
+	c.Check(byKey[cacheKeys[1]].Pinned, Equals, true)
+	c.Check(byKey[cacheKeys[2]].Referenced, Equals, true)
+
+	c.Assert(s.cm.Prune(), IsNil)
+	c.Check(s.cm.Count(), Equals, 2)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[0])), Equals, false)
+}
+
+func (s *cacheSuite) TestEntriesAndPruneNoCacheDir(c *C) {
+	cm := store.NewCacheManager(filepath.Join(s.tmp, "missing"), s.maxItems)
+
+	entries, err := cm.Entries()
+	c.Assert(err, IsNil)
+	c.Check(entries, HasLen, 0)
+	c.Check(cm.Prune(), IsNil)
+}
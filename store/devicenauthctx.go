@@ -43,6 +43,11 @@ type DeviceAndAuthContext interface {
 	CloudInfo() (*auth.CloudInfo, error)
 
 	StoreOffline() (bool, error)
+
+	PeerCachePeers() ([]string, error)
+	PeerCacheToken() (string, error)
+
+	DownloadCachePolicy() (*CachePolicy, error)
 }
 
 // DeviceSessionRequestParams gathers the assertions and information to be sent to request a device session.
@@ -524,8 +524,10 @@ func (s *downloadSuite) TestUseDeltas(c *C) {
 			})
 		}
 
-		// run the check for delta usage, we call it twice
+		// run the check for delta usage, we call it twice; only allow
+		// xdelta3 as bsdiff deltas can always be applied
 		sto := &store.Store{}
+		sto.SetDeltaFormat("xdelta3")
 		c.Check(sto.UseDeltas(), Equals, scenario.wantDelta, comment)
 
 		// cleanup the files we may have created before calling the function
@@ -598,6 +600,59 @@ func (s *downloadSuite) TestUseDeltas(c *C) {
 	}
 }
 
+func (s *downloadSuite) TestDeltaFormats(c *C) {
+	origUseDeltas := os.Getenv("SNAPD_USE_DELTAS_EXPERIMENTAL")
+	defer os.Setenv("SNAPD_USE_DELTAS_EXPERIMENTAL", origUseDeltas)
+	restore := store.MockSnapdtoolCommandFromSystemSnap(func(name string, args ...string) (*exec.Cmd, error) {
+		return nil, errors.New("no system snap")
+	})
+	defer restore()
+
+	for _, withXdelta3 := range []bool{true, false} {
+		if !withXdelta3 {
+			// make sure no xdelta3 is found on the host
+			s.mockXdelta.Restore()
+			origPath := os.Getenv("PATH")
+			defer os.Setenv("PATH", origPath)
+			os.Setenv("PATH", "")
+		}
+
+		for _, scenario := range []struct {
+			env    string
+			format string
+
+			formats []string
+		}{
+			{env: "", format: "", formats: []string{"xdelta3", "bsdiff"}},
+			{env: "1", format: "", formats: []string{"xdelta3", "bsdiff"}},
+			{env: "0", format: "", formats: nil},
+			{env: "", format: "xdelta3", formats: []string{"xdelta3"}},
+			{env: "", format: "bsdiff", formats: []string{"bsdiff"}},
+			{env: "0", format: "bsdiff", formats: nil},
+			{env: "", format: "ydelta", formats: nil},
+		} {
+			comment := Commentf("%#v with xdelta3: %v", scenario, withXdelta3)
+			os.Setenv("SNAPD_USE_DELTAS_EXPERIMENTAL", scenario.env)
+
+			expected := scenario.formats
+			if !withXdelta3 {
+				// bsdiff deltas can be applied without xdelta3
+				expected = nil
+				for _, format := range scenario.formats {
+					if format != "xdelta3" {
+						expected = append(expected, format)
+					}
+				}
+			}
+
+			sto := &store.Store{}
+			sto.SetDeltaFormat(scenario.format)
+			c.Check(sto.DeltaFormats(), DeepEquals, expected, comment)
+			c.Check(sto.UseDeltas(), Equals, len(expected) > 0, comment)
+		}
+	}
+}
+
 type downloadBehaviour []struct {
 	url   string
 	error bool
@@ -42,7 +42,8 @@ var (
 	ApiURL        = apiURL
 	Download      = download
 
-	ApplyDelta = applyDelta
+	ApplyDelta  = applyDelta
+	ApplyBsdiff = applyBsdiff
 
 	AuthLocation      = authLocation
 	AuthURL           = authURL
@@ -107,6 +108,10 @@ func MockDownloadSpeedParams(measureWindow time.Duration, minSpeed float64) (res
 	}
 }
 
+func MockDownloadCheckpointInterval(interval int64) (restore func()) {
+	return testutil.Mock(&downloadCheckpointInterval, interval)
+}
+
 func IsTransferSpeedError(err error) (ok bool, speed float64) {
 	de, ok := err.(*transferSpeedError)
 	if !ok {
@@ -221,6 +226,10 @@ func (sto *Store) UseDeltas() bool {
 	return sto.useDeltas()
 }
 
+func (sto *Store) DeltaFormats() []string {
+	return sto.deltaFormats()
+}
+
 func (sto *Store) Xdelta3Cmd(args ...string) *exec.Cmd {
 	return sto.xdelta3CmdFunc(args...)
 }
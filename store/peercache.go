@@ -0,0 +1,275 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/snap"
+)
+
+const (
+	// peerCachePath is the path under which peers serve the blobs in
+	// their download cache, by sha3-384.
+	peerCachePath = "/peer-cache/v1/"
+
+	// peerCacheTokenHeader carries the token shared by the peers, which
+	// only serve the requests presenting it.
+	peerCacheTokenHeader = "Snap-Peer-Cache-Token"
+)
+
+var (
+	// peerCacheTimeout bounds the time spent fetching a blob from a single
+	// peer, so that an unresponsive peer only delays the store download.
+	peerCacheTimeout = 5 * time.Minute
+
+	peerCacheKeyRegexp = regexp.MustCompile(`^[0-9a-f]{96}$`)
+
+	// peers are on the local network, so do not go through the proxy
+	peerCacheClient = &http.Client{Transport: &http.Transport{}}
+)
+
+// PeerCacheServer serves the snaps in a download cache to other devices,
+// which can then fetch them from it instead of from the store. Only the
+// devices presenting the token shared by the peers are served.
+type PeerCacheServer struct {
+	cacheDir string
+	token    string
+
+	listener net.Listener
+	srv      *http.Server
+}
+
+// NewPeerCacheServer returns a PeerCacheServer serving the download cache
+// in cacheDir to the peers presenting the given token.
+func NewPeerCacheServer(cacheDir, token string) *PeerCacheServer {
+	return &PeerCacheServer{cacheDir: cacheDir, token: token}
+}
+
+// Start starts serving on the given address.
+func (pcs *PeerCacheServer) Start(addr string) error {
+	if pcs.srv != nil {
+		return fmt.Errorf("internal error: peer cache server already started")
+	}
+	if pcs.token == "" {
+		return fmt.Errorf("cannot serve peer cache without a token")
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot serve peer cache: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(peerCachePath, pcs.serveBlob)
+	pcs.listener = l
+	pcs.srv = &http.Server{Handler: mux}
+	go func() {
+		if err := pcs.srv.Serve(l); err != nil && err != http.ErrServerClosed {
+			logger.Noticef("cannot serve peer cache: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Addr returns the address the server is listening on, or nil if it is
+// not started.
+func (pcs *PeerCacheServer) Addr() net.Addr {
+	if pcs.listener == nil {
+		return nil
+	}
+	return pcs.listener.Addr()
+}
+
+// Stop stops serving.
+func (pcs *PeerCacheServer) Stop() error {
+	if pcs.srv == nil {
+		return nil
+	}
+	err := pcs.srv.Close()
+	pcs.srv = nil
+	pcs.listener = nil
+	return err
+}
+
+func (pcs *PeerCacheServer) serveBlob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := r.Header.Get(peerCacheTokenHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(pcs.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, peerCachePath)
+	if !peerCacheKeyRegexp.MatchString(key) {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := os.Open(filepath.Join(pcs.cacheDir, key))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, "", fi.ModTime(), f)
+}
+
+// peerCachePeers returns the peers to try to fetch blobs from, along with
+// the token they share. Peers are only the ones configured explicitly, they
+// are not discovered on the local network (e.g. over mDNS).
+func (s *Store) peerCachePeers() (peers []string, token string) {
+	if s.dauthCtx == nil {
+		return nil, ""
+	}
+	peers, err := s.dauthCtx.PeerCachePeers()
+	if err != nil {
+		logger.Noticef("cannot get peer cache peers: %v", err)
+		return nil, ""
+	}
+	if len(peers) == 0 {
+		return nil, ""
+	}
+	token, err = s.dauthCtx.PeerCacheToken()
+	if err != nil {
+		logger.Noticef("cannot get peer cache token: %v", err)
+		return nil, ""
+	}
+	if token == "" {
+		// the peers would not serve us anyway
+		logger.Debugf("Not using peer cache peers without a token.")
+		return nil, ""
+	}
+	return peers, token
+}
+
+// hasPeerCachePeers returns whether there are peers to try to fetch blobs
+// from.
+func (s *Store) hasPeerCachePeers() bool {
+	peers, _ := s.peerCachePeers()
+	return len(peers) > 0
+}
+
+// downloadFromPeers tries to fetch the blob described by downloadInfo from
+// the configured peers into targetPath. A blob is only used if it matches
+// the expected sha3-384, which is what a download from the store is held to
+// as well.
+//
+// A fetch interrupted before is never resumed, and what it left is removed.
+// Once the blob is fetched from a peer, a partial download of it from the
+// store is not needed anymore and is removed as well.
+func (s *Store) downloadFromPeers(ctx context.Context, name, targetPath string, downloadInfo *snap.DownloadInfo) bool {
+	if err := os.Remove(targetPath + ".peer"); err != nil && !os.IsNotExist(err) {
+		logger.Noticef("Cannot remove stale peer download of %q: %v", name, err)
+	}
+	if downloadInfo.Sha3_384 == "" {
+		return false
+	}
+	peers, token := s.peerCachePeers()
+	for _, peer := range peers {
+		err := downloadFromPeer(ctx, peer, token, targetPath, downloadInfo)
+		if err == nil {
+			logger.Debugf("Downloaded %q from peer %s.", name, peer)
+			if err := os.Remove(targetPath + ".partial"); err != nil && !os.IsNotExist(err) {
+				logger.Noticef("Cannot remove partial download of %q: %v", name, err)
+			}
+			return true
+		}
+		logger.Debugf("Cannot download %q from peer %s: %v", name, peer, err)
+	}
+	return false
+}
+
+func downloadFromPeer(ctx context.Context, peer, token, targetPath string, downloadInfo *snap.DownloadInfo) (err error) {
+	u, err := url.Parse(peer)
+	if err != nil {
+		return err
+	}
+	u.Path = peerCachePath + downloadInfo.Sha3_384
+
+	ctx, cancel := context.WithTimeout(ctx, peerCacheTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(peerCacheTokenHeader, token)
+	resp, err := peerCacheClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if downloadInfo.Size > 0 && resp.ContentLength > downloadInfo.Size {
+		return fmt.Errorf("blob is larger than expected")
+	}
+
+	peerPath := targetPath + ".peer"
+	w, err := os.OpenFile(peerPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := w.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(peerPath)
+		}
+	}()
+
+	body := io.Reader(resp.Body)
+	if downloadInfo.Size > 0 {
+		// never take more than announced by the store
+		body = io.LimitReader(body, downloadInfo.Size+1)
+	}
+	h := crypto.SHA3_384.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), body); err != nil {
+		return err
+	}
+	actualSha3 := fmt.Sprintf("%x", h.Sum(nil))
+	if actualSha3 != downloadInfo.Sha3_384 {
+		return errors.New("sha3-384 mismatch")
+	}
+	if err := w.Sync(); err != nil {
+		return err
+	}
+	return os.Rename(peerPath, targetPath)
+}
@@ -106,7 +106,9 @@ type Config struct {
 	DetailFields []string
 	InfoFields   []string
 	// search v2 fields
-	FindFields  []string
+	FindFields []string
+	// DeltaFormat restricts deltas to the given format, by default all
+	// supported formats that can be applied are accepted
 	DeltaFormat string
 
 	// CacheDownloads is the number of downloads that should be cached
@@ -172,8 +174,8 @@ type Store struct {
 	userAgent string
 
 	xdeltaCheckLock sync.Mutex
-	// whether we should use deltas or not
-	shouldUseDeltas *bool
+	// whether a working xdelta3 is available
+	haveXdelta3 *bool
 	// which xdelta3 we picked when we checked the deltas
 	xdelta3CmdFunc func(args ...string) *exec.Cmd
 }
@@ -355,8 +357,10 @@ type categoryResults struct {
 	Categories []CategoryDetails `json:"categories"`
 }
 
-// The default delta format if not configured.
-var defaultSupportedDeltaFormat = "xdelta3"
+// The delta formats that can be applied, in order of preference. Unless a
+// delta format is configured, all the ones that can be applied on the system
+// are offered to the store.
+var supportedDeltaFormats = []string{"xdelta3", "bsdiff"}
 
 // New creates a new Store with the given access configuration and for given the store id.
 func New(cfg *Config, dauthCtx DeviceAndAuthContext) *Store {
@@ -389,11 +393,6 @@ func New(cfg *Config, dauthCtx DeviceAndAuthContext) *Store {
 		series = release.Series
 	}
 
-	deltaFormat := cfg.DeltaFormat
-	if deltaFormat == "" {
-		deltaFormat = defaultSupportedDeltaFormat
-	}
-
 	userAgent := snapdenv.UserAgent()
 	proxyConnectHeader := http.Header{"User-Agent": []string{userAgent}}
 
@@ -407,7 +406,7 @@ func New(cfg *Config, dauthCtx DeviceAndAuthContext) *Store {
 		infoFields:         infoFields,
 		findFields:         findFields,
 		dauthCtx:           dauthCtx,
-		deltaFormat:        deltaFormat,
+		deltaFormat:        cfg.DeltaFormat,
 		proxy:              cfg.Proxy,
 		proxyConnectHeader: proxyConnectHeader,
 		userAgent:          userAgent,
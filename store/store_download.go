@@ -25,6 +25,7 @@ import (
 	"crypto"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"net/url"
@@ -32,6 +33,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -47,6 +49,7 @@ import (
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snapdtool"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var commandFromSystemSnap = snapdtool.CommandFromSystemSnap
@@ -63,6 +66,9 @@ var downloadSpeedMeasureWindow = 5 * time.Minute
 // minimum average download speed (bytes/sec), measured over downloadSpeedMeasureWindow.
 var downloadSpeedMin = float64(4096)
 
+// number of bytes downloaded between checkpoints of a download
+var downloadCheckpointInterval = int64(8 * 1024 * 1024)
+
 func init() {
 	if v := os.Getenv("SNAPD_MIN_DOWNLOAD_SPEED"); v != "" {
 		if speed, err := strconv.Atoi(v); err == nil {
@@ -81,30 +87,51 @@ func init() {
 }
 
 // Deltas enabled by default on classic, but allow opting in or out on both classic and core.
-func (s *Store) useDeltas() (use bool) {
+func (s *Store) useDeltas() bool {
+	return len(s.deltaFormats()) > 0
+}
+
+// deltaFormats returns the delta formats that can be applied, in order of
+// preference, restricted to the configured one if any.
+func (s *Store) deltaFormats() []string {
+	// check if deltas were disabled by the environment
+	if !osutil.GetenvBool("SNAPD_USE_DELTAS_EXPERIMENTAL", true) {
+		// then the env var is explicitly false, we can't use deltas
+		logger.Debugf("delta usage disabled by environment variable")
+		return nil
+	}
+
+	var formats []string
+	for _, format := range supportedDeltaFormats {
+		if s.deltaFormat != "" && format != s.deltaFormat {
+			continue
+		}
+		// bsdiff deltas are applied by snapd itself, xdelta3 ones need
+		// the xdelta3 tool
+		if format == "xdelta3" && !s.xdelta3Available() {
+			continue
+		}
+		formats = append(formats, format)
+	}
+	return formats
+}
+
+// xdelta3Available checks whether a working xdelta3 is available, from the
+// system snap or from the host, and sets up xdelta3CmdFunc to run it.
+func (s *Store) xdelta3Available() (use bool) {
 	s.xdeltaCheckLock.Lock()
 	defer s.xdeltaCheckLock.Unlock()
 
 	// check the cached value if available
-	if s.shouldUseDeltas != nil {
-		return *s.shouldUseDeltas
+	if s.haveXdelta3 != nil {
+		return *s.haveXdelta3
 	}
 
 	defer func() {
 		// cache whatever value we return for next time
-		s.shouldUseDeltas = &use
+		s.haveXdelta3 = &use
 	}()
 
-	// check if deltas were disabled by the environment
-	if !osutil.GetenvBool("SNAPD_USE_DELTAS_EXPERIMENTAL", true) {
-		// then the env var is explicitly false, we can't use deltas
-		logger.Debugf("delta usage disabled by environment variable")
-		return false
-	}
-
-	// TODO: have a per-format checker instead, we currently only support
-	// xdelta3 as a format for deltas
-
 	// check if the xdelta3 config command works from the system snap
 	cmd, err := commandFromSystemSnap("/usr/bin/xdelta3", "config")
 	if err == nil {
@@ -185,6 +212,30 @@ type DownloadOptions struct {
 	RateLimit           int64
 	Scheduled           bool
 	LeavePartialOnError bool
+	// Checkpointer, if set, persists the progress of the download so
+	// that it can be resumed exactly where it stopped, even across
+	// restarts. A partial download is also kept if the download is
+	// cancelled.
+	Checkpointer DownloadCheckpointer
+}
+
+// DownloadCheckpoint records how much of a partial download is known to be
+// on disk and intact.
+type DownloadCheckpoint struct {
+	// Offset is the number of bytes of the partial download synced to disk.
+	Offset int64 `json:"offset"`
+	// Sha3_384 is the sha3-384 of the first Offset bytes.
+	Sha3_384 string `json:"sha3-384"`
+}
+
+// DownloadCheckpointer persists the checkpoints of a download.
+type DownloadCheckpointer interface {
+	// Checkpoint returns the last saved checkpoint, or nil if there is
+	// none.
+	Checkpoint() (*DownloadCheckpoint, error)
+	// SaveCheckpoint saves a checkpoint, it is called after the partial
+	// download was synced to disk up to the checkpoint.
+	SaveCheckpoint(cp *DownloadCheckpoint) error
 }
 
 // Download downloads the snap addressed by download info and returns its
@@ -194,9 +245,11 @@ type DownloadOptions struct {
 func (s *Store) Download(ctx context.Context, name string, targetPath string, downloadInfo *snap.DownloadInfo, pbar progress.Meter, user *auth.UserState, dlOpts *DownloadOptions) error {
 	// most other store network operations use s.endpointURL, which returns an
 	// error if the store is offline. this doesn't, so we need to explicitly
-	// check.
-	if err := s.checkStoreOnline(); err != nil {
-		return err
+	// check. Peers are on the local network though, so they are still tried
+	// while the store is offline.
+	offlineErr := s.checkStoreOnline()
+	if offlineErr != nil && !s.hasPeerCachePeers() {
+		return offlineErr
 	}
 
 	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
@@ -208,6 +261,18 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 		return nil
 	}
 
+	if s.downloadFromPeers(ctx, name, targetPath, downloadInfo) {
+		// the blob can in turn be served to other peers
+		if err := s.cacher.Put(downloadInfo.Sha3_384, targetPath); err != nil {
+			logger.Noticef("Cannot place blob for %s from peer in cache: %v", name, err)
+		}
+		return nil
+	}
+
+	if offlineErr != nil {
+		return offlineErr
+	}
+
 	if s.useDeltas() {
 		logger.Debugf("Available deltas returned by store: %v", downloadInfo.Deltas)
 
@@ -237,6 +302,12 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 	if err != nil {
 		return err
 	}
+	if dlOpts != nil && dlOpts.Checkpointer != nil && resume > 0 {
+		resume, err = resumeFromCheckpoint(name, w, resume, dlOpts.Checkpointer)
+		if err != nil {
+			return err
+		}
+	}
 	defer func() {
 		fi, _ := w.Stat()
 		if cerr := w.Close(); cerr != nil && err == nil {
@@ -245,7 +316,8 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 		if err == nil {
 			return
 		}
-		if dlOpts == nil || !dlOpts.LeavePartialOnError || fi == nil || fi.Size() == 0 {
+		leavePartial := dlOpts != nil && (dlOpts.LeavePartialOnError || dlOpts.Checkpointer != nil && ctx.Err() != nil)
+		if !leavePartial || fi == nil || fi.Size() == 0 {
 			os.Remove(w.Name())
 		}
 	}()
@@ -308,6 +380,78 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 	return s.cacher.Put(downloadInfo.Sha3_384, targetPath)
 }
 
+// resumeFromCheckpoint returns the offset to resume the partial download in w
+// from, given its current size. If there is a checkpoint, the partial download
+// is resumed from it if the data up to it is intact, and from scratch
+// otherwise. Any data past the checkpoint is discarded, as it might not have
+// made it to disk.
+func resumeFromCheckpoint(name string, w *os.File, size int64, checkpointer DownloadCheckpointer) (int64, error) {
+	cp, err := checkpointer.Checkpoint()
+	if err != nil {
+		return 0, err
+	}
+	if cp == nil {
+		return size, nil
+	}
+
+	resume := cp.Offset
+	if cp.Offset > size {
+		logger.Noticef("Partial download of %q is shorter than its checkpoint, restarting it.", name)
+		resume = 0
+	} else {
+		if _, err := w.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+		h := crypto.SHA3_384.New()
+		if _, err := io.CopyN(h, w, cp.Offset); err != nil {
+			return 0, err
+		}
+		if fmt.Sprintf("%x", h.Sum(nil)) != cp.Sha3_384 {
+			logger.Noticef("Partial download of %q does not match its checkpoint, restarting it.", name)
+			resume = 0
+		}
+	}
+
+	if err := w.Truncate(resume); err != nil {
+		return 0, err
+	}
+	return w.Seek(resume, io.SeekStart)
+}
+
+// checkpointWriter saves a checkpoint of a download every
+// downloadCheckpointInterval bytes. It must be written to after the partial
+// download and the hash of what was written so far.
+type checkpointWriter struct {
+	name         string
+	w            interface{ Sync() error }
+	h            hash.Hash
+	checkpointer DownloadCheckpointer
+
+	offset         int64
+	lastCheckpoint int64
+}
+
+func (cw *checkpointWriter) Write(p []byte) (int, error) {
+	cw.offset += int64(len(p))
+	if cw.offset-cw.lastCheckpoint < downloadCheckpointInterval {
+		return len(p), nil
+	}
+	cw.lastCheckpoint = cw.offset
+	// failing to checkpoint only costs downloading again after a restart
+	if err := cw.w.Sync(); err != nil {
+		logger.Noticef("Cannot sync partial download of %q: %v", cw.name, err)
+		return len(p), nil
+	}
+	cp := &DownloadCheckpoint{
+		Offset:   cw.offset,
+		Sha3_384: fmt.Sprintf("%x", cw.h.Sum(nil)),
+	}
+	if err := cw.checkpointer.SaveCheckpoint(cp); err != nil {
+		logger.Noticef("Cannot save checkpoint of download of %q: %v", cw.name, err)
+	}
+	return len(p), nil
+}
+
 func downloadReqOpts(storeURL *url.URL, cdnHeader string, opts *DownloadOptions) *requestOptions {
 	reqOptions := requestOptions{
 		Method:       "GET",
@@ -542,7 +686,18 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 			logger.Debugf("Download size for %s: %d", downloadURL, resp.ContentLength)
 		}
 		pbar.Start(name, dlSize)
-		mw := io.MultiWriter(w, h, pbar, tc)
+		writers := []io.Writer{w, h, pbar, tc}
+		if syncer, ok := w.(interface{ Sync() error }); ok && dlOpts.Checkpointer != nil {
+			writers = append(writers, &checkpointWriter{
+				name:           name,
+				w:              syncer,
+				h:              h,
+				checkpointer:   dlOpts.Checkpointer,
+				offset:         resume,
+				lastCheckpoint: resume,
+			})
+		}
+		mw := io.MultiWriter(writers...)
 		var limiter io.Reader
 		limiter = resp.Body
 		if limit := dlOpts.RateLimit; limit > 0 {
@@ -663,8 +818,9 @@ func (s *Store) downloadDelta(deltaName string, downloadInfo *snap.DownloadInfo,
 
 	deltaInfo := downloadInfo.Deltas[0]
 
-	if deltaInfo.Format != s.deltaFormat {
-		return fmt.Errorf("store returned unsupported delta format %q (only xdelta3 currently)", deltaInfo.Format)
+	formats := s.deltaFormats()
+	if !strutil.ListContains(formats, deltaInfo.Format) {
+		return fmt.Errorf("store returned unsupported delta format %q (only %s currently)", deltaInfo.Format, strings.Join(formats, ", "))
 	}
 
 	url := deltaInfo.DownloadURL
@@ -685,27 +841,35 @@ func (s *Store) applyDeltaImpl(name string, deltaPath string, deltaInfo *snap.De
 		return fmt.Errorf("snap %q revision %d not found at %s", name, deltaInfo.FromRevision, snapPath)
 	}
 
-	if deltaInfo.Format != "xdelta3" {
-		return fmt.Errorf("cannot apply unsupported delta format %q (only xdelta3 currently)", deltaInfo.Format)
-	}
-
 	partialTargetPath := targetPath + ".partial"
 
-	xdelta3Args := []string{"-d", "-s", snapPath, deltaPath, partialTargetPath}
-
-	// validity check that deltas are available and that the path for the xdelta3
-	// command is set
-	if ok := s.useDeltas(); !ok {
-		return fmt.Errorf("internal error: applyDelta used when deltas are not available")
-	}
+	switch deltaInfo.Format {
+	case "xdelta3":
+		// validity check that xdelta3 is available and that the path for
+		// the xdelta3 command is set
+		if ok := s.xdelta3Available(); !ok {
+			return fmt.Errorf("internal error: applyDelta used when xdelta3 is not available")
+		}
 
-	// run the xdelta3 command, cleaning up if we fail and logging about it
-	if runErr := s.xdelta3CmdFunc(xdelta3Args...).Run(); runErr != nil {
-		logger.Noticef("encountered error applying delta: %v", runErr)
-		if err := os.Remove(partialTargetPath); err != nil {
-			logger.Noticef("error cleaning up partial delta target %q: %s", partialTargetPath, err)
+		// run the xdelta3 command, cleaning up if we fail and logging about it
+		xdelta3Args := []string{"-d", "-s", snapPath, deltaPath, partialTargetPath}
+		if runErr := s.xdelta3CmdFunc(xdelta3Args...).Run(); runErr != nil {
+			logger.Noticef("encountered error applying delta: %v", runErr)
+			if err := os.Remove(partialTargetPath); err != nil {
+				logger.Noticef("error cleaning up partial delta target %q: %s", partialTargetPath, err)
+			}
+			return runErr
+		}
+	case "bsdiff":
+		if applyErr := applyBsdiff(snapPath, deltaPath, partialTargetPath); applyErr != nil {
+			logger.Noticef("encountered error applying delta: %v", applyErr)
+			if err := os.Remove(partialTargetPath); err != nil && !os.IsNotExist(err) {
+				logger.Noticef("error cleaning up partial delta target %q: %s", partialTargetPath, err)
+			}
+			return applyErr
 		}
-		return runErr
+	default:
+		return fmt.Errorf("cannot apply unsupported delta format %q (only %s currently)", deltaInfo.Format, strings.Join(supportedDeltaFormats, ", "))
 	}
 
 	if err := os.Chmod(partialTargetPath, 0600); err != nil {
@@ -770,8 +934,24 @@ func (s *Store) CacheDownloads() int {
 func (s *Store) SetCacheDownloads(fileCount int) {
 	s.cfg.CacheDownloads = fileCount
 	if fileCount > 0 {
-		s.cacher = NewCacheManager(dirs.SnapDownloadCacheDir, fileCount)
+		cm := NewCacheManager(dirs.SnapDownloadCacheDir, fileCount)
+		cm.SetPolicy(s.downloadCachePolicy)
+		s.cacher = cm
 	} else {
 		s.cacher = &nullCache{}
 	}
 }
+
+// DownloadCache returns the cache of downloaded snaps, or nil if downloads
+// are not cached.
+func (s *Store) DownloadCache() *CacheManager {
+	cm, _ := s.cacher.(*CacheManager)
+	return cm
+}
+
+func (s *Store) downloadCachePolicy() (*CachePolicy, error) {
+	if s.dauthCtx == nil {
+		return nil, nil
+	}
+	return s.dauthCtx.DownloadCachePolicy()
+}
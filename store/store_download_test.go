@@ -23,6 +23,7 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
@@ -588,6 +589,17 @@ var downloadDeltaTests = []struct {
 	format:      "bsdiff",
 	expectedURL: "",
 	expectError: true,
+}, {
+	// Without a configured format, any format that can be applied is used.
+	info: snap.DownloadInfo{
+		Sha3_384: "sha3",
+		Deltas: []snap.DeltaInfo{
+			{DownloadURL: "bsdiff-delta-url", Format: "bsdiff", FromRevision: 24, ToRevision: 26},
+		},
+	},
+	format:      "",
+	expectedURL: "bsdiff-delta-url",
+	expectError: false,
 }}
 
 func (s *storeDownloadSuite) TestDownloadDelta(c *C) {
@@ -651,7 +663,7 @@ var applyDeltaTests = []struct {
 	// An error is returned if the format is not supported.
 	deltaInfo:       snap.DeltaInfo{Format: "nodelta", FromRevision: 24, ToRevision: 26},
 	currentRevision: 24,
-	error:           "cannot apply unsupported delta format \"nodelta\" (only xdelta3 currently)",
+	error:           "cannot apply unsupported delta format \"nodelta\" (only xdelta3, bsdiff currently)",
 }}
 
 func (s *storeDownloadSuite) TestApplyDelta(c *C) {
@@ -706,6 +718,49 @@ func (s *storeDownloadSuite) TestApplyDelta(c *C) {
 	}
 }
 
+func (s *storeDownloadSuite) TestApplyDeltaBsdiff(c *C) {
+	source := []byte("The quick brown fox jumps over the lazy dog.\n")
+	target := []byte("The QUICK red fox jumps over the lazy cat.\n!The\n")
+	delta, err := base64.StdEncoding.DecodeString(bsdiffDelta)
+	c.Assert(err, IsNil)
+
+	currentSnapPath := filepath.Join(dirs.SnapBlobDir, "foo_24.snap")
+	targetSnapPath := filepath.Join(dirs.SnapBlobDir, "foo_26.snap")
+	deltaPath := filepath.Join(dirs.SnapBlobDir, "the.delta")
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+	c.Assert(os.WriteFile(currentSnapPath, source, 0644), IsNil)
+	c.Assert(os.WriteFile(deltaPath, delta, 0644), IsNil)
+
+	deltaInfo := &snap.DeltaInfo{Format: "bsdiff", FromRevision: 24, ToRevision: 26}
+	sto := &store.Store{}
+
+	// a target not matching the expected digest is discarded
+	err = store.ApplyDelta(sto, "foo", deltaPath, deltaInfo, targetSnapPath, "other-sha3")
+	c.Assert(err, FitsTypeOf, store.HashError{})
+	c.Check(osutil.FileExists(targetSnapPath+".partial"), Equals, false)
+	c.Check(osutil.FileExists(targetSnapPath), Equals, false)
+
+	h := crypto.SHA3_384.New()
+	h.Write(target)
+	err = store.ApplyDelta(sto, "foo", deltaPath, deltaInfo, targetSnapPath, fmt.Sprintf("%x", h.Sum(nil)))
+	c.Assert(err, IsNil)
+	c.Check(targetSnapPath, testutil.FileEquals, target)
+	c.Check(osutil.FileExists(targetSnapPath+".partial"), Equals, false)
+	st, err := os.Stat(targetSnapPath)
+	c.Assert(err, IsNil)
+	c.Check(st.Mode(), Equals, os.FileMode(0600))
+	// xdelta3 is not needed for bsdiff deltas
+	c.Check(s.mockXDelta.Calls(), HasLen, 0)
+
+	// a corrupt delta leaves nothing behind
+	c.Assert(os.Remove(targetSnapPath), IsNil)
+	c.Assert(os.WriteFile(deltaPath, delta[:len(delta)-40], 0644), IsNil)
+	err = store.ApplyDelta(sto, "foo", deltaPath, deltaInfo, targetSnapPath, "")
+	c.Assert(err, NotNil)
+	c.Check(osutil.FileExists(targetSnapPath+".partial"), Equals, false)
+	c.Check(osutil.FileExists(targetSnapPath), Equals, false)
+}
+
 type cacheObserver struct {
 	inCache map[string]bool
 
@@ -1252,3 +1307,352 @@ func (s *storeDownloadSuite) TestDownloadInfiniteRedirect(c *C) {
 	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, s.user, nil)
 	c.Assert(err, ErrorMatches, fmt.Sprintf("Get %q: stopped after 10 redirects", mockServer.URL))
 }
+
+const peerCacheToken = "shared-peer-cache-token"
+
+func (s *storeDownloadSuite) startPeerCache(c *C, blobs ...[]byte) (peerURL string) {
+	cacheDir := c.MkDir()
+	for _, blob := range blobs {
+		key := fmt.Sprintf("%x", sha3.Sum384(blob))
+		c.Assert(os.WriteFile(filepath.Join(cacheDir, key), blob, 0600), IsNil)
+	}
+	pcs := store.NewPeerCacheServer(cacheDir, peerCacheToken)
+	c.Assert(pcs.Start("127.0.0.1:0"), IsNil)
+	s.AddCleanup(func() { pcs.Stop() })
+	return "http://" + pcs.Addr().String()
+}
+
+func (s *storeDownloadSuite) TestPeerCacheServerNeedsToken(c *C) {
+	pcs := store.NewPeerCacheServer(c.MkDir(), "")
+	c.Check(pcs.Start("127.0.0.1:0"), ErrorMatches, "cannot serve peer cache without a token")
+	c.Check(pcs.Addr(), IsNil)
+}
+
+func (s *storeDownloadSuite) TestPeerCacheServer(c *C) {
+	blob := []byte("cached snap")
+	key := fmt.Sprintf("%x", sha3.Sum384(blob))
+	peerURL := s.startPeerCache(c, blob)
+
+	for _, tc := range []struct {
+		method, path string
+		token        string
+		status       int
+	}{
+		{"GET", "/peer-cache/v1/" + key, peerCacheToken, 200},
+		{"HEAD", "/peer-cache/v1/" + key, peerCacheToken, 200},
+		{"GET", "/peer-cache/v1/" + key, "", 401},
+		{"GET", "/peer-cache/v1/" + key, "wrong-token", 401},
+		{"POST", "/peer-cache/v1/" + key, peerCacheToken, 405},
+		{"GET", "/peer-cache/v1/" + fmt.Sprintf("%x", sha3.Sum384([]byte("other"))), peerCacheToken, 404},
+		{"GET", "/peer-cache/v1/../../etc/passwd", peerCacheToken, 404},
+		{"GET", "/peer-cache/v1/", peerCacheToken, 404},
+		{"GET", "/", peerCacheToken, 404},
+	} {
+		req, err := http.NewRequest(tc.method, peerURL+tc.path, nil)
+		c.Assert(err, IsNil)
+		if tc.token != "" {
+			req.Header.Set("Snap-Peer-Cache-Token", tc.token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		c.Assert(err, IsNil)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		c.Assert(err, IsNil)
+		c.Check(resp.StatusCode, Equals, tc.status, Commentf("%s %s", tc.method, tc.path))
+		if tc.status == 200 && tc.method == "GET" {
+			c.Check(body, DeepEquals, blob)
+		}
+	}
+}
+
+func (s *storeDownloadSuite) TestDownloadFromPeer(c *C) {
+	blob := []byte("snap from a peer")
+	peerURL := s.startPeerCache(c, blob)
+
+	// the first peer is down, the second one has the blob
+	dauthCtx := &testDauthContext{c: c, peerCachePeers: []string{"http://127.0.0.1:1", peerURL}, peerCacheToken: peerCacheToken}
+	sto := store.New(nil, dauthCtx)
+	obs := &cacheObserver{inCache: map[string]bool{}}
+	defer sto.MockCacher(obs)()
+
+	defer store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		c.Fatalf("the store should not be used when a peer has the snap")
+		return nil
+	})()
+
+	info := &snap.Info{}
+	info.Sha3_384 = fmt.Sprintf("%x", sha3.Sum384(blob))
+	info.Size = int64(len(blob))
+
+	path := filepath.Join(c.MkDir(), "downloaded-file")
+	// left by an earlier download from the store
+	c.Assert(os.WriteFile(path+".partial", blob[:4], 0600), IsNil)
+	err := sto.Download(s.ctx, "foo", path, &info.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(path, testutil.FileEquals, blob)
+	c.Check(path+".peer", testutil.FileAbsent)
+	c.Check(path+".partial", testutil.FileAbsent)
+	// and it can be served to other peers in turn
+	c.Check(obs.puts, DeepEquals, []string{fmt.Sprintf("%s:%s", info.Sha3_384, path)})
+}
+
+func (s *storeDownloadSuite) TestDownloadFromPeerStoreOffline(c *C) {
+	blob := []byte("snap from a peer")
+	peerURL := s.startPeerCache(c, blob)
+
+	dauthCtx := &testDauthContext{c: c, storeOffline: true, peerCachePeers: []string{peerURL}, peerCacheToken: peerCacheToken}
+	sto := store.New(nil, dauthCtx)
+
+	defer store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		c.Fatalf("the store should not be used when offline")
+		return nil
+	})()
+
+	info := &snap.Info{}
+	info.Sha3_384 = fmt.Sprintf("%x", sha3.Sum384(blob))
+	info.Size = int64(len(blob))
+
+	// peers are used while the store is offline
+	path := filepath.Join(c.MkDir(), "downloaded-file")
+	err := sto.Download(s.ctx, "foo", path, &info.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(path, testutil.FileEquals, blob)
+
+	// but a blob none of them has is not downloaded from the store
+	other := []byte("snap not on any peer")
+	info.Sha3_384 = fmt.Sprintf("%x", sha3.Sum384(other))
+	info.Size = int64(len(other))
+	path = filepath.Join(c.MkDir(), "downloaded-file")
+	// left by an interrupted fetch from a peer
+	c.Assert(os.WriteFile(path+".peer", other[:4], 0600), IsNil)
+	err = sto.Download(s.ctx, "foo", path, &info.DownloadInfo, nil, nil, nil)
+	c.Check(err, testutil.ErrorIs, store.ErrStoreOffline)
+	c.Check(path, testutil.FileAbsent)
+	c.Check(path+".peer", testutil.FileAbsent)
+	c.Check(path+".partial", testutil.FileAbsent)
+}
+
+func (s *storeDownloadSuite) TestDownloadFromPeerWrongToken(c *C) {
+	blob := []byte("snap from a peer")
+	peerURL := s.startPeerCache(c, blob)
+
+	for _, token := range []string{"", "wrong-token"} {
+		dauthCtx := &testDauthContext{c: c, peerCachePeers: []string{peerURL}, peerCacheToken: token}
+		sto := store.New(nil, dauthCtx)
+
+		downloadWasCalled := false
+		restore := store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+			downloadWasCalled = true
+			w.Write(blob)
+			return nil
+		})
+
+		info := &snap.Info{}
+		info.Sha3_384 = fmt.Sprintf("%x", sha3.Sum384(blob))
+		info.Size = int64(len(blob))
+
+		path := filepath.Join(c.MkDir(), "downloaded-file")
+		err := sto.Download(s.ctx, "foo", path, &info.DownloadInfo, nil, nil, nil)
+		restore()
+		c.Assert(err, IsNil)
+		// the peer did not serve the snap, so it came from the store
+		c.Check(downloadWasCalled, Equals, true, Commentf("token %q", token))
+		c.Check(path, testutil.FileEquals, blob)
+	}
+}
+
+func (s *storeDownloadSuite) TestDownloadFromPeerVerifiesHash(c *C) {
+	blob := []byte("snap from a peer")
+	cacheDir := c.MkDir()
+	key := fmt.Sprintf("%x", sha3.Sum384(blob))
+	// the peer has something else under the expected key
+	c.Assert(os.WriteFile(filepath.Join(cacheDir, key), []byte("tampered"), 0600), IsNil)
+	pcs := store.NewPeerCacheServer(cacheDir, peerCacheToken)
+	c.Assert(pcs.Start("127.0.0.1:0"), IsNil)
+	defer pcs.Stop()
+
+	dauthCtx := &testDauthContext{c: c, peerCachePeers: []string{"http://" + pcs.Addr().String()}, peerCacheToken: peerCacheToken}
+	sto := store.New(nil, dauthCtx)
+
+	downloadWasCalled := false
+	defer store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		downloadWasCalled = true
+		w.Write(blob)
+		return nil
+	})()
+
+	info := &snap.Info{}
+	info.Sha3_384 = key
+	info.Size = int64(len(blob))
+
+	path := filepath.Join(c.MkDir(), "downloaded-file")
+	err := sto.Download(s.ctx, "foo", path, &info.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(downloadWasCalled, Equals, true)
+	c.Check(path, testutil.FileEquals, blob)
+	c.Check(path+".peer", testutil.FileAbsent)
+}
+
+func (s *storeDownloadSuite) TestDownloadCachePolicyFromContext(c *C) {
+	dauthCtx := &testDauthContext{c: c, downloadCachePolicy: &store.CachePolicy{
+		Pinned: []string{"pinned-key"},
+	}}
+	sto := store.New(&store.Config{}, dauthCtx)
+	c.Check(sto.DownloadCache(), IsNil)
+
+	sto.SetCacheDownloads(5)
+	cm := sto.DownloadCache()
+	c.Assert(cm, NotNil)
+
+	c.Assert(os.MkdirAll(dirs.SnapDownloadCacheDir, 0700), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapDownloadCacheDir, "pinned-key"), nil, 0600), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapDownloadCacheDir, "other-key"), nil, 0600), IsNil)
+
+	entries, err := cm.Entries()
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 2)
+	for _, e := range entries {
+		c.Check(e.Pinned, Equals, e.Key == "pinned-key", Commentf(e.Key))
+	}
+}
+
+type memCheckpointer struct {
+	cp    *store.DownloadCheckpoint
+	saved []store.DownloadCheckpoint
+}
+
+func (m *memCheckpointer) Checkpoint() (*store.DownloadCheckpoint, error) {
+	return m.cp, nil
+}
+
+func (m *memCheckpointer) SaveCheckpoint(cp *store.DownloadCheckpoint) error {
+	m.cp = cp
+	m.saved = append(m.saved, *cp)
+	return nil
+}
+
+func (s *storeDownloadSuite) TestDownloadSavesCheckpoints(c *C) {
+	defer store.MockDownloadCheckpointInterval(10000)()
+
+	buf := bytes.Repeat([]byte("x"), 100000)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Header.Get("Range"), Equals, "")
+		w.Write(buf)
+	}))
+	defer mockServer.Close()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = fmt.Sprintf("%x", sha3.Sum384(buf))
+	snap.Size = int64(len(buf))
+
+	checkpointer := &memCheckpointer{}
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{Checkpointer: checkpointer})
+	c.Assert(err, IsNil)
+	c.Assert(targetFn, testutil.FileEquals, buf)
+
+	c.Assert(len(checkpointer.saved) >= 2, Equals, true, Commentf("%v", checkpointer.saved))
+	last := int64(0)
+	for _, cp := range checkpointer.saved {
+		c.Check(cp.Offset-last >= 10000, Equals, true)
+		c.Check(cp.Sha3_384, Equals, fmt.Sprintf("%x", sha3.Sum384(buf[:cp.Offset])))
+		last = cp.Offset
+	}
+}
+
+func (s *storeDownloadSuite) TestDownloadResumesFromCheckpoint(c *C) {
+	buf := []byte("some snap content that was partially downloaded")
+	const offset = 20
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Header.Get("Range"), Equals, fmt.Sprintf("bytes=%d-", offset))
+		w.WriteHeader(206)
+		w.Write(buf[offset:])
+	}))
+	defer mockServer.Close()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = fmt.Sprintf("%x", sha3.Sum384(buf))
+	snap.Size = int64(len(buf))
+
+	// the data past the checkpoint did not make it to disk intact
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	partial := append(append([]byte(nil), buf[:offset]...), "\x00\x00\x00\x00"...)
+	c.Assert(os.WriteFile(targetFn+".partial", partial, 0644), IsNil)
+
+	checkpointer := &memCheckpointer{cp: &store.DownloadCheckpoint{
+		Offset:   offset,
+		Sha3_384: fmt.Sprintf("%x", sha3.Sum384(buf[:offset])),
+	}}
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{Checkpointer: checkpointer})
+	c.Assert(err, IsNil)
+	c.Assert(targetFn, testutil.FileEquals, buf)
+}
+
+func (s *storeDownloadSuite) TestDownloadCheckpointMismatchRestarts(c *C) {
+	buf := []byte("some snap content that was partially downloaded")
+
+	for _, tc := range []struct {
+		partial string
+		cp      store.DownloadCheckpoint
+		log     string
+	}{
+		// the partial download got corrupted
+		{"some SNAP CORRUPTED", store.DownloadCheckpoint{Offset: 10, Sha3_384: fmt.Sprintf("%x", sha3.Sum384(buf[:10]))}, "does not match its checkpoint"},
+		// the partial download lost data
+		{"some", store.DownloadCheckpoint{Offset: 10, Sha3_384: fmt.Sprintf("%x", sha3.Sum384(buf[:10]))}, "is shorter than its checkpoint"},
+	} {
+		s.logbuf.Reset()
+		mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c.Check(r.Header.Get("Range"), Equals, "")
+			w.Write(buf)
+		}))
+
+		snap := &snap.Info{}
+		snap.RealName = "foo"
+		snap.DownloadURL = mockServer.URL
+		snap.Sha3_384 = fmt.Sprintf("%x", sha3.Sum384(buf))
+		snap.Size = int64(len(buf))
+
+		targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+		c.Assert(os.WriteFile(targetFn+".partial", []byte(tc.partial), 0644), IsNil)
+
+		cp := tc.cp
+		checkpointer := &memCheckpointer{cp: &cp}
+		err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{Checkpointer: checkpointer})
+		c.Assert(err, IsNil)
+		c.Check(targetFn, testutil.FileEquals, buf)
+		c.Check(s.logbuf.String(), testutil.Contains, tc.log)
+		mockServer.Close()
+	}
+}
+
+func (s *storeDownloadSuite) TestDownloadCancelledKeepsPartialWithCheckpointer(c *C) {
+	restore := store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		w.Write([]byte("partial"))
+		return fmt.Errorf("the download has been cancelled: %s", ctx.Err())
+	})
+	defer restore()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = "URL"
+	snap.Size = 100
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{Checkpointer: &memCheckpointer{}})
+	c.Assert(err, ErrorMatches, "the download has been cancelled: .*")
+	c.Check(targetFn+".partial", testutil.FileEquals, "partial")
+
+	// without a checkpointer the partial download is removed
+	err = s.store.Download(ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, ErrorMatches, "the download has been cancelled: .*")
+	c.Check(targetFn+".partial", testutil.FileAbsent)
+}
@@ -247,6 +247,11 @@ type testDauthContext struct {
 
 	storeOffline bool
 
+	peerCachePeers []string
+	peerCacheToken string
+
+	downloadCachePolicy *store.CachePolicy
+
 	cloudInfo *auth.CloudInfo
 }
 
@@ -321,6 +326,18 @@ func (dac *testDauthContext) StoreOffline() (bool, error) {
 	return dac.storeOffline, nil
 }
 
+func (dac *testDauthContext) PeerCachePeers() ([]string, error) {
+	return dac.peerCachePeers, nil
+}
+
+func (dac *testDauthContext) PeerCacheToken() (string, error) {
+	return dac.peerCacheToken, nil
+}
+
+func (dac *testDauthContext) DownloadCachePolicy() (*store.CachePolicy, error) {
+	return dac.downloadCachePolicy, nil
+}
+
 func (dac *testDauthContext) CloudInfo() (*auth.CloudInfo, error) {
 	return dac.cloudInfo, nil
 }